@@ -0,0 +1,138 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Actions (PDF 32000-1:2008, §12.6): what a viewer does when
+// the document is opened, a link is clicked, and so on.
+
+package pdf
+
+// An Action is something the viewer does in response to an event.
+//
+// Type is the action type, such as GoTo, GoToR, URI, Launch, Named or
+// JavaScript; the fields used by the type are set, and the dictionary
+// V holds the entries of other types.
+type Action struct {
+	Type      string
+	Dest      Dest   // GoTo, GoToR: the destination; for GoToR, pages of the remote document
+	DestName  string // GoToR: the name of the destination in the remote document, if named
+	File      string // GoToR, Launch: the file to open or launch
+	NewWindow bool   // GoToR, Launch: open the document in a new window
+	URI       string // URI: the address to go to
+	Name      string // Named: the action, such as NextPage, PrevPage, FirstPage or LastPage
+	JS        string // JavaScript: the source
+
+	// Next lists the actions performed after this one, in order.
+	// Actions that follow them are flattened into the list.
+	Next []Action
+
+	V Value // the action dictionary
+}
+
+// OpenAction returns the action performed when the document is opened,
+// the catalog's OpenAction entry. A destination given in place of an
+// action is returned as a GoTo action. OpenAction returns ok == false
+// if the document has no open action.
+func (r *Reader) OpenAction() (action Action, ok bool) {
+	a := r.Trailer.Key("Root").Key("OpenAction")
+	switch a.Kind() {
+	case Dict:
+		return newAction(a), true
+	case Array:
+		d, _ := explicitDest(a)
+		return Action{Type: "GoTo", Dest: d, V: a}, true
+	}
+	return Action{}, false
+}
+
+// AdditionalActions returns the document's additional actions, the
+// catalog's AA entry, keyed by the event that triggers them: WC (will
+// close), WS (will save), DS (did save), WP (will print) and DP (did
+// print).
+func (r *Reader) AdditionalActions() map[string]Action {
+	return additionalActions(r.Trailer.Key("Root").Key("AA"))
+}
+
+// AdditionalActions returns the page's additional actions, its AA
+// entry, keyed by the event that triggers them: O when the page is
+// opened and C when it is closed.
+func (p Page) AdditionalActions() map[string]Action {
+	return additionalActions(p.V.Key("AA"))
+}
+
+// additionalActions returns the actions in the additional-actions
+// dictionary aa, or nil if there are none.
+func additionalActions(aa Value) map[string]Action {
+	var m map[string]Action
+	for _, k := range aa.Keys() {
+		if a := aa.Key(k); a.Kind() == Dict {
+			if m == nil {
+				m = make(map[string]Action)
+			}
+			m[k] = newAction(a)
+		}
+	}
+	return m
+}
+
+// newAction returns the Action for the action dictionary a,
+// with the actions that follow it.
+func newAction(a Value) Action {
+	action := actionOf(a)
+	first := true
+	eachAction(a, func(next Value) {
+		if first {
+			first = false
+			return
+		}
+		action.Next = append(action.Next, actionOf(next))
+	})
+	return action
+}
+
+// actionOf returns the Action for the action dictionary a alone.
+func actionOf(a Value) Action {
+	action := Action{Type: a.Key("S").CoerceName(""), V: a}
+	switch action.Type {
+	case "GoTo":
+		action.Dest, _ = resolveDest(a.Key("D"))
+	case "GoToR":
+		d := a.Key("D")
+		if d.Kind() == Array {
+			action.Dest, _ = explicitDest(d)
+		} else {
+			action.DestName = d.CoerceText(d.CoerceName(""))
+		}
+		action.File = fileSpecName(a.Key("F"))
+		action.NewWindow = a.Key("NewWindow").data == true
+	case "Launch":
+		f := a.Key("F")
+		if f.Kind() == Null {
+			f = a.Key("Win").Key("F")
+		}
+		action.File = fileSpecName(f)
+		action.NewWindow = a.Key("NewWindow").data == true
+	case "URI":
+		action.URI = a.Key("URI").CoerceString("")
+	case "Named":
+		action.Name = a.Key("N").CoerceName("")
+	case "JavaScript":
+		action.JS = scriptCode(a.Key("JS"))
+	}
+	return action
+}
+
+// fileSpecName returns the file name given by the file specification
+// f (PDF 32000-1:2008, §7.11): a string, or a dictionary whose UF or F
+// entry, or failing those a platform-specific entry, holds the name.
+func fileSpecName(f Value) string {
+	if f.Kind() != Dict {
+		return f.CoerceText("")
+	}
+	for _, k := range []string{"UF", "F", "Unix", "DOS", "Mac"} {
+		if s := f.Key(k).CoerceText(""); s != "" {
+			return s
+		}
+	}
+	return ""
+}
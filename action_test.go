@@ -0,0 +1,80 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// actionString formats a for comparison in tests.
+func actionString(a Action) string {
+	s := a.Type
+	switch a.Type {
+	case "GoTo":
+		s += fmt.Sprintf(" page %d %s", a.Dest.Page, a.Dest.Fit)
+	case "GoToR":
+		s += fmt.Sprintf(" %s page %d %q new %v", a.File, a.Dest.Page, a.DestName, a.NewWindow)
+	case "Launch":
+		s += fmt.Sprintf(" %s new %v", a.File, a.NewWindow)
+	case "URI":
+		s += " " + a.URI
+	case "Named":
+		s += " " + a.Name
+	case "JavaScript":
+		s += " " + a.JS
+	}
+	for _, n := range a.Next {
+		s += "; " + actionString(n)
+	}
+	return s
+}
+
+func TestOpenAction(t *testing.T) {
+	tests := []struct {
+		action string
+		want   string
+	}{
+		{"[4 0 R /Fit]", "GoTo page 1 Fit"},
+		{"<</S /GoTo /D [6 0 R /FitH 700]>>", "GoTo page 3 FitH"},
+		{"<</S /GoToR /F (other.pdf) /D [2 /Fit] /NewWindow true>>", "GoToR other.pdf page 3 \"\" new true"},
+		{"<</S /GoToR /F <</Type /Filespec /UF (\\374n\\357.pdf) /F (uni.pdf)>> /D (chapter)>>", "GoToR ünï.pdf page 0 \"chapter\" new false"},
+		{"<</S /Launch /Win <</F (calc.exe)>>>>", "Launch calc.exe new false"},
+		{"<</S /URI /URI (https://example.com/) /Next [<</S /Named /N /LastPage>> <</S /JavaScript /JS (go\\(\\)) /Next <</S /Named /N /FirstPage>>>>]>>",
+			"URI https://example.com/; Named LastPage; JavaScript go(); Named FirstPage"},
+		{"<</S /Hide /T (field)>>", "Hide"},
+	}
+	for _, tt := range tests {
+		a, ok := openPDF(t, destPDF("/OpenAction "+tt.action)).OpenAction()
+		if got := actionString(a); !ok || got != tt.want {
+			t.Errorf("%s: OpenAction() = %s, %v, want %s", tt.action, got, ok, tt.want)
+		}
+	}
+	if a, ok := openPDF(t, destPDF("")).OpenAction(); ok {
+		t.Errorf("OpenAction() without one = %s, true", actionString(a))
+	}
+}
+
+func TestAdditionalActions(t *testing.T) {
+	r := openPDF(t, destPDF("/AA <</WC <</S /JavaScript /JS (bye)>> /WP 7 0 R /DS (not an action)>>",
+		"<</S /Named /N /Print>>",
+	))
+	format := func(m map[string]Action) string {
+		var list []string
+		for _, k := range []string{"WC", "WS", "DS", "WP", "DP", "O", "C"} {
+			if a, ok := m[k]; ok {
+				list = append(list, k+": "+actionString(a))
+			}
+		}
+		return strings.Join(list, ", ")
+	}
+	if got, want := format(r.AdditionalActions()), "WC: JavaScript bye, WP: Named Print"; got != want {
+		t.Errorf("AdditionalActions() = %s, want %s", got, want)
+	}
+	if m := r.Page(1).AdditionalActions(); m != nil {
+		t.Errorf("page AdditionalActions() = %v, want nil", m)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Embedded files (PDF 32000-1:2008, §7.11.4) attached to the document.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// An Attachment is a file embedded in the document.
+type Attachment struct {
+	Name         string    // key in the EmbeddedFiles name tree
+	FileName     string    // file name given by the file specification
+	Description  string    // description of the file (Desc)
+	MIMEType     string    // MIME type of the contents, such as application/pdf, or empty if unknown
+	Size         int64     // size of the contents in bytes, or -1 if not given
+	CreationDate time.Time // zero if missing or malformed
+	ModDate      time.Time // zero if missing or malformed
+	Item         Value     // collection item (CI): the file's values for a portfolio's schema
+
+	V Value // the file specification dictionary
+}
+
+// Attachments returns the files embedded in the document,
+// listed in its EmbeddedFiles name tree, in the tree's order.
+func (r *Reader) Attachments() []Attachment {
+	var list []Attachment
+	nameTreeEach(r.Trailer.Key("Root").Key("Names").Key("EmbeddedFiles"), func(name string, spec Value) {
+		if spec.Kind() == Dict {
+			list = append(list, newAttachment(name, spec))
+		}
+	})
+	return list
+}
+
+// newAttachment returns the Attachment for the file specification spec.
+func newAttachment(name string, spec Value) Attachment {
+	stm := embeddedFile(spec)
+	params := stm.Key("Params")
+	a := Attachment{
+		Name:        name,
+		FileName:    fileSpecName(spec),
+		Description: spec.Key("Desc").CoerceText(""),
+		MIMEType:    stm.Key("Subtype").CoerceName(""),
+		Size:        params.Key("Size").CoerceInt64(-1),
+		Item:        spec.Key("CI"),
+		V:           spec,
+	}
+	if t, err := ParseDate(params.Key("CreationDate").CoerceText("")); err == nil {
+		a.CreationDate = t
+	}
+	if t, err := ParseDate(params.Key("ModDate").CoerceText("")); err == nil {
+		a.ModDate = t
+	}
+	return a
+}
+
+// embeddedFile returns the embedded file stream of the file
+// specification spec, preferring its Unicode file name entry.
+func embeddedFile(spec Value) Value {
+	ef := spec.Key("EF")
+	for _, k := range []string{"UF", "F", "Unix", "DOS", "Mac"} {
+		if s := ef.Key(k); s.Kind() == Stream {
+			return s
+		}
+	}
+	return Value{}
+}
+
+// Open returns a reader for the contents of the embedded file.
+func (a Attachment) Open() (io.ReadCloser, error) {
+	stm := embeddedFile(a.V)
+	if stm.Kind() != Stream {
+		return nil, fmt.Errorf("malformed PDF: attachment %q has no embedded file stream", a.Name)
+	}
+	return stm.Reader(), nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// portfolioPDF returns a document with two attachments and the
+// collection dictionary coll, if it is not empty.
+func portfolioPDF(coll string) []byte {
+	return buildPDF("",
+		"<</Type /Catalog /Names <</EmbeddedFiles <</Names [(a.txt) 2 0 R (b) 3 0 R (c) 4 0 R]>>>> "+coll+">>",
+		"<</Type /Filespec /F (a.txt) /UF (\\344.txt) /Desc (The first) /EF <</F 5 0 R>> /CI <</Author (Ann)>>>>",
+		"<</Type /Filespec /F (b.pdf) /EF <</UF 6 0 R>>>>",
+		"(not a file specification)",
+		stream("/Type /EmbeddedFile /Subtype /text#2Fplain /Params <</Size 5 /ModDate (D:20240101)>> /Filter /FlateDecode", deflate("hello")),
+		stream("/Type /EmbeddedFile", "%PDF-"),
+	)
+}
+
+func TestAttachments(t *testing.T) {
+	list := openPDF(t, portfolioPDF("")).Attachments()
+	var got []string
+	for _, a := range list {
+		s := fmt.Sprintf("%s %s %q %s %d %v", a.Name, a.FileName, a.Description, a.MIMEType, a.Size, a.ModDate.Format(time.DateOnly))
+		rc, err := a.Open()
+		if err != nil {
+			t.Errorf("%s: Open: %v", a.Name, err)
+			continue
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Errorf("%s: reading: %v", a.Name, err)
+		}
+		got = append(got, s+" "+string(b))
+	}
+	want := []string{
+		`a.txt ä.txt "The first" text/plain 5 2024-01-01 hello`,
+		`b b.pdf ""  -1 0001-01-01 %PDF-`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("attachments:\n%q\nwant\n%q", got, want)
+	}
+	if len(list) > 0 && list[0].Item.Key("Author").CoerceText("") != "Ann" {
+		t.Errorf("a.txt collection item %v", list[0].Item)
+	}
+
+	if _, err := (Attachment{Name: "x"}).Open(); err == nil {
+		t.Errorf("Open of an attachment without a file succeeded")
+	}
+}
+
+func TestCollection(t *testing.T) {
+	if c := openPDF(t, portfolioPDF("")).Collection(); c != nil {
+		t.Errorf("Collection() = %+v, want nil", c)
+	}
+
+	c := openPDF(t, portfolioPDF("/Collection <</View /T /D (b)"+
+		" /Schema <</Author <</Subtype /S /N (Written by) /O 2 /E true>> /File <</Subtype /F /O 1>> /Size <</Subtype /Size /O 3 /V false>>>>"+
+		" /Sort <</S [/Author /File] /A [false]>>>>")).Collection()
+	if c == nil {
+		t.Fatal("Collection() = nil")
+	}
+	if c.View != "T" || c.Initial != "b" || len(c.Files) != 2 {
+		t.Errorf("view %s, initial %q, %d files", c.View, c.Initial, len(c.Files))
+	}
+	var schema []string
+	for _, f := range c.Schema {
+		schema = append(schema, fmt.Sprintf("%s %q %s %v %v", f.Key, f.Name, f.Type, f.Visible, f.Editable))
+	}
+	want := []string{`File "File" F true false`, `Author "Written by" S true true`, `Size "Size" Size false false`}
+	if fmt.Sprint(schema) != fmt.Sprint(want) {
+		t.Errorf("schema:\n%q\nwant\n%q", schema, want)
+	}
+	if got := fmt.Sprint(c.Sort); got != "[{Author false} {File true}]" {
+		t.Errorf("sort %s, want [{Author false} {File true}]", got)
+	}
+
+	c = openPDF(t, portfolioPDF("/Collection <</Sort <</S /File>>>>")).Collection()
+	if c.View != "D" || fmt.Sprint(c.Sort) != "[{File true}]" {
+		t.Errorf("default view %s, sort %v", c.View, c.Sort)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Recovery of logical order from right-to-left text.
+
+package pdf
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PDF content streams draw glyphs in visual order, left to right,
+// so extracted Hebrew and Arabic text comes out reversed.
+// visualToLogical undoes a simplified form of the Unicode bidirectional
+// algorithm for a single line: it picks the line's base direction from
+// its strong characters, then reverses the runs that were laid out
+// against that direction. Numbers always read left to right.
+
+func isRTL(r rune) bool {
+	return unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko)
+}
+
+func isLTR(r rune) bool {
+	return unicode.IsLetter(r) && !isRTL(r)
+}
+
+func isNumber(r rune) bool {
+	return unicode.IsDigit(r)
+}
+
+// mirrors holds the paired punctuation that is mirrored when
+// displayed in right-to-left text.
+var mirrors = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+	'«': '»', '»': '«',
+}
+
+// reverseMirrored reverses rs in place, swapping mirrored punctuation.
+func reverseMirrored(rs []rune) {
+	for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+		rs[i], rs[j] = rs[j], rs[i]
+	}
+	for i, r := range rs {
+		if m, ok := mirrors[r]; ok {
+			rs[i] = m
+		}
+	}
+}
+
+// reverseRuns reverses each maximal run in rs that begins and ends with
+// a rune satisfying in and contains no rune satisfying stop.
+// Other runes (spaces, punctuation) are absorbed into a run only
+// when they fall between two of its members.
+// It returns the [start, end) bounds of the runs it reversed.
+func reverseRuns(rs []rune, in, stop func(rune) bool) [][2]int {
+	var runs [][2]int
+	for i := 0; i < len(rs); {
+		if !in(rs[i]) {
+			i++
+			continue
+		}
+		end := i
+		for j := i + 1; j < len(rs) && !stop(rs[j]); j++ {
+			if in(rs[j]) {
+				end = j
+			}
+		}
+		reverseMirrored(rs[i : end+1])
+		runs = append(runs, [2]int{i, end + 1})
+		i = end + 1
+	}
+	return runs
+}
+
+// visualToLogical converts a line of text from visual to logical order.
+func visualToLogical(line string) string {
+	rs := []rune(line)
+	nrtl, nltr := 0, 0
+	for _, r := range rs {
+		switch {
+		case isRTL(r):
+			nrtl++
+		case isLTR(r):
+			nltr++
+		}
+	}
+	if nrtl == 0 {
+		return line
+	}
+
+	notNumber := func(r rune) bool { return !isNumber(r) && r != '.' && r != ',' }
+	if nrtl > nltr {
+		// Right-to-left line: read the whole line backward,
+		// then restore the left-to-right runs embedded in it.
+		reverseMirrored(rs)
+		reverseRuns(rs, func(r rune) bool { return isLTR(r) || isNumber(r) }, isRTL)
+	} else {
+		// Left-to-right line: reverse only the embedded right-to-left runs,
+		// keeping any numbers inside them in reading order.
+		for _, run := range reverseRuns(rs, isRTL, isLTR) {
+			reverseRuns(rs[run[0]:run[1]], isNumber, notNumber)
+		}
+	}
+	return string(rs)
+}
+
+// unshapeArabic replaces Arabic presentation forms in s
+// with the logical characters they were shaped from.
+func unshapeArabic(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if base, ok := arabicForms[r]; ok {
+			b.WriteString(base)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
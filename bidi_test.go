@@ -0,0 +1,62 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"testing"
+	"unicode"
+)
+
+func TestVisualToLogical(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{"", ""},
+		{"plain text", "plain text"},
+		{"םולש", "שלום"},                                     // Hebrew, drawn reversed
+		{"123 םולש", "שלום 123"},                             // numbers keep their order
+		{"(םולש)", "(שלום)"},                                 // mirrored brackets
+		{"say םולש now", "say שלום now"},                     // an embedded right-to-left run
+		{"abc 2024 םולש", "שלום abc 2024"},                   // a right-to-left line keeps left-to-right runs
+		{"say םולש 12 ילש now", "now שלי 12 שלום say"},       // and reads from the right
+		{"please ילש 12 םולש now", "please שלום 12 שלי now"}, // a number inside a right-to-left run
+	}
+	for _, tt := range tests {
+		if out := visualToLogical(tt.in); out != tt.out {
+			t.Errorf("visualToLogical(%q) = %q, want %q", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestUnshapeArabic(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{"", ""},
+		{"abc", "abc"},
+		{"ﻟﻠﺎﻡ", "للام"},
+		{"ﻻ", "لا"}, // lam-alef ligature
+		{"ﯝ", "ۇٴ"}, // U with hamza above: U+06C7 U+0674, not U+0677
+	}
+	for _, tt := range tests {
+		if out := unshapeArabic(tt.in); out != tt.out {
+			t.Errorf("unshapeArabic(%+q) = %+q, want %+q", tt.in, out, tt.out)
+		}
+	}
+}
+
+// TestArabicForms checks that arabicForms covers only presentation
+// forms, mapping each to Arabic letters or marks.
+func TestArabicForms(t *testing.T) {
+	for r, base := range arabicForms {
+		if !(0xFB50 <= r && r <= 0xFDFF || 0xFE70 <= r && r <= 0xFEFF) {
+			t.Errorf("arabicForms maps %U, which is not an Arabic presentation form", r)
+		}
+		for _, b := range base {
+			if !unicode.Is(unicode.Arabic, b) && b != ' ' && b != 0x0640 && !unicode.Is(unicode.Mn, b) {
+				t.Errorf("arabicForms maps %U to %+q, which is not Arabic", r, base)
+			}
+			if 0xFB50 <= b && b <= 0xFDFF || 0xFE70 <= b && b <= 0xFEFF {
+				t.Errorf("arabicForms maps %U to the presentation form %U", r, b)
+			}
+		}
+	}
+}
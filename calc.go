@@ -0,0 +1,335 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// PostScript calculator functions (PDF 32000-1:2008, §7.10.5).
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// A calcOp is one operation of a calculator program: a number or
+// boolean to push, an operator, or a conditional with its procedures.
+type calcOp struct {
+	op   string      // operator, or "" for a literal
+	val  interface{} // literal value: float64 or bool
+	then []calcOp    // for if and ifelse
+	els  []calcOp    // for ifelse
+}
+
+// maxCalcStack limits the operand stack of a calculator program.
+const maxCalcStack = 100
+
+// parseCalculator parses the calculator program read from rd,
+// a single procedure in braces.
+func parseCalculator(rd io.Reader) (prog []calcOp, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			prog, err = nil, fmt.Errorf("malformed PDF: calculator function: %v", e)
+		}
+	}()
+	b := newPdfBuffer(rd, 0)
+	b.allowEOF = true
+	b.allowObjptr = false
+	b.allowStream = false
+	if tok := b.readToken(); tok != pdfkeyword("{") {
+		panic("missing {")
+	}
+	return parseCalcProc(b, 0), nil
+}
+
+// parseCalcProc parses the body of a procedure up to its closing brace.
+func parseCalcProc(b *pdfbuffer, depth int) []calcOp {
+	if depth > maxCalcStack {
+		panic("procedures nested too deeply")
+	}
+	var prog []calcOp
+	var procs [][]calcOp // procedures awaiting if or ifelse
+	for {
+		tok := b.readToken()
+		switch tok := tok.(type) {
+		case int64:
+			prog = append(prog, calcOp{val: float64(tok)})
+		case float64, bool:
+			prog = append(prog, calcOp{val: tok})
+		case pdfkeyword:
+			switch tok {
+			case "{":
+				procs = append(procs, parseCalcProc(b, depth+1))
+				continue
+			case "}":
+				return prog
+			case "if":
+				if len(procs) != 1 {
+					panic("if without procedure")
+				}
+				prog = append(prog, calcOp{op: "if", then: procs[0]})
+			case "ifelse":
+				if len(procs) != 2 {
+					panic("ifelse without procedures")
+				}
+				prog = append(prog, calcOp{op: "ifelse", then: procs[0], els: procs[1]})
+			default:
+				prog = append(prog, calcOp{op: string(tok)})
+			}
+		default:
+			panic(fmt.Sprintf("unexpected %v", tok))
+		}
+		procs = nil
+	}
+}
+
+// runCalculator runs prog with the operand stack initially holding x,
+// returning the final stack.
+func runCalculator(prog []calcOp, x []float64) (stk []float64, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			stk, err = nil, fmt.Errorf("malformed PDF: calculator function: %v", e)
+		}
+	}()
+	var c calcMachine
+	for _, v := range x {
+		c.push(v)
+	}
+	c.run(prog)
+	stk = make([]float64, len(c.stk))
+	for i, v := range c.stk {
+		stk[i] = c.num(v)
+	}
+	return stk, nil
+}
+
+// A calcMachine runs calculator programs.
+// Its stack holds float64 and bool values.
+type calcMachine struct {
+	stk []interface{}
+}
+
+func (c *calcMachine) push(v interface{}) {
+	if len(c.stk) >= maxCalcStack {
+		panic("stack overflow")
+	}
+	c.stk = append(c.stk, v)
+}
+
+func (c *calcMachine) pop() interface{} {
+	if len(c.stk) == 0 {
+		panic("stack underflow")
+	}
+	v := c.stk[len(c.stk)-1]
+	c.stk = c.stk[:len(c.stk)-1]
+	return v
+}
+
+func (c *calcMachine) num(v interface{}) float64 {
+	x, ok := v.(float64)
+	if !ok {
+		panic("number expected")
+	}
+	return x
+}
+
+func (c *calcMachine) popNum() float64 { return c.num(c.pop()) }
+
+func (c *calcMachine) popInt() int64 { return int64(c.popNum()) }
+
+func (c *calcMachine) popBool() bool {
+	b, ok := c.pop().(bool)
+	if !ok {
+		panic("boolean expected")
+	}
+	return b
+}
+
+func (c *calcMachine) run(prog []calcOp) {
+	for _, op := range prog {
+		if op.op == "" {
+			c.push(op.val)
+			continue
+		}
+		c.do(op)
+	}
+}
+
+func (c *calcMachine) do(op calcOp) {
+	switch op.op {
+	default:
+		panic("unknown operator " + op.op)
+
+	// Arithmetic operators.
+	case "abs":
+		c.push(math.Abs(c.popNum()))
+	case "add":
+		y, x := c.popNum(), c.popNum()
+		c.push(x + y)
+	case "sub":
+		y, x := c.popNum(), c.popNum()
+		c.push(x - y)
+	case "mul":
+		y, x := c.popNum(), c.popNum()
+		c.push(x * y)
+	case "div":
+		y, x := c.popNum(), c.popNum()
+		if y == 0 {
+			panic("division by zero")
+		}
+		c.push(x / y)
+	case "idiv", "mod":
+		y, x := c.popInt(), c.popInt()
+		if y == 0 {
+			panic("division by zero")
+		}
+		if op.op == "idiv" {
+			c.push(float64(x / y))
+		} else {
+			c.push(float64(x % y))
+		}
+	case "neg":
+		c.push(-c.popNum())
+	case "ceiling":
+		c.push(math.Ceil(c.popNum()))
+	case "floor":
+		c.push(math.Floor(c.popNum()))
+	case "round":
+		c.push(math.Floor(c.popNum() + 0.5))
+	case "truncate", "cvi":
+		c.push(math.Trunc(c.popNum()))
+	case "cvr":
+		c.push(c.popNum())
+	case "sqrt":
+		c.push(math.Sqrt(c.popNum()))
+	case "sin":
+		c.push(math.Sin(c.popNum() * math.Pi / 180))
+	case "cos":
+		c.push(math.Cos(c.popNum() * math.Pi / 180))
+	case "atan":
+		den, num := c.popNum(), c.popNum()
+		a := math.Atan2(num, den) * 180 / math.Pi
+		if a < 0 {
+			a += 360
+		}
+		c.push(a)
+	case "exp":
+		e, b := c.popNum(), c.popNum()
+		c.push(math.Pow(b, e))
+	case "ln":
+		c.push(math.Log(c.popNum()))
+	case "log":
+		c.push(math.Log10(c.popNum()))
+
+	// Relational, boolean and bitwise operators.
+	case "eq", "ne":
+		y, x := c.pop(), c.pop()
+		c.push((x == y) == (op.op == "eq"))
+	case "gt":
+		y, x := c.popNum(), c.popNum()
+		c.push(x > y)
+	case "ge":
+		y, x := c.popNum(), c.popNum()
+		c.push(x >= y)
+	case "lt":
+		y, x := c.popNum(), c.popNum()
+		c.push(x < y)
+	case "le":
+		y, x := c.popNum(), c.popNum()
+		c.push(x <= y)
+	case "and", "or", "xor":
+		y, x := c.pop(), c.pop()
+		if xb, ok := x.(bool); ok {
+			yb, ok := y.(bool)
+			if !ok {
+				panic("boolean expected")
+			}
+			switch op.op {
+			case "and":
+				c.push(xb && yb)
+			case "or":
+				c.push(xb || yb)
+			case "xor":
+				c.push(xb != yb)
+			}
+			break
+		}
+		xi, yi := int64(c.num(x)), int64(c.num(y))
+		switch op.op {
+		case "and":
+			c.push(float64(xi & yi))
+		case "or":
+			c.push(float64(xi | yi))
+		case "xor":
+			c.push(float64(xi ^ yi))
+		}
+	case "not":
+		switch x := c.pop().(type) {
+		case bool:
+			c.push(!x)
+		case float64:
+			c.push(float64(^int64(x)))
+		}
+	case "bitshift":
+		shift, x := c.popInt(), c.popInt()
+		if shift >= 0 {
+			c.push(float64(x << uint(min(shift, 63))))
+		} else {
+			c.push(float64(x >> uint(min(-shift, 63))))
+		}
+	case "true":
+		c.push(true)
+	case "false":
+		c.push(false)
+
+	// Conditional operators.
+	case "if":
+		if c.popBool() {
+			c.run(op.then)
+		}
+	case "ifelse":
+		if c.popBool() {
+			c.run(op.then)
+		} else {
+			c.run(op.els)
+		}
+
+	// Stack operators.
+	case "pop":
+		c.pop()
+	case "exch":
+		y, x := c.pop(), c.pop()
+		c.push(y)
+		c.push(x)
+	case "dup":
+		x := c.pop()
+		c.push(x)
+		c.push(x)
+	case "copy":
+		n := int(c.popInt())
+		if n < 0 || n > len(c.stk) {
+			panic("copy out of range")
+		}
+		for _, v := range c.stk[len(c.stk)-n:] {
+			c.push(v)
+		}
+	case "index":
+		n := int(c.popInt())
+		if n < 0 || n >= len(c.stk) {
+			panic("index out of range")
+		}
+		c.push(c.stk[len(c.stk)-1-n])
+	case "roll":
+		j, n := int(c.popInt()), int(c.popInt())
+		if n < 0 || n > len(c.stk) {
+			panic("roll out of range")
+		}
+		if n == 0 {
+			break
+		}
+		s := c.stk[len(c.stk)-n:]
+		j = ((j % n) + n) % n
+		rolled := append(append([]interface{}{}, s[n-j:]...), s[:n-j]...)
+		copy(s, rolled)
+	}
+}
@@ -0,0 +1,357 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Embedded Compact Font Format (CFF) fonts: just enough of the
+// format to recover the glyph names behind a font's built-in encoding.
+
+package pdf
+
+import "encoding/binary"
+
+// cffIndex splits the CFF INDEX structure at the start of b into its
+// elements and returns them along with the rest of b.
+func cffIndex(b []byte) (elems [][]byte, rest []byte, ok bool) {
+	if len(b) < 2 {
+		return nil, nil, false
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if n == 0 {
+		return nil, b[2:], true
+	}
+	if len(b) < 3 {
+		return nil, nil, false
+	}
+	size := int(b[2])
+	if size < 1 || size > 4 || len(b) < 3+(n+1)*size {
+		return nil, nil, false
+	}
+	off := func(i int) int {
+		x := 0
+		for _, c := range b[3+i*size : 3+(i+1)*size] {
+			x = x<<8 | int(c)
+		}
+		return x
+	}
+	data := 3 + (n+1)*size - 1 // offsets are 1-based
+	end := off(n)
+	if data+end > len(b) {
+		return nil, nil, false
+	}
+	for i := 0; i < n; i++ {
+		lo, hi := off(i), off(i+1)
+		if lo < 1 || hi < lo || hi > end {
+			return nil, nil, false
+		}
+		elems = append(elems, b[data+lo:data+hi])
+	}
+	return elems, b[data+end:], true
+}
+
+// cffDict decodes a CFF DICT into its operands, keyed by operator.
+// Two-byte operators are keyed as 1200 plus their second byte.
+func cffDict(b []byte) map[int][]float64 {
+	d := make(map[int][]float64)
+	var args []float64
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c <= 21:
+			op := int(c)
+			i++
+			if c == 12 && i < len(b) {
+				op = 1200 + int(b[i])
+				i++
+			}
+			d[op] = args
+			args = nil
+		case c == 28 && i+2 < len(b):
+			args = append(args, float64(int16(binary.BigEndian.Uint16(b[i+1:]))))
+			i += 3
+		case c == 29 && i+4 < len(b):
+			args = append(args, float64(int32(binary.BigEndian.Uint32(b[i+1:]))))
+			i += 5
+		case c == 30:
+			// A real number, as nibbles ending in 0xf.
+			// Only its length matters here.
+			for i++; i < len(b) && b[i]&0xf != 0xf && b[i]>>4 != 0xf; i++ {
+			}
+			i++
+			args = append(args, 0)
+		case c >= 32 && c <= 246:
+			args = append(args, float64(int(c)-139))
+			i++
+		case c >= 247 && c <= 250 && i+1 < len(b):
+			args = append(args, float64((int(c)-247)*256+int(b[i+1])+108))
+			i += 2
+		case c >= 251 && c <= 254 && i+1 < len(b):
+			args = append(args, float64(-(int(c)-251)*256-int(b[i+1])-108))
+			i += 2
+		default:
+			return d
+		}
+	}
+	return d
+}
+
+const (
+	cffCharset     = 15
+	cffEncoding    = 16
+	cffCharStrings = 17
+	cffROS         = 1230
+)
+
+// cffEncodingNames returns the glyph names of the built-in encoding
+// of the CFF font in data, indexed by code.
+// It returns nil if data is not a CFF font, if the font is CID-keyed,
+// or if it uses the predefined Expert encoding or charsets.
+func cffEncodingNames(data []byte) *[256]string {
+	if len(data) < 4 || data[0] != 1 || int(data[2]) > len(data) {
+		return nil
+	}
+	_, rest, ok := cffIndex(data[data[2]:]) // Name INDEX
+	if !ok {
+		return nil
+	}
+	top, rest, ok := cffIndex(rest)
+	if !ok || len(top) == 0 {
+		return nil
+	}
+	strs, _, ok := cffIndex(rest)
+	if !ok {
+		return nil
+	}
+	dict := cffDict(top[0])
+	if _, ok := dict[cffROS]; ok {
+		return nil
+	}
+	offset := func(op int) int {
+		if a := dict[op]; len(a) > 0 && a[len(a)-1] >= 0 && int(a[len(a)-1]) < len(data) {
+			return int(a[len(a)-1])
+		}
+		return 0
+	}
+	sidName := func(sid int) string {
+		if sid < len(cffStandardStrings) {
+			return cffStandardStrings[sid]
+		}
+		if sid -= len(cffStandardStrings); sid < len(strs) {
+			return string(strs[sid])
+		}
+		return ""
+	}
+
+	cs := offset(cffCharStrings)
+	if cs == 0 {
+		return nil
+	}
+	glyphs, _, ok := cffIndex(data[cs:])
+	if !ok {
+		return nil
+	}
+	names := cffCharsetNames(data, offset(cffCharset), len(glyphs), sidName)
+	if names == nil {
+		return nil
+	}
+
+	var enc [256]string
+	off := offset(cffEncoding)
+	switch off {
+	case 0:
+		for c, sid := range cffStandardEncoding {
+			if sid != 0 {
+				enc[c] = cffStandardStrings[sid]
+			}
+		}
+		return &enc
+	case 1:
+		return nil
+	}
+	b := data[off:]
+	if len(b) < 2 {
+		return nil
+	}
+	format := b[0]
+	gid := 1
+	switch format & 0x7f {
+	case 0:
+		n := int(b[1])
+		b = b[2:]
+		for i := 0; i < n && i < len(b); i++ {
+			if gid < len(names) {
+				enc[b[i]] = names[gid]
+			}
+			gid++
+		}
+		b = b[min(n, len(b)):]
+	case 1:
+		n := int(b[1])
+		b = b[2:]
+		for i := 0; i < n && len(b) >= 2; i++ {
+			first, left := int(b[0]), int(b[1])
+			for c := first; c <= first+left && c < 256; c++ {
+				if gid < len(names) {
+					enc[c] = names[gid]
+				}
+				gid++
+			}
+			b = b[2:]
+		}
+	default:
+		return nil
+	}
+	if format&0x80 != 0 && len(b) > 0 {
+		// Supplementary codes for glyphs that have more than one.
+		n := int(b[0])
+		b = b[1:]
+		for i := 0; i < n && len(b) >= 3; i++ {
+			enc[b[0]] = sidName(int(binary.BigEndian.Uint16(b[1:])))
+			b = b[3:]
+		}
+	}
+	return &enc
+}
+
+// cffCharsetNames returns the names of the n glyphs of a CFF font
+// whose charset is at offset off in data.
+func cffCharsetNames(data []byte, off, n int, sidName func(int) string) []string {
+	names := make([]string, n)
+	if n == 0 {
+		return names
+	}
+	names[0] = ".notdef"
+	if off == 0 {
+		// The ISOAdobe charset: glyph i has string ID i.
+		for i := 1; i < n && i < 229; i++ {
+			names[i] = cffStandardStrings[i]
+		}
+		return names
+	}
+	if off <= 2 {
+		return nil
+	}
+	b := data[off:]
+	format := b[0]
+	b = b[1:]
+	for gid := 1; gid < n; {
+		switch format {
+		case 0:
+			if len(b) < 2 {
+				return names
+			}
+			names[gid] = sidName(int(binary.BigEndian.Uint16(b)))
+			gid++
+			b = b[2:]
+		case 1, 2:
+			size := 3
+			if format == 2 {
+				size = 4
+			}
+			if len(b) < size {
+				return names
+			}
+			sid := int(binary.BigEndian.Uint16(b))
+			left := int(b[2])
+			if format == 2 {
+				left = int(binary.BigEndian.Uint16(b[2:]))
+			}
+			for i := 0; i <= left && gid < n; i++ {
+				names[gid] = sidName(sid + i)
+				gid++
+			}
+			b = b[size:]
+		default:
+			return nil
+		}
+	}
+	return names
+}
+
+// cffStandardEncoding maps codes to string IDs in the
+// predefined Standard encoding.
+var cffStandardEncoding = func() (enc [256]uint16) {
+	sid := uint16(1)
+	for _, r := range [][2]int{
+		{32, 126}, {161, 175}, {177, 180}, {182, 189}, {191, 191},
+		{193, 200}, {202, 203}, {205, 208}, {225, 225}, {227, 227},
+		{232, 235}, {241, 241}, {245, 245}, {248, 251},
+	} {
+		for c := r[0]; c <= r[1]; c++ {
+			enc[c] = sid
+			sid++
+		}
+	}
+	return enc
+}()
+
+// cffStandardStrings are the 391 predefined strings
+// that string IDs below 391 refer to.
+var cffStandardStrings = [...]string{
+	".notdef", "space", "exclam", "quotedbl", "numbersign", "dollar",
+	"percent", "ampersand", "quoteright", "parenleft", "parenright",
+	"asterisk", "plus", "comma", "hyphen", "period", "slash", "zero", "one",
+	"two", "three", "four", "five", "six", "seven", "eight", "nine", "colon",
+	"semicolon", "less", "equal", "greater", "question", "at", "A", "B", "C",
+	"D", "E", "F", "G", "H", "I", "J", "K", "L", "M", "N", "O", "P", "Q", "R",
+	"S", "T", "U", "V", "W", "X", "Y", "Z", "bracketleft", "backslash",
+	"bracketright", "asciicircum", "underscore", "quoteleft", "a", "b", "c",
+	"d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q", "r",
+	"s", "t", "u", "v", "w", "x", "y", "z", "braceleft", "bar", "braceright",
+	"asciitilde", "exclamdown", "cent", "sterling", "fraction", "yen",
+	"florin", "section", "currency", "quotesingle", "quotedblleft",
+	"guillemotleft", "guilsinglleft", "guilsinglright", "fi", "fl", "endash",
+	"dagger", "daggerdbl", "periodcentered", "paragraph", "bullet",
+	"quotesinglbase", "quotedblbase", "quotedblright", "guillemotright",
+	"ellipsis", "perthousand", "questiondown", "grave", "acute", "circumflex",
+	"tilde", "macron", "breve", "dotaccent", "dieresis", "ring", "cedilla",
+	"hungarumlaut", "ogonek", "caron", "emdash", "AE", "ordfeminine",
+	"Lslash", "Oslash", "OE", "ordmasculine", "ae", "dotlessi", "lslash",
+	"oslash", "oe", "germandbls", "onesuperior", "logicalnot", "mu",
+	"trademark", "Eth", "onehalf", "plusminus", "Thorn", "onequarter",
+	"divide", "brokenbar", "degree", "thorn", "threequarters", "twosuperior",
+	"registered", "minus", "eth", "multiply", "threesuperior", "copyright",
+	"Aacute", "Acircumflex", "Adieresis", "Agrave", "Aring", "Atilde",
+	"Ccedilla", "Eacute", "Ecircumflex", "Edieresis", "Egrave", "Iacute",
+	"Icircumflex", "Idieresis", "Igrave", "Ntilde", "Oacute", "Ocircumflex",
+	"Odieresis", "Ograve", "Otilde", "Scaron", "Uacute", "Ucircumflex",
+	"Udieresis", "Ugrave", "Yacute", "Ydieresis", "Zcaron", "aacute",
+	"acircumflex", "adieresis", "agrave", "aring", "atilde", "ccedilla",
+	"eacute", "ecircumflex", "edieresis", "egrave", "iacute", "icircumflex",
+	"idieresis", "igrave", "ntilde", "oacute", "ocircumflex", "odieresis",
+	"ograve", "otilde", "scaron", "uacute", "ucircumflex", "udieresis",
+	"ugrave", "yacute", "ydieresis", "zcaron", "exclamsmall",
+	"Hungarumlautsmall", "dollaroldstyle", "dollarsuperior", "ampersandsmall",
+	"Acutesmall", "parenleftsuperior", "parenrightsuperior", "twodotenleader",
+	"onedotenleader", "zerooldstyle", "oneoldstyle", "twooldstyle",
+	"threeoldstyle", "fouroldstyle", "fiveoldstyle", "sixoldstyle",
+	"sevenoldstyle", "eightoldstyle", "nineoldstyle", "commasuperior",
+	"threequartersemdash", "periodsuperior", "questionsmall", "asuperior",
+	"bsuperior", "centsuperior", "dsuperior", "esuperior", "isuperior",
+	"lsuperior", "msuperior", "nsuperior", "osuperior", "rsuperior",
+	"ssuperior", "tsuperior", "ff", "ffi", "ffl", "parenleftinferior",
+	"parenrightinferior", "Circumflexsmall", "hyphensuperior", "Gravesmall",
+	"Asmall", "Bsmall", "Csmall", "Dsmall", "Esmall", "Fsmall", "Gsmall",
+	"Hsmall", "Ismall", "Jsmall", "Ksmall", "Lsmall", "Msmall", "Nsmall",
+	"Osmall", "Psmall", "Qsmall", "Rsmall", "Ssmall", "Tsmall", "Usmall",
+	"Vsmall", "Wsmall", "Xsmall", "Ysmall", "Zsmall", "colonmonetary",
+	"onefitted", "rupiah", "Tildesmall", "exclamdownsmall", "centoldstyle",
+	"Lslashsmall", "Scaronsmall", "Zcaronsmall", "Dieresissmall",
+	"Brevesmall", "Caronsmall", "Dotaccentsmall", "Macronsmall", "figuredash",
+	"hypheninferior", "Ogoneksmall", "Ringsmall", "Cedillasmall",
+	"questiondownsmall", "oneeighth", "threeeighths", "fiveeighths",
+	"seveneighths", "onethird", "twothirds", "zerosuperior", "foursuperior",
+	"fivesuperior", "sixsuperior", "sevensuperior", "eightsuperior",
+	"ninesuperior", "zeroinferior", "oneinferior", "twoinferior",
+	"threeinferior", "fourinferior", "fiveinferior", "sixinferior",
+	"seveninferior", "eightinferior", "nineinferior", "centinferior",
+	"dollarinferior", "periodinferior", "commainferior", "Agravesmall",
+	"Aacutesmall", "Acircumflexsmall", "Atildesmall", "Adieresissmall",
+	"Aringsmall", "AEsmall", "Ccedillasmall", "Egravesmall", "Eacutesmall",
+	"Ecircumflexsmall", "Edieresissmall", "Igravesmall", "Iacutesmall",
+	"Icircumflexsmall", "Idieresissmall", "Ethsmall", "Ntildesmall",
+	"Ogravesmall", "Oacutesmall", "Ocircumflexsmall", "Otildesmall",
+	"Odieresissmall", "OEsmall", "Oslashsmall", "Ugravesmall", "Uacutesmall",
+	"Ucircumflexsmall", "Udieresissmall", "Yacutesmall", "Thornsmall",
+	"Ydieresissmall", "001.000", "001.001", "001.002", "001.003", "Black",
+	"Bold", "Book", "Light", "Medium", "Regular", "Roman", "Semibold",
+}
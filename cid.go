@@ -0,0 +1,76 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// CID-keyed fonts (PDF 32000-1:2008, §9.7): relating the CIDs
+// of a Type 0 font's descendant to the glyphs of its font program.
+
+package pdf
+
+// descendant returns the CIDFont dictionary of a Type 0 font.
+func (f Font) descendant() Value {
+	return f.V.Key("DescendantFonts").Index(0)
+}
+
+// A cidGlyphMap relates the CIDs of a CIDFontType2 font to the glyphs
+// of its embedded TrueType program and those glyphs to Unicode.
+type cidGlyphMap struct {
+	gids      []uint16 // CIDToGIDMap stream contents; nil for Identity
+	glyphRune map[uint16]rune
+}
+
+// newCIDGlyphMap returns the glyph map for f, or nil if f is not a
+// CIDFontType2 font with an embedded program that has a Unicode cmap.
+func newCIDGlyphMap(f Font) *cidGlyphMap {
+	d := f.descendant()
+	if d.Key("Subtype").CoerceName("") != "CIDFontType2" {
+		return nil
+	}
+	cmap := sfntTables(streamBytes(d.Key("FontDescriptor").Key("FontFile2")))["cmap"]
+	uni := unicodeCmap(cmap)
+	if len(uni) == 0 {
+		return nil
+	}
+	return &cidGlyphMap{gids: cidToGIDMap(d), glyphRune: invertCmap(uni)}
+}
+
+// cidToGIDMap returns the contents of the CIDToGIDMap stream of the
+// CIDFont d, which gives the glyph for each CID as a two-byte
+// big-endian number, or nil if CIDs are glyph indexes: when the entry
+// is absent or is the name Identity.
+func cidToGIDMap(d Value) []uint16 {
+	v := d.Key("CIDToGIDMap")
+	if v.Kind() != Stream {
+		return nil
+	}
+	data := streamBytes(v)
+	gids := make([]uint16, len(data)/2)
+	for i := range gids {
+		gids[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+	}
+	return gids
+}
+
+// gid returns the glyph index for cid.
+func (m *cidGlyphMap) gid(cid uint32) uint16 {
+	return cidGID(m.gids, cid)
+}
+
+// cidGID returns the glyph index for cid given the CIDToGIDMap gids.
+func cidGID(gids []uint16, cid uint32) uint16 {
+	if gids == nil {
+		return uint16(cid)
+	}
+	if cid >= uint32(len(gids)) {
+		return 0
+	}
+	return gids[cid]
+}
+
+// rune returns the character drawn by the glyph for cid, or noRune.
+func (m *cidGlyphMap) rune(cid uint32) rune {
+	if r, ok := m.glyphRune[m.gid(cid)]; ok {
+		return r
+	}
+	return noRune
+}
@@ -0,0 +1,78 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// type0PDF returns a page using as /F1 the Type 0 font with the
+// encoding and the extra entries for its font dictionary and its
+// descendant CIDFont dictionary. Objects from 8 on are the extra objects.
+func type0PDF(encoding, entries, cidEntries string, extra ...string) []byte {
+	return pagePDF("<</Font <</F1 5 0 R>>>>", "",
+		append([]string{
+			"<</Type /Font /Subtype /Type0 /BaseFont /ABCDEF+Test /Encoding " + encoding + " /DescendantFonts [6 0 R] " + entries + ">>",
+			"<</Type /Font /BaseFont /ABCDEF+Test /FontDescriptor 7 0 R " + cidEntries + ">>",
+			"<</Type /FontDescriptor /FontName /ABCDEF+Test /Flags 4 /FontFile2 8 0 R>>",
+		}, extra...)...)
+}
+
+func TestCIDToGIDMap(t *testing.T) {
+	// Glyphs 1, 2 and 3 are A, B and C.
+	prog := stream("", sfnt(map[string]string{
+		"cmap": cmapTable(cmapSubtable{3, 1, cmapFormat4([3]uint16{'A', 'C', 0x10000 + 1 - 'A'})}),
+	}))
+	tests := []struct {
+		entries string
+		want    string
+	}{
+		{"/Subtype /CIDFontType2", "AB"},
+		{"/Subtype /CIDFontType2 /CIDToGIDMap /Identity", "AB"},
+		{"/Subtype /CIDFontType2 /CIDToGIDMap 9 0 R", "CA"},
+		// CIDs beyond the map select glyph 0, which has no character.
+		{"/Subtype /CIDFontType2 /CIDToGIDMap 10 0 R", "C�"},
+	}
+	for _, tt := range tests {
+		data := type0PDF("/Identity-H", "", tt.entries, prog,
+			stream("", "\x00\x00\x00\x03\x00\x01"), stream("", "\x00\x00\x00\x03"))
+		f := openPDF(t, data).Page(1).Font("F1")
+		if got := decodeText(f, "\x00\x01\x00\x02"); got != tt.want {
+			t.Errorf("%s: text %q, want %q", tt.entries, got, tt.want)
+		}
+	}
+}
+
+func TestIdentityCMap(t *testing.T) {
+	toUnicode := stream("", "/CIDInit /ProcSet findresource begin 12 dict begin begincmap"+
+		" 1 begincodespacerange <0000> <ffff> endcodespacerange"+
+		" 2 beginbfchar <0001> <0048> <0102> <0069> endbfchar endcmap end end")
+	tests := []struct {
+		encoding string
+		vertical bool
+		want     string
+	}{
+		{"/Identity-H", false, `["H" 250 0] ["i" 700 0] ["�" 600 0]`},
+		// Vertical glyphs advance down by an em by default.
+		{"/Identity-V", true, `["H" 250 -1000] ["i" 700 -1000] ["�" 600 -1000]`},
+	}
+	for _, tt := range tests {
+		data := type0PDF(tt.encoding, "/ToUnicode 9 0 R", "/Subtype /CIDFontType0 /DW 600 /W [1 [250] 258 258 700]",
+			"null", toUnicode)
+		f := openPDF(t, data).Page(1).Font("F1")
+		var got []string
+		for _, ch := range f.Decode("\x00\x01\x01\x02\x00\x05") {
+			got = append(got, fmt.Sprintf("[%q %v %v]", string(ch.Text), ch.Width, ch.VWidth))
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%s: glyphs %s, want %s", tt.encoding, s, tt.want)
+		}
+		if f.Vertical() != tt.vertical {
+			t.Errorf("%s: Vertical() = %v, want %v", tt.encoding, f.Vertical(), tt.vertical)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Predefined CMaps (PDF 32000-1:2008, §9.7.5.2), named by the
+// Encoding entry of Type 0 fonts such as UniGB-UCS2-H or GBK-EUC-H.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The predefined CMaps are not part of this package: there are
+// hundreds of them, and most are large. The Unicode-based ones
+// (Uni...-UCS2, -UTF16, -UTF8 and -UTF32) need no tables for text
+// extraction, since their codes are the text. Any other predefined
+// CMap must be registered by the caller, typically from Adobe's
+// published CMap resources, along with the Registry-Ordering-UCS2
+// CMap that maps the CIDs of its character collection to Unicode.
+var cmapRegistry struct {
+	sync.Mutex
+	data   map[string][]byte
+	parsed map[string]*cmapFile
+}
+
+// RegisterCMap makes the CMap file read from r available under name,
+// for Type 0 fonts whose Encoding is that predefined CMap and for
+// CMaps that refer to it with usecmap. For a character collection
+// such as Adobe-GB1, registering the Adobe-GB1-UCS2 CMap allows text
+// in fonts using any of the collection's CMaps to be decoded.
+// Files are parsed the first time a font needs them.
+func RegisterCMap(name string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	cmapRegistry.Lock()
+	defer cmapRegistry.Unlock()
+	if cmapRegistry.data == nil {
+		cmapRegistry.data = make(map[string][]byte)
+		cmapRegistry.parsed = make(map[string]*cmapFile)
+	}
+	cmapRegistry.data[name] = data
+	cmapRegistry.parsed = make(map[string]*cmapFile)
+	return nil
+}
+
+// A cmapFile is a parsed CMap: code space ranges, mappings from
+// codes to CIDs, and mappings from codes to Unicode text.
+type cmapFile struct {
+	space   [4][][2]string
+	cids    []cidRange
+	bfrange []bfrange
+}
+
+// A cidRange maps the codes from lo to hi to consecutive CIDs.
+type cidRange struct {
+	lo, hi string
+	cid    uint32
+}
+
+// maxUseCMapDepth limits chains of usecmap references.
+const maxUseCMapDepth = 8
+
+// loadCMap returns the registered CMap with the given name, or nil.
+func loadCMap(name string) *cmapFile {
+	cmapRegistry.Lock()
+	defer cmapRegistry.Unlock()
+	return loadCMapLocked(name, 0)
+}
+
+func loadCMapLocked(name string, depth int) *cmapFile {
+	if m, ok := cmapRegistry.parsed[name]; ok {
+		return m
+	}
+	data, ok := cmapRegistry.data[name]
+	if !ok || depth >= maxUseCMapDepth {
+		return nil
+	}
+	m, parent, err := parseCMapFile(bytes.NewReader(data))
+	if err != nil {
+		m = nil
+	} else if parent != "" {
+		if p := loadCMapLocked(parent, depth+1); p != nil {
+			m.use(p)
+		}
+	}
+	cmapRegistry.parsed[name] = m
+	return m
+}
+
+// parseCMapFile parses a CMap file, returning the name of the CMap
+// it builds on with usecmap, if any.
+func parseCMapFile(rd io.Reader) (m *cmapFile, parent string, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			m, err = nil, fmt.Errorf("malformed CMap: %v", e)
+		}
+	}()
+	m = new(cmapFile)
+	n := 0
+	interpret(rd, func(stk *Stack, op string) {
+		switch op {
+		case "findresource":
+			stk.Pop()
+			stk.Pop()
+			stk.Push(newDict())
+		case "begincmap":
+			stk.Push(newDict())
+		case "endcmap":
+			stk.Pop()
+		case "defineresource":
+			stk.Pop()
+			v := stk.Pop()
+			stk.Pop()
+			stk.Push(v)
+		case "usecmap":
+			parent = stk.Pop().CoerceName("")
+		case "begincodespacerange", "begincidrange", "begincidchar", "beginbfrange", "beginbfchar", "beginnotdefrange", "beginnotdefchar":
+			n = int(stk.Pop().CoerceInt64(0))
+		case "endcodespacerange":
+			for i := 0; i < n; i++ {
+				hi, lo := stk.Pop().CoerceString(""), stk.Pop().CoerceString("")
+				if len(lo) == 0 || len(lo) > 4 || len(lo) != len(hi) {
+					panic("bad codespace range")
+				}
+				m.space[len(lo)-1] = append(m.space[len(lo)-1], [2]string{lo, hi})
+			}
+		case "endcidrange":
+			for i := 0; i < n; i++ {
+				cid, hi, lo := stk.Pop().CoerceInt64(0), stk.Pop().CoerceString(""), stk.Pop().CoerceString("")
+				m.cids = append(m.cids, cidRange{lo, hi, uint32(cid)})
+			}
+		case "endcidchar":
+			for i := 0; i < n; i++ {
+				cid, lo := stk.Pop().CoerceInt64(0), stk.Pop().CoerceString("")
+				m.cids = append(m.cids, cidRange{lo, lo, uint32(cid)})
+			}
+		case "endbfrange":
+			for i := 0; i < n; i++ {
+				dst, hi, lo := stk.Pop(), stk.Pop().CoerceString(""), stk.Pop().CoerceString("")
+				m.bfrange = append(m.bfrange, bfrange{lo, hi, dst})
+			}
+		case "endbfchar":
+			for i := 0; i < n; i++ {
+				dst, lo := stk.Pop(), stk.Pop().CoerceString("")
+				m.bfrange = append(m.bfrange, bfrange{lo, lo, dst})
+			}
+		case "endnotdefrange":
+			for i := 0; i < 3*n; i++ {
+				stk.Pop()
+			}
+		case "endnotdefchar":
+			for i := 0; i < 2*n; i++ {
+				stk.Pop()
+			}
+		}
+	})
+	return m, parent, nil
+}
+
+// use adds the code space ranges and mappings of p, the CMap that m
+// builds on, to m. Mappings of m take precedence over those of p.
+func (m *cmapFile) use(p *cmapFile) {
+	if p == nil {
+		return
+	}
+	for i := range m.space {
+		m.space[i] = append(m.space[i], p.space[i]...)
+	}
+	m.cids = append(m.cids, p.cids...)
+	m.bfrange = append(m.bfrange, p.bfrange...)
+}
+
+// defaultLen returns the code length to assume for codes outside
+// every code space range, as when a ToUnicode CMap has none:
+// the length of the codes of its first mapping.
+func (m *cmapFile) defaultLen() int {
+	switch {
+	case len(m.bfrange) > 0 && len(m.bfrange[0].lo) > 0:
+		return len(m.bfrange[0].lo)
+	case len(m.cids) > 0 && len(m.cids[0].lo) > 0:
+		return len(m.cids[0].lo)
+	}
+	return 1
+}
+
+// codeLen returns the length of the code at the start of raw
+// according to the code space ranges, or 0 if it is in none.
+func (m *cmapFile) codeLen(raw string) int {
+	for n := 1; n <= 4 && n <= len(raw); n++ {
+		for _, space := range m.space[n-1] {
+			if space[0] <= raw[:n] && raw[:n] <= space[1] {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// cid returns the CID for code.
+func (m *cmapFile) cid(code string) (uint32, bool) {
+	for _, r := range m.cids {
+		if len(r.lo) == len(code) && r.lo <= code && code <= r.hi {
+			return r.cid + uint32(codeValue(code)-codeValue(r.lo)), true
+		}
+	}
+	return 0, false
+}
+
+// text returns the Unicode text mapped from code by bfrange and bfchar
+// mappings, such as those of a ToUnicode or Registry-Ordering-UCS2 CMap.
+//
+// A destination is normally a string of UTF-16BE text, which may hold
+// several characters or a surrogate pair. In a range, the last UTF-16
+// code unit is incremented for each code after the first. A range may
+// instead map to an array with one string for each code, and a single
+// code may map to a glyph name.
+func (m *cmapFile) text(code string) ([]rune, bool) {
+	for _, bf := range m.bfrange {
+		if len(bf.lo) != len(code) || code < bf.lo || bf.hi < code {
+			continue
+		}
+		off := codeValue(code) - codeValue(bf.lo)
+		switch bf.dst.Kind() {
+		case String:
+			return bfText(bf.dst.CoerceString(""), off)
+		case Array:
+			return bfText(bf.dst.Index(int(off)).CoerceString(""), 0)
+		case Name:
+			rs := glyphRunes(bf.dst.CoerceName(""))
+			return rs, len(rs) > 0
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// bfText decodes the UTF-16BE destination string s
+// with its last code unit incremented by off.
+func bfText(s string, off uint32) ([]rune, bool) {
+	if s == "" {
+		return nil, false
+	}
+	if len(s)%2 != 0 {
+		// Not UTF-16: a single-byte destination, taken as Latin-1.
+		b := []byte(s)
+		b[len(b)-1] += byte(off)
+		rs := make([]rune, len(b))
+		for i, c := range b {
+			rs[i] = rune(c)
+		}
+		return rs, true
+	}
+	u := make([]uint16, len(s)/2)
+	for i := range u {
+		u[i] = uint16(s[2*i])<<8 | uint16(s[2*i+1])
+	}
+	u[len(u)-1] += uint16(off)
+	return utf16.Decode(u), true
+}
+
+// codeValue returns the big-endian value of a code of up to four bytes.
+func codeValue(code string) uint32 {
+	x := uint32(0)
+	for i := 0; i < len(code); i++ {
+		x = x<<8 | uint32(code[i])
+	}
+	return x
+}
+
+// unicodeCMapForm returns the Unicode encoding form used by the codes
+// of the predefined CMap name, such as "UCS2" for UniJIS-UCS2-H,
+// or "" if name is not a Unicode-based CMap.
+func unicodeCMapForm(name string) string {
+	if !strings.HasPrefix(name, "Uni") {
+		return ""
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "-H"), "-V")
+	for _, form := range []string{"UCS2", "UTF16", "UTF8", "UTF32"} {
+		if strings.HasSuffix(name, "-"+form) || strings.HasSuffix(name, "-"+form+"-HW") {
+			return form
+		}
+	}
+	return ""
+}
+
+// unicodeFormLen returns the length of the code at the start of raw
+// in the Unicode encoding form.
+func unicodeFormLen(form, raw string) int {
+	switch form {
+	case "UTF16":
+		if len(raw) >= 4 && raw[0]&0xfc == 0xd8 {
+			return 4
+		}
+	case "UTF8":
+		_, n := utf8.DecodeRuneInString(raw)
+		return n
+	case "UTF32":
+		return 4
+	}
+	return 2
+}
+
+// decodeUnicodeForm decodes a code in the Unicode encoding form.
+func decodeUnicodeForm(form, code string) []rune {
+	switch form {
+	case "UCS2", "UTF16":
+		var u []uint16
+		for i := 0; i+1 < len(code); i += 2 {
+			u = append(u, uint16(code[i])<<8|uint16(code[i+1]))
+		}
+		return utf16.Decode(u)
+	case "UTF8":
+		return []rune(code)
+	case "UTF32":
+		if len(code) == 4 {
+			return []rune{rune(codeValue(code))}
+		}
+	}
+	return nil
+}
+
+// verticalCMap reports whether the CMap enc, the Encoding of a Type 0
+// font, is for vertical writing: a predefined CMap whose name ends in -V,
+// or an embedded CMap with WMode 1 or building on such a CMap.
+func verticalCMap(enc Value) bool {
+	switch enc.Kind() {
+	case Name:
+		return strings.HasSuffix(enc.CoerceName(""), "-V")
+	case Stream:
+		return enc.Key("WMode").CoerceInt64(0) == 1 || strings.HasSuffix(enc.Key("UseCMap").CoerceName(""), "-V")
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+// A CMap for a made-up character collection, Test-Test, in which
+// single bytes A to Z are CIDs 1 to 26 and two-byte codes from
+// 0x8140 are CIDs from 100, and the CMaps that build on it.
+const (
+	testCMap = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CMapName /Test-EUC-H def
+2 begincodespacerange
+<00> <7f>
+<8140> <fefe>
+endcodespacerange
+2 begincidrange
+<41> <5a> 1
+<8140> <8142> 100
+endcidrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`
+	testVCMap = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/Test-EUC-H usecmap
+/CMapName /Test-EUC-V def
+1 begincidchar
+<8142> 200
+endcidchar
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`
+	testUCS2CMap = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CMapName /Test-Test-UCS2 def
+1 begincodespacerange
+<0000> <ffff>
+endcodespacerange
+3 beginbfrange
+<0001> <001a> <0061>
+<0064> <0066> <4e00>
+<00c8> <00c8> <2191>
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`
+)
+
+func TestPredefinedCMap(t *testing.T) {
+	for name, cmap := range map[string]string{
+		"Test-EUC-H":     testCMap,
+		"Test-EUC-V":     testVCMap,
+		"Test-Test-UCS2": testUCS2CMap,
+	} {
+		if err := RegisterCMap(name, strings.NewReader(cmap)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info := "/CIDSystemInfo <</Registry (Test) /Ordering (Test) /Supplement 0>>"
+	tests := []struct {
+		encoding string
+		raw      string
+		want     string
+	}{
+		{"/Test-EUC-H", "AB\x81\x41Z\x81\x42", "ab丁z丂"},
+		// The vertical CMap maps 0x8142 to a vertical form.
+		{"/Test-EUC-V", "AB\x81\x41Z\x81\x42", "ab丁z↑"},
+		// The Unicode CMaps need no tables.
+		{"/UniGB-UCS2-H", "\x4e\x2d\x00A", "中A"},
+		{"/UniGB-UTF16-V", "\xd8\x3d\xde\x00\x00A", "😀A"},
+		{"/UniJIS-UTF8-H", "中A", "中A"},
+		{"/UniJIS-UTF32-H", "\x00\x00\x4e\x2d", "中"},
+	}
+	for _, tt := range tests {
+		data := type0PDF(tt.encoding, "", "/Subtype /CIDFontType0 "+info)
+		f := openPDF(t, data).Page(1).Font("F1")
+		if got := decodeText(f, tt.raw); got != tt.want {
+			t.Errorf("%s: %q decodes as %q, want %q", tt.encoding, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestToUnicode(t *testing.T) {
+	const parent = `/CIDInit /ProcSet findresource begin 12 dict begin begincmap
+/CMapName /Test-ToUnicode-Parent def
+1 begincodespacerange <00> <ff> endcodespacerange
+2 beginbfchar <7a> <0041> <79> <0042> endbfchar
+endcmap end end`
+	if err := RegisterCMap("Test-ToUnicode-Parent", strings.NewReader(parent)); err != nil {
+		t.Fatal(err)
+	}
+	toUnicode := `/CIDInit /ProcSet findresource begin 12 dict begin begincmap
+/Test-ToUnicode-Parent usecmap
+1 begincodespacerange <00> <ff> endcodespacerange
+4 beginbfchar
+<01> <D83DDE00>
+<02> <00660069>
+<03> /Euro
+<79> <0043>
+endbfchar
+3 beginbfrange
+<10> <12> <0061>
+<20> <22> [<0078> <D83DDE01> <00790079>]
+<30> <31> <41>
+endbfrange
+endcmap end end`
+	data := fontPDF("/Subtype /Type1 /BaseFont /Test /ToUnicode 6 0 R", stream("", toUnicode))
+	f := openPDF(t, data).Page(1).Font("F1")
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"\x01", "😀"},            // surrogate pair
+		{"\x02", "fi"},           // several characters
+		{"\x03", "€"},            // glyph name
+		{"\x10\x11\x12", "abc"},  // range
+		{"\x20\x21\x22", "x😁yy"}, // range to an array
+		{"\x30\x31", "AB"},       // single-byte destination
+		{"\x7a\x79", "AC"},       // usecmap, overridden
+		{"\x7b", "{"},            // unmapped
+	}
+	for _, tt := range tests {
+		if got := decodeText(f, tt.raw); got != tt.want {
+			t.Errorf("%q decodes as %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
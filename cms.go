@@ -0,0 +1,206 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The parts of CMS SignedData (RFC 5652) needed to verify
+// PDF signatures.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidRSAPSS        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+)
+
+// cmsDigests maps digest algorithm identifiers to hashes.
+var cmsDigests = []struct {
+	oid  asn1.ObjectIdentifier
+	hash crypto.Hash
+}{
+	{asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}, crypto.SHA1},
+	{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}, crypto.SHA256},
+	{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}, crypto.SHA384},
+	{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}, crypto.SHA512},
+}
+
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo cmsEncapContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+type cmsEncapContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     []byte `asn1:"optional,explicit,tag:0"`
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type cmsIssuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// A cmsSignature is a parsed SignedData object with a single signer.
+type cmsSignature struct {
+	certs       []*x509.Certificate
+	signer      *x509.Certificate
+	hash        crypto.Hash
+	content     []byte    // encapsulated content, if any
+	digest      []byte    // messageDigest attribute, if signed attributes are present
+	signingTime time.Time // signingTime attribute, if present
+	signed      []byte    // the bytes the signature is computed over, if signed attributes are present
+	si          cmsSignerInfo
+}
+
+// parseCMS parses the DER-encoded SignedData object der,
+// ignoring any padding after it.
+func parseCMS(der []byte) (*cmsSignature, error) {
+	var ci cmsContentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("malformed signature: %v", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unsupported signature content type %v", ci.ContentType)
+	}
+	var sd cmsSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("malformed signature: %v", err)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("malformed signature: %d signers", len(sd.SignerInfos))
+	}
+	s := &cmsSignature{content: sd.EncapContentInfo.Content, si: sd.SignerInfos[0]}
+	if len(sd.Certificates.Bytes) > 0 {
+		certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("malformed signature: %v", err)
+		}
+		s.certs = certs
+	}
+	for _, d := range cmsDigests {
+		if s.si.DigestAlgorithm.Algorithm.Equal(d.oid) {
+			s.hash = d.hash
+		}
+	}
+	if s.hash == 0 {
+		return nil, fmt.Errorf("unsupported signature digest algorithm %v", s.si.DigestAlgorithm.Algorithm)
+	}
+
+	// Find the signer's certificate.
+	var ias cmsIssuerAndSerial
+	if s.si.SID.Class == asn1.ClassContextSpecific && s.si.SID.Tag == 0 {
+		for _, c := range s.certs {
+			if bytes.Equal(c.SubjectKeyId, s.si.SID.Bytes) {
+				s.signer = c
+			}
+		}
+	} else if _, err := asn1.Unmarshal(s.si.SID.FullBytes, &ias); err == nil {
+		for _, c := range s.certs {
+			if bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) && c.SerialNumber.Cmp(ias.Serial) == 0 {
+				s.signer = c
+			}
+		}
+	}
+	if s.signer == nil {
+		return nil, fmt.Errorf("malformed signature: missing signer certificate")
+	}
+
+	// The signed attributes are signed with their SET OF tag
+	// in place of the implicit [0] tag.
+	if len(s.si.SignedAttrs.FullBytes) > 0 {
+		s.signed = append([]byte(nil), s.si.SignedAttrs.FullBytes...)
+		s.signed[0] = 0x31
+		var attrs []cmsAttribute
+		if _, err := asn1.UnmarshalWithParams(s.signed, &attrs, "set"); err != nil {
+			return nil, fmt.Errorf("malformed signature: %v", err)
+		}
+		for _, a := range attrs {
+			switch {
+			case a.Type.Equal(oidMessageDigest):
+				asn1.Unmarshal(a.Values.Bytes, &s.digest)
+			case a.Type.Equal(oidSigningTime):
+				asn1.Unmarshal(a.Values.Bytes, &s.signingTime)
+			}
+		}
+		if s.digest == nil {
+			return nil, fmt.Errorf("malformed signature: missing message digest")
+		}
+	}
+	return s, nil
+}
+
+// verify checks that s is a valid signature of the content
+// whose digest, using s.hash, is digest.
+func (s *cmsSignature) verify(digest []byte) error {
+	if s.signed != nil {
+		if !bytes.Equal(digest, s.digest) {
+			return fmt.Errorf("signature digest mismatch")
+		}
+		h := s.hash.New()
+		h.Write(s.signed)
+		digest = h.Sum(nil)
+	}
+	var ok bool
+	switch pub := s.signer.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if s.si.SignatureAlgorithm.Algorithm.Equal(oidRSAPSS) {
+			ok = rsa.VerifyPSS(pub, s.hash, digest, s.si.Signature, nil) == nil
+		} else {
+			ok = rsa.VerifyPKCS1v15(pub, s.hash, digest, s.si.Signature) == nil
+		}
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(pub, digest, s.si.Signature)
+	case ed25519.PublicKey:
+		if s.signed == nil {
+			return fmt.Errorf("unsupported Ed25519 signature without signed attributes")
+		}
+		ok = ed25519.Verify(pub, s.signed, s.si.Signature)
+	default:
+		return fmt.Errorf("unsupported signer public key %T", pub)
+	}
+	if !ok {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
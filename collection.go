@@ -0,0 +1,90 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Portable collections, or portfolios (PDF 32000-1:2008, §12.3.5):
+// documents that package other files, presented by the viewer
+// as a list of their members.
+
+package pdf
+
+import "sort"
+
+// A Collection describes a portfolio: a document whose purpose is to
+// hold its attachments, which the viewer lists rather than showing
+// the document's own pages.
+type Collection struct {
+	View    string            // initial view: D (details), T (tiles) or H (hidden, showing the cover pages)
+	Initial string            // name of the member file shown initially, or empty for the cover pages
+	Schema  []CollectionField // fields shown for each member, in display order
+	Sort    []CollectionSort  // sort order of the members, most significant first
+	Files   []Attachment      // the members
+
+	V Value // the collection dictionary
+}
+
+// A CollectionField is a field of a portfolio's schema:
+// a piece of information shown for each member file.
+type CollectionField struct {
+	Key      string // key of the field's value in each member's collection item
+	Name     string // name of the field shown to the user
+	Type     string // S (text), D (date) or N (number) for collection item values; F, Desc, ModDate, CreationDate, Size or CompressedSize for file properties
+	Visible  bool   // the field is shown initially
+	Editable bool   // the user may change the field's value
+	order    int    // relative display order (O)
+}
+
+// A CollectionSort is one key of a portfolio's sort order.
+type CollectionSort struct {
+	Key       string // key of a schema field
+	Ascending bool
+}
+
+// Collection returns the document's portfolio description, the
+// catalog's Collection entry, with its member files, or nil if the
+// document is not a portfolio.
+func (r *Reader) Collection() *Collection {
+	v := r.Trailer.Key("Root").Key("Collection")
+	if v.Kind() != Dict {
+		return nil
+	}
+	c := &Collection{
+		View:    v.Key("View").CoerceName("D"),
+		Initial: v.Key("D").CoerceText(""),
+		Files:   r.Attachments(),
+		V:       v,
+	}
+
+	schema := v.Key("Schema")
+	for _, k := range schema.Keys() {
+		f := schema.Key(k)
+		if f.Kind() != Dict {
+			continue
+		}
+		c.Schema = append(c.Schema, CollectionField{
+			Key:      k,
+			Name:     f.Key("N").CoerceText(k),
+			Type:     f.Key("Subtype").CoerceName(""),
+			Visible:  f.Key("V").data != false,
+			Editable: f.Key("E").data == true,
+			order:    int(f.Key("O").CoerceInt64(0)),
+		})
+	}
+	sort.SliceStable(c.Schema, func(i, j int) bool {
+		return c.Schema[i].order < c.Schema[j].order
+	})
+
+	// S and A are each a single value or an array of them.
+	s, a := v.Key("Sort").Key("S"), v.Key("Sort").Key("A")
+	if s.Kind() == Name {
+		c.Sort = []CollectionSort{{s.CoerceName(""), a.data != false}}
+	}
+	for i := 0; i < s.Len(); i++ {
+		asc := a.data != false
+		if a.Kind() == Array {
+			asc = i >= a.Len() || a.Index(i).data != false
+		}
+		c.Sort = append(c.Sort, CollectionSort{s.Index(i).CoerceName(""), asc})
+	}
+	return c
+}
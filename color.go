@@ -0,0 +1,221 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Colors set by content streams.
+
+package pdf
+
+import (
+	"io"
+	"math"
+)
+
+// A Color is a color as set in a content stream,
+// in the color space that was current when it was set.
+type Color struct {
+	Space      string    // color space family, such as DeviceGray, DeviceRGB or DeviceCMYK
+	Components []float64 // component values as given in the content stream
+	Pattern    string    // for the Pattern color space, the pattern's resource name
+
+	def Value // full color space definition, for families that take parameters
+}
+
+// black is the initial fill and stroke color of the graphics state.
+var black = Color{Space: "DeviceGray", Components: []float64{0}}
+
+// RGB returns the color converted to RGB, with components in [0, 1].
+// DeviceCMYK and Lab colors are converted by CMYKToRGB and LabToRGB.
+// CIE-based gray and RGB spaces are treated as their device equivalents,
+// and ICCBased spaces as their alternate spaces. Separation and DeviceN
+// colors are converted through their tint transforms.
+// If the color space cannot be converted, RGB returns ok == false.
+func (c Color) RGB() (r, g, b float64, ok bool) {
+	x := c.Components
+	switch {
+	case (c.Space == "DeviceGray" || c.Space == "CalGray") && len(x) == 1:
+		return x[0], x[0], x[0], true
+	case (c.Space == "DeviceRGB" || c.Space == "CalRGB") && len(x) == 3:
+		return x[0], x[1], x[2], true
+	case c.Space == "DeviceCMYK" && len(x) == 4:
+		r, g, b := CMYKToRGB(x[0], x[1], x[2], x[3])
+		return r, g, b, true
+	case c.Space == "Lab" && len(x) == 3:
+		r, g, b := LabToRGB(x[0], x[1], x[2])
+		return r, g, b, true
+	case c.Space == "ICCBased":
+		alt := iccAlternate(c.def)
+		return Color{Space: colorSpaceFamily(alt), Components: x, def: alt}.RGB()
+	case c.Space == "Separation" && len(x) == 1 && c.def.Index(1).CoerceName("") == "All":
+		// All colorants of the page, as for registration marks.
+		return 1 - x[0], 1 - x[0], 1 - x[0], true
+	case c.Space == "Separation" && len(x) == 1 && c.def.Index(1).CoerceName("") == "None":
+		// No colorant: nothing is painted.
+		return 1, 1, 1, true
+	case c.Space == "Separation" || c.Space == "DeviceN":
+		// The tint transform maps the tints to the alternate space.
+		alt := c.def.Index(2)
+		y, err := evalFunction(c.def.Index(3), x)
+		if err != nil || colorSpaceFamily(alt) == c.Space {
+			return 0, 0, 0, false
+		}
+		return Color{Space: colorSpaceFamily(alt), Components: y, def: alt}.RGB()
+	case c.Space == "Indexed" && len(x) == 1:
+		if bc, ok := indexedColor(c.def, indexedLookup(c.def), int(math.Round(x[0]))); ok {
+			return bc.RGB()
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// ICCProfile returns the ICC profile of the color's ICCBased color
+// space, or nil if the color is in another space.
+func (c Color) ICCProfile() []byte {
+	return iccProfile(c.def)
+}
+
+// iccProfile returns the ICC profile data of the ICCBased color
+// space def, [/ICCBased stream], or nil if def is not one.
+func iccProfile(def Value) []byte {
+	if colorSpaceFamily(def) != "ICCBased" {
+		return nil
+	}
+	b, err := io.ReadAll(def.Index(1).Reader())
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// iccAlternate returns the color space used in place of the ICCBased
+// color space def, whose profile is not interpreted: the Alternate
+// given in the profile stream or, failing that, the device space with
+// the profile's number of components.
+func iccAlternate(def Value) Value {
+	strm := def.Index(1)
+	if alt := strm.Key("Alternate"); alt.Kind() == Name || alt.Kind() == Array && colorSpaceFamily(alt) != "ICCBased" {
+		return alt
+	}
+	switch strm.Key("N").CoerceInt64(0) {
+	case 1:
+		return Value{data: pdfname("DeviceGray")}
+	case 3:
+		return Value{data: pdfname("DeviceRGB")}
+	case 4:
+		return Value{data: pdfname("DeviceCMYK")}
+	}
+	return Value{}
+}
+
+// indexedColor returns entry i of the color table of the Indexed
+// color space def as a color in the base color space.
+func indexedColor(def Value, table []byte, i int) (Color, bool) {
+	base := def.Index(1)
+	c := initialColor(base)
+	n := len(c.Components)
+	if n == 0 || i < 0 || (i+1)*n > len(table) {
+		return Color{}, false
+	}
+	rng := componentRanges(c)
+	for k := range c.Components {
+		c.Components[k] = interpolate(float64(table[i*n+k]), 0, 255, rng[2*k], rng[2*k+1])
+	}
+	return c, true
+}
+
+// componentRanges returns the ranges [min0 max0 min1 max1 ...] of the
+// components of colors in c's color space, onto which the samples of
+// images and entries of color tables are mapped by default. Components
+// range over [0, 1] except those of Lab colors.
+func componentRanges(c Color) []float64 {
+	if c.Space == "Lab" {
+		return append([]float64{0, 100}, labRange(c.def)...)
+	}
+	rng := make([]float64, 2*len(c.Components))
+	for k := range c.Components {
+		rng[2*k+1] = 1
+	}
+	return rng
+}
+
+// indexedLookup returns the color table of the Indexed color space def,
+// [/Indexed base hival lookup], in which entry i is the n components
+// of the base color space starting at byte i*n, each scaled to 0-255.
+// The table is given as a string or a stream.
+func indexedLookup(def Value) []byte {
+	lookup := def.Index(3)
+	if lookup.Kind() == Stream {
+		b, err := io.ReadAll(lookup.Reader())
+		if err != nil {
+			return nil
+		}
+		return b
+	}
+	return []byte(lookup.CoerceString(""))
+}
+
+// colorSpaceFamily returns the family name of the color space definition v,
+// which is either a name or an array beginning with a name.
+func colorSpaceFamily(v Value) string {
+	if v.Kind() == Array {
+		return v.Index(0).CoerceName("")
+	}
+	return v.CoerceName("")
+}
+
+// lookupColorSpace resolves the operand of a cs or CS operator:
+// either the name of a device or pattern color space
+// or the name of an entry in the page's /ColorSpace resources.
+func lookupColorSpace(res Value, name string) Value {
+	switch name {
+	case "DeviceGray", "DeviceRGB", "DeviceCMYK", "Pattern":
+		return Value{data: pdfname(name)}
+	}
+	return res.Key("ColorSpace").Key(name)
+}
+
+// initialColor returns the initial color for the color space def,
+// as set by the cs and CS operators (PDF 32000-1:2008, §8.6.8).
+func initialColor(def Value) Color {
+	c := Color{Space: colorSpaceFamily(def), def: def}
+	switch c.Space {
+	case "DeviceGray", "CalGray", "Indexed":
+		c.Components = []float64{0}
+	case "DeviceRGB", "CalRGB", "Lab":
+		c.Components = []float64{0, 0, 0}
+	case "DeviceCMYK":
+		c.Components = []float64{0, 0, 0, 1}
+	case "ICCBased":
+		c.Components = make([]float64, def.Index(1).Key("N").CoerceInt64(0))
+	case "Separation":
+		c.Components = []float64{1}
+	case "DeviceN":
+		c.Components = make([]float64, def.Index(1).Len())
+		for i := range c.Components {
+			c.Components[i] = 1
+		}
+	}
+	return c
+}
+
+// withComponents returns c with its components replaced by those
+// given to an sc, SC, scn or SCN operator.
+// In a Pattern color space the final operand names the pattern.
+func (c Color) withComponents(args []Value) Color {
+	c.Components = colorArgs(args)
+	if n := len(args); n > 0 && args[n-1].Kind() == Name {
+		c.Pattern = args[n-1].CoerceName("")
+	}
+	return c
+}
+
+// colorArgs returns the numeric operands of a color operator.
+func colorArgs(args []Value) []float64 {
+	x := make([]float64, 0, len(args))
+	for _, a := range args {
+		if a.Kind() == Integer || a.Kind() == Real {
+			x = append(x, a.CoerceFloat64(0))
+		}
+	}
+	return x
+}
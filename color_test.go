@@ -0,0 +1,146 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// colorString formats c for comparison in tests.
+func colorString(c Color) string {
+	r, g, b, ok := c.RGB()
+	if !ok {
+		return fmt.Sprintf("%s %v", c.Space, c.Components)
+	}
+	return fmt.Sprintf("%s %v = %.2f %.2f %.2f", c.Space, c.Components, r, g, b)
+}
+
+func TestPathColors(t *testing.T) {
+	data := pagePDF("<</ColorSpace <</CS0 /DeviceRGB /CS1 [/CalRGB <</WhitePoint [0.95 1 1.09]>>]"+
+		" /CS2 [/Indexed /DeviceRGB 1 <ff000000ff00>]>>>>",
+		"0 0 m 1 1 l S\n"+
+			"0.2 g 0.7 G 0 0 m 1 1 l B\n"+
+			"1 0 0 rg 0 1 0 RG 0 0 m 1 1 l B\n"+
+			"0 0 0 1 k 1 0 0 0 K 0 0 m 1 1 l B\n"+
+			"/CS0 cs /CS1 CS 0 0 1 sc 1 1 0 SC 0 0 m 1 1 l B\n"+
+			"/CS2 cs 1 sc /DeviceGray CS 0 0 m 1 1 l B\n")
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][2]string
+	for _, p := range c.Paths {
+		got = append(got, [2]string{colorString(p.FillColor), colorString(p.StrokeColor)})
+	}
+	want := [][2]string{
+		{"DeviceGray [0] = 0.00 0.00 0.00", "DeviceGray [0] = 0.00 0.00 0.00"},
+		{"DeviceGray [0.2] = 0.20 0.20 0.20", "DeviceGray [0.7] = 0.70 0.70 0.70"},
+		{"DeviceRGB [1 0 0] = 1.00 0.00 0.00", "DeviceRGB [0 1 0] = 0.00 1.00 0.00"},
+		{"DeviceCMYK [0 0 0 1] = 0.17 0.18 0.21", "DeviceCMYK [1 0 0 0] = 0.00 0.72 0.95"},
+		{"DeviceRGB [0 0 1] = 0.00 0.00 1.00", "CalRGB [1 1 0] = 1.00 1.00 0.00"},
+		// Setting a color space sets its initial color.
+		{"Indexed [1] = 0.00 1.00 0.00", "DeviceGray [0] = 0.00 0.00 0.00"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("path colors:\n%q\nwant\n%q", got, want)
+	}
+	if len(c.Warnings) > 0 {
+		t.Errorf("warnings: %v", c.Warnings)
+	}
+}
+
+func TestICCBased(t *testing.T) {
+	data := pagePDF("<</ColorSpace <</CS0 [/ICCBased 5 0 R] /CS1 [/ICCBased 6 0 R]>> /XObject <</Im0 7 0 R>>>>",
+		"/CS0 cs /CS1 CS 0.2 0.4 0.6 sc 0.5 SC 0 0 m 1 1 l B\n/Im0 Do\n",
+		stream("/N 3", "profile 0"),
+		stream("/N 3 /Alternate /DeviceGray", "profile 1"),
+		stream("/Subtype /Image /Width 1 /Height 1 /BitsPerComponent 8 /ColorSpace [/ICCBased 5 0 R]", "\xff\x00\x80"),
+	)
+	p := openPDF(t, data).Page(1)
+	c, err := p.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Paths) != 1 || len(c.Images) != 1 {
+		t.Fatalf("%d paths and %d images, want 1 each", len(c.Paths), len(c.Images))
+	}
+	// The profiles are not interpreted: the colors are converted
+	// through the profile's alternate or the device space with as
+	// many components.
+	fill, stroke := c.Paths[0].FillColor, c.Paths[0].StrokeColor
+	if got, want := colorString(fill), "ICCBased [0.2 0.4 0.6] = 0.20 0.40 0.60"; got != want {
+		t.Errorf("fill color %s, want %s", got, want)
+	}
+	if got, want := colorString(stroke), "ICCBased [0.5] = 0.50 0.50 0.50"; got != want {
+		t.Errorf("stroke color %s, want %s", got, want)
+	}
+	if string(fill.ICCProfile()) != "profile 0" || string(stroke.ICCProfile()) != "profile 1" {
+		t.Errorf("ICCProfile() = %q, %q, want the profile streams", fill.ICCProfile(), stroke.ICCProfile())
+	}
+	if b := black.ICCProfile(); b != nil {
+		t.Errorf("DeviceGray ICCProfile() = %q, want nil", b)
+	}
+
+	if b := c.Images[0].Info().ICCProfile(); string(b) != "profile 0" {
+		t.Errorf("image ICCProfile() = %q, want %q", b, "profile 0")
+	}
+	imgs := p.Images()
+	if len(imgs) != 1 || imgs[0].Err != nil {
+		t.Fatalf("Images() = %+v", imgs)
+	}
+	if r, g, b, _ := imgs[0].Image.At(0, 0).RGBA(); r>>8 != 0xff || g>>8 != 0 || b>>8 != 0x80 {
+		t.Errorf("image pixel %02x%02x%02x, want ff0080", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestSeparationColors(t *testing.T) {
+	data := pagePDF("<</ColorSpace <</Spot [/Separation /Spot /DeviceRGB 5 0 R] /All [/Separation /All /DeviceGray 5 0 R]"+
+		" /None [/Separation /None /DeviceGray 5 0 R] /N [/DeviceN [/A /B] /DeviceGray 6 0 R]"+
+		" /Bad [/Separation /Bad /DeviceRGB 7 0 R]>> /XObject <</Im0 8 0 R>>>>",
+		"/Spot cs /All CS 0.5 sc 0.25 SC 0 0 m 1 1 l B\n"+
+			"/None cs /N CS 1 sc 0.2 0.3 SC 0 0 m 1 1 l B\n"+
+			"/Bad cs 1 sc 0 0 m 1 1 l B\n",
+		"<</FunctionType 2 /Domain [0 1] /C0 [1 1 1] /C1 [1 0 0] /N 1>>",
+		stream("/FunctionType 4 /Domain [0 1 0 1] /Range [0 1]", "{ add 1 exch sub }"),
+		stream("/FunctionType 4 /Domain [0 1] /Range [0 1 0 1 0 1]", "{ pop }"),
+		stream("/Subtype /Image /Width 2 /Height 1 /BitsPerComponent 8 /ColorSpace [/Separation /Spot /DeviceRGB 5 0 R]", "\x00\xff"),
+	)
+	p := openPDF(t, data).Page(1)
+	c, err := p.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range c.Paths {
+		got = append(got, colorString(p.FillColor), colorString(p.StrokeColor))
+	}
+	want := []string{
+		"Separation [0.5] = 1.00 0.50 0.50",
+		"Separation [0.25] = 0.75 0.75 0.75", // All: a gray level
+		"Separation [1] = 1.00 1.00 1.00",    // None: not painted
+		"DeviceN [0.2 0.3] = 0.50 0.50 0.50",
+		"Separation [1]", // the tint transform fails
+		"DeviceN [0.2 0.3] = 0.50 0.50 0.50",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("path colors:\n%q\nwant\n%q", got, want)
+	}
+
+	imgs := p.Images()
+	if len(imgs) != 1 || imgs[0].Err != nil {
+		t.Fatalf("Images() = %+v", imgs)
+	}
+	var px []string
+	for x := 0; x < 2; x++ {
+		r, g, b, _ := imgs[0].Image.At(x, 0).RGBA()
+		px = append(px, fmt.Sprintf("%02x%02x%02x", r>>8, g>>8, b>>8))
+	}
+	if s := strings.Join(px, " "); s != "ffffff ff0000" {
+		t.Errorf("Separation image pixels %s, want ffffff ff0000", s)
+	}
+}
@@ -0,0 +1,793 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Canonical compositions, from the Unicode Character Database.
+
+package pdf
+
+// composeTable maps a base character and a combining mark
+// to their canonical (NFC) composition.
+var composeTable = map[[2]rune]rune{
+	{0x0041, 0x0300}: 0x00C0, // LATIN CAPITAL LETTER A WITH GRAVE
+	{0x0041, 0x0301}: 0x00C1, // LATIN CAPITAL LETTER A WITH ACUTE
+	{0x0041, 0x0302}: 0x00C2, // LATIN CAPITAL LETTER A WITH CIRCUMFLEX
+	{0x0041, 0x0303}: 0x00C3, // LATIN CAPITAL LETTER A WITH TILDE
+	{0x0041, 0x0308}: 0x00C4, // LATIN CAPITAL LETTER A WITH DIAERESIS
+	{0x0041, 0x030A}: 0x00C5, // LATIN CAPITAL LETTER A WITH RING ABOVE
+	{0x0043, 0x0327}: 0x00C7, // LATIN CAPITAL LETTER C WITH CEDILLA
+	{0x0045, 0x0300}: 0x00C8, // LATIN CAPITAL LETTER E WITH GRAVE
+	{0x0045, 0x0301}: 0x00C9, // LATIN CAPITAL LETTER E WITH ACUTE
+	{0x0045, 0x0302}: 0x00CA, // LATIN CAPITAL LETTER E WITH CIRCUMFLEX
+	{0x0045, 0x0308}: 0x00CB, // LATIN CAPITAL LETTER E WITH DIAERESIS
+	{0x0049, 0x0300}: 0x00CC, // LATIN CAPITAL LETTER I WITH GRAVE
+	{0x0049, 0x0301}: 0x00CD, // LATIN CAPITAL LETTER I WITH ACUTE
+	{0x0049, 0x0302}: 0x00CE, // LATIN CAPITAL LETTER I WITH CIRCUMFLEX
+	{0x0049, 0x0308}: 0x00CF, // LATIN CAPITAL LETTER I WITH DIAERESIS
+	{0x004E, 0x0303}: 0x00D1, // LATIN CAPITAL LETTER N WITH TILDE
+	{0x004F, 0x0300}: 0x00D2, // LATIN CAPITAL LETTER O WITH GRAVE
+	{0x004F, 0x0301}: 0x00D3, // LATIN CAPITAL LETTER O WITH ACUTE
+	{0x004F, 0x0302}: 0x00D4, // LATIN CAPITAL LETTER O WITH CIRCUMFLEX
+	{0x004F, 0x0303}: 0x00D5, // LATIN CAPITAL LETTER O WITH TILDE
+	{0x004F, 0x0308}: 0x00D6, // LATIN CAPITAL LETTER O WITH DIAERESIS
+	{0x0055, 0x0300}: 0x00D9, // LATIN CAPITAL LETTER U WITH GRAVE
+	{0x0055, 0x0301}: 0x00DA, // LATIN CAPITAL LETTER U WITH ACUTE
+	{0x0055, 0x0302}: 0x00DB, // LATIN CAPITAL LETTER U WITH CIRCUMFLEX
+	{0x0055, 0x0308}: 0x00DC, // LATIN CAPITAL LETTER U WITH DIAERESIS
+	{0x0059, 0x0301}: 0x00DD, // LATIN CAPITAL LETTER Y WITH ACUTE
+	{0x0061, 0x0300}: 0x00E0, // LATIN SMALL LETTER A WITH GRAVE
+	{0x0061, 0x0301}: 0x00E1, // LATIN SMALL LETTER A WITH ACUTE
+	{0x0061, 0x0302}: 0x00E2, // LATIN SMALL LETTER A WITH CIRCUMFLEX
+	{0x0061, 0x0303}: 0x00E3, // LATIN SMALL LETTER A WITH TILDE
+	{0x0061, 0x0308}: 0x00E4, // LATIN SMALL LETTER A WITH DIAERESIS
+	{0x0061, 0x030A}: 0x00E5, // LATIN SMALL LETTER A WITH RING ABOVE
+	{0x0063, 0x0327}: 0x00E7, // LATIN SMALL LETTER C WITH CEDILLA
+	{0x0065, 0x0300}: 0x00E8, // LATIN SMALL LETTER E WITH GRAVE
+	{0x0065, 0x0301}: 0x00E9, // LATIN SMALL LETTER E WITH ACUTE
+	{0x0065, 0x0302}: 0x00EA, // LATIN SMALL LETTER E WITH CIRCUMFLEX
+	{0x0065, 0x0308}: 0x00EB, // LATIN SMALL LETTER E WITH DIAERESIS
+	{0x0069, 0x0300}: 0x00EC, // LATIN SMALL LETTER I WITH GRAVE
+	{0x0069, 0x0301}: 0x00ED, // LATIN SMALL LETTER I WITH ACUTE
+	{0x0069, 0x0302}: 0x00EE, // LATIN SMALL LETTER I WITH CIRCUMFLEX
+	{0x0069, 0x0308}: 0x00EF, // LATIN SMALL LETTER I WITH DIAERESIS
+	{0x006E, 0x0303}: 0x00F1, // LATIN SMALL LETTER N WITH TILDE
+	{0x006F, 0x0300}: 0x00F2, // LATIN SMALL LETTER O WITH GRAVE
+	{0x006F, 0x0301}: 0x00F3, // LATIN SMALL LETTER O WITH ACUTE
+	{0x006F, 0x0302}: 0x00F4, // LATIN SMALL LETTER O WITH CIRCUMFLEX
+	{0x006F, 0x0303}: 0x00F5, // LATIN SMALL LETTER O WITH TILDE
+	{0x006F, 0x0308}: 0x00F6, // LATIN SMALL LETTER O WITH DIAERESIS
+	{0x0075, 0x0300}: 0x00F9, // LATIN SMALL LETTER U WITH GRAVE
+	{0x0075, 0x0301}: 0x00FA, // LATIN SMALL LETTER U WITH ACUTE
+	{0x0075, 0x0302}: 0x00FB, // LATIN SMALL LETTER U WITH CIRCUMFLEX
+	{0x0075, 0x0308}: 0x00FC, // LATIN SMALL LETTER U WITH DIAERESIS
+	{0x0079, 0x0301}: 0x00FD, // LATIN SMALL LETTER Y WITH ACUTE
+	{0x0079, 0x0308}: 0x00FF, // LATIN SMALL LETTER Y WITH DIAERESIS
+	{0x0041, 0x0304}: 0x0100, // LATIN CAPITAL LETTER A WITH MACRON
+	{0x0061, 0x0304}: 0x0101, // LATIN SMALL LETTER A WITH MACRON
+	{0x0041, 0x0306}: 0x0102, // LATIN CAPITAL LETTER A WITH BREVE
+	{0x0061, 0x0306}: 0x0103, // LATIN SMALL LETTER A WITH BREVE
+	{0x0041, 0x0328}: 0x0104, // LATIN CAPITAL LETTER A WITH OGONEK
+	{0x0061, 0x0328}: 0x0105, // LATIN SMALL LETTER A WITH OGONEK
+	{0x0043, 0x0301}: 0x0106, // LATIN CAPITAL LETTER C WITH ACUTE
+	{0x0063, 0x0301}: 0x0107, // LATIN SMALL LETTER C WITH ACUTE
+	{0x0043, 0x0302}: 0x0108, // LATIN CAPITAL LETTER C WITH CIRCUMFLEX
+	{0x0063, 0x0302}: 0x0109, // LATIN SMALL LETTER C WITH CIRCUMFLEX
+	{0x0043, 0x0307}: 0x010A, // LATIN CAPITAL LETTER C WITH DOT ABOVE
+	{0x0063, 0x0307}: 0x010B, // LATIN SMALL LETTER C WITH DOT ABOVE
+	{0x0043, 0x030C}: 0x010C, // LATIN CAPITAL LETTER C WITH CARON
+	{0x0063, 0x030C}: 0x010D, // LATIN SMALL LETTER C WITH CARON
+	{0x0044, 0x030C}: 0x010E, // LATIN CAPITAL LETTER D WITH CARON
+	{0x0064, 0x030C}: 0x010F, // LATIN SMALL LETTER D WITH CARON
+	{0x0045, 0x0304}: 0x0112, // LATIN CAPITAL LETTER E WITH MACRON
+	{0x0065, 0x0304}: 0x0113, // LATIN SMALL LETTER E WITH MACRON
+	{0x0045, 0x0306}: 0x0114, // LATIN CAPITAL LETTER E WITH BREVE
+	{0x0065, 0x0306}: 0x0115, // LATIN SMALL LETTER E WITH BREVE
+	{0x0045, 0x0307}: 0x0116, // LATIN CAPITAL LETTER E WITH DOT ABOVE
+	{0x0065, 0x0307}: 0x0117, // LATIN SMALL LETTER E WITH DOT ABOVE
+	{0x0045, 0x0328}: 0x0118, // LATIN CAPITAL LETTER E WITH OGONEK
+	{0x0065, 0x0328}: 0x0119, // LATIN SMALL LETTER E WITH OGONEK
+	{0x0045, 0x030C}: 0x011A, // LATIN CAPITAL LETTER E WITH CARON
+	{0x0065, 0x030C}: 0x011B, // LATIN SMALL LETTER E WITH CARON
+	{0x0047, 0x0302}: 0x011C, // LATIN CAPITAL LETTER G WITH CIRCUMFLEX
+	{0x0067, 0x0302}: 0x011D, // LATIN SMALL LETTER G WITH CIRCUMFLEX
+	{0x0047, 0x0306}: 0x011E, // LATIN CAPITAL LETTER G WITH BREVE
+	{0x0067, 0x0306}: 0x011F, // LATIN SMALL LETTER G WITH BREVE
+	{0x0047, 0x0307}: 0x0120, // LATIN CAPITAL LETTER G WITH DOT ABOVE
+	{0x0067, 0x0307}: 0x0121, // LATIN SMALL LETTER G WITH DOT ABOVE
+	{0x0047, 0x0327}: 0x0122, // LATIN CAPITAL LETTER G WITH CEDILLA
+	{0x0067, 0x0327}: 0x0123, // LATIN SMALL LETTER G WITH CEDILLA
+	{0x0048, 0x0302}: 0x0124, // LATIN CAPITAL LETTER H WITH CIRCUMFLEX
+	{0x0068, 0x0302}: 0x0125, // LATIN SMALL LETTER H WITH CIRCUMFLEX
+	{0x0049, 0x0303}: 0x0128, // LATIN CAPITAL LETTER I WITH TILDE
+	{0x0069, 0x0303}: 0x0129, // LATIN SMALL LETTER I WITH TILDE
+	{0x0049, 0x0304}: 0x012A, // LATIN CAPITAL LETTER I WITH MACRON
+	{0x0069, 0x0304}: 0x012B, // LATIN SMALL LETTER I WITH MACRON
+	{0x0049, 0x0306}: 0x012C, // LATIN CAPITAL LETTER I WITH BREVE
+	{0x0069, 0x0306}: 0x012D, // LATIN SMALL LETTER I WITH BREVE
+	{0x0049, 0x0328}: 0x012E, // LATIN CAPITAL LETTER I WITH OGONEK
+	{0x0069, 0x0328}: 0x012F, // LATIN SMALL LETTER I WITH OGONEK
+	{0x0049, 0x0307}: 0x0130, // LATIN CAPITAL LETTER I WITH DOT ABOVE
+	{0x004A, 0x0302}: 0x0134, // LATIN CAPITAL LETTER J WITH CIRCUMFLEX
+	{0x006A, 0x0302}: 0x0135, // LATIN SMALL LETTER J WITH CIRCUMFLEX
+	{0x004B, 0x0327}: 0x0136, // LATIN CAPITAL LETTER K WITH CEDILLA
+	{0x006B, 0x0327}: 0x0137, // LATIN SMALL LETTER K WITH CEDILLA
+	{0x004C, 0x0301}: 0x0139, // LATIN CAPITAL LETTER L WITH ACUTE
+	{0x006C, 0x0301}: 0x013A, // LATIN SMALL LETTER L WITH ACUTE
+	{0x004C, 0x0327}: 0x013B, // LATIN CAPITAL LETTER L WITH CEDILLA
+	{0x006C, 0x0327}: 0x013C, // LATIN SMALL LETTER L WITH CEDILLA
+	{0x004C, 0x030C}: 0x013D, // LATIN CAPITAL LETTER L WITH CARON
+	{0x006C, 0x030C}: 0x013E, // LATIN SMALL LETTER L WITH CARON
+	{0x004E, 0x0301}: 0x0143, // LATIN CAPITAL LETTER N WITH ACUTE
+	{0x006E, 0x0301}: 0x0144, // LATIN SMALL LETTER N WITH ACUTE
+	{0x004E, 0x0327}: 0x0145, // LATIN CAPITAL LETTER N WITH CEDILLA
+	{0x006E, 0x0327}: 0x0146, // LATIN SMALL LETTER N WITH CEDILLA
+	{0x004E, 0x030C}: 0x0147, // LATIN CAPITAL LETTER N WITH CARON
+	{0x006E, 0x030C}: 0x0148, // LATIN SMALL LETTER N WITH CARON
+	{0x004F, 0x0304}: 0x014C, // LATIN CAPITAL LETTER O WITH MACRON
+	{0x006F, 0x0304}: 0x014D, // LATIN SMALL LETTER O WITH MACRON
+	{0x004F, 0x0306}: 0x014E, // LATIN CAPITAL LETTER O WITH BREVE
+	{0x006F, 0x0306}: 0x014F, // LATIN SMALL LETTER O WITH BREVE
+	{0x004F, 0x030B}: 0x0150, // LATIN CAPITAL LETTER O WITH DOUBLE ACUTE
+	{0x006F, 0x030B}: 0x0151, // LATIN SMALL LETTER O WITH DOUBLE ACUTE
+	{0x0052, 0x0301}: 0x0154, // LATIN CAPITAL LETTER R WITH ACUTE
+	{0x0072, 0x0301}: 0x0155, // LATIN SMALL LETTER R WITH ACUTE
+	{0x0052, 0x0327}: 0x0156, // LATIN CAPITAL LETTER R WITH CEDILLA
+	{0x0072, 0x0327}: 0x0157, // LATIN SMALL LETTER R WITH CEDILLA
+	{0x0052, 0x030C}: 0x0158, // LATIN CAPITAL LETTER R WITH CARON
+	{0x0072, 0x030C}: 0x0159, // LATIN SMALL LETTER R WITH CARON
+	{0x0053, 0x0301}: 0x015A, // LATIN CAPITAL LETTER S WITH ACUTE
+	{0x0073, 0x0301}: 0x015B, // LATIN SMALL LETTER S WITH ACUTE
+	{0x0053, 0x0302}: 0x015C, // LATIN CAPITAL LETTER S WITH CIRCUMFLEX
+	{0x0073, 0x0302}: 0x015D, // LATIN SMALL LETTER S WITH CIRCUMFLEX
+	{0x0053, 0x0327}: 0x015E, // LATIN CAPITAL LETTER S WITH CEDILLA
+	{0x0073, 0x0327}: 0x015F, // LATIN SMALL LETTER S WITH CEDILLA
+	{0x0053, 0x030C}: 0x0160, // LATIN CAPITAL LETTER S WITH CARON
+	{0x0073, 0x030C}: 0x0161, // LATIN SMALL LETTER S WITH CARON
+	{0x0054, 0x0327}: 0x0162, // LATIN CAPITAL LETTER T WITH CEDILLA
+	{0x0074, 0x0327}: 0x0163, // LATIN SMALL LETTER T WITH CEDILLA
+	{0x0054, 0x030C}: 0x0164, // LATIN CAPITAL LETTER T WITH CARON
+	{0x0074, 0x030C}: 0x0165, // LATIN SMALL LETTER T WITH CARON
+	{0x0055, 0x0303}: 0x0168, // LATIN CAPITAL LETTER U WITH TILDE
+	{0x0075, 0x0303}: 0x0169, // LATIN SMALL LETTER U WITH TILDE
+	{0x0055, 0x0304}: 0x016A, // LATIN CAPITAL LETTER U WITH MACRON
+	{0x0075, 0x0304}: 0x016B, // LATIN SMALL LETTER U WITH MACRON
+	{0x0055, 0x0306}: 0x016C, // LATIN CAPITAL LETTER U WITH BREVE
+	{0x0075, 0x0306}: 0x016D, // LATIN SMALL LETTER U WITH BREVE
+	{0x0055, 0x030A}: 0x016E, // LATIN CAPITAL LETTER U WITH RING ABOVE
+	{0x0075, 0x030A}: 0x016F, // LATIN SMALL LETTER U WITH RING ABOVE
+	{0x0055, 0x030B}: 0x0170, // LATIN CAPITAL LETTER U WITH DOUBLE ACUTE
+	{0x0075, 0x030B}: 0x0171, // LATIN SMALL LETTER U WITH DOUBLE ACUTE
+	{0x0055, 0x0328}: 0x0172, // LATIN CAPITAL LETTER U WITH OGONEK
+	{0x0075, 0x0328}: 0x0173, // LATIN SMALL LETTER U WITH OGONEK
+	{0x0057, 0x0302}: 0x0174, // LATIN CAPITAL LETTER W WITH CIRCUMFLEX
+	{0x0077, 0x0302}: 0x0175, // LATIN SMALL LETTER W WITH CIRCUMFLEX
+	{0x0059, 0x0302}: 0x0176, // LATIN CAPITAL LETTER Y WITH CIRCUMFLEX
+	{0x0079, 0x0302}: 0x0177, // LATIN SMALL LETTER Y WITH CIRCUMFLEX
+	{0x0059, 0x0308}: 0x0178, // LATIN CAPITAL LETTER Y WITH DIAERESIS
+	{0x005A, 0x0301}: 0x0179, // LATIN CAPITAL LETTER Z WITH ACUTE
+	{0x007A, 0x0301}: 0x017A, // LATIN SMALL LETTER Z WITH ACUTE
+	{0x005A, 0x0307}: 0x017B, // LATIN CAPITAL LETTER Z WITH DOT ABOVE
+	{0x007A, 0x0307}: 0x017C, // LATIN SMALL LETTER Z WITH DOT ABOVE
+	{0x005A, 0x030C}: 0x017D, // LATIN CAPITAL LETTER Z WITH CARON
+	{0x007A, 0x030C}: 0x017E, // LATIN SMALL LETTER Z WITH CARON
+	{0x004F, 0x031B}: 0x01A0, // LATIN CAPITAL LETTER O WITH HORN
+	{0x006F, 0x031B}: 0x01A1, // LATIN SMALL LETTER O WITH HORN
+	{0x0055, 0x031B}: 0x01AF, // LATIN CAPITAL LETTER U WITH HORN
+	{0x0075, 0x031B}: 0x01B0, // LATIN SMALL LETTER U WITH HORN
+	{0x0041, 0x030C}: 0x01CD, // LATIN CAPITAL LETTER A WITH CARON
+	{0x0061, 0x030C}: 0x01CE, // LATIN SMALL LETTER A WITH CARON
+	{0x0049, 0x030C}: 0x01CF, // LATIN CAPITAL LETTER I WITH CARON
+	{0x0069, 0x030C}: 0x01D0, // LATIN SMALL LETTER I WITH CARON
+	{0x004F, 0x030C}: 0x01D1, // LATIN CAPITAL LETTER O WITH CARON
+	{0x006F, 0x030C}: 0x01D2, // LATIN SMALL LETTER O WITH CARON
+	{0x0055, 0x030C}: 0x01D3, // LATIN CAPITAL LETTER U WITH CARON
+	{0x0075, 0x030C}: 0x01D4, // LATIN SMALL LETTER U WITH CARON
+	{0x00DC, 0x0304}: 0x01D5, // LATIN CAPITAL LETTER U WITH DIAERESIS AND MACRON
+	{0x00FC, 0x0304}: 0x01D6, // LATIN SMALL LETTER U WITH DIAERESIS AND MACRON
+	{0x00DC, 0x0301}: 0x01D7, // LATIN CAPITAL LETTER U WITH DIAERESIS AND ACUTE
+	{0x00FC, 0x0301}: 0x01D8, // LATIN SMALL LETTER U WITH DIAERESIS AND ACUTE
+	{0x00DC, 0x030C}: 0x01D9, // LATIN CAPITAL LETTER U WITH DIAERESIS AND CARON
+	{0x00FC, 0x030C}: 0x01DA, // LATIN SMALL LETTER U WITH DIAERESIS AND CARON
+	{0x00DC, 0x0300}: 0x01DB, // LATIN CAPITAL LETTER U WITH DIAERESIS AND GRAVE
+	{0x00FC, 0x0300}: 0x01DC, // LATIN SMALL LETTER U WITH DIAERESIS AND GRAVE
+	{0x00C4, 0x0304}: 0x01DE, // LATIN CAPITAL LETTER A WITH DIAERESIS AND MACRON
+	{0x00E4, 0x0304}: 0x01DF, // LATIN SMALL LETTER A WITH DIAERESIS AND MACRON
+	{0x0226, 0x0304}: 0x01E0, // LATIN CAPITAL LETTER A WITH DOT ABOVE AND MACRON
+	{0x0227, 0x0304}: 0x01E1, // LATIN SMALL LETTER A WITH DOT ABOVE AND MACRON
+	{0x00C6, 0x0304}: 0x01E2, // LATIN CAPITAL LETTER AE WITH MACRON
+	{0x00E6, 0x0304}: 0x01E3, // LATIN SMALL LETTER AE WITH MACRON
+	{0x0047, 0x030C}: 0x01E6, // LATIN CAPITAL LETTER G WITH CARON
+	{0x0067, 0x030C}: 0x01E7, // LATIN SMALL LETTER G WITH CARON
+	{0x004B, 0x030C}: 0x01E8, // LATIN CAPITAL LETTER K WITH CARON
+	{0x006B, 0x030C}: 0x01E9, // LATIN SMALL LETTER K WITH CARON
+	{0x004F, 0x0328}: 0x01EA, // LATIN CAPITAL LETTER O WITH OGONEK
+	{0x006F, 0x0328}: 0x01EB, // LATIN SMALL LETTER O WITH OGONEK
+	{0x01EA, 0x0304}: 0x01EC, // LATIN CAPITAL LETTER O WITH OGONEK AND MACRON
+	{0x01EB, 0x0304}: 0x01ED, // LATIN SMALL LETTER O WITH OGONEK AND MACRON
+	{0x01B7, 0x030C}: 0x01EE, // LATIN CAPITAL LETTER EZH WITH CARON
+	{0x0292, 0x030C}: 0x01EF, // LATIN SMALL LETTER EZH WITH CARON
+	{0x006A, 0x030C}: 0x01F0, // LATIN SMALL LETTER J WITH CARON
+	{0x0047, 0x0301}: 0x01F4, // LATIN CAPITAL LETTER G WITH ACUTE
+	{0x0067, 0x0301}: 0x01F5, // LATIN SMALL LETTER G WITH ACUTE
+	{0x004E, 0x0300}: 0x01F8, // LATIN CAPITAL LETTER N WITH GRAVE
+	{0x006E, 0x0300}: 0x01F9, // LATIN SMALL LETTER N WITH GRAVE
+	{0x00C5, 0x0301}: 0x01FA, // LATIN CAPITAL LETTER A WITH RING ABOVE AND ACUTE
+	{0x00E5, 0x0301}: 0x01FB, // LATIN SMALL LETTER A WITH RING ABOVE AND ACUTE
+	{0x00C6, 0x0301}: 0x01FC, // LATIN CAPITAL LETTER AE WITH ACUTE
+	{0x00E6, 0x0301}: 0x01FD, // LATIN SMALL LETTER AE WITH ACUTE
+	{0x00D8, 0x0301}: 0x01FE, // LATIN CAPITAL LETTER O WITH STROKE AND ACUTE
+	{0x00F8, 0x0301}: 0x01FF, // LATIN SMALL LETTER O WITH STROKE AND ACUTE
+	{0x0041, 0x030F}: 0x0200, // LATIN CAPITAL LETTER A WITH DOUBLE GRAVE
+	{0x0061, 0x030F}: 0x0201, // LATIN SMALL LETTER A WITH DOUBLE GRAVE
+	{0x0041, 0x0311}: 0x0202, // LATIN CAPITAL LETTER A WITH INVERTED BREVE
+	{0x0061, 0x0311}: 0x0203, // LATIN SMALL LETTER A WITH INVERTED BREVE
+	{0x0045, 0x030F}: 0x0204, // LATIN CAPITAL LETTER E WITH DOUBLE GRAVE
+	{0x0065, 0x030F}: 0x0205, // LATIN SMALL LETTER E WITH DOUBLE GRAVE
+	{0x0045, 0x0311}: 0x0206, // LATIN CAPITAL LETTER E WITH INVERTED BREVE
+	{0x0065, 0x0311}: 0x0207, // LATIN SMALL LETTER E WITH INVERTED BREVE
+	{0x0049, 0x030F}: 0x0208, // LATIN CAPITAL LETTER I WITH DOUBLE GRAVE
+	{0x0069, 0x030F}: 0x0209, // LATIN SMALL LETTER I WITH DOUBLE GRAVE
+	{0x0049, 0x0311}: 0x020A, // LATIN CAPITAL LETTER I WITH INVERTED BREVE
+	{0x0069, 0x0311}: 0x020B, // LATIN SMALL LETTER I WITH INVERTED BREVE
+	{0x004F, 0x030F}: 0x020C, // LATIN CAPITAL LETTER O WITH DOUBLE GRAVE
+	{0x006F, 0x030F}: 0x020D, // LATIN SMALL LETTER O WITH DOUBLE GRAVE
+	{0x004F, 0x0311}: 0x020E, // LATIN CAPITAL LETTER O WITH INVERTED BREVE
+	{0x006F, 0x0311}: 0x020F, // LATIN SMALL LETTER O WITH INVERTED BREVE
+	{0x0052, 0x030F}: 0x0210, // LATIN CAPITAL LETTER R WITH DOUBLE GRAVE
+	{0x0072, 0x030F}: 0x0211, // LATIN SMALL LETTER R WITH DOUBLE GRAVE
+	{0x0052, 0x0311}: 0x0212, // LATIN CAPITAL LETTER R WITH INVERTED BREVE
+	{0x0072, 0x0311}: 0x0213, // LATIN SMALL LETTER R WITH INVERTED BREVE
+	{0x0055, 0x030F}: 0x0214, // LATIN CAPITAL LETTER U WITH DOUBLE GRAVE
+	{0x0075, 0x030F}: 0x0215, // LATIN SMALL LETTER U WITH DOUBLE GRAVE
+	{0x0055, 0x0311}: 0x0216, // LATIN CAPITAL LETTER U WITH INVERTED BREVE
+	{0x0075, 0x0311}: 0x0217, // LATIN SMALL LETTER U WITH INVERTED BREVE
+	{0x0053, 0x0326}: 0x0218, // LATIN CAPITAL LETTER S WITH COMMA BELOW
+	{0x0073, 0x0326}: 0x0219, // LATIN SMALL LETTER S WITH COMMA BELOW
+	{0x0054, 0x0326}: 0x021A, // LATIN CAPITAL LETTER T WITH COMMA BELOW
+	{0x0074, 0x0326}: 0x021B, // LATIN SMALL LETTER T WITH COMMA BELOW
+	{0x0048, 0x030C}: 0x021E, // LATIN CAPITAL LETTER H WITH CARON
+	{0x0068, 0x030C}: 0x021F, // LATIN SMALL LETTER H WITH CARON
+	{0x0041, 0x0307}: 0x0226, // LATIN CAPITAL LETTER A WITH DOT ABOVE
+	{0x0061, 0x0307}: 0x0227, // LATIN SMALL LETTER A WITH DOT ABOVE
+	{0x0045, 0x0327}: 0x0228, // LATIN CAPITAL LETTER E WITH CEDILLA
+	{0x0065, 0x0327}: 0x0229, // LATIN SMALL LETTER E WITH CEDILLA
+	{0x00D6, 0x0304}: 0x022A, // LATIN CAPITAL LETTER O WITH DIAERESIS AND MACRON
+	{0x00F6, 0x0304}: 0x022B, // LATIN SMALL LETTER O WITH DIAERESIS AND MACRON
+	{0x00D5, 0x0304}: 0x022C, // LATIN CAPITAL LETTER O WITH TILDE AND MACRON
+	{0x00F5, 0x0304}: 0x022D, // LATIN SMALL LETTER O WITH TILDE AND MACRON
+	{0x004F, 0x0307}: 0x022E, // LATIN CAPITAL LETTER O WITH DOT ABOVE
+	{0x006F, 0x0307}: 0x022F, // LATIN SMALL LETTER O WITH DOT ABOVE
+	{0x022E, 0x0304}: 0x0230, // LATIN CAPITAL LETTER O WITH DOT ABOVE AND MACRON
+	{0x022F, 0x0304}: 0x0231, // LATIN SMALL LETTER O WITH DOT ABOVE AND MACRON
+	{0x0059, 0x0304}: 0x0232, // LATIN CAPITAL LETTER Y WITH MACRON
+	{0x0079, 0x0304}: 0x0233, // LATIN SMALL LETTER Y WITH MACRON
+	{0x00A8, 0x0301}: 0x0385, // GREEK DIALYTIKA TONOS
+	{0x0391, 0x0301}: 0x0386, // GREEK CAPITAL LETTER ALPHA WITH TONOS
+	{0x0395, 0x0301}: 0x0388, // GREEK CAPITAL LETTER EPSILON WITH TONOS
+	{0x0397, 0x0301}: 0x0389, // GREEK CAPITAL LETTER ETA WITH TONOS
+	{0x0399, 0x0301}: 0x038A, // GREEK CAPITAL LETTER IOTA WITH TONOS
+	{0x039F, 0x0301}: 0x038C, // GREEK CAPITAL LETTER OMICRON WITH TONOS
+	{0x03A5, 0x0301}: 0x038E, // GREEK CAPITAL LETTER UPSILON WITH TONOS
+	{0x03A9, 0x0301}: 0x038F, // GREEK CAPITAL LETTER OMEGA WITH TONOS
+	{0x03CA, 0x0301}: 0x0390, // GREEK SMALL LETTER IOTA WITH DIALYTIKA AND TONOS
+	{0x0399, 0x0308}: 0x03AA, // GREEK CAPITAL LETTER IOTA WITH DIALYTIKA
+	{0x03A5, 0x0308}: 0x03AB, // GREEK CAPITAL LETTER UPSILON WITH DIALYTIKA
+	{0x03B1, 0x0301}: 0x03AC, // GREEK SMALL LETTER ALPHA WITH TONOS
+	{0x03B5, 0x0301}: 0x03AD, // GREEK SMALL LETTER EPSILON WITH TONOS
+	{0x03B7, 0x0301}: 0x03AE, // GREEK SMALL LETTER ETA WITH TONOS
+	{0x03B9, 0x0301}: 0x03AF, // GREEK SMALL LETTER IOTA WITH TONOS
+	{0x03CB, 0x0301}: 0x03B0, // GREEK SMALL LETTER UPSILON WITH DIALYTIKA AND TONOS
+	{0x03B9, 0x0308}: 0x03CA, // GREEK SMALL LETTER IOTA WITH DIALYTIKA
+	{0x03C5, 0x0308}: 0x03CB, // GREEK SMALL LETTER UPSILON WITH DIALYTIKA
+	{0x03BF, 0x0301}: 0x03CC, // GREEK SMALL LETTER OMICRON WITH TONOS
+	{0x03C5, 0x0301}: 0x03CD, // GREEK SMALL LETTER UPSILON WITH TONOS
+	{0x03C9, 0x0301}: 0x03CE, // GREEK SMALL LETTER OMEGA WITH TONOS
+	{0x03D2, 0x0301}: 0x03D3, // GREEK UPSILON WITH ACUTE AND HOOK SYMBOL
+	{0x03D2, 0x0308}: 0x03D4, // GREEK UPSILON WITH DIAERESIS AND HOOK SYMBOL
+	{0x0415, 0x0300}: 0x0400, // CYRILLIC CAPITAL LETTER IE WITH GRAVE
+	{0x0415, 0x0308}: 0x0401, // CYRILLIC CAPITAL LETTER IO
+	{0x0413, 0x0301}: 0x0403, // CYRILLIC CAPITAL LETTER GJE
+	{0x0406, 0x0308}: 0x0407, // CYRILLIC CAPITAL LETTER YI
+	{0x041A, 0x0301}: 0x040C, // CYRILLIC CAPITAL LETTER KJE
+	{0x0418, 0x0300}: 0x040D, // CYRILLIC CAPITAL LETTER I WITH GRAVE
+	{0x0423, 0x0306}: 0x040E, // CYRILLIC CAPITAL LETTER SHORT U
+	{0x0418, 0x0306}: 0x0419, // CYRILLIC CAPITAL LETTER SHORT I
+	{0x0438, 0x0306}: 0x0439, // CYRILLIC SMALL LETTER SHORT I
+	{0x0435, 0x0300}: 0x0450, // CYRILLIC SMALL LETTER IE WITH GRAVE
+	{0x0435, 0x0308}: 0x0451, // CYRILLIC SMALL LETTER IO
+	{0x0433, 0x0301}: 0x0453, // CYRILLIC SMALL LETTER GJE
+	{0x0456, 0x0308}: 0x0457, // CYRILLIC SMALL LETTER YI
+	{0x043A, 0x0301}: 0x045C, // CYRILLIC SMALL LETTER KJE
+	{0x0438, 0x0300}: 0x045D, // CYRILLIC SMALL LETTER I WITH GRAVE
+	{0x0443, 0x0306}: 0x045E, // CYRILLIC SMALL LETTER SHORT U
+	{0x0474, 0x030F}: 0x0476, // CYRILLIC CAPITAL LETTER IZHITSA WITH DOUBLE GRAVE ACCENT
+	{0x0475, 0x030F}: 0x0477, // CYRILLIC SMALL LETTER IZHITSA WITH DOUBLE GRAVE ACCENT
+	{0x0416, 0x0306}: 0x04C1, // CYRILLIC CAPITAL LETTER ZHE WITH BREVE
+	{0x0436, 0x0306}: 0x04C2, // CYRILLIC SMALL LETTER ZHE WITH BREVE
+	{0x0410, 0x0306}: 0x04D0, // CYRILLIC CAPITAL LETTER A WITH BREVE
+	{0x0430, 0x0306}: 0x04D1, // CYRILLIC SMALL LETTER A WITH BREVE
+	{0x0410, 0x0308}: 0x04D2, // CYRILLIC CAPITAL LETTER A WITH DIAERESIS
+	{0x0430, 0x0308}: 0x04D3, // CYRILLIC SMALL LETTER A WITH DIAERESIS
+	{0x0415, 0x0306}: 0x04D6, // CYRILLIC CAPITAL LETTER IE WITH BREVE
+	{0x0435, 0x0306}: 0x04D7, // CYRILLIC SMALL LETTER IE WITH BREVE
+	{0x04D8, 0x0308}: 0x04DA, // CYRILLIC CAPITAL LETTER SCHWA WITH DIAERESIS
+	{0x04D9, 0x0308}: 0x04DB, // CYRILLIC SMALL LETTER SCHWA WITH DIAERESIS
+	{0x0416, 0x0308}: 0x04DC, // CYRILLIC CAPITAL LETTER ZHE WITH DIAERESIS
+	{0x0436, 0x0308}: 0x04DD, // CYRILLIC SMALL LETTER ZHE WITH DIAERESIS
+	{0x0417, 0x0308}: 0x04DE, // CYRILLIC CAPITAL LETTER ZE WITH DIAERESIS
+	{0x0437, 0x0308}: 0x04DF, // CYRILLIC SMALL LETTER ZE WITH DIAERESIS
+	{0x0418, 0x0304}: 0x04E2, // CYRILLIC CAPITAL LETTER I WITH MACRON
+	{0x0438, 0x0304}: 0x04E3, // CYRILLIC SMALL LETTER I WITH MACRON
+	{0x0418, 0x0308}: 0x04E4, // CYRILLIC CAPITAL LETTER I WITH DIAERESIS
+	{0x0438, 0x0308}: 0x04E5, // CYRILLIC SMALL LETTER I WITH DIAERESIS
+	{0x041E, 0x0308}: 0x04E6, // CYRILLIC CAPITAL LETTER O WITH DIAERESIS
+	{0x043E, 0x0308}: 0x04E7, // CYRILLIC SMALL LETTER O WITH DIAERESIS
+	{0x04E8, 0x0308}: 0x04EA, // CYRILLIC CAPITAL LETTER BARRED O WITH DIAERESIS
+	{0x04E9, 0x0308}: 0x04EB, // CYRILLIC SMALL LETTER BARRED O WITH DIAERESIS
+	{0x042D, 0x0308}: 0x04EC, // CYRILLIC CAPITAL LETTER E WITH DIAERESIS
+	{0x044D, 0x0308}: 0x04ED, // CYRILLIC SMALL LETTER E WITH DIAERESIS
+	{0x0423, 0x0304}: 0x04EE, // CYRILLIC CAPITAL LETTER U WITH MACRON
+	{0x0443, 0x0304}: 0x04EF, // CYRILLIC SMALL LETTER U WITH MACRON
+	{0x0423, 0x0308}: 0x04F0, // CYRILLIC CAPITAL LETTER U WITH DIAERESIS
+	{0x0443, 0x0308}: 0x04F1, // CYRILLIC SMALL LETTER U WITH DIAERESIS
+	{0x0423, 0x030B}: 0x04F2, // CYRILLIC CAPITAL LETTER U WITH DOUBLE ACUTE
+	{0x0443, 0x030B}: 0x04F3, // CYRILLIC SMALL LETTER U WITH DOUBLE ACUTE
+	{0x0427, 0x0308}: 0x04F4, // CYRILLIC CAPITAL LETTER CHE WITH DIAERESIS
+	{0x0447, 0x0308}: 0x04F5, // CYRILLIC SMALL LETTER CHE WITH DIAERESIS
+	{0x042B, 0x0308}: 0x04F8, // CYRILLIC CAPITAL LETTER YERU WITH DIAERESIS
+	{0x044B, 0x0308}: 0x04F9, // CYRILLIC SMALL LETTER YERU WITH DIAERESIS
+	{0x0041, 0x0325}: 0x1E00, // LATIN CAPITAL LETTER A WITH RING BELOW
+	{0x0061, 0x0325}: 0x1E01, // LATIN SMALL LETTER A WITH RING BELOW
+	{0x0042, 0x0307}: 0x1E02, // LATIN CAPITAL LETTER B WITH DOT ABOVE
+	{0x0062, 0x0307}: 0x1E03, // LATIN SMALL LETTER B WITH DOT ABOVE
+	{0x0042, 0x0323}: 0x1E04, // LATIN CAPITAL LETTER B WITH DOT BELOW
+	{0x0062, 0x0323}: 0x1E05, // LATIN SMALL LETTER B WITH DOT BELOW
+	{0x0042, 0x0331}: 0x1E06, // LATIN CAPITAL LETTER B WITH LINE BELOW
+	{0x0062, 0x0331}: 0x1E07, // LATIN SMALL LETTER B WITH LINE BELOW
+	{0x00C7, 0x0301}: 0x1E08, // LATIN CAPITAL LETTER C WITH CEDILLA AND ACUTE
+	{0x00E7, 0x0301}: 0x1E09, // LATIN SMALL LETTER C WITH CEDILLA AND ACUTE
+	{0x0044, 0x0307}: 0x1E0A, // LATIN CAPITAL LETTER D WITH DOT ABOVE
+	{0x0064, 0x0307}: 0x1E0B, // LATIN SMALL LETTER D WITH DOT ABOVE
+	{0x0044, 0x0323}: 0x1E0C, // LATIN CAPITAL LETTER D WITH DOT BELOW
+	{0x0064, 0x0323}: 0x1E0D, // LATIN SMALL LETTER D WITH DOT BELOW
+	{0x0044, 0x0331}: 0x1E0E, // LATIN CAPITAL LETTER D WITH LINE BELOW
+	{0x0064, 0x0331}: 0x1E0F, // LATIN SMALL LETTER D WITH LINE BELOW
+	{0x0044, 0x0327}: 0x1E10, // LATIN CAPITAL LETTER D WITH CEDILLA
+	{0x0064, 0x0327}: 0x1E11, // LATIN SMALL LETTER D WITH CEDILLA
+	{0x0044, 0x032D}: 0x1E12, // LATIN CAPITAL LETTER D WITH CIRCUMFLEX BELOW
+	{0x0064, 0x032D}: 0x1E13, // LATIN SMALL LETTER D WITH CIRCUMFLEX BELOW
+	{0x0112, 0x0300}: 0x1E14, // LATIN CAPITAL LETTER E WITH MACRON AND GRAVE
+	{0x0113, 0x0300}: 0x1E15, // LATIN SMALL LETTER E WITH MACRON AND GRAVE
+	{0x0112, 0x0301}: 0x1E16, // LATIN CAPITAL LETTER E WITH MACRON AND ACUTE
+	{0x0113, 0x0301}: 0x1E17, // LATIN SMALL LETTER E WITH MACRON AND ACUTE
+	{0x0045, 0x032D}: 0x1E18, // LATIN CAPITAL LETTER E WITH CIRCUMFLEX BELOW
+	{0x0065, 0x032D}: 0x1E19, // LATIN SMALL LETTER E WITH CIRCUMFLEX BELOW
+	{0x0045, 0x0330}: 0x1E1A, // LATIN CAPITAL LETTER E WITH TILDE BELOW
+	{0x0065, 0x0330}: 0x1E1B, // LATIN SMALL LETTER E WITH TILDE BELOW
+	{0x0228, 0x0306}: 0x1E1C, // LATIN CAPITAL LETTER E WITH CEDILLA AND BREVE
+	{0x0229, 0x0306}: 0x1E1D, // LATIN SMALL LETTER E WITH CEDILLA AND BREVE
+	{0x0046, 0x0307}: 0x1E1E, // LATIN CAPITAL LETTER F WITH DOT ABOVE
+	{0x0066, 0x0307}: 0x1E1F, // LATIN SMALL LETTER F WITH DOT ABOVE
+	{0x0047, 0x0304}: 0x1E20, // LATIN CAPITAL LETTER G WITH MACRON
+	{0x0067, 0x0304}: 0x1E21, // LATIN SMALL LETTER G WITH MACRON
+	{0x0048, 0x0307}: 0x1E22, // LATIN CAPITAL LETTER H WITH DOT ABOVE
+	{0x0068, 0x0307}: 0x1E23, // LATIN SMALL LETTER H WITH DOT ABOVE
+	{0x0048, 0x0323}: 0x1E24, // LATIN CAPITAL LETTER H WITH DOT BELOW
+	{0x0068, 0x0323}: 0x1E25, // LATIN SMALL LETTER H WITH DOT BELOW
+	{0x0048, 0x0308}: 0x1E26, // LATIN CAPITAL LETTER H WITH DIAERESIS
+	{0x0068, 0x0308}: 0x1E27, // LATIN SMALL LETTER H WITH DIAERESIS
+	{0x0048, 0x0327}: 0x1E28, // LATIN CAPITAL LETTER H WITH CEDILLA
+	{0x0068, 0x0327}: 0x1E29, // LATIN SMALL LETTER H WITH CEDILLA
+	{0x0048, 0x032E}: 0x1E2A, // LATIN CAPITAL LETTER H WITH BREVE BELOW
+	{0x0068, 0x032E}: 0x1E2B, // LATIN SMALL LETTER H WITH BREVE BELOW
+	{0x0049, 0x0330}: 0x1E2C, // LATIN CAPITAL LETTER I WITH TILDE BELOW
+	{0x0069, 0x0330}: 0x1E2D, // LATIN SMALL LETTER I WITH TILDE BELOW
+	{0x00CF, 0x0301}: 0x1E2E, // LATIN CAPITAL LETTER I WITH DIAERESIS AND ACUTE
+	{0x00EF, 0x0301}: 0x1E2F, // LATIN SMALL LETTER I WITH DIAERESIS AND ACUTE
+	{0x004B, 0x0301}: 0x1E30, // LATIN CAPITAL LETTER K WITH ACUTE
+	{0x006B, 0x0301}: 0x1E31, // LATIN SMALL LETTER K WITH ACUTE
+	{0x004B, 0x0323}: 0x1E32, // LATIN CAPITAL LETTER K WITH DOT BELOW
+	{0x006B, 0x0323}: 0x1E33, // LATIN SMALL LETTER K WITH DOT BELOW
+	{0x004B, 0x0331}: 0x1E34, // LATIN CAPITAL LETTER K WITH LINE BELOW
+	{0x006B, 0x0331}: 0x1E35, // LATIN SMALL LETTER K WITH LINE BELOW
+	{0x004C, 0x0323}: 0x1E36, // LATIN CAPITAL LETTER L WITH DOT BELOW
+	{0x006C, 0x0323}: 0x1E37, // LATIN SMALL LETTER L WITH DOT BELOW
+	{0x1E36, 0x0304}: 0x1E38, // LATIN CAPITAL LETTER L WITH DOT BELOW AND MACRON
+	{0x1E37, 0x0304}: 0x1E39, // LATIN SMALL LETTER L WITH DOT BELOW AND MACRON
+	{0x004C, 0x0331}: 0x1E3A, // LATIN CAPITAL LETTER L WITH LINE BELOW
+	{0x006C, 0x0331}: 0x1E3B, // LATIN SMALL LETTER L WITH LINE BELOW
+	{0x004C, 0x032D}: 0x1E3C, // LATIN CAPITAL LETTER L WITH CIRCUMFLEX BELOW
+	{0x006C, 0x032D}: 0x1E3D, // LATIN SMALL LETTER L WITH CIRCUMFLEX BELOW
+	{0x004D, 0x0301}: 0x1E3E, // LATIN CAPITAL LETTER M WITH ACUTE
+	{0x006D, 0x0301}: 0x1E3F, // LATIN SMALL LETTER M WITH ACUTE
+	{0x004D, 0x0307}: 0x1E40, // LATIN CAPITAL LETTER M WITH DOT ABOVE
+	{0x006D, 0x0307}: 0x1E41, // LATIN SMALL LETTER M WITH DOT ABOVE
+	{0x004D, 0x0323}: 0x1E42, // LATIN CAPITAL LETTER M WITH DOT BELOW
+	{0x006D, 0x0323}: 0x1E43, // LATIN SMALL LETTER M WITH DOT BELOW
+	{0x004E, 0x0307}: 0x1E44, // LATIN CAPITAL LETTER N WITH DOT ABOVE
+	{0x006E, 0x0307}: 0x1E45, // LATIN SMALL LETTER N WITH DOT ABOVE
+	{0x004E, 0x0323}: 0x1E46, // LATIN CAPITAL LETTER N WITH DOT BELOW
+	{0x006E, 0x0323}: 0x1E47, // LATIN SMALL LETTER N WITH DOT BELOW
+	{0x004E, 0x0331}: 0x1E48, // LATIN CAPITAL LETTER N WITH LINE BELOW
+	{0x006E, 0x0331}: 0x1E49, // LATIN SMALL LETTER N WITH LINE BELOW
+	{0x004E, 0x032D}: 0x1E4A, // LATIN CAPITAL LETTER N WITH CIRCUMFLEX BELOW
+	{0x006E, 0x032D}: 0x1E4B, // LATIN SMALL LETTER N WITH CIRCUMFLEX BELOW
+	{0x00D5, 0x0301}: 0x1E4C, // LATIN CAPITAL LETTER O WITH TILDE AND ACUTE
+	{0x00F5, 0x0301}: 0x1E4D, // LATIN SMALL LETTER O WITH TILDE AND ACUTE
+	{0x00D5, 0x0308}: 0x1E4E, // LATIN CAPITAL LETTER O WITH TILDE AND DIAERESIS
+	{0x00F5, 0x0308}: 0x1E4F, // LATIN SMALL LETTER O WITH TILDE AND DIAERESIS
+	{0x014C, 0x0300}: 0x1E50, // LATIN CAPITAL LETTER O WITH MACRON AND GRAVE
+	{0x014D, 0x0300}: 0x1E51, // LATIN SMALL LETTER O WITH MACRON AND GRAVE
+	{0x014C, 0x0301}: 0x1E52, // LATIN CAPITAL LETTER O WITH MACRON AND ACUTE
+	{0x014D, 0x0301}: 0x1E53, // LATIN SMALL LETTER O WITH MACRON AND ACUTE
+	{0x0050, 0x0301}: 0x1E54, // LATIN CAPITAL LETTER P WITH ACUTE
+	{0x0070, 0x0301}: 0x1E55, // LATIN SMALL LETTER P WITH ACUTE
+	{0x0050, 0x0307}: 0x1E56, // LATIN CAPITAL LETTER P WITH DOT ABOVE
+	{0x0070, 0x0307}: 0x1E57, // LATIN SMALL LETTER P WITH DOT ABOVE
+	{0x0052, 0x0307}: 0x1E58, // LATIN CAPITAL LETTER R WITH DOT ABOVE
+	{0x0072, 0x0307}: 0x1E59, // LATIN SMALL LETTER R WITH DOT ABOVE
+	{0x0052, 0x0323}: 0x1E5A, // LATIN CAPITAL LETTER R WITH DOT BELOW
+	{0x0072, 0x0323}: 0x1E5B, // LATIN SMALL LETTER R WITH DOT BELOW
+	{0x1E5A, 0x0304}: 0x1E5C, // LATIN CAPITAL LETTER R WITH DOT BELOW AND MACRON
+	{0x1E5B, 0x0304}: 0x1E5D, // LATIN SMALL LETTER R WITH DOT BELOW AND MACRON
+	{0x0052, 0x0331}: 0x1E5E, // LATIN CAPITAL LETTER R WITH LINE BELOW
+	{0x0072, 0x0331}: 0x1E5F, // LATIN SMALL LETTER R WITH LINE BELOW
+	{0x0053, 0x0307}: 0x1E60, // LATIN CAPITAL LETTER S WITH DOT ABOVE
+	{0x0073, 0x0307}: 0x1E61, // LATIN SMALL LETTER S WITH DOT ABOVE
+	{0x0053, 0x0323}: 0x1E62, // LATIN CAPITAL LETTER S WITH DOT BELOW
+	{0x0073, 0x0323}: 0x1E63, // LATIN SMALL LETTER S WITH DOT BELOW
+	{0x015A, 0x0307}: 0x1E64, // LATIN CAPITAL LETTER S WITH ACUTE AND DOT ABOVE
+	{0x015B, 0x0307}: 0x1E65, // LATIN SMALL LETTER S WITH ACUTE AND DOT ABOVE
+	{0x0160, 0x0307}: 0x1E66, // LATIN CAPITAL LETTER S WITH CARON AND DOT ABOVE
+	{0x0161, 0x0307}: 0x1E67, // LATIN SMALL LETTER S WITH CARON AND DOT ABOVE
+	{0x1E62, 0x0307}: 0x1E68, // LATIN CAPITAL LETTER S WITH DOT BELOW AND DOT ABOVE
+	{0x1E63, 0x0307}: 0x1E69, // LATIN SMALL LETTER S WITH DOT BELOW AND DOT ABOVE
+	{0x0054, 0x0307}: 0x1E6A, // LATIN CAPITAL LETTER T WITH DOT ABOVE
+	{0x0074, 0x0307}: 0x1E6B, // LATIN SMALL LETTER T WITH DOT ABOVE
+	{0x0054, 0x0323}: 0x1E6C, // LATIN CAPITAL LETTER T WITH DOT BELOW
+	{0x0074, 0x0323}: 0x1E6D, // LATIN SMALL LETTER T WITH DOT BELOW
+	{0x0054, 0x0331}: 0x1E6E, // LATIN CAPITAL LETTER T WITH LINE BELOW
+	{0x0074, 0x0331}: 0x1E6F, // LATIN SMALL LETTER T WITH LINE BELOW
+	{0x0054, 0x032D}: 0x1E70, // LATIN CAPITAL LETTER T WITH CIRCUMFLEX BELOW
+	{0x0074, 0x032D}: 0x1E71, // LATIN SMALL LETTER T WITH CIRCUMFLEX BELOW
+	{0x0055, 0x0324}: 0x1E72, // LATIN CAPITAL LETTER U WITH DIAERESIS BELOW
+	{0x0075, 0x0324}: 0x1E73, // LATIN SMALL LETTER U WITH DIAERESIS BELOW
+	{0x0055, 0x0330}: 0x1E74, // LATIN CAPITAL LETTER U WITH TILDE BELOW
+	{0x0075, 0x0330}: 0x1E75, // LATIN SMALL LETTER U WITH TILDE BELOW
+	{0x0055, 0x032D}: 0x1E76, // LATIN CAPITAL LETTER U WITH CIRCUMFLEX BELOW
+	{0x0075, 0x032D}: 0x1E77, // LATIN SMALL LETTER U WITH CIRCUMFLEX BELOW
+	{0x0168, 0x0301}: 0x1E78, // LATIN CAPITAL LETTER U WITH TILDE AND ACUTE
+	{0x0169, 0x0301}: 0x1E79, // LATIN SMALL LETTER U WITH TILDE AND ACUTE
+	{0x016A, 0x0308}: 0x1E7A, // LATIN CAPITAL LETTER U WITH MACRON AND DIAERESIS
+	{0x016B, 0x0308}: 0x1E7B, // LATIN SMALL LETTER U WITH MACRON AND DIAERESIS
+	{0x0056, 0x0303}: 0x1E7C, // LATIN CAPITAL LETTER V WITH TILDE
+	{0x0076, 0x0303}: 0x1E7D, // LATIN SMALL LETTER V WITH TILDE
+	{0x0056, 0x0323}: 0x1E7E, // LATIN CAPITAL LETTER V WITH DOT BELOW
+	{0x0076, 0x0323}: 0x1E7F, // LATIN SMALL LETTER V WITH DOT BELOW
+	{0x0057, 0x0300}: 0x1E80, // LATIN CAPITAL LETTER W WITH GRAVE
+	{0x0077, 0x0300}: 0x1E81, // LATIN SMALL LETTER W WITH GRAVE
+	{0x0057, 0x0301}: 0x1E82, // LATIN CAPITAL LETTER W WITH ACUTE
+	{0x0077, 0x0301}: 0x1E83, // LATIN SMALL LETTER W WITH ACUTE
+	{0x0057, 0x0308}: 0x1E84, // LATIN CAPITAL LETTER W WITH DIAERESIS
+	{0x0077, 0x0308}: 0x1E85, // LATIN SMALL LETTER W WITH DIAERESIS
+	{0x0057, 0x0307}: 0x1E86, // LATIN CAPITAL LETTER W WITH DOT ABOVE
+	{0x0077, 0x0307}: 0x1E87, // LATIN SMALL LETTER W WITH DOT ABOVE
+	{0x0057, 0x0323}: 0x1E88, // LATIN CAPITAL LETTER W WITH DOT BELOW
+	{0x0077, 0x0323}: 0x1E89, // LATIN SMALL LETTER W WITH DOT BELOW
+	{0x0058, 0x0307}: 0x1E8A, // LATIN CAPITAL LETTER X WITH DOT ABOVE
+	{0x0078, 0x0307}: 0x1E8B, // LATIN SMALL LETTER X WITH DOT ABOVE
+	{0x0058, 0x0308}: 0x1E8C, // LATIN CAPITAL LETTER X WITH DIAERESIS
+	{0x0078, 0x0308}: 0x1E8D, // LATIN SMALL LETTER X WITH DIAERESIS
+	{0x0059, 0x0307}: 0x1E8E, // LATIN CAPITAL LETTER Y WITH DOT ABOVE
+	{0x0079, 0x0307}: 0x1E8F, // LATIN SMALL LETTER Y WITH DOT ABOVE
+	{0x005A, 0x0302}: 0x1E90, // LATIN CAPITAL LETTER Z WITH CIRCUMFLEX
+	{0x007A, 0x0302}: 0x1E91, // LATIN SMALL LETTER Z WITH CIRCUMFLEX
+	{0x005A, 0x0323}: 0x1E92, // LATIN CAPITAL LETTER Z WITH DOT BELOW
+	{0x007A, 0x0323}: 0x1E93, // LATIN SMALL LETTER Z WITH DOT BELOW
+	{0x005A, 0x0331}: 0x1E94, // LATIN CAPITAL LETTER Z WITH LINE BELOW
+	{0x007A, 0x0331}: 0x1E95, // LATIN SMALL LETTER Z WITH LINE BELOW
+	{0x0068, 0x0331}: 0x1E96, // LATIN SMALL LETTER H WITH LINE BELOW
+	{0x0074, 0x0308}: 0x1E97, // LATIN SMALL LETTER T WITH DIAERESIS
+	{0x0077, 0x030A}: 0x1E98, // LATIN SMALL LETTER W WITH RING ABOVE
+	{0x0079, 0x030A}: 0x1E99, // LATIN SMALL LETTER Y WITH RING ABOVE
+	{0x017F, 0x0307}: 0x1E9B, // LATIN SMALL LETTER LONG S WITH DOT ABOVE
+	{0x0041, 0x0323}: 0x1EA0, // LATIN CAPITAL LETTER A WITH DOT BELOW
+	{0x0061, 0x0323}: 0x1EA1, // LATIN SMALL LETTER A WITH DOT BELOW
+	{0x0041, 0x0309}: 0x1EA2, // LATIN CAPITAL LETTER A WITH HOOK ABOVE
+	{0x0061, 0x0309}: 0x1EA3, // LATIN SMALL LETTER A WITH HOOK ABOVE
+	{0x00C2, 0x0301}: 0x1EA4, // LATIN CAPITAL LETTER A WITH CIRCUMFLEX AND ACUTE
+	{0x00E2, 0x0301}: 0x1EA5, // LATIN SMALL LETTER A WITH CIRCUMFLEX AND ACUTE
+	{0x00C2, 0x0300}: 0x1EA6, // LATIN CAPITAL LETTER A WITH CIRCUMFLEX AND GRAVE
+	{0x00E2, 0x0300}: 0x1EA7, // LATIN SMALL LETTER A WITH CIRCUMFLEX AND GRAVE
+	{0x00C2, 0x0309}: 0x1EA8, // LATIN CAPITAL LETTER A WITH CIRCUMFLEX AND HOOK ABOVE
+	{0x00E2, 0x0309}: 0x1EA9, // LATIN SMALL LETTER A WITH CIRCUMFLEX AND HOOK ABOVE
+	{0x00C2, 0x0303}: 0x1EAA, // LATIN CAPITAL LETTER A WITH CIRCUMFLEX AND TILDE
+	{0x00E2, 0x0303}: 0x1EAB, // LATIN SMALL LETTER A WITH CIRCUMFLEX AND TILDE
+	{0x1EA0, 0x0302}: 0x1EAC, // LATIN CAPITAL LETTER A WITH CIRCUMFLEX AND DOT BELOW
+	{0x1EA1, 0x0302}: 0x1EAD, // LATIN SMALL LETTER A WITH CIRCUMFLEX AND DOT BELOW
+	{0x0102, 0x0301}: 0x1EAE, // LATIN CAPITAL LETTER A WITH BREVE AND ACUTE
+	{0x0103, 0x0301}: 0x1EAF, // LATIN SMALL LETTER A WITH BREVE AND ACUTE
+	{0x0102, 0x0300}: 0x1EB0, // LATIN CAPITAL LETTER A WITH BREVE AND GRAVE
+	{0x0103, 0x0300}: 0x1EB1, // LATIN SMALL LETTER A WITH BREVE AND GRAVE
+	{0x0102, 0x0309}: 0x1EB2, // LATIN CAPITAL LETTER A WITH BREVE AND HOOK ABOVE
+	{0x0103, 0x0309}: 0x1EB3, // LATIN SMALL LETTER A WITH BREVE AND HOOK ABOVE
+	{0x0102, 0x0303}: 0x1EB4, // LATIN CAPITAL LETTER A WITH BREVE AND TILDE
+	{0x0103, 0x0303}: 0x1EB5, // LATIN SMALL LETTER A WITH BREVE AND TILDE
+	{0x1EA0, 0x0306}: 0x1EB6, // LATIN CAPITAL LETTER A WITH BREVE AND DOT BELOW
+	{0x1EA1, 0x0306}: 0x1EB7, // LATIN SMALL LETTER A WITH BREVE AND DOT BELOW
+	{0x0045, 0x0323}: 0x1EB8, // LATIN CAPITAL LETTER E WITH DOT BELOW
+	{0x0065, 0x0323}: 0x1EB9, // LATIN SMALL LETTER E WITH DOT BELOW
+	{0x0045, 0x0309}: 0x1EBA, // LATIN CAPITAL LETTER E WITH HOOK ABOVE
+	{0x0065, 0x0309}: 0x1EBB, // LATIN SMALL LETTER E WITH HOOK ABOVE
+	{0x0045, 0x0303}: 0x1EBC, // LATIN CAPITAL LETTER E WITH TILDE
+	{0x0065, 0x0303}: 0x1EBD, // LATIN SMALL LETTER E WITH TILDE
+	{0x00CA, 0x0301}: 0x1EBE, // LATIN CAPITAL LETTER E WITH CIRCUMFLEX AND ACUTE
+	{0x00EA, 0x0301}: 0x1EBF, // LATIN SMALL LETTER E WITH CIRCUMFLEX AND ACUTE
+	{0x00CA, 0x0300}: 0x1EC0, // LATIN CAPITAL LETTER E WITH CIRCUMFLEX AND GRAVE
+	{0x00EA, 0x0300}: 0x1EC1, // LATIN SMALL LETTER E WITH CIRCUMFLEX AND GRAVE
+	{0x00CA, 0x0309}: 0x1EC2, // LATIN CAPITAL LETTER E WITH CIRCUMFLEX AND HOOK ABOVE
+	{0x00EA, 0x0309}: 0x1EC3, // LATIN SMALL LETTER E WITH CIRCUMFLEX AND HOOK ABOVE
+	{0x00CA, 0x0303}: 0x1EC4, // LATIN CAPITAL LETTER E WITH CIRCUMFLEX AND TILDE
+	{0x00EA, 0x0303}: 0x1EC5, // LATIN SMALL LETTER E WITH CIRCUMFLEX AND TILDE
+	{0x1EB8, 0x0302}: 0x1EC6, // LATIN CAPITAL LETTER E WITH CIRCUMFLEX AND DOT BELOW
+	{0x1EB9, 0x0302}: 0x1EC7, // LATIN SMALL LETTER E WITH CIRCUMFLEX AND DOT BELOW
+	{0x0049, 0x0309}: 0x1EC8, // LATIN CAPITAL LETTER I WITH HOOK ABOVE
+	{0x0069, 0x0309}: 0x1EC9, // LATIN SMALL LETTER I WITH HOOK ABOVE
+	{0x0049, 0x0323}: 0x1ECA, // LATIN CAPITAL LETTER I WITH DOT BELOW
+	{0x0069, 0x0323}: 0x1ECB, // LATIN SMALL LETTER I WITH DOT BELOW
+	{0x004F, 0x0323}: 0x1ECC, // LATIN CAPITAL LETTER O WITH DOT BELOW
+	{0x006F, 0x0323}: 0x1ECD, // LATIN SMALL LETTER O WITH DOT BELOW
+	{0x004F, 0x0309}: 0x1ECE, // LATIN CAPITAL LETTER O WITH HOOK ABOVE
+	{0x006F, 0x0309}: 0x1ECF, // LATIN SMALL LETTER O WITH HOOK ABOVE
+	{0x00D4, 0x0301}: 0x1ED0, // LATIN CAPITAL LETTER O WITH CIRCUMFLEX AND ACUTE
+	{0x00F4, 0x0301}: 0x1ED1, // LATIN SMALL LETTER O WITH CIRCUMFLEX AND ACUTE
+	{0x00D4, 0x0300}: 0x1ED2, // LATIN CAPITAL LETTER O WITH CIRCUMFLEX AND GRAVE
+	{0x00F4, 0x0300}: 0x1ED3, // LATIN SMALL LETTER O WITH CIRCUMFLEX AND GRAVE
+	{0x00D4, 0x0309}: 0x1ED4, // LATIN CAPITAL LETTER O WITH CIRCUMFLEX AND HOOK ABOVE
+	{0x00F4, 0x0309}: 0x1ED5, // LATIN SMALL LETTER O WITH CIRCUMFLEX AND HOOK ABOVE
+	{0x00D4, 0x0303}: 0x1ED6, // LATIN CAPITAL LETTER O WITH CIRCUMFLEX AND TILDE
+	{0x00F4, 0x0303}: 0x1ED7, // LATIN SMALL LETTER O WITH CIRCUMFLEX AND TILDE
+	{0x1ECC, 0x0302}: 0x1ED8, // LATIN CAPITAL LETTER O WITH CIRCUMFLEX AND DOT BELOW
+	{0x1ECD, 0x0302}: 0x1ED9, // LATIN SMALL LETTER O WITH CIRCUMFLEX AND DOT BELOW
+	{0x01A0, 0x0301}: 0x1EDA, // LATIN CAPITAL LETTER O WITH HORN AND ACUTE
+	{0x01A1, 0x0301}: 0x1EDB, // LATIN SMALL LETTER O WITH HORN AND ACUTE
+	{0x01A0, 0x0300}: 0x1EDC, // LATIN CAPITAL LETTER O WITH HORN AND GRAVE
+	{0x01A1, 0x0300}: 0x1EDD, // LATIN SMALL LETTER O WITH HORN AND GRAVE
+	{0x01A0, 0x0309}: 0x1EDE, // LATIN CAPITAL LETTER O WITH HORN AND HOOK ABOVE
+	{0x01A1, 0x0309}: 0x1EDF, // LATIN SMALL LETTER O WITH HORN AND HOOK ABOVE
+	{0x01A0, 0x0303}: 0x1EE0, // LATIN CAPITAL LETTER O WITH HORN AND TILDE
+	{0x01A1, 0x0303}: 0x1EE1, // LATIN SMALL LETTER O WITH HORN AND TILDE
+	{0x01A0, 0x0323}: 0x1EE2, // LATIN CAPITAL LETTER O WITH HORN AND DOT BELOW
+	{0x01A1, 0x0323}: 0x1EE3, // LATIN SMALL LETTER O WITH HORN AND DOT BELOW
+	{0x0055, 0x0323}: 0x1EE4, // LATIN CAPITAL LETTER U WITH DOT BELOW
+	{0x0075, 0x0323}: 0x1EE5, // LATIN SMALL LETTER U WITH DOT BELOW
+	{0x0055, 0x0309}: 0x1EE6, // LATIN CAPITAL LETTER U WITH HOOK ABOVE
+	{0x0075, 0x0309}: 0x1EE7, // LATIN SMALL LETTER U WITH HOOK ABOVE
+	{0x01AF, 0x0301}: 0x1EE8, // LATIN CAPITAL LETTER U WITH HORN AND ACUTE
+	{0x01B0, 0x0301}: 0x1EE9, // LATIN SMALL LETTER U WITH HORN AND ACUTE
+	{0x01AF, 0x0300}: 0x1EEA, // LATIN CAPITAL LETTER U WITH HORN AND GRAVE
+	{0x01B0, 0x0300}: 0x1EEB, // LATIN SMALL LETTER U WITH HORN AND GRAVE
+	{0x01AF, 0x0309}: 0x1EEC, // LATIN CAPITAL LETTER U WITH HORN AND HOOK ABOVE
+	{0x01B0, 0x0309}: 0x1EED, // LATIN SMALL LETTER U WITH HORN AND HOOK ABOVE
+	{0x01AF, 0x0303}: 0x1EEE, // LATIN CAPITAL LETTER U WITH HORN AND TILDE
+	{0x01B0, 0x0303}: 0x1EEF, // LATIN SMALL LETTER U WITH HORN AND TILDE
+	{0x01AF, 0x0323}: 0x1EF0, // LATIN CAPITAL LETTER U WITH HORN AND DOT BELOW
+	{0x01B0, 0x0323}: 0x1EF1, // LATIN SMALL LETTER U WITH HORN AND DOT BELOW
+	{0x0059, 0x0300}: 0x1EF2, // LATIN CAPITAL LETTER Y WITH GRAVE
+	{0x0079, 0x0300}: 0x1EF3, // LATIN SMALL LETTER Y WITH GRAVE
+	{0x0059, 0x0323}: 0x1EF4, // LATIN CAPITAL LETTER Y WITH DOT BELOW
+	{0x0079, 0x0323}: 0x1EF5, // LATIN SMALL LETTER Y WITH DOT BELOW
+	{0x0059, 0x0309}: 0x1EF6, // LATIN CAPITAL LETTER Y WITH HOOK ABOVE
+	{0x0079, 0x0309}: 0x1EF7, // LATIN SMALL LETTER Y WITH HOOK ABOVE
+	{0x0059, 0x0303}: 0x1EF8, // LATIN CAPITAL LETTER Y WITH TILDE
+	{0x0079, 0x0303}: 0x1EF9, // LATIN SMALL LETTER Y WITH TILDE
+	{0x03B1, 0x0313}: 0x1F00, // GREEK SMALL LETTER ALPHA WITH PSILI
+	{0x03B1, 0x0314}: 0x1F01, // GREEK SMALL LETTER ALPHA WITH DASIA
+	{0x1F00, 0x0300}: 0x1F02, // GREEK SMALL LETTER ALPHA WITH PSILI AND VARIA
+	{0x1F01, 0x0300}: 0x1F03, // GREEK SMALL LETTER ALPHA WITH DASIA AND VARIA
+	{0x1F00, 0x0301}: 0x1F04, // GREEK SMALL LETTER ALPHA WITH PSILI AND OXIA
+	{0x1F01, 0x0301}: 0x1F05, // GREEK SMALL LETTER ALPHA WITH DASIA AND OXIA
+	{0x1F00, 0x0342}: 0x1F06, // GREEK SMALL LETTER ALPHA WITH PSILI AND PERISPOMENI
+	{0x1F01, 0x0342}: 0x1F07, // GREEK SMALL LETTER ALPHA WITH DASIA AND PERISPOMENI
+	{0x0391, 0x0313}: 0x1F08, // GREEK CAPITAL LETTER ALPHA WITH PSILI
+	{0x0391, 0x0314}: 0x1F09, // GREEK CAPITAL LETTER ALPHA WITH DASIA
+	{0x1F08, 0x0300}: 0x1F0A, // GREEK CAPITAL LETTER ALPHA WITH PSILI AND VARIA
+	{0x1F09, 0x0300}: 0x1F0B, // GREEK CAPITAL LETTER ALPHA WITH DASIA AND VARIA
+	{0x1F08, 0x0301}: 0x1F0C, // GREEK CAPITAL LETTER ALPHA WITH PSILI AND OXIA
+	{0x1F09, 0x0301}: 0x1F0D, // GREEK CAPITAL LETTER ALPHA WITH DASIA AND OXIA
+	{0x1F08, 0x0342}: 0x1F0E, // GREEK CAPITAL LETTER ALPHA WITH PSILI AND PERISPOMENI
+	{0x1F09, 0x0342}: 0x1F0F, // GREEK CAPITAL LETTER ALPHA WITH DASIA AND PERISPOMENI
+	{0x03B5, 0x0313}: 0x1F10, // GREEK SMALL LETTER EPSILON WITH PSILI
+	{0x03B5, 0x0314}: 0x1F11, // GREEK SMALL LETTER EPSILON WITH DASIA
+	{0x1F10, 0x0300}: 0x1F12, // GREEK SMALL LETTER EPSILON WITH PSILI AND VARIA
+	{0x1F11, 0x0300}: 0x1F13, // GREEK SMALL LETTER EPSILON WITH DASIA AND VARIA
+	{0x1F10, 0x0301}: 0x1F14, // GREEK SMALL LETTER EPSILON WITH PSILI AND OXIA
+	{0x1F11, 0x0301}: 0x1F15, // GREEK SMALL LETTER EPSILON WITH DASIA AND OXIA
+	{0x0395, 0x0313}: 0x1F18, // GREEK CAPITAL LETTER EPSILON WITH PSILI
+	{0x0395, 0x0314}: 0x1F19, // GREEK CAPITAL LETTER EPSILON WITH DASIA
+	{0x1F18, 0x0300}: 0x1F1A, // GREEK CAPITAL LETTER EPSILON WITH PSILI AND VARIA
+	{0x1F19, 0x0300}: 0x1F1B, // GREEK CAPITAL LETTER EPSILON WITH DASIA AND VARIA
+	{0x1F18, 0x0301}: 0x1F1C, // GREEK CAPITAL LETTER EPSILON WITH PSILI AND OXIA
+	{0x1F19, 0x0301}: 0x1F1D, // GREEK CAPITAL LETTER EPSILON WITH DASIA AND OXIA
+	{0x03B7, 0x0313}: 0x1F20, // GREEK SMALL LETTER ETA WITH PSILI
+	{0x03B7, 0x0314}: 0x1F21, // GREEK SMALL LETTER ETA WITH DASIA
+	{0x1F20, 0x0300}: 0x1F22, // GREEK SMALL LETTER ETA WITH PSILI AND VARIA
+	{0x1F21, 0x0300}: 0x1F23, // GREEK SMALL LETTER ETA WITH DASIA AND VARIA
+	{0x1F20, 0x0301}: 0x1F24, // GREEK SMALL LETTER ETA WITH PSILI AND OXIA
+	{0x1F21, 0x0301}: 0x1F25, // GREEK SMALL LETTER ETA WITH DASIA AND OXIA
+	{0x1F20, 0x0342}: 0x1F26, // GREEK SMALL LETTER ETA WITH PSILI AND PERISPOMENI
+	{0x1F21, 0x0342}: 0x1F27, // GREEK SMALL LETTER ETA WITH DASIA AND PERISPOMENI
+	{0x0397, 0x0313}: 0x1F28, // GREEK CAPITAL LETTER ETA WITH PSILI
+	{0x0397, 0x0314}: 0x1F29, // GREEK CAPITAL LETTER ETA WITH DASIA
+	{0x1F28, 0x0300}: 0x1F2A, // GREEK CAPITAL LETTER ETA WITH PSILI AND VARIA
+	{0x1F29, 0x0300}: 0x1F2B, // GREEK CAPITAL LETTER ETA WITH DASIA AND VARIA
+	{0x1F28, 0x0301}: 0x1F2C, // GREEK CAPITAL LETTER ETA WITH PSILI AND OXIA
+	{0x1F29, 0x0301}: 0x1F2D, // GREEK CAPITAL LETTER ETA WITH DASIA AND OXIA
+	{0x1F28, 0x0342}: 0x1F2E, // GREEK CAPITAL LETTER ETA WITH PSILI AND PERISPOMENI
+	{0x1F29, 0x0342}: 0x1F2F, // GREEK CAPITAL LETTER ETA WITH DASIA AND PERISPOMENI
+	{0x03B9, 0x0313}: 0x1F30, // GREEK SMALL LETTER IOTA WITH PSILI
+	{0x03B9, 0x0314}: 0x1F31, // GREEK SMALL LETTER IOTA WITH DASIA
+	{0x1F30, 0x0300}: 0x1F32, // GREEK SMALL LETTER IOTA WITH PSILI AND VARIA
+	{0x1F31, 0x0300}: 0x1F33, // GREEK SMALL LETTER IOTA WITH DASIA AND VARIA
+	{0x1F30, 0x0301}: 0x1F34, // GREEK SMALL LETTER IOTA WITH PSILI AND OXIA
+	{0x1F31, 0x0301}: 0x1F35, // GREEK SMALL LETTER IOTA WITH DASIA AND OXIA
+	{0x1F30, 0x0342}: 0x1F36, // GREEK SMALL LETTER IOTA WITH PSILI AND PERISPOMENI
+	{0x1F31, 0x0342}: 0x1F37, // GREEK SMALL LETTER IOTA WITH DASIA AND PERISPOMENI
+	{0x0399, 0x0313}: 0x1F38, // GREEK CAPITAL LETTER IOTA WITH PSILI
+	{0x0399, 0x0314}: 0x1F39, // GREEK CAPITAL LETTER IOTA WITH DASIA
+	{0x1F38, 0x0300}: 0x1F3A, // GREEK CAPITAL LETTER IOTA WITH PSILI AND VARIA
+	{0x1F39, 0x0300}: 0x1F3B, // GREEK CAPITAL LETTER IOTA WITH DASIA AND VARIA
+	{0x1F38, 0x0301}: 0x1F3C, // GREEK CAPITAL LETTER IOTA WITH PSILI AND OXIA
+	{0x1F39, 0x0301}: 0x1F3D, // GREEK CAPITAL LETTER IOTA WITH DASIA AND OXIA
+	{0x1F38, 0x0342}: 0x1F3E, // GREEK CAPITAL LETTER IOTA WITH PSILI AND PERISPOMENI
+	{0x1F39, 0x0342}: 0x1F3F, // GREEK CAPITAL LETTER IOTA WITH DASIA AND PERISPOMENI
+	{0x03BF, 0x0313}: 0x1F40, // GREEK SMALL LETTER OMICRON WITH PSILI
+	{0x03BF, 0x0314}: 0x1F41, // GREEK SMALL LETTER OMICRON WITH DASIA
+	{0x1F40, 0x0300}: 0x1F42, // GREEK SMALL LETTER OMICRON WITH PSILI AND VARIA
+	{0x1F41, 0x0300}: 0x1F43, // GREEK SMALL LETTER OMICRON WITH DASIA AND VARIA
+	{0x1F40, 0x0301}: 0x1F44, // GREEK SMALL LETTER OMICRON WITH PSILI AND OXIA
+	{0x1F41, 0x0301}: 0x1F45, // GREEK SMALL LETTER OMICRON WITH DASIA AND OXIA
+	{0x039F, 0x0313}: 0x1F48, // GREEK CAPITAL LETTER OMICRON WITH PSILI
+	{0x039F, 0x0314}: 0x1F49, // GREEK CAPITAL LETTER OMICRON WITH DASIA
+	{0x1F48, 0x0300}: 0x1F4A, // GREEK CAPITAL LETTER OMICRON WITH PSILI AND VARIA
+	{0x1F49, 0x0300}: 0x1F4B, // GREEK CAPITAL LETTER OMICRON WITH DASIA AND VARIA
+	{0x1F48, 0x0301}: 0x1F4C, // GREEK CAPITAL LETTER OMICRON WITH PSILI AND OXIA
+	{0x1F49, 0x0301}: 0x1F4D, // GREEK CAPITAL LETTER OMICRON WITH DASIA AND OXIA
+	{0x03C5, 0x0313}: 0x1F50, // GREEK SMALL LETTER UPSILON WITH PSILI
+	{0x03C5, 0x0314}: 0x1F51, // GREEK SMALL LETTER UPSILON WITH DASIA
+	{0x1F50, 0x0300}: 0x1F52, // GREEK SMALL LETTER UPSILON WITH PSILI AND VARIA
+	{0x1F51, 0x0300}: 0x1F53, // GREEK SMALL LETTER UPSILON WITH DASIA AND VARIA
+	{0x1F50, 0x0301}: 0x1F54, // GREEK SMALL LETTER UPSILON WITH PSILI AND OXIA
+	{0x1F51, 0x0301}: 0x1F55, // GREEK SMALL LETTER UPSILON WITH DASIA AND OXIA
+	{0x1F50, 0x0342}: 0x1F56, // GREEK SMALL LETTER UPSILON WITH PSILI AND PERISPOMENI
+	{0x1F51, 0x0342}: 0x1F57, // GREEK SMALL LETTER UPSILON WITH DASIA AND PERISPOMENI
+	{0x03A5, 0x0314}: 0x1F59, // GREEK CAPITAL LETTER UPSILON WITH DASIA
+	{0x1F59, 0x0300}: 0x1F5B, // GREEK CAPITAL LETTER UPSILON WITH DASIA AND VARIA
+	{0x1F59, 0x0301}: 0x1F5D, // GREEK CAPITAL LETTER UPSILON WITH DASIA AND OXIA
+	{0x1F59, 0x0342}: 0x1F5F, // GREEK CAPITAL LETTER UPSILON WITH DASIA AND PERISPOMENI
+	{0x03C9, 0x0313}: 0x1F60, // GREEK SMALL LETTER OMEGA WITH PSILI
+	{0x03C9, 0x0314}: 0x1F61, // GREEK SMALL LETTER OMEGA WITH DASIA
+	{0x1F60, 0x0300}: 0x1F62, // GREEK SMALL LETTER OMEGA WITH PSILI AND VARIA
+	{0x1F61, 0x0300}: 0x1F63, // GREEK SMALL LETTER OMEGA WITH DASIA AND VARIA
+	{0x1F60, 0x0301}: 0x1F64, // GREEK SMALL LETTER OMEGA WITH PSILI AND OXIA
+	{0x1F61, 0x0301}: 0x1F65, // GREEK SMALL LETTER OMEGA WITH DASIA AND OXIA
+	{0x1F60, 0x0342}: 0x1F66, // GREEK SMALL LETTER OMEGA WITH PSILI AND PERISPOMENI
+	{0x1F61, 0x0342}: 0x1F67, // GREEK SMALL LETTER OMEGA WITH DASIA AND PERISPOMENI
+	{0x03A9, 0x0313}: 0x1F68, // GREEK CAPITAL LETTER OMEGA WITH PSILI
+	{0x03A9, 0x0314}: 0x1F69, // GREEK CAPITAL LETTER OMEGA WITH DASIA
+	{0x1F68, 0x0300}: 0x1F6A, // GREEK CAPITAL LETTER OMEGA WITH PSILI AND VARIA
+	{0x1F69, 0x0300}: 0x1F6B, // GREEK CAPITAL LETTER OMEGA WITH DASIA AND VARIA
+	{0x1F68, 0x0301}: 0x1F6C, // GREEK CAPITAL LETTER OMEGA WITH PSILI AND OXIA
+	{0x1F69, 0x0301}: 0x1F6D, // GREEK CAPITAL LETTER OMEGA WITH DASIA AND OXIA
+	{0x1F68, 0x0342}: 0x1F6E, // GREEK CAPITAL LETTER OMEGA WITH PSILI AND PERISPOMENI
+	{0x1F69, 0x0342}: 0x1F6F, // GREEK CAPITAL LETTER OMEGA WITH DASIA AND PERISPOMENI
+	{0x03B1, 0x0300}: 0x1F70, // GREEK SMALL LETTER ALPHA WITH VARIA
+	{0x03B5, 0x0300}: 0x1F72, // GREEK SMALL LETTER EPSILON WITH VARIA
+	{0x03B7, 0x0300}: 0x1F74, // GREEK SMALL LETTER ETA WITH VARIA
+	{0x03B9, 0x0300}: 0x1F76, // GREEK SMALL LETTER IOTA WITH VARIA
+	{0x03BF, 0x0300}: 0x1F78, // GREEK SMALL LETTER OMICRON WITH VARIA
+	{0x03C5, 0x0300}: 0x1F7A, // GREEK SMALL LETTER UPSILON WITH VARIA
+	{0x03C9, 0x0300}: 0x1F7C, // GREEK SMALL LETTER OMEGA WITH VARIA
+	{0x1F00, 0x0345}: 0x1F80, // GREEK SMALL LETTER ALPHA WITH PSILI AND YPOGEGRAMMENI
+	{0x1F01, 0x0345}: 0x1F81, // GREEK SMALL LETTER ALPHA WITH DASIA AND YPOGEGRAMMENI
+	{0x1F02, 0x0345}: 0x1F82, // GREEK SMALL LETTER ALPHA WITH PSILI AND VARIA AND YPOGEGRAMMENI
+	{0x1F03, 0x0345}: 0x1F83, // GREEK SMALL LETTER ALPHA WITH DASIA AND VARIA AND YPOGEGRAMMENI
+	{0x1F04, 0x0345}: 0x1F84, // GREEK SMALL LETTER ALPHA WITH PSILI AND OXIA AND YPOGEGRAMMENI
+	{0x1F05, 0x0345}: 0x1F85, // GREEK SMALL LETTER ALPHA WITH DASIA AND OXIA AND YPOGEGRAMMENI
+	{0x1F06, 0x0345}: 0x1F86, // GREEK SMALL LETTER ALPHA WITH PSILI AND PERISPOMENI AND YPOGEGRAMMENI
+	{0x1F07, 0x0345}: 0x1F87, // GREEK SMALL LETTER ALPHA WITH DASIA AND PERISPOMENI AND YPOGEGRAMMENI
+	{0x1F08, 0x0345}: 0x1F88, // GREEK CAPITAL LETTER ALPHA WITH PSILI AND PROSGEGRAMMENI
+	{0x1F09, 0x0345}: 0x1F89, // GREEK CAPITAL LETTER ALPHA WITH DASIA AND PROSGEGRAMMENI
+	{0x1F0A, 0x0345}: 0x1F8A, // GREEK CAPITAL LETTER ALPHA WITH PSILI AND VARIA AND PROSGEGRAMMENI
+	{0x1F0B, 0x0345}: 0x1F8B, // GREEK CAPITAL LETTER ALPHA WITH DASIA AND VARIA AND PROSGEGRAMMENI
+	{0x1F0C, 0x0345}: 0x1F8C, // GREEK CAPITAL LETTER ALPHA WITH PSILI AND OXIA AND PROSGEGRAMMENI
+	{0x1F0D, 0x0345}: 0x1F8D, // GREEK CAPITAL LETTER ALPHA WITH DASIA AND OXIA AND PROSGEGRAMMENI
+	{0x1F0E, 0x0345}: 0x1F8E, // GREEK CAPITAL LETTER ALPHA WITH PSILI AND PERISPOMENI AND PROSGEGRAMMENI
+	{0x1F0F, 0x0345}: 0x1F8F, // GREEK CAPITAL LETTER ALPHA WITH DASIA AND PERISPOMENI AND PROSGEGRAMMENI
+	{0x1F20, 0x0345}: 0x1F90, // GREEK SMALL LETTER ETA WITH PSILI AND YPOGEGRAMMENI
+	{0x1F21, 0x0345}: 0x1F91, // GREEK SMALL LETTER ETA WITH DASIA AND YPOGEGRAMMENI
+	{0x1F22, 0x0345}: 0x1F92, // GREEK SMALL LETTER ETA WITH PSILI AND VARIA AND YPOGEGRAMMENI
+	{0x1F23, 0x0345}: 0x1F93, // GREEK SMALL LETTER ETA WITH DASIA AND VARIA AND YPOGEGRAMMENI
+	{0x1F24, 0x0345}: 0x1F94, // GREEK SMALL LETTER ETA WITH PSILI AND OXIA AND YPOGEGRAMMENI
+	{0x1F25, 0x0345}: 0x1F95, // GREEK SMALL LETTER ETA WITH DASIA AND OXIA AND YPOGEGRAMMENI
+	{0x1F26, 0x0345}: 0x1F96, // GREEK SMALL LETTER ETA WITH PSILI AND PERISPOMENI AND YPOGEGRAMMENI
+	{0x1F27, 0x0345}: 0x1F97, // GREEK SMALL LETTER ETA WITH DASIA AND PERISPOMENI AND YPOGEGRAMMENI
+	{0x1F28, 0x0345}: 0x1F98, // GREEK CAPITAL LETTER ETA WITH PSILI AND PROSGEGRAMMENI
+	{0x1F29, 0x0345}: 0x1F99, // GREEK CAPITAL LETTER ETA WITH DASIA AND PROSGEGRAMMENI
+	{0x1F2A, 0x0345}: 0x1F9A, // GREEK CAPITAL LETTER ETA WITH PSILI AND VARIA AND PROSGEGRAMMENI
+	{0x1F2B, 0x0345}: 0x1F9B, // GREEK CAPITAL LETTER ETA WITH DASIA AND VARIA AND PROSGEGRAMMENI
+	{0x1F2C, 0x0345}: 0x1F9C, // GREEK CAPITAL LETTER ETA WITH PSILI AND OXIA AND PROSGEGRAMMENI
+	{0x1F2D, 0x0345}: 0x1F9D, // GREEK CAPITAL LETTER ETA WITH DASIA AND OXIA AND PROSGEGRAMMENI
+	{0x1F2E, 0x0345}: 0x1F9E, // GREEK CAPITAL LETTER ETA WITH PSILI AND PERISPOMENI AND PROSGEGRAMMENI
+	{0x1F2F, 0x0345}: 0x1F9F, // GREEK CAPITAL LETTER ETA WITH DASIA AND PERISPOMENI AND PROSGEGRAMMENI
+	{0x1F60, 0x0345}: 0x1FA0, // GREEK SMALL LETTER OMEGA WITH PSILI AND YPOGEGRAMMENI
+	{0x1F61, 0x0345}: 0x1FA1, // GREEK SMALL LETTER OMEGA WITH DASIA AND YPOGEGRAMMENI
+	{0x1F62, 0x0345}: 0x1FA2, // GREEK SMALL LETTER OMEGA WITH PSILI AND VARIA AND YPOGEGRAMMENI
+	{0x1F63, 0x0345}: 0x1FA3, // GREEK SMALL LETTER OMEGA WITH DASIA AND VARIA AND YPOGEGRAMMENI
+	{0x1F64, 0x0345}: 0x1FA4, // GREEK SMALL LETTER OMEGA WITH PSILI AND OXIA AND YPOGEGRAMMENI
+	{0x1F65, 0x0345}: 0x1FA5, // GREEK SMALL LETTER OMEGA WITH DASIA AND OXIA AND YPOGEGRAMMENI
+	{0x1F66, 0x0345}: 0x1FA6, // GREEK SMALL LETTER OMEGA WITH PSILI AND PERISPOMENI AND YPOGEGRAMMENI
+	{0x1F67, 0x0345}: 0x1FA7, // GREEK SMALL LETTER OMEGA WITH DASIA AND PERISPOMENI AND YPOGEGRAMMENI
+	{0x1F68, 0x0345}: 0x1FA8, // GREEK CAPITAL LETTER OMEGA WITH PSILI AND PROSGEGRAMMENI
+	{0x1F69, 0x0345}: 0x1FA9, // GREEK CAPITAL LETTER OMEGA WITH DASIA AND PROSGEGRAMMENI
+	{0x1F6A, 0x0345}: 0x1FAA, // GREEK CAPITAL LETTER OMEGA WITH PSILI AND VARIA AND PROSGEGRAMMENI
+	{0x1F6B, 0x0345}: 0x1FAB, // GREEK CAPITAL LETTER OMEGA WITH DASIA AND VARIA AND PROSGEGRAMMENI
+	{0x1F6C, 0x0345}: 0x1FAC, // GREEK CAPITAL LETTER OMEGA WITH PSILI AND OXIA AND PROSGEGRAMMENI
+	{0x1F6D, 0x0345}: 0x1FAD, // GREEK CAPITAL LETTER OMEGA WITH DASIA AND OXIA AND PROSGEGRAMMENI
+	{0x1F6E, 0x0345}: 0x1FAE, // GREEK CAPITAL LETTER OMEGA WITH PSILI AND PERISPOMENI AND PROSGEGRAMMENI
+	{0x1F6F, 0x0345}: 0x1FAF, // GREEK CAPITAL LETTER OMEGA WITH DASIA AND PERISPOMENI AND PROSGEGRAMMENI
+	{0x03B1, 0x0306}: 0x1FB0, // GREEK SMALL LETTER ALPHA WITH VRACHY
+	{0x03B1, 0x0304}: 0x1FB1, // GREEK SMALL LETTER ALPHA WITH MACRON
+	{0x1F70, 0x0345}: 0x1FB2, // GREEK SMALL LETTER ALPHA WITH VARIA AND YPOGEGRAMMENI
+	{0x03B1, 0x0345}: 0x1FB3, // GREEK SMALL LETTER ALPHA WITH YPOGEGRAMMENI
+	{0x03AC, 0x0345}: 0x1FB4, // GREEK SMALL LETTER ALPHA WITH OXIA AND YPOGEGRAMMENI
+	{0x03B1, 0x0342}: 0x1FB6, // GREEK SMALL LETTER ALPHA WITH PERISPOMENI
+	{0x1FB6, 0x0345}: 0x1FB7, // GREEK SMALL LETTER ALPHA WITH PERISPOMENI AND YPOGEGRAMMENI
+	{0x0391, 0x0306}: 0x1FB8, // GREEK CAPITAL LETTER ALPHA WITH VRACHY
+	{0x0391, 0x0304}: 0x1FB9, // GREEK CAPITAL LETTER ALPHA WITH MACRON
+	{0x0391, 0x0300}: 0x1FBA, // GREEK CAPITAL LETTER ALPHA WITH VARIA
+	{0x0391, 0x0345}: 0x1FBC, // GREEK CAPITAL LETTER ALPHA WITH PROSGEGRAMMENI
+	{0x00A8, 0x0342}: 0x1FC1, // GREEK DIALYTIKA AND PERISPOMENI
+	{0x1F74, 0x0345}: 0x1FC2, // GREEK SMALL LETTER ETA WITH VARIA AND YPOGEGRAMMENI
+	{0x03B7, 0x0345}: 0x1FC3, // GREEK SMALL LETTER ETA WITH YPOGEGRAMMENI
+	{0x03AE, 0x0345}: 0x1FC4, // GREEK SMALL LETTER ETA WITH OXIA AND YPOGEGRAMMENI
+	{0x03B7, 0x0342}: 0x1FC6, // GREEK SMALL LETTER ETA WITH PERISPOMENI
+	{0x1FC6, 0x0345}: 0x1FC7, // GREEK SMALL LETTER ETA WITH PERISPOMENI AND YPOGEGRAMMENI
+	{0x0395, 0x0300}: 0x1FC8, // GREEK CAPITAL LETTER EPSILON WITH VARIA
+	{0x0397, 0x0300}: 0x1FCA, // GREEK CAPITAL LETTER ETA WITH VARIA
+	{0x0397, 0x0345}: 0x1FCC, // GREEK CAPITAL LETTER ETA WITH PROSGEGRAMMENI
+	{0x1FBF, 0x0300}: 0x1FCD, // GREEK PSILI AND VARIA
+	{0x1FBF, 0x0301}: 0x1FCE, // GREEK PSILI AND OXIA
+	{0x1FBF, 0x0342}: 0x1FCF, // GREEK PSILI AND PERISPOMENI
+	{0x03B9, 0x0306}: 0x1FD0, // GREEK SMALL LETTER IOTA WITH VRACHY
+	{0x03B9, 0x0304}: 0x1FD1, // GREEK SMALL LETTER IOTA WITH MACRON
+	{0x03CA, 0x0300}: 0x1FD2, // GREEK SMALL LETTER IOTA WITH DIALYTIKA AND VARIA
+	{0x03B9, 0x0342}: 0x1FD6, // GREEK SMALL LETTER IOTA WITH PERISPOMENI
+	{0x03CA, 0x0342}: 0x1FD7, // GREEK SMALL LETTER IOTA WITH DIALYTIKA AND PERISPOMENI
+	{0x0399, 0x0306}: 0x1FD8, // GREEK CAPITAL LETTER IOTA WITH VRACHY
+	{0x0399, 0x0304}: 0x1FD9, // GREEK CAPITAL LETTER IOTA WITH MACRON
+	{0x0399, 0x0300}: 0x1FDA, // GREEK CAPITAL LETTER IOTA WITH VARIA
+	{0x1FFE, 0x0300}: 0x1FDD, // GREEK DASIA AND VARIA
+	{0x1FFE, 0x0301}: 0x1FDE, // GREEK DASIA AND OXIA
+	{0x1FFE, 0x0342}: 0x1FDF, // GREEK DASIA AND PERISPOMENI
+	{0x03C5, 0x0306}: 0x1FE0, // GREEK SMALL LETTER UPSILON WITH VRACHY
+	{0x03C5, 0x0304}: 0x1FE1, // GREEK SMALL LETTER UPSILON WITH MACRON
+	{0x03CB, 0x0300}: 0x1FE2, // GREEK SMALL LETTER UPSILON WITH DIALYTIKA AND VARIA
+	{0x03C1, 0x0313}: 0x1FE4, // GREEK SMALL LETTER RHO WITH PSILI
+	{0x03C1, 0x0314}: 0x1FE5, // GREEK SMALL LETTER RHO WITH DASIA
+	{0x03C5, 0x0342}: 0x1FE6, // GREEK SMALL LETTER UPSILON WITH PERISPOMENI
+	{0x03CB, 0x0342}: 0x1FE7, // GREEK SMALL LETTER UPSILON WITH DIALYTIKA AND PERISPOMENI
+	{0x03A5, 0x0306}: 0x1FE8, // GREEK CAPITAL LETTER UPSILON WITH VRACHY
+	{0x03A5, 0x0304}: 0x1FE9, // GREEK CAPITAL LETTER UPSILON WITH MACRON
+	{0x03A5, 0x0300}: 0x1FEA, // GREEK CAPITAL LETTER UPSILON WITH VARIA
+	{0x03A1, 0x0314}: 0x1FEC, // GREEK CAPITAL LETTER RHO WITH DASIA
+	{0x00A8, 0x0300}: 0x1FED, // GREEK DIALYTIKA AND VARIA
+	{0x1F7C, 0x0345}: 0x1FF2, // GREEK SMALL LETTER OMEGA WITH VARIA AND YPOGEGRAMMENI
+	{0x03C9, 0x0345}: 0x1FF3, // GREEK SMALL LETTER OMEGA WITH YPOGEGRAMMENI
+	{0x03CE, 0x0345}: 0x1FF4, // GREEK SMALL LETTER OMEGA WITH OXIA AND YPOGEGRAMMENI
+	{0x03C9, 0x0342}: 0x1FF6, // GREEK SMALL LETTER OMEGA WITH PERISPOMENI
+	{0x1FF6, 0x0345}: 0x1FF7, // GREEK SMALL LETTER OMEGA WITH PERISPOMENI AND YPOGEGRAMMENI
+	{0x039F, 0x0300}: 0x1FF8, // GREEK CAPITAL LETTER OMICRON WITH VARIA
+	{0x03A9, 0x0300}: 0x1FFA, // GREEK CAPITAL LETTER OMEGA WITH VARIA
+	{0x03A9, 0x0345}: 0x1FFC, // GREEK CAPITAL LETTER OMEGA WITH PROSGEGRAMMENI
+}
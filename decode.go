@@ -0,0 +1,321 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Decoding strategies: the sources of the text behind a font's
+// character codes, tried in a configurable order.
+
+package pdf
+
+import "sort"
+
+// A DecodeStrategy is a source of the text for a font's character codes.
+type DecodeStrategy int
+
+const (
+	DecodeNone      DecodeStrategy = iota // no source: codes decode as U+FFFD
+	DecodeToUnicode                       // the font's ToUnicode CMap
+	DecodeEmbedded                        // the cmap or built-in encoding of the embedded font program
+	DecodeEncoding                        // the Encoding entry: named encodings, Differences, predefined CMaps
+	DecodeRaw                             // single-byte codes taken as ISO Latin-1 characters
+)
+
+var decodeStrategyNames = [...]string{"None", "ToUnicode", "Embedded", "Encoding", "Raw"}
+
+func (s DecodeStrategy) String() string {
+	if s >= 0 && int(s) < len(decodeStrategyNames) {
+		return decodeStrategyNames[s]
+	}
+	return "DecodeStrategy(?)"
+}
+
+// DefaultDecodeOrder is the order in which the decoding strategies
+// are tried for a Reader whose DecodeOrder is nil.
+var DefaultDecodeOrder = []DecodeStrategy{DecodeToUnicode, DecodeEmbedded, DecodeEncoding, DecodeRaw}
+
+// A textSource returns the text for a code, or nil if it has none.
+// The code's CID is given for Type 0 fonts when known, and is the
+// code itself for simple fonts.
+type textSource func(code string, cid uint32, hasCID bool) []rune
+
+// A chainEncoder divides strings into codes as the font's encoding
+// dictates and decodes each code with the first of its text sources
+// that has text for it.
+type chainEncoder struct {
+	wg      WidthGrabber
+	codeLen func(raw string) int             // length of the code at the start of raw
+	cid     func(code string) (uint32, bool) // CID, for widths and CID-keyed sources
+	dw      float64                          // width of codes without a CID
+	sources []textSource
+
+	// vertical is set for Type 0 fonts in vertical writing mode,
+	// whose codes are given vertical metrics.
+	vertical bool
+}
+
+func (e *chainEncoder) Decode(raw string) (text []PositionedChar) {
+	r := make([]PositionedChar, 0, len(raw))
+	for len(raw) > 0 {
+		n := max(1, min(e.codeLen(raw), len(raw)))
+		code := raw[:n]
+		raw = raw[n:]
+		cid, ok := e.cid(code)
+		var rs []rune
+		for _, src := range e.sources {
+			if rs = src(code, cid, ok); len(rs) > 0 {
+				break
+			}
+		}
+		if len(rs) == 0 {
+			rs = []rune{noRune}
+		}
+		ch := PositionedChar{Text: rs, Width: e.dw}
+		if ok {
+			ch.Width = e.wg.Width(cid)
+		}
+		if e.vertical {
+			ch.VWidth, ch.VOrigin = -1000, Point{ch.Width / 2, 880}
+			if vg, isCID := e.wg.(CIDWidthGrabber); isCID && ok {
+				ch.VWidth, ch.VOrigin = vg.Vertical(cid)
+			}
+		}
+		r = append(r, ch)
+	}
+	return r
+}
+
+// newEncoder returns the encoder for f that tries the decoding
+// strategies in order, along with the first strategy that the font
+// supports, which is the one used for most of its text.
+func newEncoder(f Font, wg WidthGrabber, order []DecodeStrategy) (*chainEncoder, DecodeStrategy) {
+	e := &chainEncoder{wg: wg}
+	enc := f.V.Key("Encoding")
+	var toUnicode *cmapFile
+	if v := f.V.Key("ToUnicode"); v.Kind() == Stream {
+		toUnicode = readCmap(v)
+	}
+
+	var sources map[DecodeStrategy]textSource
+	if f.V.Key("Subtype").CoerceName("") == "Type0" {
+		sources = f.compositeSources(e, enc, toUnicode)
+	} else {
+		e.codeLen = func(string) int { return 1 }
+		e.cid = func(code string) (uint32, bool) { return uint32(code[0]), true }
+		sources = f.simpleSources(enc)
+	}
+	if toUnicode != nil {
+		sources[DecodeToUnicode] = func(code string, _ uint32, _ bool) []rune {
+			rs, _ := toUnicode.text(code)
+			return rs
+		}
+	}
+	sources[DecodeRaw] = func(code string, _ uint32, _ bool) []rune {
+		if len(code) != 1 {
+			return nil
+		}
+		return []rune{rune(code[0])}
+	}
+
+	used := DecodeNone
+	for _, s := range order {
+		if src := sources[s]; src != nil {
+			e.sources = append(e.sources, src)
+			if used == DecodeNone {
+				used = s
+			}
+		}
+	}
+	return e, used
+}
+
+// simpleSources returns the text sources for a simple font
+// other than its ToUnicode map.
+func (f Font) simpleSources(enc Value) map[DecodeStrategy]textSource {
+	sources := make(map[DecodeStrategy]textSource)
+
+	// The embedded program decides the text when the font has
+	// no usable encoding of its own, or is a symbolic TrueType
+	// font whose codes select glyphs directly.
+	unknown := enc.Kind() == Null || enc.Kind() == Name && namedEncoding(enc.CoerceName("")) == nil
+	switch {
+	case f.isTrueType() && (unknown || f.isSymbolic()):
+		if t := trueTypeTable(f); t != nil {
+			sources[DecodeEmbedded] = tableSource(runeTable(t))
+		} else if names := f.builtinEncoding(); names != nil && enc.Kind() == Null {
+			sources[DecodeEmbedded] = tableSource(namesTable(names, nil))
+		}
+	case enc.Kind() == Null:
+		if names := f.builtinEncoding(); names != nil {
+			sources[DecodeEmbedded] = tableSource(namesTable(names, nil))
+		}
+	}
+
+	switch enc.Kind() {
+	case Name:
+		if t := namedEncoding(enc.CoerceName("")); t != nil {
+			sources[DecodeEncoding] = tableSource(runeTable(t))
+		}
+	case Dict:
+		sources[DecodeEncoding] = tableSource(f.dictTable(enc))
+	case Null:
+		// Without an Encoding, a font uses the built-in encoding of
+		// its font program, which for the standard symbolic fonts is
+		// known, and for others is taken to be PDFDocEncoding.
+		if t := f.standardFontEncoding(); t != nil {
+			sources[DecodeEncoding] = tableSource(runeTable(t))
+		} else {
+			sources[DecodeEncoding] = tableSource(runeTable(&pdfDocEncoding))
+		}
+	}
+	return sources
+}
+
+// compositeSources sets up e to divide text into codes and CIDs
+// as the CMap of the Type 0 font f dictates, and returns the font's
+// text sources other than its ToUnicode map.
+func (f Font) compositeSources(e *chainEncoder, enc Value, toUnicode *cmapFile) map[DecodeStrategy]textSource {
+	sources := make(map[DecodeStrategy]textSource)
+	e.dw = f.descendant().Key("DW").CoerceFloat64(1000)
+	e.codeLen = func(string) int { return 2 }
+	e.cid = func(string) (uint32, bool) { return 0, false }
+	e.vertical = verticalCMap(enc)
+
+	var cm *cmapFile
+	form := ""
+	switch enc.Kind() {
+	case Name:
+		name := enc.CoerceName("")
+		switch name {
+		case "Identity-H", "Identity-V":
+			e.cid = func(code string) (uint32, bool) { return codeValue(code), true }
+		default:
+			form = unicodeCMapForm(name)
+			cm = loadCMap(name)
+		}
+	case Stream:
+		// An embedded CMap, which may build on a predefined one.
+		if m, parent, err := parseCMapFile(enc.Reader()); err == nil {
+			if parent == "" {
+				parent = enc.Key("UseCMap").CoerceName("")
+			}
+			if parent != "" {
+				m.use(loadCMap(parent))
+			}
+			cm = m
+		}
+	}
+	switch {
+	case form != "":
+		e.codeLen = func(raw string) int { return unicodeFormLen(form, raw) }
+	case cm != nil:
+		e.codeLen = func(raw string) int {
+			if n := cm.codeLen(raw); n > 0 {
+				return n
+			}
+			return cm.defaultLen()
+		}
+	case toUnicode != nil:
+		// Without the CMap, the ToUnicode map can still
+		// divide the text into codes.
+		e.codeLen = func(raw string) int {
+			if n := toUnicode.codeLen(raw); n > 0 {
+				return n
+			}
+			return toUnicode.defaultLen()
+		}
+	}
+	if cm != nil {
+		e.cid = cm.cid
+	}
+
+	if glyphs := newCIDGlyphMap(f); glyphs != nil {
+		sources[DecodeEmbedded] = func(_ string, cid uint32, ok bool) []rune {
+			if r := glyphs.rune(cid); ok && r != noRune {
+				return []rune{r}
+			}
+			return nil
+		}
+	}
+	info := f.descendant().Key("CIDSystemInfo")
+	ucs2 := loadCMap(info.Key("Registry").CoerceString("") + "-" + info.Key("Ordering").CoerceString("") + "-UCS2")
+	switch {
+	case form != "":
+		sources[DecodeEncoding] = func(code string, _ uint32, _ bool) []rune {
+			return decodeUnicodeForm(form, code)
+		}
+	case ucs2 != nil:
+		sources[DecodeEncoding] = func(_ string, cid uint32, ok bool) []rune {
+			if !ok {
+				return nil
+			}
+			rs, _ := ucs2.text(string([]byte{byte(cid >> 8), byte(cid)}))
+			return rs
+		}
+	}
+	return sources
+}
+
+// namedEncoding returns the table for the named simple font encoding,
+// or nil if the name is not one of the standard encodings.
+func namedEncoding(name string) *[256]rune {
+	switch name {
+	case "WinAnsiEncoding":
+		return &winAnsiEncoding
+	case "MacRomanEncoding":
+		return &macRomanEncoding
+	case "StandardEncoding":
+		return &standardEncoding
+	}
+	return nil
+}
+
+// runeTable converts an encoding table to a text table,
+// omitting codes that map to noRune.
+func runeTable(t *[256]rune) *[256][]rune {
+	var tt [256][]rune
+	for c, r := range t {
+		if r != noRune {
+			tt[c] = []rune{r}
+		}
+	}
+	return &tt
+}
+
+// tableSource returns the text source for a simple font's text table.
+func tableSource(t *[256][]rune) textSource {
+	return func(code string, _ uint32, _ bool) []rune {
+		return t[code[0]]
+	}
+}
+
+// A FontDecoding reports how the text of a font is decoded.
+type FontDecoding struct {
+	Font     string         // BaseFont name
+	Ref      ObjRef         // the font dictionary
+	Strategy DecodeStrategy // the first strategy available for the font
+}
+
+// DecodeReport describes the decoding of the fonts that the Reader has
+// loaded so far, such as those of the pages whose text has been read,
+// in order of their references. Fonts decoded mostly as DecodeRaw or
+// DecodeNone are likely to produce wrong text.
+func (r *Reader) DecodeReport() []FontDecoding {
+	r.fontMu.Lock()
+	var report []FontDecoding
+	for ptr, f := range r.fonts {
+		report = append(report, FontDecoding{f.V.Key("BaseFont").CoerceName(""), ObjRef{ptr.id, ptr.gen}, f.strategy})
+	}
+	r.fontMu.Unlock()
+	sort.Slice(report, func(i, j int) bool {
+		a, b := report[i].Ref, report[j].Ref
+		return a.ID < b.ID || a.ID == b.ID && a.Gen < b.Gen
+	})
+	return report
+}
+
+// DecodeStrategy returns the first decoding strategy in the Reader's
+// order that is available for the font: the one that decodes most of
+// its text. Other strategies decode codes that it has no text for.
+func (f Font) DecodeStrategy() DecodeStrategy {
+	return f.strategy
+}
@@ -0,0 +1,44 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecodeOrder(t *testing.T) {
+	// The ToUnicode map has text for A only.
+	data := fontPDF("/Subtype /Type1 /BaseFont /Test /Encoding /WinAnsiEncoding /ToUnicode 6 0 R",
+		stream("", "/CIDInit /ProcSet findresource begin 12 dict begin begincmap"+
+			" 1 begincodespacerange <00> <ff> endcodespacerange"+
+			" 1 beginbfchar <41> <0078> endbfchar endcmap end end"))
+	tests := []struct {
+		order    []DecodeStrategy
+		want     string
+		strategy DecodeStrategy
+	}{
+		{nil, "xB€", DecodeToUnicode},
+		{[]DecodeStrategy{DecodeEncoding, DecodeToUnicode}, "AB€", DecodeEncoding},
+		{[]DecodeStrategy{DecodeToUnicode}, "x��", DecodeToUnicode},
+		{[]DecodeStrategy{DecodeRaw}, "AB\u0080", DecodeRaw},
+		{[]DecodeStrategy{DecodeEmbedded}, "���", DecodeNone},
+	}
+	for _, tt := range tests {
+		r := openPDF(t, data)
+		r.DecodeOrder = tt.order
+		f := r.Page(1).Font("F1")
+		if got := decodeText(f, "AB\x80"); got != tt.want {
+			t.Errorf("order %v: text %q, want %q", tt.order, got, tt.want)
+		}
+		if s := f.DecodeStrategy(); s != tt.strategy {
+			t.Errorf("order %v: DecodeStrategy() = %v, want %v", tt.order, s, tt.strategy)
+		}
+		want := fmt.Sprint([]FontDecoding{{"Test", ObjRef{5, 0}, tt.strategy}})
+		if report := fmt.Sprint(r.DecodeReport()); report != want {
+			t.Errorf("order %v: DecodeReport() = %v, want %v", tt.order, report, want)
+		}
+	}
+}
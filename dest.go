@@ -0,0 +1,138 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Destinations (PDF 32000-1:2008, §12.3.2): the targets
+// of outline items, links and go-to actions.
+
+package pdf
+
+import "math"
+
+// A Dest is a destination: a page of the document
+// and the view of it to display.
+//
+// The view is given by Fit, one of XYZ, Fit, FitH, FitV, FitR,
+// FitB, FitBH and FitBV, and the coordinates it uses, in default user
+// space. Coordinates that the destination leaves unchanged from the
+// current view, and those its Fit does not use, are NaN.
+type Dest struct {
+	Page   int    // page number, starting at 1, or 0 if not found
+	Fit    string // type of view
+	Left   float64
+	Bottom float64
+	Right  float64
+	Top    float64
+	Zoom   float64 // magnification for XYZ, with 0 or NaN leaving it unchanged
+
+	V Value // the destination array
+}
+
+// Destination returns the named destination with the given name,
+// looking in the Dests name tree of the document's name dictionary
+// and in the document catalog's Dests dictionary, which older files use.
+// It returns ok == false if the document has no such destination.
+func (r *Reader) Destination(name string) (dest Dest, ok bool) {
+	root := r.Trailer.Key("Root")
+	if d := nameTreeLookup(root.Key("Names").Key("Dests"), name); d.Kind() != Null {
+		return destValue(d)
+	}
+	return destValue(root.Key("Dests").Key(name))
+}
+
+// resolveDest returns the destination given by d, which is either
+// an explicit destination or the name of one: a name for the catalog's
+// Dests dictionary or a string for the Dests name tree.
+func resolveDest(d Value) (Dest, bool) {
+	switch d.Kind() {
+	case Name:
+		if d.r != nil {
+			return destValue(d.r.Trailer.Key("Root").Key("Dests").Key(d.CoerceName("")))
+		}
+	case String:
+		if d.r != nil {
+			return d.r.Destination(d.CoerceString(""))
+		}
+	}
+	return explicitDest(d)
+}
+
+// destValue returns the destination given by the value d of a named
+// destination: an explicit destination or a dictionary whose D entry
+// is one.
+func destValue(d Value) (Dest, bool) {
+	if d.Kind() == Dict {
+		d = d.Key("D")
+	}
+	return explicitDest(d)
+}
+
+// explicitDest returns the destination given by d, an explicit
+// destination array, or ok == false if d is not one.
+func explicitDest(d Value) (dest Dest, ok bool) {
+	if d.Kind() != Array || d.Len() < 2 {
+		return Dest{}, false
+	}
+	nan := math.NaN()
+	dest = Dest{Fit: d.Index(1).CoerceName(""), Left: nan, Bottom: nan, Right: nan, Top: nan, Zoom: nan, V: d}
+	switch page := d.Index(0); page.Kind() {
+	case Dict:
+		dest.Page = pageNumber(page)
+	case Integer:
+		// In remote destinations, pages are numbered from 0.
+		dest.Page = int(page.CoerceInt64(-1)) + 1
+	}
+	arg := func(i int) float64 {
+		return d.Index(i).CoerceFloat64(nan)
+	}
+	switch dest.Fit {
+	case "XYZ":
+		dest.Left, dest.Top, dest.Zoom = arg(2), arg(3), arg(4)
+	case "FitH", "FitBH":
+		dest.Top = arg(2)
+	case "FitV", "FitBV":
+		dest.Left = arg(2)
+	case "FitR":
+		dest.Left, dest.Bottom, dest.Right, dest.Top = arg(2), arg(3), arg(4), arg(5)
+	}
+	return dest, true
+}
+
+// maxPageTreeDepth limits the depth of the page tree walked
+// when looking for a page.
+const maxPageTreeDepth = 64
+
+// pageNumber returns the number of the page whose dictionary is page,
+// starting at 1, or 0 if it is not in the page tree. It counts the pages
+// preceding it in each of its ancestors, using their Count entries.
+func pageNumber(page Value) int {
+	if page.Key("Type").CoerceName("Page") != "Page" {
+		return 0
+	}
+	num := 1
+	p := page
+	for depth := 0; depth < maxPageTreeDepth; depth++ {
+		parent := p.Key("Parent")
+		if parent.Kind() != Dict {
+			return num
+		}
+		kids := parent.Key("Kids")
+		found := false
+		for i := 0; i < kids.Len() && !found; i++ {
+			kid := kids.Index(i)
+			switch {
+			case kid.Ref() == p.Ref():
+				found = true
+			case kid.Key("Type").CoerceName("") == "Pages":
+				num += int(kid.Key("Count").CoerceInt64(0))
+			default:
+				num++
+			}
+		}
+		if !found {
+			return 0
+		}
+		p = parent
+	}
+	return 0
+}
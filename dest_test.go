@@ -0,0 +1,127 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// destString formats d for comparison in tests.
+func destString(d Dest) string {
+	s := fmt.Sprintf("page %d %s", d.Page, d.Fit)
+	for _, x := range []float64{d.Left, d.Bottom, d.Right, d.Top, d.Zoom} {
+		if math.IsNaN(x) {
+			s += " -"
+		} else {
+			s += fmt.Sprintf(" %g", x)
+		}
+	}
+	return s
+}
+
+// destPDF returns a document of three pages, objects 4, 5 and 6, the
+// first two in a subtree, followed by the objects extra, numbered from 7.
+func destPDF(catalog string, extra ...string) []byte {
+	return buildPDF("", append([]string{
+		"<</Type /Catalog /Pages 2 0 R " + catalog + ">>",
+		"<</Type /Pages /Kids [3 0 R 6 0 R] /Count 3>>",
+		"<</Type /Pages /Parent 2 0 R /Kids [4 0 R 5 0 R] /Count 2>>",
+		"<</Type /Page /Parent 3 0 R /MediaBox [0 0 612 792]>>",
+		"<</Type /Page /Parent 3 0 R /MediaBox [0 0 612 792]>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792]>>",
+	}, extra...)...)
+}
+
+func TestExplicitDest(t *testing.T) {
+	r := openPDF(t, destPDF("",
+		"[4 0 R /XYZ 72 720 null]",
+		"[5 0 R /FitH 500]",
+		"[6 0 R /FitR 10 20 30 40]",
+		"[6 0 R /FitBV 10]",
+		"[2 /Fit]", // in another document
+		"[7 0 R /Fit]",
+		"[4 0 R]",
+		"(not a destination)",
+	))
+	tests := []struct {
+		id   uint32
+		want string
+	}{
+		{7, "page 1 XYZ 72 - - 720 -"},
+		{8, "page 2 FitH - - - 500 -"},
+		{9, "page 3 FitR 10 20 30 40 -"},
+		{10, "page 3 FitBV 10 - - - -"},
+		{11, "page 3 Fit - - - - -"},
+		{12, "page 0 Fit - - - - -"},
+		{13, "none"},
+		{14, "none"},
+	}
+	for _, tt := range tests {
+		got := "none"
+		if d, ok := resolveDest(object(r, tt.id)); ok {
+			got = destString(d)
+		}
+		if got != tt.want {
+			t.Errorf("destination %d: %s, want %s", tt.id, got, tt.want)
+		}
+	}
+
+	// Outline items give destinations directly or by go-to actions.
+	r = openPDF(t, destPDF("/Outlines 7 0 R",
+		"<</First 8 0 R /Last 10 0 R /Count 3>>",
+		"<</Title (A) /Parent 7 0 R /Next 9 0 R /Dest [5 0 R /Fit]>>",
+		"<</Title (B) /Parent 7 0 R /Next 10 0 R /A <</S /GoTo /D [6 0 R /FitH 100]>>>>",
+		"<</Title (C) /Parent 7 0 R /A <</S /URI /URI (http://example.com/)>>>>",
+	))
+	var got []string
+	for _, c := range r.Outline().Child {
+		got = append(got, c.Title+": "+destString(c.Dest))
+	}
+	want := []string{"A: page 2 Fit - - - - -", "B: page 3 FitH - - - 100 -", "C: page 0  0 0 0 0 0"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("outline destinations:\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestDestination(t *testing.T) {
+	r := openPDF(t, destPDF("/Names <</Dests 7 0 R>> /Dests <</old [6 0 R /Fit] /both [4 0 R /Fit]>>",
+		"<</Kids [8 0 R 9 0 R]>>",
+		"<</Limits [(a) (m)] /Names [(intro) [4 0 R /Fit] (both) [5 0 R /Fit]]>>",
+		"<</Limits [(n) (z)] /Names [(summary) <</D [6 0 R /FitH 700]>>]>>",
+		"<</Type /Annot /Subtype /Link /Dest (summary)>>",
+		"<</Type /Annot /Subtype /Link /Dest /old>>",
+	))
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"intro", "page 1 Fit - - - - -"},
+		{"summary", "page 3 FitH - - - 700 -"},
+		{"old", "page 3 Fit - - - - -"},
+		// The name tree is searched first.
+		{"both", "page 2 Fit - - - - -"},
+		{"missing", "none"},
+	}
+	for _, tt := range tests {
+		got := "none"
+		if d, ok := r.Destination(tt.name); ok {
+			got = destString(d)
+		}
+		if got != tt.want {
+			t.Errorf("Destination(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// Strings name destinations in the name tree,
+	// names those in the catalog's Dests dictionary.
+	for id, want := range map[uint32]string{10: "page 3 FitH - - - 700 -", 11: "page 3 Fit - - - - -"} {
+		d, ok := resolveDest(object(r, id).Key("Dest"))
+		if got := destString(d); !ok || got != want {
+			t.Errorf("link %d: destination %s, want %s", id, got, want)
+		}
+	}
+}
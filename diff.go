@@ -0,0 +1,315 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Comparing the structure of two documents.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+)
+
+// Differences are the differences Diff finds between two documents.
+type Differences struct {
+	Added   []ObjRef       // objects of b with no counterpart in a, in order of number
+	Removed []ObjRef       // objects of a with no counterpart in b, in order of number
+	Changed []ObjectChange // objects differing from their counterparts, in the order reached
+	Pages   []int          // pages whose content differs, or which only one document has
+}
+
+// An ObjectChange is an object of one document that differs from its
+// counterpart in the other.
+type ObjectChange struct {
+	A, B  ObjRef
+	Path  string   // the path by which the objects were first reached, such as /Root/Pages/Kids[0]
+	Diffs []string // the entries that differ, such as "/Rotate: 0 != 90"
+}
+
+// Diff compares the documents a and b, as generated by two versions
+// of a program, say. It walks the objects reachable from the two
+// catalogs and information dictionaries in step, pairing the objects
+// reached by the same path, and reports the pairs that differ, the
+// objects that have no counterpart, and the pages whose content
+// differs.
+//
+// The comparison ignores how the documents are written: an object
+// compares equal to its counterpart whatever their numbers, whether
+// either is direct or indirect, whether numbers are integers or reals
+// of the same value, and whatever filters their stream data are
+// encoded with. Page content compares equal if the pages' content
+// streams hold the same operators and operands, however they are
+// split into streams and spaced.
+func Diff(a, b *Reader) (d *Differences, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			d, err = nil, fmt.Errorf("pdf: comparing: %v", e)
+		}
+	}()
+	df := &differ{
+		a:        a,
+		b:        b,
+		ab:       make(map[pdfobjptr]pdfobjptr),
+		ba:       make(map[pdfobjptr]pdfobjptr),
+		compared: make(map[[2]pdfobjptr]bool),
+		coveredA: make(map[pdfobjptr]bool),
+		coveredB: make(map[pdfobjptr]bool),
+		out:      &Differences{},
+	}
+	roots := func(r *Reader) pdfdict {
+		t, _ := r.Trailer.data.(pdfdict)
+		d := pdfdict{}
+		for _, key := range []pdfname{"Root", "Info"} {
+			if x, ok := t[key]; ok {
+				d[key] = x
+			}
+		}
+		return d
+	}
+	ta, tb := roots(a), roots(b)
+	if diffs := df.compare(ta, tb, "", ""); len(diffs) > 0 {
+		df.out.Changed = append(df.out.Changed, ObjectChange{Path: "trailer", Diffs: diffs})
+	}
+	for len(df.queue) > 0 {
+		p := df.queue[0]
+		df.queue = df.queue[1:]
+		va, vb := a.resolve(pdfobjptr{}, p.a), b.resolve(pdfobjptr{}, p.b)
+		if diffs := df.compare(va.data, vb.data, p.path, ""); len(diffs) > 0 {
+			df.out.Changed = append(df.out.Changed, ObjectChange{
+				A:     ObjRef{p.a.id, p.a.gen},
+				B:     ObjRef{p.b.id, p.b.gen},
+				Path:  p.path,
+				Diffs: diffs,
+			})
+		}
+	}
+
+	_, reachedA := (&objCopier{r: a}).reachable([]pdfobject{ta})
+	_, reachedB := (&objCopier{r: b}).reachable([]pdfobject{tb})
+	for ptr := range reachedA {
+		if _, ok := df.ab[ptr]; !ok && !df.coveredA[ptr] {
+			df.out.Removed = append(df.out.Removed, ObjRef{ptr.id, ptr.gen})
+		}
+	}
+	for ptr := range reachedB {
+		if _, ok := df.ba[ptr]; !ok && !df.coveredB[ptr] {
+			df.out.Added = append(df.out.Added, ObjRef{ptr.id, ptr.gen})
+		}
+	}
+	for _, refs := range [][]ObjRef{df.out.Removed, df.out.Added} {
+		sort.Slice(refs, func(i, j int) bool { return refs[i].ID < refs[j].ID })
+	}
+
+	for num := 1; num <= max(a.NumPage(), b.NumPage()); num++ {
+		if num > a.NumPage() || num > b.NumPage() || !sameContent(a.Page(num), b.Page(num)) {
+			df.out.Pages = append(df.out.Pages, num)
+		}
+	}
+	return df.out, nil
+}
+
+// A differ holds the state of Diff.
+type differ struct {
+	a, b     *Reader
+	ab, ba   map[pdfobjptr]pdfobjptr // the objects paired, each with the first reached by the same path
+	queue    []diffPair              // pairs of objects to compare
+	compared map[[2]pdfobjptr]bool   // pairs compared or queued
+	coveredA map[pdfobjptr]bool      // objects compared with direct objects
+	coveredB map[pdfobjptr]bool
+	out      *Differences
+}
+
+// A diffPair is a pair of objects of a and b reached by path.
+type diffPair struct {
+	a, b pdfobjptr
+	path string
+}
+
+// compare compares the direct objects x of a and y of b, found at
+// path in the objects reached by base, returning the differences
+// between them and queueing the pairs of objects they refer to.
+// A reference compares equal to the object it refers to.
+func (d *differ) compare(x, y pdfobject, base, path string) []string {
+	px, okx := x.(pdfobjptr)
+	py, oky := y.(pdfobjptr)
+	switch {
+	case okx && oky:
+		d.pair(px, py, base+path)
+		return nil
+	case okx:
+		d.coveredA[px] = true
+		x = d.a.resolve(pdfobjptr{}, px).data
+	case oky:
+		d.coveredB[py] = true
+		y = d.b.resolve(pdfobjptr{}, py).data
+	}
+
+	var diffs []string
+	switch x := x.(type) {
+	case pdfdict:
+		y, ok := y.(pdfdict)
+		if !ok {
+			break
+		}
+		return d.compareDicts(x, y, base, path, nil)
+	case pdfarray:
+		y, ok := y.(pdfarray)
+		if !ok {
+			break
+		}
+		if len(x) != len(y) {
+			diffs = append(diffs, fmt.Sprintf("%s: %d elements != %d", pathOrSelf(path), len(x), len(y)))
+		}
+		for i := 0; i < min(len(x), len(y)); i++ {
+			diffs = append(diffs, d.compare(x[i], y[i], base, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return diffs
+	case pdfstream:
+		y, ok := y.(pdfstream)
+		if !ok {
+			break
+		}
+		diffs = d.compareDicts(x.hdr, y.hdr, base, path, map[pdfname]bool{"Length": true, "Filter": true, "DecodeParms": true, "DL": true})
+		da, erra := streamData(Value{r: d.a, data: x})
+		db, errb := streamData(Value{r: d.b, data: y})
+		if erra != nil || errb != nil || !bytes.Equal(da, db) {
+			diffs = append(diffs, fmt.Sprintf("%s: stream data differs", pathOrSelf(path)))
+		}
+		return diffs
+	case int64:
+		if n, ok := number(y); ok && float64(x) == n {
+			return nil
+		}
+	case float64:
+		if n, ok := number(y); ok && x == n {
+			return nil
+		}
+	default:
+		if x == y {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("%s: %s != %s", pathOrSelf(path), diffValue(x), diffValue(y))}
+}
+
+// compareDicts compares the dictionaries x of a and y of b, as
+// compare does, except for the entries skipped.
+func (d *differ) compareDicts(x, y pdfdict, base, path string, skip map[pdfname]bool) []string {
+	keys := make(map[pdfname]bool)
+	for k := range x {
+		keys[k] = true
+	}
+	for k := range y {
+		keys[k] = true
+	}
+	var sorted []string
+	for k := range keys {
+		if !skip[k] {
+			sorted = append(sorted, string(k))
+		}
+	}
+	sort.Strings(sorted)
+	var diffs []string
+	for _, k := range sorted {
+		diffs = append(diffs, d.compare(x[pdfname(k)], y[pdfname(k)], base, path+"/"+k)...)
+	}
+	return diffs
+}
+
+// pair queues the comparison of the object x of a and y of b,
+// reached by path, pairing them unless either is already paired.
+func (d *differ) pair(x, y pdfobjptr, path string) {
+	if d.compared[[2]pdfobjptr{x, y}] {
+		return
+	}
+	d.compared[[2]pdfobjptr{x, y}] = true
+	if _, ok := d.ab[x]; !ok {
+		d.ab[x] = y
+	}
+	if _, ok := d.ba[y]; !ok {
+		d.ba[y] = x
+	}
+	d.queue = append(d.queue, diffPair{x, y, path})
+}
+
+// streamData returns the decoded data of the stream v.
+func streamData(v Value) (data []byte, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			data, err = nil, fmt.Errorf("%v", e)
+		}
+	}()
+	return io.ReadAll(v.Reader())
+}
+
+// number returns the value of x if it is a number.
+func number(x pdfobject) (float64, bool) {
+	switch x := x.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// diffValue describes the direct object x for a difference:
+// a scalar as written, a dictionary, array or stream by its kind.
+func diffValue(x pdfobject) string {
+	switch x.(type) {
+	case pdfdict:
+		return "dict"
+	case pdfarray:
+		return "array"
+	case pdfstream:
+		return "stream"
+	case nil:
+		return "null"
+	}
+	s := objfmt(x)
+	if len(s) > 40 {
+		s = s[:37] + "..."
+	}
+	return s
+}
+
+// sameContent reports whether the pages p and q have the same
+// content operators and operands or, if either's content cannot be
+// parsed, the same content data.
+func sameContent(p, q Page) bool {
+	opsP, okP := contentOps(p)
+	opsQ, okQ := contentOps(q)
+	if okP && okQ {
+		return slices.Equal(opsP, opsQ)
+	}
+	dataP, errP := io.ReadAll(p.ContentsReader())
+	dataQ, errQ := io.ReadAll(q.ContentsReader())
+	return errP == nil && errQ == nil && bytes.Equal(dataP, dataQ)
+}
+
+// contentOps returns the operators of the content of the page p,
+// each with its operands, as written by Save, or ok == false if
+// the content cannot be parsed.
+func contentOps(p Page) (ops []string, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ops, ok = nil, false
+		}
+	}()
+	interpret(p.ContentsReader(), func(stk *Stack, op string) {
+		args := make([]Value, stk.Len())
+		for i := len(args) - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+		var b []byte
+		for _, arg := range args {
+			b = append(appendObject(b, arg.data), ' ')
+		}
+		ops = append(ops, string(b)+op)
+	})
+	return ops, true
+}
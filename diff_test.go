@@ -0,0 +1,76 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	diff := func(a, b []byte) *Differences {
+		t.Helper()
+		d, err := Diff(openPDF(t, a), openPDF(t, b))
+		if err != nil {
+			t.Fatalf("Diff: %v", err)
+		}
+		return d
+	}
+	plain := threePages(t, false)
+
+	// The same document, written differently.
+	var saved bytes.Buffer
+	if err := openPDF(t, plain).Save(&saved); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range [][]byte{plain, threePages(t, true), saved.Bytes()} {
+		if d := diff(plain, b); d.Added != nil || d.Removed != nil || d.Changed != nil || d.Pages != nil {
+			t.Errorf("same document differs: %+v", d)
+		}
+	}
+
+	// Rotating a page changes its object, not its content; annotating
+	// one adds objects.
+	r := openPDF(t, plain)
+	r.Page(2).V.SetKey("Rotate", NewInt(90))
+	annot := r.Add(r.NewDict())
+	annot.SetKey("Type", NewName("Annot"))
+	annot.SetKey("Subtype", NewName("Text"))
+	annot.SetKey("Rect", r.NewArray(NewInt(0), NewInt(0), NewInt(10), NewInt(10)))
+	r.Page(3).V.SetKey("Annots", r.NewArray(annot))
+	var edited bytes.Buffer
+	if err := r.Save(&edited); err != nil {
+		t.Fatal(err)
+	}
+	d := diff(plain, edited.Bytes())
+	if len(d.Changed) != 2 || d.Removed != nil || len(d.Added) != 1 || d.Pages != nil {
+		t.Fatalf("edited: %+v", d)
+	}
+	got := fmt.Sprint(d.Changed[0].Path, d.Changed[0].Diffs, d.Changed[1].Path, d.Changed[1].Diffs)
+	if want := fmt.Sprint("/Root/Pages/Kids[1]", []string{"/Rotate: null != 90"}, "/Root/Pages/Kids[2]", []string{"/Annots: null != array"}); got != want {
+		t.Errorf("edited: changes %s, want %s", got, want)
+	}
+
+	// Changing the text changes the page content.
+	other := bytes.Replace(plain, []byte("(two)"), []byte("(2to)"), 1)
+	if d := diff(plain, other); fmt.Sprint(d.Pages) != "[2]" || len(d.Changed) != 1 || d.Changed[0].Diffs[0] != "object: stream data differs" {
+		t.Errorf("changed text: %+v", d)
+	}
+
+	// A missing page is added to the differing pages.
+	var two bytes.Buffer
+	w := NewWriter(&two)
+	w.Compress = false
+	for _, s := range []string{"one", "two"} {
+		w.NewPage(612, 792).ShowText(72, 700, s)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if d := diff(plain, two.Bytes()); fmt.Sprint(d.Pages) != "[3]" || d.Removed == nil || d.Added != nil {
+		t.Errorf("page removed: %+v", d)
+	}
+}
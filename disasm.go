@@ -0,0 +1,219 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Printing content streams for people to read.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Disassemble writes the content stream strm to w for people to read:
+// an operator per line, after its operands, indented by the nesting
+// of q and Q, BT and ET, and marked-content operators. Strings are
+// written as literal strings if they are printable ASCII and as
+// hexadecimal strings otherwise, and the text that text-showing
+// operators show is given in a comment, decoded with the current font.
+// Names of resources are resolved in a comment, such as
+//
+//	/F1 12 Tf % /F1 = 6 0 R Type1 font Helvetica
+//
+// strm may also be a page dictionary, whose content streams are then
+// disassembled as one. The resources are those of the page, or those
+// of strm if it is a form XObject or another stream with Resources.
+func Disassemble(w io.Writer, strm Value) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("pdf: disassembling: %v", e)
+		}
+	}()
+	var rd io.Reader
+	var res Value
+	switch strm.Kind() {
+	case Stream:
+		rd = strm.Reader()
+		res = strm.Key("Resources")
+	case Dict:
+		p := Page{V: strm}
+		rd = p.ContentsReader()
+		res = p.Resources()
+	default:
+		return fmt.Errorf("pdf: disassembling: not a content stream or page")
+	}
+
+	d := &disassembler{w: w, res: res}
+	interpret(rd, d.op)
+	return d.err
+}
+
+// A disassembler holds the state of Disassemble.
+type disassembler struct {
+	w     io.Writer
+	err   error // the first error writing to w
+	res   Value
+	depth int    // nesting of q, BT and marked content
+	font  Font   // the current font
+	fonts []Font // the fonts saved by q
+}
+
+// resourceOperand gives, for the operators naming resources, the
+// category of resources named and the index of the operand naming
+// one, counting back from the last operand.
+var resourceOperand = map[string]struct {
+	category string
+	index    int
+}{
+	"Tf":  {"Font", 1},
+	"Do":  {"XObject", 0},
+	"gs":  {"ExtGState", 0},
+	"cs":  {"ColorSpace", 0},
+	"CS":  {"ColorSpace", 0},
+	"sh":  {"Shading", 0},
+	"scn": {"Pattern", 0},
+	"SCN": {"Pattern", 0},
+	"BDC": {"Properties", 0},
+	"DP":  {"Properties", 0},
+}
+
+// op writes the operator op with its operands on stk.
+func (d *disassembler) op(stk *Stack, op string) {
+	args := make([]Value, stk.Len())
+	for i := len(args) - 1; i >= 0; i-- {
+		args[i] = stk.Pop()
+	}
+
+	switch op {
+	case "Q", "ET", "EMC":
+		d.depth = max(d.depth-1, 0)
+	}
+	b := []byte(strings.Repeat("  ", d.depth))
+	var comments []string
+	if op == "BI" && len(args) == 2 {
+		b = append(appendObject(append(b, "BI "...), args[0].data), " ID EI"...)
+		comments = append(comments, fmt.Sprintf("%d bytes of image data", len(args[1].CoerceString(""))))
+	} else {
+		for _, arg := range args {
+			b = append(appendOperand(b, arg.data), ' ')
+		}
+		b = append(b, op...)
+	}
+
+	switch op {
+	case "q", "BT", "BMC", "BDC":
+		d.depth++
+	}
+	switch op {
+	case "q":
+		d.fonts = append(d.fonts, d.font)
+	case "Q":
+		if n := len(d.fonts); n > 0 {
+			d.font, d.fonts = d.fonts[n-1], d.fonts[:n-1]
+		}
+	case "Tf":
+		if len(args) == 2 {
+			d.font = fontEntry(d.res.Key("Font"), args[0].CoerceName(""))
+		}
+	case "Tj", "'", "\"", "TJ":
+		var raw string
+		if len(args) > 0 {
+			s := args[len(args)-1]
+			if s.Kind() == String {
+				raw = s.CoerceString("")
+			}
+			for i := 0; i < s.Len(); i++ {
+				raw += s.Index(i).CoerceString("")
+			}
+		}
+		var text []rune
+		for _, c := range d.font.Decode(raw) {
+			text = append(text, c.Text...)
+		}
+		comments = append(comments, fmt.Sprintf("%q", string(text)))
+	}
+	if ro, ok := resourceOperand[op]; ok && ro.index < len(args) {
+		if name := args[len(args)-1-ro.index]; name.Kind() == Name {
+			if c := describeResource(ro.category, d.res.Key(ro.category), name.CoerceName("")); c != "" {
+				comments = append(comments, c)
+			}
+		}
+	}
+
+	if len(comments) > 0 {
+		b = append(append(b, " % "...), strings.Join(comments, "; ")...)
+	}
+	if d.err == nil {
+		_, d.err = d.w.Write(append(b, '\n'))
+	}
+}
+
+// describeResource describes the resource named name in dict,
+// a resource dictionary of the given category, for a comment.
+// It returns "" for the names of color spaces that are not resources.
+func describeResource(category string, dict Value, name string) string {
+	if category == "ColorSpace" && lookupColorSpace(Value{}, name).Kind() == Name {
+		return ""
+	}
+	s := "/" + name + " ="
+	v := dict.Key(name)
+	if v.Kind() == Null {
+		return s + " missing"
+	}
+	if ptr, ok := dict.entryRef(name); ok {
+		s += fmt.Sprintf(" %d %d R", ptr.id, ptr.gen)
+	}
+	switch category {
+	case "Font":
+		return s + fmt.Sprintf(" %s font %s", v.Key("Subtype").CoerceName(""), v.Key("BaseFont").CoerceName(""))
+	case "XObject":
+		switch sub := v.Key("Subtype").CoerceName(""); sub {
+		case "Image":
+			return s + fmt.Sprintf(" image %dx%d %s", v.Key("Width").CoerceInt64(0), v.Key("Height").CoerceInt64(0), colorSpaceFamily(v.Key("ColorSpace")))
+		default:
+			return s + " " + strings.ToLower(sub)
+		}
+	case "ColorSpace":
+		return s + " " + colorSpaceFamily(v)
+	case "Shading":
+		return s + fmt.Sprintf(" shading type %d", v.Key("ShadingType").CoerceInt64(0))
+	case "Pattern":
+		if v.Key("PatternType").CoerceInt64(0) == 2 {
+			return s + " shading pattern"
+		}
+		return s + " tiling pattern"
+	}
+	desc := objfmt(v.data)
+	if strm, ok := v.data.(pdfstream); ok {
+		desc = objfmt(strm.hdr)
+	}
+	if len(desc) > 60 {
+		desc = desc[:57] + "..."
+	}
+	return s + " " + desc
+}
+
+// appendOperand appends the content stream syntax for the operand x
+// to b, writing strings that are not printable ASCII in hexadecimal.
+func appendOperand(b []byte, x pdfobject) []byte {
+	switch x := x.(type) {
+	case string:
+		for i := 0; i < len(x); i++ {
+			if x[i] < ' ' || x[i] >= 0x7f {
+				return fmt.Appendf(b, "<%x>", x)
+			}
+		}
+	case pdfarray:
+		b = append(b, '[')
+		for i, y := range x {
+			if i > 0 {
+				b = append(b, ' ')
+			}
+			b = appendOperand(b, y)
+		}
+		return append(b, ']')
+	}
+	return appendObject(b, x)
+}
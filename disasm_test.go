@@ -0,0 +1,59 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	data := pagePDF("<</Font <</F1 5 0 R>> /XObject <</Im0 6 0 R>> /ColorSpace <</CS0 [/ICCBased 7 0 R]>>>>",
+		"q 1 0 0 1 10 10 cm/Im0 Do Q\n"+
+			"/CS0 cs 0.5 scn /DeviceRGB CS\n"+
+			"BT /F1 12 Tf 72 700 Td [(Hel) -20 (lo)] TJ <00ff> Tj ET\n"+
+			"BI /W 1 /H 1 /BPC 8 /CS /G ID \x80 EI\n"+
+			"/Missing gs",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>",
+		stream("/Type /XObject /Subtype /Image /Width 2 /Height 3 /ColorSpace /DeviceGray /BitsPerComponent 8", "\x00\x00\x00\x00\x00\x00"),
+		stream("/N 1", ""),
+	)
+	r := openPDF(t, data)
+	want := `q
+  1 0 0 1 10 10 cm
+  /Im0 Do % /Im0 = 6 0 R image 2x3 DeviceGray
+Q
+/CS0 cs % /CS0 = ICCBased
+0.5 scn
+/DeviceRGB CS
+BT
+  /F1 12 Tf % /F1 = 5 0 R Type1 font Helvetica
+  72 700 Td
+  [(Hel) -20 (lo)] TJ % "Hello"
+  <00ff> Tj % "\x00ÿ"
+ET
+BI <</BitsPerComponent 8/ColorSpace /DeviceGray/Height 1/Width 1>> ID EI % 1 bytes of image data
+/Missing gs % /Missing = missing
+`
+	var buf bytes.Buffer
+	if err := Disassemble(&buf, r.Page(1).V); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("Disassemble(page) =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// A content stream alone has no resources.
+	buf.Reset()
+	if err := Disassemble(&buf, r.Page(1).V.Key("Contents")); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("/Im0 Do % /Im0 = missing\n")) {
+		t.Errorf("Disassemble(stream) =\n%s", buf.String())
+	}
+	if err := Disassemble(&buf, NewInt(1)); err == nil {
+		t.Errorf("Disassemble(1) succeeded")
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Changing the objects of a document and creating new ones,
+// for writing with Reader.Save.
+
+package pdf
+
+import "fmt"
+
+// A Reader keeps the objects it has resolved, so that every Value for
+// an object shares its data and changes made through one are seen
+// through the others and written by Save.
+
+// cachedObject returns the object ptr, if it has been resolved or created.
+func (r *Reader) cachedObject(ptr pdfobjptr) (pdfobject, bool) {
+	r.objMu.Lock()
+	defer r.objMu.Unlock()
+	obj, ok := r.objs[ptr]
+	return obj, ok
+}
+
+// exists reports whether the document has the object ptr: whether it
+// has been resolved or created, or the cross-reference table lists it.
+func (r *Reader) exists(ptr pdfobjptr) bool {
+	if _, ok := r.cachedObject(ptr); ok {
+		return true
+	}
+	if ptr.id >= uint32(len(r.xref)) {
+		return false
+	}
+	x := r.xref[ptr.id]
+	return x.ptr == ptr && (x.inStream || x.offset != 0)
+}
+
+// cacheObject records obj as the object ptr.
+func (r *Reader) cacheObject(ptr pdfobjptr, obj pdfobject) {
+	r.objMu.Lock()
+	defer r.objMu.Unlock()
+	if r.objs == nil {
+		r.objs = make(map[pdfobjptr]pdfobject)
+	}
+	r.objs[ptr] = obj
+}
+
+// markDirty records that the object ptr has changed.
+func (r *Reader) markDirty(ptr pdfobjptr) {
+	if ptr == (pdfobjptr{}) {
+		return // a direct object not yet stored in any indirect one
+	}
+	r.objMu.Lock()
+	if r.dirty == nil {
+		r.dirty = make(map[pdfobjptr]bool)
+	}
+	r.dirty[ptr] = true
+	r.objMu.Unlock()
+	r.uncacheFonts()
+	r.uncachePages()
+	r.streams.clear()
+}
+
+// newObject records obj as a new object and returns its number.
+func (r *Reader) newObject(obj pdfobject) pdfobjptr {
+	r.objMu.Lock()
+	if r.nextID == 0 {
+		r.nextID = uint32(max(len(r.xref), 1))
+	}
+	ptr := pdfobjptr{r.nextID, 0}
+	r.nextID++
+	r.objMu.Unlock()
+	r.cacheObject(ptr, obj)
+	r.markDirty(ptr)
+	return ptr
+}
+
+// NewDict returns a new, empty dictionary, to be filled with SetKey
+// and stored in the document by setting it as an entry of another
+// object or by Add.
+func (r *Reader) NewDict() Value {
+	return Value{r: r, data: pdfdict{}}
+}
+
+// NewArray returns a new array holding elems, stored as SetKey
+// stores its val. If an element is from another document, the
+// returned Value holds the error.
+func (r *Reader) NewArray(elems ...Value) Value {
+	a := make(pdfarray, len(elems))
+	for i, e := range elems {
+		x, err := r.objectFor(e)
+		if err != nil {
+			return Value{err: err}
+		}
+		a[i] = x
+	}
+	return Value{r: r, data: a}
+}
+
+// NewStream returns a new stream with the header dictionary hdr,
+// which may be a null Value for an empty one, and the contents data,
+// which must be encoded as hdr's Filter entry says. The stream is an
+// indirect object of the document, like those added by Add.
+func (r *Reader) NewStream(hdr Value, data []byte) Value {
+	d, ok := hdr.data.(pdfdict)
+	if !ok {
+		d = pdfdict{}
+	}
+	d["Length"] = int64(len(data))
+	strm := pdfstream{hdr: d, mem: true, data: data}
+	strm.ptr = r.newObject(nil)
+	r.cacheObject(strm.ptr, strm)
+	return Value{r, strm.ptr, strm, nil, true}
+}
+
+// Add adds v to the document as a new indirect object and returns
+// the Value for that object. Setting the returned Value as an entry
+// of another object stores a reference to it, so that several
+// objects can share it. Streams are already indirect objects and
+// are returned unchanged. If v is from another document, the
+// returned Value holds the error.
+func (r *Reader) Add(v Value) Value {
+	if v.r != nil && v.r != r {
+		return Value{err: errForeign(v)}
+	}
+	if _, ok := v.data.(pdfstream); ok {
+		return v
+	}
+	ptr := r.newObject(v.data)
+	return Value{r, ptr, v.data, nil, true}
+}
+
+// NewName returns a Value for the name s.
+func NewName(s string) Value { return Value{data: pdfname(s)} }
+
+// NewString returns a Value for the string of bytes s.
+func NewString(s string) Value { return Value{data: s} }
+
+// NewText returns a Value for the text string s, encoded as
+// PDFDocEncoding or UTF-16 as needed, for entries such as Title.
+func NewText(s string) Value { return Value{data: encodeTextString(s)} }
+
+// NewInt returns a Value for the integer i.
+func NewInt(i int64) Value { return Value{data: i} }
+
+// NewReal returns a Value for the real number x.
+func NewReal(x float64) Value { return Value{data: x} }
+
+// NewBool returns a Value for the boolean b.
+func NewBool(b bool) Value { return Value{data: b} }
+
+// SetKey sets the entry key of the dictionary or stream v to val,
+// or deletes the entry if val is null. If val is an indirect object
+// of the same document, as returned by Add, NewStream or the document's
+// references, the entry refers to it; otherwise val is stored in v
+// directly. Values of another document cannot be set, as their
+// references would name objects of this one; SetKey returns an error
+// for them. Changes are seen through all Values for v and written by
+// Save. A Reader is not safe for use by other goroutines while its
+// objects are being changed.
+func (v Value) SetKey(key string, val Value) error {
+	var d pdfdict
+	switch x := v.data.(type) {
+	case pdfdict:
+		d = x
+	case pdfstream:
+		d = x.hdr
+	default:
+		return fmt.Errorf("pdf: SetKey on %v, not a dictionary", v.Kind())
+	}
+	x, err := v.r.objectFor(val)
+	if err != nil {
+		return err
+	}
+	if val.Kind() == Null {
+		delete(d, pdfname(key))
+	} else {
+		d[pdfname(key)] = x
+	}
+	v.r.markDirty(v.ptr)
+	return nil
+}
+
+// SetIndex sets the element i of the array v to val,
+// storing it as SetKey does.
+func (v Value) SetIndex(i int, val Value) error {
+	a, ok := v.data.(pdfarray)
+	if !ok {
+		return fmt.Errorf("pdf: SetIndex on %v, not an array", v.Kind())
+	}
+	if i < 0 || i >= len(a) {
+		return fmt.Errorf("pdf: SetIndex %d out of range [0, %d)", i, len(a))
+	}
+	x, err := v.r.objectFor(val)
+	if err != nil {
+		return err
+	}
+	a[i] = x
+	v.r.markDirty(v.ptr)
+	return nil
+}
+
+// objectFor returns the object to store in another to hold val:
+// a reference if val is an indirect object of r, and val's data if not.
+// It returns an error if val is from another document, or holds one.
+func (r *Reader) objectFor(val Value) (pdfobject, error) {
+	if val.err != nil {
+		return nil, val.err
+	}
+	if val.r != nil && val.r != r {
+		return nil, errForeign(val)
+	}
+	if s, ok := val.data.(pdfstream); ok {
+		return s.ptr, nil
+	}
+	if val.indirect {
+		return val.ptr, nil
+	}
+	return val.data, nil
+}
+
+// errForeign returns the error for storing val, from another document.
+func errForeign(val Value) error {
+	return fmt.Errorf("pdf: %v is from another document", val.Kind())
+}
@@ -0,0 +1,188 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// save returns the document r saved and read again.
+func save(t *testing.T, r *Reader) *Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := r.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return openPDF(t, buf.Bytes())
+}
+
+func TestEdit(t *testing.T) {
+	// Object 6 is used by nothing.
+	r := openPDF(t, pagePDF("<</Font <</F1 5 0 R>>>>", show(72, 700, "hello"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Unused true>>"))
+	cat := r.Trailer.Key("Root")
+	page := r.Page(1).V
+
+	cat.SetKey("PageMode", NewName("UseOutlines"))
+	cat.SetKey("Lang", NewText("en"))
+	cat.SetKey("Lang", Value{})
+	page.Key("MediaBox").SetIndex(2, NewInt(300))
+	page.SetKey("UserUnit", NewReal(1.5))
+
+	// A dictionary added once is shared by the entries set to it;
+	// one set directly is copied into each.
+	shared := r.NewDict()
+	shared.SetKey("N", NewInt(1))
+	shared = r.Add(shared)
+	direct := r.NewDict()
+	direct.SetKey("Text", NewText("Zoë"))
+	cat.SetKey("A", shared)
+	cat.SetKey("B", r.NewArray(shared, direct, NewBool(true), NewString("x")))
+	shared.SetKey("N", NewInt(2)) // seen through both
+	stm := r.NewStream(Value{}, []byte("data"))
+	cat.SetKey("S", stm)
+
+	if err := cat.Key("B").SetKey("X", NewInt(1)); err == nil {
+		t.Errorf("SetKey on an array succeeded")
+	}
+	if err := cat.Key("B").SetIndex(4, NewInt(1)); err == nil {
+		t.Errorf("SetIndex out of range succeeded")
+	}
+	if err := cat.SetIndex(0, NewInt(1)); err == nil {
+		t.Errorf("SetIndex on a dictionary succeeded")
+	}
+
+	r = save(t, r)
+	cat = r.Trailer.Key("Root")
+	if s := cat.Key("PageMode").CoerceName(""); s != "UseOutlines" {
+		t.Errorf("PageMode %q, want UseOutlines", s)
+	}
+	if cat.Key("Lang").Kind() != Null {
+		t.Errorf("deleted Lang is %v", cat.Key("Lang"))
+	}
+	p := r.Page(1)
+	if w := p.MediaBox().Index(2).CoerceInt64(0); w != 300 {
+		t.Errorf("MediaBox width %d, want 300", w)
+	}
+	if u := p.V.Key("UserUnit").CoerceFloat64(0); u != 1.5 {
+		t.Errorf("UserUnit %v, want 1.5", u)
+	}
+	if s, err := p.GetPlainText(); err != nil || s != "hello" {
+		t.Errorf("page text %q, %v, want %q", s, err, "hello")
+	}
+
+	a, b := cat.Key("A"), cat.Key("B")
+	if a.Ref() == (ObjRef{}) || b.Index(0).Ref() != a.Ref() {
+		t.Errorf("shared dictionary written as %v and %v, want one indirect object", a.Ref(), b.Index(0).Ref())
+	}
+	if n := a.Key("N").CoerceInt64(0); n != 2 {
+		t.Errorf("shared N = %d, want 2", n)
+	}
+	if s := b.Index(1).Key("Text").CoerceText(""); s != "Zoë" {
+		t.Errorf("B[1] Text %q, want %q", s, "Zoë")
+	}
+	if b.Index(2).data != true || b.Index(3).data != "x" {
+		t.Errorf("B = %v", b)
+	}
+	var data bytes.Buffer
+	data.ReadFrom(cat.Key("S").Reader())
+	if data.String() != "data" {
+		t.Errorf("stream data %q, want %q", data.String(), "data")
+	}
+
+	// Catalog, page tree, page, contents, font, A and S.
+	if n := r.Trailer.Key("Size").CoerceInt64(0); n != 8 {
+		t.Errorf("saved file has Size %d, want 8: unused objects kept", n)
+	}
+}
+
+// TestEditAddScalar checks that objects added by Add are referred
+// to whatever they hold, as integers for indirect lengths are.
+func TestEditAddScalar(t *testing.T) {
+	r := openPDF(t, pagePDF("<<>>", "0 0 m 10 10 l S"))
+	cat := r.Trailer.Key("Root")
+	n := r.Add(NewInt(4))
+	empty := r.Add(r.NewArray())
+	cat.SetKey("N", n)
+	cat.SetKey("E", r.NewArray(empty, n))
+	cat.SetKey("Rotate", r.Page(1).V.Key("MediaBox").Index(2)) // a direct value, copied
+
+	r = save(t, r)
+	cat = r.Trailer.Key("Root")
+	ref, ok := cat.entryRef("N")
+	if !ok || cat.Key("N").CoerceInt64(0) != 4 {
+		t.Fatalf("N = %v, want a reference to 4", cat.Key("N"))
+	}
+	e := cat.Key("E")
+	if got, ok := e.entryRef(1); !ok || got != ref {
+		t.Errorf("E[1] refers to %v, want %v", got, ref)
+	}
+	if _, ok := e.entryRef(0); !ok || e.Index(0).Kind() != Array || e.Index(0).Len() != 0 {
+		t.Errorf("E[0] = %v, want a reference to an empty array", e.Index(0))
+	}
+	if _, ok := cat.entryRef("Rotate"); ok {
+		t.Errorf("direct MediaBox element stored as a reference")
+	}
+}
+
+func TestEditForeign(t *testing.T) {
+	r1 := openPDF(t, pagePDF("<<>>", show(72, 700, "one")))
+	r2 := openPDF(t, pagePDF("<<>>", show(72, 700, "two")))
+	page := r1.Page(1).V
+	if err := page.SetKey("Extra", r2.NewStream(Value{}, []byte("x"))); err == nil {
+		t.Errorf("SetKey of a stream of another document succeeded")
+	}
+	if err := page.SetKey("Contents", r2.Page(1).V.Key("Contents")); err == nil {
+		t.Errorf("SetKey of the contents of another document succeeded")
+	}
+	if err := page.Key("MediaBox").SetIndex(0, r2.Page(1).V.Key("MediaBox").Index(0)); err == nil {
+		t.Errorf("SetIndex of a value of another document succeeded")
+	}
+	if a := r1.NewArray(r2.Trailer.Key("Root")); a.err == nil {
+		t.Errorf("NewArray of a value of another document succeeded")
+	}
+	if err := page.SetKey("Extra", r1.NewArray(r2.Trailer.Key("Root"))); err == nil {
+		t.Errorf("SetKey of a failed NewArray succeeded")
+	}
+	if a := r1.Add(r2.NewDict()); a.err == nil {
+		t.Errorf("Add of a dictionary of another document succeeded")
+	}
+
+	r1 = save(t, r1)
+	if s, err := r1.Page(1).GetPlainText(); err != nil || s != "one" {
+		t.Errorf("page text %q, %v, want %q", s, err, "one")
+	}
+	if r1.Page(1).V.Key("Extra").Kind() != Null {
+		t.Errorf("Extra = %v, want none", r1.Page(1).V.Key("Extra"))
+	}
+}
+
+func TestEditFont(t *testing.T) {
+	r := openPDF(t, pagePDF("<</Font <</F1 5 0 R>>>>", show(72, 700, "AB"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Test /FirstChar 65 /LastChar 66 /Widths [500 500]>>"))
+	widths := func() string {
+		c, err := r.Page(1).Content()
+		if err != nil || len(c.Text) != 1 {
+			t.Fatalf("Content: %d fragments, %v", len(c.Text), err)
+		}
+		var w []float64
+		for _, ch := range c.Text[0].S {
+			w = append(w, ch.Width)
+		}
+		return fmt.Sprint(w)
+	}
+	if w := widths(); w != "[500 500]" {
+		t.Fatalf("character widths %s, want [500 500]", w)
+	}
+	if err := object(r, 5).SetKey("Widths", r.NewArray(NewInt(700), NewInt(600))); err != nil {
+		t.Fatal(err)
+	}
+	if w := widths(); w != "[700 600]" {
+		t.Errorf("after SetKey of Widths, character widths %s, want [700 600]", w)
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Encrypting files as they are written, with the standard security
+// handler (PDF 32000-1:2008, §7.6.3, and ISO 32000-2, §7.6.4).
+
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"io"
+)
+
+// A Permission is a set of operations that viewers allow to a user who
+// opens an encrypted document with the user password (Table 22).
+// The owner password grants them all.
+type Permission uint32
+
+const (
+	PermPrint            Permission = 1 << 2  // print, at low resolution unless PermPrintHighQuality is also set
+	PermModify           Permission = 1 << 3  // change the document other than by the operations below
+	PermCopy             Permission = 1 << 4  // copy or extract text and graphics
+	PermAnnotate         Permission = 1 << 5  // add or change annotations and fill in form fields
+	PermFillForms        Permission = 1 << 8  // fill in form fields, even without PermAnnotate
+	PermAccessibility    Permission = 1 << 9  // extract text and graphics for accessibility
+	PermAssemble         Permission = 1 << 10 // insert, rotate or delete pages and make bookmarks
+	PermPrintHighQuality Permission = 1 << 11 // print at full resolution
+
+	PermAll = PermPrint | PermModify | PermCopy | PermAnnotate | PermFillForms |
+		PermAccessibility | PermAssemble | PermPrintHighQuality
+)
+
+// An EncryptionMethod is a cipher with which to encrypt a file.
+type EncryptionMethod int
+
+const (
+	RC4128 EncryptionMethod = iota // RC4 with a 128-bit key (PDF 1.4)
+	AES128                         // AES with a 128-bit key (PDF 1.6)
+	AES256                         // AES with a 256-bit key (PDF 2.0)
+)
+
+// An Encryption describes how a Writer encrypts a file.
+type Encryption struct {
+	Method EncryptionMethod
+
+	// UserPassword is the password needed to open the file,
+	// which may be empty to let anyone open it, still subject to
+	// Permissions. OwnerPassword lifts the restrictions; if it is
+	// empty, the user password does. For RC4128 and AES128 the
+	// passwords may hold only Latin-1 characters.
+	UserPassword  string
+	OwnerPassword string
+
+	Permissions Permission
+}
+
+// SetEncryption makes w encrypt the file it writes as e describes.
+// It must be called before pages or images are added.
+func (w *Writer) SetEncryption(e Encryption) error {
+	if len(w.xref) > 1 {
+		return fmt.Errorf("pdf: SetEncryption after objects were added")
+	}
+	owner := e.OwnerPassword
+	if owner == "" {
+		owner = e.UserPassword
+	}
+	// Bits 7, 8 and 13 to 32 of P must be set (Table 22).
+	p := uint32(e.Permissions&PermAll) | 0xfffff0c0
+	c := &writeCrypt{id: string(randomBytes(16))}
+	var err error
+	switch e.Method {
+	case RC4128, AES128:
+		err = c.initMD5(e.UserPassword, owner, p, e.Method == AES128)
+	case AES256:
+		err = c.initSHA(e.UserPassword, owner, p)
+	default:
+		err = fmt.Errorf("pdf: unknown encryption method %d", e.Method)
+	}
+	if err != nil {
+		return err
+	}
+	w.crypt = c
+	return nil
+}
+
+// A writeCrypt holds the state of a Writer's encryption.
+type writeCrypt struct {
+	id      string  // first element of the file identifier, from which keys derive
+	key     []byte  // file encryption key
+	aes     bool    // whether to use AES rather than RC4
+	v5      bool    // whether key is used unchanged for every object (AES-256)
+	encrypt pdfdict // encryption dictionary
+}
+
+// initMD5 sets up RC4 or AES encryption with a 128-bit key,
+// as for revisions 3 and 4 of the standard security handler.
+func (c *writeCrypt) initMD5(user, owner string, p uint32, useAES bool) error {
+	upw, err := latin1Password(user)
+	if err != nil {
+		return err
+	}
+	opw, err := latin1Password(owner)
+	if err != nil {
+		return err
+	}
+
+	// Algorithm 3: the O entry, the user password
+	// encrypted with a key derived from the owner password.
+	h := md5.New()
+	h.Write(padPassword(opw))
+	okey := h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		okey = md5Sum(okey)
+	}
+	O := padPassword(upw)
+	rc4Rounds(okey, O)
+
+	// Algorithm 2: the file encryption key.
+	h.Reset()
+	h.Write(padPassword(upw))
+	h.Write(O)
+	h.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+	h.Write([]byte(c.id))
+	key := h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		key = md5Sum(key)
+	}
+
+	// Algorithm 5: the U entry, the padding and
+	// file identifier encrypted with the file key.
+	h.Reset()
+	h.Write(passwordPad)
+	h.Write([]byte(c.id))
+	U := h.Sum(nil)
+	rc4Rounds(key, U)
+	U = append(U, make([]byte, 16)...)
+
+	c.key = key
+	c.aes = useAES
+	c.encrypt = pdfdict{
+		"Filter": pdfname("Standard"),
+		"V":      int64(2),
+		"R":      int64(3),
+		"Length": int64(128),
+		"O":      string(O),
+		"U":      string(U),
+		"P":      int64(int32(p)),
+	}
+	if useAES {
+		c.encrypt["V"] = int64(4)
+		c.encrypt["R"] = int64(4)
+		c.encrypt["CF"] = pdfdict{"StdCF": pdfdict{
+			"CFM":       pdfname("AESV2"),
+			"AuthEvent": pdfname("DocOpen"),
+			"Length":    int64(16),
+		}}
+		c.encrypt["StmF"] = pdfname("StdCF")
+		c.encrypt["StrF"] = pdfname("StdCF")
+	}
+	return nil
+}
+
+// initSHA sets up AES encryption with a 256-bit key,
+// as for revision 6 of the standard security handler.
+func (c *writeCrypt) initSHA(user, owner string, p uint32) error {
+	// Passwords are UTF-8, truncated to 127 bytes.
+	// They should be normalized with SASLprep (RFC 4013), which is
+	// not done, so only passwords it leaves unchanged can be used
+	// reliably by other readers.
+	upw, opw := []byte(user), []byte(owner)
+	upw, opw = upw[:min(len(upw), 127)], opw[:min(len(opw), 127)]
+
+	key := randomBytes(32)
+
+	// Algorithm 8: the U and UE entries.
+	salts := randomBytes(16)
+	U := append(hashR6(upw, salts[:8], nil), salts...)
+	UE := aesNoPad(hashR6(upw, salts[8:], nil), key)
+
+	// Algorithm 9: the O and OE entries, which depend on U.
+	salts = randomBytes(16)
+	O := append(hashR6(opw, salts[:8], U), salts...)
+	OE := aesNoPad(hashR6(opw, salts[8:], U), key)
+
+	// Algorithm 10: the Perms entry, the permissions
+	// encrypted with the file key.
+	perms := []byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24), 0xff, 0xff, 0xff, 0xff, 'T', 'a', 'd', 'b'}
+	perms = append(perms, randomBytes(4)...)
+	block, _ := aes.NewCipher(key)
+	block.Encrypt(perms, perms)
+
+	c.key = key
+	c.aes = true
+	c.v5 = true
+	c.encrypt = pdfdict{
+		"Filter": pdfname("Standard"),
+		"V":      int64(5),
+		"R":      int64(6),
+		"Length": int64(256),
+		"CF": pdfdict{"StdCF": pdfdict{
+			"CFM":       pdfname("AESV3"),
+			"AuthEvent": pdfname("DocOpen"),
+			"Length":    int64(32),
+		}},
+		"StmF":  pdfname("StdCF"),
+		"StrF":  pdfname("StdCF"),
+		"O":     string(O),
+		"U":     string(U),
+		"OE":    string(OE),
+		"UE":    string(UE),
+		"P":     int64(int32(p)),
+		"Perms": string(perms),
+	}
+	return nil
+}
+
+// hashR6 computes the hash of Algorithm 2.B of ISO 32000-2
+// for the password pw, salt and, for the owner password, U entry.
+func hashR6(pw, salt, u []byte) []byte {
+	h := sha256.New()
+	h.Write(pw)
+	h.Write(salt)
+	h.Write(u)
+	k := h.Sum(nil)
+	for i := 0; ; i++ {
+		var k1 []byte
+		for j := 0; j < 64; j++ {
+			k1 = append(k1, pw...)
+			k1 = append(k1, k...)
+			k1 = append(k1, u...)
+		}
+		block, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+
+		// The first 16 bytes of e as a number modulo 3
+		// choose the hash for the next round.
+		mod := 0
+		for _, b := range e[:16] {
+			mod += int(b)
+		}
+		switch mod % 3 {
+		case 0:
+			s := sha256.Sum256(e)
+			k = s[:]
+		case 1:
+			s := sha512.Sum384(e)
+			k = s[:]
+		case 2:
+			s := sha512.Sum512(e)
+			k = s[:]
+		}
+		if i >= 63 && int(e[len(e)-1]) <= i+1-32 {
+			break
+		}
+	}
+	return k[:32]
+}
+
+// aesNoPad encrypts data, a multiple of 16 bytes long, with AES-256
+// in CBC mode with a zero initialization vector and no padding.
+func aesNoPad(key, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, make([]byte, 16)).CryptBlocks(out, data)
+	return out
+}
+
+// latin1Password returns the password s as bytes of Latin-1,
+// which the MD5-based security handlers expect.
+func latin1Password(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, fmt.Errorf("pdf: password character %q is not Latin-1", r)
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
+
+// padPassword returns pw truncated or padded to 32 bytes.
+func padPassword(pw []byte) []byte {
+	b := make([]byte, 32)
+	n := copy(b, pw)
+	copy(b[n:], passwordPad)
+	return b
+}
+
+func md5Sum(b []byte) []byte {
+	s := md5.Sum(b)
+	return s[:]
+}
+
+// rc4Rounds encrypts data in place with key and then with
+// 19 variations of key, as the MD5-based security handlers do.
+func rc4Rounds(key, data []byte) {
+	k := make([]byte, len(key))
+	for i := 0; i <= 19; i++ {
+		for j := range k {
+			k[j] = key[j] ^ byte(i)
+		}
+		c, _ := rc4.NewCipher(k)
+		c.XORKeyStream(data, data)
+	}
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// encryptData returns data encrypted for the object ptr (§7.6.2).
+// AES output begins with the random initialization vector.
+func (c *writeCrypt) encryptData(ptr pdfobjptr, data []byte) []byte {
+	key := c.key
+	if !c.v5 {
+		key = cryptKey(c.key, c.aes, ptr)
+	}
+	if !c.aes {
+		out := make([]byte, len(data))
+		ciph, _ := rc4.NewCipher(key)
+		ciph.XORKeyStream(out, data)
+		return out
+	}
+	// PKCS#5 padding, then CBC.
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	plain := append(bytes.Clone(data), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	out := randomBytes(aes.BlockSize)
+	out = append(out, make([]byte, len(plain))...)
+	block, _ := aes.NewCipher(key)
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], plain)
+	return out
+}
+
+// encryptObject returns a copy of the direct object x, part of the
+// object ptr, with its strings encrypted.
+func (c *writeCrypt) encryptObject(ptr pdfobjptr, x pdfobject) pdfobject {
+	switch x := x.(type) {
+	case string:
+		return string(c.encryptData(ptr, []byte(x)))
+	case pdfarray:
+		y := make(pdfarray, len(x))
+		for i, v := range x {
+			y[i] = c.encryptObject(ptr, v)
+		}
+		return y
+	case pdfdict:
+		y := make(pdfdict, len(x))
+		for k, v := range x {
+			y[k] = c.encryptObject(ptr, v)
+		}
+		if v, ok := x["Contents"]; ok && isSignatureDict(x) {
+			y["Contents"] = v // not encrypted (§7.6.1)
+		}
+		return y
+	}
+	return x
+}
+
+// SaveEncrypted writes the document as Save does,
+// but encrypted as e describes.
+func (r *Reader) SaveEncrypted(w io.Writer, e Encryption) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("pdf: saving: %v", e)
+		}
+	}()
+	wr := NewWriter(w)
+	if err := wr.SetEncryption(e); err != nil {
+		return err
+	}
+	return newObjCopier(r, wr).save()
+}
@@ -0,0 +1,214 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// encryptedPDF returns a document of one page, with a title,
+// encrypted as e describes.
+func encryptedPDF(t *testing.T, e Encryption) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.SetEncryption(e); err != nil {
+		t.Fatal(err)
+	}
+	w.Info.Title = "Secret"
+	w.NewPage(612, 792).ShowText(72, 700, "hello, encrypted")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// passwords returns a password function for NewReaderEncrypted
+// that tries each of list in turn.
+func passwords(list ...string) func() string {
+	return func() string {
+		if len(list) == 0 {
+			return ""
+		}
+		pw := list[0]
+		list = list[1:]
+		return pw
+	}
+}
+
+// openEncrypted opens data with the password pw, checking that it
+// holds the document written by encryptedPDF.
+func openEncrypted(t *testing.T, name string, data []byte, pw string) {
+	t.Helper()
+	r, err := NewReaderEncrypted(bytes.NewReader(data), int64(len(data)), passwords(pw))
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	c, err := r.Page(1).Content()
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	if text := contentText(c); text != "hello, encrypted" {
+		t.Errorf("%s: text %q", name, text)
+	}
+	if title := r.Info().Title; title != "Secret" {
+		t.Errorf("%s: title %q", name, title)
+	}
+}
+
+var encryptionMethods = []struct {
+	name   string
+	method EncryptionMethod
+}{
+	{"RC4-128", RC4128},
+	{"AES-128", AES128},
+	{"AES-256", AES256},
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	for _, m := range encryptionMethods {
+		data := encryptedPDF(t, Encryption{Method: m.method})
+		openEncrypted(t, m.name, data, "")
+		// A user password is needed to open the file.
+		data = encryptedPDF(t, Encryption{Method: m.method, UserPassword: "user", OwnerPassword: "owner"})
+		if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err != ErrInvalidPassword {
+			t.Errorf("%s: opened without the password: %v", m.name, err)
+		}
+		openEncrypted(t, m.name+" with the user password", data, "user")
+		openEncrypted(t, m.name+" with the owner password", data, "owner")
+		if _, err := NewReaderEncrypted(bytes.NewReader(data), int64(len(data)), passwords("wrong")); err == nil {
+			t.Errorf("%s: opened with the wrong password", m.name)
+		}
+	}
+}
+
+// TestEncryptionAES256 checks what is particular to AES-256: the
+// owner password opens the file, and passwords are UTF-8.
+func TestEncryptionAES256(t *testing.T) {
+	data := encryptedPDF(t, Encryption{Method: AES256, UserPassword: "pässwörd", OwnerPassword: "owner"})
+	openEncrypted(t, "user password", data, "pässwörd")
+	openEncrypted(t, "owner password", data, "owner")
+
+	// Damaged Perms do not match the key.
+	i := bytes.Index(data, []byte("/Perms (")) + len("/Perms (")
+	if i < len("/Perms (") {
+		t.Fatal("Perms not found")
+	}
+	// Change a byte that needs no escape, before or after.
+	plain := func(c byte) bool {
+		return ' ' <= c && c < 0x7f && c != '(' && c != ')' && c != '\\'
+	}
+	for !plain(data[i]) || !plain(data[i]^1) {
+		i++
+	}
+	bad := bytes.Clone(data)
+	bad[i] ^= 1
+	if _, err := NewReaderEncrypted(bytes.NewReader(bad), int64(len(bad)), passwords("owner")); err == nil {
+		t.Errorf("opened a file whose Perms do not match the key")
+	}
+}
+
+func TestSaveEncrypted(t *testing.T) {
+	plain := encryptedPDF(t, Encryption{Method: RC4128})
+	r := openPDF(t, plain)
+	for _, m := range encryptionMethods {
+		var buf bytes.Buffer
+		if err := r.SaveEncrypted(&buf, Encryption{Method: m.method, UserPassword: "user"}); err != nil {
+			t.Errorf("%s: %v", m.name, err)
+			continue
+		}
+		openEncrypted(t, m.name+" saved", buf.Bytes(), "user")
+	}
+
+	// The Contents of a signature dictionary is saved unencrypted.
+	s := newTestSigner(t)
+	signed := encryptedUnsignedPDF()
+	start, end := contentsHole(signed)
+	s.sign(t, signed, 0, start, end, len(signed)-end)
+	r, sig := signature(t, signed)
+	for _, m := range encryptionMethods {
+		var buf bytes.Buffer
+		if err := r.SaveEncrypted(&buf, Encryption{Method: m.method}); err != nil {
+			t.Fatalf("%s: %v", m.name, err)
+		}
+		if _, saved := signature(t, buf.Bytes()); !bytes.Equal(saved.Contents, sig.Contents) {
+			t.Errorf("%s: saved signature Contents changed", m.name)
+		}
+	}
+}
+
+func TestEncryptionPermissions(t *testing.T) {
+	perm := PermPrint | PermCopy
+	want := int64(int32(uint32(perm) | 0xfffff0c0))
+	for _, m := range encryptionMethods {
+		data := encryptedPDF(t, Encryption{Method: m.method, UserPassword: "user", OwnerPassword: "owner", Permissions: perm | 1})
+		r, err := NewReaderEncrypted(bytes.NewReader(data), int64(len(data)), passwords("user"))
+		if err != nil {
+			t.Errorf("%s: %v", m.name, err)
+			continue
+		}
+		if p := r.Trailer.Key("Encrypt").Key("P").CoerceInt64(0); p != want {
+			t.Errorf("%s: P = %#x, want %#x", m.name, p, want)
+		}
+	}
+}
+
+// TestEncryptionLarge checks that unfiltered streams and strings larger
+// than the buffers data is decrypted in read back exactly.
+func TestEncryptionLarge(t *testing.T) {
+	text := strings.Repeat("large, encrypted ", 5000)
+	write := func(e *Encryption) []byte {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.Compress = false
+		if e != nil {
+			if err := w.SetEncryption(*e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.Info.Subject = text
+		w.NewPage(612, 792).ShowText(72, 700, text)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	want, err := io.ReadAll(openPDF(t, write(nil)).Page(1).ContentsReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range encryptionMethods {
+		r := openPDF(t, write(&Encryption{Method: m.method}))
+		got, err := io.ReadAll(r.Page(1).ContentsReader())
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s: content of %d bytes, %v; want %d bytes", m.name, len(got), err, len(want))
+		}
+		if s := r.Info().Subject; s != text {
+			t.Errorf("%s: subject of %d bytes, want %d", m.name, len(s), len(text))
+		}
+	}
+}
+
+func TestSetEncryptionErrors(t *testing.T) {
+	w := NewWriter(new(bytes.Buffer))
+	if err := w.SetEncryption(Encryption{Method: 99}); err == nil {
+		t.Errorf("SetEncryption with an unknown method succeeded")
+	}
+	if err := w.SetEncryption(Encryption{Method: RC4128, UserPassword: "пароль"}); err == nil {
+		t.Errorf("SetEncryption with a password not in Latin-1 succeeded for RC4")
+	}
+	if err := w.SetEncryption(Encryption{Method: AES256, UserPassword: "пароль"}); err != nil {
+		t.Errorf("SetEncryption with a Unicode password for AES-256: %v", err)
+	}
+	w = NewWriter(new(bytes.Buffer))
+	w.NewPage(612, 792).SetFont("Helvetica", 12)
+	if err := w.SetEncryption(Encryption{Method: AES128}); err == nil {
+		t.Errorf("SetEncryption after a font was written succeeded")
+	}
+}
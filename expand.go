@@ -0,0 +1,61 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Writing a document in an expanded form, for reading in a text editor.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+)
+
+// SaveExpanded writes the document, with any changes made to it, to w
+// as Save does, but in a form meant for people rather than viewers:
+// streams are written decoded, where their filters can be decoded,
+// and uncompressed; objects packed in object streams are written one
+// by one, keeping the numbers they have in the document; and each
+// dictionary entry is written on a line of its own, in sorted order.
+// Files written by SaveExpanded can be read in a text editor and
+// compared with diff, which helps in finding what a parser trips on.
+// A stream that cannot be decoded is written as it is, with its filters.
+func (r *Reader) SaveExpanded(w io.Writer) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("pdf: saving: %v", e)
+		}
+	}()
+	wr := NewWriter(w)
+	wr.Compress = false
+	wr.pretty = true
+	// Objects keep their numbers; those of objects not written are free.
+	wr.xref = make([]objLocation, r.numObjects())
+	c := newObjCopier(r, wr)
+	c.expand = true
+	return c.save()
+}
+
+// numObjects returns the number of objects of the document,
+// counting those created through the editing methods.
+func (r *Reader) numObjects() uint32 {
+	r.objMu.Lock()
+	defer r.objMu.Unlock()
+	return max(uint32(len(r.xref)), r.nextID, 1)
+}
+
+// expandedStream returns the decoded contents of the stream v and
+// reports whether they could be decoded. A stream without filters
+// is returned as it is.
+func expandedStream(v Value) (data []byte, ok bool) {
+	defer func() {
+		if recover() != nil {
+			data, ok = nil, false
+		}
+	}()
+	data, err := io.ReadAll(v.decode(v.filters()))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
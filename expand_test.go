@@ -0,0 +1,68 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSaveExpanded(t *testing.T) {
+	// A file with compressed streams and object streams.
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Info.Title = "Expanded"
+	w.NewPage(612, 792).ShowText(72, 700, strings.Repeat("hello expanded ", 10))
+	w.NewPage(612, 792).ShowText(72, 700, "two")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var packed bytes.Buffer
+	if _, err := openPDF(t, buf.Bytes()).Optimize(&packed); err != nil {
+		t.Fatal(err)
+	}
+	r := openPDF(t, packed.Bytes())
+	page2 := r.Page(2).V.Ref()
+
+	var out bytes.Buffer
+	if err := r.SaveExpanded(&out); err != nil {
+		t.Fatal(err)
+	}
+	s := out.String()
+	for _, bad := range []string{"/FlateDecode", "/ObjStm", "/XRef"} {
+		if strings.Contains(s, bad) {
+			t.Errorf("expanded file has %s", bad)
+		}
+	}
+	if !strings.Contains(s, "(hello expanded hello expanded ") {
+		t.Errorf("expanded file does not show the page content decoded")
+	}
+	if !strings.Contains(s, "<<\n  /Contents ") || !strings.Contains(s, "\n  /Type /Page\n>>") {
+		t.Errorf("page dictionary not written one entry per line, in order:\n%s", s)
+	}
+
+	r = openPDF(t, out.Bytes())
+	if ref := r.Page(2).V.Ref(); ref != page2 {
+		t.Errorf("page 2 renumbered from %v to %v", page2, ref)
+	}
+	if title := r.Info().Title; title != "Expanded" {
+		t.Errorf("Title %q, want Expanded", title)
+	}
+	if text, err := r.Page(2).GetPlainText(); err != nil || text != "two" {
+		t.Errorf("page 2 text %q, %v", text, err)
+	}
+
+	// A stream that cannot be decoded is kept with its filter.
+	r = openPDF(t, pagePDF("<<>>", "", stream("/Filter /FlateDecode", "not compressed")))
+	r.Page(1).V.SetKey("Thumb", object(r, 5))
+	out.Reset()
+	if err := r.SaveExpanded(&out); err != nil {
+		t.Fatal(err)
+	}
+	if s := out.String(); !strings.Contains(s, "/Filter /FlateDecode") || !strings.Contains(s, "not compressed") {
+		t.Errorf("undecodable stream not kept as it was:\n%s", s)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Extracting the content of every page at once.
+
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ExtractAll extracts the content of every page, as Page.Content does,
+// and calls fn with the number of each page and its content. The pages
+// are shared among workers goroutines, or GOMAXPROCS of them if workers
+// is 0 or less, so fn is called concurrently and not in page order.
+//
+// Each worker reuses the memory of the content it passes to fn for the
+// next page it extracts, so fn must copy what it keeps of c once it
+// returns.
+//
+// ExtractAll stops at the first error fn returns, at the first page
+// that cannot be interpreted at all, or when ctx is done, and returns
+// that error once the calls of fn in progress have returned.
+// The document must not be changed while ExtractAll runs.
+func (r *Reader) ExtractAll(ctx context.Context, workers int, fn func(pageNum int, c Content) error) error {
+	n := r.NumPage()
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, n)
+
+	work, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu    sync.Mutex
+		first error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if first == nil {
+			first = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+
+	nums := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var c Content
+			for num := range nums {
+				if work.Err() != nil {
+					continue
+				}
+				c = Content{
+					Text:         c.Text[:0],
+					Rect:         c.Rect[:0],
+					Paths:        c.Paths[:0],
+					Shadings:     c.Shadings[:0],
+					Images:       c.Images[:0],
+					InlineImages: c.InlineImages[:0],
+					Warnings:     c.Warnings[:0],
+				}
+				if err := r.Page(num).appendContent(&c, nil); err != nil {
+					fail(fmt.Errorf("pdf: page %d: %w", num, err))
+					continue
+				}
+				if err := fn(num, c); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+Feed:
+	for num := 1; num <= n; num++ {
+		select {
+		case nums <- num:
+		case <-work.Done():
+			break Feed
+		}
+	}
+	close(nums)
+	wg.Wait()
+
+	if first != nil {
+		return first
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,64 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestExtractAll(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	const n = 20
+	for i := 1; i <= n; i++ {
+		w.NewPage(612, 792).ShowText(72, 700, fmt.Sprintf("page %d", i))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r := openPDF(t, buf.Bytes())
+
+	var mu sync.Mutex
+	text := make(map[int]string)
+	err := r.ExtractAll(context.Background(), 4, func(num int, c Content) error {
+		mu.Lock()
+		text[num] = contentText(c)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= n; i++ {
+		if want := fmt.Sprintf("page %d", i); text[i] != want {
+			t.Errorf("page %d: text %q, want %q", i, text[i], want)
+		}
+	}
+
+	// The first error stops the extraction.
+	stop := errors.New("stop")
+	calls := 0
+	err = r.ExtractAll(context.Background(), 1, func(num int, c Content) error {
+		calls++
+		if num == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || calls != 3 {
+		t.Errorf("stopping at page 3: err %v after %d calls", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.ExtractAll(ctx, 0, func(int, Content) error { return nil }); err != context.Canceled {
+		t.Errorf("canceled: err %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Export of form data as FDF (PDF 32000-1:2008, §12.7.7) and JSON.
+
+package pdf
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// exported reports whether the field's value is exported
+// when the form is submitted or its data saved.
+func (f Field) exported() bool {
+	switch f.Type {
+	case UnknownField, PushButtonField, SignatureField:
+		return false
+	}
+	return f.Flags&FieldNoExport == 0
+}
+
+// WriteFDF writes the values of the fields, as returned by Reader.Form,
+// to w as an FDF file, which viewers can import into the form to fill it.
+// Fields that are not exported, such as push buttons and fields with the
+// FieldNoExport flag, are omitted. Fields are nested by their partial
+// names, as in the form.
+func WriteFDF(w io.Writer, fields []Field) error {
+	// Build the hierarchy of partial names.
+	type node struct {
+		name  string
+		field *Field
+		kids  []*node
+	}
+	root := new(node)
+	for i := range fields {
+		f := &fields[i]
+		if !f.exported() {
+			continue
+		}
+		n := root
+		for _, part := range strings.Split(f.Name, ".") {
+			var kid *node
+			for _, k := range n.kids {
+				if k.name == part {
+					kid = k
+					break
+				}
+			}
+			if kid == nil {
+				kid = &node{name: part}
+				n.kids = append(n.kids, kid)
+			}
+			n = kid
+		}
+		n.field = f
+	}
+
+	b := bufio.NewWriter(w)
+	b.WriteString("%FDF-1.2\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<< /FDF << /Fields [")
+	var write func(n *node)
+	write = func(n *node) {
+		fmt.Fprintf(b, "\n<< /T %s", literalString(encodeTextString(n.name)))
+		if f := n.field; f != nil {
+			b.WriteString(" /V ")
+			switch {
+			case f.Type == CheckboxField || f.Type == RadioField:
+				b.WriteString(nameToken(f.Value))
+			case len(f.Values) > 1:
+				b.WriteString("[")
+				for i, v := range f.Values {
+					if i > 0 {
+						b.WriteString(" ")
+					}
+					b.WriteString(literalString(encodeTextString(v)))
+				}
+				b.WriteString("]")
+			default:
+				b.WriteString(literalString(encodeTextString(f.Value)))
+			}
+		}
+		if len(n.kids) > 0 {
+			b.WriteString(" /Kids [")
+			for _, k := range n.kids {
+				write(k)
+			}
+			b.WriteString("]")
+		}
+		b.WriteString(" >>")
+	}
+	for _, k := range root.kids {
+		write(k)
+	}
+	b.WriteString("\n] >> >>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Flush()
+}
+
+// WriteFormJSON writes the values of the fields, as returned by
+// Reader.Form, to w as a JSON object mapping each field's fully
+// qualified name to its value: a string, or for list boxes with
+// several selections an array of strings. Fields that are not
+// exported are omitted, as by WriteFDF.
+func WriteFormJSON(w io.Writer, fields []Field) error {
+	m := make(map[string]interface{})
+	for _, f := range fields {
+		if !f.exported() {
+			continue
+		}
+		if len(f.Values) > 1 {
+			m[f.Name] = f.Values
+		} else {
+			m[f.Name] = f.Value
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(m)
+}
@@ -0,0 +1,62 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var exportFields = []Field{
+	{Name: "person.name", Type: TextField, Value: "Ann (Lee)"},
+	{Name: "person.city", Type: TextField, Value: "Zürich"},
+	{Name: "agree", Type: CheckboxField, Value: "Yes"},
+	{Name: "tags", Type: ListBoxField, Value: "a", Values: []string{"a", "c"}},
+	{Name: "one", Type: ListBoxField, Value: "b", Values: []string{"b"}},
+	{Name: "secret", Type: TextField, Flags: FieldNoExport, Value: "x"},
+	{Name: "submit", Type: PushButtonField},
+	{Name: "sig", Type: SignatureField},
+}
+
+func TestWriteFDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFDF(&buf, exportFields); err != nil {
+		t.Fatal(err)
+	}
+	want := "%FDF-1.2\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<< /FDF << /Fields [\n" +
+		"<< /T (person) /Kids [\n" +
+		"<< /T (name) /V (Ann \\(Lee\\)) >>\n" +
+		"<< /T (city) /V (\xfe\xff\x00Z\x00\xfc\x00r\x00i\x00c\x00h) >>] >>\n" + // in UTF-16
+		"<< /T (agree) /V /Yes >>\n" +
+		"<< /T (tags) /V [(a) (c)] >>\n" +
+		"<< /T (one) /V (b) >>\n" +
+		"] >> >>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n"
+	if buf.String() != want {
+		t.Errorf("WriteFDF wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteFormJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFormJSON(&buf, exportFields); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v in %s", err, buf.String())
+	}
+	want := map[string]interface{}{
+		"person.name": "Ann (Lee)",
+		"person.city": "Zürich",
+		"agree":       "Yes",
+		"tags":        []interface{}{"a", "c"},
+		"one":         "b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteFormJSON wrote %s, want %v", buf.String(), want)
+	}
+}
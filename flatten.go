@@ -0,0 +1,226 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Flattening annotations into page content (PDF 32000-1:2008, §12.5.5).
+
+package pdf
+
+import (
+	"fmt"
+	"math"
+)
+
+// annotHidden is the annotation flag (PDF 32000-1:2008, Table 165)
+// marking an annotation that is neither shown nor printed.
+const annotHidden = 1 << 1
+
+// FlattenAnnotations draws the normal appearances of the annotations
+// of the page with the given number, starting at 1, into the page's
+// content and removes the annotations, so that the filled-in form
+// fields, stamps and markup they show are kept by viewers and printers
+// that ignore annotations and can no longer be changed. Annotations
+// with no appearance, such as most links, are kept; hidden ones are
+// removed without being drawn, as are the pop-up windows of those
+// removed. Form fields left with no widgets are removed from the form.
+// The change is written by Save.
+func (r *Reader) FlattenAnnotations(page int) error {
+	if n := r.NumPage(); page < 1 || page > n {
+		return fmt.Errorf("pdf: page %d out of range [1, %d]", page, n)
+	}
+	p := r.Page(page).V
+	if p.Kind() != Dict {
+		return fmt.Errorf("malformed PDF: page %d not found", page)
+	}
+	annots := p.Key("Annots")
+	if annots.Len() == 0 {
+		return nil
+	}
+
+	var xobjs Value
+	var content []byte
+	removed := make(map[pdfobjptr]bool)
+	var keep, widgets []Value
+	for i := 0; i < annots.Len(); i++ {
+		a := annots.Index(i)
+		ap := annotAppearance(a)
+		if ap.Kind() != Stream {
+			keep = append(keep, a)
+			continue
+		}
+		if ptr, ok := annots.entryRef(i); ok {
+			removed[ptr] = true
+		}
+		if a.Key("Subtype").CoerceName("") == "Widget" {
+			widgets = append(widgets, a)
+		}
+		if a.Key("F").CoerceInt64(0)&annotHidden != 0 {
+			continue
+		}
+		m, ok := appearanceMatrix(ap, rectValue(a.Key("Rect")))
+		if !ok {
+			continue
+		}
+		if xobjs.Kind() != Dict {
+			xobjs = r.pageXObjects(p)
+		}
+		name := "Fm1"
+		for n := 2; xobjs.Key(name).Kind() != Null; n++ {
+			name = fmt.Sprintf("Fm%d", n)
+		}
+		xobjs.SetKey(name, ap)
+		content = append(content, "q "...)
+		for _, x := range m {
+			content = appendReal(content, x)
+			content = append(content, ' ')
+		}
+		content = append(content, "cm "...)
+		content = appendObject(content, pdfname(name))
+		content = append(content, " Do Q\n"...)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	// Pop-up windows belong to the annotations removed.
+	n := 0
+	for _, a := range keep {
+		if a.Key("Subtype").CoerceName("") == "Popup" {
+			if ptr, ok := a.entryRef("Parent"); ok && removed[ptr] {
+				continue
+			}
+		}
+		keep[n] = a
+		n++
+	}
+	keep = keep[:n]
+	if len(keep) == 0 {
+		p.SetKey("Annots", Value{})
+	} else {
+		p.SetKey("Annots", r.NewArray(keep...))
+	}
+
+	if len(content) > 0 {
+		if err := r.appendContent(p, content); err != nil {
+			return err
+		}
+	}
+	for _, w := range widgets {
+		r.removeField(w, 0)
+	}
+	return nil
+}
+
+// annotAppearance returns the normal appearance stream of the annotation a,
+// chosen by its appearance state if it has several, or a null Value if none.
+func annotAppearance(a Value) Value {
+	n := a.Key("AP").Key("N")
+	if n.Kind() == Dict {
+		n = n.Key(a.Key("AS").CoerceName(""))
+	}
+	if n.Kind() != Stream {
+		return Value{}
+	}
+	return n
+}
+
+// appearanceMatrix returns the matrix [a b c d e f] that maps the
+// appearance stream ap, transformed by its own Matrix, into the
+// annotation rectangle rect, as a viewer draws it (§12.5.5).
+// It reports false if the appearance or rect is empty.
+func appearanceMatrix(ap Value, rect Rectangle) ([6]float64, bool) {
+	m := [6]float64{1, 0, 0, 1, 0, 0}
+	if a := floats(ap.Key("Matrix")); len(a) == 6 {
+		copy(m[:], a)
+	}
+	bbox := rectValue(ap.Key("BBox"))
+	x0, y0 := math.Inf(1), math.Inf(1)
+	x1, y1 := math.Inf(-1), math.Inf(-1)
+	for _, pt := range []Point{bbox.Min, {bbox.Max.X, bbox.Min.Y}, bbox.Max, {bbox.Min.X, bbox.Max.Y}} {
+		x := m[0]*pt.X + m[2]*pt.Y + m[4]
+		y := m[1]*pt.X + m[3]*pt.Y + m[5]
+		x0, y0 = min(x0, x), min(y0, y)
+		x1, y1 = max(x1, x), max(y1, y)
+	}
+	if x1-x0 <= 0 || y1-y0 <= 0 || rect.Max.X-rect.Min.X <= 0 || rect.Max.Y-rect.Min.Y <= 0 {
+		return m, false
+	}
+	sx := (rect.Max.X - rect.Min.X) / (x1 - x0)
+	sy := (rect.Max.Y - rect.Min.Y) / (y1 - y0)
+	return [6]float64{sx, 0, 0, sy, rect.Min.X - sx*x0, rect.Min.Y - sy*y0}, true
+}
+
+// pageXObjects returns the XObject resource dictionary of the page p,
+// creating it if needed. A page inheriting its resources is given its
+// own copy of them, so that the other pages sharing them are unchanged.
+func (r *Reader) pageXObjects(p Value) Value {
+	res := p.Key("Resources")
+	if res.Kind() != Dict {
+		inh := Page{V: p}.findInherited("Resources")
+		res = r.NewDict()
+		for _, k := range inh.Keys() {
+			res.SetKey(k, inh.Key(k))
+		}
+		p.SetKey("Resources", res)
+	}
+	xobjs := res.Key("XObject")
+	if xobjs.Kind() != Dict {
+		xobjs = r.NewDict()
+		res.SetKey("XObject", xobjs)
+	}
+	return xobjs
+}
+
+// appendContent appends the content c to the page p, drawn with the
+// graphics state in effect before the page's own content, which is
+// enclosed in a q and Q pair in case it leaves the state changed.
+func (r *Reader) appendContent(p Value, c []byte) error {
+	var streams []Value
+	contents := p.Key("Contents")
+	switch contents.Kind() {
+	case Array:
+		for i := 0; i < contents.Len(); i++ {
+			streams = append(streams, contents.Index(i))
+		}
+	case Stream:
+		streams = append(streams, contents)
+	}
+	if len(streams) > 0 {
+		streams = append([]Value{r.NewStream(Value{}, []byte("q\n"))}, streams...)
+		c = append([]byte("Q\n"), c...)
+	}
+	streams = append(streams, r.NewStream(Value{}, c))
+	return p.SetKey("Contents", r.NewArray(streams...))
+}
+
+// removeField removes the widget annotation or field v from the form's
+// field hierarchy, removing in turn its ancestors left with no kids,
+// and the form itself if no fields are left.
+func (r *Reader) removeField(v Value, depth int) {
+	if v.ptr == (pdfobjptr{}) || depth > maxFieldDepth {
+		return
+	}
+	form := r.Trailer.Key("Root").Key("AcroForm")
+	parent, key := v.Key("Parent"), "Kids"
+	if parent.Kind() != Dict {
+		parent, key = form, "Fields"
+	}
+	kids := parent.Key(key)
+	var keep []Value
+	for i := 0; i < kids.Len(); i++ {
+		if ptr, ok := kids.entryRef(i); !ok || ptr != v.ptr {
+			keep = append(keep, kids.Index(i))
+		}
+	}
+	if len(keep) == kids.Len() {
+		return
+	}
+	switch {
+	case len(keep) > 0:
+		parent.SetKey(key, r.NewArray(keep...))
+	case key == "Kids":
+		r.removeField(parent, depth+1)
+	default:
+		r.Trailer.Key("Root").SetKey("AcroForm", Value{})
+	}
+}
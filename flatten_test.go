@@ -0,0 +1,74 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFlattenAnnotations(t *testing.T) {
+	// The page inherits its resources. It has a filled-in text field,
+	// a link without an appearance, a hidden square with a pop-up,
+	// and a stamp whose appearance is drawn at twice its size.
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R /AcroForm <</Fields [5 0 R]>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1 /Resources <</Font <</F1 9 0 R>>>>>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Annots [5 0 R 6 0 R 7 0 R 8 0 R 10 0 R]>>",
+		stream("", show(72, 700, "body")),
+		"<</Type /Annot /Subtype /Widget /FT /Tx /T (name) /V (field) /Rect [100 600 200 620] /P 3 0 R /AP <</N 11 0 R>>>>",
+		"<</Type /Annot /Subtype /Link /Rect [0 0 10 10] /Dest [3 0 R /Fit]>>",
+		"<</Type /Annot /Subtype /Square /F 2 /Rect [0 0 10 10] /Popup 8 0 R /AP <</N 12 0 R>>>>",
+		"<</Type /Annot /Subtype /Popup /Parent 7 0 R /Rect [20 20 120 120]>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /Annot /Subtype /Stamp /Rect [300 300 400 350] /AP <</N 13 0 R>>>>",
+		stream("/Type /XObject /Subtype /Form /BBox [0 0 100 20] /Resources <</Font <</F1 9 0 R>>>>", show(2, 5, "field")),
+		stream("/Type /XObject /Subtype /Form /BBox [0 0 10 10]", "0 0 10 10 re f"),
+		stream("/Type /XObject /Subtype /Form /BBox [0 0 50 25] /Resources <</Font <</F1 9 0 R>>>>", show(0, 0, "stamp")),
+	))
+	if err := r.FlattenAnnotations(2); err == nil {
+		t.Errorf("FlattenAnnotations of page 2 of 1 succeeded")
+	}
+	if err := r.FlattenAnnotations(1); err != nil {
+		t.Fatal(err)
+	}
+	r = save(t, r)
+	p := r.Page(1)
+
+	annots := p.V.Key("Annots")
+	if annots.Len() != 1 || annots.Index(0).Key("Subtype").CoerceName("") != "Link" {
+		t.Errorf("annotations left %v, want the link alone", annots)
+	}
+	if form := r.Trailer.Key("Root").Key("AcroForm"); form.Kind() != Null {
+		t.Errorf("AcroForm %v, want none with its only field flattened", form)
+	}
+	if keys := fmt.Sprint(p.Resources().Key("XObject").Keys()); keys != "[Fm1 Fm2]" {
+		t.Errorf("page XObjects %s, want [Fm1 Fm2]", keys)
+	}
+	if x := r.Page(1).V.Key("Parent").Key("Resources").Key("XObject"); x.Kind() != Null {
+		t.Errorf("inherited resources changed: XObject %v", x)
+	}
+
+	c, err := p.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tx := range c.Text {
+		got = append(got, fmt.Sprintf("%s %g,%g %g", textString(tx), tx.X, tx.Y, tx.FontSize))
+	}
+	want := "[body 72,700 10 field 102,605 10 stamp 300,300 20]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("page text %v, want %s", got, want)
+	}
+
+	// A page with nothing to flatten is left alone.
+	if err := r.FlattenAnnotations(1); err != nil {
+		t.Fatal(err)
+	}
+	if n := r.Page(1).V.Key("Annots").Len(); n != 1 {
+		t.Errorf("second flattening left %d annotations, want 1", n)
+	}
+}
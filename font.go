@@ -4,10 +4,7 @@
 
 package pdf
 
-import (
-	"fmt"
-	"unicode/utf16"
-)
+import "strings"
 
 type WidthGrabber interface {
 	Width(uint32) float64
@@ -17,25 +14,79 @@ type WidthGrabber interface {
 // The methods interpret a Font dictionary stored in V.
 type Font struct {
 	V   Value
-	enc TextEncoding
+	enc      TextEncoding
+	strategy DecodeStrategy
+	wg       WidthGrabber
+	boxes    *glyphBBoxes
 }
 
 func FontFromValue(v Value) Font {
-	f := Font{V: v}
+	f := Font{V: v, boxes: new(glyphBBoxes)}
 
 	wg, ok := CreateCIDWidthGrabber(f)
 	if !ok {
 		wg, ok = CreateDefaultWidthGrabber(f)
 	}
+	if f.isType3() {
+		wg = newType3WidthGrabber(f, wg)
+	}
+
+	order := DefaultDecodeOrder
+	if v.r != nil && v.r.DecodeOrder != nil {
+		order = v.r.DecodeOrder
+	}
+	f.wg = wg
+	f.enc, f.strategy = newEncoder(f, wg, order)
+	return f
+}
 
-	f.enc = Encoder(f, wg)
+// fontEntry returns the font stored in the font resource dictionary
+// or Tf array v under key. A font dictionary stored as an object of
+// its own is loaded once per Reader, however many pages and forms
+// refer to it, since loading may involve parsing a ToUnicode CMap
+// and an embedded font program.
+func fontEntry(v Value, key interface{}) Font {
+	var fv Value
+	switch key := key.(type) {
+	case string:
+		fv = v.Key(key)
+	case int:
+		fv = v.Index(key)
+	}
+	r := v.r
+	ptr, ok := v.entryRef(key)
+	if r == nil || !ok {
+		return FontFromValue(fv)
+	}
+	r.fontMu.Lock()
+	f, ok := r.fonts[ptr]
+	r.fontMu.Unlock()
+	if ok {
+		return f
+	}
+	f = FontFromValue(fv)
+	r.fontMu.Lock()
+	if r.fonts == nil {
+		r.fonts = make(map[pdfobjptr]Font)
+	}
+	r.fonts[ptr] = f
+	r.fontMu.Unlock()
 	return f
 }
 
+// uncacheFonts discards the fonts loaded by r, as when an object they
+// may have been loaded from has changed.
+func (r *Reader) uncacheFonts() {
+	r.fontMu.Lock()
+	r.fonts = nil
+	r.fontMu.Unlock()
+}
+
 type DefaultWidthGrabber struct {
 	first uint32
 	last uint32
 	widths []float64
+	missing float64 // width of codes not covered by widths
 }
 
 func CreateDefaultWidthGrabber(f Font) (WidthGrabber, bool){
@@ -44,15 +95,19 @@ func CreateDefaultWidthGrabber(f Font) (WidthGrabber, bool){
 	w := f.V.Key("Widths")	
 		  widths := make([]float64, w.Len())	
 	for i := 0; i < w.Len(); i += 1 {
-		widths[i] = w.Index(i).Float64()
+		widths[i] = w.Index(i).CoerceFloat64(0)
 	}
-	return DefaultWidthGrabber{first, last, widths}, true 
+	missing := f.descriptor().Key("MissingWidth").CoerceFloat64(0)
+	return DefaultWidthGrabber{first, last, widths, missing}, true 
 
 }
 
+// Width returns the width of the given code, or the font descriptor's
+// MissingWidth if the code is outside FirstChar to LastChar or has no
+// entry in the Widths array.
 func (wg DefaultWidthGrabber) Width(code uint32) float64 {
-	if code < wg.first || code >= wg.last{
-		return 0
+	if code < wg.first || code > wg.last || code-wg.first >= uint32(len(wg.widths)) {
+		return wg.missing
 	}
 	return wg.widths[code-wg.first]
 }
@@ -73,53 +128,98 @@ type CIDWidthGrabber struct {
 	wmap1 []WidthRange1 
 	wmap2 []WidthRange2
 	defaultwidth float64
+
+	// Vertical metrics, from W2 and DW2.
+	vmap []verticalRange
+	dw2  [2]float64 // vy and w1y of CIDs not in vmap
 }
 
-func CreateCIDWidthGrabber(f Font) (WidthGrabber, bool) {
-	df := f.V.Key("DescendantFonts")
+// A verticalRange gives the vertical metrics of the CIDs from start
+// to end: the vertical displacement w1y of each, and the position
+// vector (vx, vy) from its horizontal origin to its vertical origin.
+// A range of the first W2 form holds one triple for each CID;
+// one of the second form holds a single triple for all of them.
+type verticalRange struct {
+	start   uint32
+	end     uint32
+	metrics [][3]float64
+}
 
-	if df.Kind() != 0 {
+func CreateCIDWidthGrabber(f Font) (WidthGrabber, bool) {
+	d := f.descendant()
+	if d.Kind() != Dict {
 		return nil, false
 	}
 
-	w := df.Index(0).Key("W")
-	if w.Kind() != 0 {
-		return nil, false
+	// The W array holds entries of two forms:
+	//	c [w1 w2 ... wn]	widths of CIDs c to c+n-1
+	//	first last w	one width for CIDs first to last
+	cw := CIDWidthGrabber{defaultwidth: d.Key("DW").CoerceFloat64(1000)}
+	w := d.Key("W")
+	for i := 0; i+1 < w.Len(); {
+		first := uint32(w.Index(i).CoerceInt64(0))
+		next := w.Index(i + 1)
+		if next.Kind() == Array {
+			widths := make([]float64, next.Len())
+			for j := range widths {
+				widths[j] = next.Index(j).CoerceFloat64(0)
+			}
+			cw.wmap1 = append(cw.wmap1, WidthRange1{first, first + uint32(len(widths)), widths})
+			i += 2
+			continue
+		}
+		last := uint32(next.CoerceInt64(0))
+		cw.wmap2 = append(cw.wmap2, WidthRange2{first, last, w.Index(i + 2).CoerceFloat64(0)})
+		i += 3
 	}
 
-	dw := f.V.Key("DescendantFonts").Index(0).Key("DW").Float64()
-	cw := CIDWidthGrabber{[]WidthRange1{}, []WidthRange2{}, dw}
-	sz := 3
-	for i := 0; i < w.Len(); i += sz {
-		glyph := uint32(w.Index(i).Int64())
-
-		unk := w.Index(i + 1)
-		if unk.Kind() == Array {
-			sz = 2
-		  widths := make([]float64, unk.Len())	
-			for j := 0; j < unk.Len(); i += 1 {
-					widths = append(widths, unk.Index(j).Float64())
+	// The W2 array holds vertical metrics in the same two forms,
+	// with a triple w1y vx vy in place of each width.
+	cw.dw2 = [2]float64{880, -1000}
+	if dw2 := d.Key("DW2"); dw2.Len() == 2 {
+		cw.dw2 = [2]float64{dw2.Index(0).CoerceFloat64(880), dw2.Index(1).CoerceFloat64(-1000)}
+	}
+	w2 := d.Key("W2")
+	for i := 0; i+1 < w2.Len(); {
+		first := uint32(w2.Index(i).CoerceInt64(0))
+		next := w2.Index(i + 1)
+		if next.Kind() == Array {
+			var metrics [][3]float64
+			for j := 0; j+2 < next.Len(); j += 3 {
+				metrics = append(metrics, [3]float64{next.Index(j).CoerceFloat64(0), next.Index(j + 1).CoerceFloat64(0), next.Index(j + 2).CoerceFloat64(0)})
+			}
+			if len(metrics) > 0 {
+				cw.vmap = append(cw.vmap, verticalRange{first, first + uint32(len(metrics)) - 1, metrics})
 			}
-			wr1 := WidthRange1{glyph, glyph+uint32(unk.Len()), widths}
-			cw.wmap1 = append(cw.wmap1, wr1)
-			/*if code >= glyph && code < glyph+uint32(widths.Len()) {
-				return widths.Index(int(code - glyph)).Float64(), true
-			}*/
-		} else {
-			sz = 3
-			endglyph := uint32(unk.Int64())
-			width := w.Index(i + 2).Float64()
-			wr := WidthRange2{glyph, endglyph, width}
-			cw.wmap2 = append(cw.wmap2, wr)
-			/*if code >= glyph && code < endglyph {
-				return width, true
-			}*/
+			i += 2
+			continue
 		}
+		last := uint32(next.CoerceInt64(0))
+		cw.vmap = append(cw.vmap, verticalRange{first, last, [][3]float64{{w2.Index(i + 2).CoerceFloat64(0), w2.Index(i + 3).CoerceFloat64(0), w2.Index(i + 4).CoerceFloat64(0)}}})
+		i += 5
 	}
-
 	return cw, true
 }
 
+// Vertical returns the vertical metrics of the CID for vertical
+// writing: the vertical displacement w1y, normally negative since
+// text advances downward, and the position vector v from the glyph's
+// horizontal origin to its vertical origin. CIDs without metrics of
+// their own take w1y and v.Y from DW2 and v.X as half their width.
+func (wg CIDWidthGrabber) Vertical(cid uint32) (w1y float64, v Point) {
+	for _, vr := range wg.vmap {
+		if cid < vr.start || cid > vr.end {
+			continue
+		}
+		m := vr.metrics[0]
+		if len(vr.metrics) > 1 {
+			m = vr.metrics[cid-vr.start]
+		}
+		return m[0], Point{m[1], m[2]}
+	}
+	return wg.dw2[1], Point{wg.Width(cid) / 2, wg.dw2[0]}
+}
+
 // Width returns the width of the given code point.
 func (wg CIDWidthGrabber) Width(code uint32) float64 {
 	for _, wr1 := range wg.wmap1{
@@ -128,7 +228,7 @@ func (wg CIDWidthGrabber) Width(code uint32) float64 {
 		}
 	}
 	for _, wr2 := range wg.wmap2{
-		if code >= wr2.start && code < wr2.end{
+		if code >= wr2.start && code <= wr2.end{
 			return wr2.width
 		}
 	}
@@ -137,108 +237,152 @@ func (wg CIDWidthGrabber) Width(code uint32) float64 {
 
 // BaseFont returns the font's name (BaseFont property).
 func (f Font) BaseFont() string {
-	return f.V.Key("BaseFont").Name()
+	return f.V.Key("BaseFont").CoerceName("")
+}
+
+// SubsetTag returns the tag that marks the font as a subset of the
+// font named by the rest of BaseFont: six uppercase letters, such as
+// EOODIA in EOODIA+Poetica. It returns "" if the font is not a subset.
+func (f Font) SubsetTag() string {
+	name := f.V.Key("BaseFont").CoerceName("")
+	if len(name) < 7 || name[6] != '+' {
+		return ""
+	}
+	for i := 0; i < 6; i++ {
+		if name[i] < 'A' || name[i] > 'Z' {
+			return ""
+		}
+	}
+	return name[:6]
+}
+
+// IsSubset reports whether the font's embedded program holds only
+// the glyphs used in the document, as its subset tag indicates.
+func (f Font) IsSubset() bool {
+	return f.SubsetTag() != ""
+}
+
+// CanonicalBaseFont returns the font's name without any subset tag,
+// so that subsets of the same font share a name.
+func (f Font) CanonicalBaseFont() string {
+	name := f.V.Key("BaseFont").CoerceName("")
+	if tag := f.SubsetTag(); tag != "" {
+		name = name[len(tag)+1:]
+	}
+	return name
 }
 
+// FontWeight returns the weight (thickness) of the font's glyphs,
+// from 100 to 900 with 400 normal and 700 bold, or 0 if unknown.
 func (f Font) FontWeight() float64 {
-	fd := f.V.Key("FontDescriptor")
+	return f.Descriptor().FontWeight
+}
 
-	if fd.Kind() == 0 {
-		fd = f.V.Key("DescendantFonts").Index(0).Key("FontDescriptor")
+// descriptor returns the font's FontDescriptor dictionary,
+// looking in the descendant font for composite fonts.
+func (f Font) descriptor() Value {
+	fd := f.V.Key("FontDescriptor")
+	if fd.Kind() == Null {
+		fd = f.descendant().Key("FontDescriptor")
+	}
+	return fd
+}
 
+// isBold reports whether the font appears to be bold,
+// judging by its weight, its descriptor flags and its name.
+func (f Font) isBold() bool {
+	d := f.Descriptor()
+	if d.FontWeight >= 600 || d.Flags&FlagForceBold != 0 {
+		return true
 	}
+	name := strings.ToLower(f.V.Key("BaseFont").CoerceName(""))
+	return strings.Contains(name, "bold") || strings.Contains(name, "black") || strings.Contains(name, "heavy")
+}
 
-	return fd.Key("FontWeight").Float64()
+// isItalic reports whether the font appears to be italic or oblique,
+// judging by its descriptor and its name.
+func (f Font) isItalic() bool {
+	d := f.Descriptor()
+	if d.Flags&FlagItalic != 0 || d.ItalicAngle != 0 {
+		return true
+	}
+	name := strings.ToLower(f.V.Key("BaseFont").CoerceName(""))
+	return strings.Contains(name, "italic") || strings.Contains(name, "oblique")
 }
 
 // FirstChar returns the code point of the first character in the font.
 func (f Font) FirstChar() int {
-	return int(f.V.Key("FirstChar").Int64())
+	return int(f.V.Key("FirstChar").CoerceInt64(0))
 }
 
 // LastChar returns the code point of the last character in the font.
 func (f Font) LastChar() int {
-	return int(f.V.Key("LastChar").Int64())
+	return int(f.V.Key("LastChar").CoerceInt64(0))
 }
 
-// Encoder returns the encoding between font code point sequences and UTF-8.
+// Encoder returns the encoding between font code point sequences and UTF-8,
+// trying the decoding strategies in the DefaultDecodeOrder.
 func Encoder(f Font, wg WidthGrabber) TextEncoding {
-	enc := f.V.Key("Encoding")
-	switch enc.Kind() {
-	case Name:
-		switch enc.Name() {
-		case "WinAnsiEncoding":
-			return &byteEncoder{f, wg, &winAnsiEncoding}
-		case "MacRomanEncoding":
-			return &byteEncoder{f, wg, &macRomanEncoding}
-		case "Identity-H", "Identity-V":
-			// TODO: Should be big-endian UCS-2 decoder
-		default:
-			println("unknown encoding", enc.Name())
-			return &nopEncoder{f, wg}
-		}
-	case Dict:
-		return &dictEncoder{f, wg, enc.Key("Differences")}
-	case Null:
-		// ok, try ToUnicode
-	default:
-		println("unexpected encoding", enc.String())
-		return &nopEncoder{f, wg}
-	}
+	e, _ := newEncoder(f, wg, DefaultDecodeOrder)
+	return e
+}
 
-	toUnicode := f.V.Key("ToUnicode")
+// dictTable returns the text table for an encoding dictionary,
+// which applies the Differences array to a base encoding.
+// The base is the BaseEncoding entry if present; otherwise it is
+// the font program's built-in encoding, or StandardEncoding
+// (WinAnsiEncoding for TrueType fonts) if that cannot be read.
+func (f Font) dictTable(enc Value) *[256][]rune {
+	var names [256]string
+	base := &standardEncoding
+	if f.isTrueType() {
+		base = &winAnsiEncoding
+	}
+	if t := namedEncoding(enc.Key("BaseEncoding").CoerceName("")); t != nil {
+		base = t
+	} else if b := f.builtinEncoding(); b != nil {
+		names = *b
+	} else if t := f.standardFontEncoding(); t != nil {
+		base = t
+	}
 
-	if toUnicode.Kind() == Stream {
-		m := readCmap(f, wg, toUnicode)
-		if m == nil {
-			return &nopEncoder{f, wg}
+	diff := enc.Key("Differences")
+	code := -1
+	for i := 0; i < diff.Len(); i++ {
+		x := diff.Index(i)
+		switch x.Kind() {
+		case Integer:
+			code = int(x.CoerceInt64(-1))
+		case Name:
+			if code >= 0 && code < 256 {
+				names[code] = x.CoerceName("")
+			}
+			code++
 		}
-		return m
 	}
-
-	return &byteEncoder{f, wg, &pdfDocEncoding}
-}
-
-type dictEncoder struct {
-	f  Font
-	wg WidthGrabber
-	v  Value
+	return namesTable(&names, base)
 }
 
 func (f Font) Decode(raw string) (text []PositionedChar) {
-	return f.enc.Decode(raw)
-}
-
-func (e *dictEncoder) Decode(raw string) (text []PositionedChar) {
-	r := []PositionedChar{}
-	for i := 0; i < len(raw); i++ {
-		ch := rune(raw[i])
-		n := -1
-		for j := 0; j < e.v.Len(); j++ {
-			x := e.v.Index(j)
-			if x.Kind() == Integer {
-				n = int(x.Int64())
-				continue
-			}
-			if x.Kind() == Name {
-				if int(raw[i]) == n {
-					r := nameToRune[x.Name()]
-					if r != 0 {
-						ch = r
-						break
-					}
-				}
-				n++
-			}
+	if f.enc == nil {
+		// A missing font: keep the bytes, with no width.
+		text = make([]PositionedChar, len(raw))
+		for i := range text {
+			text[i] = PositionedChar{Text: []rune{rune(raw[i])}}
 		}
-		r = append(r, PositionedChar{[]rune{ch}, e.wg.Width(uint32(ch))})
+		return text
 	}
-	return r
+	return f.enc.Decode(raw)
 }
 
 type PositionedChar struct {
 	Text  []rune
 	Width float64
+
+	// For fonts in vertical writing mode, the vertical displacement
+	// and the position vector of the glyph (see CIDWidthGrabber.Vertical).
+	VWidth  float64
+	VOrigin Point
 }
 
 // A TextEncoding represents a mapping between
@@ -249,184 +393,21 @@ type TextEncoding interface {
 	Decode(raw string) (text []PositionedChar)
 }
 
-type nopEncoder struct {
-	f  Font
-	wg WidthGrabber
-}
-
-func (e *nopEncoder) Decode(raw string) (text []PositionedChar) {
-	r := []PositionedChar{}
-	for i := 0; i < len(raw); i++ {
-		r = append(r, PositionedChar{[]rune{rune(raw[i])}, e.wg.Width(uint32(raw[i]))})
-	}
-	return r
-}
-
-type byteEncoder struct {
-	f     Font
-	wg    WidthGrabber
-	table *[256]rune
-}
-
-func (e *byteEncoder) Decode(raw string) (text []PositionedChar) {
-	r := []PositionedChar{}
-	for i := 0; i < len(raw); i++ {
-		r = append(r, PositionedChar{[]rune{e.table[raw[i]]}, e.wg.Width(uint32(raw[i]))})
-	}
-	return r
-}
-
-type cmap struct {
-	f       Font
-	wg      WidthGrabber
-	space   [4][][2]string
-	bfrange []bfrange
-}
-
-func arraydecode(utf16Strings Value) []rune {
-	var utf16CodePoints []uint16
-	for n := 0; n < utf16Strings.Len(); n++ {
-		for i := 0; i < len(utf16Strings.Index(n).RawString()); i += 2 {
-			// Assuming little-endian encoding for UTF-16
-			codePoint := uint16(utf16Strings.Index(n).RawString()[i]) + uint16(utf16Strings.Index(n).RawString()[i+1])<<8
-			utf16CodePoints = append(utf16CodePoints, codePoint)
-		}
-	}
-
-	// Decode UTF-16 to UTF-8
-	return utf16.Decode(utf16CodePoints)
-}
-
-func (m *cmap) Decode(raw string) (text []PositionedChar) {
-	r := []PositionedChar{}
-Parse:
-	for len(raw) > 0 { //Loop through raw string
-		for n := 1; n <= 4 && n <= len(raw); n++ { //Loop through codespace lengths n
-			for _, space := range m.space[n-1] { //Loop through codespaces
-				if space[0] <= raw[:n] && raw[:n] <= space[1] { //Check if character inside codespace
-					text := raw[:n]
-					raw = raw[n:]
-					for _, bf := range m.bfrange { //Loop through bfranges
-						if len(bf.lo) == n && bf.lo <= text && text <= bf.hi {
-							if bf.dst.Kind() == String {
-								s := bf.dst.RawString()
-								if bf.lo != text {
-									b := []byte(s)
-									b[len(b)-1] += text[len(text)-1] - bf.lo[len(bf.lo)-1]
-									s = string(b)
-								}
-								code := uint32(0)
-								for _, char := range s {
-									code = code << 8
-									code = code + uint32(char)
-								}
-								//fmt.Println("FOUND", s, code, m.wg.Width(code))
-
-								r = append(r, PositionedChar{[]rune(utf16Decode(s)), m.wg.Width(code)})
-								continue Parse
-							}
-							if bf.dst.Kind() == Array { //TODO this code doesn't work?
-								q := text[len(text)-1] - bf.lo[len(bf.lo)-1]
-								//TODO: make it work with multi-byte strings
-								r = append(r, PositionedChar{[]rune(utf16Decode(bf.dst.Index(int(q)).RawString())), m.wg.Width(uint32(text[len(text)-1]))})
-								//}
-							} else {
-								fmt.Printf("unknown dst %v\n", bf.dst)
-							}
-							r = append(r, PositionedChar{[]rune{noRune}, 0})
-							continue Parse
-						}
-					}
-					r = append(r, PositionedChar{[]rune{noRune}, 0})
-					continue Parse
-				}
-			}
-		}
-		println("no code space found")
-		r = append(r, PositionedChar{[]rune{noRune}, 0})
-		raw = raw[1:]
-	}
-	return r
-}
-
 type bfrange struct {
 	lo  string
 	hi  string
 	dst Value
 }
 
-func readCmap(f Font, wg WidthGrabber, toUnicode Value) *cmap {
-	n := -1
-	var m cmap
-	m.wg = wg
-	m.f = f
-	ok := true
-	Interpret(toUnicode, func(stk *Stack, op string) {
-		if !ok {
-			return
-		}
-		switch op {
-		case "findresource":
-			_ = stk.Pop()
-			_ = stk.Pop()
-			//fmt.Println("findresource", key, category)
-			stk.Push(newDict())
-		case "begincmap":
-			stk.Push(newDict())
-		case "endcmap":
-			stk.Pop()
-		case "begincodespacerange":
-			n = int(stk.Pop().Int64())
-		case "endcodespacerange":
-			if n < 0 {
-				println("missing begincodespacerange")
-				ok = false
-				return
-			}
-			for i := 0; i < n; i++ {
-				hi, lo := stk.Pop().RawString(), stk.Pop().RawString()
-				if len(lo) == 0 || len(lo) != len(hi) {
-					println("bad codespace range")
-					ok = false
-					return
-				}
-				m.space[len(lo)-1] = append(m.space[len(lo)-1], [2]string{lo, hi})
-			}
-			n = -1
-		case "beginbfrange":
-			n = int(stk.Pop().Int64())
-		case "endbfrange":
-			if n < 0 {
-				panic("missing beginbfrange")
-			}
-			for i := 0; i < n; i++ {
-				dst, srcHi, srcLo := stk.Pop(), stk.Pop().RawString(), stk.Pop().RawString()
-				m.bfrange = append(m.bfrange, bfrange{srcLo, srcHi, dst})
-			}
-		case "defineresource":
-			_ = stk.Pop().Name()
-			value := stk.Pop()
-			_ = stk.Pop().Name()
-			stk.Push(value)
-		case "CMapName":
-			_ = stk.Pop().Name()
-		case "beginbfchar":
-			n = int(stk.Pop().Int64())
-		case "endbfchar":
-			if n < 0 {
-				panic("missing beginbfchar")
-			}
-			for i := 0; i < n; i++ {
-				dst, srcLo := stk.Pop(), stk.Pop().RawString()
-				//fmt.Println(srcLo, dst)
-				m.bfrange = append(m.bfrange, bfrange{srcLo, srcLo, dst})
-			}
-		default:
-			println("interp\t", op)
-		}
-	})
-	if !ok {
+// readCmap reads the ToUnicode CMap stream toUnicode, along with any
+// CMap it builds on with usecmap, or returns nil if it is malformed.
+func readCmap(toUnicode Value) *cmapFile {
+	m, parent, err := parseCMapFile(toUnicode.Reader())
+	if err != nil {
 		return nil
 	}
-	return &m
+	if parent != "" {
+		m.use(loadCMap(parent))
+	}
+	return m
 }
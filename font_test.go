@@ -0,0 +1,92 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import "testing"
+
+// fontPDF returns a page using as /F1 the font with the dictionary
+// entries, followed by the extra objects, which are numbered from 6.
+func fontPDF(entries string, extra ...string) []byte {
+	return pagePDF("<</Font <</F1 5 0 R>>>>", "",
+		append([]string{"<</Type /Font " + entries + ">>"}, extra...)...)
+}
+
+func TestEncodingDifferences(t *testing.T) {
+	tests := []struct {
+		encoding string
+		raw      string
+		want     string
+	}{
+		// Differences replace codes of the base encoding; a name not
+		// in the glyph list leaves the base encoding's character.
+		{"<</Type /Encoding /BaseEncoding /MacRomanEncoding /Differences [65 /fi /B 200 /Euro /uni2192 /bogus]>>",
+			"AB\x8a\xc8\xc9\xcaC", "ﬁBä€→\u00a0C"},
+		// Without a BaseEncoding, a Type 1 font builds on StandardEncoding.
+		{"<</Differences [65 /fi]>>", "AB'`", "ﬁB’‘"},
+		{"<</BaseEncoding /WinAnsiEncoding>>", "\x80'", "€'"},
+	}
+	for _, tt := range tests {
+		f := openPDF(t, fontPDF("/Subtype /Type1 /BaseFont /Helvetica /Encoding "+tt.encoding)).Page(1).Font("F1")
+		if got := decodeText(f, tt.raw); got != tt.want {
+			t.Errorf("%s: %q decodes as %q, want %q", tt.encoding, tt.raw, got, tt.want)
+		}
+	}
+}
+
+// TestFontCache checks that a font dictionary shared by several pages
+// and forms is loaded once per Reader.
+func TestFontCache(t *testing.T) {
+	data := buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 792]>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>> /XObject <</Fm 7 0 R>>>> /Contents 6 0 R>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F2 5 0 R /F3 <</Type /Font /Subtype /Type1 /BaseFont /Courier>>>>>> /Contents 6 0 R>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>",
+		stream("", "BT /F1 10 Tf (a) Tj ET /Fm Do"),
+		stream("/Type /XObject /Subtype /Form /BBox [0 0 10 10] /Resources <</Font <</F9 5 0 R>>>>", "BT /F9 10 Tf (b) Tj ET"),
+	)
+	r := openPDF(t, data)
+	p1, p2 := r.Page(1), r.Page(2)
+	if _, err := p1.Content(); err != nil {
+		t.Fatal(err)
+	}
+	f1, f2 := p1.Font("F1"), p2.Font("F2")
+	if f1.enc == nil || f1.enc != f2.enc {
+		t.Errorf("pages sharing a font dictionary have different fonts")
+	}
+	if f3 := p2.Font("F3"); f3.BaseFont() != "Courier" {
+		t.Errorf("direct font dictionary loaded as %q", f3.BaseFont())
+	}
+	if len(r.fonts) != 1 {
+		t.Errorf("Reader caches %d fonts, want 1", len(r.fonts))
+	}
+}
+
+func TestSubsetTag(t *testing.T) {
+	tests := []struct {
+		name      string
+		tag       string
+		canonical string
+	}{
+		{"EOODIA+Poetica", "EOODIA", "Poetica"},
+		{"ABCDEF+Times-Bold", "ABCDEF", "Times-Bold"},
+		{"Poetica", "", "Poetica"},
+		{"abcdef+Poetica", "", "abcdef+Poetica"},
+		{"ABCDE+Poetica", "", "ABCDE+Poetica"},
+		{"ABCDEFG", "", "ABCDEFG"},
+	}
+	for _, tt := range tests {
+		f := openPDF(t, fontPDF("/Subtype /Type1 /BaseFont /"+tt.name)).Page(1).Font("F1")
+		if tag := f.SubsetTag(); tag != tt.tag {
+			t.Errorf("%s: SubsetTag() = %q, want %q", tt.name, tag, tt.tag)
+		}
+		if f.IsSubset() != (tt.tag != "") {
+			t.Errorf("%s: IsSubset() = %v", tt.name, f.IsSubset())
+		}
+		if c := f.CanonicalBaseFont(); c != tt.canonical {
+			t.Errorf("%s: CanonicalBaseFont() = %q, want %q", tt.name, c, tt.canonical)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Interactive forms (PDF 32000-1:2008, §12.7).
+
+package pdf
+
+import "io"
+
+// A FieldType is the type of an interactive form field.
+type FieldType int
+
+const (
+	UnknownField    FieldType = iota
+	TextField                 // text box
+	CheckboxField             // check box, on or off
+	RadioField                // set of radio buttons, at most one on
+	PushButtonField           // button with no value
+	ComboBoxField             // drop-down list of choices, perhaps editable
+	ListBoxField              // scrolling list of choices
+	SignatureField            // digital signature
+)
+
+var fieldTypeNames = [...]string{"Unknown", "Text", "Checkbox", "Radio", "PushButton", "ComboBox", "ListBox", "Signature"}
+
+func (t FieldType) String() string {
+	if t >= 0 && int(t) < len(fieldTypeNames) {
+		return fieldTypeNames[t]
+	}
+	return "FieldType(?)"
+}
+
+// FieldFlags are the characteristics of a form field given by its Ff
+// entry (PDF 32000-1:2008, Tables 221, 226, 228 and 230). Flags from
+// FieldMultiline on apply only to fields of some types.
+type FieldFlags uint32
+
+const (
+	FieldReadOnly          FieldFlags = 1 << 0  // the user may not change the value
+	FieldRequired          FieldFlags = 1 << 1  // the field must have a value when exported
+	FieldNoExport          FieldFlags = 1 << 2  // the field is not exported
+	FieldMultiline         FieldFlags = 1 << 12 // text: may hold several lines
+	FieldPassword          FieldFlags = 1 << 13 // text: not echoed visibly
+	FieldNoToggleToOff     FieldFlags = 1 << 14 // radio: exactly one button is on
+	FieldRadio             FieldFlags = 1 << 15 // button: radio buttons
+	FieldPushbutton        FieldFlags = 1 << 16 // button: push button
+	FieldCombo             FieldFlags = 1 << 17 // choice: combo box rather than list box
+	FieldEdit              FieldFlags = 1 << 18 // combo box: editable text
+	FieldSort              FieldFlags = 1 << 19 // choice: options should be sorted
+	FieldFileSelect        FieldFlags = 1 << 20 // text: names a file
+	FieldMultiSelect       FieldFlags = 1 << 21 // choice: several options may be selected
+	FieldDoNotSpellCheck   FieldFlags = 1 << 22 // text and choice: not spell checked
+	FieldDoNotScroll       FieldFlags = 1 << 23 // text: does not scroll
+	FieldComb              FieldFlags = 1 << 24 // text: divided into MaxLen equal cells
+	FieldRichText          FieldFlags = 1 << 25 // text: value is rich text
+	FieldRadiosInUnison    FieldFlags = 1 << 25 // radio: buttons with one value turn on together
+	FieldCommitOnSelChange FieldFlags = 1 << 26 // choice: committed on selection
+)
+
+// A Field is a terminal field of an interactive form:
+// one that has a value, rather than only grouping other fields.
+type Field struct {
+	Name     string // fully qualified name: the partial names of it and its ancestors, joined by periods
+	Type     FieldType
+	Flags    FieldFlags
+	Value    string        // current value: text, the selected choice, or a button's on state (Off if off)
+	Values   []string      // for list boxes allowing several selections, all the selected choices
+	Default  string        // value to which the field is reset
+	Options  []FieldOption // for choice fields, the choices; for check boxes and radio buttons, their export values
+	MaxLen   int           // for text fields, the maximum length of the value, or 0 if unlimited
+	Tooltip  string        // alternate name used in the user interface (TU)
+	Widgets  []Value       // the widget annotations showing the field
+	V        Value         // the field dictionary
+	inherits []Value       // the field dictionary and its ancestors, nearest first
+}
+
+// A FieldOption is a choice offered by a choice field.
+type FieldOption struct {
+	Export string // value of the field when the option is chosen
+	Label  string // text shown for the option
+}
+
+// maxFieldDepth limits the depth of the field hierarchy,
+// so that a cycle of Kids cannot be followed forever.
+const maxFieldDepth = 32
+
+// Form returns the terminal fields of the document's interactive form
+// (the catalog's AcroForm entry), in the order of the field hierarchy,
+// or nil if the document has no form. Field attributes inherited from
+// ancestors in the hierarchy are resolved.
+func (r *Reader) Form() []Field {
+	var fields []Field
+	seen := make(map[ObjRef]bool)
+	var walk func(v Value, parent string, ancestors []Value)
+	walk = func(v Value, parent string, ancestors []Value) {
+		if v.Kind() != Dict || len(ancestors) > maxFieldDepth {
+			return
+		}
+		if ref := v.Ref(); ref != (ObjRef{}) {
+			if seen[ref] {
+				return
+			}
+			seen[ref] = true
+		}
+		name := parent
+		if t := v.Key("T").CoerceText(""); t != "" {
+			if name != "" {
+				name += "."
+			}
+			name += t
+		}
+		inherits := append([]Value{v}, ancestors...)
+
+		// Kids with partial names are fields; others are widgets.
+		var widgets []Value
+		kids := v.Key("Kids")
+		isField := false
+		for i := 0; i < kids.Len(); i++ {
+			kid := kids.Index(i)
+			if kid.Key("T").Kind() != Null {
+				isField = true
+				walk(kid, name, inherits)
+			} else {
+				widgets = append(widgets, kid)
+			}
+		}
+		if isField {
+			return
+		}
+		if kids.Len() == 0 && v.Key("Subtype").CoerceName("") == "Widget" {
+			// A field merged with its single widget annotation.
+			widgets = []Value{v}
+		}
+		fields = append(fields, newField(v, name, inherits, widgets))
+	}
+	top := r.Trailer.Key("Root").Key("AcroForm").Key("Fields")
+	for i := 0; i < top.Len(); i++ {
+		walk(top.Index(i), "", nil)
+	}
+	return fields
+}
+
+// newField returns the Field for the terminal field dictionary v.
+func newField(v Value, name string, inherits []Value, widgets []Value) Field {
+	f := Field{Name: name, Widgets: widgets, V: v, inherits: inherits}
+	f.Flags = FieldFlags(f.inherited("Ff").CoerceInt64(0))
+	switch f.inherited("FT").CoerceName("") {
+	case "Tx":
+		f.Type = TextField
+	case "Btn":
+		switch {
+		case f.Flags&FieldPushbutton != 0:
+			f.Type = PushButtonField
+		case f.Flags&FieldRadio != 0:
+			f.Type = RadioField
+		default:
+			f.Type = CheckboxField
+		}
+	case "Ch":
+		f.Type = ListBoxField
+		if f.Flags&FieldCombo != 0 {
+			f.Type = ComboBoxField
+		}
+	case "Sig":
+		f.Type = SignatureField
+	}
+	f.Value, f.Values = fieldValue(f.inherited("V"))
+	f.Default, _ = fieldValue(f.inherited("DV"))
+	f.MaxLen = int(f.inherited("MaxLen").CoerceInt64(0))
+	f.Tooltip = v.Key("TU").CoerceText("")
+
+	switch f.Type {
+	case ComboBoxField, ListBoxField:
+		opt := f.inherited("Opt")
+		for i := 0; i < opt.Len(); i++ {
+			o := opt.Index(i)
+			if o.Kind() == Array {
+				f.Options = append(f.Options, FieldOption{o.Index(0).CoerceText(""), o.Index(1).CoerceText("")})
+			} else {
+				s := o.CoerceText("")
+				f.Options = append(f.Options, FieldOption{s, s})
+			}
+		}
+	case CheckboxField, RadioField:
+		// The on states are the names of the widgets' normal
+		// appearances other than Off. Opt, if present, gives the
+		// export value of each widget in turn, reported as the
+		// option's label.
+		opt := f.inherited("Opt")
+		for i, w := range f.Widgets {
+			for _, state := range w.Key("AP").Key("N").Keys() {
+				if state == "Off" {
+					continue
+				}
+				label := state
+				if i < opt.Len() {
+					label = opt.Index(i).CoerceText(state)
+				}
+				f.Options = append(f.Options, FieldOption{state, label})
+			}
+		}
+	}
+	if f.Type == CheckboxField || f.Type == RadioField {
+		if f.Value == "" {
+			f.Value = "Off"
+		}
+	}
+	return f
+}
+
+// inherited returns the entry key of the field dictionary
+// or, if it has none, of its nearest ancestor that does.
+func (f Field) inherited(key string) Value {
+	for _, v := range f.inherits {
+		if x := v.Key(key); x.Kind() != Null {
+			return x
+		}
+	}
+	return Value{}
+}
+
+// fieldValue returns the value v of a field as text: a text string,
+// a name or, for a multiple selection, the first of an array of text
+// strings, which are all returned in values.
+func fieldValue(v Value) (value string, values []string) {
+	switch v.Kind() {
+	case String:
+		return v.CoerceText(""), nil
+	case Name:
+		return v.CoerceName(""), nil
+	case Array:
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, v.Index(i).CoerceText(""))
+		}
+		if len(values) > 0 {
+			value = values[0]
+		}
+		return value, values
+	case Stream:
+		// A text field's rich value may be given as a stream.
+		if b, err := io.ReadAll(v.Reader()); err == nil {
+			return string(b), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFormFields(t *testing.T) {
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog /AcroForm <</Fields [2 0 R 5 0 R 8 0 R 9 0 R 10 0 R 2 0 R]>>>>",
+		// The group person holds the text fields person.name,
+		// which inherits its type and flags, and person.age.
+		"<</T (person) /FT /Tx /Ff 2 /Kids [3 0 R 4 0 R]>>",
+		"<</T (name) /Parent 2 0 R /V (Ann) /DV (nobody) /MaxLen 20 /TU (Your name) /Subtype /Widget>>",
+		"<</T (age) /Parent 2 0 R /Ff 1 /V <feff00340032>>>",
+		// A check box with two widgets, which is on.
+		"<</T (agree) /FT /Btn /V /Yes /Kids [6 0 R 7 0 R]>>",
+		"<</Subtype /Widget /Parent 5 0 R /AP <</N <</Yes 11 0 R /Off 11 0 R>>>>>>",
+		"<</Subtype /Widget /Parent 5 0 R>>",
+		"<</T (size) /FT /Btn /Ff 49152 /Opt [(Small) (Large)] /Kids [12 0 R 13 0 R]>>",
+		"<</T (tags) /FT /Ch /Ff 2097152 /V [(a) (c)] /Opt [(a) [(b) (Bee)] (c)]>>",
+		"<</T (color) /FT /Ch /Ff 131072 /Opt [[(r) (Red)]]>>",
+		stream("", ""),
+		"<</Subtype /Widget /Parent 8 0 R /AP <</N <</S 11 0 R /Off 11 0 R>>>>>>",
+		"<</Subtype /Widget /Parent 8 0 R /AP <</N <</L 11 0 R /Off 11 0 R>>>>>>",
+	))
+	fields := r.Form()
+	var got []string
+	for _, f := range fields {
+		got = append(got, fmt.Sprintf("%s %v %d %q %q %q %v %d", f.Name, f.Type, f.Flags, f.Value, f.Values, f.Default, f.Options, len(f.Widgets)))
+	}
+	// A field listed twice in Fields appears once.
+	want := []string{
+		`person.name Text 2 "Ann" [] "nobody" [] 1`,
+		`person.age Text 1 "42" [] "" [] 0`,
+		`agree Checkbox 0 "Yes" [] "" [{Yes Yes}] 2`,
+		`size Radio 49152 "Off" [] "" [{S Small} {L Large}] 2`,
+		`tags ListBox 2097152 "a" ["a" "c"] "" [{a a} {b Bee} {c c}] 0`,
+		`color ComboBox 131072 "" [] "" [{r Red}] 0`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields:\n%s\nwant\n%s", got, want)
+	}
+	if len(fields) > 0 && (fields[0].MaxLen != 20 || fields[0].Tooltip != "Your name" || fields[0].V.Ref() != (ObjRef{3, 0})) {
+		t.Errorf("person.name: MaxLen %d, Tooltip %q, object %v", fields[0].MaxLen, fields[0].Tooltip, fields[0].V.Ref())
+	}
+
+	if f := openPDF(t, buildPDF("", "<</Type /Catalog>>")).Form(); f != nil {
+		t.Errorf("Form() without a form = %v, want nil", f)
+	}
+}
+
+func TestFieldTypeString(t *testing.T) {
+	for typ, want := range map[FieldType]string{TextField: "Text", SignatureField: "Signature", UnknownField: "Unknown", 99: "FieldType(?)"} {
+		if s := typ.String(); s != want {
+			t.Errorf("FieldType(%d).String() = %q, want %q", int(typ), s, want)
+		}
+	}
+}
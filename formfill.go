@@ -0,0 +1,515 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Filling in interactive forms (PDF 32000-1:2008, §12.7.3).
+
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SetFieldValue sets the value of the form field with the fully
+// qualified name given, as Form reports it. For a text field, value is
+// the text; for a check box or radio button, the on state to turn on,
+// given as the option's export value or label, or Off; for a choice
+// field, one of its options, given as the export value or label, or
+// for an editable combo box any text.
+//
+// The appearances of text fields, combo boxes and check boxes are
+// regenerated so that viewers show the new value; where that cannot be
+// done, as for list boxes, fields whose fonts are missing or are Type 0
+// fonts and text the field's font cannot encode, the form's
+// NeedAppearances flag is set to ask viewers to regenerate them.
+// The changes are written by Save or SaveIncremental.
+func (r *Reader) SetFieldValue(name, value string) error {
+	for _, f := range r.Form() {
+		if f.Name != name {
+			continue
+		}
+		var complete bool
+		var err error
+		switch f.Type {
+		case TextField:
+			complete, err = r.setTextValue(f, value)
+		case CheckboxField, RadioField:
+			complete, err = r.setButtonValue(f, value)
+		case ComboBoxField, ListBoxField:
+			complete, err = r.setChoiceValue(f, value)
+		default:
+			return fmt.Errorf("pdf: cannot set the value of %v field %q", f.Type, name)
+		}
+		if err != nil || complete {
+			return err
+		}
+		return r.SetNeedAppearances(true)
+	}
+	return fmt.Errorf("pdf: no form field %q", name)
+}
+
+// SetNeedAppearances sets or clears the form's NeedAppearances flag,
+// which asks viewers to regenerate the appearances of its fields from
+// their values rather than show those stored in the document.
+func (r *Reader) SetNeedAppearances(need bool) error {
+	form := r.Trailer.Key("Root").Key("AcroForm")
+	if form.Kind() != Dict {
+		return fmt.Errorf("pdf: document has no interactive form")
+	}
+	if !need {
+		return form.SetKey("NeedAppearances", Value{})
+	}
+	return form.SetKey("NeedAppearances", NewBool(true))
+}
+
+// setTextValue sets the value of the text field f and reports
+// whether the appearances of all its widgets were regenerated.
+func (r *Reader) setTextValue(f Field, value string) (bool, error) {
+	if f.MaxLen > 0 && utf8.RuneCountInString(value) > f.MaxLen {
+		return false, fmt.Errorf("pdf: value of form field %q longer than its MaxLen %d", f.Name, f.MaxLen)
+	}
+	if err := f.V.SetKey("V", NewText(value)); err != nil {
+		return false, err
+	}
+	// A rich text value no longer matches.
+	if err := f.V.SetKey("RV", Value{}); err != nil {
+		return false, err
+	}
+	if f.Flags&FieldPassword != 0 {
+		value = strings.Repeat("*", utf8.RuneCountInString(value))
+	}
+	complete := true
+	for _, w := range f.Widgets {
+		if !r.textAppearance(f, w, value) {
+			complete = false
+		}
+	}
+	return complete, nil
+}
+
+// setChoiceValue sets the value of the choice field f and reports
+// whether the appearances of all its widgets were regenerated,
+// which is done only for combo boxes.
+func (r *Reader) setChoiceValue(f Field, value string) (bool, error) {
+	index := -1
+	for i, o := range f.Options {
+		if o.Export == value || o.Label == value {
+			index = i
+			break
+		}
+	}
+	shown := value
+	if index >= 0 {
+		value, shown = f.Options[index].Export, f.Options[index].Label
+	} else if f.Type != ComboBoxField || f.Flags&FieldEdit == 0 {
+		return false, fmt.Errorf("pdf: %q is not an option of form field %q", value, f.Name)
+	}
+	if err := f.V.SetKey("V", NewText(value)); err != nil {
+		return false, err
+	}
+	sel := Value{}
+	if index >= 0 {
+		sel = r.NewArray(NewInt(int64(index)))
+	}
+	if err := f.V.SetKey("I", sel); err != nil {
+		return false, err
+	}
+	if f.Type != ComboBoxField {
+		return false, nil
+	}
+	complete := true
+	for _, w := range f.Widgets {
+		if !r.textAppearance(f, w, shown) {
+			complete = false
+		}
+	}
+	return complete, nil
+}
+
+// setButtonValue turns on the on state value of the check box or
+// radio button field f, or turns it off, and reports whether all its
+// widgets have appearances for their new states. Check box widgets
+// with no appearances are given ones drawing a check mark.
+func (r *Reader) setButtonValue(f Field, value string) (bool, error) {
+	state := ""
+	for _, o := range f.Options {
+		if o.Export == value || o.Label == value {
+			state = o.Export
+			break
+		}
+	}
+	switch {
+	case value == "Off":
+		state = "Off"
+	case state == "" && f.Type == CheckboxField && len(f.Options) == 0:
+		// No widget has appearances naming its on state.
+		state = value
+	case state == "":
+		return false, fmt.Errorf("pdf: %q is not a state of form field %q", value, f.Name)
+	}
+	if err := f.V.SetKey("V", NewName(state)); err != nil {
+		return false, err
+	}
+	complete := true
+	for _, w := range f.Widgets {
+		n := w.Key("AP").Key("N")
+		if n.Kind() != Dict && f.Type == CheckboxField {
+			var ok bool
+			if n, ok = r.checkAppearance(f, w, state); !ok {
+				complete = false
+			}
+		}
+		as := "Off"
+		if n.Key(state).Kind() != Null {
+			as = state
+		} else if n.Kind() != Dict {
+			complete = false
+		}
+		if err := w.SetKey("AS", NewName(as)); err != nil {
+			return false, err
+		}
+	}
+	return complete, nil
+}
+
+// fieldPadding is the space, in default user space units, left
+// between the edge of a field's widget and the text it shows,
+// allowing for a border.
+const fieldPadding = 2
+
+// textAppearance sets the normal appearance of the widget w of the
+// text or combo box field f to one showing text, laid out as f's
+// default appearance string, quadding and flags say, and reports
+// whether it could: it cannot if the font the default appearance
+// string names is missing from the form's resources or is a Type 0
+// font, or if the font's encoding has no code for a character of text.
+func (r *Reader) textAppearance(f Field, w Value, text string) bool {
+	form := r.Trailer.Key("Root").Key("AcroForm")
+	da := parseDA(fieldDA(f, w))
+	if da.tf < 0 {
+		return false
+	}
+	name := strings.TrimPrefix(da.tokens[da.tf-2], "/")
+	fontv := form.Key("DR").Key("Font").Key(name)
+	if fontv.Kind() != Dict || fontv.Key("Subtype").CoerceName("") == "Type0" {
+		return false
+	}
+	font := FontFromValue(fontv)
+	enc := newFieldEncoding(font)
+	size, _ := strconv.ParseFloat(da.tokens[da.tf-1], 64)
+	width, height, rot := widgetBox(w)
+	avail := width - 2*fieldPadding
+	q := f.inherited("Q")
+	if q.Kind() == Null {
+		q = form.Key("Q")
+	}
+	quad := q.CoerceInt64(0)
+
+	// place returns the x at which a line of the width tw starts.
+	place := func(tw float64) float64 {
+		switch quad {
+		case 1:
+			return (width - tw) / 2
+		case 2:
+			return width - fieldPadding - tw
+		}
+		return fieldPadding
+	}
+
+	var b []byte
+	line := func(x, y float64, raw string) {
+		b = append(b, "1 0 0 1 "...)
+		b = appendReal(b, x)
+		b = append(b, ' ')
+		b = appendReal(b, y)
+		b = append(b, " Tm "...)
+		b = appendObject(b, raw)
+		b = append(b, " Tj\n"...)
+	}
+	comb := f.Flags&(FieldComb|FieldMultiline|FieldPassword|FieldFileSelect) == FieldComb && f.MaxLen > 0
+	switch {
+	case comb:
+		cell := width / float64(f.MaxLen)
+		if size <= 0 {
+			size = min(height-2*fieldPadding, cell) * 0.8
+		}
+		raw, ok := enc.encode(text)
+		if !ok {
+			return false
+		}
+		y := height/2 - 0.3*size
+		for i := 0; i < len(raw); i++ {
+			cw := fieldTextWidth(font, raw[i:i+1], size)
+			line(float64(i)*cell+(cell-cw)/2, y, raw[i:i+1])
+		}
+	case f.Flags&FieldMultiline != 0:
+		if size <= 0 {
+			size = 12
+		}
+		lead := size * 1.15
+		y := height - fieldPadding - 0.8*size
+		lines, ok := wrapFieldText(font, enc, text, size, avail)
+		if !ok {
+			return false
+		}
+		for _, raw := range lines {
+			line(place(fieldTextWidth(font, raw, size)), y, raw)
+			y -= lead
+		}
+	default:
+		raw, ok := enc.encode(strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(text))
+		if !ok {
+			return false
+		}
+		if size <= 0 {
+			size = (height - 2*fieldPadding) / 1.2
+			if tw := fieldTextWidth(font, raw, size); tw > avail && tw > 0 {
+				size *= avail / tw
+			}
+			size = max(size, 4)
+		}
+		line(place(fieldTextWidth(font, raw, size)), height/2-0.3*size, raw)
+	}
+	da.tokens[da.tf-1] = string(appendReal(nil, size))
+
+	c := []byte("/Tx BMC\nq\n")
+	c = appendReal(c, fieldPadding/2)
+	c = append(c, ' ')
+	c = appendReal(c, fieldPadding/2)
+	c = append(c, ' ')
+	c = appendReal(c, width-fieldPadding)
+	c = append(c, ' ')
+	c = appendReal(c, height-fieldPadding)
+	c = append(c, " re W n\nBT\n"...)
+	c = append(c, strings.Join(da.tokens, " ")...)
+	c = append(c, '\n')
+	c = append(c, b...)
+	c = append(c, "ET\nQ\nEMC\n"...)
+
+	res := r.NewDict()
+	fonts := r.NewDict()
+	fonts.SetKey(name, fontv)
+	res.SetKey("Font", fonts)
+	return r.setNormalAppearance(w, r.formXObject(width, height, rot, res, c))
+}
+
+// checkAppearance gives the check box widget w, which has none,
+// normal appearances for its on state, drawing the character its
+// MK entry's CA entry gives (by default a check mark) in ZapfDingbats,
+// and for Off, drawing nothing. It returns the appearances.
+func (r *Reader) checkAppearance(f Field, w Value, on string) (Value, bool) {
+	width, height, rot := widgetBox(w)
+	ch, _ := utf8.DecodeRuneInString(w.Key("MK").Key("CA").CoerceText(""))
+	if ch == utf8.RuneError || ch > 0xff {
+		ch = '4' // check mark
+	}
+	da := parseDA(fieldDA(f, w))
+	size := 0.0
+	name := "ZaDb"
+	if da.tf >= 0 {
+		size, _ = strconv.ParseFloat(da.tokens[da.tf-1], 64)
+		da.tokens = append(da.tokens[:da.tf-2], da.tokens[da.tf+1:]...)
+	}
+	if size <= 0 {
+		size = min(width, height) * 0.8
+	}
+	fontv := r.Trailer.Key("Root").Key("AcroForm").Key("DR").Key("Font").Key(name)
+	if fontv.Key("BaseFont").CoerceName("") != "ZapfDingbats" {
+		fontv = r.NewDict()
+		fontv.SetKey("Type", NewName("Font"))
+		fontv.SetKey("Subtype", NewName("Type1"))
+		fontv.SetKey("BaseFont", NewName("ZapfDingbats"))
+	}
+	res := r.NewDict()
+	fonts := r.NewDict()
+	fonts.SetKey(name, fontv)
+	res.SetKey("Font", fonts)
+
+	// The ZapfDingbats check mark is about 0.85 em wide
+	// and 0.7 em high.
+	c := []byte("q BT ")
+	if len(da.tokens) > 0 {
+		c = append(c, strings.Join(da.tokens, " ")...)
+		c = append(c, ' ')
+	}
+	c = appendObject(c, pdfname(name))
+	c = append(c, ' ')
+	c = appendReal(c, size)
+	c = append(c, " Tf "...)
+	c = appendReal(c, (width-0.85*size)/2)
+	c = append(c, ' ')
+	c = appendReal(c, (height-0.7*size)/2)
+	c = append(c, " Td "...)
+	c = appendObject(c, string([]byte{byte(ch)}))
+	c = append(c, " Tj ET Q\n"...)
+
+	n := r.NewDict()
+	if on != "Off" {
+		n.SetKey(on, r.formXObject(width, height, rot, res, c))
+	}
+	n.SetKey("Off", r.formXObject(width, height, rot, r.NewDict(), nil))
+	return n, r.setNormalAppearance(w, n)
+}
+
+// setNormalAppearance sets the normal appearance of the widget w to n,
+// removing its other appearances, and reports whether it succeeded.
+func (r *Reader) setNormalAppearance(w, n Value) bool {
+	ap := r.NewDict()
+	ap.SetKey("N", n)
+	return w.SetKey("AP", ap) == nil
+}
+
+// formXObject returns a new form XObject for a widget appearance
+// of the given width and height, rotated by rot degrees, with the
+// resources res and content c.
+func (r *Reader) formXObject(width, height float64, rot int, res Value, c []byte) Value {
+	hdr := r.NewDict()
+	hdr.SetKey("Type", NewName("XObject"))
+	hdr.SetKey("Subtype", NewName("Form"))
+	hdr.SetKey("BBox", r.NewArray(NewInt(0), NewInt(0), NewReal(width), NewReal(height)))
+	switch rot {
+	case 90:
+		hdr.SetKey("Matrix", r.NewArray(NewInt(0), NewInt(1), NewInt(-1), NewInt(0), NewInt(0), NewInt(0)))
+	case 180:
+		hdr.SetKey("Matrix", r.NewArray(NewInt(-1), NewInt(0), NewInt(0), NewInt(-1), NewInt(0), NewInt(0)))
+	case 270:
+		hdr.SetKey("Matrix", r.NewArray(NewInt(0), NewInt(-1), NewInt(1), NewInt(0), NewInt(0), NewInt(0)))
+	}
+	hdr.SetKey("Resources", res)
+	return r.NewStream(hdr, c)
+}
+
+// widgetBox returns the width and height of the widget w's appearance
+// and its rotation, a multiple of 90 degrees given by its MK entry.
+// The width runs along the rotated text, so for rotations of 90 and
+// 270 degrees it is the height of the widget's rectangle.
+func widgetBox(w Value) (width, height float64, rot int) {
+	rect := rectValue(w.Key("Rect"))
+	width, height = rect.Max.X-rect.Min.X, rect.Max.Y-rect.Min.Y
+	rot = int(w.Key("MK").Key("R").CoerceInt64(0)) % 360
+	if rot < 0 {
+		rot += 360
+	}
+	switch rot {
+	case 90, 270:
+		width, height = height, width
+	case 0, 180:
+	default:
+		rot = 0
+	}
+	return width, height, rot
+}
+
+// fieldDA returns the default appearance string of the widget w
+// of the field f: its own, its field's or the form's.
+func fieldDA(f Field, w Value) string {
+	if da := w.Key("DA"); da.Kind() == String {
+		return da.CoerceString("")
+	}
+	if da := f.inherited("DA"); da.Kind() == String {
+		return da.CoerceString("")
+	}
+	return w.r.Trailer.Key("Root").Key("AcroForm").Key("DA").CoerceString("")
+}
+
+// A defaultAppearance is a default appearance string (§12.7.3.3),
+// the operators that set up the text state for a field's text.
+type defaultAppearance struct {
+	tokens []string
+	tf     int // index of the Tf operator, whose operands precede it, or -1
+}
+
+// parseDA splits the default appearance string da into tokens.
+// Strings are not expected among the operands.
+func parseDA(da string) defaultAppearance {
+	d := defaultAppearance{tokens: strings.Fields(da), tf: -1}
+	for i, tok := range d.tokens {
+		if tok == "Tf" && i >= 2 && strings.HasPrefix(d.tokens[i-2], "/") {
+			d.tf = i
+		}
+	}
+	return d
+}
+
+// fieldTextWidth returns the width of raw, a string of character codes
+// of the simple font f, at the given font size. Characters for which
+// the font gives no width, as for standard fonts without a Widths
+// array, are taken to be half an em wide.
+func fieldTextWidth(f Font, raw string, size float64) float64 {
+	w := 0.0
+	for i := 0; i < len(raw); i++ {
+		cw := f.Width(uint32(raw[i]))
+		if cw == 0 {
+			cw = 500
+		}
+		w += cw
+	}
+	return w / 1000 * size
+}
+
+// wrapFieldText splits text into lines at its line breaks and between
+// words so that each fits in the width avail at the given size, and
+// returns them encoded with enc, or ok == false if enc cannot encode
+// them. A word too wide for a line of its own is left whole, to be
+// clipped.
+func wrapFieldText(f Font, enc fieldEncoding, text string, size, avail float64) (lines []string, ok bool) {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	space, ok := enc.encode(" ")
+	if !ok {
+		return nil, false
+	}
+	for _, para := range strings.Split(text, "\n") {
+		cur := ""
+		for _, word := range strings.Fields(para) {
+			word, ok := enc.encode(word)
+			if !ok {
+				return nil, false
+			}
+			next := word
+			if cur != "" {
+				next = cur + space + word
+			}
+			if cur != "" && fieldTextWidth(f, next, size) > avail {
+				lines = append(lines, cur)
+				next = word
+			}
+			cur = next
+		}
+		lines = append(lines, cur)
+	}
+	return lines, true
+}
+
+// A fieldEncoding maps characters to the codes of a simple font
+// that show them, inverting the font's encoding.
+type fieldEncoding map[rune]byte
+
+// newFieldEncoding returns the fieldEncoding of the simple font f.
+// Where several codes show a character, the lowest is used.
+func newFieldEncoding(f Font) fieldEncoding {
+	enc := make(fieldEncoding)
+	for code := 255; code >= 0; code-- {
+		text := f.Decode(string([]byte{byte(code)}))
+		if len(text) == 1 && len(text[0].Text) == 1 {
+			enc[text[0].Text[0]] = byte(code)
+		}
+	}
+	return enc
+}
+
+// encode returns text encoded as codes of the font, or ok == false
+// if the font's encoding has no code for one of its characters.
+func (enc fieldEncoding) encode(text string) (raw string, ok bool) {
+	b := make([]byte, 0, len(text))
+	for _, r := range text {
+		c, ok := enc[r]
+		if !ok {
+			return "", false
+		}
+		b = append(b, c)
+	}
+	return string(b), true
+}
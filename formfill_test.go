@@ -0,0 +1,204 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// formPDF returns a document with a form of four fields: the text
+// fields name, in the WinAnsiEncoding font Helv, and greek, a multiline
+// field in the font Grk, which shows α and β as codes 128 and 129;
+// the check box agree, which has no appearances and draws é (PDFDocEncoding
+// \351); and the combo box color.
+func formPDF() []byte {
+	return buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R /AcroForm <</Fields [5 0 R 6 0 R 7 0 R 8 0 R]"+
+			" /DR <</Font <</Helv 9 0 R /Grk 10 0 R>>>> /DA (/Helv 0 Tf 0 g)>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Annots [5 0 R 6 0 R 7 0 R 8 0 R]>>",
+		stream("", ""),
+		"<</Type /Annot /Subtype /Widget /FT /Tx /T (name) /Rect [72 600 300 620] /P 3 0 R>>",
+		"<</Type /Annot /Subtype /Widget /FT /Tx /T (greek) /Ff 4096 /DA (/Grk 10 Tf 0 g) /Rect [72 400 300 500] /P 3 0 R>>",
+		"<</Type /Annot /Subtype /Widget /FT /Btn /T (agree) /MK <</CA (\\351)>> /Rect [72 300 86 314] /P 3 0 R>>",
+		"<</Type /Annot /Subtype /Widget /FT /Ch /T (color) /Ff 131072 /Opt [[(r) (Red)] [(g) (Green)]] /Rect [72 200 300 220] /P 3 0 R>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Symbol /Encoding <</BaseEncoding /WinAnsiEncoding /Differences [128 /alpha /beta]>>>>",
+	)
+}
+
+// fieldAppearance returns the content of the normal appearance of
+// the first widget of the field name, or of its state on.
+func fieldAppearance(t *testing.T, r *Reader, name, on string) string {
+	t.Helper()
+	for _, f := range r.Form() {
+		if f.Name != name {
+			continue
+		}
+		n := f.Widgets[0].Key("AP").Key("N")
+		if on != "" {
+			n = n.Key(on)
+		}
+		if n.Kind() != Stream {
+			t.Fatalf("field %s has no appearance", name)
+		}
+		b, err := io.ReadAll(n.Reader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	t.Fatalf("no field %s", name)
+	return ""
+}
+
+// shows reports whether the content c shows the string raw.
+func shows(c, raw string) bool {
+	return strings.Contains(c, string(appendObject(nil, raw))+" Tj")
+}
+
+func TestSetFieldValue(t *testing.T) {
+	r := openPDF(t, formPDF())
+	for _, c := range [][2]string{
+		{"name", "Jane (Doe) – café"},
+		{"greek", "αβ α\nβ"},
+		{"agree", "Yes"},
+		{"color", "Green"},
+	} {
+		if err := r.SetFieldValue(c[0], c[1]); err != nil {
+			t.Fatalf("SetFieldValue(%q, %q): %v", c[0], c[1], err)
+		}
+	}
+	if err := r.SetFieldValue("color", "Blue"); err == nil {
+		t.Errorf("set color to Blue, which is not an option")
+	}
+	if err := r.SetFieldValue("nothing", "x"); err == nil {
+		t.Errorf("set a missing field")
+	}
+
+	var buf bytes.Buffer
+	if err := r.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	r = openPDF(t, buf.Bytes())
+	if r.Trailer.Key("Root").Key("AcroForm").Key("NeedAppearances").Kind() != Null {
+		t.Errorf("NeedAppearances set, though every appearance was made")
+	}
+	want := map[string]string{"name": "Jane (Doe) – café", "greek": "αβ α\nβ", "agree": "Yes", "color": "g"}
+	for _, f := range r.Form() {
+		if f.Value != want[f.Name] {
+			t.Errorf("field %s has value %q, want %q", f.Name, f.Value, want[f.Name])
+		}
+	}
+	if c := fieldAppearance(t, r, "name", ""); !shows(c, "Jane (Doe) \x96 caf\xe9") {
+		t.Errorf("name appearance does not show the value in WinAnsiEncoding:\n%s", c)
+	}
+	if c := fieldAppearance(t, r, "greek", ""); !shows(c, "\x80\x81 \x80") || !shows(c, "\x81") {
+		t.Errorf("greek appearance does not show the value in the font's encoding:\n%s", c)
+	}
+	if c := fieldAppearance(t, r, "agree", "Yes"); !shows(c, "\xe9") {
+		t.Errorf("agree appearance does not show its MK CA character:\n%s", c)
+	}
+	if c := fieldAppearance(t, r, "color", ""); !shows(c, "Green") {
+		t.Errorf("color appearance does not show the option's label:\n%s", c)
+	}
+}
+
+// TestSetFieldValueUnencodable checks that text the field's font
+// cannot show is left to viewers to draw.
+func TestSetFieldValueUnencodable(t *testing.T) {
+	for _, c := range [][2]string{
+		{"name", "日本"},
+		{"name", "α"},
+		{"greek", "→"},
+		{"greek", "α\n日本"},
+	} {
+		r := openPDF(t, formPDF())
+		if err := r.SetFieldValue(c[0], c[1]); err != nil {
+			t.Fatalf("SetFieldValue(%q, %q): %v", c[0], c[1], err)
+		}
+		if r.Trailer.Key("Root").Key("AcroForm").Key("NeedAppearances").data != true {
+			t.Errorf("SetFieldValue(%q, %q) did not set NeedAppearances", c[0], c[1])
+		}
+		if v := r.Form()[0].V.Key("V"); c[0] == "name" && v.CoerceText("") != c[1] {
+			t.Errorf("SetFieldValue(%q, %q) set the value %q", c[0], c[1], v.CoerceText(""))
+		}
+	}
+}
+
+func TestSetFieldValueKinds(t *testing.T) {
+	data := buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R /AcroForm <</Fields [4 0 R 5 0 R 6 0 R 9 0 R]"+
+			" /DR <</Font <</Helv 10 0 R>>>> /DA (/Helv 0 Tf 0 g)>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [4 0 R 5 0 R 7 0 R 8 0 R 9 0 R]>>",
+		"<</Type /Annot /Subtype /Widget /FT /Tx /T (pin) /Ff 8192 /MaxLen 4 /Rect [72 600 300 620] /P 3 0 R>>",
+		"<</Type /Annot /Subtype /Widget /FT /Ch /T (list) /Opt [(a) (b)] /Rect [72 500 300 560] /P 3 0 R>>",
+		"<</FT /Btn /T (radio) /Ff 49152 /Kids [7 0 R 8 0 R]>>",
+		"<</Type /Annot /Subtype /Widget /Parent 6 0 R /Rect [72 400 86 414] /P 3 0 R /AP <</N <</a 11 0 R /Off 11 0 R>>>> /AS /Off>>",
+		"<</Type /Annot /Subtype /Widget /Parent 6 0 R /Rect [92 400 106 414] /P 3 0 R /AP <</N <</b 11 0 R /Off 11 0 R>>>> /AS /Off>>",
+		"<</Type /Annot /Subtype /Widget /FT /Btn /T (check) /V /Yes /Rect [72 300 86 314] /P 3 0 R /AP <</N <</Yes 11 0 R /Off 11 0 R>>>> /AS /Yes>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		stream("/Type /XObject /Subtype /Form /BBox [0 0 14 14]", ""),
+	)
+	r := openPDF(t, data)
+	if err := r.SetFieldValue("pin", "12345"); err == nil {
+		t.Errorf("set pin to a value longer than its MaxLen")
+	}
+	if err := r.SetFieldValue("radio", "c"); err == nil {
+		t.Errorf("set radio to c, which is not a state")
+	}
+	for _, c := range [][2]string{{"pin", "1234"}, {"radio", "b"}, {"check", "Off"}} {
+		if err := r.SetFieldValue(c[0], c[1]); err != nil {
+			t.Fatalf("SetFieldValue(%q, %q): %v", c[0], c[1], err)
+		}
+	}
+	form := r.Trailer.Key("Root").Key("AcroForm")
+	if form.Key("NeedAppearances").Kind() != Null {
+		t.Errorf("NeedAppearances set before a list box was changed")
+	}
+	// List boxes are left to viewers to draw.
+	if err := r.SetFieldValue("list", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if form.Key("NeedAppearances").data != true {
+		t.Errorf("NeedAppearances not set for a list box")
+	}
+
+	r = save(t, r)
+	want := map[string]string{"pin": "1234", "list": "b", "radio": "b", "check": "Off"}
+	for _, f := range r.Form() {
+		if f.Value != want[f.Name] {
+			t.Errorf("field %s has value %q, want %q", f.Name, f.Value, want[f.Name])
+		}
+	}
+	if c := fieldAppearance(t, r, "pin", ""); !shows(c, "****") || strings.Contains(c, "1234") {
+		t.Errorf("password appearance shows more than asterisks:\n%s", c)
+	}
+	var states []string
+	for _, f := range r.Form() {
+		if f.Name == "radio" || f.Name == "check" {
+			for _, w := range f.Widgets {
+				states = append(states, w.Key("AS").CoerceName(""))
+			}
+		}
+	}
+	if strings.Join(states, " ") != "Off b Off" {
+		t.Errorf("widget states %q, want [Off b Off]", states)
+	}
+
+	if err := r.SetNeedAppearances(false); err != nil {
+		t.Fatal(err)
+	}
+	if r.Trailer.Key("Root").Key("AcroForm").Key("NeedAppearances").Kind() != Null {
+		t.Errorf("SetNeedAppearances(false) left the flag")
+	}
+	if err := openPDF(t, textPage()).SetNeedAppearances(true); err == nil {
+		t.Errorf("SetNeedAppearances succeeded on a document without a form")
+	}
+}
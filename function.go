@@ -0,0 +1,250 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Evaluation of PDF functions (PDF 32000-1:2008, §7.10).
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// maxFunctionDepth limits the nesting of stitching functions, which
+// a malformed file can make refer to themselves.
+const maxFunctionDepth = 16
+
+// evalFunction evaluates the function f at the input values x.
+// The function may also be an array of 1-output functions,
+// one per output component, as shadings allow.
+func evalFunction(f Value, x []float64) ([]float64, error) {
+	if f.Kind() == Array {
+		var out []float64
+		for i := 0; i < f.Len(); i++ {
+			y, err := evalFunctionDepth(f.Index(i), x, 0)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, y...)
+		}
+		return out, nil
+	}
+	return evalFunctionDepth(f, x, 0)
+}
+
+// evalFunctionDepth evaluates the function f, nested in depth
+// stitching functions, at the input values x.
+func evalFunctionDepth(f Value, x []float64, depth int) ([]float64, error) {
+	if depth > maxFunctionDepth {
+		return nil, fmt.Errorf("malformed PDF: stitching functions nested too deeply")
+	}
+	x = clipToRange(x, f.Key("Domain"))
+	var y []float64
+	switch typ := f.Key("FunctionType").CoerceInt64(-1); typ {
+	default:
+		return nil, fmt.Errorf("unsupported PDF: function type %d", typ)
+
+	case 0: // sampled
+		var err error
+		y, err = evalSampled(f, x)
+		if err != nil {
+			return nil, err
+		}
+
+	case 2: // exponential interpolation
+		c0, c1 := floats(f.Key("C0")), floats(f.Key("C1"))
+		if c0 == nil {
+			c0 = []float64{0}
+		}
+		if c1 == nil {
+			c1 = []float64{1}
+		}
+		if len(c0) != len(c1) || len(x) < 1 {
+			return nil, fmt.Errorf("malformed PDF: exponential function")
+		}
+		t := math.Pow(x[0], f.Key("N").CoerceFloat64(1))
+		y = make([]float64, len(c0))
+		for i := range y {
+			y[i] = c0[i] + t*(c1[i]-c0[i])
+		}
+
+	case 3: // stitching
+		fns := f.Key("Functions")
+		bounds := floats(f.Key("Bounds"))
+		encode := floats(f.Key("Encode"))
+		domain := floats(f.Key("Domain"))
+		k := fns.Len()
+		if k == 0 || len(bounds) != k-1 || len(encode) != 2*k || len(domain) < 2 || len(x) < 1 {
+			return nil, fmt.Errorf("malformed PDF: stitching function")
+		}
+		i := 0
+		for i < len(bounds) && x[0] >= bounds[i] {
+			i++
+		}
+		lo, hi := domain[0], domain[1]
+		if i > 0 {
+			lo = bounds[i-1]
+		}
+		if i < len(bounds) {
+			hi = bounds[i]
+		}
+		t := interpolate(x[0], lo, hi, encode[2*i], encode[2*i+1])
+		var err error
+		y, err = evalFunctionDepth(fns.Index(i), []float64{t}, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+	case 4: // PostScript calculator
+		prog, err := parseCalculator(f.Reader())
+		if err != nil {
+			return nil, err
+		}
+		stk, err := runCalculator(prog, x)
+		if err != nil {
+			return nil, err
+		}
+		n := len(floats(f.Key("Range"))) / 2
+		if len(stk) < n {
+			return nil, fmt.Errorf("malformed PDF: calculator function leaves %d results, want %d", len(stk), n)
+		}
+		y = stk[len(stk)-n:]
+	}
+	return clipToRange(y, f.Key("Range")), nil
+}
+
+// maxSampledInputs limits the number of inputs of a sampled function,
+// each of which doubles the samples read to interpolate a value.
+const maxSampledInputs = 8
+
+// evalSampled evaluates the sampled function f at x, interpolating
+// multilinearly between the samples surrounding x.
+func evalSampled(f Value, x []float64) ([]float64, error) {
+	size := floats(f.Key("Size"))
+	domain := floats(f.Key("Domain"))
+	rng := floats(f.Key("Range"))
+	m, n := len(size), len(rng)/2
+	bps := int(f.Key("BitsPerSample").CoerceInt64(0))
+	if m == 0 || m > maxSampledInputs || len(x) < m || len(domain) < 2*m || n == 0 || bps <= 0 || bps > 32 {
+		return nil, fmt.Errorf("malformed PDF: sampled function")
+	}
+	encode := floats(f.Key("Encode"))
+	if len(encode) < 2*m {
+		encode = make([]float64, 2*m)
+		for i := range size {
+			encode[2*i+1] = size[i] - 1
+		}
+	}
+	decode := floats(f.Key("Decode"))
+	if len(decode) < 2*n {
+		decode = rng
+	}
+	data, err := io.ReadAll(f.Reader())
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDF: sampled function: %v", err)
+	}
+
+	// e[i] is the position of x[i] in the samples along input i.
+	e := make([]float64, m)
+	for i := range e {
+		e[i] = interpolate(x[i], domain[2*i], domain[2*i+1], encode[2*i], encode[2*i+1])
+		e[i] = math.Max(0, math.Min(e[i], size[i]-1))
+	}
+	y := make([]float64, n)
+	for corner := 0; corner < 1<<m; corner++ {
+		weight, index, stride := 1.0, 0, 1
+		for i := range e {
+			lo := math.Floor(e[i])
+			frac := e[i] - lo
+			c := int(lo)
+			if corner>>i&1 != 0 {
+				c = min(c+1, int(size[i])-1)
+				weight *= frac
+			} else {
+				weight *= 1 - frac
+			}
+			index += c * stride
+			stride *= int(size[i])
+		}
+		if weight == 0 {
+			continue
+		}
+		for j := range y {
+			bit := (index*n + j) * bps
+			if (bit+bps+7)/8 > len(data) {
+				return nil, fmt.Errorf("malformed PDF: sampled function data too short")
+			}
+			y[j] += weight * float64(bitsAt(data, bit, bps))
+		}
+	}
+	maxSample := math.Exp2(float64(bps)) - 1
+	for j := range y {
+		y[j] = interpolate(y[j], 0, maxSample, decode[2*j], decode[2*j+1])
+	}
+	return y, nil
+}
+
+// bitsAt returns the n-bit big-endian value starting at the given bit of data.
+func bitsAt(data []byte, bit, n int) uint32 {
+	x := uint32(0)
+	for i := bit; i < bit+n; i++ {
+		x = x<<1 | uint32(data[i/8]>>(7-i%8))&1
+	}
+	return x
+}
+
+// describeFunction returns a short human-readable summary of the function f.
+func describeFunction(f Value) string {
+	if f.Kind() == Array {
+		return fmt.Sprintf("%d functions, one per component", f.Len())
+	}
+	switch typ := f.Key("FunctionType").CoerceInt64(-1); typ {
+	case 0:
+		return fmt.Sprintf("sampled (%d bits per sample)", f.Key("BitsPerSample").CoerceInt64(0))
+	case 2:
+		return fmt.Sprintf("exponential (N=%g)", f.Key("N").CoerceFloat64(1))
+	case 3:
+		return fmt.Sprintf("stitching of %d functions", f.Key("Functions").Len())
+	case 4:
+		return "PostScript calculator"
+	default:
+		return fmt.Sprintf("unknown function type %d", typ)
+	}
+}
+
+// interpolate maps x from the range [xmin, xmax] to [ymin, ymax].
+func interpolate(x, xmin, xmax, ymin, ymax float64) float64 {
+	if xmax == xmin {
+		return ymin
+	}
+	return ymin + (x-xmin)*(ymax-ymin)/(xmax-xmin)
+}
+
+// clipToRange clips each x[i] to the interval [r[2i], r[2i+1]]
+// given by the array r. Values without a corresponding interval are unchanged.
+func clipToRange(x []float64, r Value) []float64 {
+	lim := floats(r)
+	out := make([]float64, len(x))
+	for i, v := range x {
+		if 2*i+1 < len(lim) {
+			v = math.Max(lim[2*i], math.Min(v, lim[2*i+1]))
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// floats returns the numbers in the array v.
+// If v is not an array, floats returns nil.
+func floats(v Value) []float64 {
+	if v.Kind() != Array {
+		return nil
+	}
+	x := make([]float64, v.Len())
+	for i := range x {
+		x[i] = v.Index(i).CoerceFloat64(0)
+	}
+	return x
+}
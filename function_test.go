@@ -0,0 +1,165 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEvalFunction(t *testing.T) {
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog>>",
+		"<</FunctionType 2 /Domain [0 1] /C0 [0 0] /C1 [1 0.5] /N 1>>",
+		"<</FunctionType 2 /Domain [0 1] /C0 [1] /C1 [0] /N 2>>",
+		"<</FunctionType 3 /Domain [0 1] /Functions [2 0 R 3 0 R] /Bounds [0.5] /Encode [0 1 0 1]>>",
+		stream("/FunctionType 4 /Domain [0 1] /Range [0 1 0 1]", "{ dup 2 div }"),
+		stream("/FunctionType 0 /Domain [0 1] /Range [0 1] /Size [2] /BitsPerSample 8", "\x00\xff"),
+		"[3 0 R 3 0 R]",
+	))
+	tests := []struct {
+		id   uint32
+		x    float64
+		want string
+	}{
+		{2, 0.5, "[0.5 0.25]"},
+		{3, 0.5, "[0.75]"},
+		{4, 0.25, "[0.5 0.25]"},
+		{4, 0.75, "[0.75]"},
+		{5, 0.5, "[0.5 0.25]"},
+		{6, 0.25, "[0.25]"},
+		{7, 0.5, "[0.75 0.75]"},
+	}
+	for _, tt := range tests {
+		y, err := evalFunction(object(r, tt.id), []float64{tt.x})
+		if err != nil {
+			t.Errorf("function %d at %g: %v", tt.id, tt.x, err)
+		} else if fmt.Sprint(y) != tt.want {
+			t.Errorf("function %d at %g = %v, want %s", tt.id, tt.x, y, tt.want)
+		}
+	}
+}
+
+// TestEvalFunctionCycle evaluates stitching functions that refer to
+// themselves, which must fail rather than recurse without end.
+func TestEvalFunctionCycle(t *testing.T) {
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog>>",
+		"<</FunctionType 3 /Domain [0 1] /Functions [2 0 R] /Bounds [] /Encode [0 1]>>",
+		"<</FunctionType 3 /Domain [0 1] /Functions [4 0 R 4 0 R] /Bounds [0.5] /Encode [0 1 0 1]>>",
+		"<</FunctionType 3 /Domain [0 1] /Functions [3 0 R 3 0 R] /Bounds [0.5] /Encode [0 1 0 1]>>",
+		"[5 0 R]",
+		"<</FunctionType 3 /Domain [0 1] /Functions [5 0 R] /Bounds [] /Encode [0 1]>>",
+	))
+	for id := uint32(2); id <= 6; id++ {
+		f := object(r, id)
+		if _, err := evalFunction(f, []float64{0.5}); err == nil {
+			t.Errorf("function %d evaluated without error", id)
+		}
+		if linearFunction(f) {
+			t.Errorf("function %d is linear", id)
+		}
+	}
+}
+
+func TestLinearFunction(t *testing.T) {
+	// Object 5 is a stitching function that uses one function
+	// many times, which must be checked only once.
+	fns := strings.TrimSpace(strings.Repeat("2 0 R ", 64))
+	bounds := make([]string, 63)
+	for i := range bounds {
+		bounds[i] = fmt.Sprint(float64(i+1) / 64)
+	}
+	encode := strings.TrimSpace(strings.Repeat("0 1 ", 64))
+	var nested []string
+	for i := 0; i < 8; i++ {
+		nested = append(nested, fmt.Sprintf("<</FunctionType 3 /Domain [0 1] /Functions [%s] /Bounds [%s] /Encode [%s]>>",
+			strings.TrimSpace(strings.Repeat(fmt.Sprintf("%d 0 R ", 5+i), 64)), strings.Join(bounds, " "), encode))
+	}
+	objs := append([]string{
+		"<</Type /Catalog>>",
+		"<</FunctionType 2 /Domain [0 1] /C0 [0] /C1 [1] /N 1>>",
+		"<</FunctionType 2 /Domain [0 1] /C0 [0] /C1 [1] /N 3>>",
+		"[2 0 R 2 0 R]",
+		fmt.Sprintf("<</FunctionType 3 /Domain [0 1] /Functions [%s] /Bounds [%s] /Encode [%s]>>", fns, strings.Join(bounds, " "), encode),
+	}, nested...)
+	r := openPDF(t, buildPDF("", objs...))
+	tests := []struct {
+		id   uint32
+		want bool
+	}{
+		{2, true},
+		{3, false},
+		{4, true},
+		{5, true},
+		{6, true},
+	}
+	for _, tt := range tests {
+		if got := linearFunction(object(r, tt.id)); got != tt.want {
+			t.Errorf("linearFunction(%d) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestShadingFunctionCycle(t *testing.T) {
+	r := openPDF(t, pagePDF("<</Shading <</Sh 5 0 R>>>>", "/Sh sh",
+		"<</ShadingType 2 /ColorSpace /DeviceGray /Coords [0 0 100 0] /Function 6 0 R>>",
+		"<</FunctionType 3 /Domain [0 1] /Functions [6 0 R 6 0 R] /Bounds [0.5] /Encode [0 1 0 1]>>",
+	))
+	c, err := r.Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Shadings) != 1 || len(c.Shadings[0].Stops) != 0 {
+		t.Errorf("shadings %+v, want one with no color stops", c.Shadings)
+	}
+}
+
+func TestCalculator(t *testing.T) {
+	tests := []struct {
+		prog string
+		x    []float64
+		want string
+	}{
+		{"{ add 2 mul }", []float64{1, 2}, "[6]"},
+		{"{ 7 exch idiv 7 3 mod }", []float64{2}, "[3 1]"},
+		{"{ dup 0.5 gt { 1 } { 0 } ifelse }", []float64{0.7}, "[0.7 1]"},
+		{"{ dup 0 lt { neg } if sqrt }", []float64{-4}, "[2]"},
+		{"{ 1 2 3 3 1 roll 2 index }", nil, "[3 1 2 3]"},
+		{"{ 2 copy pop }", []float64{1, 2}, "[1 2 1]"},
+		{"{ 1.5 round 2.5 truncate -1.5 floor 1.2 ceiling }", nil, "[2 2 -2 2]"},
+		{"{ 5 3 and 1 4 bitshift true not { 1 } if }", nil, "[1 16]"},
+		{"{ 90 sin 0 cos 1 1 atan }", nil, "[1 1 45]"},
+		{"{ 100 log 2 3 exp }", nil, "[2 8]"},
+		{"{ 1 2 eq 1 1 eq and { 1 } { 0 } ifelse }", nil, "[0]"},
+		// Errors.
+		{"{ pop }", nil, "error"},
+		{"{ 1 0 idiv }", nil, "error"},
+		{"{ true 1 add }", nil, "error"},
+		{"{ 1 frob }", nil, "error"},
+		{"{ true { 1 } { 2 } if }", nil, "error"},
+		{"1 2 add", nil, "error"},
+		{"{ 1 2 add", nil, "error"},
+	}
+	for _, tt := range tests {
+		prog, err := parseCalculator(strings.NewReader(tt.prog))
+		var stk []float64
+		if err == nil {
+			stk, err = runCalculator(prog, tt.x)
+		}
+		got := "error"
+		if err == nil {
+			for i := range stk {
+				stk[i] = math.Round(stk[i]*1e9) / 1e9
+			}
+			got = fmt.Sprint(stk)
+		}
+		if got != tt.want {
+			t.Errorf("%s with %v = %s, want %s", tt.prog, tt.x, got, tt.want)
+		}
+	}
+}
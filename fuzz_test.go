@@ -0,0 +1,149 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+// The fuzz targets below check that malformed input cannot crash the
+// package. Run one with, for example,
+//
+//	go test -fuzz=FuzzNewReader
+
+// noCrash, deferred, fails the test if the function panics with a
+// runtime error, such as an index out of range. The lexer reports
+// malformed input by panicking with other errors, which its callers
+// recover from.
+func noCrash(t *testing.T) {
+	if e := recover(); e != nil {
+		if _, ok := e.(runtime.Error); ok {
+			t.Fatalf("panic: %v\n%s", e, debug.Stack())
+		}
+	}
+}
+
+func FuzzLexer(f *testing.F) {
+	for _, s := range []string{
+		"<</A [1 -2.5 (x\\)y\\q\\777) <41 4>] /B 3 0 R /C#20D null>>",
+		"1 0 obj\n<</Length 2>>\nstream\nxx\nendstream\nendobj",
+		"[[[[[[[[",
+		"(unterminated",
+		"<</Contents (sig)>>",
+	} {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		defer noCrash(t)
+		b := newPdfBuffer(bytes.NewReader(data), 0)
+		b.allowEOF = true
+		for i := 0; i <= len(data); i++ {
+			tok := b.readToken()
+			if tok == io.EOF {
+				break
+			}
+			b.unreadToken(tok)
+			b.readObject()
+		}
+	})
+}
+
+func FuzzNewReader(f *testing.F) {
+	f.Add(pagePDF("<</Font <</F1 5 0 R>>>>", "BT /F1 12 Tf 72 700 Td (hello) Tj ET",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>"))
+	for _, objstm := range []bool{false, true} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.ObjectStreams = objstm
+		w.NewPage(612, 792).ShowText(72, 700, "hello")
+		if err := w.Close(); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		for i := 1; i <= min(r.NumPage(), 4); i++ {
+			r.Page(i).Content()
+		}
+	})
+}
+
+func FuzzFilter(f *testing.F) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte("\x02abc\x02def"))
+	zw.Close()
+	f.Add(z.Bytes(), int64(12), int64(3))
+	f.Add(z.Bytes(), int64(1), int64(1))
+	f.Add([]byte("not zlib"), int64(0), int64(0))
+	f.Fuzz(func(t *testing.T, data []byte, predictor, columns int64) {
+		hdr := pdfdict{
+			"Filter":      pdfname("FlateDecode"),
+			"DecodeParms": pdfdict{"Predictor": predictor, "Columns": columns},
+		}
+		strm := Value{r: &Reader{}, data: pdfstream{hdr: hdr, mem: true, data: data}}
+		io.Copy(io.Discard, io.LimitReader(strm.Reader(), 1<<20))
+	})
+}
+
+func FuzzContent(f *testing.F) {
+	for _, s := range []string{
+		"q 1 0 0 1 10 10 cm 0 0 m 10 10 l S Q",
+		"BT /F1 12 Tf 72 700 Td [(Hel) -20 (lo)] TJ ET",
+		"BI /W 2 /H 1 /BPC 8 /CS /G ID \x00\xff EI",
+		"/Fm0 Do /CS0 cs 0.5 scn",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, content string) {
+		data := pagePDF("<</Font <</F1 5 0 R>> /XObject <</Fm0 6 0 R>>>>", content,
+			"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>",
+			stream("/Type /XObject /Subtype /Form /BBox [0 0 10 10]", "/Fm0 Do"))
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Page(1).Content()
+	})
+}
+
+func FuzzImages(f *testing.F) {
+	f.Add(pagePDF("<</XObject <</Im0 5 0 R>>>>", "/Im0 Do",
+		stream(oversizedImage, "\x00\x01\x02\x03"), tintFunction))
+	f.Add(pagePDF("<</XObject <</Im0 5 0 R /Im1 7 0 R>>>>", "/Im0 Do /Im1 Do BI /IM true /W 8 /H 1 ID \xaa EI",
+		stream("/Subtype /Image /Width 2 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8 /SMask 6 0 R", "\x00\xff"),
+		stream("/Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8", "\x80"),
+		stream("/Subtype /Image /Width 2 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode",
+			jpegData(2, 2, 0x80))))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		for i := 1; i <= min(r.NumPage(), 4); i++ {
+			p := r.Page(i)
+			p.Images()
+			c, err := p.Content()
+			if err != nil {
+				continue
+			}
+			for _, img := range c.Images {
+				img.DecodeMasked()
+			}
+			for _, img := range c.InlineImages {
+				img.Decode()
+			}
+		}
+	})
+}
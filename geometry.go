@@ -0,0 +1,163 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Geometry of paths: flattening, bounds and hit testing.
+
+package pdf
+
+import "math"
+
+// Flatten returns the path as a polyline: a sequence of points joined by
+// straight segments. Bezier curves are subdivided until no point on the
+// curve is farther than tolerance from the polyline; a tolerance of zero
+// or less means 0.1 point. A rectangle becomes its four corners, with the
+// first repeated at the end to close it.
+func (p Path) Flatten(tolerance float64) []Point {
+	if tolerance <= 0 {
+		tolerance = 0.1
+	}
+	switch p.Kind {
+	case "bezier":
+		if len(p.Points) != 4 {
+			break
+		}
+		pts := []Point{p.Points[0]}
+		return flattenCubic(pts, p.Points[0], p.Points[1], p.Points[2], p.Points[3], tolerance, 0)
+	case "rect":
+		if len(p.Points) != 2 {
+			break
+		}
+		a, b := p.Points[0], p.Points[1]
+		return []Point{a, {b.X, a.Y}, b, {a.X, b.Y}, a}
+	}
+	return append([]Point(nil), p.Points...)
+}
+
+// maxFlattenDepth limits the subdivision of a single curve to 2^16 segments.
+const maxFlattenDepth = 16
+
+// flattenCubic appends to pts the end points of the line segments
+// approximating the cubic Bezier curve p0 p1 p2 p3, excluding p0.
+func flattenCubic(pts []Point, p0, p1, p2, p3 Point, tolerance float64, depth int) []Point {
+	if depth >= maxFlattenDepth || lineDist(p1, p0, p3) <= tolerance && lineDist(p2, p0, p3) <= tolerance {
+		return append(pts, p3)
+	}
+	// Split at t = 1/2 (de Casteljau).
+	p01, p12, p23 := midpoint(p0, p1), midpoint(p1, p2), midpoint(p2, p3)
+	p012, p123 := midpoint(p01, p12), midpoint(p12, p23)
+	m := midpoint(p012, p123)
+	pts = flattenCubic(pts, p0, p01, p012, m, tolerance, depth+1)
+	return flattenCubic(pts, m, p123, p23, p3, tolerance, depth+1)
+}
+
+func midpoint(a, b Point) Point {
+	return Point{(a.X + b.X) / 2, (a.Y + b.Y) / 2}
+}
+
+// lineDist returns the distance from p to the line through a and b,
+// or to a if a and b coincide.
+func lineDist(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	d := math.Hypot(dx, dy)
+	if d == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	return math.Abs(dx*(a.Y-p.Y)-dy*(a.X-p.X)) / d
+}
+
+// Bounds returns the smallest rectangle containing the path.
+// For a Bezier curve this is the bounding box of the curve itself,
+// which may be smaller than that of its control points.
+// The line width is not included.
+func (p Path) Bounds() Rectangle {
+	if len(p.Points) == 0 {
+		return Rectangle{}
+	}
+	pts := p.Points
+	if p.Kind == "bezier" && len(pts) == 4 {
+		pts = append([]Point{pts[0], pts[3]}, cubicExtrema(pts[0], pts[1], pts[2], pts[3])...)
+	}
+	r := Rectangle{pts[0], pts[0]}
+	for _, pt := range pts[1:] {
+		r = unionRect(r, Rectangle{pt, pt})
+	}
+	return r
+}
+
+// cubicExtrema returns the points of the cubic Bezier curve p0 p1 p2 p3
+// at which its X or Y coordinate reaches a local minimum or maximum.
+func cubicExtrema(p0, p1, p2, p3 Point) []Point {
+	var pts []Point
+	var ts []float64
+	for _, c := range [][4]float64{{p0.X, p1.X, p2.X, p3.X}, {p0.Y, p1.Y, p2.Y, p3.Y}} {
+		// The derivative is the quadratic a t² + b t + c.
+		a := 3 * (-c[0] + 3*c[1] - 3*c[2] + c[3])
+		b := 6 * (c[0] - 2*c[1] + c[2])
+		k := 3 * (c[1] - c[0])
+		ts = append(ts, quadraticRoots(a, b, k)...)
+	}
+	for _, t := range ts {
+		if t <= 0 || t >= 1 {
+			continue
+		}
+		u := 1 - t
+		pts = append(pts, Point{
+			u*u*u*p0.X + 3*u*u*t*p1.X + 3*u*t*t*p2.X + t*t*t*p3.X,
+			u*u*u*p0.Y + 3*u*u*t*p1.Y + 3*u*t*t*p2.Y + t*t*t*p3.Y,
+		})
+	}
+	return pts
+}
+
+// quadraticRoots returns the real roots of a x² + b x + c.
+func quadraticRoots(a, b, c float64) []float64 {
+	const eps = 1e-12
+	if math.Abs(a) < eps {
+		if math.Abs(b) < eps {
+			return nil
+		}
+		return []float64{-c / b}
+	}
+	d := b*b - 4*a*c
+	if d < 0 {
+		return nil
+	}
+	sq := math.Sqrt(d)
+	return []float64{(-b + sq) / (2 * a), (-b - sq) / (2 * a)}
+}
+
+// Contains reports whether pt lies inside the area enclosed by the path,
+// using the even-odd rule if p.EvenOdd is set and the nonzero winding
+// number rule otherwise. The path is flattened with the default tolerance
+// and implicitly closed, as for filling.
+// A single line segment encloses no area.
+func (p Path) Contains(pt Point) bool {
+	w := polygonWinding(p.Flatten(0), pt)
+	if p.EvenOdd {
+		return w%2 != 0
+	}
+	return w != 0
+}
+
+// polygonWinding returns the winding number of the closed polygon poly around pt.
+func polygonWinding(poly []Point, pt Point) int {
+	n := len(poly)
+	if n < 3 {
+		return 0
+	}
+	w := 0
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%n]
+		// Cross product sign: positive if pt is left of a→b.
+		cross := (b.X-a.X)*(pt.Y-a.Y) - (pt.X-a.X)*(b.Y-a.Y)
+		if a.Y <= pt.Y {
+			if b.Y > pt.Y && cross > 0 {
+				w++
+			}
+		} else if b.Y <= pt.Y && cross < 0 {
+			w--
+		}
+	}
+	return w
+}
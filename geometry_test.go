@@ -0,0 +1,120 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Tests for path geometry.
+
+package pdf
+
+import (
+	"math"
+	"testing"
+)
+
+// quarter is a Bezier approximation of the quarter circle of radius 100
+// centred on the origin, from (100, 0) to (0, 100).
+var quarter = Path{Kind: "bezier", Points: []Point{{100, 0}, {100, 55.23}, {55.23, 100}, {0, 100}}}
+
+func TestFlatten(t *testing.T) {
+	for _, tol := range []float64{1, 0.1, 0.01} {
+		pts := quarter.Flatten(tol)
+		if pts[0] != quarter.Points[0] || pts[len(pts)-1] != quarter.Points[3] {
+			t.Errorf("tolerance %v: polyline runs from %v to %v, want the curve's end points", tol, pts[0], pts[len(pts)-1])
+		}
+		for i := 1; i < len(pts); i++ {
+			// The chord midpoints lie near the circle, by no more
+			// than the tolerance plus the approximation's own error.
+			m := midpoint(pts[i-1], pts[i])
+			if d := 100 - math.Hypot(m.X, m.Y); d < -0.03 || d > tol+0.03 {
+				t.Errorf("tolerance %v: chord %v-%v is %.3f from the curve", tol, pts[i-1], pts[i], d)
+			}
+		}
+		if tol == 0.01 && len(pts) < 8 {
+			t.Errorf("tolerance 0.01: only %d points", len(pts))
+		}
+	}
+	if n, m := len(quarter.Flatten(0)), len(quarter.Flatten(0.1)); n != m {
+		t.Errorf("tolerance 0 gives %d points, tolerance 0.1 %d", n, m)
+	}
+
+	rect := Path{Kind: "rect", Points: []Point{{10, 20}, {30, 50}}}
+	want := []Point{{10, 20}, {30, 20}, {30, 50}, {10, 50}, {10, 20}}
+	if got := rect.Flatten(0); !equalPoints(got, want) {
+		t.Errorf("rectangle flattened to %v, want %v", got, want)
+	}
+	line := Path{Kind: "line", Points: []Point{{0, 0}, {5, 5}}}
+	if got := line.Flatten(0); !equalPoints(got, line.Points) {
+		t.Errorf("line flattened to %v, want %v", got, line.Points)
+	}
+}
+
+func equalPoints(a, b []Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBounds(t *testing.T) {
+	// The control points reach y = 100, the curve only 75.
+	arch := Path{Kind: "bezier", Points: []Point{{0, 0}, {0, 100}, {100, 100}, {100, 0}}}
+	b := arch.Bounds()
+	if b.Min != (Point{0, 0}) || b.Max.X != 100 || math.Abs(b.Max.Y-75) > 1e-9 {
+		t.Errorf("arch bounds %v, want [0 0 100 75]", b)
+	}
+
+	rect := Path{Kind: "rect", Points: []Point{{30, 50}, {10, 20}}}
+	if b := rect.Bounds(); b != (Rectangle{Point{10, 20}, Point{30, 50}}) {
+		t.Errorf("rectangle bounds %v, want [10 20 30 50]", b)
+	}
+	if b := (Path{}).Bounds(); b != (Rectangle{}) {
+		t.Errorf("empty path bounds %v, want zero", b)
+	}
+}
+
+func TestContains(t *testing.T) {
+	// A square with a square hole drawn in the same direction, so the
+	// hole is only empty under the even-odd rule.
+	ring := Path{Kind: "line", Points: []Point{
+		{0, 0}, {100, 0}, {100, 100}, {0, 100}, {0, 0},
+		{25, 25}, {75, 25}, {75, 75}, {25, 75}, {25, 25},
+	}}
+	tests := []struct {
+		pt      Point
+		nonzero bool
+		evenOdd bool
+	}{
+		{Point{10, 50}, true, true},
+		{Point{50, 50}, true, false},
+		{Point{150, 50}, false, false},
+		{Point{50, -1}, false, false},
+	}
+	for _, tt := range tests {
+		ring.EvenOdd = false
+		if got := ring.Contains(tt.pt); got != tt.nonzero {
+			t.Errorf("nonzero Contains(%v) = %v, want %v", tt.pt, got, tt.nonzero)
+		}
+		ring.EvenOdd = true
+		if got := ring.Contains(tt.pt); got != tt.evenOdd {
+			t.Errorf("even-odd Contains(%v) = %v, want %v", tt.pt, got, tt.evenOdd)
+		}
+	}
+
+	rect := Path{Kind: "rect", Points: []Point{{10, 20}, {30, 50}}}
+	if !rect.Contains(Point{20, 30}) || rect.Contains(Point{5, 30}) {
+		t.Errorf("rectangle Contains wrong")
+	}
+	// Only the area under the chord of the arch is enclosed.
+	arch := Path{Kind: "bezier", Points: []Point{{0, 0}, {0, 100}, {100, 100}, {100, 0}}}
+	if !arch.Contains(Point{50, 70}) || arch.Contains(Point{50, 80}) {
+		t.Errorf("arch Contains wrong")
+	}
+	if (Path{Kind: "line", Points: []Point{{0, 0}, {10, 10}}}).Contains(Point{5, 5}) {
+		t.Errorf("a line segment contains a point")
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Drawing the graph of the objects of a document.
+
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// WriteDOT writes the graph of the document's objects to w in the DOT
+// language of Graphviz: a node for each object, labeled with its
+// number and its Type entry, or else its Subtype entry or kind, and an
+// edge for each reference, labeled with the path of the entry holding
+// it, such as /Kids[0]. Streams are drawn as boxes.
+//
+// If from is null, the graph holds every object in the file, and the
+// trailer, as a node of its own. Otherwise it holds the objects
+// reachable from the object holding from, such as the catalog; if from
+// is a page, they are those reachable from it not following Parent
+// entries or into other pages, together with the resources it
+// inherits, as in RevisionHistory. Edges to objects left out are not
+// drawn.
+//
+// The graph can be drawn with Graphviz's dot command, as in
+//
+//	dot -Tsvg -o graph.svg graph.dot
+func (r *Reader) WriteDOT(w io.Writer, from Value) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("pdf: writing graph: %v", e)
+		}
+	}()
+	var objs []pdfobjptr
+	switch {
+	case from.Kind() == Null:
+		for _, x := range r.xref {
+			if x.ptr.id != 0 && (x.inStream || x.offset != 0) {
+				objs = append(objs, x.ptr)
+			}
+		}
+	case from.ptr == (pdfobjptr{}):
+		return fmt.Errorf("pdf: writing graph: %v is not in an object of the file", from)
+	case from.Key("Type").CoerceName("") == "Page":
+		for ptr := range pageObjects(Page{V: from}) {
+			objs = append(objs, ptr)
+		}
+	default:
+		objs, _ = (&objCopier{r: r}).reachable([]pdfobject{from.ptr})
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].id < objs[j].id })
+	in := make(map[pdfobjptr]bool)
+	for _, ptr := range objs {
+		in[ptr] = true
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph pdf {\n\tnode [shape=ellipse];\n")
+	edges := func(name string, x pdfobject) {
+		eachRef(x, "", func(path string, ptr pdfobjptr) {
+			if in[ptr] {
+				fmt.Fprintf(bw, "\t%s -> %s [label=%s];\n", name, dotNode(ptr), strconv.Quote(path))
+			}
+		})
+	}
+	if from.Kind() == Null {
+		bw.WriteString("\ttrailer [shape=plaintext];\n")
+		edges("trailer", r.Trailer.data)
+	}
+	for _, ptr := range objs {
+		v := r.resolve(pdfobjptr{}, ptr)
+		label := v.Key("Type").CoerceName("")
+		if label == "" {
+			label = v.Key("Subtype").CoerceName("")
+		}
+		if label == "" {
+			label = v.Kind().String()
+		}
+		shape := ""
+		if v.Kind() == Stream {
+			shape = ", shape=box"
+		}
+		fmt.Fprintf(bw, "\t%s [label=%s%s];\n", dotNode(ptr), strconv.Quote(fmt.Sprintf("%d %d R\n%s", ptr.id, ptr.gen, label)), shape)
+		if strm, ok := v.data.(pdfstream); ok {
+			edges(dotNode(ptr), strm.hdr)
+		} else {
+			edges(dotNode(ptr), v.data)
+		}
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// dotNode returns the DOT identifier of the node for the object ptr.
+func dotNode(ptr pdfobjptr) string {
+	return fmt.Sprintf("\"%d %d\"", ptr.id, ptr.gen)
+}
+
+// eachRef calls f for each reference in the direct object x, found at
+// path, with the path of the entry holding it, in order of path.
+func eachRef(x pdfobject, path string, f func(path string, ptr pdfobjptr)) {
+	switch x := x.(type) {
+	case pdfobjptr:
+		f(path, x)
+	case pdfarray:
+		for i, y := range x {
+			eachRef(y, fmt.Sprintf("%s[%d]", path, i), f)
+		}
+	case pdfdict:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, string(k))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			eachRef(x[pdfname(k)], path+"/"+k, f)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	r := openPDF(t, buildPDF("/Info 6 0 R",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 5 0 R>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents [5 0 R]>>",
+		stream("", "0 0 m"),
+		"<</Title (graph)>>",
+	))
+	dot := func(from Value) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := r.WriteDOT(&buf, from); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	all := dot(Value{})
+	for _, line := range []string{
+		`trailer -> "1 0" [label="/Root"];`,
+		`trailer -> "6 0" [label="/Info"];`,
+		`"1 0" [label="1 0 R\nCatalog"];`,
+		`"2 0" -> "4 0" [label="/Kids[1]"];`,
+		`"4 0" -> "5 0" [label="/Contents[0]"];`,
+		`"5 0" [label="5 0 R\nStream", shape=box];`,
+		`"6 0" [label="6 0 R\nDict"];`,
+	} {
+		if !strings.Contains(all, "\t"+line+"\n") {
+			t.Errorf("graph of the file lacks %s:\n%s", line, all)
+		}
+	}
+	if !strings.HasPrefix(all, "digraph pdf {\n") || !strings.HasSuffix(all, "}\n") {
+		t.Errorf("graph of the file is not a digraph:\n%s", all)
+	}
+
+	// From the catalog, the Info dictionary is left out; from a page,
+	// the other pages and the page tree.
+	if g := dot(r.Trailer.Key("Root")); strings.Contains(g, "trailer") || strings.Contains(g, `"6 0"`) || !strings.Contains(g, `"3 0" -> "2 0" [label="/Parent"]`) {
+		t.Errorf("graph from the catalog:\n%s", g)
+	}
+	want := "digraph pdf {\n\tnode [shape=ellipse];\n" +
+		"\t\"3 0\" [label=\"3 0 R\\nPage\"];\n" +
+		"\t\"3 0\" -> \"5 0\" [label=\"/Contents\"];\n" +
+		"\t\"5 0\" [label=\"5 0 R\\nStream\", shape=box];\n" +
+		"}\n"
+	if g := dot(r.Page(1).V); g != want {
+		t.Errorf("graph from page 1:\n%s\nwant\n%s", g, want)
+	}
+	if err := r.WriteDOT(new(bytes.Buffer), NewInt(1)); err == nil {
+		t.Errorf("WriteDOT from a number succeeded")
+	}
+}
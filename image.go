@@ -0,0 +1,547 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Decoding of image streams into Go images.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"math"
+)
+
+// An Image is an image XObject painted on a page by the Do operator.
+type Image struct {
+	Name             string // resource name
+	Ref              ObjRef // the image XObject
+	Width, Height    int    // size in samples
+	BitsPerComponent int
+	ColorSpace       string    // color space family
+	Matrix           Matrix    // maps the unit square to device space (the CTM when painted)
+	Rect             Rectangle // bounding box on the page, in device space
+	ScaleX, ScaleY   float64   // displayed size of the image's sides, in points
+	Rotation         float64   // rotation, in degrees counterclockwise
+	Clip             Rectangle
+	Alpha            float64 // opacity, from 0 (transparent) to 1
+	BlendMode        string
+	ImageMask        bool  // the image is a stencil mask painted in FillColor
+	FillColor        Color // the fill color when the image was painted
+	V                Value // the image XObject
+}
+
+// newImage returns the Image for the image XObject v,
+// painted with the current transformation matrix m.
+func newImage(name string, v Value, m Matrix) Image {
+	img := Image{
+		Name:             name,
+		Ref:              v.Ref(),
+		Width:            int(v.Key("Width").CoerceInt64(0)),
+		Height:           int(v.Key("Height").CoerceInt64(0)),
+		BitsPerComponent: int(v.Key("BitsPerComponent").CoerceInt64(0)),
+		ColorSpace:       colorSpaceFamily(v.Key("ColorSpace")),
+		Matrix:           m,
+		ScaleX:           math.Hypot(m[0][0], m[0][1]),
+		ScaleY:           math.Hypot(m[1][0], m[1][1]),
+		Rotation:         math.Atan2(m[0][1], m[0][0]) * 180 / math.Pi,
+		ImageMask:        v.Key("ImageMask").data == true,
+		V:                v,
+	}
+	for i, c := range []Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}} {
+		p := Matrix{{1, 0, 0}, {0, 1, 0}, {c.X, c.Y, 1}}.mul(m)
+		r := Rectangle{Point{p[2][0], p[2][1]}, Point{p[2][0], p[2][1]}}
+		if i > 0 {
+			r = unionRect(img.Rect, r)
+		}
+		img.Rect = r
+	}
+	return img
+}
+
+// Decode decodes the image's samples.
+// It supports the same image formats as Page.Images.
+// A stencil mask (ImageMask) is decoded as an *image.NRGBA that is
+// FillColor where the mask paints and transparent elsewhere.
+func (img Image) Decode() (image.Image, error) {
+	if img.ImageMask {
+		return decodeImageMask(img.V, img.V.Reader(), img.FillColor)
+	}
+	return decodeImage(img.V)
+}
+
+// Info returns the image's metadata and undecoded data.
+func (img Image) Info() ImageInfo {
+	return imageInfo(img.V)
+}
+
+// An ImageInfo describes an image XObject as stored in the file,
+// for callers that want its data without decoding it,
+// such as to save an embedded JPEG image unchanged.
+type ImageInfo struct {
+	Ref              ObjRef // the image XObject
+	Width, Height    int    // size in samples
+	BitsPerComponent int
+	ColorSpace       string   // color space family
+	Components       int      // color components per sample, 0 if unknown
+	Filters          []string // filters applied to the data, in decoding order
+	Length           int64    // size of the stored data, in bytes
+	Interpolate      bool     // the image should be smoothed when scaled up
+	ImageMask        bool     // the image is a stencil mask painted in the fill color
+
+	v Value
+}
+
+// imageInfo returns the ImageInfo for the image XObject v.
+func imageInfo(v Value) ImageInfo {
+	info := ImageInfo{
+		Ref:              v.Ref(),
+		Width:            int(v.Key("Width").CoerceInt64(0)),
+		Height:           int(v.Key("Height").CoerceInt64(0)),
+		BitsPerComponent: int(v.Key("BitsPerComponent").CoerceInt64(0)),
+		ColorSpace:       colorSpaceFamily(v.Key("ColorSpace")),
+		Components:       len(initialColor(v.Key("ColorSpace")).Components),
+		Length:           v.Key("Length").CoerceInt64(0),
+		Interpolate:      v.Key("Interpolate").data == true,
+		ImageMask:        v.Key("ImageMask").data == true,
+		Filters:          filterNames(v),
+		v:                v,
+	}
+	if info.ImageMask {
+		info.BitsPerComponent, info.Components = 1, 1
+	}
+	return info
+}
+
+// filterNames returns the names of the filters applied to the
+// data of the stream v, in decoding order.
+func filterNames(v Value) []string {
+	var names []string
+	switch f := v.Key("Filter"); f.Kind() {
+	case Name:
+		names = []string{f.CoerceName("")}
+	case Array:
+		for i := 0; i < f.Len(); i++ {
+			names = append(names, f.Index(i).CoerceName(""))
+		}
+	}
+	return names
+}
+
+// ICCProfile returns the ICC profile of the image's color space,
+// or nil if it is not an ICCBased color space.
+func (info ImageInfo) ICCProfile() []byte {
+	return iccProfile(info.v.Key("ColorSpace"))
+}
+
+// DecodeParms returns the parameters of the i'th filter in Filters,
+// or a null Value if it has none.
+func (info ImageInfo) DecodeParms(i int) Value {
+	parms := info.v.Key("DecodeParms")
+	if parms.Kind() == Array {
+		return parms.Index(i)
+	}
+	if i == 0 {
+		return parms
+	}
+	return Value{}
+}
+
+// RawReader returns the image data as stored in the file, decrypted
+// but with none of its Filters applied. For an image whose only
+// filter is DCTDecode this is a JPEG file, and for one whose only
+// filter is JPXDecode a JPEG 2000 file.
+func (info ImageInfo) RawReader() io.ReadCloser {
+	if info.v.Kind() != Stream {
+		return &errorReadCloser{fmt.Errorf("stream not present")}
+	}
+	return io.NopCloser(info.v.decode(nil))
+}
+
+// A PageImage is an image XObject available to a page, decoded.
+type PageImage struct {
+	Name  string      // resource name
+	Info  ImageInfo   // the image XObject as stored
+	Image image.Image // the decoded image, or nil if it could not be decoded
+	Err   error       // the reason the image could not be decoded
+}
+
+// Images returns the image XObjects in the page's resources,
+// including those of the form XObjects in its resources, decoded,
+// in order of their resource names. An image listed under several
+// names or forms appears once. The images need not all be painted
+// on the page; Content reports those that are, and where.
+//
+// Stencil masks are decoded as by Image.Decode, painted in black.
+// Images with 1, 2, 4, 8 or 16 bits per component in the DeviceGray,
+// DeviceRGB and DeviceCMYK color spaces (or their CIE-based and
+// ICC-based equivalents), in the Lab color space, in Separation and
+// DeviceN color spaces whose tint transforms can be evaluated, or in
+// Indexed color spaces based on any of these, and JPEG images, are
+// supported; others have Err set. Colors are converted to RGB as
+// by Color.RGB.
+func (p Page) Images() []PageImage {
+	var imgs []PageImage
+	p.eachImageXObject(func(name string, x Value) {
+		m, err := decodeImage(x)
+		imgs = append(imgs, PageImage{name, imageInfo(x), m, err})
+	})
+	return imgs
+}
+
+// eachImageXObject calls f for each image XObject in the page's
+// resources and those of the form XObjects in its resources,
+// once per image, in order of resource names.
+func (p Page) eachImageXObject(f func(name string, x Value)) {
+	seen := make(map[ObjRef]bool)
+	var walk func(res Value, depth int)
+	walk = func(res Value, depth int) {
+		xobjs := res.Key("XObject")
+		for _, name := range xobjs.Keys() {
+			x := xobjs.Key(name)
+			if seen[x.Ref()] {
+				continue
+			}
+			seen[x.Ref()] = true
+			switch x.Key("Subtype").CoerceName("") {
+			case "Image":
+				f(name, x)
+			case "Form":
+				if depth < maxFormDepth {
+					walk(x.Key("Resources"), depth+1)
+				}
+			}
+		}
+	}
+	walk(p.Resources(), 0)
+}
+
+// A DocImage is an image XObject used in a document.
+type DocImage struct {
+	Info ImageInfo  // the image XObject as stored
+	Uses []ImageUse // the pages using the image, in page order
+}
+
+// An ImageUse is a use of an image by a page.
+type ImageUse struct {
+	Page int    // page number, starting at 1
+	Name string // resource name of the image on the page
+}
+
+// Images returns an inventory of the image XObjects used by the
+// document's pages, directly or through form XObjects, in order of
+// their first use. An image shared by several pages appears once,
+// with all its uses, so that the images can be extracted or their
+// sizes totaled without counting any twice. The images are not
+// decoded; use the Info's Decode or RawReader methods for their data.
+func (r *Reader) Images() []DocImage {
+	var imgs []DocImage
+	index := make(map[ObjRef]int)
+	for i := 1; i <= r.NumPage(); i++ {
+		r.Page(i).eachImageXObject(func(name string, x Value) {
+			j, ok := index[x.Ref()]
+			if !ok {
+				j = len(imgs)
+				index[x.Ref()] = j
+				imgs = append(imgs, DocImage{Info: imageInfo(x)})
+			}
+			imgs[j].Uses = append(imgs[j].Uses, ImageUse{i, name})
+		})
+	}
+	return imgs
+}
+
+// decodeImage converts the image stream v (an image XObject or a
+// page thumbnail) into an image.Image.
+func decodeImage(v Value) (m image.Image, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			m, err = nil, fmt.Errorf("malformed PDF: image data: %v", e)
+		}
+	}()
+	if v.Kind() != Stream {
+		return nil, fmt.Errorf("malformed PDF: image is not a stream")
+	}
+
+	if v.Key("ImageMask").data == true {
+		return decodeImageMask(v, v.Reader(), black)
+	}
+	return decodeSamples(v, v.filters(), v.decode)
+}
+
+// decodeImageMask decodes the stencil mask with image dictionary v
+// and data rd, painting it in the color fill.
+func decodeImageMask(v Value, rd io.Reader, fill Color) (image.Image, error) {
+	a, err := decodeStencil(v, rd)
+	if err != nil {
+		return nil, err
+	}
+	r, g, b, _ := fill.RGB()
+	c := color.NRGBA{colorByte(r), colorByte(g), colorByte(b), 0}
+	img := image.NewNRGBA(a.Rect)
+	for i, alpha := range a.Pix {
+		c.A = alpha
+		img.SetNRGBA(i%a.Stride, i/a.Stride, c)
+	}
+	return img, nil
+}
+
+// decodeSamples decodes an image with the image dictionary v and filters fs.
+// The open function returns the image data passed through the given
+// prefix of fs, so that a final DCTDecode can be left to the JPEG decoder.
+func decodeSamples(v Value, fs []streamFilter, open func([]streamFilter) io.Reader) (image.Image, error) {
+	if n := len(fs); n > 0 && fs[n-1].name == "DCTDecode" {
+		// The JPEG decoder allocates the image its header describes
+		// before reading any samples, so the size is checked first.
+		rd := open(fs[:n-1])
+		var hdr bytes.Buffer
+		cfg, err := jpeg.DecodeConfig(io.TeeReader(rd, &hdr))
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Width > maxImageSide || cfg.Height > maxImageSide {
+			return nil, fmt.Errorf("malformed PDF: image size %dx%d", cfg.Width, cfg.Height)
+		}
+		m, err := jpeg.Decode(io.MultiReader(&hdr, rd))
+		if err != nil {
+			return nil, err
+		}
+		// The JPEG decoder applies its own color conversion, so the
+		// only Decode array honored is one inverting every component,
+		// as used with Adobe's inverted CMYK JPEG images.
+		if d := floats(v.Key("Decode")); len(d) > 0 && invertsAll(d) {
+			m = invertColors(m)
+		}
+		return m, nil
+	}
+
+	w, h, bpc, err := imageSize(v)
+	if err != nil {
+		return nil, err
+	}
+	cs := v.Key("ColorSpace")
+	if colorSpaceFamily(cs) == "ICCBased" {
+		cs = iccAlternate(cs)
+	}
+	c := initialColor(cs)
+	n := len(c.Components)
+	if _, _, _, ok := c.RGB(); !ok || n == 0 {
+		return nil, fmt.Errorf("unsupported image: color space %v", cs)
+	}
+	buf, stride, err := readSamples(open(fs), w, h, n, bpc)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSample := float64(uint32(1)<<bpc - 1)
+	rng, custom := decodeRanges(v, c, maxSample)
+
+	if bpc == 8 && !custom {
+		switch c.Space {
+		case "DeviceGray":
+			img := image.NewGray(image.Rect(0, 0, w, h))
+			copy(img.Pix, buf)
+			return img, nil
+
+		case "DeviceRGB":
+			img := image.NewRGBA(image.Rect(0, 0, w, h))
+			for i, j := 0, 0; i < len(buf); i, j = i+3, j+4 {
+				img.Pix[j+0] = buf[i+0]
+				img.Pix[j+1] = buf[i+1]
+				img.Pix[j+2] = buf[i+2]
+				img.Pix[j+3] = 0xff
+			}
+			return img, nil
+		}
+	}
+
+	if c.Space == "Indexed" {
+		// Samples are indexes into the color table, whose colors
+		// are converted once to make the image's palette.
+		hival := int(min(max(cs.Index(2).CoerceInt64(0), 0), 255))
+		table := indexedLookup(cs)
+		pal := make(color.Palette, hival+1)
+		for i := range pal {
+			bc, ok := indexedColor(cs, table, i)
+			if !ok {
+				return nil, fmt.Errorf("malformed PDF: indexed color table too short")
+			}
+			r, g, b, _ := bc.RGB()
+			pal[i] = color.RGBA{colorByte(r), colorByte(g), colorByte(b), 0xff}
+		}
+		img := image.NewPaletted(image.Rect(0, 0, w, h), pal)
+		for y := 0; y < h; y++ {
+			row := buf[y*stride : (y+1)*stride]
+			for x := 0; x < w; x++ {
+				i := math.Round(interpolate(float64(sample(row, x, bpc)), 0, maxSample, rng[0], rng[1]))
+				img.Pix[y*img.Stride+x] = uint8(min(max(int(i), 0), hival))
+			}
+		}
+		return img, nil
+	}
+
+	// Other images are converted sample by sample through their
+	// color space, into gray images if the space is a gray one.
+	gray := c.Space == "DeviceGray" || c.Space == "CalGray"
+	var img draw.Image
+	if gray {
+		img = image.NewGray(image.Rect(0, 0, w, h))
+	} else {
+		img = image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+	// Conversions through tint transforms are slow, and images
+	// have few distinct colors, so converted colors are cached
+	// by their samples when those fit in a key.
+	var cache map[uint64]color.RGBA
+	if n*bpc <= 64 {
+		cache = make(map[uint64]color.RGBA)
+	}
+	for y := 0; y < h; y++ {
+		row := buf[y*stride : (y+1)*stride]
+		for x := 0; x < w; x++ {
+			key := uint64(0)
+			for k := range c.Components {
+				s := sample(row, x*n+k, bpc)
+				key = key<<bpc | uint64(s)
+				c.Components[k] = interpolate(float64(s), 0, maxSample, rng[2*k], rng[2*k+1])
+			}
+			rgba, ok := cache[key]
+			if !ok {
+				r, g, b, _ := c.RGB()
+				rgba = color.RGBA{colorByte(r), colorByte(g), colorByte(b), 0xff}
+				if cache != nil {
+					cache[key] = rgba
+				}
+			}
+			if gray {
+				img.Set(x, y, color.Gray{rgba.R})
+			} else {
+				img.Set(x, y, rgba)
+			}
+		}
+	}
+	return img, nil
+}
+
+// decodeRanges returns the ranges [min0 max0 min1 max1 ...] onto which
+// the samples, from 0 to maxSample, of the image with dictionary v and
+// colors like c are mapped: its Decode array, if it has a valid one,
+// or the default for its color space. It reports whether the ranges
+// differ from the default.
+func decodeRanges(v Value, c Color, maxSample float64) (rng []float64, custom bool) {
+	def := componentRanges(c)
+	if c.Space == "Indexed" {
+		def = []float64{0, maxSample}
+	}
+	d := floats(v.Key("Decode"))
+	if len(d) != len(def) {
+		return def, false
+	}
+	for i := range d {
+		if d[i] != def[i] {
+			return d, true
+		}
+	}
+	return def, false
+}
+
+// invertsAll reports whether the Decode array d maps every
+// component from 1 to 0, inverting it.
+func invertsAll(d []float64) bool {
+	if len(d)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(d); i += 2 {
+		if d[i] != 1 || d[i+1] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// invertColors returns m with each color component inverted.
+func invertColors(m image.Image) image.Image {
+	switch m := m.(type) {
+	case *image.Gray:
+		for i := range m.Pix {
+			m.Pix[i] = 0xff - m.Pix[i]
+		}
+		return m
+	case *image.CMYK:
+		for i := range m.Pix {
+			m.Pix[i] = 0xff - m.Pix[i]
+		}
+		return m
+	}
+	b := m.Bounds()
+	out := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(m.At(x, y)).(color.RGBA)
+			out.SetRGBA(x, y, color.RGBA{0xff - c.R, 0xff - c.G, 0xff - c.B, c.A})
+		}
+	}
+	return out
+}
+
+// imageSize returns the width, height and bits per component
+// of the image with dictionary v.
+func imageSize(v Value) (w, h, bpc int, err error) {
+	w = int(v.Key("Width").CoerceInt64(0))
+	h = int(v.Key("Height").CoerceInt64(0))
+	if w <= 0 || h <= 0 || w > maxImageSide || h > maxImageSide {
+		return 0, 0, 0, fmt.Errorf("malformed PDF: image size %dx%d", w, h)
+	}
+	bpc = int(v.Key("BitsPerComponent").CoerceInt64(8))
+	switch bpc {
+	case 1, 2, 4, 8, 16:
+	default:
+		return 0, 0, 0, fmt.Errorf("unsupported image: %d bits per component", bpc)
+	}
+	return w, h, bpc, nil
+}
+
+// readSamples reads the samples of an image of h rows of w pixels,
+// each of n components of bpc bits, returning them with the length
+// of a row, which is padded to a whole number of bytes. The buffer
+// grows with the data read rather than being sized from the image
+// dictionary, so that a malformed size cannot exhaust memory before
+// the data is found to be short.
+func readSamples(rd io.Reader, w, h, n, bpc int) (buf []byte, stride int, err error) {
+	stride = (w*n*bpc + 7) / 8
+	size := int64(stride) * int64(h)
+	buf, err = io.ReadAll(io.LimitReader(rd, size))
+	if err == nil && int64(len(buf)) < size {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed PDF: reading image data: %v", err)
+	}
+	return buf, stride, nil
+}
+
+// maxImageSide limits the width and height of decoded images,
+// so that the size of their samples stays within range.
+const maxImageSide = 1 << 15
+
+// sample returns the i'th sample in row, a row of bpc-bit samples.
+func sample(row []byte, i, bpc int) uint32 {
+	switch bpc {
+	case 8:
+		return uint32(row[i])
+	case 16:
+		return uint32(row[2*i])<<8 | uint32(row[2*i+1])
+	}
+	bit := i * bpc
+	return uint32(row[bit/8]>>(8-bpc-bit%8)) & (1<<bpc - 1)
+}
+
+// colorByte converts a color component in [0, 1] to a byte.
+func colorByte(x float64) uint8 {
+	return uint8(math.Round(255 * math.Max(0, math.Min(x, 1))))
+}
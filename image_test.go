@@ -0,0 +1,289 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImagePlacement(t *testing.T) {
+	data := pagePDF("<</XObject <</Im0 5 0 R>> /ExtGState <</GS0 <</ca 0.5 /BM /Multiply>>>>>>",
+		"q 100 0 0 50 10 20 cm /Im0 Do Q\n"+
+			"q /GS0 gs 0 0 200 200 re W n 0 50 -100 0 300 100 cm /Im0 Do Q\n",
+		stream("/Type /XObject /Subtype /Image /Width 2 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 8",
+			"\xff\x00\x00\x00\x00\xff"),
+	)
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Images) != 2 {
+		t.Fatalf("page has %d images, want 2", len(c.Images))
+	}
+	img := c.Images[0]
+	if img.Name != "Im0" || img.Ref != (ObjRef{5, 0}) || img.Width != 2 || img.Height != 1 ||
+		img.BitsPerComponent != 8 || img.ColorSpace != "DeviceRGB" {
+		t.Errorf("image 0 is %+v", img)
+	}
+	if img.Rect != (Rectangle{Point{10, 20}, Point{110, 70}}) || img.ScaleX != 100 || img.ScaleY != 50 || img.Rotation != 0 ||
+		img.Alpha != 1 || img.BlendMode != "Normal" || img.Clip != (Rectangle{Point{0, 0}, Point{612, 792}}) {
+		t.Errorf("image 0 is at %v, %v×%v, rotated %v, alpha %v, %s, clip %v",
+			img.Rect, img.ScaleX, img.ScaleY, img.Rotation, img.Alpha, img.BlendMode, img.Clip)
+	}
+	img = c.Images[1]
+	if img.Rect != (Rectangle{Point{200, 100}, Point{300, 150}}) || img.ScaleX != 50 || img.ScaleY != 100 || img.Rotation != 90 ||
+		img.Alpha != 0.5 || img.BlendMode != "Multiply" || img.Clip != (Rectangle{Point{0, 0}, Point{200, 200}}) {
+		t.Errorf("image 1 is at %v, %v×%v, rotated %v, alpha %v, %s, clip %v",
+			img.Rect, img.ScaleX, img.ScaleY, img.Rotation, img.Alpha, img.BlendMode, img.Clip)
+	}
+	m, err := img.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := m.At(1, 0).RGBA(); r != 0 || g != 0 || b != 0xffff {
+		t.Errorf("image pixel 1 is %x %x %x, want blue", r, g, b)
+	}
+}
+
+// jpegData returns a w×h JPEG image of a single gray level.
+func jpegData(w, h int, level uint8) string {
+	m := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range m.Pix {
+		m.Pix[i] = level
+	}
+	var b bytes.Buffer
+	jpeg.Encode(&b, m, nil)
+	return b.String()
+}
+
+func TestPageImages(t *testing.T) {
+	data := pagePDF("<</XObject <</A 5 0 R /B 6 0 R /C 5 0 R /D 7 0 R /E 8 0 R /F 9 0 R>>>>", "",
+		stream("/Subtype /Image /Width 2 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8", "\x00\xff"),
+		stream("/Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 16 /Filter /FlateDecode",
+			deflate("\xff\xff\x00\x00\x80\x00")),
+		stream("/Subtype /Form /BBox [0 0 1 1] /Resources <</XObject <</G 10 0 R /A 5 0 R>>>>", ""),
+		stream("/Subtype /Image /Width 2 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode",
+			jpegData(2, 2, 0x80)),
+		stream("/Subtype /Image /Width 1 /Height 1 /ColorSpace /Pattern /BitsPerComponent 8", "\x00"),
+		stream("/Subtype /Image /Width 3 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 1", "\xa0"),
+	)
+	imgs := openPDF(t, data).Page(1).Images()
+	var got []string
+	for _, img := range imgs {
+		s := img.Name
+		if img.Err != nil {
+			s += " error"
+		} else {
+			b := img.Image.Bounds()
+			s += fmt.Sprintf(" %dx%d", b.Dx(), b.Dy())
+			for y := 0; y < b.Dy(); y++ {
+				for x := 0; x < b.Dx(); x++ {
+					r, g, b, _ := img.Image.At(x, y).RGBA()
+					s += fmt.Sprintf(" %02x%02x%02x", r>>8, g>>8, b>>8)
+				}
+			}
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		"A 2x1 000000 ffffff",
+		"B 1x1 ff0080",
+		"G 3x1 ffffff 000000 ffffff", // in the form D
+		"E 2x2 808080 808080 808080 808080",
+		"F error",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("images:\n%q\nwant\n%q", got, want)
+	}
+	if len(imgs) > 0 && imgs[0].Info.Ref != (ObjRef{5, 0}) {
+		t.Errorf("image A is object %v, want 5", imgs[0].Info.Ref)
+	}
+}
+
+// oversizedImage is the dictionary of an image whose samples would
+// take 16GB, with only a few bytes of data.
+const oversizedImage = "/Subtype /Image /Width 32768 /Height 32768 /BitsPerComponent 16" +
+	" /ColorSpace [/DeviceN [/A /B /C /D /E /F /G /H] /DeviceCMYK 6 0 R]"
+
+// tintFunction is a tint transform for oversizedImage.
+var tintFunction = stream("/FunctionType 4 /Domain [0 1 0 1 0 1 0 1 0 1 0 1 0 1 0 1] /Range [0 1 0 1 0 1 0 1]",
+	"{pop pop pop pop}")
+
+func TestOversizedImage(t *testing.T) {
+	data := pagePDF("<</XObject <</Im0 5 0 R>>>>", "/Im0 Do",
+		stream(oversizedImage, "\x00\x01\x02\x03"), tintFunction)
+	imgs := openPDF(t, data).Page(1).Images()
+	if len(imgs) != 1 || imgs[0].Err == nil {
+		t.Fatalf("Images() = %+v, want an error for the short data", imgs)
+	}
+	if err := imgs[0].Err.Error(); !strings.Contains(err, "reading image data") {
+		t.Errorf("Images() error %q, want one reading the image data", err)
+	}
+}
+
+func TestImageInfo(t *testing.T) {
+	jpg := jpegData(4, 2, 0x40)
+	raw := deflate(jpg)
+	data := pagePDF("<</XObject <</Im0 5 0 R /Im1 6 0 R>>>>", "/Im0 Do /Im1 Do",
+		stream("/Subtype /Image /Width 4 /Height 2 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Interpolate true"+
+			" /Filter [/FlateDecode /DCTDecode] /DecodeParms [null <</ColorTransform 0>>]", raw),
+		stream("/Subtype /Image /Width 8 /Height 1 /ImageMask true /Filter /FlateDecode /DecodeParms <</Predictor 1>>",
+			deflate("\xf0")),
+	)
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Images) != 2 {
+		t.Fatalf("page has %d images, want 2", len(c.Images))
+	}
+	info := c.Images[0].Info()
+	if info.Ref != (ObjRef{5, 0}) || info.Width != 4 || info.Height != 2 || info.BitsPerComponent != 8 ||
+		info.ColorSpace != "DeviceRGB" || info.Components != 3 || info.Length != int64(len(raw)) ||
+		!info.Interpolate || info.ImageMask || fmt.Sprint(info.Filters) != "[FlateDecode DCTDecode]" {
+		t.Errorf("image 0 info %+v", info)
+	}
+	if p := info.DecodeParms(1).Key("ColorTransform"); p.CoerceInt64(-1) != 0 {
+		t.Errorf("DecodeParms(1) ColorTransform = %v, want 0", p)
+	}
+	if p := info.DecodeParms(0); p.Kind() != Null {
+		t.Errorf("DecodeParms(0) = %v, want null", p)
+	}
+	b, err := io.ReadAll(info.RawReader())
+	if err != nil || string(b) != raw {
+		t.Errorf("RawReader returned %d bytes, %v, want the %d stored bytes", len(b), err, len(raw))
+	}
+
+	info = c.Images[1].Info()
+	if !info.ImageMask || info.BitsPerComponent != 1 || info.Components != 1 || fmt.Sprint(info.Filters) != "[FlateDecode]" {
+		t.Errorf("image 1 info %+v", info)
+	}
+	if p := info.DecodeParms(0).Key("Predictor"); p.CoerceInt64(0) != 1 {
+		t.Errorf("DecodeParms(0) Predictor = %v, want 1", p)
+	}
+	if p := info.DecodeParms(1); p.Kind() != Null {
+		t.Errorf("DecodeParms(1) = %v, want null", p)
+	}
+}
+
+func TestIndexedImages(t *testing.T) {
+	tests := []struct {
+		name string
+		dict string
+		data string
+		want string
+	}{
+		{"string table", "/Width 3 /Height 1 /BitsPerComponent 8 /ColorSpace [/Indexed /DeviceRGB 1 <ff000000ff00>]",
+			"\x00\x01\x00", "ff0000 00ff00 ff0000"},
+		// Indexes above hival select its color.
+		{"4 bits", "/Width 3 /Height 1 /BitsPerComponent 4 /ColorSpace [/Indexed /DeviceGray 2 <00 80 ff>]",
+			"\x12\x90", "808080 ffffff ffffff"},
+		{"stream table", "/Width 2 /Height 1 /BitsPerComponent 8 /ColorSpace [/Indexed /DeviceRGB 1 6 0 R]",
+			"\x01\x00", "0000ff ff0000"},
+		{"short table", "/Width 1 /Height 1 /BitsPerComponent 8 /ColorSpace [/Indexed /DeviceRGB 2 <ff0000>]",
+			"\x00", "error"},
+	}
+	for _, tt := range tests {
+		data := pagePDF("<</XObject <</Im0 5 0 R>>>>", "",
+			stream("/Subtype /Image "+tt.dict, tt.data),
+			stream("", "\xff\x00\x00\x00\x00\xff"))
+		imgs := openPDF(t, data).Page(1).Images()
+		if len(imgs) != 1 {
+			t.Fatalf("%s: %d images, want 1", tt.name, len(imgs))
+		}
+		got := "error"
+		if imgs[0].Err == nil {
+			m := imgs[0].Image
+			if _, ok := m.(*image.Paletted); !ok {
+				t.Errorf("%s: image is %T, want *image.Paletted", tt.name, m)
+			}
+			var px []string
+			for x := 0; x < m.Bounds().Dx(); x++ {
+				r, g, b, _ := m.At(x, 0).RGBA()
+				px = append(px, fmt.Sprintf("%02x%02x%02x", r>>8, g>>8, b>>8))
+			}
+			got = strings.Join(px, " ")
+		}
+		if got != tt.want {
+			t.Errorf("%s: pixels %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeArray(t *testing.T) {
+	tests := []struct {
+		dict string
+		data string
+		want string
+	}{
+		{"/ColorSpace /DeviceGray /BitsPerComponent 8 /Decode [1 0]", "\x00\xff", "ffffff 000000"},
+		{"/ColorSpace /DeviceGray /BitsPerComponent 8 /Decode [0 1]", "\x00\xff", "000000 ffffff"},
+		{"/ColorSpace /DeviceGray /BitsPerComponent 4 /Decode [0.5 1]", "\x0f", "808080 ffffff"},
+		{"/ColorSpace /DeviceRGB /BitsPerComponent 8 /Decode [1 0 0 1 0 0.5]", "\x00\x00\xff\xff\xff\x00", "ff0080 00ff00"},
+		// The Decode array of an Indexed image maps samples onto indexes.
+		{"/ColorSpace [/Indexed /DeviceRGB 1 <ff000000ff00>] /BitsPerComponent 1 /Decode [1 0]", "\x80", "ff0000 00ff00"},
+		// An invalid Decode array is ignored.
+		{"/ColorSpace /DeviceGray /BitsPerComponent 8 /Decode [1]", "\x00\xff", "000000 ffffff"},
+		// A JPEG image can only be inverted.
+		{"/ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode /Decode [1 0]", jpegData(2, 1, 0x40), "bfbfbf bfbfbf"},
+		{"/ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode /Decode [0 0.5]", jpegData(2, 1, 0x40), "404040 404040"},
+	}
+	for _, tt := range tests {
+		data := pagePDF("<</XObject <</Im0 5 0 R>>>>", "", stream("/Subtype /Image /Width 2 /Height 1 "+tt.dict, tt.data))
+		imgs := openPDF(t, data).Page(1).Images()
+		if len(imgs) != 1 || imgs[0].Err != nil {
+			t.Errorf("%s: Images() = %+v", tt.dict, imgs)
+			continue
+		}
+		var px []string
+		for x := 0; x < 2; x++ {
+			r, g, b, _ := imgs[0].Image.At(x, 0).RGBA()
+			px = append(px, fmt.Sprintf("%02x%02x%02x", r>>8, g>>8, b>>8))
+		}
+		if got := strings.Join(px, " "); got != tt.want {
+			t.Errorf("%s: pixels %s, want %s", tt.dict, got, tt.want)
+		}
+	}
+}
+
+func TestDocImages(t *testing.T) {
+	gray := "/Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8"
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources <</XObject <</B 7 0 R /A 6 0 R>>>>>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792]>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources <</XObject <</Logo 6 0 R /Fm 8 0 R>>>>>>",
+		stream(gray, "\x00"),
+		stream(gray, "\xff"),
+		stream("/Subtype /Form /BBox [0 0 1 1] /Resources <</XObject <</X 7 0 R /Y 9 0 R>>>>", ""),
+		stream(gray+" /Filter /FlateDecode", deflate("\x80")),
+	))
+	var got []string
+	for _, img := range r.Images() {
+		s := fmt.Sprint(img.Info.Ref.ID, img.Info.Length)
+		for _, u := range img.Uses {
+			s += fmt.Sprintf(" %d/%s", u.Page, u.Name)
+		}
+		got = append(got, s)
+	}
+	// Images appear in order of first use, those of a page in order
+	// of resource names, including those in its forms.
+	want := []string{
+		"6 1 1/A 3/Logo",
+		"7 1 1/B 3/X",
+		fmt.Sprint("9 ", len(deflate("\x80")), " 3/Y"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Images():\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Writing changes as an incremental update (PDF 32000-1:2008, §7.5.6).
+
+package pdf
+
+import (
+	"bufio"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SaveIncremental writes the file as it was read, followed by an
+// incremental update holding the objects changed or created through
+// SetKey, SetIndex and the other editing methods. Unlike Save, it
+// leaves the original bytes, and so any signatures over them, intact,
+// and it keeps the objects' numbers; objects no longer used are not
+// removed. The update's cross-reference section is a table or a
+// stream as the file's last one is. Encrypted files cannot be updated.
+func (r *Reader) SaveIncremental(w io.Writer) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("pdf: saving: %v", e)
+		}
+	}()
+	if r.key != nil {
+		return fmt.Errorf("unsupported PDF: incremental update of an encrypted file")
+	}
+
+	r.objMu.Lock()
+	var ptrs []pdfobjptr
+	for ptr := range r.dirty {
+		if ptr != r.Trailer.ptr {
+			ptrs = append(ptrs, ptr)
+		}
+	}
+	size := uint32(max(len(r.xref), 1))
+	size = max(size, r.nextID)
+	r.objMu.Unlock()
+	sort.Slice(ptrs, func(i, j int) bool { return ptrs[i].id < ptrs[j].id })
+	for _, ptr := range ptrs {
+		size = max(size, ptr.id+1)
+	}
+
+	wr := &Writer{
+		Compress: true,
+		w:        bufio.NewWriter(w),
+		sum:      md5.New(),
+		xref:     make([]objLocation, size),
+	}
+	if _, err := io.Copy(writerFunc(wr.write), io.NewSectionReader(r.f, 0, r.end)); err != nil {
+		return err
+	}
+	var last [1]byte
+	if _, err := r.f.ReadAt(last[:], r.end-1); err == nil && last[0] != '\n' && last[0] != '\r' {
+		wr.write([]byte("\n"))
+	}
+
+	for _, ptr := range ptrs {
+		v := r.resolve(pdfobjptr{}, ptr)
+		strm, ok := v.data.(pdfstream)
+		if !ok {
+			wr.writeObject(ptr, v.data)
+			continue
+		}
+		data, err := io.ReadAll(v.decode(nil))
+		if err != nil {
+			return fmt.Errorf("reading stream %d %d R: %v", ptr.id, ptr.gen, err)
+		}
+		hdr := make(pdfdict, len(strm.hdr))
+		for k, x := range strm.hdr {
+			hdr[k] = x
+		}
+		wr.writeStream(ptr, hdr, data)
+	}
+
+	trailer := pdfdict{}
+	for k, x := range r.Trailer.data.(pdfdict) {
+		switch k {
+		case "Prev", "Type", "W", "Index", "Length", "Filter", "DecodeParms", "XRefStm":
+			// Entries of the last section's cross-reference stream.
+		default:
+			trailer[k] = x
+		}
+	}
+	trailer["Size"] = int64(size)
+	trailer["Prev"] = r.startxref
+	if ids, ok := trailer["ID"].(pdfarray); ok && len(ids) == 2 {
+		trailer["ID"] = pdfarray{ids[0], string(wr.sum.Sum(nil))}
+	}
+	if r.Trailer.ptr != (pdfobjptr{}) {
+		wr.writeUpdateXrefStream(ptrs, trailer)
+	} else {
+		wr.writeUpdateXrefTable(ptrs, trailer)
+	}
+	if wr.err != nil {
+		return wr.err
+	}
+	return wr.w.Flush()
+}
+
+// writerFunc adapts a function to io.Writer.
+type writerFunc func([]byte)
+
+func (f writerFunc) Write(b []byte) (int, error) {
+	f(b)
+	return len(b), nil
+}
+
+// writeUpdateXrefTable writes the cross-reference table of an update
+// holding the objects ptrs, sorted by number, followed by trailer.
+func (w *Writer) writeUpdateXrefTable(ptrs []pdfobjptr, trailer pdfdict) {
+	start := w.offset
+	b := []byte("xref\n")
+	for i := 0; i < len(ptrs); {
+		j := i + 1
+		for j < len(ptrs) && ptrs[j].id == ptrs[j-1].id+1 {
+			j++
+		}
+		b = fmt.Appendf(b, "%d %d\n", ptrs[i].id, j-i)
+		for _, ptr := range ptrs[i:j] {
+			b = fmt.Appendf(b, "%010d %05d n\r\n", w.xref[ptr.id].offset, ptr.gen)
+		}
+		i = j
+	}
+	b = appendObject(append(b, "trailer\n"...), trailer)
+	b = fmt.Appendf(b, "\nstartxref\n%d\n%%%%EOF\n", start)
+	w.write(b)
+}
+
+// writeUpdateXrefStream writes the cross-reference stream of an update
+// holding the objects ptrs, sorted by number, with the entries of trailer.
+func (w *Writer) writeUpdateXrefStream(ptrs []pdfobjptr, trailer pdfdict) {
+	ptr := pdfobjptr{uint32(len(w.xref)), 0}
+	w.xref = append(w.xref, objLocation{})
+	trailer["Size"] = int64(len(w.xref))
+	start := w.offset
+	w.xref[ptr.id] = objLocation{offset: start}
+	ptrs = append(ptrs, ptr)
+
+	width := 1
+	for start >= 1<<(8*width) {
+		width++
+	}
+	var index pdfarray
+	var data []byte
+	for i, p := range ptrs {
+		if i == 0 || p.id != ptrs[i-1].id+1 {
+			index = append(index, int64(p.id), int64(0))
+		}
+		index[len(index)-1] = index[len(index)-1].(int64) + 1
+		off := w.xref[p.id].offset
+		data = append(data, 1)
+		for j := width - 1; j >= 0; j-- {
+			data = append(data, byte(off>>(8*j)))
+		}
+		data = append(data, byte(p.gen>>8), byte(p.gen))
+	}
+	trailer["Type"] = pdfname("XRef")
+	trailer["W"] = pdfarray{int64(1), int64(width), int64(2)}
+	trailer["Index"] = index
+	w.writeStream(ptr, trailer, data)
+	w.write(fmt.Appendf(nil, "startxref\n%d\n%%%%EOF\n", start))
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestSaveIncremental(t *testing.T) {
+	for _, objStms := range []bool{false, true} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.ObjectStreams = objStms
+		w.NewPage(612, 792).ShowText(72, 700, "hello")
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		orig := buf.Bytes()
+		r := openPDF(t, orig)
+		page := r.Page(1).V.Ref()
+		r.Trailer.Key("Root").SetKey("PageMode", NewName("UseThumbs"))
+		if err := r.SetInfo(Info{Title: "Updated"}); err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		if err := r.SaveIncremental(&out); err != nil {
+			t.Fatal(err)
+		}
+		data := out.Bytes()
+		if !bytes.HasPrefix(data, orig) {
+			t.Errorf("object streams %v: update changed the original file", objStms)
+			continue
+		}
+		r = openPDF(t, data)
+		if title := r.Info().Title; title != "Updated" {
+			t.Errorf("object streams %v: Title %q, want Updated", objStms, title)
+		}
+		if m := r.Trailer.Key("Root").Key("PageMode").CoerceName(""); m != "UseThumbs" {
+			t.Errorf("object streams %v: PageMode %q, want UseThumbs", objStms, m)
+		}
+		if ref := r.Page(1).V.Ref(); ref != page {
+			t.Errorf("object streams %v: page renumbered from %v to %v", objStms, page, ref)
+		}
+		if s, err := r.Page(1).GetPlainText(); err != nil || s != "hello" {
+			t.Errorf("object streams %v: text %q, %v", objStms, s, err)
+		}
+
+		// The update's cross-reference section is of the original's
+		// kind and leads back to it.
+		update := data[len(orig):]
+		if isStream := r.Trailer.Key("Type").CoerceName("") == "XRef"; isStream != objStms {
+			t.Errorf("object streams %v: update has a cross-reference stream: %v", objStms, isStream)
+		}
+		if table := bytes.Contains(update, []byte("\nxref\n")); table == objStms {
+			t.Errorf("object streams %v: update has a cross-reference table: %v", objStms, table)
+		}
+		m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(orig)
+		prev, _ := strconv.ParseInt(string(m[1]), 10, 64)
+		if p := r.Trailer.Key("Prev").CoerceInt64(0); p != prev {
+			t.Errorf("object streams %v: Prev %d, want %d", objStms, p, prev)
+		}
+	}
+
+	encrypted := encryptedPDF(t, Encryption{Method: AES128})
+	r := openPDF(t, encrypted)
+	r.SetInfo(Info{Title: "Updated"})
+	if err := r.SaveIncremental(new(bytes.Buffer)); err == nil {
+		t.Errorf("SaveIncremental of an encrypted file succeeded")
+	}
+}
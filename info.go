@@ -0,0 +1,282 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The document information dictionary (PDF 32000-1:2008, §14.3.3),
+// file identifiers (§14.4), page-piece dictionaries (§14.5) and
+// PDF dates (§7.9.4).
+
+package pdf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An Info holds the entries of the document information dictionary.
+// Entries missing from the dictionary are empty, or zero times.
+type Info struct {
+	Title        string
+	Author       string
+	Subject      string
+	Keywords     string
+	Creator      string    // application that created the original document
+	Producer     string    // application that converted it to PDF
+	CreationDate time.Time // zero if missing or malformed
+	ModDate      time.Time // zero if missing or malformed
+	Trapped      string    // True, False or Unknown
+
+	V Value // the dictionary itself, for other entries
+}
+
+// Info returns the document information dictionary,
+// the trailer's Info entry.
+func (r *Reader) Info() Info {
+	d := r.Trailer.Key("Info")
+	info := Info{
+		Title:    d.Key("Title").CoerceText(""),
+		Author:   d.Key("Author").CoerceText(""),
+		Subject:  d.Key("Subject").CoerceText(""),
+		Keywords: d.Key("Keywords").CoerceText(""),
+		Creator:  d.Key("Creator").CoerceText(""),
+		Producer: d.Key("Producer").CoerceText(""),
+		Trapped:  d.Key("Trapped").CoerceName(""),
+		V:        d,
+	}
+	if t, err := ParseDate(d.Key("CreationDate").CoerceText("")); err == nil {
+		info.CreationDate = t
+	}
+	if t, err := ParseDate(d.Key("ModDate").CoerceText("")); err == nil {
+		info.ModDate = t
+	}
+	return info
+}
+
+// SetInfo sets the entries of the document information dictionary
+// held by Info to those of info, deleting those that are empty, and
+// keeping the dictionary's other entries. It adds the dictionary if
+// the document has none. Viewers may prefer the document's XMP
+// metadata to the dictionary, so a document that has it should have
+// it changed to match, with SetMetadata and XMPPacket.
+// The change is written by Save or SaveIncremental.
+func (r *Reader) SetInfo(info Info) error {
+	d, err := r.infoForUpdate()
+	if err != nil {
+		return err
+	}
+	set := infoDict(info)
+	for _, key := range infoKeys {
+		val := Value{}
+		if x, ok := set[pdfname(key)]; ok {
+			val = Value{data: x}
+		}
+		if err := d.SetKey(key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// infoKeys are the entries of the information dictionary held by Info.
+var infoKeys = []string{"Title", "Author", "Subject", "Keywords", "Creator", "Producer", "CreationDate", "ModDate", "Trapped"}
+
+// SetInfoEntry sets the entry key of the document information
+// dictionary to the text value, or deletes it if value is empty.
+// It is for custom properties, which viewers list with the document's
+// others, and adds the dictionary if the document has none.
+// The change is written by Save or SaveIncremental.
+func (r *Reader) SetInfoEntry(key, value string) error {
+	d, err := r.infoForUpdate()
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return d.SetKey(key, Value{})
+	}
+	return d.SetKey(key, NewText(value))
+}
+
+// infoForUpdate returns the document information dictionary,
+// adding an empty one if the document has none.
+func (r *Reader) infoForUpdate() (Value, error) {
+	d := r.Trailer.Key("Info")
+	if d.Kind() == Dict {
+		return d, nil
+	}
+	d = r.Add(r.NewDict())
+	if err := r.Trailer.SetKey("Info", d); err != nil {
+		return Value{}, err
+	}
+	return d, nil
+}
+
+// infoDict returns the document information dictionary
+// holding the entries of info that are set.
+func infoDict(info Info) pdfdict {
+	d := pdfdict{}
+	for _, e := range []struct{ key, val string }{
+		{"Title", info.Title},
+		{"Author", info.Author},
+		{"Subject", info.Subject},
+		{"Keywords", info.Keywords},
+		{"Creator", info.Creator},
+		{"Producer", info.Producer},
+	} {
+		if e.val != "" {
+			d[pdfname(e.key)] = encodeTextString(e.val)
+		}
+	}
+	if !info.CreationDate.IsZero() {
+		d["CreationDate"] = FormatDate(info.CreationDate)
+	}
+	if !info.ModDate.IsZero() {
+		d["ModDate"] = FormatDate(info.ModDate)
+	}
+	if info.Trapped != "" {
+		d["Trapped"] = pdfname(info.Trapped)
+	}
+	return d
+}
+
+// ID returns the two parts of the file identifier, the trailer's ID
+// entry, as hexadecimal strings, or empty strings if the file has none.
+// The permanent identifier is set when the document is created and kept
+// by later revisions, so two files with the same permanent identifier
+// are versions of the same document; the changing identifier differs
+// for each revision. The permanent identifier is also an input to the
+// encryption key.
+func (r *Reader) ID() (permanent, changing string) {
+	ids := r.Trailer.Key("ID")
+	if ids.Kind() != Array || ids.Len() != 2 {
+		return "", ""
+	}
+	return hex.EncodeToString([]byte(ids.Index(0).CoerceString(""))), hex.EncodeToString([]byte(ids.Index(1).CoerceString("")))
+}
+
+// A PieceInfo is the private data that an application, such as
+// Illustrator or InDesign, stored with the document or a page.
+type PieceInfo struct {
+	App          string    // name of the application, the key in the page-piece dictionary
+	LastModified time.Time // when the application last changed the data, zero if missing or malformed
+	Private      Value     // the application's private data
+
+	V Value // the application's data dictionary
+}
+
+// PieceInfo returns the application data stored with the document,
+// the catalog's PieceInfo entry, ordered by application name.
+func (r *Reader) PieceInfo() []PieceInfo {
+	return pieceInfo(r.Trailer.Key("Root").Key("PieceInfo"))
+}
+
+// PieceInfo returns the application data stored with the page,
+// its PieceInfo entry, ordered by application name.
+func (p Page) PieceInfo() []PieceInfo {
+	return pieceInfo(p.V.Key("PieceInfo"))
+}
+
+// pieceInfo returns the entries of the page-piece dictionary v.
+func pieceInfo(v Value) []PieceInfo {
+	var list []PieceInfo
+	for _, app := range v.Keys() {
+		d := v.Key(app)
+		if d.Kind() != Dict {
+			continue
+		}
+		pi := PieceInfo{App: app, Private: d.Key("Private"), V: d}
+		if t, err := ParseDate(d.Key("LastModified").CoerceText("")); err == nil {
+			pi.LastModified = t
+		}
+		list = append(list, pi)
+	}
+	return list
+}
+
+// ParseDate parses a PDF date string, D:YYYYMMDDHHmmSSOHH'mm', in
+// which everything after the year is optional and O is the relation of
+// local time to UT: +, - or Z. Missing fields default to the start of
+// their period, and a missing offset to UT. The D: prefix is optional,
+// as is the apostrophe after the offset's hours.
+func ParseDate(s string) (time.Time, error) {
+	orig := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+	bad := func() (time.Time, error) {
+		return time.Time{}, fmt.Errorf("malformed PDF date %q", orig)
+	}
+
+	// The date and time fields: year, month, day, hour, minute, second.
+	fields := [6]int{0, 1, 1, 0, 0, 0}
+	widths := [6]int{4, 2, 2, 2, 2, 2}
+	for i, w := range widths {
+		if len(s) < w || !isDigits(s[:w]) {
+			if i == 0 {
+				return bad()
+			}
+			break
+		}
+		fields[i], _ = strconv.Atoi(s[:w])
+		s = s[w:]
+	}
+	if fields[1] < 1 || fields[1] > 12 || fields[2] < 1 || fields[2] > 31 || fields[3] > 23 || fields[4] > 59 || fields[5] > 59 {
+		return bad()
+	}
+
+	// The offset from UT.
+	loc := time.UTC
+	if s != "" {
+		sign := 1
+		switch s[0] {
+		case 'Z':
+			s = ""
+		case '+':
+		case '-':
+			sign = -1
+		default:
+			return bad()
+		}
+		if s != "" {
+			s = strings.ReplaceAll(s[1:], "'", "")
+			if len(s) != 2 && len(s) != 4 || !isDigits(s) {
+				return bad()
+			}
+			h, _ := strconv.Atoi(s[:2])
+			m := 0
+			if len(s) == 4 {
+				m, _ = strconv.Atoi(s[2:])
+			}
+			if h > 23 || m > 59 {
+				return bad()
+			}
+			loc = time.FixedZone("", sign*(h*3600+m*60))
+		}
+	}
+	return time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, loc), nil
+}
+
+// FormatDate returns t as a PDF date string, D:YYYYMMDDHHmmSSOHH'mm',
+// the form parsed by ParseDate.
+func FormatDate(t time.Time) string {
+	s := t.Format("D:20060102150405")
+	_, offset := t.Zone()
+	if offset == 0 {
+		return s + "Z"
+	}
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("%s%c%02d'%02d'", s, sign, offset/3600, offset/60%60)
+}
+
+// isDigits reports whether s consists of decimal digits.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
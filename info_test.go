@@ -0,0 +1,163 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInfo(t *testing.T) {
+	r := openPDF(t, buildPDF("/Info 2 0 R",
+		"<</Type /Catalog>>",
+		"<</Title (A \\(short\\) title) /Author <feff00c9006d0069006c0065> /Keywords 3 0 R"+
+			" /CreationDate (D:20240131120000+01'00') /ModDate (yesterday) /Trapped /False /Custom (x)>>",
+		"(pdf, test)",
+	))
+	info := r.Info()
+	if info.Title != "A (short) title" || info.Author != "Émile" || info.Keywords != "pdf, test" ||
+		info.Subject != "" || info.Trapped != "False" || !info.ModDate.IsZero() {
+		t.Errorf("Info() = %+v", info)
+	}
+	if want := time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC); !info.CreationDate.Equal(want) {
+		t.Errorf("CreationDate = %v, want %v", info.CreationDate, want)
+	}
+	if s := info.V.Key("Custom").CoerceText(""); s != "x" {
+		t.Errorf("V.Key(Custom) = %q, want %q", s, "x")
+	}
+
+	if info := openPDF(t, buildPDF("", "<</Type /Catalog>>")).Info(); info.Title != "" || info.V.Kind() != Null {
+		t.Errorf("Info() without a dictionary = %+v", info)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // in RFC 3339, or "error"
+	}{
+		{"D:20240131120000+01'00'", "2024-01-31T12:00:00+01:00"},
+		{"D:20240131120000-05'30", "2024-01-31T12:00:00-05:30"},
+		{"D:20240131120000Z", "2024-01-31T12:00:00Z"},
+		{"D:20240131120000+0100", "2024-01-31T12:00:00+01:00"},
+		{"20240131", "2024-01-31T00:00:00Z"},
+		{"D:2024", "2024-01-01T00:00:00Z"},
+		{" D:202402 ", "2024-02-01T00:00:00Z"},
+		{"D:20241301", "error"},
+		{"D:20240131250000", "error"},
+		{"D:20240131120000*", "error"},
+		{"D:20240131120000+1", "error"},
+		{"D:24", "error"},
+		{"", "error"},
+	}
+	for _, tt := range tests {
+		got := "error"
+		if d, err := ParseDate(tt.in); err == nil {
+			got = d.Format(time.RFC3339)
+		}
+		if got != tt.want {
+			t.Errorf("ParseDate(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestID(t *testing.T) {
+	tests := []struct {
+		trailer             string
+		permanent, changing string
+	}{
+		{"/ID [<0123abcd> (xy)]", "0123abcd", "7879"},
+		{"/ID [<0123abcd>]", "", ""},
+		{"/ID (0123)", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		p, c := openPDF(t, buildPDF(tt.trailer, "<</Type /Catalog>>")).ID()
+		if p != tt.permanent || c != tt.changing {
+			t.Errorf("%s: ID() = %q, %q, want %q, %q", tt.trailer, p, c, tt.permanent, tt.changing)
+		}
+	}
+}
+
+func TestPieceInfo(t *testing.T) {
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R /PieceInfo <</Zeta <</LastModified (D:20240301) /Private 4 0 R>> /Alpha <</Private (x)>> /Bad 5>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /PieceInfo <</Illustrator <</LastModified (D:20240102030405Z) /Private <</Layers 3>>>>>>>>",
+		"<</Version 2>>",
+	))
+	format := func(list []PieceInfo) string {
+		var s []string
+		for _, pi := range list {
+			s = append(s, fmt.Sprintf("%s %s %v", pi.App, pi.LastModified.Format(time.RFC3339), pi.Private))
+		}
+		return fmt.Sprint(s)
+	}
+	if got, want := format(r.PieceInfo()), `[Alpha 0001-01-01T00:00:00Z "x" Zeta 2024-03-01T00:00:00Z <</Version 2>>]`; got != want {
+		t.Errorf("Reader.PieceInfo() = %s, want %s", got, want)
+	}
+	if got, want := format(r.Page(1).PieceInfo()), "[Illustrator 2024-01-02T03:04:05Z <</Layers 3>>]"; got != want {
+		t.Errorf("Page.PieceInfo() = %s, want %s", got, want)
+	}
+	if list := openPDF(t, textPage()).PieceInfo(); list != nil {
+		t.Errorf("PieceInfo() without entries = %v", list)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{time.Date(2024, 1, 31, 12, 0, 5, 0, time.UTC), "D:20240131120005Z"},
+		{time.Date(2024, 1, 31, 12, 0, 5, 0, time.FixedZone("", 3600+30*60)), "D:20240131120005+01'30'"},
+		{time.Date(2024, 1, 31, 12, 0, 5, 0, time.FixedZone("", -8*3600)), "D:20240131120005-08'00'"},
+	}
+	for _, tt := range tests {
+		s := FormatDate(tt.t)
+		if s != tt.want {
+			t.Errorf("FormatDate(%v) = %q, want %q", tt.t, s, tt.want)
+		}
+		if d, err := ParseDate(s); err != nil || !d.Equal(tt.t) {
+			t.Errorf("ParseDate(%q) = %v, %v, want %v", s, d, err, tt.t)
+		}
+	}
+}
+
+func TestSetInfo(t *testing.T) {
+	// A document without an information dictionary gets one.
+	r := openPDF(t, textPage())
+	created := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	if err := r.SetInfo(Info{Title: "Title", Author: "Zoë", CreationDate: created}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetInfoEntry("Department", "R&D"); err != nil {
+		t.Fatal(err)
+	}
+	r = save(t, r)
+	info := r.Info()
+	if info.Title != "Title" || info.Author != "Zoë" || !info.CreationDate.Equal(created) {
+		t.Errorf("Info %+v", info)
+	}
+	if d := info.V.Key("Department").CoerceText(""); d != "R&D" {
+		t.Errorf("Department %q, want R&D", d)
+	}
+
+	// Empty fields and values delete their entries; others are kept.
+	info.Author = ""
+	if err := r.SetInfo(info); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetInfoEntry("Department", ""); err != nil {
+		t.Fatal(err)
+	}
+	r.SetInfoEntry("Project", "X")
+	r = save(t, r)
+	v := r.Info().V
+	if keys := fmt.Sprint(v.Keys()); keys != "[CreationDate Project Title]" {
+		t.Errorf("Info keys %s, want [CreationDate Project Title]", keys)
+	}
+}
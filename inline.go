@@ -0,0 +1,224 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Inline images (PDF 32000-1:2008, §8.9.7).
+
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"io"
+)
+
+// An InlineImage is an image given directly in a content stream,
+// between the BI and EI operators, rather than as an image XObject.
+type InlineImage struct {
+	Width, Height    int
+	BitsPerComponent int
+	ColorSpace       string   // color space family
+	ImageMask        bool     // the image is a stencil mask painted in FillColor
+	FillColor        Color    // the fill color when the image was painted
+	Filters          []string // filters applied to Data, in decoding order
+	Data             []byte   // image data, still encoded
+	Matrix           Matrix   // maps the unit square to device space
+
+	dict Value // image dictionary with abbreviations expanded
+}
+
+// Decode decodes the image data.
+// It supports the same image formats as Image.Decode.
+func (img InlineImage) Decode() (image.Image, error) {
+	open := func(fs []streamFilter) io.Reader {
+		var rd io.Reader = bytes.NewReader(img.Data)
+		for _, f := range fs {
+			rd = applyFilter(rd, f.name, f.param)
+		}
+		return rd
+	}
+	if img.ImageMask {
+		return decodeImageMask(img.dict, open(img.dict.filters()), img.FillColor)
+	}
+	return decodeSamples(img.dict, img.dict.filters(), open)
+}
+
+// newInlineImage returns the InlineImage for the dictionary and data
+// read by Interpret, painted with the current transformation matrix ctm.
+// A color space given by name is looked up in the resources res.
+func newInlineImage(dict, data Value, res Value, ctm Matrix) InlineImage {
+	d := pdfdict{}
+	for k, v := range dict.data.(pdfdict) {
+		d[k] = v
+	}
+	if name, ok := d["ColorSpace"].(pdfname); ok {
+		if cs := lookupColorSpace(res, string(name)); cs.Kind() != Null {
+			d["ColorSpace"] = cs.data
+		}
+	}
+	dict = Value{data: d}
+
+	img := InlineImage{
+		Width:            int(dict.Key("Width").CoerceInt64(0)),
+		Height:           int(dict.Key("Height").CoerceInt64(0)),
+		BitsPerComponent: int(dict.Key("BitsPerComponent").CoerceInt64(0)),
+		ColorSpace:       colorSpaceFamily(dict.Key("ColorSpace")),
+		ImageMask:        dict.Key("ImageMask").data == true,
+		Data:             []byte(data.CoerceString("")),
+		Matrix:           ctm,
+		dict:             dict,
+	}
+	for _, f := range dict.filters() {
+		img.Filters = append(img.Filters, f.name)
+	}
+	return img
+}
+
+// inlineKeys maps the abbreviated keys of inline image dictionaries to their full names.
+var inlineKeys = map[pdfname]pdfname{
+	"BPC": "BitsPerComponent",
+	"CS":  "ColorSpace",
+	"D":   "Decode",
+	"DP":  "DecodeParms",
+	"F":   "Filter",
+	"H":   "Height",
+	"IM":  "ImageMask",
+	"I":   "Interpolate",
+	"L":   "Length",
+	"W":   "Width",
+}
+
+// inlineNames maps the abbreviated color space and filter names
+// of inline image dictionaries to their full names.
+var inlineNames = map[pdfname]pdfname{
+	"G":    "DeviceGray",
+	"RGB":  "DeviceRGB",
+	"CMYK": "DeviceCMYK",
+	"I":    "Indexed",
+	"AHx":  "ASCIIHexDecode",
+	"A85":  "ASCII85Decode",
+	"LZW":  "LZWDecode",
+	"Fl":   "FlateDecode",
+	"RL":   "RunLengthDecode",
+	"CCF":  "CCITTFaxDecode",
+	"DCT":  "DCTDecode",
+}
+
+// expandInline returns the value x of an inline image dictionary
+// with abbreviated names replaced by their full names.
+func expandInline(x pdfobject) pdfobject {
+	switch x := x.(type) {
+	case pdfname:
+		if full, ok := inlineNames[x]; ok {
+			return full
+		}
+	case pdfarray:
+		y := make(pdfarray, len(x))
+		for i, v := range x {
+			y[i] = expandInline(v)
+		}
+		return y
+	}
+	return x
+}
+
+// inlineDataLen returns the length of the data of the inline image
+// with dictionary d, or -1 if it cannot be known without scanning for EI.
+func inlineDataLen(d pdfdict) int {
+	if n, ok := d["Length"].(int64); ok && n >= 0 {
+		return int(n)
+	}
+	if d["Filter"] != nil {
+		return -1
+	}
+	w, _ := d["Width"].(int64)
+	h, _ := d["Height"].(int64)
+	bpc, _ := d["BitsPerComponent"].(int64)
+	var ncomp int64
+	switch cs := d["ColorSpace"].(type) {
+	case pdfname:
+		ncomp = map[pdfname]int64{"DeviceGray": 1, "DeviceRGB": 3, "DeviceCMYK": 4}[cs]
+	case pdfarray:
+		if len(cs) > 0 && cs[0] == pdfname("Indexed") {
+			ncomp = 1
+		}
+	}
+	if d["ImageMask"] == true {
+		ncomp, bpc = 1, 1
+	}
+	if w <= 0 || h <= 0 || bpc <= 0 || ncomp == 0 {
+		return -1
+	}
+	return int(h * ((w*ncomp*bpc + 7) / 8))
+}
+
+// readInlineImage reads an inline image following the BI operator:
+// the image dictionary, the ID operator, the image data and the EI operator.
+// It returns the dictionary, with abbreviations expanded, and the raw data.
+func (b *pdfbuffer) readInlineImage() (pdfdict, []byte) {
+	d := pdfdict{}
+	for {
+		tok := b.readToken()
+		if tok == pdfkeyword("ID") {
+			break
+		}
+		if tok == io.EOF {
+			b.errorf("malformed PDF: inline image without ID")
+		}
+		key, ok := tok.(pdfname)
+		if !ok {
+			b.errorf("malformed PDF: inline image dictionary key %v", tok)
+		}
+		if full, ok := inlineKeys[key]; ok {
+			key = full
+		}
+		d[key] = expandInline(b.readObject())
+	}
+
+	// A single white-space character separates ID from the data.
+	b.readByte()
+
+	if n := inlineDataLen(d); n >= 0 {
+		// The data may be cut short, so n is not trusted
+		// for more than a modest allocation.
+		data := make([]byte, 0, min(n, 1<<16))
+		for len(data) < n {
+			c := b.readByte()
+			if b.eof {
+				b.errorf("malformed PDF: truncated inline image")
+			}
+			data = append(data, c)
+		}
+		b.skipToEI()
+		return d, data
+	}
+	return d, b.skipToEI()
+}
+
+// skipToEI reads up to and including the EI operator that ends
+// inline image data, returning the bytes before it.
+// EI must be preceded by white space and followed by white space,
+// a delimiter or the end of the stream; the white space before it
+// is not part of the returned data.
+func (b *pdfbuffer) skipToEI() []byte {
+	var data []byte
+	for {
+		c := b.readByte()
+		if b.eof {
+			b.errorf("malformed PDF: inline image without EI")
+		}
+		data = append(data, c)
+		n := len(data)
+		if n < 2 || data[n-2] != 'E' || data[n-1] != 'I' || n > 2 && !isSpace(data[n-3]) {
+			continue
+		}
+		next := b.readByte()
+		if b.eof {
+			return data[:max(0, n-3)]
+		}
+		b.unreadByte()
+		if isSpace(next) || isDelim(next) {
+			return data[:max(0, n-3)]
+		}
+	}
+}
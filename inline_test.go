@@ -0,0 +1,96 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInlineImages(t *testing.T) {
+	data := pagePDF("<</ColorSpace <</CS0 /DeviceRGB>>>>",
+		// The data of the first image holds EI and white space.
+		"q 20 0 0 10 100 100 cm BI /W 2 /H 1 /BPC 8 /CS /RGB ID \xff\x00\x00EI \nEI Q\n"+
+			"BI /W 1 /H 2 /CS /G /BPC 8 /F /Fl ID "+deflate("\x80\xff")+" EI\n"+
+			"1 0 0 rg BI /IM true /W 8 /H 1 ID \xaa EI\n"+
+			"BI /W 1 /H 1 /BPC 8 /CS /CS0 ID \x00\x80\xff EI\n")
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.InlineImages) != 4 {
+		t.Fatalf("page has %d inline images, want 4", len(c.InlineImages))
+	}
+
+	img := c.InlineImages[0]
+	if img.Width != 2 || img.Height != 1 || img.BitsPerComponent != 8 || img.ColorSpace != "DeviceRGB" ||
+		string(img.Data) != "\xff\x00\x00EI " || img.Matrix != (Matrix{{20, 0, 0}, {0, 10, 0}, {100, 100, 1}}) {
+		t.Errorf("image 0 is %+v", img)
+	}
+	m, err := img.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := color.RGBAModel.Convert(m.At(1, 0)); got != (color.RGBA{0x45, 0x49, 0x20, 0xff}) {
+		t.Errorf("image 0 pixel 1 is %v", got)
+	}
+
+	img = c.InlineImages[1]
+	if img.ColorSpace != "DeviceGray" || !reflect.DeepEqual(img.Filters, []string{"FlateDecode"}) || img.Matrix != ident {
+		t.Errorf("image 1 is %+v", img)
+	}
+	if m, err := img.Decode(); err != nil {
+		t.Error(err)
+	} else if got := color.GrayModel.Convert(m.At(0, 1)); got != (color.Gray{0xff}) {
+		t.Errorf("image 1 pixel (0, 1) is %v", got)
+	}
+
+	img = c.InlineImages[2]
+	if !img.ImageMask || !reflect.DeepEqual(img.FillColor.Components, []float64{1, 0, 0}) || string(img.Data) != "\xaa" {
+		t.Errorf("image 2 is %+v", img)
+	}
+	if m, err := img.Decode(); err != nil {
+		t.Error(err)
+	} else {
+		// Samples of 0 are painted; 1 are masked out.
+		for x, a := range []uint8{0, 0xff} {
+			if _, _, _, got := m.At(x, 0).RGBA(); uint8(got>>8) != a {
+				t.Errorf("image 2 pixel %d has alpha %d, want %d", x, got>>8, a)
+			}
+		}
+	}
+
+	if img := c.InlineImages[3]; img.ColorSpace != "DeviceRGB" || string(img.Data) != "\x00\x80\xff" {
+		t.Errorf("image 3 is %+v", img)
+	}
+}
+
+func TestOversizedInlineImages(t *testing.T) {
+	// A JPEG header giving a size of 65000x65000.
+	jpg := []byte(jpegData(8, 8, 0x80))
+	sof := bytes.Index(jpg, []byte{0xff, 0xc0})
+	copy(jpg[sof+5:], []byte{0xfd, 0xe8, 0xfd, 0xe8})
+
+	// The data is filtered, so its length is not checked as it is read.
+	data := pagePDF("<<>>",
+		"BI /W 32768 /H 32768 /BPC 16 /CS /RGB /F /Fl ID "+deflate("\x00\x01")+" EI\n"+
+			"BI /IM true /W 32768 /H 32768 /F /Fl ID "+deflate("\x00\x01")+" EI\n"+
+			"BI /W 8 /H 8 /BPC 8 /CS /G /F /DCT ID "+string(jpg)+" EI\n")
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.InlineImages) != 3 {
+		t.Fatalf("page has %d inline images, want 3", len(c.InlineImages))
+	}
+	for i, img := range c.InlineImages {
+		if _, err := img.Decode(); err == nil || !strings.HasPrefix(err.Error(), "malformed PDF") {
+			t.Errorf("image %d: Decode error %v, want malformed PDF", i, err)
+		}
+	}
+}
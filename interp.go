@@ -0,0 +1,979 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Interpretation of page content streams.
+
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// A GraphicsState is the graphics state maintained by an Interpreter,
+// as described in PDF 32000-1:2008, §8.4.
+// The text state parameters use the names of the operators that set them.
+type GraphicsState struct {
+	Tc          float64 // character spacing
+	Tw          float64 // word spacing
+	Th          float64 // horizontal scaling, as a fraction
+	Tl          float64 // leading
+	Tf          Font    // text font
+	Tfs         float64 // text font size
+	Tmode       int     // text rendering mode
+	Trise       float64 // text rise
+	Tm          Matrix  // text matrix
+	Tlm         Matrix  // text line matrix
+	Trm         Matrix  // text rendering matrix
+	CTM         Matrix  // current transformation matrix
+	Px          float64 // current point, in user space
+	Py          float64
+	JoinStyle   int
+	CapStyle    int
+	LineWidth   float64
+	MiterLimit  float64
+	DashArray   []float64 // lengths of alternating dashes and gaps; empty for a solid line
+	DashPhase   float64   // distance into the dash pattern at which to start
+	Fill        Color     // nonstroking color
+	Stroke      Color     // stroking color
+	Clip        Rectangle // bounding box of the clipping path, in device space
+	FillAlpha   float64   // constant opacity for nonstroking operations (ca), from 0 to 1
+	StrokeAlpha float64   // constant opacity for stroking operations (CA), from 0 to 1
+	BlendMode   string    // blend mode (BM), such as Normal or Multiply
+}
+
+// A MarkedContent is a marked-content sequence, begun by
+// the BMC or BDC operator and ended by EMC (PDF 32000-1:2008, §14.6).
+type MarkedContent struct {
+	Tag        string // the tag operand, such as P, Span or Artifact
+	Properties Value  // the property list of BDC, resolved from the page resources if named
+	MCID       int    // the marked-content identifier in Properties, or -1 if none
+
+	hidden bool // the sequence is optional content that is not visible
+	seq    int  // number of the sequence within the interpretation, starting at 1
+}
+
+// An Interpreter executes the content streams of a page,
+// maintaining the graphics state and reporting what is drawn
+// through its callbacks. Any callback may be nil.
+// The GraphicsState passed to a callback is owned by the Interpreter
+// and is only valid for the duration of the call.
+//
+// Page.Content is implemented using an Interpreter; programs that
+// need more than Content provides can use one directly:
+//
+//	in := pdf.Interpreter{
+//		OnText: func(t pdf.Text, g *pdf.GraphicsState) { ... },
+//	}
+//	err := in.Run(page)
+type Interpreter struct {
+	// OnText is called for each string shown by a text operator.
+	OnText func(t Text, g *GraphicsState)
+
+	// OnPath is called for each segment of a path when the path is painted
+	// (or ended by n), with the painting operator recorded in its Op.
+	OnPath func(p Path, g *GraphicsState)
+
+	// OnImage is called when the Do operator paints an image XObject.
+	OnImage func(img Image, g *GraphicsState)
+
+	// OnInlineImage is called for each inline image (BI ... EI).
+	OnInlineImage func(img InlineImage, g *GraphicsState)
+
+	// OnRect is called when a path containing an axis-aligned rectangle,
+	// appended by the re operator, is filled or stroked.
+	OnRect func(r Rect, g *GraphicsState)
+
+	// OnShading is called when a shading is painted, either by
+	// the sh operator or by filling a path with a shading pattern.
+	OnShading func(s Shading, g *GraphicsState)
+
+	// OnStateChange is called after an operator changes the graphics state.
+	OnStateChange func(op string, g *GraphicsState)
+
+	// OnOperator is called for every operator before it is executed,
+	// with its operands. It allows callers to handle operators
+	// the Interpreter ignores, such as compatibility sections.
+	OnOperator func(op string, args []Value, g *GraphicsState)
+
+	// Strict makes problems in the content streams errors:
+	// Run stops at the first one and returns it.
+	// By default the Interpreter is lenient: it skips the offending
+	// operator, or the rest of a malformed stream, and reports a Warning.
+	Strict bool
+
+	// OnWarning is called for each problem found in lenient mode.
+	OnWarning func(w Warning)
+
+	// Layers, if non-nil, enables optional content processing:
+	// content in hidden layers is skipped, as a viewer would.
+	// Layers maps the Ref of a Layer to whether it is visible;
+	// layers not in the map are visible or hidden as in the document's
+	// default configuration. If Layers is nil, all content is reported.
+	Layers map[ObjRef]bool
+
+	page   Page
+	res    Value       // resources of the page or form being interpreted
+	forms  []pdfobjptr // forms being interpreted, outermost first
+	g      GraphicsState
+	gstack []GraphicsState
+	gfloor int // length of gstack when the innermost form began; Q cannot restore below it
+
+	cur    pathState // the path under construction
+	pathID int       // number of paths painted so far
+
+	marked     []MarkedContent // open marked-content sequences, outermost first
+	markedCopy []MarkedContent // copy of marked shared with emitted Text, or nil
+	hidden     int             // number of open marked-content sequences that are hidden
+	markedSeq  int             // number of marked-content sequences begun
+
+	layers map[ObjRef]bool // visibility of optional content groups, if Layers != nil
+
+	compat int   // depth of BX/EX compatibility sections
+	err    error // first problem found in strict mode, or the error passed to stop
+
+	// Work done on the page, limited by maxContentOps and maxFormRuns.
+	ops       int  // operators executed
+	formRuns  int  // forms interpreted
+	exhausted bool // a limit was reached; the rest of the content is skipped
+}
+
+// A pathState is a path under construction by an Interpreter.
+type pathState struct {
+	segs           []Path    // segments, in device space, not yet painted
+	sub            int       // index of the current subpath
+	subLen         int       // number of segments in the current subpath
+	closed         bool      // the current subpath has been closed
+	startX, startY float64   // start of the current subpath, in user space
+	bbox           Rectangle // bounding box of the path, in device space
+	have           bool      // the path is not empty; bbox is valid
+	clip           bool      // W or W* seen; the path clips when painted
+	clipEvenOdd    bool      // the clip was set by W*
+	rects          []Rect    // axis-aligned rectangles appended by re
+}
+
+// A Warning describes a problem in a content stream
+// that a lenient Interpreter worked around.
+type Warning struct {
+	Op  string // the operator involved, or "" for a malformed stream
+	Msg string
+}
+
+func (w Warning) String() string {
+	if w.Op == "" {
+		return w.Msg
+	}
+	return w.Op + ": " + w.Msg
+}
+
+// stop ends interpretation: Run returns err
+// once the current operator has been executed.
+func (in *Interpreter) stop(err error) {
+	if in.err == nil {
+		in.err = err
+	}
+}
+
+// warn reports a problem with the operator op.
+func (in *Interpreter) warn(op, format string, args ...interface{}) {
+	w := Warning{op, fmt.Sprintf(format, args...)}
+	if in.Strict {
+		if in.err == nil {
+			in.err = fmt.Errorf("malformed PDF: content stream: %v", w)
+		}
+		return
+	}
+	if in.OnWarning != nil {
+		in.OnWarning(w)
+	}
+}
+
+// Run interprets the content streams of page p.
+// In strict mode it returns the first problem found;
+// otherwise problems are reported to OnWarning and Run returns nil.
+func (in *Interpreter) Run(p Page) error {
+	if r := p.V.r; r != nil && r.Hook != nil {
+		defer func(start time.Time) {
+			r.Hook.ContentInterpreted(p.V.Ref(), time.Since(start))
+		}(time.Now())
+	}
+	in.page = p
+	in.res = p.Resources()
+	in.forms = nil
+	in.marked, in.markedCopy, in.hidden = nil, nil, 0
+	in.layers = nil
+	if in.Layers != nil && p.V.r != nil {
+		in.layers = p.V.r.layerState()
+		for ref, v := range in.Layers {
+			in.layers[ref] = v
+		}
+	}
+	in.g = GraphicsState{
+		Th:          1,
+		CTM:         ident,
+		MiterLimit:  10,
+		FillAlpha:   1,
+		StrokeAlpha: 1,
+		BlendMode:   "Normal",
+		Fill:        black,
+		Stroke:      black,
+		Clip:        p.cropBox(),
+	}
+	in.gstack, in.gfloor = nil, 0
+	in.cur, in.pathID = pathState{}, 0
+	in.compat, in.err = 0, nil
+	in.ops, in.formRuns, in.exhausted = 0, 0, false
+	for _, strm := range p.RawContents() {
+		in.runStream(strm)
+		if in.err != nil {
+			return in.err
+		}
+	}
+	return nil
+}
+
+// runStream interprets the content stream strm, reporting
+// a stream that cannot be parsed as a problem rather than panicking.
+func (in *Interpreter) runStream(strm Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			in.warn("", "%v", r)
+		}
+	}()
+	Interpret(strm, in.do)
+}
+
+// maxFormDepth limits the nesting of form XObjects.
+const maxFormDepth = 32
+
+// maxContentOps and maxFormRuns limit the operators executed and the
+// forms interpreted for a page. Forms that paint other forms more than
+// once can make a small file do exponential work, which the check for
+// forms already being interpreted does not prevent.
+const (
+	maxContentOps = 1 << 24
+	maxFormRuns   = 1 << 18
+)
+
+// skipRest reports that a limit on the work done for the page has been
+// reached, as a problem with op, and skips the rest of the content.
+func (in *Interpreter) skipRest(op, format string, args ...interface{}) {
+	in.exhausted = true
+	in.warn(op, format+"; skipping the rest of the page", args...)
+}
+
+// runForm interprets the content stream of the form XObject form
+// as if it appeared in place of the Do operator that paints it
+// (PDF 32000-1:2008, §8.10). Forms that are already being interpreted,
+// which would recurse forever, are skipped.
+func (in *Interpreter) runForm(form Value) {
+	if form.Kind() != Stream || len(in.forms) >= maxFormDepth || in.exhausted {
+		return
+	}
+	for _, ptr := range in.forms {
+		if ptr == form.ptr {
+			return
+		}
+	}
+	if in.formRuns++; in.formRuns > maxFormRuns {
+		in.skipRest("Do", "more than %d forms painted", maxFormRuns)
+		return
+	}
+
+	// The form runs in its own graphics state and resources,
+	// with its matrix concatenated to the CTM and clipped to its bounding box.
+	// Its Q operators cannot restore the states saved by its painter.
+	saved, savedStack, savedFloor, savedRes := in.g, len(in.gstack), in.gfloor, in.res
+	savedPath := in.cur
+	savedMarked := len(in.marked)
+	defer func() {
+		in.g, in.gstack, in.gfloor, in.res = saved, in.gstack[:savedStack], savedFloor, savedRes
+		in.cur = savedPath
+		for len(in.marked) > savedMarked {
+			in.endMarked()
+		}
+		in.forms = in.forms[:len(in.forms)-1]
+	}()
+	in.forms = append(in.forms, form.ptr)
+	in.gfloor = len(in.gstack)
+
+	if a := floats(form.Key("Matrix")); len(a) == 6 {
+		in.g.CTM = Matrix{{a[0], a[1], 0}, {a[2], a[3], 0}, {a[4], a[5], 1}}.mul(in.g.CTM)
+	}
+	if bbox := form.Key("BBox"); bbox.Len() == 4 {
+		r := rectValue(bbox)
+		in.cur = pathState{}
+		in.extendPath(in.g.transform(r.Min.X, r.Min.Y), in.g.transform(r.Max.X, r.Min.Y),
+			in.g.transform(r.Max.X, r.Max.Y), in.g.transform(r.Min.X, r.Max.Y))
+		in.g.Clip = intersectRect(in.g.Clip, in.cur.bbox)
+	}
+	in.cur = pathState{}
+
+	// Old files may omit the form's resources,
+	// in which case it uses those of the page or form that paints it.
+	if res := form.Key("Resources"); res.Kind() == Dict {
+		in.res = res
+	}
+	in.runStream(form)
+}
+
+// font returns the font with the given resource name.
+// Page fonts are cached by the Page, and all fonts by the Reader.
+func (in *Interpreter) font(name string) Font {
+	if len(in.forms) == 0 {
+		return in.page.Font(name)
+	}
+	return fontEntry(in.res.Key("Font"), name)
+}
+
+// visible reports whether content in the optional content oc is visible.
+// All content is visible unless Layers is set.
+func (in *Interpreter) visible(oc Value) bool {
+	return in.layers == nil || oc.Kind() != Dict || ocVisible(oc, in.layers)
+}
+
+// endMarked ends the innermost marked-content sequence.
+func (in *Interpreter) endMarked() {
+	n := len(in.marked)
+	if in.marked[n-1].hidden {
+		in.hidden--
+	}
+	in.marked, in.markedCopy = in.marked[:n-1], nil
+}
+
+func (in *Interpreter) emitText(t Text) {
+	if in.hidden > 0 {
+		return
+	}
+	t.Clip = in.g.Clip
+	t.FillAlpha, t.StrokeAlpha, t.BlendMode = in.g.FillAlpha, in.g.StrokeAlpha, in.g.BlendMode
+	if in.markedCopy == nil && len(in.marked) > 0 {
+		in.markedCopy = append([]MarkedContent(nil), in.marked...)
+	}
+	t.MarkedContent = in.markedCopy
+	if in.OnText != nil {
+		in.OnText(t, &in.g)
+	}
+}
+
+func (in *Interpreter) emitPath(p Path) {
+	if in.hidden > 0 {
+		return
+	}
+	p.Clip = in.g.Clip
+	p.FillAlpha, p.StrokeAlpha, p.BlendMode = in.g.FillAlpha, in.g.StrokeAlpha, in.g.BlendMode
+	p.JoinStyle = in.g.JoinStyle
+	p.CapStyle = in.g.CapStyle
+	p.FillColor = in.g.Fill
+	p.StrokeColor = in.g.Stroke
+	p.MiterLimit = in.g.MiterLimit
+
+	// Like the line width, dash lengths are scaled to device space.
+	scale := math.Sqrt(in.g.CTM[0][0]*in.g.CTM[0][0] + in.g.CTM[1][0]*in.g.CTM[1][0])
+	p.LineWidth = in.g.LineWidth * scale
+	for _, d := range in.g.DashArray {
+		p.DashArray = append(p.DashArray, d*scale)
+	}
+	p.DashPhase = in.g.DashPhase * scale
+	if in.OnPath != nil {
+		in.OnPath(p, &in.g)
+	}
+}
+
+// moveTo begins a new subpath at (x, y) in user space.
+func (in *Interpreter) moveTo(x, y float64) {
+	if in.cur.subLen > 0 {
+		in.cur.sub++
+	}
+	in.cur.subLen, in.cur.closed = 0, false
+	in.cur.startX, in.cur.startY = x, y
+}
+
+// addSegment appends the segment p, in device space, to the current subpath.
+// A segment following a closed subpath begins a new one at the same point.
+func (in *Interpreter) addSegment(p Path) {
+	if in.cur.closed {
+		in.moveTo(in.cur.startX, in.cur.startY)
+	}
+	p.Subpath = in.cur.sub
+	in.cur.segs = append(in.cur.segs, p)
+	in.cur.subLen++
+	in.extendPath(p.Points...)
+}
+
+// closeSubpath closes the current subpath, appending a straight line
+// back to its start if needed, as the h operator does.
+func (in *Interpreter) closeSubpath() {
+	g := &in.g
+	if in.cur.subLen == 0 || in.cur.closed {
+		return
+	}
+	if g.Px != in.cur.startX || g.Py != in.cur.startY {
+		pt1, pt2 := g.transform(g.Px, g.Py), g.transform(in.cur.startX, in.cur.startY)
+		in.addSegment(Path{Kind: "line", Points: []Point{pt1, pt2}, EndPoint: pt2})
+	}
+	for i := len(in.cur.segs) - 1; i >= 0 && in.cur.segs[i].Subpath == in.cur.sub; i-- {
+		in.cur.segs[i].Closed = true
+	}
+	g.Px, g.Py = in.cur.startX, in.cur.startY
+	in.cur.closed = true
+}
+
+// paintPath reports the segments of the current path,
+// painted by the operator op.
+func (in *Interpreter) paintPath(op string) {
+	filled := strings.ContainsAny(op, "fFBb")
+	evenOdd := strings.HasSuffix(op, "*") || !filled && in.cur.clipEvenOdd
+	stroked := strings.ContainsAny(op, "SsBb")
+	for _, p := range in.cur.segs {
+		p.Op = op
+		p.PathID = in.pathID
+		p.Filled, p.Stroked, p.EvenOdd, p.ClipPath = filled, stroked, evenOdd, in.cur.clip
+		in.emitPath(p)
+	}
+	if len(in.cur.segs) > 0 {
+		in.pathID++
+	}
+	if in.OnRect == nil || in.hidden > 0 || !filled && !stroked {
+		return
+	}
+	scale := math.Sqrt(in.g.CTM[0][0]*in.g.CTM[0][0] + in.g.CTM[1][0]*in.g.CTM[1][0])
+	for _, r := range in.cur.rects {
+		r.Filled, r.Stroked = filled, stroked
+		r.FillColor, r.StrokeColor = in.g.Fill, in.g.Stroke
+		r.LineWidth = in.g.LineWidth * scale
+		r.Clip = in.g.Clip
+		in.OnRect(r, &in.g)
+	}
+}
+
+// paintShadingPattern reports the shading of the current fill pattern,
+// if it is a shading pattern, clipped to the current path.
+// It must be called before the path is ended.
+func (in *Interpreter) paintShadingPattern() {
+	if in.OnShading == nil || in.hidden > 0 || in.g.Fill.Space != "Pattern" || !in.cur.have {
+		return
+	}
+	pat := in.res.Key("Pattern").Key(in.g.Fill.Pattern)
+	if pat.Key("PatternType").CoerceInt64(0) != 2 {
+		return
+	}
+	// A pattern's matrix maps pattern space to the default
+	// coordinate space of the page, not to the current user space.
+	m := ident
+	if a := floats(pat.Key("Matrix")); len(a) == 6 {
+		m = Matrix{{a[0], a[1], 0}, {a[2], a[3], 0}, {a[4], a[5], 1}}
+	}
+	in.OnShading(newShading(pat.Key("Shading"), m, intersectRect(in.g.Clip, in.cur.bbox)), &in.g)
+}
+
+// extendPath adds the device-space points pts to the bounding box of the current path.
+func (in *Interpreter) extendPath(pts ...Point) {
+	for _, pt := range pts {
+		r := Rectangle{pt, pt}
+		if in.cur.have {
+			r = unionRect(in.cur.bbox, r)
+		}
+		in.cur.bbox = r
+		in.cur.have = true
+	}
+}
+
+// endPath ends the current path after a painting operator,
+// intersecting it into the clipping region if W or W* preceded it,
+// and starts a new, empty path.
+func (in *Interpreter) endPath() {
+	if in.cur.clip && in.cur.have {
+		in.g.Clip = intersectRect(in.g.Clip, in.cur.bbox)
+		in.stateChanged("W")
+	}
+	in.cur = pathState{segs: in.cur.segs[:0], rects: in.cur.rects[:0]}
+}
+
+func (in *Interpreter) stateChanged(op string) {
+	if in.OnStateChange != nil {
+		in.OnStateChange(op, &in.g)
+	}
+}
+
+// opArgs gives the number of operands of each content stream operator
+// (PDF 32000-1:2008, Table A.1). A negative number -n means at least n.
+// BI has the two operands that Interpret passes for an inline image.
+var opArgs = map[string]int{
+	"b": 0, "B": 0, "b*": 0, "B*": 0, "BDC": 2, "BI": 2, "BMC": 1, "BT": 0, "BX": 0,
+	"c": 6, "cm": 6, "CS": 1, "cs": 1, "d": 2, "d0": 2, "d1": 6, "Do": 1, "DP": 2,
+	"EMC": 0, "ET": 0, "EX": 0, "f": 0, "F": 0, "f*": 0, "G": 1, "g": 1, "gs": 1,
+	"h": 0, "i": 1, "j": 1, "J": 1, "K": 4, "k": 4, "l": 2, "m": 2, "M": 1, "MP": 1,
+	"n": 0, "q": 0, "Q": 0, "re": 4, "RG": 3, "rg": 3, "ri": 1, "s": 0, "S": 0,
+	"SC": -1, "SCN": -1, "sc": -1, "scn": -1, "sh": 1,
+	"T*": 0, "Tc": 1, "TD": 2, "Td": 2, "Tf": 2, "TJ": 1, "Tj": 1, "TL": 1, "Tm": 6,
+	"Tr": 1, "Ts": 1, "Tw": 1, "Tz": 1, "v": 4, "w": 1, "W": 0, "W*": 0, "y": 4,
+	"'": 1, "\"": 3,
+}
+
+// stateOps lists the operators that change the graphics state.
+var stateOps = map[string]bool{
+	"q": true, "Q": true, "cm": true, "w": true, "J": true, "j": true, "M": true,
+	"d": true, "ri": true, "i": true, "gs": true,
+	"CS": true, "cs": true, "SC": true, "SCN": true, "sc": true, "scn": true,
+	"G": true, "g": true, "RG": true, "rg": true, "K": true, "k": true,
+	"BT": true, "Tc": true, "Tw": true, "Tz": true, "TL": true, "Tf": true,
+	"Tr": true, "Ts": true, "Td": true, "TD": true, "Tm": true, "T*": true,
+}
+
+// setExtGState applies the parameters of the graphics state
+// parameter dictionary gs (PDF 32000-1:2008, §8.4.5) that
+// the Interpreter tracks. Other parameters are ignored.
+func (in *Interpreter) setExtGState(gs Value) {
+	g := &in.g
+	if gs.Kind() != Dict {
+		in.warn("gs", "graphics state parameter dictionary not found")
+		return
+	}
+	if v := gs.Key("LW"); v.Kind() != Null {
+		g.LineWidth = v.CoerceFloat64(g.LineWidth)
+	}
+	if v := gs.Key("LC"); v.Kind() != Null {
+		g.CapStyle = int(v.CoerceInt64(int64(g.CapStyle)))
+	}
+	if v := gs.Key("LJ"); v.Kind() != Null {
+		g.JoinStyle = int(v.CoerceInt64(int64(g.JoinStyle)))
+	}
+	if v := gs.Key("ML"); v.Kind() != Null {
+		g.MiterLimit = v.CoerceFloat64(g.MiterLimit)
+	}
+	if d := gs.Key("D"); d.Len() == 2 {
+		g.DashArray = floats(d.Index(0))
+		g.DashPhase = d.Index(1).CoerceFloat64(0)
+	}
+	if v := gs.Key("CA"); v.Kind() != Null {
+		g.StrokeAlpha = v.CoerceFloat64(g.StrokeAlpha)
+	}
+	if v := gs.Key("ca"); v.Kind() != Null {
+		g.FillAlpha = v.CoerceFloat64(g.FillAlpha)
+	}
+	switch bm := gs.Key("BM"); bm.Kind() {
+	case Name:
+		g.BlendMode = bm.CoerceName(g.BlendMode)
+	case Array:
+		// An array lists blend modes in order of preference;
+		// all readers support the first standard one.
+		if bm.Len() > 0 {
+			g.BlendMode = bm.Index(0).CoerceName(g.BlendMode)
+		}
+	}
+	if font := gs.Key("Font"); font.Len() == 2 {
+		g.Tf = fontEntry(font, 0)
+		g.Tfs = font.Index(1).CoerceFloat64(0)
+	}
+}
+
+// transform returns the point (x, y) in user space mapped to device space.
+func (g *GraphicsState) transform(x, y float64) Point {
+	m := Matrix{{1, 0, 0}, {0, 1, 0}, {x, y, 1}}.mul(g.CTM)
+	return Point{m[2][0], m[2][1]}
+}
+
+// verticalAdvance returns the distance in text space that showing ch
+// in vertical writing mode moves the text position along the y axis.
+// Horizontal scaling does not apply.
+func (g *GraphicsState) verticalAdvance(ch PositionedChar) float64 {
+	ty := ch.VWidth/1000*g.Tfs + g.Tc
+	if string(ch.Text) == " " {
+		ty += g.Tw
+	}
+	return ty
+}
+
+func (in *Interpreter) showText(s string) {
+	g := &in.g
+	//if g.Tf.V.Key("Name").Kind() == 0 {
+	//	fmt.Println(g)
+	//}
+	decoded := g.Tf.Decode(s)
+	vertical := g.Tf.Vertical()
+
+	for _, ch := range decoded {
+		if string(ch.Text) != " " {
+			break
+		}
+		if vertical {
+			g.Tm = Matrix{{1, 0, 0}, {0, 1, 0}, {0, g.verticalAdvance(ch), 1}}.mul(g.Tm)
+			continue
+		}
+		w0 := ch.Width / 1000
+		if w0 < 0.05 {
+			//fmt.Println("Fonth width small?", w0, "\t", string(ch.Text), "\t", decoded)
+		}
+		//fmt.Println(ch.Length())
+		tx := (w0*g.Tfs + g.Tc) * g.Th
+		if string(ch.Text) == string(" ") {
+			tx += g.Tw * g.Th
+		}
+		tx = tx * g.Th
+		g.Tm = Matrix{{1, 0, 0}, {0, 1, 0}, {tx, 0, 1}}.mul(g.Tm)
+	}
+
+	Trm := Matrix{{g.Tfs * g.Th, 0, 0}, {0, g.Tfs, 0}, {0, g.Trise, 1}}.mul(g.Tm).mul(g.CTM)
+	if vertical && len(decoded) > 0 {
+		// In vertical writing the current point is the vertical origin
+		// of the glyph; its horizontal origin lies back along the
+		// position vector.
+		v := decoded[0].VOrigin
+		Trm = Matrix{{1, 0, 0}, {0, 1, 0}, {-v.X / 1000, -v.Y / 1000, 1}}.mul(Trm)
+	}
+
+	f := g.Tf.BaseFont()
+	if i := strings.Index(f, "+"); i >= 0 {
+		f = f[i+1:]
+	}
+
+	fw := g.Tf.FontWeight()
+
+	// For Type 3 fonts too the size is that of the text space unit,
+	// the em in which their widths are given.
+	fontsize := math.Sqrt(Trm[0][0]*Trm[0][0] + Trm[1][0]*Trm[1][0])
+	rotationAngle := math.Atan2(Trm[1][0], Trm[0][0]) * 180 / math.Pi
+
+	in.emitText(Text{
+		Font:          f,
+		FontSize:      fontsize,
+		RotationAngle: rotationAngle,
+		FontWeight:    fw,
+		X:             Trm[2][0],
+		Y:             Trm[2][1],
+		W:             Trm[0][0],
+		S:             decoded,
+		FillColor:     g.Fill,
+		StrokeColor:   g.Stroke,
+		RenderMode:    g.Tmode,
+		Invisible:     g.Tmode == 3 || g.Tmode == 7,
+		Bold:          g.Tf.isBold(),
+		Italic:        g.Tf.isItalic(),
+	})
+
+	skip := true
+	for _, ch := range decoded {
+		if skip && string(ch.Text) == " " {
+			continue
+		} else {
+			skip = false
+		}
+		if vertical {
+			g.Tm = Matrix{{1, 0, 0}, {0, 1, 0}, {0, g.verticalAdvance(ch), 1}}.mul(g.Tm)
+			continue
+		}
+		w0 := ch.Width
+		tx := w0/1000*g.Tfs + g.Tc
+		for _, ch3 := range string(ch.Text) {
+			if string(ch3) == " " {
+				tx += g.Tw
+			}
+		}
+		tx *= g.Th
+		ty := 0.0
+		g.Tm = Matrix{{1, 0, 0}, {0, 1, 0}, {tx, ty, 1}}.mul(g.Tm)
+	}
+}
+
+func (in *Interpreter) do(stk *Stack, op string) {
+	g := &in.g
+	n := stk.Len()
+	args := make([]Value, n)
+	for i := n - 1; i >= 0; i-- {
+		args[i] = stk.Pop()
+	}
+	if in.err != nil || in.exhausted {
+		return
+	}
+	if in.ops++; in.ops > maxContentOps {
+		in.skipRest(op, "more than %d operators", maxContentOps)
+		return
+	}
+	if in.OnOperator != nil {
+		in.OnOperator(op, args, g)
+	}
+
+	want, ok := opArgs[op]
+	switch {
+	case !ok:
+		// Unknown operators are expected inside BX/EX compatibility sections.
+		if in.compat == 0 {
+			in.warn(op, "unknown operator")
+		}
+		return
+	case want < 0 && len(args) < -want:
+		in.warn(op, "want at least %d operands, have %d", -want, len(args))
+		return
+	case want >= 0 && len(args) < want:
+		in.warn(op, "want %d operands, have %d", want, len(args))
+		return
+	case want >= 0 && len(args) > want:
+		in.warn(op, "want %d operands, have %d", want, len(args))
+		args = args[len(args)-want:]
+	}
+
+	switch op {
+	case "BX": // begin compatibility section
+		in.compat++
+	case "EX": // end compatibility section
+		if in.compat > 0 {
+			in.compat--
+		}
+	case "ri", "i", "d0", "d1", "MP", "DP": // rendering intent, flatness, glyph metrics, marked points
+	case "c", "v", "y": // append curved segment to path
+		x0, y0 := g.Px, g.Py
+		var c [6]float64
+		for i := range args {
+			c[i] = args[i].CoerceFloat64(0)
+		}
+		switch op {
+		case "v": // first control point is the current point
+			c = [6]float64{x0, y0, c[0], c[1], c[2], c[3]}
+		case "y": // second control point is the end point
+			c = [6]float64{c[0], c[1], c[2], c[3], c[2], c[3]}
+		}
+		g.Px, g.Py = c[4], c[5]
+		pt1, pt2, pt3, pt4 := g.transform(x0, y0), g.transform(c[0], c[1]), g.transform(c[2], c[3]), g.transform(c[4], c[5])
+		in.addSegment(Path{Kind: "bezier", Points: []Point{pt1, pt2, pt3, pt4}, EndPoint: pt4})
+
+	case "cm": // update g.CTM
+		var m Matrix
+		for i := 0; i < 6; i++ {
+			m[i/2][i%2] = args[i].CoerceFloat64(0)
+		}
+		m[2][2] = 1
+		g.CTM = m.mul(g.CTM)
+	case "gs": // set parameters from graphics state resource
+		in.setExtGState(in.res.Key("ExtGState").Key(args[0].CoerceName("")))
+	case "l": // append straight line segment to path
+		pt1 := g.transform(g.Px, g.Py)
+		g.Px, g.Py = args[0].CoerceFloat64(0), args[1].CoerceFloat64(0)
+		pt2 := g.transform(g.Px, g.Py)
+		in.addSegment(Path{Kind: "line", Points: []Point{pt1, pt2}, EndPoint: pt2})
+
+	case "m": // begin new subpath
+		g.Px, g.Py = args[0].CoerceFloat64(0), args[1].CoerceFloat64(0)
+		in.moveTo(g.Px, g.Py)
+		in.extendPath(g.transform(g.Px, g.Py))
+
+	case "re": // append rectangle to path as a complete subpath
+		x, y := args[0].CoerceFloat64(0), args[1].CoerceFloat64(0)
+		w, h := args[2].CoerceFloat64(0), args[3].CoerceFloat64(0)
+		g.Px, g.Py = x, y
+		in.moveTo(x, y)
+		in.addSegment(Path{Kind: "rect", Points: []Point{g.transform(x, y), g.transform(x+w, y+h)}, EndPoint: g.transform(x, y)})
+		in.extendPath(g.transform(x+w, y), g.transform(x, y+h))
+		in.closeSubpath()
+		if m := g.CTM; m[0][1] == 0 && m[1][0] == 0 || m[0][0] == 0 && m[1][1] == 0 {
+			in.cur.rects = append(in.cur.rects, rectOf(g.transform(x, y), g.transform(x+w, y+h)))
+		}
+
+	case "q": // save graphics state
+		in.gstack = append(in.gstack, *g)
+
+	case "Q": // restore graphics state
+		n := len(in.gstack) - 1
+		if n < in.gfloor {
+			in.warn(op, "unbalanced Q")
+			break
+		}
+		*g = in.gstack[n]
+		in.gstack = in.gstack[:n]
+
+	case "BT": // begin text (reset text matrix and line matrix)
+		g.Tm = ident
+		g.Tlm = g.Tm
+	case "ET": // end text
+
+	case "T*": // move to start of next line
+		x := Matrix{{1, 0, 0}, {0, 1, 0}, {0, -g.Tl, 1}}
+		g.Tlm = x.mul(g.Tlm)
+		g.Tm = g.Tlm
+
+	case "Tc": // set character spacing
+		g.Tc = args[0].CoerceFloat64(0)
+
+	case "TD": // move text position and set leading
+		g.Tl = -args[1].CoerceFloat64(0)
+
+		fallthrough
+	case "Td": // move text position
+		tx := args[0].CoerceFloat64(0)
+		ty := args[1].CoerceFloat64(0)
+		x := Matrix{{1, 0, 0}, {0, 1, 0}, {tx, ty, 1}}
+		g.Tlm = x.mul(g.Tlm)
+		g.Tm = g.Tlm
+
+	case "Tf": // set text font and size
+		f := args[0].CoerceName("")
+		g.Tf = in.font(f)
+		if g.Tf.V.Kind() == Null {
+			in.warn(op, "font %s not found", f)
+		}
+		g.Tfs = args[1].CoerceFloat64(0)
+
+	case "\"": // set spacing, move to next line, and show text
+		g.Tw = args[0].CoerceFloat64(0)
+		g.Tc = args[1].CoerceFloat64(0)
+		args = args[2:]
+		fallthrough
+	case "'": // move to next line and show text
+		x := Matrix{{1, 0, 0}, {0, 1, 0}, {0, -g.Tl, 1}}
+		g.Tlm = x.mul(g.Tlm)
+		g.Tm = g.Tlm
+		fallthrough
+	case "Tj": // show text
+		in.showText(args[0].CoerceString(""))
+
+	case "TJ": // show text, allowing individual glyph positioning
+		v := args[0]
+		var tx float64
+		var rs string
+		w0 := 0.0
+		for i := 0; i < v.Len(); i++ {
+			x := v.Index(i)
+			if x.Kind() == String {
+				rs = x.CoerceString("")
+				in.showText(rs)
+				w0 = 0.0
+				//for _, runeValue := range rs {
+				//	//fmt.Println("waaaa", string(runeValue), int(runeValue))
+				//	w0 += g.Tf.Width(int(runeValue)) / 1000
+				//}
+
+			} else {
+				tx = (w0 - x.CoerceFloat64(0)/1000 + g.Tc) * g.Tfs * g.Th
+				g.Tm = Matrix{{1, 0, 0}, {0, 1, 0}, {tx, 0, 1}}.mul(g.Tm)
+			}
+		}
+
+	case "TL": // set text leading
+		g.Tl = args[0].CoerceFloat64(0)
+
+	case "Tm": // set text matrix and line matrix
+		var m Matrix
+		for i := 0; i < 6; i++ {
+			m[i/2][i%2] = args[i].CoerceFloat64(0)
+		}
+		m[2][2] = 1
+		g.Tm = m
+		g.Tlm = m
+
+	case "Tr": // set text rendering mode
+		g.Tmode = int(args[0].CoerceInt64(0))
+
+	case "Ts": // set text rise
+		g.Trise = args[0].CoerceFloat64(0)
+
+	case "Tw": // set word spacing
+		g.Tw = args[0].CoerceFloat64(0)
+
+	case "Tz": // set horizontal text scaling
+		g.Th = args[0].CoerceFloat64(0) / 100
+	case "W", "W*": // clip to the current path when it is next painted
+		in.cur.clip = true
+		in.cur.clipEvenOdd = op == "W*"
+	case "Do": // paint XObject
+		name := args[0].CoerceName("")
+		xobj := in.res.Key("XObject").Key(name)
+		if in.hidden > 0 || !in.visible(xobj.Key("OC")) {
+			break
+		}
+		switch xobj.Key("Subtype").CoerceName("") {
+		case "Image":
+			if in.OnImage != nil {
+				img := newImage(name, xobj, g.CTM)
+				img.Clip = g.Clip
+				img.Alpha, img.BlendMode = g.FillAlpha, g.BlendMode
+				img.FillColor = g.Fill
+				in.OnImage(img, g)
+			}
+		case "Form":
+			in.runForm(xobj)
+		}
+	case "": //something went wrong
+	case "d": // set line dash pattern
+		g.DashArray = nil
+		for i := 0; i < args[0].Len(); i++ {
+			g.DashArray = append(g.DashArray, args[0].Index(i).CoerceFloat64(0))
+		}
+		g.DashPhase = args[1].CoerceFloat64(0)
+	case "g": // set gray level for nonstroking operations
+		g.Fill = Color{Space: "DeviceGray", Components: colorArgs(args)}
+	case "G": // set gray level for stroking operations
+		g.Stroke = Color{Space: "DeviceGray", Components: colorArgs(args)}
+	case "rg": // set RGB color for nonstroking operations
+		g.Fill = Color{Space: "DeviceRGB", Components: colorArgs(args)}
+	case "RG": // set RGB color for stroking operations
+		g.Stroke = Color{Space: "DeviceRGB", Components: colorArgs(args)}
+	case "k": // set CMYK color for nonstroking operations
+		g.Fill = Color{Space: "DeviceCMYK", Components: colorArgs(args)}
+	case "K": // set CMYK color for stroking operations
+		g.Stroke = Color{Space: "DeviceCMYK", Components: colorArgs(args)}
+	case "w": // Set line width
+		g.LineWidth = args[0].CoerceFloat64(0)
+	case "j": // Set line join style
+		g.JoinStyle = int(args[0].CoerceInt64(0))
+	case "J": // Set line cap style
+		g.CapStyle = int(args[0].CoerceInt64(0))
+	case "f", "F", "f*", "B", "B*", "b", "b*", "n", "S", "s": // paint (or just end) the path
+		if op == "b" || op == "b*" || op == "s" {
+			in.closeSubpath()
+		}
+		if op != "n" && op != "S" && op != "s" {
+			in.paintShadingPattern()
+		}
+		in.paintPath(op)
+		in.endPath()
+	case "BI": // inline image, read by Interpret
+		if in.OnInlineImage != nil && in.hidden == 0 && len(args) == 2 {
+			img := newInlineImage(args[0], args[1], in.res, g.CTM)
+			img.FillColor = g.Fill
+			in.OnInlineImage(img, g)
+		}
+	case "sh": // paint shading
+		sh := in.res.Key("Shading").Key(args[0].CoerceName(""))
+		if in.OnShading != nil && in.hidden == 0 && sh.Kind() != Null {
+			in.OnShading(newShading(sh, g.CTM, g.Clip), g)
+		}
+	case "M": // set miter limit
+		g.MiterLimit = args[0].CoerceFloat64(0)
+	case "h": // close subpath
+		in.closeSubpath()
+	case "cs": // set color space for nonstroking operations
+		g.Fill = initialColor(lookupColorSpace(in.res, args[0].CoerceName("")))
+	case "CS": // set color space for stroking operations
+		g.Stroke = initialColor(lookupColorSpace(in.res, args[0].CoerceName("")))
+	case "sc", "scn": // set color for nonstroking operations
+		g.Fill = g.Fill.withComponents(args)
+	case "SC", "SCN": // set color for stroking operations
+		g.Stroke = g.Stroke.withComponents(args)
+	case "BMC", "BDC": // begin marked-content sequence
+		in.markedSeq++
+		mc := MarkedContent{Tag: args[0].CoerceName(""), MCID: -1, seq: in.markedSeq}
+		if op == "BDC" && len(args) == 2 {
+			mc.Properties = args[1]
+			if mc.Properties.Kind() == Name {
+				mc.Properties = in.res.Key("Properties").Key(args[1].CoerceName(""))
+			}
+			mc.MCID = int(mc.Properties.Key("MCID").CoerceInt64(-1))
+			if mc.Tag == "OC" && !in.visible(mc.Properties) {
+				mc.hidden = true
+				in.hidden++
+			}
+		}
+		in.marked, in.markedCopy = append(in.marked, mc), nil
+	case "EMC": // end marked-content sequence
+		if len(in.marked) > 0 {
+			in.endMarked()
+		}
+	}
+
+	if stateOps[op] {
+		in.stateChanged(op)
+	}
+}
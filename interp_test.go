@@ -0,0 +1,198 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// formsPDF returns a page that paints the form /F0, whose content is
+// content, followed by the forms /F1, /F2 and so on, with the contents
+// forms. Each form can paint the others.
+func formsPDF(content string, forms ...string) []byte {
+	var names, objs []string
+	for i := range forms {
+		names = append(names, fmt.Sprintf("/F%d %d 0 R", i, 5+i))
+	}
+	xobjs := "<</XObject <<" + strings.Join(names, " ") + ">>>>"
+	for _, f := range forms {
+		objs = append(objs, stream("/Type /XObject /Subtype /Form /BBox [0 0 612 792] /Resources "+xobjs, f))
+	}
+	return pagePDF(xobjs, content, objs...)
+}
+
+// run interprets the page of data, returning the widths of the lines
+// painted and the warnings reported.
+func run(t *testing.T, data []byte, strict bool) (widths []float64, warnings []string, err error) {
+	in := Interpreter{
+		OnPath: func(p Path, g *GraphicsState) {
+			widths = append(widths, p.LineWidth)
+		},
+		OnWarning: func(w Warning) {
+			warnings = append(warnings, w.String())
+		},
+		Strict: strict,
+	}
+	err = in.Run(openPDF(t, data).Page(1))
+	return widths, warnings, err
+}
+
+// TestFormRestore checks that a form cannot restore the graphics
+// states saved by the page that paints it.
+func TestFormRestore(t *testing.T) {
+	data := formsPDF("2 w q 3 w /F0 Do 0 0 m 5 5 l S Q 0 0 m 1 1 l S",
+		"Q Q 0 0 m 10 10 l S q 9 w")
+	widths, warnings, err := run(t, data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(widths) != "[3 3 2]" {
+		t.Errorf("line widths %v, want [3 3 2]", widths)
+	}
+	if len(warnings) != 2 || warnings[0] != "Q: unbalanced Q" {
+		t.Errorf("warnings %q, want two unbalanced Q", warnings)
+	}
+}
+
+// TestFormBudget interprets forms that each paint the next twice,
+// which without a limit on the forms painted would run 2^31 forms.
+func TestFormBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("paints many forms")
+	}
+	var forms []string
+	for i := 1; i < maxFormDepth; i++ {
+		forms = append(forms, fmt.Sprintf("/F%d Do /F%d Do", i, i))
+	}
+	forms = append(forms, "")
+	data := formsPDF("/F0 Do 0 0 m 1 1 l S", forms...)
+	widths, warnings, err := run(t, data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("Do: more than %d forms painted; skipping the rest of the page", maxFormRuns)
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("warnings %q, want %q", warnings, want)
+	}
+	if len(widths) != 0 {
+		t.Errorf("the rest of the page was painted")
+	}
+	if _, _, err := run(t, data, true); err == nil || !strings.Contains(err.Error(), "forms painted") {
+		t.Errorf("strict mode: error %v", err)
+	}
+}
+
+// TestOperatorBudget interprets a form of many operators painted many
+// times over, which must stop at the limit on the operators executed.
+func TestOperatorBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("executes many operators")
+	}
+	const opsPerForm = 1 << 12
+	var forms []string
+	for i := 1; (1<<i)*opsPerForm <= 2*maxContentOps; i++ {
+		forms = append(forms, fmt.Sprintf("/F%d Do /F%d Do", i, i))
+	}
+	forms = append(forms, strings.Repeat("1 w\n", opsPerForm))
+	data := formsPDF("/F0 Do", forms...)
+	_, warnings, err := run(t, data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("w: more than %d operators; skipping the rest of the page", maxContentOps)
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("warnings %q, want %q", warnings, want)
+	}
+}
+
+func TestInterpreter(t *testing.T) {
+	data := pagePDF("<</Font <</F1 5 0 R>>>>",
+		"q 2 0 0 2 10 20 cm 3 w 1 j 2 J 4 M [3 1] 2 d 0.5 g"+
+			" BT /F1 12 Tf 1 Tc 2 Tw 50 Tz 14 TL 5 Ts 10 10 Td (hi) Tj T* (x) Tj ET Q"+
+			" 0 0 m 10 0 l S bogus",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>")
+	var texts, ops, states, warnings []string
+	var paths []Path
+	in := Interpreter{
+		OnText: func(tx Text, g *GraphicsState) {
+			s := contentText(Content{Text: []Text{tx}})
+			texts = append(texts, fmt.Sprintf("%s at %v,%v", s, tx.X, tx.Y))
+			want := GraphicsState{Tc: 1, Tw: 2, Th: 0.5, Tl: 14, Tfs: 12, Trise: 5, JoinStyle: 1, CapStyle: 2, LineWidth: 3, MiterLimit: 4, DashPhase: 2}
+			got := GraphicsState{Tc: g.Tc, Tw: g.Tw, Th: g.Th, Tl: g.Tl, Tfs: g.Tfs, Trise: g.Trise, JoinStyle: g.JoinStyle, CapStyle: g.CapStyle, LineWidth: g.LineWidth, MiterLimit: g.MiterLimit, DashPhase: g.DashPhase}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("graphics state for %q is %+v, want %+v", s, got, want)
+			}
+			if g.CTM != (Matrix{{2, 0, 0}, {0, 2, 0}, {10, 20, 1}}) || g.Tf.BaseFont() != "Helvetica" ||
+				!reflect.DeepEqual(g.DashArray, []float64{3, 1}) || !reflect.DeepEqual(g.Fill.Components, []float64{0.5}) {
+				t.Errorf("graphics state for %q has CTM %v, font %s, dashes %v, fill %v", s, g.CTM, g.Tf.BaseFont(), g.DashArray, g.Fill)
+			}
+		},
+		OnPath: func(p Path, g *GraphicsState) {
+			paths = append(paths, p)
+		},
+		OnStateChange: func(op string, g *GraphicsState) { states = append(states, op) },
+		OnOperator:    func(op string, args []Value, g *GraphicsState) { ops = append(ops, op) },
+		OnWarning:     func(w Warning) { warnings = append(warnings, w.String()) },
+	}
+	if err := in.Run(openPDF(t, data).Page(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rise and the leading are in text space, scaled by the CTM.
+	if want := []string{"hi at 30,50", "x at 30,22"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("text %q, want %q", texts, want)
+	}
+	// Q restores the state saved by q.
+	if len(paths) != 1 || paths[0].LineWidth != 0 || paths[0].Points[1] != (Point{10, 0}) || paths[0].MiterLimit != 10 {
+		t.Errorf("paths %+v, want one line to (10, 0) in the initial state", paths)
+	}
+	if want := "q cm w j J M d g BT Tf Tc Tw Tz TL Ts Td Tj T* Tj ET Q m l S bogus"; strings.Join(ops, " ") != want {
+		t.Errorf("operators %q, want %q", strings.Join(ops, " "), want)
+	}
+	if want := "q cm w j J M d g BT Tf Tc Tw Tz TL Ts Td T* Q"; strings.Join(states, " ") != want {
+		t.Errorf("state changes %q, want %q", strings.Join(states, " "), want)
+	}
+	if want := []string{"bogus: unknown operator"}; !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings %q, want %q", warnings, want)
+	}
+
+	in.Strict = true
+	if err := in.Run(openPDF(t, data).Page(1)); err == nil || !strings.Contains(err.Error(), "bogus: unknown operator") {
+		t.Errorf("strict Run returned %v, want an unknown operator error", err)
+	}
+}
+
+// TestForm checks that the content of forms is reported as drawn on
+// the page, transformed by the form's matrix and using its resources.
+func TestForm(t *testing.T) {
+	data := pagePDF("<</XObject <</Fm 5 0 R>>>>",
+		"q 1 0 0 1 100 200 cm /Fm Do Q BT /F1 10 Tf 0 0 Td (missing) Tj ET",
+		stream("/Type /XObject /Subtype /Form /BBox [0 0 100 100] /Matrix [2 0 0 2 0 0]"+
+			" /Resources <</Font <</F1 6 0 R>> /XObject <</Inner 7 0 R>>>>",
+			"BT /F1 10 Tf 5 5 Td (form) Tj ET /Inner Do"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>",
+		stream("/Type /XObject /Subtype /Form /BBox [0 0 10 10] /Resources <</Font <</F2 6 0 R>>>>",
+			"BT /F2 10 Tf 1 1 Td (inner) Tj ET"),
+	)
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tx := range c.Text {
+		got = append(got, fmt.Sprintf("%s %s at %v,%v, %v", contentText(Content{Text: []Text{tx}}), tx.Font, tx.X, tx.Y, tx.Clip))
+	}
+	want := []string{
+		"form Helvetica at 110,210, {{100 200} {300 400}}",
+		"inner Helvetica at 102,202, {{100 200} {120 220}}",
+		"missing  at 0,0, {{0 0} {612 792}}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("text:\n%q\nwant\n%q", got, want)
+	}
+}
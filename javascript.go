@@ -0,0 +1,126 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// JavaScript embedded in documents (PDF 32000-1:2008, §12.6.4.16).
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+)
+
+// A Script is a JavaScript action found in the document.
+type Script struct {
+	// Where describes what runs the script: "Names" for document-level
+	// scripts, "OpenAction", "Catalog AA/WC" for a catalog additional
+	// action (here, will close), "Page 2 AA/O", "Page 2 Annot 3 A",
+	// "Page 2 Annot 3 AA/U" or "Field name AA/K". Scripts reached
+	// through the Next entry of another action have the same Where.
+	Where string
+	Name  string // for document-level scripts, the name in the JavaScript name tree
+	Code  string // the JavaScript source
+
+	V Value // the action dictionary
+}
+
+// maxActions limits the number of actions followed through Next
+// entries from a single action, so that a cycle cannot be followed
+// forever.
+const maxActions = 1000
+
+// JavaScript returns the JavaScript actions in the document: the
+// document-level scripts of the JavaScript name tree, the open action,
+// and the actions and additional actions of the catalog, the pages,
+// their annotations and the form fields, in that order. Security
+// scanners can use it to find documents that run code when opened.
+func (r *Reader) JavaScript() []Script {
+	var scripts []Script
+	root := r.Trailer.Key("Root")
+	add := func(where, name string, action Value) {
+		eachAction(action, func(a Value) {
+			if a.Key("S").CoerceName("") == "JavaScript" {
+				scripts = append(scripts, Script{Where: where, Name: name, Code: scriptCode(a.Key("JS")), V: a})
+			}
+		})
+	}
+	addAA := func(where string, aa Value) {
+		for _, k := range aa.Keys() {
+			add(where+"AA/"+k, "", aa.Key(k))
+		}
+	}
+
+	nameTreeEach(root.Key("Names").Key("JavaScript"), func(name string, a Value) {
+		add("Names", name, a)
+	})
+	if a := root.Key("OpenAction"); a.Kind() == Dict {
+		add("OpenAction", "", a)
+	}
+	addAA("Catalog ", root.Key("AA"))
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		where := fmt.Sprintf("Page %d ", i)
+		addAA(where, p.V.Key("AA"))
+		annots := p.V.Key("Annots")
+		for j := 0; j < annots.Len(); j++ {
+			annot := annots.Index(j)
+			where := fmt.Sprintf("Page %d Annot %d ", i, j+1)
+			add(where+"A", "", annot.Key("A"))
+			addAA(where, annot.Key("AA"))
+		}
+	}
+	for _, f := range r.Form() {
+		addAA("Field "+f.Name+" ", f.V.Key("AA"))
+	}
+	return scripts
+}
+
+// eachAction calls f for the action a and each action that follows it
+// through the Next entries, in the order they are performed.
+// Each action that is an indirect object is performed once, so that
+// Next entries that form a cycle are not followed forever.
+func eachAction(a Value, f func(Value)) {
+	seen := map[ObjRef]bool{a.Ref(): true}
+	n := 0
+	var walk func(a Value)
+	walk = func(a Value) {
+		if a.Kind() != Dict || n >= maxActions {
+			return
+		}
+		n++
+		f(a)
+		next := a.Key("Next")
+		if next.Kind() != Array {
+			if ptr, ok := a.entryRef("Next"); ok {
+				next = Value{r: a.r, data: pdfarray{ptr}}
+			} else {
+				next = arrayOf(next)
+			}
+		}
+		for i := 0; i < next.Len(); i++ {
+			if ptr, ok := next.entryRef(i); ok {
+				ref := ObjRef{ptr.id, ptr.gen}
+				if seen[ref] {
+					continue
+				}
+				seen[ref] = true
+			}
+			walk(next.Index(i))
+		}
+	}
+	walk(a)
+}
+
+// scriptCode returns the JavaScript source js,
+// a text string or a stream.
+func scriptCode(js Value) string {
+	if js.Kind() == Stream {
+		b, err := io.ReadAll(js.Reader())
+		if err != nil {
+			return ""
+		}
+		return Value{data: string(b)}.CoerceText("")
+	}
+	return js.CoerceText("")
+}
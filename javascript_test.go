@@ -0,0 +1,50 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestJavaScript(t *testing.T) {
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R /Names <</JavaScript <</Names [(init) 5 0 R]>>>>"+
+			" /OpenAction <</S /JavaScript /JS (open\\(\\)) /Next [6 0 R <</S /GoTo /D [3 0 R /Fit]>>]>>"+
+			" /AA <</WC 7 0 R>> /AcroForm <</Fields [8 0 R]>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /AA <</O <</S /JavaScript /JS 9 0 R>>>> /Annots [4 0 R 8 0 R]>>",
+		"<</Type /Annot /Subtype /Link /Rect [0 0 1 1] /A <</S /URI /URI (http://example.com/)>>>>",
+		"<</S /JavaScript /JS (var x = 1;)>>",
+		// Followed from the open action, and from itself.
+		"<</S /JavaScript /JS (next\\(\\)) /Next 6 0 R>>",
+		"<</S /JavaScript /JS <feff0063006c006f00730065>>>",
+		"<</Type /Annot /Subtype /Widget /FT /Tx /T (total) /Rect [0 0 1 1] /P 3 0 R"+
+			" /A <</S /JavaScript /JS (click)>> /AA <</K <</S /JavaScript /JS (keystroke)>>>>>>",
+		stream("", "app.alert('hi');"),
+	))
+	var got []string
+	for _, s := range r.JavaScript() {
+		got = append(got, fmt.Sprintf("%s|%s|%s", s.Where, s.Name, s.Code))
+	}
+	want := []string{
+		"Names|init|var x = 1;",
+		"OpenAction||open()",
+		"OpenAction||next()",
+		"Catalog AA/WC||close",
+		"Page 1 AA/O||app.alert('hi');",
+		"Page 1 Annot 2 A||click",
+		"Page 1 Annot 2 AA/K||keystroke",
+		"Field total AA/K||keystroke",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scripts:\n%q\nwant\n%q", got, want)
+	}
+
+	if s := openPDF(t, textPage(show(72, 700, "no scripts"))).JavaScript(); s != nil {
+		t.Errorf("JavaScript() = %v, want none", s)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Optional content (layers), PDF 32000-1:2008, §8.11.
+
+package pdf
+
+// A Layer is an optional content group: a collection of content
+// that a viewer can show or hide, such as a layer of a drawing,
+// a translation or a watermark.
+type Layer struct {
+	Name    string   // name shown in a viewer's layer list
+	Ref     ObjRef   // the optional content group
+	Visible bool     // visible in the document's default configuration
+	Intent  []string // intended use, such as View or Design
+	V       Value    // the optional content group dictionary
+}
+
+// Layers returns the document's optional content groups,
+// in the order listed in the catalog's /OCProperties.
+// A document without optional content has no layers.
+func (r *Reader) Layers() []Layer {
+	ocgs := r.Trailer.Key("Root").Key("OCProperties").Key("OCGs")
+	state := r.layerState()
+	var layers []Layer
+	for i := 0; i < ocgs.Len(); i++ {
+		g := ocgs.Index(i)
+		l := Layer{
+			Name:    g.Key("Name").CoerceText(""),
+			Ref:     g.Ref(),
+			Visible: true,
+			V:       g,
+		}
+		if v, ok := state[l.Ref]; ok {
+			l.Visible = v
+		}
+		switch intent := g.Key("Intent"); intent.Kind() {
+		case Name:
+			l.Intent = []string{intent.CoerceName("")}
+		case Array:
+			for j := 0; j < intent.Len(); j++ {
+				l.Intent = append(l.Intent, intent.Index(j).CoerceName(""))
+			}
+		}
+		layers = append(layers, l)
+	}
+	return layers
+}
+
+// layerState returns the visibility of the optional content groups
+// in the document's default configuration (the /D entry of /OCProperties).
+func (r *Reader) layerState() map[ObjRef]bool {
+	ocp := r.Trailer.Key("Root").Key("OCProperties")
+	d := ocp.Key("D")
+	state := make(map[ObjRef]bool)
+	if base := d.Key("BaseState").CoerceName("ON"); base == "OFF" {
+		ocgs := ocp.Key("OCGs")
+		for i := 0; i < ocgs.Len(); i++ {
+			state[ocgs.Index(i).Ref()] = false
+		}
+	}
+	on, off := d.Key("ON"), d.Key("OFF")
+	for i := 0; i < on.Len(); i++ {
+		state[on.Index(i).Ref()] = true
+	}
+	for i := 0; i < off.Len(); i++ {
+		state[off.Index(i).Ref()] = false
+	}
+	return state
+}
+
+// maxVisibilityDepth limits the nesting of visibility expressions.
+const maxVisibilityDepth = 16
+
+// ocVisible reports whether content belonging to oc, an optional content
+// group or membership dictionary, is visible given the group states.
+// Groups missing from state are visible.
+func ocVisible(oc Value, state map[ObjRef]bool) bool {
+	if oc.Key("Type").CoerceName("") != "OCMD" {
+		return groupVisible(oc, state)
+	}
+
+	// A membership dictionary's visibility expression,
+	// if present, takes precedence over its groups and policy.
+	if ve := oc.Key("VE"); ve.Kind() == Array {
+		return visibilityExpr(ve, state, make(map[ObjRef]bool), 0)
+	}
+	ocgs := oc.Key("OCGs")
+	if ocgs.Kind() == Null {
+		return true
+	}
+	var groups []Value
+	if ocgs.Kind() == Array {
+		for i := 0; i < ocgs.Len(); i++ {
+			groups = append(groups, ocgs.Index(i))
+		}
+	} else {
+		groups = append(groups, ocgs)
+	}
+	nOn := 0
+	for _, g := range groups {
+		if groupVisible(g, state) {
+			nOn++
+		}
+	}
+	switch oc.Key("P").CoerceName("AnyOn") {
+	case "AllOn":
+		return nOn == len(groups)
+	case "AnyOff":
+		return nOn < len(groups)
+	case "AllOff":
+		return nOn == 0
+	default: // AnyOn
+		return nOn > 0
+	}
+}
+
+// groupVisible reports whether the optional content group g is visible
+// given the group states. The groups of a membership dictionary and
+// the operands of a visibility expression can only be groups, so a
+// membership dictionary in their place is taken as a group.
+func groupVisible(g Value, state map[ObjRef]bool) bool {
+	if v, ok := state[g.Ref()]; ok {
+		return v
+	}
+	return true
+}
+
+// visibilityExpr evaluates a visibility expression, an array
+// whose first element is And, Or or Not and whose remaining elements
+// are optional content groups or nested expressions, nested in depth
+// others. The results of the nested expressions that are indirect
+// objects are kept in done, so that each is evaluated once however
+// often it is used; one that contains itself is visible.
+func visibilityExpr(ve Value, state map[ObjRef]bool, done map[ObjRef]bool, depth int) bool {
+	if ve.Kind() != Array {
+		return groupVisible(ve, state)
+	}
+	if depth >= maxVisibilityDepth {
+		return true
+	}
+	operand := func(i int) bool {
+		x := ve.Index(i)
+		ptr, ok := ve.entryRef(i)
+		if !ok || x.Kind() != Array {
+			return visibilityExpr(x, state, done, depth+1)
+		}
+		ref := ObjRef{ptr.id, ptr.gen}
+		if v, ok := done[ref]; ok {
+			return v
+		}
+		done[ref] = true
+		v := visibilityExpr(x, state, done, depth+1)
+		done[ref] = v
+		return v
+	}
+	n := ve.Len()
+	switch ve.Index(0).CoerceName("") {
+	case "And":
+		for i := 1; i < n; i++ {
+			if !operand(i) {
+				return false
+			}
+		}
+		return true
+	case "Or":
+		for i := 1; i < n; i++ {
+			if operand(i) {
+				return true
+			}
+		}
+		return false
+	case "Not":
+		return n != 2 || !operand(1)
+	}
+	return true
+}
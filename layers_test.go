@@ -0,0 +1,124 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// layersPDF returns a document with optional content groups A (5),
+// B (6) and C (7), of which B is hidden by default, followed by the
+// objects extra, numbered from 8. The page draws one line of text,
+// "Qa", "Qb" and so on, for each optional content in ocs, marked as
+// belonging to it.
+func layersPDF(ocs []string, extra ...string) []byte {
+	var props, content []string
+	for i, oc := range ocs {
+		props = append(props, fmt.Sprintf("/Q%c %s", 'a'+i, oc))
+		content = append(content, fmt.Sprintf("/OC /Q%c BDC BT /F1 12 Tf 72 %d Td (Q%c) Tj ET EMC", 'a'+i, 700-20*i, 'a'+i))
+	}
+	return buildPDF("", append([]string{
+		"<</Type /Catalog /Pages 2 0 R /OCProperties <</OCGs [5 0 R 6 0 R 7 0 R] /D <</OFF [6 0 R]>>>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R" +
+			" /Resources <</Font <</F1 <</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>>> /Properties <<" +
+			strings.Join(props, " ") + ">>>>>>",
+		stream("", strings.Join(content, "\n")),
+		"<</Type /OCG /Name (A) /Intent /View>>",
+		"<</Type /OCG /Name (B) /Intent [/View /Design]>>",
+		"<</Type /OCG /Name (C)>>",
+	}, extra...)...)
+}
+
+func TestLayers(t *testing.T) {
+	r := openPDF(t, layersPDF(nil))
+	var got []string
+	for _, l := range r.Layers() {
+		got = append(got, fmt.Sprintf("%s %v %v %d", l.Name, l.Visible, l.Intent, l.Ref.ID))
+	}
+	want := "A true [View] 5, B false [View Design] 6, C true [] 7"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("layers %s, want %s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestLayerVisibility(t *testing.T) {
+	tests := []struct {
+		oc      string
+		visible bool
+	}{
+		{"5 0 R", true},
+		{"6 0 R", false},
+		{"<</Type /OCMD /OCGs [5 0 R 6 0 R]>>", true},
+		{"<</Type /OCMD /OCGs [5 0 R 6 0 R] /P /AllOn>>", false},
+		{"<</Type /OCMD /OCGs [5 0 R 7 0 R] /P /AnyOff>>", false},
+		{"<</Type /OCMD /OCGs 6 0 R /P /AllOff>>", true},
+		{"<</Type /OCMD /OCGs [5 0 R] /VE [/Not 5 0 R]>>", false},
+		{"<</Type /OCMD /VE [/Or 6 0 R [/And 5 0 R 7 0 R]]>>", true},
+		{"<</Type /OCMD /VE [/And 5 0 R [/Not [/Or 6 0 R 7 0 R]]]>>", false},
+	}
+	var ocs []string
+	for _, tt := range tests {
+		ocs = append(ocs, tt.oc)
+	}
+	r := openPDF(t, layersPDF(ocs))
+	c, err := r.Page(1).ContentWithLayers(map[ObjRef]bool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := contentText(c)
+	for i, tt := range tests {
+		if shown := strings.Contains(text, fmt.Sprintf("Q%c", 'a'+i)); shown != tt.visible {
+			t.Errorf("%s: visible %v, want %v", tt.oc, shown, tt.visible)
+		}
+	}
+
+	// Hiding A and showing B.
+	c, err = r.Page(1).ContentWithLayers(map[ObjRef]bool{{ID: 5}: false, {ID: 6}: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := contentText(c); strings.Contains(text, "Qa") || !strings.Contains(text, "Qb") {
+		t.Errorf("with A hidden and B shown, the text is %q", text)
+	}
+}
+
+// TestVisibilityExprCycle evaluates visibility expressions that contain
+// themselves or use other expressions many times, and a membership
+// dictionary that lists itself as a group.
+func TestVisibilityExprCycle(t *testing.T) {
+	extra := []string{
+		"<</Type /OCMD /VE [/And 5 0 R 8 0 R]>>",         // 8: contains itself
+		"<</Type /OCMD /VE 10 0 R>>",                     // 9
+		"[/And 5 0 R 10 0 R]",                            // 10: contains itself
+		"<</Type /OCMD /OCGs [11 0 R 6 0 R] /P /AnyOn>>", // 11: lists itself
+		"<</Type /OCMD /VE 13 0 R>>",                     // 12
+	}
+	// Expression 13 uses expression 14 fifty times, which uses 15
+	// fifty times, and so on, which would be 50^12 evaluations of
+	// the last, which is B, hidden.
+	const levels, uses = 12, 50
+	for i := 0; i < levels; i++ {
+		extra = append(extra, "[/Or"+strings.Repeat(fmt.Sprintf(" %d 0 R", 14+i), uses)+"]")
+	}
+	extra = append(extra, "[/And 5 0 R 6 0 R]")
+	r := openPDF(t, layersPDF(nil, extra...))
+	state := r.layerState()
+	for _, tt := range []struct {
+		id      uint32
+		visible bool
+	}{
+		{8, true},
+		{9, true},
+		{11, true},
+		{12, false},
+	} {
+		if got := ocVisible(object(r, tt.id), state); got != tt.visible {
+			t.Errorf("object %d: visible %v, want %v", tt.id, got, tt.visible)
+		}
+	}
+}
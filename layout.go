@@ -0,0 +1,509 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Reconstruction of readable text from positioned Text fragments.
+
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// A textLine is a run of Text fragments sharing (approximately) a baseline,
+// sorted left to right.
+type textLine struct {
+	y    float64 // baseline of the first fragment in the line
+	size float64 // largest font size in the line
+	text []Text
+}
+
+// textString returns the decoded text carried by t.
+func textString(t Text) string {
+	var b strings.Builder
+	for _, ch := range t.S {
+		for _, r := range ch.Text {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// charAdvance returns the horizontal advance of ch at the given font size, in points.
+// When the font supplies no width, it assumes half an em.
+func charAdvance(ch PositionedChar, size float64) float64 {
+	if ch.Width > 0 {
+		return ch.Width / 1000 * size
+	}
+	return size / 2
+}
+
+// textWidth returns the horizontal advance of t, in points.
+func textWidth(t Text) float64 {
+	w := 0.0
+	for _, ch := range t.S {
+		w += charAdvance(ch, t.FontSize)
+	}
+	return w
+}
+
+// glyphBoxes returns the approximate box occupied by each character of t:
+// the character's advance horizontally, and from the descender (0.2 em
+// below the baseline) to the ascender (0.8 em above) vertically.
+// Rotated text is treated as if it were horizontal.
+func glyphBoxes(t Text) []Rectangle {
+	boxes := make([]Rectangle, len(t.S))
+	x := t.X
+	for i, ch := range t.S {
+		w := charAdvance(ch, t.FontSize)
+		boxes[i] = Rectangle{Point{x, t.Y - 0.2*t.FontSize}, Point{x + w, t.Y + 0.8*t.FontSize}}
+		x += w
+	}
+	return boxes
+}
+
+// visible reports whether any part of t lies inside its clipping region.
+// Text drawn entirely outside the clip is invisible on the rendered page.
+func visible(t Text) bool {
+	for _, b := range glyphBoxes(t) {
+		if b.Intersects(t.Clip) {
+			return true
+		}
+	}
+	return false
+}
+
+// TextInRect returns the text on the page whose glyph boxes intersect r.
+// Fragments that straddle the edge of r are trimmed to the characters
+// inside it, with X and W adjusted to the characters kept.
+func (p Page) TextInRect(r Rectangle) ([]Text, error) {
+	c, err := p.Content()
+	if err != nil {
+		return nil, err
+	}
+	return textInRect(c.Text, r), nil
+}
+
+func textInRect(text []Text, r Rectangle) []Text {
+	var out []Text
+	for _, t := range text {
+		boxes := glyphBoxes(t)
+		i := 0
+		for i < len(boxes) {
+			if !boxes[i].Intersects(r) {
+				i++
+				continue
+			}
+			j := i
+			for j < len(boxes) && boxes[j].Intersects(r) {
+				j++
+			}
+			sub := t
+			if i > 0 || j < len(boxes) {
+				sub.X = boxes[i].Min.X
+				sub.W = boxes[j-1].Max.X - boxes[i].Min.X
+				sub.S = t.S[i:j]
+			}
+			out = append(out, sub)
+			i = j
+		}
+	}
+	return out
+}
+
+// buildLines groups the fragments in text into lines, top to bottom.
+// Fragments whose baselines differ by less than half the font size
+// are considered to be on the same line.
+func buildLines(text []Text) []textLine {
+	var sorted []Text
+	for _, t := range text {
+		if len(t.S) > 0 {
+			sorted = append(sorted, t)
+		}
+	}
+	return clusterLines(sorted, 0)
+}
+
+// clusterLines sorts text top to bottom and groups it into lines,
+// each sorted left to right. A fragment joins the current line if its
+// baseline is within tolerance of the line's first baseline; a tolerance
+// of zero or less means half the larger of the two font sizes.
+// clusterLines reorders text.
+func clusterLines(text []Text, tolerance float64) []textLine {
+	sort.Stable(TextVertical(text))
+
+	var lines []textLine
+	for _, t := range text {
+		if n := len(lines); n > 0 {
+			l := &lines[n-1]
+			tol := tolerance
+			if tol <= 0 {
+				tol = 0.5 * max(l.size, t.FontSize)
+			}
+			if l.y-t.Y <= tol {
+				l.text = append(l.text, t)
+				l.size = max(l.size, t.FontSize)
+				continue
+			}
+		}
+		lines = append(lines, textLine{y: t.Y, size: t.FontSize, text: []Text{t}})
+	}
+	for i := range lines {
+		sort.Stable(TextHorizontal(lines[i].text))
+	}
+	return lines
+}
+
+// SortByLine sorts text into reading order line by line: lines top to
+// bottom, and fragments left to right within a line. Unlike TextVertical,
+// which compares baselines exactly, SortByLine keeps fragments whose
+// baselines differ slightly (superscripts, mixed fonts) on the same line:
+// a fragment belongs to a line if its baseline is within tolerance of the
+// line's first baseline. A tolerance of zero or less means half the font size.
+func SortByLine(text []Text, tolerance float64) {
+	lines := clusterLines(append([]Text(nil), text...), tolerance)
+	i := 0
+	for _, l := range lines {
+		i += copy(text[i:], l.text)
+	}
+}
+
+// defaultWordGap is the gap between glyphs, as a fraction of the font size,
+// above which a word break is assumed.
+const defaultWordGap = 0.15
+
+// glyphs returns the characters of the line, with spaces inserted
+// wherever the gap between fragments is wider than wordGap times
+// the font size, together with the box of each character.
+// An inserted space gets a zero-height box spanning the gap.
+// A negative wordGap inserts no spaces.
+func (l textLine) glyphs(wordGap float64) ([]rune, []Rectangle) {
+	var rs []rune
+	var boxes []Rectangle
+	end := 0.0
+	for i, t := range l.text {
+		gb := glyphBoxes(t)
+		if i > 0 && wordGap >= 0 && t.X-end > wordGap*max(t.FontSize, 1) {
+			if len(rs) > 0 && !unicode.IsSpace(rs[len(rs)-1]) && !startsWithSpace(textString(t)) {
+				rs = append(rs, ' ')
+				boxes = append(boxes, Rectangle{Point{end, t.Y}, Point{t.X, t.Y}})
+			}
+		}
+		for k, ch := range t.S {
+			for _, r := range ch.Text {
+				rs = append(rs, r)
+				boxes = append(boxes, gb[k])
+			}
+		}
+		end = max(end, t.X+textWidth(t))
+	}
+	return rs, boxes
+}
+
+// String returns the text of the line, as computed by glyphs
+// with the default word gap.
+func (l textLine) String() string {
+	rs, _ := l.glyphs(defaultWordGap)
+	return string(rs)
+}
+
+// layoutText returns the readable text for the fragments in text:
+// lines are separated by newlines, and a blank line is inserted
+// where the vertical gap between lines suggests a paragraph break.
+// Options that work line by line, such as opt.Bidi, are applied here.
+func layoutText(text []Text, opt TextOptions) string {
+	var b strings.Builder
+	lines := buildLines(text)
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+			prev := lines[i-1]
+			if prev.y-l.y > 2*max(prev.size, l.size) {
+				b.WriteByte('\n')
+			}
+		}
+		rs, _ := l.glyphs(opt.wordGap())
+		s := strings.TrimRightFunc(string(rs), unicode.IsSpace)
+		if opt.Bidi {
+			s = visualToLogical(s)
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// TextOptions controls how PlainText reconstructs text from a page.
+// The zero TextOptions gives strict top-to-bottom geometric order.
+type TextOptions struct {
+	// Columns enables detection of multi-column layouts.
+	// When set, the page is split into blocks along the white space
+	// between columns and between full-width sections, and the text
+	// is emitted block by block in reading order: sections top to bottom,
+	// and columns left to right within a section.
+	Columns bool
+
+	// ExpandLigatures replaces ligature presentation forms
+	// such as U+FB01 (ﬁ) with their component letters.
+	ExpandLigatures bool
+
+	// Normalize composes base characters and combining marks
+	// into precomposed characters (Unicode normalization form C),
+	// so that the text compares equal to text typed on a keyboard.
+	Normalize bool
+
+	// Bidi reorders right-to-left text (Hebrew, Arabic) from the visual
+	// order in which it is drawn into logical reading order, line by line.
+	Bidi bool
+
+	// UnshapeArabic replaces Arabic presentation forms
+	// with the logical characters they were shaped from.
+	UnshapeArabic bool
+
+	// IncludeClipped includes text that lies entirely outside
+	// the clipping region in effect when it was drawn.
+	// Such text is invisible on the rendered page and is omitted by default.
+	IncludeClipped bool
+
+	// WordGap is the horizontal gap between consecutive pieces of text,
+	// as a fraction of the font size, above which a word break is inserted
+	// if the text has no space there. Many PDFs omit space characters and
+	// position words apart instead. Zero means 0.15; a negative value
+	// inserts no word breaks.
+	WordGap float64
+
+	// KeepDuplicates disables the merging of text drawn several times
+	// at almost the same position. Some generators simulate bold type
+	// this way, which would otherwise repeat every word; by default
+	// the copies are merged and the remaining Text is marked Bold.
+	KeepDuplicates bool
+
+	// Invisible selects whether text drawn in an invisible rendering mode,
+	// such as the OCR layer over a scanned page, is included.
+	Invisible InvisibleText
+
+	// IgnoreActualText disables the substitution of replacement text.
+	// By default, text drawn within a marked-content sequence or
+	// structure element with an ActualText entry is replaced by that
+	// text, as tagged documents use for drop caps, hyphenated words
+	// and decorative glyphs.
+	IgnoreActualText bool
+}
+
+// An InvisibleText selects how text that is not painted is extracted.
+type InvisibleText int
+
+const (
+	IncludeInvisible InvisibleText = iota // extract both painted and invisible text
+	ExcludeInvisible                      // extract only painted text
+	OnlyInvisible                         // extract only invisible text
+)
+
+func (opt TextOptions) wordGap() float64 {
+	if opt.WordGap == 0 {
+		return defaultWordGap
+	}
+	return opt.WordGap
+}
+
+// keep reports whether t is extracted with the options opt.
+func (opt TextOptions) keep(t Text) bool {
+	switch opt.Invisible {
+	case ExcludeInvisible:
+		if t.Invisible {
+			return false
+		}
+	case OnlyInvisible:
+		if !t.Invisible {
+			return false
+		}
+	}
+	return opt.IncludeClipped || visible(t)
+}
+
+// apply applies the character-level transformations selected in opt to s.
+func (opt TextOptions) apply(s string) string {
+	if opt.ExpandLigatures {
+		s = expandLigatures(s)
+	}
+	if opt.UnshapeArabic {
+		s = unshapeArabic(s)
+	}
+	if opt.Normalize {
+		s = composeNFC(s)
+	}
+	return s
+}
+
+// GetPlainText returns the page's text as a readable string,
+// with fragments ordered top to bottom and left to right and
+// spaces and newlines inserted according to the page geometry.
+// It is equivalent to p.PlainText(TextOptions{}).
+func (p Page) GetPlainText() (string, error) {
+	return p.PlainText(TextOptions{})
+}
+
+// PlainText is like GetPlainText but reconstructs the text according to opt.
+func (p Page) PlainText(opt TextOptions) (string, error) {
+	if p.V.Kind() == Null {
+		return "", fmt.Errorf("page not found")
+	}
+	c, err := p.Content()
+	if err != nil {
+		return "", err
+	}
+	var text []Text
+	for _, t := range c.Text {
+		if opt.keep(t) {
+			text = append(text, t)
+		}
+	}
+	if !opt.IgnoreActualText {
+		text = p.substituteActualText(text)
+	}
+	if !opt.KeepDuplicates {
+		text = mergeFakeBold(text)
+	}
+	if !opt.Columns {
+		return opt.apply(layoutText(text, opt)), nil
+	}
+	var blocks []string
+	for _, blk := range xyCut(text) {
+		if s := layoutText(blk, opt); s != "" {
+			blocks = append(blocks, s)
+		}
+	}
+	return opt.apply(strings.Join(blocks, "\n\n")), nil
+}
+
+// GetPlainText returns the text of every page in the document,
+// as returned by Page.GetPlainText, with pages separated by a blank line.
+func (r *Reader) GetPlainText() (string, error) {
+	return r.PlainText(TextOptions{})
+}
+
+// PlainText is like GetPlainText but reconstructs the text according to opt.
+func (r *Reader) PlainText(opt TextOptions) (string, error) {
+	var b strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		s, err := r.Page(i).PlainText(opt)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %v", i, err)
+		}
+		if i > 1 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(s)
+	}
+	return b.String(), nil
+}
+
+// mergeFakeBold removes Text fragments that repeat an earlier fragment
+// with the same characters and font size at an offset of less than
+// a tenth of the font size, marking the fragment that remains as Bold.
+// Such overprinting is how some generators simulate bold type.
+func mergeFakeBold(text []Text) []Text {
+	seen := make(map[string][]int) // string -> indexes in out
+	var out []Text
+Text:
+	for _, t := range text {
+		s := textString(t)
+		if strings.TrimSpace(s) == "" {
+			out = append(out, t)
+			continue
+		}
+		for _, i := range seen[s] {
+			o := &out[i]
+			tol := 0.1 * max(o.FontSize, 1)
+			if math.Abs(o.FontSize-t.FontSize) <= 0.01*o.FontSize &&
+				math.Abs(o.X-t.X) < tol && math.Abs(o.Y-t.Y) < tol {
+				o.Bold = true
+				continue Text
+			}
+		}
+		seen[s] = append(seen[s], len(out))
+		out = append(out, t)
+	}
+	return out
+}
+
+// xyCut splits text into blocks in reading order using recursive XY-cut:
+// a set of fragments is first split at horizontal white space bands that
+// no fragment crosses, and then at vertical bands (column gutters),
+// recursing into each piece until no further cut is possible.
+func xyCut(text []Text) [][]Text {
+	var size float64
+	var frags []Text
+	for _, t := range text {
+		if len(t.S) > 0 {
+			frags = append(frags, t)
+			size += t.FontSize
+		}
+	}
+	if len(frags) <= 1 {
+		return [][]Text{frags}
+	}
+	size /= float64(len(frags))
+
+	// Horizontal cut: sections separated by more than a line of white space.
+	// Bands are ordered top to bottom, so project -Y.
+	hbands := cutBands(frags, 1.0*size, func(t Text) (float64, float64) {
+		return -(t.Y + 0.8*t.FontSize), -(t.Y - 0.2*t.FontSize)
+	})
+	if len(hbands) > 1 {
+		var out [][]Text
+		for _, b := range hbands {
+			out = append(out, xyCut(b)...)
+		}
+		return out
+	}
+
+	// Vertical cut: column gutters, well wider than a word space.
+	vbands := cutBands(frags, 1.5*size, func(t Text) (float64, float64) {
+		return t.X, t.X + textWidth(t)
+	})
+	if len(vbands) > 1 {
+		var out [][]Text
+		for _, b := range vbands {
+			out = append(out, xyCut(b)...)
+		}
+		return out
+	}
+	return [][]Text{frags}
+}
+
+// cutBands projects each fragment onto an axis using span and splits
+// the fragments wherever the projection has a gap wider than gap.
+// The bands are returned in increasing order along the axis.
+func cutBands(text []Text, gap float64, span func(Text) (lo, hi float64)) [][]Text {
+	type item struct {
+		lo, hi float64
+		t      Text
+	}
+	items := make([]item, len(text))
+	for i, t := range text {
+		lo, hi := span(t)
+		items[i] = item{lo, hi, t}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].lo < items[j].lo })
+
+	var bands [][]Text
+	var cur []Text
+	end := items[0].hi
+	for _, it := range items {
+		if len(cur) > 0 && it.lo-end > gap {
+			bands = append(bands, cur)
+			cur = nil
+		}
+		cur = append(cur, it.t)
+		end = max(end, it.hi)
+	}
+	return append(bands, cur)
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && unicode.IsSpace(rune(s[0]))
+}
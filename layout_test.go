@@ -0,0 +1,258 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// textOf returns a fragment showing s at (x, y) in a 10-point font
+// whose characters are all half an em wide.
+func textOf(s string, x, y float64) Text {
+	t := Text{X: x, Y: y, FontSize: 10}
+	for _, r := range s {
+		t.S = append(t.S, PositionedChar{Text: []rune{r}, Width: 500})
+	}
+	t.W = textWidth(t)
+	return t
+}
+
+func TestTextInRect(t *testing.T) {
+	text := []Text{
+		textOf("hello", 100, 700), // characters from x = 100, 105, ..., 120 to 125
+		textOf("world", 200, 700),
+		textOf("below", 100, 600),
+	}
+	tests := []struct {
+		r    Rectangle
+		want []Text
+	}{
+		{Rectangle{Point{111, 695}, Point{118, 705}}, []Text{textOf("ll", 110, 700)}},
+		{Rectangle{Point{90, 695}, Point{102, 705}}, []Text{textOf("h", 100, 700)}},
+		{Rectangle{Point{118, 695}, Point{202, 705}}, []Text{textOf("lo", 115, 700), textOf("w", 200, 700)}},
+		{Rectangle{Point{0, 650}, Point{612, 792}}, text[:2]},
+		{Rectangle{Point{0, 0}, Point{50, 50}}, nil},
+	}
+	for _, tt := range tests {
+		got := textInRect(text, tt.r)
+		if len(got) != len(tt.want) {
+			t.Errorf("textInRect(%v) = %d fragments, want %d", tt.r, len(got), len(tt.want))
+			continue
+		}
+		for i, g := range got {
+			w := tt.want[i]
+			if s, ws := contentText(Content{Text: []Text{g}}), contentText(Content{Text: []Text{w}}); s != ws || g.X != w.X || g.W != w.W {
+				t.Errorf("textInRect(%v)[%d] = %q at %v, width %v; want %q at %v, width %v", tt.r, i, s, g.X, g.W, ws, w.X, w.W)
+			}
+		}
+	}
+
+	// A fragment kept whole keeps its width, which may include
+	// spacing between its characters.
+	spaced := textOf("ab", 100, 700)
+	spaced.W = 20
+	if got := textInRect([]Text{spaced}, Rectangle{Point{0, 0}, Point{612, 792}}); len(got) != 1 || got[0].W != 20 {
+		t.Errorf("textInRect changed the width of a whole fragment: %+v", got)
+	}
+}
+
+// show returns content showing s at (x, y) in the 10-point font F1.
+func show(x, y float64, s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+	return fmt.Sprintf("BT /F1 10 Tf %g %g Td (%s) Tj ET\n", x, y, s)
+}
+
+// textPage returns a document of one page with the content c,
+// in which F1 is Helvetica.
+func textPage(c ...string) []byte {
+	return pagePDF("<</Font <</F1 5 0 R>>>>", strings.Join(c, ""),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>")
+}
+
+func TestPlainText(t *testing.T) {
+	r := openPDF(t, textPage(
+		show(300, 700, "line,"), // drawn out of order
+		show(72, 700, "The first"),
+		show(72, 688, "The second line"),
+		show(72, 640, "After a gap"),
+	))
+	s, err := r.Page(1).GetPlainText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "The first line,\nThe second line\n\nAfter a gap"; s != want {
+		t.Errorf("GetPlainText() = %q, want %q", s, want)
+	}
+
+	data := buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 792] /Resources <</Font <</F1 7 0 R>>>>>>",
+		"<</Type /Page /Parent 2 0 R /Contents 5 0 R>>",
+		"<</Type /Page /Parent 2 0 R /Contents 6 0 R>>",
+		stream("", show(72, 700, "one")),
+		stream("", show(72, 700, "two")),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>",
+	)
+	s, err = openPDF(t, data).GetPlainText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "one\n\ntwo"; s != want {
+		t.Errorf("Reader.GetPlainText() = %q, want %q", s, want)
+	}
+}
+
+func TestPlainTextColumns(t *testing.T) {
+	r := openPDF(t, textPage(
+		show(72, 750, "A title across the page"),
+		show(72, 700, "Left one"),
+		show(320, 700, "Right one"),
+		show(72, 688, "Left two"),
+		show(320, 688, "Right two"),
+		show(72, 640, "A footer across the page"),
+	))
+	p := r.Page(1)
+	s, err := p.PlainText(TextOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A title across the page\n\nLeft one Right one\nLeft two Right two\n\nA footer across the page"; s != want {
+		t.Errorf("PlainText without Columns = %q, want %q", s, want)
+	}
+	s, err = p.PlainText(TextOptions{Columns: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A title across the page\n\nLeft one\nLeft two\n\nRight one\nRight two\n\nA footer across the page"; s != want {
+		t.Errorf("PlainText with Columns = %q, want %q", s, want)
+	}
+}
+
+func TestInvisibleText(t *testing.T) {
+	r := openPDF(t, textPage(
+		show(72, 700, "painted"),
+		"3 Tr\n", show(72, 680, "ocr"),
+		"7 Tr\n", show(72, 670, "clip"),
+		"1 Tr\n", show(72, 660, "outline"),
+	))
+	p := r.Page(1)
+	c, err := p.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []bool
+	for _, tx := range c.Text {
+		got = append(got, tx.Invisible)
+	}
+	if want := []bool{false, true, true, false}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Invisible %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		mode InvisibleText
+		want string
+	}{
+		{IncludeInvisible, "painted\nocr\nclip\noutline"},
+		{ExcludeInvisible, "painted\n\noutline"}, // a gap where the invisible text was
+		{OnlyInvisible, "ocr\nclip"},
+	}
+	for _, tt := range tests {
+		s, err := p.PlainText(TextOptions{Invisible: tt.mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != tt.want {
+			t.Errorf("PlainText with Invisible %d = %q, want %q", tt.mode, s, tt.want)
+		}
+	}
+}
+
+func TestWordGap(t *testing.T) {
+	// "ab" ends at 110, 0.2 em before "cd" and 0.1 em before "ef".
+	text := []Text{textOf("ab", 100, 700), textOf("cd", 112, 700), textOf("ef", 100, 680), textOf("gh", 111, 680)}
+	tests := []struct {
+		gap  float64
+		want string
+	}{
+		{0, "ab cd\nefgh"},
+		{0.05, "ab cd\nef gh"},
+		{0.3, "abcd\nefgh"},
+		{-1, "abcd\nefgh"},
+	}
+	for _, tt := range tests {
+		if got := layoutText(text, TextOptions{WordGap: tt.gap}); got != tt.want {
+			t.Errorf("WordGap %v: text %q, want %q", tt.gap, got, tt.want)
+		}
+	}
+}
+
+func TestFakeBold(t *testing.T) {
+	text := []Text{
+		textOf("Bold", 100, 700),
+		textOf("Bold", 100.5, 700),   // overprinted
+		textOf("Bold", 100, 700.25),  // and again
+		textOf("Bold", 102, 700),     // too far off
+		textOf("Bolder", 100.5, 700), // different text
+		textOf("Bold", 100, 680),
+	}
+	got := mergeFakeBold(text)
+	var s []string
+	for _, tx := range got {
+		s = append(s, fmt.Sprintf("%s %v,%v %v", textString(tx), tx.X, tx.Y, tx.Bold))
+	}
+	want := []string{"Bold 100,700 true", "Bold 102,700 false", "Bolder 100.5,700 false", "Bold 100,680 false"}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("merged:\n%q\nwant\n%q", s, want)
+	}
+
+	r := openPDF(t, textPage(show(72, 700, "Bold"), show(72.3, 700, "Bold"), show(72, 700.3, "Bold")))
+	for _, tt := range []struct {
+		opt  TextOptions
+		want string
+	}{
+		{TextOptions{}, "Bold"},
+		{TextOptions{KeepDuplicates: true}, "BoldBoldBold"},
+	} {
+		if s, err := r.Page(1).PlainText(tt.opt); err != nil || s != tt.want {
+			t.Errorf("PlainText(%+v) = %q, %v, want %q", tt.opt, s, err, tt.want)
+		}
+	}
+	if m, err := r.Search("Bold", SearchOptions{}); err != nil || len(m) != 1 {
+		t.Errorf("Search found %d matches, %v, want 1", len(m), err)
+	}
+}
+
+func TestSortByLine(t *testing.T) {
+	text := []Text{
+		textOf("2", 150, 703), // superscript
+		textOf("b", 100, 680),
+		textOf("E=mc", 100, 700),
+		textOf("a", 50, 681),
+		textOf("c", 40, 678),
+	}
+	tests := []struct {
+		tolerance float64
+		want      string
+	}{
+		{0, "E=mc 2 c a b"},
+		{1, "2 E=mc a b c"},
+		{2, "2 E=mc a b c"},
+		{3, "E=mc 2 c a b"},
+	}
+	for _, tt := range tests {
+		s := append([]Text(nil), text...)
+		SortByLine(s, tt.tolerance)
+		var got []string
+		for _, tx := range s {
+			got = append(got, textString(tx))
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("tolerance %v: order %q, want %q", tt.tolerance, strings.Join(got, " "), tt.want)
+		}
+	}
+}
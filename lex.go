@@ -46,8 +46,13 @@ type pdfbuffer struct {
 	key         []byte
 	useAES      bool
 	objptr      pdfobjptr
+	nesting     int // arrays and dictionaries being read
 }
 
+// maxNesting limits the nesting of arrays and dictionaries,
+// so that malformed input cannot exhaust the stack.
+const maxNesting = 256
+
 // newPdfBuffer returns a new buffer reading from r at the given offset.
 func newPdfBuffer(r io.Reader, offset int64) *pdfbuffer {
 	return &pdfbuffer{
@@ -191,6 +196,9 @@ func (b *pdfbuffer) readHexString() token {
 	for {
 	Loop:
 		c := b.readByte()
+		if b.eof {
+			b.errorf("unexpected EOF in hex string")
+		}
 		if c == '>' {
 			break
 		}
@@ -199,6 +207,9 @@ func (b *pdfbuffer) readHexString() token {
 		}
 	Loop2:
 		c2 := b.readByte()
+		if b.eof {
+			b.errorf("unexpected EOF in hex string")
+		}
 		if isSpace(c2) {
 			goto Loop2
 		}
@@ -231,6 +242,9 @@ func (b *pdfbuffer) readLiteralString() token {
 Loop:
 	for {
 		c := b.readByte()
+		if b.eof {
+			b.errorf("unexpected EOF in string")
+		}
 		switch c {
 		default:
 			tmp = append(tmp, c)
@@ -245,8 +259,9 @@ Loop:
 		case '\\':
 			switch c = b.readByte(); c {
 			default:
-				b.errorf("invalid escape sequence \\%c", c)
-				tmp = append(tmp, '\\', c)
+				// A backslash before any other character
+				// is ignored (PDF 32000-1:2008, §7.3.4.2).
+				tmp = append(tmp, c)
 			case 'n':
 				tmp = append(tmp, '\n')
 			case 'r':
@@ -276,9 +291,7 @@ Loop:
 					}
 					x = x*8 + int(c-'0')
 				}
-				if x > 255 {
-					b.errorf("invalid octal escape \\%03o", x)
-				}
+				// High-order overflow is ignored.
 				tmp = append(tmp, byte(x))
 			}
 		}
@@ -401,6 +414,8 @@ type pdfstream struct {
 	hdr    pdfdict
 	ptr    pdfobjptr
 	offset int64
+	mem    bool   // the stream was created in memory, with contents data
+	data   []byte // encoded contents of a stream created in memory
 }
 
 type pdfobjptr struct {
@@ -465,12 +480,17 @@ func (b *pdfbuffer) readObject() pdfobject {
 }
 
 func (b *pdfbuffer) readArray() pdfobject {
+	b.nest()
+	defer b.unnest()
 	var x pdfarray
 	for {
 		tok := b.readToken()
 		if tok == nil || tok == pdfkeyword("]") {
 			break
 		}
+		if tok == io.EOF {
+			b.errorf("unexpected EOF in array")
+		}
 		b.unreadToken(tok)
 		x = append(x, b.readObject())
 	}
@@ -478,19 +498,44 @@ func (b *pdfbuffer) readArray() pdfobject {
 }
 
 func (b *pdfbuffer) readDict() pdfobject {
+	b.nest()
+	defer b.unnest()
 	x := make(pdfdict)
+	var (
+		contents string // the Contents string, not yet decrypted
+		raw      bool
+	)
 	for {
 		tok := b.readToken()
 		if tok == nil || tok == pdfkeyword(">>") {
 			break
 		}
+		if tok == io.EOF {
+			b.errorf("unexpected EOF in dictionary")
+		}
 		n, ok := tok.(pdfname)
 		if !ok {
 			b.errorf("unexpected non-name key %T(%v) parsing dictionary", tok, tok)
 			continue
 		}
+		if n == "Contents" && b.key != nil {
+			// The Contents string of a signature dictionary is not
+			// encrypted, which is known only once the dictionary is read.
+			tok := b.readToken()
+			if s, ok := tok.(string); ok {
+				contents, raw = s, true
+				continue
+			}
+			b.unreadToken(tok)
+		}
 		x[n] = b.readObject()
 	}
+	if raw {
+		if !isSignatureDict(x) && b.objptr.id != 0 {
+			contents = decryptString(b.key, b.useAES, b.objptr, contents)
+		}
+		x["Contents"] = contents
+	}
 
 	if !b.allowStream {
 		return x
@@ -513,7 +558,20 @@ func (b *pdfbuffer) readDict() pdfobject {
 		b.errorf("stream keyword not followed by newline")
 	}
 
-	return pdfstream{x, b.objptr, b.readOffset()}
+	return pdfstream{hdr: x, ptr: b.objptr, offset: b.readOffset()}
+}
+
+// nest records the start of an array or dictionary,
+// failing if they are nested too deeply.
+func (b *pdfbuffer) nest() {
+	if b.nesting++; b.nesting > maxNesting {
+		b.errorf("malformed PDF: objects nested more than %d deep", maxNesting)
+	}
+}
+
+// unnest records the end of an array or dictionary.
+func (b *pdfbuffer) unnest() {
+	b.nesting--
 }
 
 func isSpace(b byte) bool {
@@ -0,0 +1,534 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Writing linearized files (PDF 32000-1:2008, Annex F).
+
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io"
+)
+
+// SaveLinearized writes the document, with any changes made to it, to w
+// as Save does, but as a linearized file, which a viewer can begin to
+// display before it has all arrived. The file begins with the document
+// catalog and the objects of the first page, and with hint tables
+// locating the objects of every other page, so that a viewer reading it
+// over HTTP with range requests can fetch each page on its own. A file
+// made by a Writer can be linearized by reading it with NewReader and
+// writing it again with SaveLinearized.
+func (r *Reader) SaveLinearized(w io.Writer) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("pdf: linearizing: %v", e)
+		}
+	}()
+	l := &linearizer{r: r, data: make(map[pdfobjptr][]byte)}
+	if err := l.plan(); err != nil {
+		return err
+	}
+	l.serialize()
+	return l.write(w)
+}
+
+// A linearizer lays out a document as a linearized file.
+//
+// The file holds, in order, the header, the linearization dictionary,
+// the cross-reference table and trailer for the first page, the
+// catalog, the hint stream, the objects of the first page, those of
+// each later page, the objects shared by later pages, all other objects,
+// and finally the main cross-reference table and trailer. Objects are
+// numbered in that order, those of the first-page table after the others.
+type linearizer struct {
+	r *Reader
+	c *objCopier
+
+	catalog pdfobjptr     // the document catalog
+	first   []pdfobjptr   // objects of the first page, its page object first
+	pages   [][]pdfobjptr // objects of each later page used by no other, its page object first
+	shared  []pdfobjptr   // objects shared by later pages and not used by the first
+	other   []pdfobjptr   // objects used by no page
+
+	// sharedRefs lists, for each page, the indexes of the shared
+	// objects it uses in the shared object hint table, which lists
+	// the objects of the first page and then those of l.shared.
+	sharedRefs  [][]int
+	sharedIndex map[pdfobjptr]int
+
+	contents [][]pdfobjptr // content streams of each page
+
+	nmain   uint32 // number of objects in the main table, including object 0
+	header  []byte
+	data    map[pdfobjptr][]byte // each object's bytes, by old number
+	trailer pdfdict
+}
+
+// plan divides the objects among the parts of the file and numbers them.
+func (l *linearizer) plan() error {
+	r := l.r
+	n := r.NumPage()
+	if n < 1 {
+		return fmt.Errorf("pdf: cannot linearize a document with no pages")
+	}
+	root, ok := r.trailerEntry("Root").(pdfobjptr)
+	if !ok {
+		return fmt.Errorf("malformed PDF: document catalog is not an indirect object")
+	}
+	l.catalog = root
+
+	pages := make([]pdfobjptr, n)
+	isPage := make(map[pdfobjptr]bool)
+	for i := range pages {
+		p := r.Page(i + 1).V
+		if p.Kind() != Dict {
+			return fmt.Errorf("malformed PDF: page %d not found", i+1)
+		}
+		pages[i] = p.ptr
+		isPage[p.ptr] = true
+	}
+
+	objs := make([][]pdfobjptr, n)
+	uses := make(map[pdfobjptr]int)
+	for i, p := range pages {
+		objs[i] = l.pageObjects(p, isPage)
+		for _, ptr := range objs[i] {
+			uses[ptr]++
+		}
+		var contents []pdfobjptr
+		switch x := r.resolve(pdfobjptr{}, p).Key("Contents"); x.Kind() {
+		case Stream:
+			contents = append(contents, x.ptr)
+		case Array:
+			for j := 0; j < x.Len(); j++ {
+				if ref, ok := x.entryRef(j); ok {
+					contents = append(contents, ref)
+				}
+			}
+		}
+		l.contents = append(l.contents, contents)
+	}
+
+	placed := map[pdfobjptr]bool{root: true}
+	l.sharedIndex = make(map[pdfobjptr]int)
+	for _, ptr := range objs[0] {
+		if !placed[ptr] {
+			placed[ptr] = true
+			l.sharedIndex[ptr] = len(l.first)
+			l.first = append(l.first, ptr)
+		}
+	}
+	l.pages = make([][]pdfobjptr, n)
+	for i := 1; i < n; i++ {
+		for _, ptr := range objs[i] {
+			if uses[ptr] == 1 && !placed[ptr] {
+				placed[ptr] = true
+				l.pages[i] = append(l.pages[i], ptr)
+			}
+		}
+	}
+	for i := 1; i < n; i++ {
+		for _, ptr := range objs[i] {
+			if uses[ptr] > 1 && !placed[ptr] {
+				placed[ptr] = true
+				l.sharedIndex[ptr] = len(l.first) + len(l.shared)
+				l.shared = append(l.shared, ptr)
+			}
+		}
+	}
+	l.sharedRefs = make([][]int, n)
+	for i := range objs {
+		for _, ptr := range objs[i] {
+			if idx, ok := l.sharedIndex[ptr]; ok && uses[ptr] > 1 {
+				l.sharedRefs[i] = append(l.sharedRefs[i], idx)
+			}
+		}
+	}
+	c := newObjCopier(r, nil)
+	l.c = c
+	var roots []pdfobject
+	for _, key := range savedTrailerKeys {
+		roots = append(roots, r.trailerEntry(key))
+	}
+	all, _ := c.reachable(roots)
+	for _, ptr := range all {
+		if !placed[ptr] {
+			placed[ptr] = true
+			l.other = append(l.other, ptr)
+		}
+	}
+
+	// Number the objects of the main table, then those of the first-page
+	// table: the linearization dictionary, catalog, hint stream and the
+	// first page's objects.
+	id := uint32(1)
+	number := func(ptr pdfobjptr) {
+		c.refs[ptr] = pdfobjptr{id, 0}
+		id++
+	}
+	for _, objs := range l.pages {
+		for _, ptr := range objs {
+			number(ptr)
+		}
+	}
+	for _, ptr := range l.shared {
+		number(ptr)
+	}
+	for _, ptr := range l.other {
+		number(ptr)
+	}
+	l.nmain = id
+	id += 3 // linearization dictionary, catalog, hint stream
+	c.refs[root] = pdfobjptr{l.nmain + 1, 0}
+	for _, ptr := range l.first {
+		number(ptr)
+	}
+	return nil
+}
+
+// pageObjects returns the objects the page uses, in the order they are
+// reached from the page object, which comes first. It does not follow
+// references to other pages and to the parents of pages, annotations,
+// form fields and structure elements, which belong to the document
+// rather than to the page.
+func (l *linearizer) pageObjects(page pdfobjptr, isPage map[pdfobjptr]bool) []pdfobjptr {
+	objs := []pdfobjptr{page}
+	seen := map[pdfobjptr]bool{page: true}
+	var visit func(x pdfobject)
+	visit = func(x pdfobject) {
+		switch x := x.(type) {
+		case pdfobjptr:
+			if seen[x] || isPage[x] {
+				return
+			}
+			seen[x] = true
+			if l.r.resolve(pdfobjptr{}, x).Key("Type").CoerceName("") == "Pages" {
+				return
+			}
+			objs = append(objs, x)
+		case pdfstream:
+			visit(x.ptr)
+		case pdfarray:
+			for _, y := range x {
+				visit(y)
+			}
+		case pdfdict:
+			for k, y := range x {
+				if k != "Parent" && k != "P" {
+					visit(y)
+				}
+			}
+		}
+	}
+	for i := 0; i < len(objs); i++ {
+		switch x := l.r.resolve(pdfobjptr{}, objs[i]).data.(type) {
+		case pdfstream:
+			visit(x.hdr)
+		default:
+			visit(x)
+		}
+	}
+	return objs
+}
+
+// objects returns the objects in the order they are written.
+func (l *linearizer) objects() []pdfobjptr {
+	objs := []pdfobjptr{l.catalog}
+	objs = append(objs, l.first...)
+	for _, page := range l.pages {
+		objs = append(objs, page...)
+	}
+	objs = append(objs, l.shared...)
+	return append(objs, l.other...)
+}
+
+// serialize writes each object to l.data and builds the trailer.
+func (l *linearizer) serialize() {
+	var buf bytes.Buffer
+	wr := NewWriter(&buf)
+	wr.w.Flush()
+	l.header = bytes.Clone(buf.Bytes())
+	buf.Reset()
+
+	c := l.c
+	c.w = wr
+	wr.xref = make([]objLocation, int(l.nmain)+3+len(l.first))
+	sum := md5.New()
+	for _, old := range l.objects() {
+		c.write(old, c.refs[old])
+		wr.w.Flush()
+		l.data[old] = bytes.Clone(buf.Bytes())
+		sum.Write(buf.Bytes())
+		buf.Reset()
+	}
+	if len(c.queue) > 0 {
+		panic("object not found when numbering")
+	}
+	if wr.err != nil {
+		panic(wr.err)
+	}
+
+	l.trailer = pdfdict{"Size": int64(len(wr.xref))}
+	for _, key := range savedTrailerKeys {
+		if x := l.r.trailerEntry(key); x != nil {
+			l.trailer[key] = c.copy(x)
+		}
+	}
+	if l.trailer["ID"] == nil {
+		id := string(sum.Sum(nil))
+		l.trailer["ID"] = pdfarray{id, id}
+	}
+}
+
+// An offsetWidth formats offsets in the linearization dictionary and
+// first-page trailer, which are written before the offsets are known,
+// with a fixed width.
+const offsetWidth = "%010d"
+
+// linDict returns the linearization dictionary object.
+func (l *linearizer) linDict(length, hintOff, hintLen, end, mainXref int64) []byte {
+	return fmt.Appendf(nil, "%d 0 obj\n<</Linearized 1/L "+offsetWidth+"/H ["+offsetWidth+" "+offsetWidth+"]/O %d/E "+offsetWidth+"/N %d/T "+offsetWidth+">>\nendobj\n",
+		l.nmain, length, hintOff, hintLen, l.c.refs[l.first[0]].id, end, len(l.pages), mainXref)
+}
+
+// firstXref returns the first-page cross-reference table and trailer,
+// given the offsets of the objects it lists and of the main table.
+func (l *linearizer) firstXref(offsets []int64, mainXref int64) []byte {
+	b := fmt.Appendf(nil, "xref\n%d %d\n", l.nmain, len(offsets))
+	for _, off := range offsets {
+		b = fmt.Appendf(b, "%010d 00000 n\r\n", off)
+	}
+	b = appendObject(append(b, "trailer\n"...), l.trailer)
+	b = b[:len(b)-len(">>")]
+	b = fmt.Appendf(b, "/Prev "+offsetWidth+">>\nstartxref\n0\n%%%%EOF\n", mainXref)
+	return b
+}
+
+// write lays out the file and writes it to w.
+func (l *linearizer) write(w io.Writer) error {
+	c := l.c
+	catalog := l.data[l.catalog]
+	hintID := l.nmain + 2
+	nfirst := 3 + len(l.first)
+
+	// The parts before the hint stream have fixed sizes.
+	pre := int64(len(l.header) + len(l.linDict(0, 0, 0, 0, 0)) + len(l.firstXref(make([]int64, nfirst), 0)) + len(catalog))
+
+	// Lay out the objects after the hint stream, first as if it were
+	// absent, as the hint tables give offsets without it (§F.4).
+	order := l.objects()[1:]
+	offset := make(map[pdfobjptr]int64)
+	pos := pre
+	for _, ptr := range order {
+		offset[ptr] = pos
+		pos += int64(len(l.data[ptr]))
+	}
+	hint := l.hintStream(hintID, offset)
+	hintLen := int64(len(hint))
+	for ptr := range offset {
+		offset[ptr] += hintLen
+	}
+	mainXref := pos + hintLen
+	end := offset[l.first[0]]
+	for _, ptr := range l.first {
+		end += int64(len(l.data[ptr]))
+	}
+
+	// The main table and trailer close the file.
+	mainTable := fmt.Appendf(nil, "xref\n0 %d\n", l.nmain)
+	firstEntry := mainXref + int64(len(mainTable)) - 1 // the line end before the first entry
+	mainTable = append(mainTable, "0000000000 65535 f\r\n"...)
+	xrefs := make([]int64, l.nmain)
+	for old, ptr := range c.refs {
+		if ptr.id < l.nmain {
+			xrefs[ptr.id] = offset[old]
+		}
+	}
+	for _, off := range xrefs[1:] {
+		mainTable = fmt.Appendf(mainTable, "%010d 00000 n\r\n", off)
+	}
+	firstXrefOff := int64(len(l.header)) + int64(len(l.linDict(0, 0, 0, 0, 0)))
+	mainTable = fmt.Appendf(mainTable, "trailer\n<</Size %d>>\nstartxref\n%d\n%%%%EOF\n", l.nmain, firstXrefOff)
+	length := mainXref + int64(len(mainTable))
+
+	hintOff := pre
+	offsets := make([]int64, nfirst)
+	offsets[0] = int64(len(l.header))
+	offsets[1] = hintOff - int64(len(catalog))
+	offsets[2] = hintOff
+	for i, ptr := range l.first {
+		offsets[3+i] = offset[ptr]
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.Write(l.header)
+	bw.Write(l.linDict(length, hintOff, hintLen, end, firstEntry))
+	bw.Write(l.firstXref(offsets, mainXref))
+	bw.Write(catalog)
+	bw.Write(hint)
+	for _, ptr := range order {
+		bw.Write(l.data[ptr])
+	}
+	bw.Write(mainTable)
+	return bw.Flush()
+}
+
+// hintStream returns the primary hint stream object, numbered id,
+// holding the page offset hint table (§F.4.1) and the shared object
+// hint table (§F.4.2), given the offsets of the objects in a file
+// without the hint stream.
+func (l *linearizer) hintStream(id uint32, offset map[pdfobjptr]int64) []byte {
+	var b bitWriter
+
+	// Page offset hint table.
+	n := len(l.pages)
+	nobjs := make([]int64, n)
+	lengths := make([]int64, n)
+	contentOff := make([]int64, n)
+	contentLen := make([]int64, n)
+	for i := range l.pages {
+		objs := l.pages[i]
+		if i == 0 {
+			objs = l.first
+		}
+		start := offset[objs[0]]
+		in := make(map[pdfobjptr]bool)
+		for _, ptr := range objs {
+			in[ptr] = true
+			nobjs[i]++
+			lengths[i] += int64(len(l.data[ptr]))
+		}
+		// The content streams are those of the page's own objects,
+		// from the first to the end of the last.
+		first, last := int64(-1), int64(-1)
+		for _, ptr := range l.contents[i] {
+			if !in[ptr] {
+				continue
+			}
+			if off := offset[ptr]; first < 0 || off < first {
+				first = off
+			}
+			if end := offset[ptr] + int64(len(l.data[ptr])); end > last {
+				last = end
+			}
+		}
+		if first >= 0 {
+			contentOff[i], contentLen[i] = first-start, last-first
+		}
+	}
+	var nshared []int64
+	var sharedIDs []int64
+	for _, refs := range l.sharedRefs {
+		nshared = append(nshared, int64(len(refs)))
+		for _, idx := range refs {
+			sharedIDs = append(sharedIDs, int64(idx))
+		}
+	}
+	minObjs, objBits := minBits(nobjs)
+	minLen, lenBits := minBits(lengths)
+	minOff, offBits := minBits(contentOff)
+	minCLen, clenBits := minBits(contentLen)
+	_, nsharedBits := minBits(append(nshared, 0))
+	_, idBits := minBits(append(sharedIDs, 0))
+	b.write(uint64(minObjs), 32)
+	b.write(uint64(offset[l.first[0]]), 32)
+	b.write(uint64(objBits), 16)
+	b.write(uint64(minLen), 32)
+	b.write(uint64(lenBits), 16)
+	b.write(uint64(minOff), 32)
+	b.write(uint64(offBits), 16)
+	b.write(uint64(minCLen), 32)
+	b.write(uint64(clenBits), 16)
+	b.write(uint64(nsharedBits), 16)
+	b.write(uint64(idBits), 16)
+	b.write(0, 16) // bits for the numerators of the fractional positions of shared objects
+	b.write(1, 16) // their denominator
+	b.writeDeltas(nobjs, minObjs, objBits)
+	b.writeDeltas(lengths, minLen, lenBits)
+	b.writeDeltas(nshared, 0, nsharedBits)
+	b.writeDeltas(sharedIDs, 0, idBits)
+	b.writeDeltas(contentOff, minOff, offBits)
+	b.writeDeltas(contentLen, minCLen, clenBits)
+
+	// Shared object hint table, with a group for each object
+	// of the first page and each shared object.
+	sharedStart := len(b.buf)
+	var groups []int64
+	for _, ptr := range append(l.first[:len(l.first):len(l.first)], l.shared...) {
+		groups = append(groups, int64(len(l.data[ptr])))
+	}
+	var firstShared pdfobjptr
+	var firstOffset int64
+	if len(l.shared) > 0 {
+		firstShared = l.c.refs[l.shared[0]]
+		firstOffset = offset[l.shared[0]]
+	}
+	minGroup, groupBits := minBits(groups)
+	b.write(uint64(firstShared.id), 32)
+	b.write(uint64(firstOffset), 32)
+	b.write(uint64(len(l.first)), 32)
+	b.write(uint64(len(groups)), 32)
+	b.write(0, 16) // bits for the number of objects in a group, all of one
+	b.write(uint64(minGroup), 32)
+	b.write(uint64(groupBits), 16)
+	b.writeDeltas(groups, minGroup, groupBits)
+	b.writeDeltas(make([]int64, len(groups)), 0, 1) // no MD5 signatures
+
+	h := fmt.Appendf(nil, "%d 0 obj\n", id)
+	h = appendObject(h, pdfdict{"Length": int64(len(b.buf)), "S": int64(sharedStart)})
+	h = append(h, "\nstream\n"...)
+	h = append(h, b.buf...)
+	return append(h, "\nendstream\nendobj\n"...)
+}
+
+// minBits returns the least of xs and the number of bits needed
+// for the difference between it and the greatest.
+func minBits(xs []int64) (least int64, bits int) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	least, most := xs[0], xs[0]
+	for _, x := range xs {
+		least, most = min(least, x), max(most, x)
+	}
+	for d := most - least; d > 0; d >>= 1 {
+		bits++
+	}
+	return least, bits
+}
+
+// A bitWriter packs the fields of hint tables,
+// most significant bit first.
+type bitWriter struct {
+	buf  []byte
+	cur  byte
+	nbit uint
+}
+
+// write writes the low bits of x.
+func (b *bitWriter) write(x uint64, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		b.cur = b.cur<<1 | byte(x>>uint(i)&1)
+		b.nbit++
+		if b.nbit == 8 {
+			b.buf = append(b.buf, b.cur)
+			b.cur, b.nbit = 0, 0
+		}
+	}
+}
+
+// writeDeltas writes x-least for each x in xs, in bits bits each,
+// and pads the result to a byte boundary, as each item of a hint
+// table's entries begins on one.
+func (b *bitWriter) writeDeltas(xs []int64, least int64, bits int) {
+	for _, x := range xs {
+		b.write(uint64(x-least), bits)
+	}
+	if b.nbit > 0 {
+		b.write(0, int(8-b.nbit))
+	}
+}
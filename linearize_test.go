@@ -0,0 +1,99 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestSaveLinearized(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Info.Title = "Linearized"
+	img, err := w.AddImage(image.NewGray(image.Rect(0, 0, 10, 10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"one", "two", "three"} {
+		p := w.NewPage(612, 792)
+		p.ShowText(72, 700, s)
+		if s != "two" {
+			p.DrawImage(img, 0, 0, 10, 10)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := openPDF(t, buf.Bytes()).SaveLinearized(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	r := openPDF(t, data)
+	for i, want := range []string{"one", "two", "three"} {
+		if s, err := r.Page(i + 1).GetPlainText(); err != nil || s != want {
+			t.Errorf("page %d text %q, %v, want %q", i+1, s, err, want)
+		}
+	}
+	if title := r.Info().Title; title != "Linearized" {
+		t.Errorf("Title %q, want Linearized", title)
+	}
+
+	// The linearization dictionary is the first object.
+	m := regexp.MustCompile(`^%PDF-1\.\d\n.*\n(\d+) 0 obj\n<</Linearized`).FindSubmatch(data)
+	if m == nil {
+		t.Fatalf("file does not begin with a linearization dictionary:\n%.100q", data)
+	}
+	id, _ := strconv.Atoi(string(m[1]))
+	lin := object(r, uint32(id))
+	at := func(key string) int64 { return lin.Key(key).CoerceInt64(-1) }
+	objAt := func(off int64, id uint32) bool {
+		return off >= 0 && off < int64(len(data)) && bytes.HasPrefix(data[off:], fmt.Appendf(nil, "%d 0 obj", id))
+	}
+	if l := at("L"); l != int64(len(data)) {
+		t.Errorf("L = %d, want the file length %d", l, len(data))
+	}
+	if n := at("N"); n != 3 {
+		t.Errorf("N = %d, want 3", n)
+	}
+	page1 := r.Page(1).V.Ref()
+	if o := at("O"); o != int64(page1.ID) {
+		t.Errorf("O = %d, want the first page's object %d", o, page1.ID)
+	}
+	hint := lin.Key("H").Index(0).CoerceInt64(-1)
+	if hint < 0 || hint >= int64(len(data)) {
+		t.Fatalf("H = %v", lin.Key("H"))
+	}
+	if m := regexp.MustCompile(`^(\d+) 0 obj`).FindSubmatch(data[hint:]); m == nil {
+		t.Errorf("H = %d does not locate an object: %.20q", hint, data[hint:])
+	} else if id, _ := strconv.Atoi(string(m[1])); object(r, uint32(id)).Key("S").Kind() != Integer {
+		t.Errorf("H = %d does not locate the hint stream", hint)
+	}
+	if t0 := at("T"); t0 < 0 || t0 >= int64(len(data)) || !bytes.HasPrefix(data[t0+1:], []byte("0000000000 65535 f")) {
+		t.Errorf("T = %d does not precede the main cross-reference table", t0)
+	}
+
+	// The objects of the first page, including the image it shares
+	// with the third, come before E, and those of the others after.
+	e := at("E")
+	first := []ObjRef{page1, r.Page(1).Resources().Key("XObject").Key("Im1").Ref()}
+	for _, ref := range first {
+		off := bytes.Index(data, fmt.Appendf(nil, "\n%d 0 obj", ref.ID)) + 1
+		if !objAt(int64(off), ref.ID) || int64(off) >= e {
+			t.Errorf("first-page object %d at %d, want before E = %d", ref.ID, off, e)
+		}
+	}
+	for i := 2; i <= 3; i++ {
+		ref := r.Page(i).V.Ref()
+		if off := bytes.Index(data, fmt.Appendf(nil, "\n%d 0 obj", ref.ID)) + 1; int64(off) < e {
+			t.Errorf("page %d object at %d, want after E = %d", i, off, e)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Image masks (PDF 32000-1:2008, §8.9.6 and §11.6.5.3): soft masks,
+// stencil masks and color key masking.
+
+package pdf
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// DecodeMasked decodes the image like Decode and, if it has a soft
+// mask (SMask) or a mask (Mask), applies the mask as the image's
+// alpha channel, returning an *image.NRGBA in which the masked out
+// areas are transparent. An image without a mask is returned as Decode
+// returns it. A mask of a different size from the image is scaled to fit.
+func (img Image) DecodeMasked() (image.Image, error) {
+	return decodeMasked(img.V)
+}
+
+// Decode decodes the image's samples, like Image.Decode.
+func (info ImageInfo) Decode() (image.Image, error) {
+	return decodeImage(info.v)
+}
+
+// DecodeMasked decodes the image and applies its mask,
+// like Image.DecodeMasked.
+func (info ImageInfo) DecodeMasked() (image.Image, error) {
+	return decodeMasked(info.v)
+}
+
+// decodeMasked decodes the image XObject v with its mask applied.
+func decodeMasked(v Value) (image.Image, error) {
+	m, err := decodeImage(v)
+	if err != nil {
+		return nil, err
+	}
+	b := m.Bounds()
+	alpha, err := imageAlpha(v, b.Dx(), b.Dy())
+	if err != nil || alpha == nil {
+		return m, err
+	}
+	out := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			c.A = alpha.AlphaAt(x-b.Min.X, y-b.Min.Y).A
+			out.SetNRGBA(x, y, c)
+		}
+	}
+	return out, nil
+}
+
+// imageAlpha returns the alpha channel that the mask of the image
+// XObject v gives its w by h samples, or nil if v has no mask.
+func imageAlpha(v Value, w, h int) (*image.Alpha, error) {
+	if sm := v.Key("SMask"); sm.Kind() == Stream {
+		// A soft mask is a gray image whose samples are alpha values.
+		m, err := decodeImage(sm)
+		if err != nil {
+			return nil, fmt.Errorf("soft mask: %v", err)
+		}
+		return scaleAlpha(m, w, h), nil
+	}
+	switch mask := v.Key("Mask"); mask.Kind() {
+	case Stream:
+		m, err := decodeStencil(mask, mask.Reader())
+		if err != nil {
+			return nil, fmt.Errorf("mask: %v", err)
+		}
+		return scaleAlpha(m, w, h), nil
+	case Array:
+		return colorKeyAlpha(v, floats(mask))
+	}
+	return nil, nil
+}
+
+// decodeStencil decodes the stencil mask with image dictionary v and
+// data rd, an image with ImageMask set or used as another image's Mask,
+// into an alpha image that is opaque where the mask paints and
+// transparent where it masks out. With the default Decode array [0 1],
+// samples of 0 paint.
+func decodeStencil(v Value, rd io.Reader) (*image.Alpha, error) {
+	w := int(v.Key("Width").CoerceInt64(0))
+	h := int(v.Key("Height").CoerceInt64(0))
+	if w <= 0 || h <= 0 || w > maxImageSide || h > maxImageSide {
+		return nil, fmt.Errorf("malformed PDF: image size %dx%d", w, h)
+	}
+	buf, stride, err := readSamples(rd, w, h, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	paint := uint32(0)
+	if d := floats(v.Key("Decode")); len(d) == 2 && d[0] > d[1] {
+		paint = 1
+	}
+	m := image.NewAlpha(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		row := buf[y*stride : (y+1)*stride]
+		for x := 0; x < w; x++ {
+			if sample(row, x, 1) == paint {
+				m.Pix[y*m.Stride+x] = 0xff
+			}
+		}
+	}
+	return m, nil
+}
+
+// colorKeyAlpha returns the alpha channel given by color key masking
+// of the image XObject v: samples whose every component lies in the
+// corresponding range [min max] of ranges are masked out.
+// The samples of JPEG images are not exact, so such images are
+// not masked.
+func colorKeyAlpha(v Value, ranges []float64) (*image.Alpha, error) {
+	fs := v.filters()
+	if len(fs) > 0 && fs[len(fs)-1].name == "DCTDecode" {
+		return nil, nil
+	}
+	w, h, bpc, err := imageSize(v)
+	if err != nil {
+		return nil, err
+	}
+	n := len(initialColor(v.Key("ColorSpace")).Components)
+	if n == 0 || len(ranges) < 2*n {
+		return nil, nil
+	}
+	buf, stride, err := readSamples(v.Reader(), w, h, n, bpc)
+	if err != nil {
+		return nil, err
+	}
+	m := image.NewAlpha(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		row := buf[y*stride : (y+1)*stride]
+		for x := 0; x < w; x++ {
+			masked := true
+			for k := 0; k < n && masked; k++ {
+				s := float64(sample(row, x*n+k, bpc))
+				masked = ranges[2*k] <= s && s <= ranges[2*k+1]
+			}
+			if !masked {
+				m.Pix[y*m.Stride+x] = 0xff
+			}
+		}
+	}
+	return m, nil
+}
+
+// scaleAlpha returns the gray levels of m as an alpha channel
+// of size w by h, scaling m by nearest neighbor if necessary.
+func scaleAlpha(m image.Image, w, h int) *image.Alpha {
+	b := m.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	a := image.NewAlpha(image.Rect(0, 0, w, h))
+	if sw == 0 || sh == 0 {
+		return a
+	}
+	for y := 0; y < h; y++ {
+		sy := b.Min.Y + y*sh/h
+		for x := 0; x < w; x++ {
+			sx := b.Min.X + x*sw/w
+			a.Pix[y*a.Stride+x] = color.GrayModel.Convert(m.At(sx, sy)).(color.Gray).Y
+		}
+	}
+	return a
+}
@@ -0,0 +1,139 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestDecodeMasked(t *testing.T) {
+	gray := "/Subtype /Image /Width 2 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8 "
+	tests := []struct {
+		mask string
+		want string
+	}{
+		{"", "none"},
+		// A soft mask, scaled up to the image's size.
+		{"/SMask 6 0 R", "80 80 80 80"},
+		{"/SMask 7 0 R", "00 ff 80 40"},
+		// A stencil mask, in which 0 paints.
+		{"/Mask 8 0 R", "ff 00 ff 00"},
+		// Color key masking of the samples from 0x10 to 0x20.
+		{"/Mask [16 32]", "00 00 ff ff"},
+	}
+	for _, tt := range tests {
+		data := pagePDF("<</XObject <</Im0 5 0 R>>>>", "/Im0 Do",
+			stream(gray+tt.mask, "\x10\x20\x30\x40"),
+			stream("/Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8", "\x80"),
+			stream(gray, "\x00\xff\x80\x40"),
+			stream("/Subtype /Image /Width 2 /Height 1 /ImageMask true", "\x40"),
+		)
+		c, err := openPDF(t, data).Page(1).Content()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := c.Images[0].DecodeMasked()
+		if err != nil {
+			t.Errorf("%s: %v", tt.mask, err)
+			continue
+		}
+		got := "none"
+		if n, ok := m.(*image.NRGBA); ok {
+			got = ""
+			for i := 0; i < 4; i++ {
+				px := n.NRGBAAt(i%2, i/2)
+				if want := []uint8{0x10, 0x20, 0x30, 0x40}[i]; px.R != want || px.G != want || px.B != want {
+					t.Errorf("%s: pixel %d is %v, want gray %#x", tt.mask, i, px, want)
+				}
+				got += fmt.Sprintf(" %02x", px.A)
+			}
+			got = got[1:]
+		}
+		if got != tt.want {
+			t.Errorf("%s: alpha %s, want %s", tt.mask, got, tt.want)
+		}
+		if m2, err := c.Images[0].Info().DecodeMasked(); err != nil || m2.Bounds() != m.Bounds() {
+			t.Errorf("%s: ImageInfo.DecodeMasked returned %v, %v", tt.mask, m2, err)
+		}
+	}
+}
+
+func TestOversizedMask(t *testing.T) {
+	gray := "/Subtype /Image /Width 2 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8 "
+	for _, tt := range []struct{ mask, dict, want string }{
+		{"/SMask 6 0 R", "/Subtype /Image /Width 32768 /Height 32768 /ColorSpace /DeviceGray /BitsPerComponent 16", "soft mask"},
+		{"/Mask 6 0 R", "/Subtype /Image /Width 32768 /Height 32768 /ImageMask true", "mask"},
+	} {
+		data := pagePDF("<</XObject <</Im0 5 0 R>>>>", "/Im0 Do",
+			stream(gray+tt.mask, "\x10\x20\x30\x40"),
+			stream(tt.dict, "\x00\x01"),
+		)
+		c, err := openPDF(t, data).Page(1).Content()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Images[0].DecodeMasked(); err == nil || !strings.HasPrefix(err.Error(), tt.want+":") {
+			t.Errorf("%s: DecodeMasked error %v, want a %s error", tt.mask, err, tt.want)
+		}
+	}
+}
+
+func TestImageMask(t *testing.T) {
+	data := pagePDF("<</XObject <</Im0 5 0 R /Im1 6 0 R>>>>", "0 0 1 rg /Im0 Do 0.5 g /Im0 Do /Im1 Do",
+		stream("/Subtype /Image /Width 3 /Height 1 /ImageMask true", "\x40"),
+		stream("/Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8", "\x80"),
+	)
+	p := openPDF(t, data).Page(1)
+	c, err := p.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Images) != 3 {
+		t.Fatalf("page has %d images, want 3", len(c.Images))
+	}
+	pixels := func(m image.Image) string {
+		var s string
+		for x := 0; x < m.Bounds().Dx(); x++ {
+			px := color.NRGBAModel.Convert(m.At(x, 0)).(color.NRGBA)
+			s += fmt.Sprintf(" %02x%02x%02x/%02x", px.R, px.G, px.B, px.A)
+		}
+		return s[1:]
+	}
+	tests := []struct {
+		mask bool
+		want string
+	}{
+		{true, "0000ff/ff 0000ff/00 0000ff/ff"},
+		{true, "808080/ff 808080/00 808080/ff"},
+		{false, "808080/ff"},
+	}
+	for i, tt := range tests {
+		img := c.Images[i]
+		if img.ImageMask != tt.mask {
+			t.Errorf("image %d: ImageMask = %v, want %v", i, img.ImageMask, tt.mask)
+		}
+		m, err := img.Decode()
+		if err != nil {
+			t.Errorf("image %d: %v", i, err)
+			continue
+		}
+		if got := pixels(m); got != tt.want {
+			t.Errorf("image %d: pixels %s, want %s", i, got, tt.want)
+		}
+	}
+
+	// Page.Images knows no fill color and paints stencil masks black.
+	imgs := p.Images()
+	if len(imgs) != 2 || imgs[0].Err != nil {
+		t.Fatalf("Images() = %+v", imgs)
+	}
+	if got, want := pixels(imgs[0].Image), "000000/ff 000000/00 000000/ff"; got != want {
+		t.Errorf("Images()[0] pixels %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Font metrics.
+
+package pdf
+
+import "sync"
+
+// UnitsPerEm returns the number of glyph units per text space unit
+// in the widths reported by Width: a glyph of width w set at font
+// size s advances w / UnitsPerEm * s, before character spacing,
+// word spacing and horizontal scaling. It is 1000 for every font,
+// Type 3 fonts' widths being converted from their own glyph space.
+func (f Font) UnitsPerEm() float64 {
+	return 1000
+}
+
+// Width returns the width of the glyph for the character code,
+// in glyph units (see UnitsPerEm). For a Type 0 font the code is a CID.
+// A missing font has no widths.
+func (f Font) Width(code uint32) float64 {
+	if f.wg == nil {
+		return 0
+	}
+	return f.wg.Width(code)
+}
+
+// Vertical reports whether the font is a Type 0 font in vertical
+// writing mode, whose text advances down the page.
+func (f Font) Vertical() bool {
+	return f.V.Key("Subtype").CoerceName("") == "Type0" && verticalCMap(f.V.Key("Encoding"))
+}
+
+// VerticalMetrics returns the vertical metrics of the glyph for
+// the CID in a Type 0 font, in glyph units (see UnitsPerEm):
+// its vertical displacement w1y, normally negative, and the position
+// vector v from its horizontal origin to its vertical origin, from
+// which it is placed in vertical writing mode. Fonts other than Type 0
+// fonts use the defaults: w1y is -1000 and v is half the glyph's width
+// across and 880 up.
+func (f Font) VerticalMetrics(cid uint32) (w1y float64, v Point) {
+	if wg, ok := f.wg.(CIDWidthGrabber); ok {
+		return wg.Vertical(cid)
+	}
+	return -1000, Point{f.Width(cid) / 2, 880}
+}
+
+// Advance returns the horizontal distance in text space units that
+// showing raw, a string of character codes, moves the text position
+// at the given font size, ignoring character and word spacing and
+// horizontal scaling.
+func (f Font) Advance(raw string, size float64) float64 {
+	w := 0.0
+	for _, ch := range f.Decode(raw) {
+		w += ch.Width
+	}
+	return w / f.UnitsPerEm() * size
+}
+
+// A FontDescriptor holds the metrics and other attributes of a font
+// other than its glyph widths (PDF 32000-1:2008, §9.8).
+// Lengths are in glyph units (see Font.UnitsPerEm).
+type FontDescriptor struct {
+	FontName     string    // PostScript name, as in BaseFont
+	FontFamily   string    // family name, such as Times
+	Flags        FontFlags // characteristics of the font
+	FontWeight   float64   // 100 to 900, or 0 if not given
+	ItalicAngle  float64   // degrees counterclockwise from vertical
+	Ascent       float64   // maximum height above the baseline
+	Descent      float64   // maximum depth below the baseline, negative
+	CapHeight    float64   // height of flat capital letters
+	XHeight      float64   // height of flat lowercase letters
+	StemV        float64   // thickness of vertical stems
+	MissingWidth float64   // width of codes the font gives no width
+	FontBBox     Rectangle // union of all the glyphs' bounding boxes
+}
+
+// FontFlags are the characteristics of a font given by its
+// descriptor's Flags entry (PDF 32000-1:2008, Table 123).
+type FontFlags uint32
+
+const (
+	FlagFixedPitch  FontFlags = 1 << 0  // all glyphs have the same width
+	FlagSerif       FontFlags = 1 << 1  // glyphs have serifs
+	FlagSymbolic    FontFlags = 1 << 2  // glyphs outside the standard Latin set
+	FlagScript      FontFlags = 1 << 3  // glyphs resemble cursive handwriting
+	FlagNonsymbolic FontFlags = 1 << 5  // glyphs within the standard Latin set
+	FlagItalic      FontFlags = 1 << 6  // glyphs have dominant slanted strokes
+	FlagAllCap      FontFlags = 1 << 16 // no lowercase letters
+	FlagSmallCap    FontFlags = 1 << 17 // lowercase letters are small capitals
+	FlagForceBold   FontFlags = 1 << 18 // embolden at small sizes
+)
+
+// Descriptor returns the font's descriptor, looking in the descendant
+// font for composite fonts. Entries missing from the descriptor, and all
+// entries for a font without one, such as a standard 14 font, are zero.
+func (f Font) Descriptor() FontDescriptor {
+	fd := f.descriptor()
+	return FontDescriptor{
+		FontName:     fd.Key("FontName").CoerceName(""),
+		FontFamily:   fd.Key("FontFamily").CoerceText(""),
+		Flags:        FontFlags(fd.Key("Flags").CoerceInt64(0)),
+		FontWeight:   fd.Key("FontWeight").CoerceFloat64(0),
+		ItalicAngle:  fd.Key("ItalicAngle").CoerceFloat64(0),
+		Ascent:       fd.Key("Ascent").CoerceFloat64(0),
+		Descent:      fd.Key("Descent").CoerceFloat64(0),
+		CapHeight:    fd.Key("CapHeight").CoerceFloat64(0),
+		XHeight:      fd.Key("XHeight").CoerceFloat64(0),
+		StemV:        fd.Key("StemV").CoerceFloat64(0),
+		MissingWidth: fd.Key("MissingWidth").CoerceFloat64(0),
+		FontBBox:     rectValue(fd.Key("FontBBox")),
+	}
+}
+
+// glyphBBoxes holds the glyph bounding boxes read from a font program,
+// shared by all copies of a Font and loaded on first use.
+type glyphBBoxes struct {
+	once  sync.Once
+	glyph func(code uint32) (Rectangle, bool)
+}
+
+// GlyphBBox returns the bounding box of the glyph for the character
+// code, in glyph units (see UnitsPerEm) relative to the glyph origin.
+// For a Type 0 font the code is a CID.
+//
+// The box is exact for Type 3 glyphs whose procedures declare it
+// with d1 and for glyphs of embedded TrueType programs. Otherwise
+// it spans the glyph's advance width horizontally and the font's
+// FontBBox, or failing that its ascent and descent, vertically.
+func (f Font) GlyphBBox(code uint32) Rectangle {
+	if f.isType3() {
+		if _, b, ok := glyphProcMetrics(f.CharProc(byte(code))); ok {
+			m := f.fontMatrix()
+			var r Rectangle
+			for i, c := range []Point{b.Min, {b.Max.X, b.Min.Y}, b.Max, {b.Min.X, b.Max.Y}} {
+				p := Matrix{{1, 0, 0}, {0, 1, 0}, {c.X, c.Y, 1}}.mul(m)
+				q := Rectangle{Point{1000 * p[2][0], 1000 * p[2][1]}, Point{1000 * p[2][0], 1000 * p[2][1]}}
+				if i > 0 {
+					q = unionRect(r, q)
+				}
+				r = q
+			}
+			return r
+		}
+	}
+	if f.boxes != nil {
+		f.boxes.once.Do(func() { f.boxes.glyph = f.programGlyphBoxes() })
+		if f.boxes.glyph != nil {
+			if r, ok := f.boxes.glyph(code); ok {
+				return r
+			}
+		}
+	}
+	d := f.Descriptor()
+	r := Rectangle{Point{0, d.FontBBox.Min.Y}, Point{f.Width(code), d.FontBBox.Max.Y}}
+	if d.FontBBox == (Rectangle{}) {
+		r.Min.Y, r.Max.Y = d.Descent, d.Ascent
+	}
+	return r
+}
+
+// programGlyphBoxes returns a function reporting the bounding boxes of
+// the glyphs of f's embedded TrueType program by character code,
+// or nil if f has no such program.
+func (f Font) programGlyphBoxes() func(code uint32) (Rectangle, bool) {
+	if f.V.Key("Subtype").CoerceName("") == "Type0" {
+		d := f.descendant()
+		boxes := sfntGlyphBoxes(sfntTables(streamBytes(d.Key("FontDescriptor").Key("FontFile2"))))
+		if boxes == nil {
+			return nil
+		}
+		gids := cidToGIDMap(d)
+		return func(cid uint32) (Rectangle, bool) {
+			return boxes(cidGID(gids, cid))
+		}
+	}
+
+	tables := sfntTables(streamBytes(f.descriptor().Key("FontFile2")))
+	boxes := sfntGlyphBoxes(tables)
+	if boxes == nil {
+		return nil
+	}
+	// A simple font's code selects a glyph through the symbol
+	// or Macintosh cmap subtable, or through the Unicode one
+	// by way of the character it decodes to.
+	cmap := tables["cmap"]
+	sym, mac, uni := sfntCmap(cmap, 3, 0), sfntCmap(cmap, 1, 0), unicodeCmap(cmap)
+	return func(code uint32) (Rectangle, bool) {
+		if code > 0xff {
+			return Rectangle{}, false
+		}
+		for _, base := range []uint32{0, 0xF000, 0xF100, 0xF200} {
+			if g, ok := sym[base+code]; ok {
+				return boxes(g)
+			}
+		}
+		if g, ok := mac[code]; ok {
+			return boxes(g)
+		}
+		if rs := f.Decode(string([]byte{byte(code)})); len(rs) == 1 && len(rs[0].Text) == 1 {
+			if g, ok := uni[uint32(rs[0].Text[0])]; ok {
+				return boxes(g)
+			}
+		}
+		return Rectangle{}, false
+	}
+}
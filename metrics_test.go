@@ -0,0 +1,186 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFontWidth(t *testing.T) {
+	simple := openPDF(t, fontPDF("/Subtype /Type1 /BaseFont /Test /FirstChar 65 /LastChar 67 /Widths [600 700 0]")).Page(1).Font("F1")
+	// Type 3 widths are in glyph space, here 100 units to the em.
+	type3 := openPDF(t, fontPDF("/Subtype /Type3 /FontMatrix [0.01 0 0 0.01 0 0] /FontBBox [0 0 100 100]"+
+		" /CharProcs <<>> /Resources <<>> /FirstChar 65 /LastChar 66 /Widths [60 70]")).Page(1).Font("F1")
+	cid := openPDF(t, type0PDF("/Identity-H", "", "/Subtype /CIDFontType2 /DW 500 /W [65 [600 700]]")).Page(1).Font("F1")
+	tests := []struct {
+		name    string
+		f       Font
+		code    uint32
+		width   float64
+		raw     string
+		advance float64
+	}{
+		{"simple", simple, 'B', 700, "AAB", 38},
+		{"Type 3", type3, 'A', 600, "AB", 26},
+		{"CID", cid, 66, 700, "\x00\x41\x00\x42\x00\x43", 36},
+		{"missing", Font{}, 'A', 0, "AB", 0},
+	}
+	for _, tt := range tests {
+		if u := tt.f.UnitsPerEm(); u != 1000 {
+			t.Errorf("%s: UnitsPerEm() = %v, want 1000", tt.name, u)
+		}
+		if w := tt.f.Width(tt.code); w != tt.width {
+			t.Errorf("%s: Width(%d) = %v, want %v", tt.name, tt.code, w, tt.width)
+		}
+		if a := tt.f.Advance(tt.raw, 20); a != tt.advance {
+			t.Errorf("%s: Advance(%q, 20) = %v, want %v", tt.name, tt.raw, a, tt.advance)
+		}
+	}
+}
+
+func TestFontDescriptor(t *testing.T) {
+	fd := "<</Type /FontDescriptor /FontName /ABCDEF+Test-Bold /FontFamily (Test) /Flags 262178 /FontWeight 700" +
+		" /ItalicAngle -12 /Ascent 720 /Descent -210 /CapHeight 700 /XHeight 500 /StemV 120 /MissingWidth 250" +
+		" /FontBBox [-50 -210 1000 900]>>"
+	want := FontDescriptor{
+		FontName:     "ABCDEF+Test-Bold",
+		FontFamily:   "Test",
+		Flags:        FlagSerif | FlagNonsymbolic | FlagForceBold,
+		FontWeight:   700,
+		ItalicAngle:  -12,
+		Ascent:       720,
+		Descent:      -210,
+		CapHeight:    700,
+		XHeight:      500,
+		StemV:        120,
+		MissingWidth: 250,
+		FontBBox:     Rectangle{Point{-50, -210}, Point{1000, 900}},
+	}
+	simple := openPDF(t, fontPDF("/Subtype /Type1 /BaseFont /ABCDEF+Test-Bold /FontDescriptor 6 0 R", fd)).Page(1).Font("F1")
+	// A composite font's descriptor is that of its descendant.
+	composite := openPDF(t, fontPDF("/Subtype /Type0 /BaseFont /ABCDEF+Test-Bold /Encoding /Identity-H /DescendantFonts [6 0 R]",
+		"<</Type /Font /Subtype /CIDFontType0 /BaseFont /ABCDEF+Test-Bold /FontDescriptor 7 0 R>>", fd)).Page(1).Font("F1")
+	if d := simple.Descriptor(); d != want {
+		t.Errorf("simple font descriptor %+v, want %+v", d, want)
+	}
+	if d := composite.Descriptor(); d != want {
+		t.Errorf("composite font descriptor %+v, want %+v", d, want)
+	}
+	if !simple.isBold() || !simple.isItalic() {
+		t.Errorf("font is not bold and italic")
+	}
+
+	std := openPDF(t, fontPDF("/Subtype /Type1 /BaseFont /Helvetica")).Page(1).Font("F1")
+	if d := std.Descriptor(); d != (FontDescriptor{}) {
+		t.Errorf("standard font descriptor %+v, want zero", d)
+	}
+}
+
+func TestGlyphBBox(t *testing.T) {
+	type3 := openPDF(t, fontPDF("/Subtype /Type3 /FontMatrix [0.01 0 0 0.01 0 0] /FontBBox [0 0 100 100]"+
+		" /CharProcs <</a 6 0 R /b 7 0 R>> /Encoding <</Differences [97 /a /b]>> /FirstChar 97 /LastChar 98 /Widths [50 60]",
+		stream("", "50 0 5 -10 45 70 d1 5 -10 40 80 re f"),
+		stream("", "60 0 d0 0 0 60 60 re f"))).Page(1).Font("F1")
+
+	// Glyph 1, for code A, is 1024 units wide and 2048 high.
+	tables := glyfTables([4]int16{}, [4]int16{0, -512, 1024, 1536})
+	tables["cmap"] = cmapTable(cmapSubtable{1, 0, cmapFormat6('A', 1)})
+	trueType := openPDF(t, trueTypePDF("/FirstChar 65 /LastChar 66 /Widths [600 700]", 32, sfnt(tables))).Page(1).Font("F1")
+
+	bbox := openPDF(t, fontPDF("/Subtype /Type1 /BaseFont /Test /FirstChar 65 /LastChar 65 /Widths [600] /FontDescriptor 6 0 R",
+		"<</Type /FontDescriptor /FontBBox [-100 -200 900 800] /Ascent 700 /Descent -300>>")).Page(1).Font("F1")
+	metrics := openPDF(t, fontPDF("/Subtype /Type1 /BaseFont /Test /FirstChar 65 /LastChar 65 /Widths [600] /FontDescriptor 6 0 R",
+		"<</Type /FontDescriptor /Ascent 700 /Descent -300>>")).Page(1).Font("F1")
+
+	r := func(x0, y0, x1, y1 float64) Rectangle { return Rectangle{Point{x0, y0}, Point{x1, y1}} }
+	tests := []struct {
+		name string
+		f    Font
+		code uint32
+		want Rectangle
+	}{
+		{"Type 3 d1", type3, 'a', r(50, -100, 450, 700)},
+		{"Type 3 d0", type3, 'b', r(0, 0, 600, 0)},
+		{"TrueType", trueType, 'A', r(0, -250, 500, 750)},
+		{"TrueType without glyph", trueType, 'B', r(0, 0, 700, 0)},
+		{"FontBBox", bbox, 'A', r(0, -200, 600, 800)},
+		{"ascent and descent", metrics, 'A', r(0, -300, 600, 700)},
+	}
+	for _, tt := range tests {
+		if got := tt.f.GlyphBBox(tt.code); !nearRect(got, tt.want) {
+			t.Errorf("%s: GlyphBBox(%d) = %v, want %v", tt.name, tt.code, got, tt.want)
+		}
+	}
+}
+
+// nearRect reports whether the corners of r and s differ by rounding error.
+func nearRect(r, s Rectangle) bool {
+	for _, d := range []float64{r.Min.X - s.Min.X, r.Min.Y - s.Min.Y, r.Max.X - s.Max.X, r.Max.Y - s.Max.Y} {
+		if math.Abs(d) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestVerticalMetrics(t *testing.T) {
+	data := pagePDF("<</Font <</F1 5 0 R>>>>", "BT /F1 10 Tf 100 700 Td <00010002> Tj <0001> Tj ET",
+		"<</Type /Font /Subtype /Type0 /BaseFont /Test /Encoding /Identity-V /DescendantFonts [6 0 R]>>",
+		"<</Type /Font /Subtype /CIDFontType0 /BaseFont /Test /DW 1000 /W [1 [600]]"+
+			" /DW2 [900 -1200] /W2 [1 [-500 300 800]]>>")
+	p := openPDF(t, data).Page(1)
+	f := p.Font("F1")
+	// CID 1 has its metrics in W2; CID 2 takes the x of its vertical
+	// origin from its width and the rest from DW2.
+	for _, tt := range []struct {
+		cid uint32
+		w1y float64
+		v   Point
+	}{
+		{1, -500, Point{300, 800}},
+		{2, -1200, Point{500, 900}},
+	} {
+		if w1y, v := f.VerticalMetrics(tt.cid); w1y != tt.w1y || v != tt.v {
+			t.Errorf("VerticalMetrics(%d) = %v, %v, want %v, %v", tt.cid, w1y, v, tt.w1y, tt.v)
+		}
+	}
+	if w1y, v := (Font{}).VerticalMetrics(1); w1y != -1000 || v != (Point{0, 880}) {
+		t.Errorf("missing font VerticalMetrics(1) = %v, %v, want the defaults", w1y, v)
+	}
+
+	// Each glyph is placed from its vertical origin, and the text
+	// moves down by 5 points for CID 1 and 12 points for CID 2.
+	c, err := p.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Text) != 2 || c.Text[0].X != 97 || c.Text[0].Y != 692 || c.Text[1].X != 97 || c.Text[1].Y != 675 {
+		t.Errorf("text %+v, want to start at (97, 692) and (97, 675)", c.Text)
+	}
+}
+
+func TestMissingWidth(t *testing.T) {
+	// Widths is one entry short of LastChar.
+	f := openPDF(t, fontPDF("/Subtype /Type1 /BaseFont /Test /FirstChar 65 /LastChar 67 /Widths [600 700] /FontDescriptor 6 0 R",
+		"<</Type /FontDescriptor /FontName /Test /MissingWidth 250>>")).Page(1).Font("F1")
+	for _, tt := range []struct {
+		code  uint32
+		width float64
+	}{
+		{64, 250},
+		{65, 600},
+		{66, 700},
+		{67, 250}, // LastChar, with no entry in Widths
+		{68, 250},
+	} {
+		if w := f.Width(tt.code); w != tt.width {
+			t.Errorf("Width(%d) = %v, want %v", tt.code, w, tt.width)
+		}
+	}
+	if a := f.Advance("@ABCD", 10); a != 20.5 {
+		t.Errorf("Advance = %v, want 20.5", a)
+	}
+}
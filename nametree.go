@@ -0,0 +1,116 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Name trees (PDF 32000-1:2008, §7.9.6): maps from strings to
+// objects, such as the document's named destinations and
+// embedded files, stored as balanced trees, and number trees
+// (§7.9.7), the same structure keyed by integers.
+
+package pdf
+
+// maxNameTreeDepth limits the depth of name trees.
+const maxNameTreeDepth = 32
+
+// treeKid returns the i'th node of the Kids array kids of a name or
+// number tree, reporting false if it has been visited before, as
+// recorded in seen. Following each node once keeps Kids that form a
+// cycle, or list a node twice, from being followed again.
+func treeKid(kids Value, i int, seen map[ObjRef]bool) (Value, bool) {
+	if ptr, ok := kids.entryRef(i); ok {
+		ref := ObjRef{ptr.id, ptr.gen}
+		if seen[ref] {
+			return Value{}, false
+		}
+		seen[ref] = true
+	}
+	return kids.Index(i), true
+}
+
+// nameTreeLookup returns the value for key in the name tree whose root
+// node is root, or a null Value if the tree has no such key. The Limits
+// of intermediate nodes direct the search, but nodes whose limits are
+// missing are searched too.
+func nameTreeLookup(root Value, key string) Value {
+	return nameTreeFind(root, key, 0, map[ObjRef]bool{root.Ref(): true})
+}
+
+func nameTreeFind(node Value, key string, depth int, seen map[ObjRef]bool) Value {
+	if depth > maxNameTreeDepth {
+		return Value{}
+	}
+	if lim := node.Key("Limits"); lim.Len() == 2 && depth > 0 {
+		if key < lim.Index(0).CoerceString("") || key > lim.Index(1).CoerceString("") {
+			return Value{}
+		}
+	}
+	names := node.Key("Names")
+	for i := 0; i+1 < names.Len(); i += 2 {
+		if names.Index(i).CoerceString("") == key {
+			return names.Index(i + 1)
+		}
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		if kid, ok := treeKid(kids, i, seen); ok {
+			if v := nameTreeFind(kid, key, depth+1, seen); v.Kind() != Null {
+				return v
+			}
+		}
+	}
+	return Value{}
+}
+
+// nameTreeEach calls f for each key and value in the name tree whose
+// root node is root, in the tree's order, which is that of the keys.
+func nameTreeEach(root Value, f func(key string, v Value)) {
+	nameTreeWalk(root, f, 0, map[ObjRef]bool{root.Ref(): true})
+}
+
+func nameTreeWalk(node Value, f func(key string, v Value), depth int, seen map[ObjRef]bool) {
+	if depth > maxNameTreeDepth {
+		return
+	}
+	names := node.Key("Names")
+	for i := 0; i+1 < names.Len(); i += 2 {
+		f(names.Index(i).CoerceString(""), names.Index(i+1))
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		if kid, ok := treeKid(kids, i, seen); ok {
+			nameTreeWalk(kid, f, depth+1, seen)
+		}
+	}
+}
+
+// numTreeLookup returns the value for key in the number tree whose root
+// node is root, or a null Value if the tree has no such key.
+func numTreeLookup(root Value, key int64) Value {
+	return numTreeFind(root, key, 0, map[ObjRef]bool{root.Ref(): true})
+}
+
+func numTreeFind(node Value, key int64, depth int, seen map[ObjRef]bool) Value {
+	if depth > maxNameTreeDepth {
+		return Value{}
+	}
+	if lim := node.Key("Limits"); lim.Len() == 2 && depth > 0 {
+		if key < lim.Index(0).CoerceInt64(key) || key > lim.Index(1).CoerceInt64(key) {
+			return Value{}
+		}
+	}
+	nums := node.Key("Nums")
+	for i := 0; i+1 < nums.Len(); i += 2 {
+		if nums.Index(i).CoerceInt64(-1) == key {
+			return nums.Index(i + 1)
+		}
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		if kid, ok := treeKid(kids, i, seen); ok {
+			if v := numTreeFind(kid, key, depth+1, seen); v.Kind() != Null {
+				return v
+			}
+		}
+	}
+	return Value{}
+}
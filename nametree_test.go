@@ -0,0 +1,87 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// treePDF returns a document holding the objects of a name or number
+// tree, numbered from 2.
+func treePDF(nodes ...string) []byte {
+	return buildPDF("", append([]string{"<</Type /Catalog>>"}, nodes...)...)
+}
+
+// treeKeys returns the keys of the name tree root, in order.
+func treeKeys(root Value) string {
+	var keys []string
+	nameTreeEach(root, func(key string, v Value) {
+		keys = append(keys, key)
+	})
+	return strings.Join(keys, " ")
+}
+
+func TestNameTree(t *testing.T) {
+	r := openPDF(t, treePDF(
+		"<</Kids [3 0 R 4 0 R]>>",
+		"<</Limits [(apple) (fig)] /Names [(apple) 1 (cherry) 2 (fig) 3]>>",
+		"<</Limits [(grape) (pear)] /Kids [5 0 R]>>",
+		"<</Limits [(grape) (pear)] /Names [(grape) 4 (pear) 5]>>",
+	))
+	root := object(r, 2)
+	if got, want := treeKeys(root), "apple cherry fig grape pear"; got != want {
+		t.Errorf("keys %s, want %s", got, want)
+	}
+	for key, want := range map[string]int64{"apple": 1, "fig": 3, "pear": 5, "banana": -1, "zebra": -1} {
+		if got := nameTreeLookup(root, key).CoerceInt64(-1); got != want {
+			t.Errorf("lookup %s = %d, want %d", key, got, want)
+		}
+	}
+}
+
+func TestNumberTree(t *testing.T) {
+	r := openPDF(t, treePDF(
+		"<</Kids [3 0 R 4 0 R]>>",
+		"<</Limits [0 9] /Nums [0 (zero) 9 (nine)]>>",
+		"<</Limits [10 20] /Nums [10 (ten) 20 (twenty)]>>",
+	))
+	root := object(r, 2)
+	for key, want := range map[int64]string{0: "zero", 9: "nine", 20: "twenty", 5: "", 30: ""} {
+		if got := numTreeLookup(root, key).CoerceString(""); got != want {
+			t.Errorf("lookup %d = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// TestNameTreeCycle walks trees whose Kids refer to the nodes above
+// them, or to one node many times, which must visit each node once.
+func TestNameTreeCycle(t *testing.T) {
+	// Node 3 lists node 4 twice, and so on: without keeping track
+	// of the nodes visited, 2^31 visits of the last.
+	nodes := []string{"<</Kids [2 0 R 2 0 R 3 0 R] /Names [(root) 0] /Nums [0 (root)]>>"}
+	for i := 3; i < 34; i++ {
+		nodes = append(nodes, fmt.Sprintf("<</Kids [%d 0 R %d 0 R]>>", i+1, i+1))
+	}
+	nodes = append(nodes, "<</Kids [2 0 R 34 0 R] /Names [(leaf) 1] /Nums [1 (leaf)]>>")
+	r := openPDF(t, treePDF(nodes...))
+	root := object(r, 2)
+	if got, want := treeKeys(root), "root leaf"; got != want {
+		t.Errorf("keys %s, want %s", got, want)
+	}
+	if got := nameTreeLookup(root, "leaf").CoerceInt64(-1); got != 1 {
+		t.Errorf("lookup leaf = %d, want 1", got)
+	}
+	if got := nameTreeLookup(root, "missing"); got.Kind() != Null {
+		t.Errorf("lookup missing = %v, want null", got)
+	}
+	if got := numTreeLookup(root, 1).CoerceString(""); got != "leaf" {
+		t.Errorf("lookup 1 = %q, want leaf", got)
+	}
+	if got := numTreeLookup(root, 2); got.Kind() != Null {
+		t.Errorf("lookup 2 = %v, want null", got)
+	}
+}
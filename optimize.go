@@ -0,0 +1,229 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Writing a document smaller than it was read.
+
+package pdf
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+)
+
+// An OptimizeReport describes what Optimize did.
+type OptimizeReport struct {
+	OriginalSize int64 // size of the file read
+	Size         int64 // size of the file written
+	Objects      int   // objects written, not counting object streams
+	Orphans      int   // objects of the file read not written, as nothing used them
+	Duplicates   int   // objects dropped in favor of identical ones
+	Compressed   int   // streams that had no filter, now compressed
+	Packed       int   // objects packed into object streams
+}
+
+// Saved returns the number of bytes saved, which is negative
+// if the file written is larger than the file read.
+func (rep OptimizeReport) Saved() int64 {
+	return rep.OriginalSize - rep.Size
+}
+
+// Optimize writes the document, with any changes made to it, to w as
+// Save does, making the file smaller where it can. Objects not used
+// by the document are dropped, as by Save; streams without a filter
+// are compressed with Flate; identical streams and identical fonts,
+// font descriptors, encodings, graphics states, patterns and shadings,
+// such as the copies of a font or image that merged documents often
+// hold, are written once; and the objects other than streams are
+// packed into compressed object streams. Optimize returns a report
+// of the savings.
+func (r *Reader) Optimize(w io.Writer) (rep OptimizeReport, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("pdf: optimizing: %v", e)
+		}
+	}()
+	wr := NewWriter(w)
+	wr.ObjectStreams = true
+	c := newObjCopier(r, wr)
+	var roots []pdfobject
+	for _, key := range savedTrailerKeys {
+		roots = append(roots, r.trailerEntry(key))
+	}
+	reached := c.dedupe(roots)
+	if err := c.save(); err != nil {
+		return rep, err
+	}
+
+	rep.OriginalSize = r.end
+	rep.Size = wr.offset
+	rep.Objects = len(c.refs)
+	rep.Duplicates = len(c.same)
+	rep.Compressed = wr.compressed
+	rep.Packed = wr.packed
+	for _, x := range r.xref {
+		if x.ptr == (pdfobjptr{}) || !x.inStream && x.offset == 0 || reached[x.ptr] {
+			continue
+		}
+		switch r.resolve(pdfobjptr{}, x.ptr).Key("Type").CoerceName("") {
+		case "ObjStm", "XRef":
+			// Part of the file's structure, not the document.
+		default:
+			rep.Orphans++
+		}
+	}
+	return rep, nil
+}
+
+// maxDedupePasses limits the passes dedupe makes over the objects.
+// Each pass finds the objects made identical by those merged in the
+// pass before, such as the font dictionaries of identical font files,
+// so a few passes find nearly all.
+const maxDedupePasses = 8
+
+// dedupe finds the objects reachable from roots that are identical to
+// others, recording them in c.same so that only one of each is copied.
+// It returns the objects reachable from roots.
+func (c *objCopier) dedupe(roots []pdfobject) map[pdfobjptr]bool {
+	objs, reached := c.reachable(roots)
+	c.same = make(map[pdfobjptr]pdfobjptr)
+	data := make(map[pdfobjptr][sha256.Size]byte) // digests of the stream data
+	for pass := 0; pass < maxDedupePasses; pass++ {
+		first := make(map[[sha256.Size]byte]pdfobjptr)
+		merged := false
+		for _, ptr := range objs {
+			if _, ok := c.same[ptr]; ok {
+				continue
+			}
+			v := c.r.resolve(pdfobjptr{}, ptr)
+			if !dedupable(v) {
+				continue
+			}
+			b := []byte{}
+			if strm, ok := v.data.(pdfstream); ok {
+				sum, ok := data[ptr]
+				if !ok {
+					h := sha256.New()
+					if _, err := io.Copy(h, v.decode(nil)); err != nil {
+						panic(fmt.Errorf("reading stream %d %d R: %v", ptr.id, ptr.gen, err))
+					}
+					h.Sum(sum[:0])
+					data[ptr] = sum
+				}
+				hdr := make(pdfdict, len(strm.hdr))
+				for k, x := range strm.hdr {
+					if k != "Length" {
+						hdr[k] = x
+					}
+				}
+				b = append(appendObject(b, c.canonical(hdr)), sum[:]...)
+			} else {
+				b = appendObject(b, c.canonical(v.data))
+			}
+			sum := sha256.Sum256(b)
+			if p, ok := first[sum]; ok {
+				c.same[ptr] = p
+				merged = true
+			} else {
+				first[sum] = ptr
+			}
+		}
+		if !merged {
+			break
+		}
+	}
+	return reached
+}
+
+// reachable returns the objects reachable from roots,
+// in the order they are reached, and as a set.
+func (c *objCopier) reachable(roots []pdfobject) ([]pdfobjptr, map[pdfobjptr]bool) {
+	var objs []pdfobjptr
+	seen := make(map[pdfobjptr]bool)
+	var visit func(x pdfobject)
+	visit = func(x pdfobject) {
+		switch x := x.(type) {
+		case pdfobjptr:
+			if !seen[x] {
+				seen[x] = true
+				objs = append(objs, x)
+			}
+		case pdfstream:
+			visit(x.ptr)
+		case pdfarray:
+			for _, y := range x {
+				visit(y)
+			}
+		case pdfdict:
+			for _, y := range x {
+				visit(y)
+			}
+		}
+	}
+	for _, x := range roots {
+		visit(x)
+	}
+	for i := 0; i < len(objs); i++ {
+		switch x := c.r.resolve(pdfobjptr{}, objs[i]).data.(type) {
+		case pdfstream:
+			visit(x.hdr)
+		default:
+			visit(x)
+		}
+	}
+	return objs, seen
+}
+
+// dedupable reports whether the object v may be replaced by an
+// identical one. Objects whose identity matters, such as pages,
+// annotations and form fields, which the document lists and which
+// refer back to their parents, are never replaced.
+func dedupable(v Value) bool {
+	switch v.Kind() {
+	case Stream:
+		return true
+	case Dict:
+		switch v.Key("Type").CoerceName("") {
+		case "Font", "FontDescriptor", "Encoding", "ExtGState", "Pattern", "Shading":
+			return true
+		}
+	}
+	return false
+}
+
+// canonical returns a copy of the direct object x with its references
+// replaced by those to the objects chosen among identical ones.
+func (c *objCopier) canonical(x pdfobject) pdfobject {
+	switch x := x.(type) {
+	case pdfobjptr:
+		return c.canon(x)
+	case pdfstream:
+		return c.canon(x.ptr)
+	case pdfarray:
+		y := make(pdfarray, len(x))
+		for i, v := range x {
+			y[i] = c.canonical(v)
+		}
+		return y
+	case pdfdict:
+		y := make(pdfdict, len(x))
+		for k, v := range x {
+			y[k] = c.canonical(v)
+		}
+		return y
+	}
+	return x
+}
+
+// canon returns the object chosen to replace the object ptr
+// and those identical to it.
+func (c *objCopier) canon(ptr pdfobjptr) pdfobjptr {
+	for {
+		p, ok := c.same[ptr]
+		if !ok {
+			return ptr
+		}
+		ptr = p
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	// Each page has its own copy of the same embedded font,
+	// and object 13 is used by nothing.
+	font := strings.Repeat("%!PS-AdobeFont-1.0: Test\n", 100)
+	pad := strings.Repeat("q Q\n", 50)
+	data := buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources <</Font <</F1 7 0 R>>>> /Contents 5 0 R>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources <</Font <</F1 8 0 R>>>> /Contents 6 0 R>>",
+		stream("", pad+show(72, 700, "one")),
+		stream("", pad+show(72, 700, "two")),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Test /Encoding /WinAnsiEncoding /FontDescriptor 9 0 R>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Test /Encoding /WinAnsiEncoding /FontDescriptor 10 0 R>>",
+		"<</Type /FontDescriptor /FontName /Test /Flags 32 /FontFile 11 0 R>>",
+		"<</Type /FontDescriptor /FontName /Test /Flags 32 /FontFile 12 0 R>>",
+		stream("", font),
+		stream("", font),
+		"<</Unused true>>",
+	)
+	r := openPDF(t, data)
+	var buf bytes.Buffer
+	rep, err := r.Optimize(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := OptimizeReport{
+		OriginalSize: int64(len(data)),
+		Size:         int64(buf.Len()),
+		Objects:      9,
+		Orphans:      1,
+		Duplicates:   3, // the font, its descriptor and its file
+		Compressed:   3, // the contents and the font file
+		Packed:       6, // the catalog, page tree, pages, font and descriptor
+	}
+	if rep != want {
+		t.Errorf("report %+v, want %+v", rep, want)
+	}
+	if rep.Saved() <= 0 {
+		t.Errorf("Optimize saved %d bytes", rep.Saved())
+	}
+
+	r = openPDF(t, buf.Bytes())
+	if typ := r.Trailer.Key("Type").CoerceName(""); typ != "XRef" {
+		t.Errorf("trailer Type %q, want a cross-reference stream", typ)
+	}
+	f1, f2 := r.Page(1).Resources().Key("Font").Key("F1"), r.Page(2).Resources().Key("Font").Key("F1")
+	if f1.Ref() != f2.Ref() {
+		t.Errorf("pages use fonts %v and %v, want one", f1.Ref(), f2.Ref())
+	}
+	for i, want := range []string{"one", "two"} {
+		if s, err := r.Page(i + 1).GetPlainText(); err != nil || s != want {
+			t.Errorf("page %d text %q, %v, want %q", i+1, s, err, want)
+		}
+	}
+	var ff bytes.Buffer
+	ff.ReadFrom(f1.Key("FontDescriptor").Key("FontFile").Reader())
+	if ff.String() != font {
+		t.Errorf("font file changed: %d bytes, want %d", ff.Len(), len(font))
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+// outlineString returns the titles of o's descendants, with children
+// in parentheses after their parent.
+func outlineString(o Outline) string {
+	var list []string
+	for _, c := range o.Child {
+		s := c.Title
+		if len(c.Child) > 0 {
+			s += "(" + outlineString(c) + ")"
+		}
+		list = append(list, s)
+	}
+	return strings.Join(list, " ")
+}
+
+func TestOutline(t *testing.T) {
+	// The outline dictionary is object 5; its items start at 6.
+	outline := func(items ...string) []byte {
+		return buildPDF("", append([]string{
+			"<</Type /Catalog /Pages 2 0 R /Outlines 5 0 R>>",
+			"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+			"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R>>",
+			stream("", ""),
+			"<</Type /Outlines /First 6 0 R /Count 3>>",
+		}, items...)...)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"tree", outline(
+			"<</Title (One) /Parent 5 0 R /Next 7 0 R /First 8 0 R /Count 1 /Dest [3 0 R /Fit]>>",
+			"<</Title (Two) /Parent 5 0 R /Prev 6 0 R /C [1 0 0] /F 3>>",
+			"<</Title (One.One) /Parent 6 0 R>>",
+		), "One(One.One) Two"},
+		{"Next to itself", outline(
+			"<</Title (One) /Parent 5 0 R /Next 6 0 R>>",
+		), "One"},
+		{"First to itself", outline(
+			"<</Title (One) /Parent 5 0 R /First 6 0 R /Next 7 0 R>>",
+			"<</Title (Two) /Parent 5 0 R>>",
+		), "One Two"},
+		{"First to the root", outline(
+			"<</Title (One) /Parent 5 0 R /First 5 0 R>>",
+		), "One"},
+		{"Next to an earlier item", outline(
+			"<</Title (One) /Parent 5 0 R /Next 7 0 R>>",
+			"<</Title (Two) /Parent 5 0 R /Next 6 0 R>>",
+		), "One Two"},
+		// Each item's children are the next item twice over, which
+		// would make a tree of 2^64 items.
+		{"shared children", outline(
+			"<</Title (A) /First 7 0 R /Next 7 0 R>>",
+			"<</Title (B) /First 8 0 R /Next 8 0 R>>",
+			"<</Title (C) /First 9 0 R /Next 9 0 R>>",
+			"<</Title (D)>>",
+		), "A(B(C(D)))"},
+	}
+	for _, tt := range tests {
+		o := openPDF(t, tt.data).Outline()
+		if got := outlineString(o); got != tt.want {
+			t.Errorf("%s: outline %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	o := openPDF(t, tests[0].data).Outline()
+	one, two := o.Child[0], o.Child[1]
+	if !one.Open || one.Dest.Page != 1 || one.Dest.Fit != "Fit" {
+		t.Errorf("One: open %v, destination %+v", one.Open, one.Dest)
+	}
+	if two.Color != [3]float64{1, 0, 0} || !two.Bold || !two.Italic {
+		t.Errorf("Two: color %v, bold %v, italic %v", two.Color, two.Bold, two.Italic)
+	}
+}
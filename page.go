@@ -6,8 +6,12 @@ package pdf
 
 import (
 	"fmt"
+	"image"
+	"io"
 	"math"
+	"slices"
 	"strings"
+	"sync/atomic"
 )
 
 // A Page represent a single page in a PDF file.
@@ -15,87 +19,121 @@ import (
 type Page struct {
 	V         Value
 	fontcache map[string]Font
+	entry     *pageEntry // the page's entry in the page index, if it came from there
 }
 
+// Page returns the page for the given page number.
+// Page numbers are indexed starting at 1, not 0.
+// If the page is not found, Page returns a Page with p.V.IsNull().
+func (r *Reader) Page(num int) Page {
+	idx := r.pageIndex()
+	if num < 1 || num > len(idx.pages) {
+		return Page{}
+	}
+	e := &idx.pages[num-1]
+	return Page{V: e.v, fontcache: map[string]Font{}, entry: e}
+}
 
+// NumPage returns the number of pages in the PDF file.
+func (r *Reader) NumPage() int {
+	return len(r.pageIndex().pages)
+}
 
-func (r *Reader) Page(num int) Page {
-	num-- // now 0-indexed
-	page := r.Trailer.Key("Root").Key("Pages")
-    if page.err != nil{
-        return Page {}
-    }
+// A pageIndex lists the pages of a document in order, so that Page
+// need not walk the page tree. The Reader builds it on first use and
+// discards it when the document changes, as the pages may have.
+type pageIndex struct {
+	pages []pageEntry
+	stale atomic.Bool // whether the index has been discarded
+}
 
-    if page.Key("Type").CoerceString("") != "Pages"{
-        return Page {}
-    }
+// A pageEntry is a page listed in a pageIndex, with its attributes
+// in the order of inheritableKeys, set on the page or inherited.
+type pageEntry struct {
+	v     Value
+	attrs []Value
+	index *pageIndex
+}
 
-    //TODO: make this function recursive 
+// pageIndex returns the index of the pages of r, building it if need be.
+func (r *Reader) pageIndex() *pageIndex {
+	r.pageMu.Lock()
+	defer r.pageMu.Unlock()
+	if r.pages == nil {
+		r.pages = r.buildPageIndex()
+	}
+	return r.pages
 }
-// Page returns the page for the given page number.
-// Page numbers are indexed starting at 1, not 0.
-// If the page is not found, Page returns a Page with p.V.IsNull().
-func (r *Reader) Page_OLD(num int) Page {
-	num-- // now 0-indexed
-	page := r.Trailer.Key("Root").Key("Pages")
-    if page.err != nil{
-        return Page {}
-    }
-Search:
-	for {
-        if page.Key("Type").CoerceString("") != "Pages"{
-            break
-        }
-		count := page.Key("Count").CoerceInt64(-1)
-		if count < num {
-			return Page{}
+
+// uncachePages discards the index of the pages of r, as when an
+// object of the page tree may have changed.
+func (r *Reader) uncachePages() {
+	r.pageMu.Lock()
+	if r.pages != nil {
+		r.pages.stale.Store(true)
+		r.pages = nil
+	}
+	r.pageMu.Unlock()
+}
+
+// buildPageIndex lists the pages of r by walking the page tree,
+// visiting each node once however the tree is linked.
+func (r *Reader) buildPageIndex() *pageIndex {
+	idx := &pageIndex{}
+	seen := make(map[pdfobjptr]bool)
+	var walk func(node Value, attrs []Value, depth int)
+	walk = func(node Value, attrs []Value, depth int) {
+		own := make([]Value, len(inheritableKeys))
+		for i, key := range inheritableKeys {
+			if v := node.Key(key); v.Kind() != Null {
+				own[i] = v
+			} else {
+				own[i] = attrs[i]
+			}
 		}
-		kids := page.Key("Kids")
-        if kids.err != nil {
-            return Page{}
-        }
-		for i := 0; i < kids.Len(); i++ {
-			kid := kids.Index(i)
-            if kid.err != nil {
-               return Page{} 
-            }
-        
-			if kid.Key("Type").Name() == "Pages" {
-				c := int(kid.Key("Count").Int64())
-				if num < c {
-					page = kid
-					continue Search
-				}
-				num -= c
-				continue
+		switch node.Key("Type").CoerceName("") {
+		case "Pages":
+			if depth >= maxPageTreeDepth {
+				return
 			}
-			if kid.Key("Type").Name() == "Page" {
-				if num == 0 {
-					return Page{kid, map[string]Font{}}
+			kids := node.Key("Kids")
+			for i := 0; i < kids.Len(); i++ {
+				if ptr, ok := kids.entryRef(i); ok {
+					if seen[ptr] {
+						continue
+					}
+					seen[ptr] = true
 				}
-				num--
+				walk(kids.Index(i), own, depth+1)
+			}
+		case "Page":
+			if depth > 0 {
+				idx.pages = append(idx.pages, pageEntry{v: node, attrs: own, index: idx})
 			}
 		}
-		break
 	}
-	return Page{}
-}
-
-// NumPage returns the number of pages in the PDF file.
-func (r *Reader) NumPage() int {
-    num, _ := r.Trailer().Int("Root", "Pages", "Count")
-	return num
+	root := r.Trailer.Key("Root")
+	if ptr, ok := root.entryRef("Pages"); ok {
+		seen[ptr] = true
+	}
+	walk(root.Key("Pages"), make([]Value, len(inheritableKeys)), 0)
+	return idx
 }
 
-func (p Page) findInherited(key string) (Value, error) {
-	for v := p.V; v.Kind() != Null; v, _ = v.Key("Parent") {
-        r, err := v.Key(key)
-	    if err != nil {
-            return Value{}, err
-        }
-        return r, nil
+func (p Page) findInherited(key string) Value {
+	if e := p.entry; e != nil && !e.index.stale.Load() {
+		if i := slices.Index(inheritableKeys, key); i >= 0 {
+			return e.attrs[i]
+		}
 	}
-	return Value{}, nil
+	v := p.V
+	for depth := 0; depth <= maxPageTreeDepth && v.Kind() != Null; depth++ {
+		if r := v.Key(key); r.Kind() != Null {
+			return r
+		}
+		v = v.Key("Parent")
+	}
+	return Value{}
 }
 
 func (p Page) MediaBox() Value {
@@ -106,11 +144,67 @@ func (p Page) CropBox() Value {
 	return p.findInherited("CropBox")
 }
 
+// cropBox returns the page's crop box, which defaults to the media box.
+// If neither is present, the page is treated as unbounded.
+func (p Page) cropBox() Rectangle {
+	for _, key := range []string{"CropBox", "MediaBox"} {
+		if box := p.findInherited(key); box.Len() == 4 {
+			return rectValue(box)
+		}
+	}
+	inf := math.Inf(1)
+	return Rectangle{Point{-inf, -inf}, Point{inf, inf}}
+}
+
 // Resources returns the resources dictionary associated with the page.
 func (p Page) Resources() Value {
 	return p.findInherited("Resources")
 }
 
+// RawContents returns the page's content streams (the /Contents entry),
+// in the order they are to be interpreted.
+// A page with a single content stream returns a one-element slice.
+func (p Page) RawContents() []Value {
+	var streams []Value
+	contents := p.V.Key("Contents")
+	switch contents.Kind() {
+	case Array:
+		for i := 0; i < contents.Len(); i++ {
+			if strm := contents.Index(i); strm.Kind() == Stream {
+				streams = append(streams, strm)
+			}
+		}
+	case Stream:
+		streams = append(streams, contents)
+	}
+	return streams
+}
+
+// ContentsReader returns the decoded data of all the page's content streams
+// as a single stream, separated by white space as PDF 32000-1:2008 §7.8.2 requires,
+// so that callers can run their own content stream interpreters.
+func (p Page) ContentsReader() io.Reader {
+	var rds []io.Reader
+	for i, strm := range p.RawContents() {
+		if i > 0 {
+			rds = append(rds, strings.NewReader("\n"))
+		}
+		rds = append(rds, strm.Reader())
+	}
+	return io.MultiReader(rds...)
+}
+
+// Thumbnail returns the page's thumbnail image (the /Thumb entry).
+// If the page has no thumbnail, Thumbnail returns a nil image and a nil error.
+// The undecoded thumbnail stream remains available as p.V.Key("Thumb").
+func (p Page) Thumbnail() (image.Image, error) {
+	thumb := p.V.Key("Thumb")
+	if thumb.Kind() == Null {
+		return nil, nil
+	}
+	return decodeImage(thumb)
+}
+
 // Fonts returns a list of the fonts associated with the page.
 /*func (p Page) Fonts() []string {
 	return p.Resources().Key("Font").Keys()
@@ -122,18 +216,24 @@ func (p Page) Font(name string) Font {
 	var f Font
 	f, ok := p.fontcache[name]
 	if !ok {
-		f = FontFromValue(p.Resources().Key("Font").Key(name))
+		f = fontEntry(p.Resources().Key("Font"), name)
 		p.fontcache[name] = f
 	}
 	return f
 }
 
-type matrix [3][3]float64
+// A Matrix is a PDF transformation matrix [a b c d e f],
+// stored in the 3x3 form of PDF 32000-1:2008, §8.3.3:
+//
+//	a b 0
+//	c d 0
+//	e f 1
+type Matrix [3][3]float64
 
-var ident = matrix{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+var ident = Matrix{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
 
-func (x matrix) mul(y matrix) matrix {
-	var z matrix
+func (x Matrix) mul(y Matrix) Matrix {
+	var z Matrix
 	for i := 0; i < 3; i++ {
 		for j := 0; j < 3; j++ {
 			for k := 0; k < 3; k++ {
@@ -154,15 +254,66 @@ type Text struct {
 	Y             float64          // the Y coordinate, in points, increasing bottom to top
 	W             float64          // the width of the text, in points
 	S             []PositionedChar // the actual UTF-8 text
+	FillColor     Color            // the color used to fill the glyphs
+	StrokeColor   Color            // the color used to stroke the glyph outlines
+	RenderMode    int              // the text rendering mode (Tr); 0 is ordinary filled text
+	Invisible     bool             // the rendering mode paints nothing (3, or 7 which only clips)
+	Bold          bool             // the font is bold, by weight, descriptor flags or name
+	Italic        bool             // the font is italic or oblique, by descriptor or name
+	Underline     bool             // a thin horizontal rule runs just below the text
+	Clip          Rectangle        // bounding box of the clipping region in effect
+	MarkedContent []MarkedContent  // enclosing marked-content sequences, outermost first
+	FillAlpha     float64          // opacity of the fill, from 0 (transparent) to 1
+	StrokeAlpha   float64          // opacity of the stroke
+	BlendMode     string           // blend mode, such as Normal or Multiply
 }
 
+// A Path is a single segment of a painted path, in device space:
+// a straight line (Kind "line", two Points), a cubic Bezier curve
+// ("bezier", four Points) or a rectangle ("rect", two opposite corners).
+// Segments with the same PathID were painted together by one operator,
+// and segments with the same Subpath within it are connected.
 type Path struct {
-	Kind      string
-	Points    []Point
-	EndPoint Point
-	JoinStyle int
-	CapStyle  int
-	LineWidth float64
+	Kind        string
+	Points      []Point
+	EndPoint    Point
+	JoinStyle   int
+	CapStyle    int
+	LineWidth   float64
+	Op          string    // painting operator: S, s, f, F, f*, B, B*, b, b*, or n for none
+	PathID      int       // index of the painted path on the page, starting at 0
+	Subpath     int       // index of the subpath within the painted path, starting at 0
+	Closed      bool      // the subpath is closed (by h, s, b, b* or re)
+	Filled      bool      // the path is filled (f, F, f*, B, B*, b, b*)
+	Stroked     bool      // the path is stroked (S, s, B, B*, b, b*)
+	EvenOdd     bool      // filling or clipping uses the even-odd rule instead of nonzero winding
+	ClipPath    bool      // the path also sets the clipping path (W or W*); with neither Filled nor Stroked, it only clips
+	Clip        Rectangle // bounding box of the clipping region in effect
+	FillColor   Color     // the color used if the path is filled
+	StrokeColor Color     // the color used if the path is stroked
+	MiterLimit  float64   // the miter limit for mitered joins
+	DashArray   []float64 // the dash pattern's dash and gap lengths; empty for a solid line
+	DashPhase   float64   // the offset into the dash pattern at which stroking starts
+	FillAlpha   float64   // opacity of the fill, from 0 (transparent) to 1
+	StrokeAlpha float64   // opacity of the stroke
+	BlendMode   string    // blend mode, such as Normal or Multiply
+}
+
+// A Rect is an axis-aligned rectangle drawn on the page with the re operator,
+// in device space. Tables and form fields are typically drawn this way.
+type Rect struct {
+	Min, Max    Point   // lower left and upper right corners
+	Filled      bool    // the rectangle is filled
+	Stroked     bool    // the rectangle's outline is stroked
+	FillColor   Color   // the color used if the rectangle is filled
+	StrokeColor Color   // the color used if the rectangle is stroked
+	LineWidth   float64 // the width of the outline, in points
+	Clip        Rectangle
+}
+
+// rectOf returns the Rect with opposite corners a and b.
+func rectOf(a, b Point) Rect {
+	return Rect{Min: Point{min(a.X, b.X), min(a.Y, b.Y)}, Max: Point{max(a.X, b.X), max(a.Y, b.Y)}}
 }
 
 // A Point represents an X, Y pair.
@@ -171,386 +322,186 @@ type Point struct {
 	Y float64
 }
 
-// Content describes the basic content on a page: the text and any drawn rectangles.
-type Content struct {
-	Text []Text
-	//Rect []Rect
-	Paths []Path
-}
-
-type gstate struct {
-	Tc        float64
-	Tw        float64
-	Th        float64
-	Tl        float64
-	Tf        Font
-	Tfs       float64
-	Tmode     int
-	Trise     float64
-	Tm        matrix
-	Tlm       matrix
-	Trm       matrix
-	CTM       matrix
-	Px        float64
-	Py        float64
-	JoinStyle int
-	CapStyle  int
-	LineWidth float64
+// A Rectangle represents a rectangle on the page, in points.
+// Min is the lower left corner and Max the upper right.
+type Rectangle struct {
+	Min Point
+	Max Point
 }
 
-// Content returns the page's content.
-func (p Page) Content() Content {
-	var text []Text
+// Intersects reports whether r and s have a non-empty intersection.
+func (r Rectangle) Intersects(s Rectangle) bool {
+	return r.Min.X < s.Max.X && s.Min.X < r.Max.X &&
+		r.Min.Y < s.Max.Y && s.Min.Y < r.Max.Y
+}
 
-	var g = gstate{
-		Th:  1,
-		CTM: ident,
+// unionRect returns the smallest rectangle containing r and s.
+func unionRect(r, s Rectangle) Rectangle {
+	return Rectangle{
+		Point{min(r.Min.X, s.Min.X), min(r.Min.Y, s.Min.Y)},
+		Point{max(r.Max.X, s.Max.X), max(r.Max.Y, s.Max.Y)},
 	}
+}
 
-	var paths []Path
-	var gstack []gstate
-	var streams []Value
-
-	if p.V.Key("Contents").Kind() == Array {
-		for i := 0; i < p.V.Key("Contents").Len(); i++ {
-			streams = append(streams, p.V.Key("Contents").Index(i))
-		}
-	} else if p.V.Key("Contents").Kind() == Stream {
-		streams = append(streams, p.V.Key("Contents"))
+// intersectRect returns the largest rectangle contained in both r and s.
+// If they do not intersect, the result is empty (Min == Max).
+func intersectRect(r, s Rectangle) Rectangle {
+	x := Rectangle{
+		Point{max(r.Min.X, s.Min.X), max(r.Min.Y, s.Min.Y)},
+		Point{min(r.Max.X, s.Max.X), min(r.Max.Y, s.Max.Y)},
 	}
-
-	// Estimate amount of paths based on heuristic
-	sl := int64(0)
-	for i := 0; i < len(streams); i++ {
-		sl += streams[len(streams)-1].Key("Length").CoerceInt64(0)
+	if x.Min.X > x.Max.X || x.Min.Y > x.Max.Y {
+		return Rectangle{x.Min, x.Min}
 	}
+	return x
+}
 
-	paths = make([]Path, sl/10)
-	text = make([]Text, sl/100)
-
-	for i := 0; i < len(streams); i++ {
-		strm := streams[i]
-
-		showText := func(s string) {
-			//if g.Tf.V.Key("Name").Kind() == 0 {
-			//	fmt.Println(g)
-			//}
-			decoded := g.Tf.Decode(s)
-
-			for _, ch := range decoded {
-				if string(ch.Text) != " " {
-					break
-				}
-				w0 := ch.Width / 1000
-				if w0 < 0.05 {
-					//fmt.Println("Fonth width small?", w0, "\t", string(ch.Text), "\t", decoded)
-				}
-				//fmt.Println(ch.Length())
-				tx := (w0*g.Tfs + g.Tc) * g.Th
-				if string(ch.Text) == string(" ") {
-					tx += g.Tw * g.Th
-				}
-				tx = tx * g.Th
-				g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {tx, 0, 1}}.mul(g.Tm)
-			}
+// rectValue returns the PDF rectangle [llx lly urx ury] in v,
+// normalized so that Min is the lower left corner.
+func rectValue(v Value) Rectangle {
+	x0, y0 := v.Index(0).CoerceFloat64(0), v.Index(1).CoerceFloat64(0)
+	x1, y1 := v.Index(2).CoerceFloat64(0), v.Index(3).CoerceFloat64(0)
+	return Rectangle{Point{min(x0, x1), min(y0, y1)}, Point{max(x0, x1), max(y0, y1)}}
+}
 
-			Trm := matrix{{g.Tfs * g.Th, 0, 0}, {0, g.Tfs, 0}, {0, g.Trise, 1}}.mul(g.Tm).mul(g.CTM)
+// Content describes the basic content on a page: the text and any drawn rectangles.
+type Content struct {
+	Text         []Text
+	Rect         []Rect // axis-aligned rectangles, which are also reported in Paths
+	Paths        []Path
+	Shadings     []Shading
+	Images       []Image
+	InlineImages []InlineImage
+	Warnings     []Warning // problems in the content streams that were worked around
+}
 
-			f := g.Tf.BaseFont()
-			if i := strings.Index(f, "+"); i >= 0 {
-				f = f[i+1:]
-			}
+// Content returns the page's content.
+// Content in all optional content groups (layers) is included,
+// whether or not a viewer would show it.
+// Malformed content is skipped and described in the result's Warnings;
+// the error is non-nil only if the page cannot be interpreted at all.
+func (p Page) Content() (Content, error) {
+	return p.ContentWithLayers(nil)
+}
 
-			fw := g.Tf.FontWeight()
+// ContentWithLayers returns the page's content, skipping content in
+// hidden layers. The layers map gives the visibility of layers by their Ref;
+// layers not in the map are shown or hidden as in the document's
+// default configuration. A nil map includes all layers, like Content.
+func (p Page) ContentWithLayers(layers map[ObjRef]bool) (Content, error) {
+	var c Content
+	err := p.appendContent(&c, layers)
+	return c, err
+}
 
-			fontsize := math.Sqrt(Trm[0][0]*Trm[0][0] + Trm[1][0]*Trm[1][0])
-			rotationAngle := math.Atan2(Trm[1][0], Trm[0][0]) * 180 / math.Pi
+// appendContent appends the page's content to c, as ContentWithLayers
+// returns it.
+func (p Page) appendContent(c *Content, layers map[ObjRef]bool) error {
+	err := p.eachContent(layers, func(item ContentItem) error {
+		switch item := item.(type) {
+		case Text:
+			c.Text = append(c.Text, item)
+		case Rect:
+			c.Rect = append(c.Rect, item)
+		case Path:
+			c.Paths = append(c.Paths, item)
+		case Shading:
+			c.Shadings = append(c.Shadings, item)
+		case Image:
+			c.Images = append(c.Images, item)
+		case InlineImage:
+			c.InlineImages = append(c.InlineImages, item)
+		case Warning:
+			c.Warnings = append(c.Warnings, item)
+		}
+		return nil
+	})
+	markUnderlines(c.Text, c.Paths)
+	return err
+}
 
-			text = append(text, Text{f, fontsize, rotationAngle, fw, Trm[2][0], Trm[2][1], Trm[0][0], decoded})
+// A ContentItem is a single item of page content, as passed to
+// the EachContent handler: a Text, Rect, Path, Shading, Image, InlineImage,
+// or a Warning about malformed content that was skipped.
+type ContentItem interface {
+	contentItem()
+}
 
-			skip := true
-			for _, ch := range decoded {
-				if skip && string(ch.Text) == " " {
-					continue
-				} else {
-					skip = false
-				}
-				w0 := ch.Width
-				tx := w0/1000*g.Tfs + g.Tc
-				for _, ch3 := range string(ch.Text) {
-					if string(ch3) == " " {
-						tx += g.Tw
-					}
-				}
-				tx *= g.Th
-				ty := 0.0
-				g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {tx, ty, 1}}.mul(g.Tm)
-			}
+func (Text) contentItem()        {}
+func (Rect) contentItem()        {}
+func (Path) contentItem()        {}
+func (Shading) contentItem()     {}
+func (Image) contentItem()       {}
+func (InlineImage) contentItem() {}
+func (Warning) contentItem()     {}
+
+// EachContent calls handler for each item of the page's content,
+// in the order it is drawn, without accumulating the whole page
+// in memory. If handler returns an error, EachContent stops and
+// returns that error.
+// The items are those of Content, except that Text.Underline is not set,
+// because it depends on paths that may not have been drawn yet.
+func (p Page) EachContent(handler func(item ContentItem) error) error {
+	return p.eachContent(nil, handler)
+}
 
+func (p Page) eachContent(layers map[ObjRef]bool, handler func(item ContentItem) error) error {
+	if p.V.Kind() != Dict {
+		return fmt.Errorf("malformed PDF: page is not a dictionary")
+	}
+	var in Interpreter
+	emit := func(item ContentItem) {
+		if err := handler(item); err != nil {
+			in.stop(err)
 		}
+	}
+	in = Interpreter{
+		Layers:        layers,
+		OnWarning:     func(w Warning) { emit(w) },
+		OnText:        func(t Text, g *GraphicsState) { emit(t) },
+		OnRect:        func(r Rect, g *GraphicsState) { emit(r) },
+		OnPath:        func(path Path, g *GraphicsState) { emit(path) },
+		OnShading:     func(s Shading, g *GraphicsState) { emit(s) },
+		OnImage:       func(img Image, g *GraphicsState) { emit(img) },
+		OnInlineImage: func(img InlineImage, g *GraphicsState) { emit(img) },
+	}
+	return in.Run(p)
+}
 
-		Interpret(strm, func(stk *Stack, op string) {
-			var x, y, w, h float64
-			var x1, x2, x3, x4, y1, y2, y3, y4 float64
-			n := stk.Len()
-			args := make([]Value, n)
-			for i := n - 1; i >= 0; i-- {
-				args[i] = stk.Pop()
+// markUnderlines sets Underline on each Text that has a thin horizontal
+// line or rectangle just below its baseline covering most of its width.
+func markUnderlines(text []Text, paths []Path) {
+	for i := range text {
+		t := &text[i]
+		if t.FontSize <= 0 {
+			continue
+		}
+		x0, x1 := t.X, t.X+textWidth(*t)
+		for _, p := range paths {
+			if len(p.Points) < 2 || (p.Kind != "line" && p.Kind != "rect") {
+				continue
 			}
-			//if true {
-			//	fmt.Println(strings.Repeat("--", len(gstack)), op, "", args, "     ", g.CTM)
-			//}
-
-			switch op {
-			default:
-				fmt.Println(op, args)
-				panic("bad g.Tm")
-			case "y":
-				fallthrough
-			case "v":
-				g.Px, g.Py = args[2].CoerceFloat64(0), args[3].CoerceFloat64(0)
-			case "c":
-				x1, y1, x2, y2, x3, y3, x4, y4 = g.Px, g.Py, args[0].Float64(), args[1].Float64(), args[2].Float64(), args[3].Float64(), args[4].Float64(), args[5].Float64()
-				g.Px, g.Py = x4, y4
-
-				loc1 := matrix{{1, 0, 0}, {0, 1, 0}, {x1, y1, 1}}.mul(g.CTM)
-				loc2 := matrix{{1, 0, 0}, {0, 1, 0}, {x2, y2, 1}}.mul(g.CTM)
-				loc3 := matrix{{1, 0, 0}, {0, 1, 0}, {x3, y3, 1}}.mul(g.CTM)
-				loc4 := matrix{{1, 0, 0}, {0, 1, 0}, {x4, y4, 1}}.mul(g.CTM)
-
-				pt1 := Point{loc1[2][0], loc1[2][1]}
-				pt2 := Point{loc2[2][0], loc2[2][1]}
-				pt3 := Point{loc3[2][0], loc3[2][1]}
-				pt4 := Point{loc4[2][0], loc4[2][1]}
-
-				lw := math.Sqrt(g.CTM[0][0]*g.CTM[0][0] + g.CTM[1][0]*g.CTM[1][0])
-				paths = append(paths, Path{"bezier", []Point{pt1, pt2, pt3, pt4}, pt4, g.JoinStyle, g.CapStyle, lw * g.LineWidth})
-
-			case "cm": // update g.CTM
-				if len(args) != 6 {
-					panic("bad g.Tm")
-				}
-				var m matrix
-				for i := 0; i < 6; i++ {
-					m[i/2][i%2] = args[i].Float64()
-				}
-				m[2][2] = 1
-				g.CTM = m.mul(g.CTM)
-			case "gs": // set parameters from graphics state resource
-				gs := p.Resources().Key("ExtGState").Key(args[0].Name())
-				font := gs.Key("Font")
-				if font.Kind() == Array && font.Len() == 2 {
-					//fmt.Println("FONT", font)
-				}
-			case "l": // lineto
-				x, y = g.Px, g.Py
-				g.Px, g.Py = args[0].Float64(), args[1].Float64()
-				loc1 := matrix{{1, 0, 0}, {0, 1, 0}, {x, y, 1}}.mul(g.CTM)
-				loc2 := matrix{{1, 0, 0}, {0, 1, 0}, {g.Px, g.Py, 1}}.mul(g.CTM)
-
-				pt1 := Point{loc1[2][0], loc1[2][1]}
-				pt2 := Point{loc2[2][0], loc2[2][1]}
-
-				lw := math.Sqrt(g.CTM[0][0]*g.CTM[0][0] + g.CTM[1][0]*g.CTM[1][0])
-				paths = append(paths, Path{"line", []Point{pt1, pt2}, pt2, g.JoinStyle, g.CapStyle, lw * g.LineWidth})
-
-			case "m": // moveto
-				g.Px, g.Py = args[0].Float64(), args[1].Float64()
-
-			case "re": // append rectangle to path
-				if len(args) != 4 {
-					panic("bad re")
-				}
-				x, y, w, h = args[0].Float64(), args[1].Float64(), args[2].Float64(), args[3].Float64()
-				lw := math.Sqrt(g.CTM[0][0]*g.CTM[0][0] + g.CTM[1][0]*g.CTM[1][0])
-				paths = append(paths, Path{"rect", []Point{{x, y}, {x + w, y + h}}, Point{x, y}, g.JoinStyle, g.CapStyle, lw * g.LineWidth})
-
-			case "q": // save graphics state
-				gstack = append(gstack, g)
-
-			case "Q": // restore graphics state
-				n := len(gstack) - 1
-				g = gstack[n]
-				gstack = gstack[:n]
-
-			case "BT": // begin text (reset text matrix and line matrix)
-				g.Tm = ident
-				g.Tlm = g.Tm
-			case "ET": // end text
-
-			case "T*": // move to start of next line
-				x := matrix{{1, 0, 0}, {0, 1, 0}, {0, -g.Tl, 1}}
-				g.Tlm = x.mul(g.Tlm)
-				g.Tm = g.Tlm
-
-			case "Tc": // set character spacing
-				if len(args) != 1 {
-					panic("bad g.Tc")
-				}
-				g.Tc = args[0].Float64()
-
-			case "TD": // move text position and set leading
-				if len(args) != 2 {
-					panic("bad Td")
-				}
-				g.Tl = -args[1].Float64()
-
-				fallthrough
-			case "Td": // move text position
-				if len(args) != 2 {
-					panic("bad Td")
-				}
-				tx := args[0].Float64()
-				ty := args[1].Float64()
-				x := matrix{{1, 0, 0}, {0, 1, 0}, {tx, ty, 1}}
-				g.Tlm = x.mul(g.Tlm)
-				g.Tm = g.Tlm
-
-			case "Tf": // set text font and size
-				if len(args) != 2 {
-					panic("bad TL")
-				}
-				f := args[0].Name()
-				g.Tf = p.Font(f)
-				g.Tfs = args[1].Float64()
-
-			case "\"": // set spacing, move to next line, and show text
-				if len(args) != 3 {
-					panic("bad \" operator")
-				}
-				g.Tw = args[0].Float64()
-				g.Tc = args[1].Float64()
-				args = args[2:]
-				fallthrough
-			case "'": // move to next line and show text
-				if len(args) != 1 {
-					panic("bad ' operator")
-				}
-				x := matrix{{1, 0, 0}, {0, 1, 0}, {0, -g.Tl, 1}}
-				g.Tlm = x.mul(g.Tlm)
-				g.Tm = g.Tlm
-				fallthrough
-			case "Tj": // show text
-				if len(args) != 1 {
-					panic("bad Tj operator")
-				}
-				showText(args[0].RawString())
-
-			case "TJ": // show text, allowing individual glyph positioning
-				v := args[0]
-				var tx float64
-				var rs string
-				w0 := 0.0
-				for i := 0; i < v.Len(); i++ {
-					x := v.Index(i)
-					if x.Kind() == String {
-						rs = x.RawString()
-						showText(rs)
-						w0 = 0.0
-						//for _, runeValue := range rs {
-						//	//fmt.Println("waaaa", string(runeValue), int(runeValue))
-						//	w0 += g.Tf.Width(int(runeValue)) / 1000
-						//}
-
-						strs := g.Tf.Decode(rs)
-						for _, s := range strs {
-							fmt.Print(string(s.Text))
-							//fmt.Print(s.Width)
-							/*for _, ch3 := range string(s.Text) {
-								if string(ch3) == " " {
-									w0 += g.Tw
-								}
-							}*/
-							//w0 += s.Width / 1000
-							//fmt.Println(s.Width)
-						}
-
-					} else {
-						tx = (w0 - x.Float64()/1000 + g.Tc) * g.Tfs * g.Th
-						g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {tx, 0, 1}}.mul(g.Tm)
-					}
-				}
-
-			case "TL": // set text leading
-				if len(args) != 1 {
-					panic("bad TL")
-				}
-				g.Tl = args[0].Float64()
-
-			case "Tm": // set text matrix and line matrix
-				if len(args) != 6 {
-					panic("bad g.Tm")
-				}
-				var m matrix
-				for i := 0; i < 6; i++ {
-					m[i/2][i%2] = args[i].Float64()
-				}
-				m[2][2] = 1
-				g.Tm = m
-				g.Tlm = m
-
-			case "Tr": // set text rendering mode
-				if len(args) != 1 {
-					panic("bad Tr")
-				}
-				g.Tmode = int(args[0].Int64())
-
-			case "Ts": // set text rise
-				if len(args) != 1 {
-					panic("bad Ts")
-				}
-				g.Trise = args[0].Float64()
-
-			case "Tw": // set word spacing
-				if len(args) != 1 {
-					panic("bad g.Tw")
-				}
-				g.Tw = args[0].Float64()
-
-			case "Tz": // set horizontal text scaling
-				if len(args) != 1 {
-					panic("bad Tz")
-				}
-				g.Th = args[0].Float64() / 100
-			case "W": // Set clipping path
-			case "Do": //?
-			case "W*": //?
-			case "f*": //?
-			case "": //something went wrong
-			case "d": //?
-			case "w": // Set line width
-				g.LineWidth = args[0].Float64()
-			case "j": // Set line join style
-				g.JoinStyle = int(args[0].Int64())
-			case "J": // Set line cap style
-				g.CapStyle = int(args[0].Int64())
-			case "n": //end path
-			case "RG": //Set RGB color
-			case "S": //stroke path
-			case "rg": //Set RGB color
-			case "M": //set miter limit
-			case "h": //close path
-			case "b": //close fill stroke path
-			case "cs": // set colorspace non-stroking
-			case "scn": // set color non-stroking
-			case "f": // fill
-			case "g": // setgray
-			case "G": //?
-			case "CS": //set color space
-			case "BMC": //
-			case "BDC": //marked content sequence
-			case "EMC": //end marked content
-			case "i": //??
-			case "s": //??
+			a, b := p.Points[0], p.Points[len(p.Points)-1]
+			if math.Abs(a.Y-b.Y) > 0.1*t.FontSize {
+				continue // not horizontal, or too thick for a rule
+			}
+			y := (a.Y + b.Y) / 2
+			if y > t.Y || y < t.Y-0.3*t.FontSize {
+				continue
 			}
-		})
+			lo, hi := math.Max(x0, math.Min(a.X, b.X)), math.Min(x1, math.Max(a.X, b.X))
+			if hi-lo >= 0.5*(x1-x0) {
+				t.Underline = true
+				break
+			}
+		}
 	}
-	return Content{text, paths}
 }
 
 // TextVertical implements sort.Interface for sorting
 // a slice of Text values in vertical order, top to bottom,
 // and then left to right within a line.
+// Baselines are compared exactly; SortByLine tolerates small differences.
 type TextVertical []Text
 
 func (x TextVertical) Len() int      { return len(x) }
@@ -579,22 +530,83 @@ func (x TextHorizontal) Less(i, j int) bool {
 // An Outline is a tree describing the outline (also known as the table of contents)
 // of a document.
 type Outline struct {
-	Title string    // title for this element
-	Child []Outline // child elements
+	Title  string     // title for this element
+	Child  []Outline  // child elements
+	Dest   Dest       // destination of the element, with Page 0 if it has none
+	Open   bool       // the children are shown rather than collapsed
+	Color  [3]float64 // RGB color of the title, with components in [0, 1]
+	Bold   bool       // the title is shown in bold
+	Italic bool       // the title is shown in italic
+
+	V Value // the outline item dictionary
 }
 
 // Outline returns the document outline.
 // The Outline returned is the root of the outline tree and typically has no Title itself.
 // That is, the children of the returned root are the top-level entries in the outline.
 func (r *Reader) Outline() Outline {
-	return buildOutline(r.Trailer().Key("Root").Key("Outlines"))
+	root := r.Trailer.Key("Root").Key("Outlines")
+	w := outlineWalk{seen: map[ObjRef]bool{root.Ref(): true}}
+	return w.build(root, 0)
+}
+
+// maxOutlineDepth and maxOutlineItems limit the outline tree built by
+// an outlineWalk, so that a malformed file cannot make it grow without
+// bound.
+const (
+	maxOutlineDepth = 64
+	maxOutlineItems = 100000
+)
+
+// An outlineWalk builds an outline tree, visiting each item once,
+// so that First or Next links that form a cycle end the walk there.
+type outlineWalk struct {
+	seen  map[ObjRef]bool
+	items int
 }
 
-func buildOutline(entry Value) Outline {
+// next returns the outline item linked to by the key entry of item,
+// and counts it, reporting false if there is none or it has been
+// visited before.
+func (w *outlineWalk) next(item Value, key string) (Value, bool) {
+	v := item.Key(key)
+	if v.Kind() != Dict || w.items >= maxOutlineItems {
+		return Value{}, false
+	}
+	if ptr, ok := item.entryRef(key); ok {
+		if w.seen[ObjRef{ptr.id, ptr.gen}] {
+			return Value{}, false
+		}
+		w.seen[ObjRef{ptr.id, ptr.gen}] = true
+	}
+	w.items++
+	return v, true
+}
+
+func (w *outlineWalk) build(entry Value, depth int) Outline {
 	var x Outline
-	x.Title = entry.Key("Title").Text()
-	for child := entry.Key("First"); child.Kind() == Dict; child = child.Key("Next") {
-		x.Child = append(x.Child, buildOutline(child))
+	x.V = entry
+	x.Title = entry.Key("Title").CoerceText("")
+	x.Open = entry.Key("Count").CoerceInt64(0) > 0
+	if c := floats(entry.Key("C")); len(c) == 3 {
+		x.Color = [3]float64{c[0], c[1], c[2]}
+	}
+	flags := entry.Key("F").CoerceInt64(0)
+	x.Italic = flags&1 != 0
+	x.Bold = flags&2 != 0
+
+	// The destination is given directly or by a go-to action.
+	d := entry.Key("Dest")
+	if a := entry.Key("A"); d.Kind() == Null && a.Key("S").CoerceName("") == "GoTo" {
+		d = a.Key("D")
+	}
+	x.Dest, _ = resolveDest(d)
+
+	if depth >= maxOutlineDepth {
+		return x
+	}
+	for child, ok := w.next(entry, "First"); ok; child, ok = w.next(child, "Next") {
+		x.Child = append(x.Child, w.build(child, depth+1))
 	}
 	return x
 }
@@ -0,0 +1,507 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// deflate returns data compressed for the FlateDecode filter.
+func deflate(data string) string {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	zw.Write([]byte(data))
+	zw.Close()
+	return b.String()
+}
+
+func TestThumbnail(t *testing.T) {
+	data := buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 /MediaBox [0 0 612 792]>>",
+		"<</Type /Page /Parent 2 0 R /Thumb 6 0 R>>",
+		"<</Type /Page /Parent 2 0 R /Thumb 7 0 R>>",
+		"<</Type /Page /Parent 2 0 R>>",
+		stream("/Width 2 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 8", "\xff\x00\x00\x00\x00\xff"),
+		stream("/Width 1 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode", deflate("\x00\x80")),
+	)
+	r := openPDF(t, data)
+
+	m, err := r.Page(1).Thumbnail()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := m.Bounds(); b.Dx() != 2 || b.Dy() != 1 {
+		t.Fatalf("page 1 thumbnail is %v, want 2×1", b)
+	}
+	for x, want := range []color.RGBA{{255, 0, 0, 255}, {0, 0, 255, 255}} {
+		if c := color.RGBAModel.Convert(m.At(x, 0)); c != want {
+			t.Errorf("page 1 thumbnail pixel %d is %v, want %v", x, c, want)
+		}
+	}
+
+	m, err = r.Page(2).Thumbnail()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := color.GrayModel.Convert(m.At(0, 1)); c != (color.Gray{0x80}) {
+		t.Errorf("page 2 thumbnail pixel (0, 1) is %v, want %v", c, color.Gray{0x80})
+	}
+
+	if m, err := r.Page(3).Thumbnail(); m != nil || err != nil {
+		t.Errorf("page 3 Thumbnail() = %v, %v; want nil, nil", m, err)
+	}
+}
+
+func TestRawContents(t *testing.T) {
+	data := buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 /MediaBox [0 0 612 792]>>",
+		"<</Type /Page /Parent 2 0 R /Contents [6 0 R 7 0 R 8 0 R]>>",
+		"<</Type /Page /Parent 2 0 R /Contents 6 0 R>>",
+		"<</Type /Page /Parent 2 0 R>>",
+		stream("", "q 1 0 0 1 0 0 cm"),
+		stream("/Filter /FlateDecode", deflate("0 0 10 10 re f")),
+		"(not a stream)",
+	)
+	r := openPDF(t, data)
+	tests := []struct {
+		page    int
+		streams int
+		data    string
+	}{
+		{1, 2, "q 1 0 0 1 0 0 cm\n0 0 10 10 re f"},
+		{2, 1, "q 1 0 0 1 0 0 cm"},
+		{3, 0, ""},
+	}
+	for _, tt := range tests {
+		p := r.Page(tt.page)
+		if n := len(p.RawContents()); n != tt.streams {
+			t.Errorf("page %d has %d content streams, want %d", tt.page, n, tt.streams)
+		}
+		b, err := io.ReadAll(p.ContentsReader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.data {
+			t.Errorf("page %d contents are %q, want %q", tt.page, b, tt.data)
+		}
+	}
+}
+
+func TestTextStyle(t *testing.T) {
+	data := pagePDF("<</Font <</F1 5 0 R /F2 6 0 R /F3 7 0 R>>>>",
+		"BT /F1 10 Tf 72 700 Td (plain) Tj ET\n"+
+			"q BT /F2 10 Tf 1 0 0 rg 72 680 Td (bold) Tj ET Q\n"+
+			"q BT /F3 10 Tf 0 0 0 1 k 0 1 0 RG 2 Tr 72 660 Td (italic) Tj ET Q\n"+
+			"BT /F1 10 Tf 72 640 Td (underlined) Tj ET 72 638 m 130 638 l S\n",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Custom /FontDescriptor 8 0 R>>",
+		"<</Type /FontDescriptor /FontName /Custom /Flags 96 /FontWeight 700 /ItalicAngle -12>>",
+	)
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	type style struct {
+		text                    string
+		bold, italic, underline bool
+		mode                    int
+		fill, stroke            string
+		fillComps, strokeComps  int
+	}
+	want := []style{
+		{"plain", false, false, false, 0, "DeviceGray", "DeviceGray", 1, 1},
+		{"bold", true, false, false, 0, "DeviceRGB", "DeviceGray", 3, 1},
+		{"italic", true, true, false, 2, "DeviceCMYK", "DeviceRGB", 4, 3},
+		{"underlined", false, false, true, 0, "DeviceGray", "DeviceGray", 1, 1},
+	}
+	if len(c.Text) != len(want) {
+		t.Fatalf("page has %d text fragments, want %d", len(c.Text), len(want))
+	}
+	for i, tx := range c.Text {
+		got := style{contentText(Content{Text: c.Text[i : i+1]}), tx.Bold, tx.Italic, tx.Underline, tx.RenderMode,
+			tx.FillColor.Space, tx.StrokeColor.Space, len(tx.FillColor.Components), len(tx.StrokeColor.Components)}
+		if got != want[i] {
+			t.Errorf("fragment %d is %+v, want %+v", i, got, want[i])
+		}
+	}
+	if r, g, b, ok := c.Text[1].FillColor.RGB(); !ok || r != 1 || g != 0 || b != 0 {
+		t.Errorf("bold text fill is %v %v %v, want red", r, g, b)
+	}
+	if comps := c.Text[2].FillColor.Components; comps[0] != 0 || comps[3] != 1 {
+		t.Errorf("italic text fill is %v, want [0 0 0 1]", comps)
+	}
+}
+
+func TestClip(t *testing.T) {
+	data := textPage(
+		"q 100 100 200 200 re W n 150 150 m 400 150 l S\n",
+		show(150, 150, "in"),
+		show(10, 10, "out"),
+		"q 2 0 0 2 0 0 cm 0 0 100 100 re W n 200 200 m 300 300 l S Q Q\n",
+		show(20, 20, "page"),
+	)
+	p := openPDF(t, data).Page(1)
+	c, err := p.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := Rectangle{Point{0, 0}, Point{612, 792}}
+	clip := Rectangle{Point{100, 100}, Point{300, 300}}
+	wantText := []Rectangle{clip, clip, page}
+	if len(c.Text) != len(wantText) {
+		t.Fatalf("page has %d text fragments, want %d", len(c.Text), len(wantText))
+	}
+	for i, tx := range c.Text {
+		if tx.Clip != wantText[i] {
+			t.Errorf("text %d has clip %v, want %v", i, tx.Clip, wantText[i])
+		}
+	}
+	// A clipping path applies after it is ended by n. The second,
+	// scaled by the CTM, is intersected with the first.
+	wantPath := []Rectangle{page, clip, clip, {Point{100, 100}, Point{200, 200}}}
+	if len(c.Paths) != len(wantPath) {
+		t.Fatalf("page has %d paths, want %d", len(c.Paths), len(wantPath))
+	}
+	for i, pa := range c.Paths {
+		if pa.Clip != wantPath[i] {
+			t.Errorf("path %d (%s) has clip %v, want %v", i, pa.Op, pa.Clip, wantPath[i])
+		}
+	}
+
+	// Text outside the clip is invisible.
+	s, err := p.PlainText(TextOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "in\n\npage" {
+		t.Errorf("PlainText = %q, want %q", s, "in\n\npage")
+	}
+	s, err = p.PlainText(TextOptions{IncludeClipped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "in\n\npage\nout" {
+		t.Errorf("PlainText with IncludeClipped = %q, want %q", s, "in\n\npage\nout")
+	}
+}
+
+func TestPathDash(t *testing.T) {
+	data := pagePDF("<<>>",
+		"0 0 m 1 1 l S\n"+
+			"q 2 0 0 2 0 0 cm [3 1] 0.5 d 4 M 0 0 m 1 1 l S Q\n"+
+			"[] 0 d 0 0 m 1 1 l S\n"+
+			"[2] 1 d 1 M 0 0 m 1 1 l S\n")
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	type dash struct {
+		array []float64
+		phase float64
+		miter float64
+	}
+	want := []dash{
+		{[]float64{}, 0, 10},
+		{[]float64{6, 2}, 1, 4}, // scaled by the CTM
+		{[]float64{}, 0, 10},
+		{[]float64{2}, 1, 1},
+	}
+	if len(c.Paths) != len(want) {
+		t.Fatalf("page has %d paths, want %d", len(c.Paths), len(want))
+	}
+	for i, p := range c.Paths {
+		got := dash{p.DashArray, p.DashPhase, p.MiterLimit}
+		if len(got.array) == 0 {
+			got.array = []float64{} // a solid line
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("path %d has dash %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestMarkedContent(t *testing.T) {
+	data := pagePDF("<</Font <</F1 5 0 R>> /Properties <</Pr0 <</MCID 7 /Lang (fr)>>>>>>",
+		"/Artifact BMC BT /F1 10 Tf 72 750 Td (header) Tj ET EMC\n"+
+			"/P <</MCID 3>> BDC /Span /Pr0 BDC BT /F1 10 Tf 72 700 Td (nested) Tj ET EMC"+
+			" BT /F1 10 Tf 72 690 Td (para) Tj ET EMC\n"+
+			"BT /F1 10 Tf 72 680 Td (plain) Tj ET EMC\n",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>")
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tx := range c.Text {
+		s := contentText(Content{Text: []Text{tx}}) + ":"
+		for _, mc := range tx.MarkedContent {
+			s += fmt.Sprintf(" %s %d", mc.Tag, mc.MCID)
+			if lang := mc.Properties.Key("Lang"); lang.Kind() != Null {
+				s += " " + lang.CoerceText("")
+			}
+		}
+		got = append(got, s)
+	}
+	want := []string{"header: Artifact -1", "nested: P 3 Span 7 fr", "para: P 3", "plain:"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("marked content %q, want %q", got, want)
+	}
+	// The unbalanced EMC is ignored.
+	if len(c.Warnings) != 0 {
+		t.Errorf("warnings %v", c.Warnings)
+	}
+}
+
+// TestContentWarnings checks that malformed content is skipped and
+// reported, with the rest of the page still interpreted.
+func TestContentWarnings(t *testing.T) {
+	data := buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 792]>>",
+		"<</Type /Page /Parent 2 0 R /Resources <<>> /Contents [5 0 R 6 0 R]>>",
+		"[1 2]",
+		stream("", "BT /Nope 10 Tf 10 10 Td (a) Tj ET 5 Td 0 0 m 1 1 2 l S BX foo EX /X gs"),
+		stream("", "0 0 m 5 5 l S"),
+	)
+	r := openPDF(t, data)
+	c, err := r.Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, w := range c.Warnings {
+		got = append(got, w.String())
+	}
+	want := []string{
+		"Tf: font Nope not found",
+		"Td: want 2 operands, have 1",
+		"l: want 2 operands, have 3",
+		"gs: graphics state parameter dictionary not found",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("warnings:\n%q\nwant\n%q", got, want)
+	}
+	// The text in the missing font is kept, and the second stream runs.
+	if contentText(c) != "a" || len(c.Paths) != 2 {
+		t.Errorf("content has text %q and %d paths, want \"a\" and 2", contentText(c), len(c.Paths))
+	}
+
+	if _, err := r.Page(2).Content(); err == nil || err.Error() != "malformed PDF: page is not a dictionary" {
+		t.Errorf("Content of a non-dictionary page returned %v", err)
+	}
+	if _, err := r.Page(2).TextInRect(Rectangle{Max: Point{612, 792}}); err == nil {
+		t.Errorf("TextInRect of a non-dictionary page succeeded")
+	}
+}
+
+// pathSummary describes a path segment's shape and how it was painted.
+func pathSummary(p Path) string {
+	s := fmt.Sprintf("%s %v %s %d/%d", p.Kind, p.Points, p.Op, p.PathID, p.Subpath)
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{p.Closed, "closed"},
+		{p.Filled, "filled"},
+		{p.Stroked, "stroked"},
+		{p.EvenOdd, "evenodd"},
+		{p.ClipPath, "clip"},
+	} {
+		if f.set {
+			s += " " + f.name
+		}
+	}
+	return s
+}
+
+func pathSummaries(t *testing.T, content string) []string {
+	c, err := openPDF(t, pagePDF("<<>>", content)).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range c.Paths {
+		got = append(got, pathSummary(p))
+	}
+	return got
+}
+
+// TestPathGrouping checks that segments are reported when painted,
+// numbered by path and subpath, and that h closes a subpath.
+func TestPathGrouping(t *testing.T) {
+	got := pathSummaries(t, "0 0 m 10 0 l 10 10 l h 20 20 m 30 30 l S 0 0 5 5 re f 1 1 m 2 2 l 0 0 m")
+	want := []string{
+		"line [{0 0} {10 0}] S 0/0 closed stroked",
+		"line [{10 0} {10 10}] S 0/0 closed stroked",
+		"line [{10 10} {0 0}] S 0/0 closed stroked",
+		"line [{20 20} {30 30}] S 0/1 stroked",
+		"rect [{0 0} {5 5}] f 1/0 closed filled",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paths:\n%q\nwant\n%q", got, want)
+	}
+}
+
+// TestPathPainting checks the fill rule, stroking and clipping
+// recorded for each painting operator.
+func TestPathPainting(t *testing.T) {
+	got := pathSummaries(t, "0 0 m 1 0 l B* 0 0 m 1 1 2 2 3 3 c s 0 0 1 1 re W* n 0 0 m 1 1 l f 0 0 m 1 1 l W S 0 0 m 1 0 l b")
+	want := []string{
+		"line [{0 0} {1 0}] B* 0/0 filled stroked evenodd",
+		"bezier [{0 0} {1 1} {2 2} {3 3}] s 1/0 closed stroked",
+		"line [{3 3} {0 0}] s 1/0 closed stroked",
+		"rect [{0 0} {1 1}] n 2/0 closed evenodd clip",
+		"line [{0 0} {1 1}] f 3/0 filled",
+		"line [{0 0} {1 1}] S 4/0 stroked clip",
+		"line [{0 0} {1 0}] b 5/0 closed filled stroked",
+		"line [{1 0} {0 0}] b 5/0 closed filled stroked",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paths:\n%q\nwant\n%q", got, want)
+	}
+}
+
+// TestExtGState checks that the parameters set by gs apply to
+// the paths and text that follow, until the state is restored.
+func TestExtGState(t *testing.T) {
+	data := pagePDF("<</ExtGState <</GS0 <</LW 3 /LC 1 /LJ 2 /ML 4 /D [[2 1] 0.5] /CA 0.25 /ca 0.5 /BM [/Screen /Normal] /Font [5 0 R 12]>>>>>>",
+		"q /GS0 gs 0 0 m 10 0 l B BT 0 0 Td (a) Tj ET Q 0 0 m 10 0 l S",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Courier>>")
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Paths) != 2 || len(c.Text) != 1 {
+		t.Fatalf("content has %d paths and %d text, want 2 and 1", len(c.Paths), len(c.Text))
+	}
+	p := c.Paths[0]
+	if p.LineWidth != 3 || p.CapStyle != 1 || p.JoinStyle != 2 || p.MiterLimit != 4 ||
+		fmt.Sprint(p.DashArray) != "[2 1]" || p.DashPhase != 0.5 ||
+		p.StrokeAlpha != 0.25 || p.FillAlpha != 0.5 || p.BlendMode != "Screen" {
+		t.Errorf("path %+v does not have the parameters set by gs", p)
+	}
+	tx := c.Text[0]
+	if tx.Font != "Courier" || tx.FontSize != 12 || tx.FillAlpha != 0.5 || tx.StrokeAlpha != 0.25 || tx.BlendMode != "Screen" {
+		t.Errorf("text in %s %v with alpha %v/%v and blend mode %s, want Courier 12, 0.5/0.25, Screen",
+			tx.Font, tx.FontSize, tx.FillAlpha, tx.StrokeAlpha, tx.BlendMode)
+	}
+	p = c.Paths[1]
+	if p.LineWidth != 0 || p.DashArray != nil || p.StrokeAlpha != 1 || p.FillAlpha != 1 || p.BlendMode != "Normal" {
+		t.Errorf("path %+v after Q does not have the initial parameters", p)
+	}
+}
+
+// TestEachContent checks that EachContent reports the items of Content
+// in drawing order and stops at the first error from the handler.
+func TestEachContent(t *testing.T) {
+	data := pagePDF("<</Font <</F1 5 0 R>>>>",
+		"0 0 m 10 10 l S BT /F1 10 Tf 72 700 Td (a) Tj ET 0 0 m 5 5 l f bogus BT /F1 10 Tf (b) Tj ET",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>")
+	p := openPDF(t, data).Page(1)
+	var got []string
+	err := p.EachContent(func(item ContentItem) error {
+		got = append(got, fmt.Sprintf("%T", item))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[pdf.Path pdf.Text pdf.Path pdf.Warning pdf.Text]"; fmt.Sprint(got) != want {
+		t.Errorf("items %v, want %v", got, want)
+	}
+
+	errStop := fmt.Errorf("stop")
+	n := 0
+	err = p.EachContent(func(item ContentItem) error {
+		if n++; n == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || n != 2 {
+		t.Errorf("EachContent returned %v after %d items, want %v after 2", err, n, errStop)
+	}
+}
+
+// TestRect checks that rectangles drawn with re are reported in
+// device space, unless the CTM rotates them off the axes.
+func TestRect(t *testing.T) {
+	data := pagePDF("<<>>", "q 2 0 0 2 10 10 cm 2 w 1 0 0 rg 0 0 1 RG 5 5 -5 10 re B Q"+
+		" 0 0 10 10 re W n 20 20 m 30 30 l S q 0 1 -1 0 0 0 cm 0 0 10 20 re S Q"+
+		" q 1 1 -1 1 0 0 cm 0 0 10 10 re f Q")
+	c, err := openPDF(t, data).Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range c.Rect {
+		got = append(got, fmt.Sprintf("%v-%v filled %v stroked %v, %v %v, %v wide, clip %v",
+			r.Min, r.Max, r.Filled, r.Stroked, r.FillColor.Components, r.StrokeColor.Components, r.LineWidth, r.Clip))
+	}
+	want := []string{
+		"{10 20}-{20 40} filled true stroked true, [1 0 0] [0 0 1], 4 wide, clip {{0 0} {612 792}}",
+		"{-20 0}-{0 10} filled false stroked true, [0] [0], 0 wide, clip {{0 0} {10 10}}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rects:\n%q\nwant\n%q", got, want)
+	}
+	// The rectangles are paths as well.
+	if len(c.Paths) != 5 {
+		t.Errorf("%d paths, want 5", len(c.Paths))
+	}
+}
+
+func TestPageIndex(t *testing.T) {
+	// Pages under two nodes, the second of which sets MediaBox for
+	// its pages, and a Count that is wrong.
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 9 /MediaBox [0 0 612 792] /Rotate 90>>",
+		"<</Type /Pages /Parent 2 0 R /Kids [5 0 R] /Count 1>>",
+		"<</Type /Pages /Parent 2 0 R /Kids [6 0 R 7 0 R] /Count 2 /MediaBox [0 0 100 100]>>",
+		"<</Type /Page /Parent 3 0 R>>",
+		"<</Type /Page /Parent 4 0 R /Rotate 0>>",
+		"<</Type /Page /Parent 4 0 R>>",
+	))
+	if n := r.NumPage(); n != 3 {
+		t.Fatalf("NumPage() = %d, want 3", n)
+	}
+	for i, want := range []struct {
+		ref    uint32
+		width  float64
+		rotate int
+	}{{5, 612, 90}, {6, 100, 0}, {7, 100, 90}} {
+		p := r.Page(i + 1)
+		if p.V.ptr.id != want.ref || p.MediaBox().Index(2).CoerceFloat64(0) != want.width || p.Rotation() != want.rotate {
+			t.Errorf("page %d: %v, MediaBox %v, Rotation %d, want %d 0 R, width %v, rotation %d",
+				i+1, p.V.Ref(), p.MediaBox(), p.Rotation(), want.ref, want.width, want.rotate)
+		}
+	}
+	if p := r.Page(4); p.V.Kind() != Null {
+		t.Errorf("Page(4) = %v, want null", p.V)
+	}
+
+	// Changes are seen by pages already returned and those returned later.
+	p := r.Page(3)
+	object(r, 4).SetKey("MediaBox", r.NewArray(NewInt(0), NewInt(0), NewInt(50), NewInt(50)))
+	if w := p.MediaBox().Index(2).CoerceFloat64(0); w != 50 {
+		t.Errorf("MediaBox width after change = %v, want 50", w)
+	}
+	if err := r.RemovePages([]int{1}); err != nil {
+		t.Fatal(err)
+	}
+	if n, ref := r.NumPage(), r.Page(1).V.ptr.id; n != 2 || ref != 6 {
+		t.Errorf("after RemovePages: NumPage() = %d, Page(1) = %d 0 R, want 2 and 6 0 R", n, ref)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Changing the pages of a document, for writing with Reader.Save.
+
+package pdf
+
+import "fmt"
+
+// RemovePages removes the pages with the given numbers, starting at 1,
+// from the document's page tree, adjusting the Count of each node above
+// them. Destinations of the document that lead to the removed pages are
+// removed too: outline items keep their titles but lose their targets,
+// link annotations to them are deleted from the remaining pages, and
+// named destinations and an open action leading to them are dropped.
+// The change is written by Save.
+func (r *Reader) RemovePages(pages []int) error {
+	n := r.NumPage()
+	removed := make(map[pdfobjptr]bool)
+	for _, num := range pages {
+		if num < 1 || num > n {
+			return fmt.Errorf("pdf: page %d out of range [1, %d]", num, n)
+		}
+		p := r.Page(num).V
+		if p.Kind() != Dict {
+			return fmt.Errorf("malformed PDF: page %d not found", num)
+		}
+		removed[p.ptr] = true
+	}
+	if len(removed) == n {
+		return fmt.Errorf("pdf: cannot remove every page")
+	}
+
+	for ptr := range removed {
+		page := r.resolve(pdfobjptr{}, ptr)
+		parent := page.Key("Parent")
+		if err := removeKid(parent, ptr); err != nil {
+			return err
+		}
+		for depth := 0; parent.Kind() == Dict && depth < maxPageTreeDepth; depth++ {
+			parent.SetKey("Count", NewInt(parent.Key("Count").CoerceInt64(1)-1))
+			parent = parent.Key("Parent")
+		}
+	}
+	r.removeDests(removed)
+	return nil
+}
+
+// Reorder arranges the pages in the given order, a list of all the
+// page numbers, starting at 1, each appearing once: the page numbered
+// order[0] becomes the first page, and so on. The page tree is replaced
+// by a single node holding all the pages, so the attributes pages
+// inherited from the nodes removed are copied to the pages themselves.
+// The change is written by Save.
+func (r *Reader) Reorder(order []int) error {
+	n := r.NumPage()
+	if len(order) != n {
+		return fmt.Errorf("pdf: Reorder of %d pages, document has %d", len(order), n)
+	}
+	seen := make([]bool, n+1)
+	pages := make([]Value, n)
+	for i, num := range order {
+		if num < 1 || num > n {
+			return fmt.Errorf("pdf: page %d out of range [1, %d]", num, n)
+		}
+		if seen[num] {
+			return fmt.Errorf("pdf: page %d appears twice in Reorder", num)
+		}
+		seen[num] = true
+		pages[i] = r.Page(num).V
+		if pages[i].Kind() != Dict {
+			return fmt.Errorf("malformed PDF: page %d not found", num)
+		}
+	}
+
+	r.setPages(r.Trailer.Key("Root").Key("Pages"), pages)
+	return nil
+}
+
+// setPages makes pages the kids of the page tree node root, replacing
+// the page tree below it. The attributes the pages inherited from the
+// nodes removed are copied to the pages themselves.
+func (r *Reader) setPages(root Value, pages []Value) {
+	for _, p := range pages {
+		for _, key := range inheritableKeys {
+			if p.Key(key).Kind() == Null {
+				p.SetKey(key, Page{V: p}.findInherited(key))
+			}
+		}
+		p.SetKey("Parent", root)
+	}
+	root.SetKey("Kids", r.NewArray(pages...))
+	root.SetKey("Count", NewInt(int64(len(pages))))
+}
+
+// inheritableKeys are the page attributes
+// that pages inherit from their ancestors in the page tree.
+var inheritableKeys = []string{"Resources", "MediaBox", "CropBox", "Rotate"}
+
+// Rotation returns the number of degrees by which the page is rotated
+// clockwise when displayed or printed, a multiple of 90 from 0 to 270.
+func (p Page) Rotation() int {
+	rot := int(p.findInherited("Rotate").CoerceInt64(0)) % 360
+	if rot < 0 {
+		rot += 360
+	}
+	return rot - rot%90
+}
+
+// SetRotation sets the number of degrees by which the page is rotated
+// clockwise when displayed or printed, which must be a multiple of 90.
+// To turn a page a quarter turn further, call
+//
+//	p.SetRotation(p.Rotation() + 90)
+//
+// The change is written by Save.
+func (p Page) SetRotation(degrees int) error {
+	if degrees%90 != 0 {
+		return fmt.Errorf("pdf: rotation %d is not a multiple of 90", degrees)
+	}
+	degrees %= 360
+	if degrees < 0 {
+		degrees += 360
+	}
+	return p.V.SetKey("Rotate", NewInt(int64(degrees)))
+}
+
+// removeKid removes the reference to ptr from the Kids of the page tree node parent.
+func removeKid(parent Value, ptr pdfobjptr) error {
+	kids := parent.Key("Kids")
+	var keep []Value
+	found := false
+	for i := 0; i < kids.Len(); i++ {
+		if ref, ok := kids.entryRef(i); ok && ref == ptr {
+			found = true
+			continue
+		}
+		keep = append(keep, kids.Index(i))
+	}
+	if !found {
+		return fmt.Errorf("malformed PDF: page %d %d R missing from its parent's Kids", ptr.id, ptr.gen)
+	}
+	return parent.SetKey("Kids", parent.r.NewArray(keep...))
+}
+
+// removeDests removes the destinations leading to the removed pages
+// from the outline, the link annotations, the named destinations and
+// the open action.
+func (r *Reader) removeDests(removed map[pdfobjptr]bool) {
+	root := r.Trailer.Key("Root")
+	gone := func(d Value) bool { return r.destRemoved(d, removed) }
+
+	// Outline items.
+	n := 0
+	var walk func(item Value, depth int)
+	walk = func(item Value, depth int) {
+		for ; item.Kind() == Dict && n < maxOutlineItems && depth < maxOutlineDepth; item = item.Key("Next") {
+			n++
+			if gone(item.Key("Dest")) {
+				item.SetKey("Dest", Value{})
+			}
+			if a := item.Key("A"); a.Key("S").CoerceName("") == "GoTo" && gone(a.Key("D")) {
+				item.SetKey("A", Value{})
+			}
+			walk(item.Key("First"), depth+1)
+		}
+	}
+	walk(root.Key("Outlines").Key("First"), 0)
+
+	// Link annotations on the remaining pages.
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i).V
+		annots := p.Key("Annots")
+		var keep []Value
+		for j := 0; j < annots.Len(); j++ {
+			a := annots.Index(j)
+			if a.Key("Subtype").CoerceName("") == "Link" {
+				if gone(a.Key("Dest")) || a.Key("A").Key("S").CoerceName("") == "GoTo" && gone(a.Key("A").Key("D")) {
+					continue
+				}
+			}
+			keep = append(keep, a)
+		}
+		switch {
+		case len(keep) == 0 && annots.Len() > 0:
+			p.SetKey("Annots", Value{})
+		case len(keep) < annots.Len():
+			p.SetKey("Annots", r.NewArray(keep...))
+		}
+	}
+
+	// Named destinations, which gone looks up, are removed last.
+	dests := root.Key("Dests")
+	for _, k := range dests.Keys() {
+		if gone(dests.Key(k)) {
+			dests.SetKey(k, Value{})
+		}
+	}
+	nameTreeFilter(root.Key("Names").Key("Dests"), func(_ string, v Value) bool { return !gone(v) }, 0)
+
+	if a := root.Key("OpenAction"); a.Kind() == Array && gone(a) || a.Key("S").CoerceName("") == "GoTo" && gone(a.Key("D")) {
+		root.SetKey("OpenAction", Value{})
+	}
+}
+
+// destRemoved reports whether the destination d,
+// explicit or named, leads to one of the removed pages.
+func (r *Reader) destRemoved(d Value, removed map[pdfobjptr]bool) bool {
+	switch d.Kind() {
+	case Name:
+		d = r.Trailer.Key("Root").Key("Dests").Key(d.CoerceName(""))
+	case String:
+		d = nameTreeLookup(r.Trailer.Key("Root").Key("Names").Key("Dests"), d.CoerceString(""))
+	}
+	if d.Kind() == Dict {
+		d = d.Key("D")
+	}
+	ref, ok := d.entryRef(0)
+	return d.Kind() == Array && ok && removed[ref]
+}
+
+// nameTreeFilter removes the entries of the name tree whose root node
+// is node for which keep returns false. The Limits of the nodes are
+// left as they are, still bounding the keys that remain.
+func nameTreeFilter(node Value, keep func(key string, v Value) bool, depth int) {
+	if depth > maxNameTreeDepth {
+		return
+	}
+	names := node.Key("Names")
+	var kept []Value
+	for i := 0; i+1 < names.Len(); i += 2 {
+		if keep(names.Index(i).CoerceString(""), names.Index(i+1)) {
+			kept = append(kept, names.Index(i), names.Index(i+1))
+		}
+	}
+	if len(kept) < names.Len() {
+		node.SetKey("Names", node.r.NewArray(kept...))
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		nameTreeFilter(kids.Index(i), keep, depth+1)
+	}
+}
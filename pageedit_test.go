@@ -0,0 +1,136 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+// pageWidths returns the widths of the pages of r, which tell apart
+// the pages of the documents used here.
+func pageWidths(r *Reader) string {
+	var w []int64
+	for i := 1; i <= r.NumPage(); i++ {
+		w = append(w, r.Page(i).MediaBox().Index(2).CoerceInt64(0))
+	}
+	return fmt.Sprint(w)
+}
+
+// pageTreePDF returns a document of three pages, objects 4, 5 and 6,
+// 100, 200 and 300 points wide, the first two in a subtree rotated 90
+// degrees, followed by the objects extra, numbered from 7.
+func pageTreePDF(catalog, page1, page3 string, extra ...string) []byte {
+	return buildPDF("", append([]string{
+		"<</Type /Catalog /Pages 2 0 R " + catalog + ">>",
+		"<</Type /Pages /Kids [3 0 R 6 0 R] /Count 3>>",
+		"<</Type /Pages /Parent 2 0 R /Kids [4 0 R 5 0 R] /Count 2 /Rotate 90>>",
+		"<</Type /Page /Parent 3 0 R /MediaBox [0 0 100 100] " + page1 + ">>",
+		"<</Type /Page /Parent 3 0 R /MediaBox [0 0 200 200]>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 300 300] " + page3 + ">>",
+	}, extra...)...)
+}
+
+func TestRemovePages(t *testing.T) {
+	r := openPDF(t, pageTreePDF(
+		"/Outlines 7 0 R /Dests <</a [4 0 R /Fit] /b [6 0 R /Fit]>> /Names <</Dests 10 0 R>> /OpenAction [5 0 R /Fit]",
+		"/Annots [11 0 R 12 0 R 13 0 R]", "/Annots [14 0 R]",
+		"<</First 8 0 R /Last 9 0 R /Count 2>>",
+		"<</Title (one) /Parent 7 0 R /Next 9 0 R /Dest [5 0 R /Fit]>>",
+		"<</Title (two) /Parent 7 0 R /Prev 8 0 R /A <</S /GoTo /D /b>>>>",
+		"<</Names [(x) [5 0 R /Fit] (y) [6 0 R /Fit]]>>",
+		"<</Type /Annot /Subtype /Link /Rect [0 0 10 10] /Dest [5 0 R /Fit]>>",
+		"<</Type /Annot /Subtype /Link /Rect [0 0 10 10] /A <</S /GoTo /D (x)>>>>",
+		"<</Type /Annot /Subtype /Link /Rect [0 0 10 10] /Dest /a>>",
+		"<</Type /Annot /Subtype /Link /Rect [0 0 10 10] /Dest [5 0 R /Fit]>>",
+	))
+	if err := r.RemovePages([]int{4}); err == nil {
+		t.Errorf("RemovePages of page 4 of 3 succeeded")
+	}
+	if err := r.RemovePages([]int{1, 2, 3}); err == nil {
+		t.Errorf("RemovePages of every page succeeded")
+	}
+	if err := r.RemovePages([]int{2}); err != nil {
+		t.Fatal(err)
+	}
+	r = save(t, r)
+
+	if got := pageWidths(r); got != "[100 300]" {
+		t.Errorf("page widths %s, want [100 300]", got)
+	}
+	root := r.Trailer.Key("Root")
+	if n := root.Key("Pages").Key("Count").CoerceInt64(0); n != 2 {
+		t.Errorf("page tree Count %d, want 2", n)
+	}
+	if n := root.Key("Pages").Key("Kids").Index(0).Key("Count").CoerceInt64(0); n != 1 {
+		t.Errorf("subtree Count %d, want 1", n)
+	}
+	out := r.Outline()
+	if len(out.Child) != 2 || out.Child[0].Title != "one" || out.Child[0].Dest.Page != 0 || out.Child[1].Dest.Page != 2 {
+		t.Errorf("outline %+v, want one without a target and two leading to page 2", out.Child)
+	}
+	if n := r.Page(1).V.Key("Annots").Len(); n != 1 {
+		t.Errorf("page 1 has %d annotations, want 1", n)
+	}
+	if a := r.Page(2).V.Key("Annots"); a.Kind() != Null {
+		t.Errorf("page 2 Annots %v, want none", a)
+	}
+	if keys := fmt.Sprint(root.Key("Dests").Keys()); keys != "[a b]" {
+		t.Errorf("Dests keys %s, want [a b]", keys)
+	}
+	names := root.Key("Names").Key("Dests").Key("Names")
+	if names.Len() != 2 || names.Index(0).CoerceString("") != "y" {
+		t.Errorf("Dests name tree %v, want only y", names)
+	}
+	if a := root.Key("OpenAction"); a.Kind() != Null {
+		t.Errorf("OpenAction %v, want none", a)
+	}
+}
+
+func TestReorder(t *testing.T) {
+	r := openPDF(t, pageTreePDF("", "", "/Rotate 180"))
+	for _, order := range [][]int{{1, 2}, {1, 2, 2}, {1, 2, 4}} {
+		if err := r.Reorder(order); err == nil {
+			t.Errorf("Reorder(%v) succeeded", order)
+		}
+	}
+	if err := r.Reorder([]int{3, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	r = save(t, r)
+	if got := pageWidths(r); got != "[300 100 200]" {
+		t.Errorf("page widths %s, want [300 100 200]", got)
+	}
+	// The rotation the first two pages inherited is kept.
+	var rot []int
+	for i := 1; i <= r.NumPage(); i++ {
+		rot = append(rot, r.Page(i).Rotation())
+	}
+	if fmt.Sprint(rot) != "[180 90 90]" {
+		t.Errorf("rotations %v, want [180 90 90]", rot)
+	}
+}
+
+func TestSetRotation(t *testing.T) {
+	r := openPDF(t, pageTreePDF("", "", ""))
+	p := r.Page(1)
+	if err := p.SetRotation(45); err == nil {
+		t.Errorf("SetRotation(45) succeeded")
+	}
+	if err := p.SetRotation(p.Rotation() + 270); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Page(3).SetRotation(-90); err != nil {
+		t.Fatal(err)
+	}
+	r = save(t, r)
+	var rot []int
+	for i := 1; i <= r.NumPage(); i++ {
+		rot = append(rot, r.Page(i).Rotation())
+	}
+	if fmt.Sprint(rot) != "[0 90 270]" {
+		t.Errorf("rotations %v, want [0 90 270]", rot)
+	}
+}
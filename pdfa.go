@@ -0,0 +1,350 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Checking documents against PDF/A (ISO 19005-1 and ISO 19005-2).
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// A PDFALevel is a PDF/A conformance level that CheckPDFA checks.
+type PDFALevel int
+
+const (
+	PDFA1B PDFALevel = 1 + iota // PDF/A-1b, ISO 19005-1 level B
+	PDFA2B                      // PDF/A-2b, ISO 19005-2 level B
+)
+
+func (l PDFALevel) String() string {
+	switch l {
+	case PDFA1B:
+		return "PDF/A-1b"
+	case PDFA2B:
+		return "PDF/A-2b"
+	}
+	return "PDFALevel(?)"
+}
+
+// part returns the part of ISO 19005 defining l.
+func (l PDFALevel) part() int {
+	return int(l)
+}
+
+// A Violation is a way in which a document fails a requirement.
+type Violation struct {
+	Rule string // short name of the requirement, such as font-embedding
+	Msg  string
+	Ref  ObjRef // the object at fault, or the zero ObjRef for the document as a whole
+}
+
+func (v Violation) String() string {
+	if v.Ref == (ObjRef{}) {
+		return v.Rule + ": " + v.Msg
+	}
+	return fmt.Sprintf("%s: %d %d R: %s", v.Rule, v.Ref.ID, v.Ref.Gen, v.Msg)
+}
+
+// CheckPDFA checks the document against the essential requirements
+// of the PDF/A conformance level and returns the violations found,
+// or nil if none are. It checks that:
+//
+//   - the file is not encrypted and its trailer has an ID;
+//   - for PDF/A-1, the cross-reference table is not a stream, as
+//     PDF/A-1 is based on PDF 1.4, which has none;
+//   - the fonts used by the pages, their form XObjects and annotation
+//     appearances, other than Type 3 fonts, are embedded;
+//   - the catalog has XMP metadata, unfiltered for PDF/A-1, whose PDF/A
+//     identification schema gives the part and conformance checked;
+//   - the document has no JavaScript;
+//   - device color spaces are used only as a PDF/A output intent
+//     allows: DeviceRGB with an RGB profile, DeviceCMYK with a CMYK
+//     one and DeviceGray with any, unless a default color space
+//     replaces them.
+//
+// Passing these checks does not make a document conform; a full
+// validator checks many more requirements.
+func (r *Reader) CheckPDFA(level PDFALevel) []Violation {
+	c := &pdfaChecker{
+		r:      r,
+		level:  level,
+		seen:   make(map[ObjRef]bool),
+		device: make(map[string]ObjRef),
+	}
+	c.checkDocument()
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		c.checkResources(p.Resources(), p.V.Ref(), 0)
+		for _, strm := range p.RawContents() {
+			c.checkContent(strm, p.Resources(), p.V.Ref())
+		}
+		annots := p.V.Key("Annots")
+		for j := 0; j < annots.Len(); j++ {
+			ap := annots.Index(j).Key("AP")
+			for _, k := range ap.Keys() {
+				if n := ap.Key(k); n.Kind() == Stream {
+					c.checkForm(n, 0)
+				} else {
+					for _, state := range n.Keys() {
+						c.checkForm(n.Key(state), 0)
+					}
+				}
+			}
+		}
+	}
+	c.checkColor()
+	return c.out
+}
+
+// A pdfaChecker accumulates the violations CheckPDFA finds.
+type pdfaChecker struct {
+	r      *Reader
+	level  PDFALevel
+	out    []Violation
+	seen   map[ObjRef]bool   // fonts and form XObjects checked
+	device map[string]ObjRef // device color spaces used, and where first
+}
+
+func (c *pdfaChecker) add(rule string, ref ObjRef, format string, args ...interface{}) {
+	c.out = append(c.out, Violation{rule, fmt.Sprintf(format, args...), ref})
+}
+
+// pdfaidNS is the namespace of the PDF/A identification schema.
+const pdfaidNS = "http://www.aiim.org/pdfa/ns/id/"
+
+// checkDocument checks the requirements on the document as a whole.
+func (c *pdfaChecker) checkDocument() {
+	r := c.r
+	if r.Trailer.Key("Encrypt").Kind() != Null {
+		c.add("encryption", ObjRef{}, "document is encrypted")
+	}
+	if r.Trailer.Key("ID").Len() != 2 {
+		c.add("file-id", ObjRef{}, "trailer has no ID")
+	}
+	if c.level == PDFA1B && r.Trailer.ptr != (pdfobjptr{}) {
+		c.add("xref-stream", r.Trailer.Ref(), "cross-reference stream not allowed in PDF/A-1")
+	}
+
+	root := r.Trailer.Key("Root")
+	md := root.Key("Metadata")
+	if md.Kind() != Stream {
+		c.add("metadata", root.Ref(), "catalog has no XMP metadata")
+	} else {
+		if c.level == PDFA1B && md.Key("Filter").Kind() != Null {
+			c.add("metadata", md.Ref(), "metadata stream has a filter")
+		}
+		packet := streamBytes(md)
+		part := xmpProperty(packet, pdfaidNS, "part")
+		conf := xmpProperty(packet, pdfaidNS, "conformance")
+		switch {
+		case part == "":
+			c.add("identification", md.Ref(), "metadata has no PDF/A identification")
+		case part != fmt.Sprint(c.level.part()):
+			c.add("identification", md.Ref(), "metadata identifies PDF/A-%s, not %v", part, c.level)
+		case conf != "A" && conf != "B" && (conf != "U" || c.level == PDFA1B):
+			c.add("identification", md.Ref(), "metadata has invalid PDF/A conformance %q", conf)
+		}
+	}
+
+	for _, s := range r.JavaScript() {
+		c.add("javascript", s.V.Ref(), "JavaScript action (%s)", s.Where)
+	}
+}
+
+// checkResources checks the fonts and form XObjects
+// of the resource dictionary res, used by the object where.
+func (c *pdfaChecker) checkResources(res Value, where ObjRef, depth int) {
+	fonts := res.Key("Font")
+	for _, name := range fonts.Keys() {
+		c.checkFont(fonts.Key(name))
+	}
+	cs := res.Key("ColorSpace")
+	for _, name := range cs.Keys() {
+		c.useColorSpace(res, cs.Key(name), where)
+	}
+	xobjs := res.Key("XObject")
+	for _, name := range xobjs.Keys() {
+		x := xobjs.Key(name)
+		switch x.Key("Subtype").CoerceName("") {
+		case "Image":
+			if x.Key("ImageMask").data != true {
+				c.useColorSpace(res, x.Key("ColorSpace"), x.Ref())
+			}
+		case "Form":
+			c.checkForm(x, depth)
+		}
+	}
+}
+
+// checkForm checks the form XObject x, its resources and content.
+func (c *pdfaChecker) checkForm(x Value, depth int) {
+	if x.Kind() != Stream || c.seen[x.Ref()] || depth >= maxFormDepth {
+		return
+	}
+	c.seen[x.Ref()] = true
+	res := x.Key("Resources")
+	c.checkResources(res, x.Ref(), depth+1)
+	c.checkContent(x, res, x.Ref())
+}
+
+// checkFont checks that the font f, other than a Type 3 font, is embedded.
+func (c *pdfaChecker) checkFont(f Value) {
+	if f.Kind() != Dict || c.seen[f.Ref()] && f.Ref() != (ObjRef{}) {
+		return
+	}
+	c.seen[f.Ref()] = true
+	font := FontFromValue(f)
+	if font.isType3() {
+		for _, name := range f.Key("Resources").Key("Font").Keys() {
+			c.checkFont(f.Key("Resources").Key("Font").Key(name))
+		}
+		return
+	}
+	fd := font.descriptor()
+	for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		if fd.Key(key).Kind() == Stream {
+			return
+		}
+	}
+	c.add("font-embedding", f.Ref(), "font %s is not embedded", font.BaseFont())
+}
+
+// checkContent records the device color spaces that the content stream
+// strm, with resources res, of the object where selects by its operators.
+func (c *pdfaChecker) checkContent(strm Value, res Value, where ObjRef) {
+	defer func() {
+		if e := recover(); e != nil {
+			c.add("content", where, "malformed content stream: %v", e)
+		}
+	}()
+	Interpret(strm, func(stk *Stack, op string) {
+		var args []Value
+		for stk.Len() > 0 {
+			args = append(args, stk.Pop())
+		}
+		switch op {
+		case "g", "G":
+			c.useDevice(res, "DeviceGray", where)
+		case "rg", "RG":
+			c.useDevice(res, "DeviceRGB", where)
+		case "k", "K":
+			c.useDevice(res, "DeviceCMYK", where)
+		case "cs", "CS":
+			if len(args) > 0 {
+				c.useColorSpace(res, lookupColorSpace(res, args[0].CoerceName("")), where)
+			}
+		case "BI":
+			if len(args) == 2 {
+				dict := args[1]
+				if dict.Key("ImageMask").data != true {
+					cs := dict.Key("ColorSpace")
+					if cs.Kind() == Name {
+						if named := lookupColorSpace(res, cs.CoerceName("")); named.Kind() != Null {
+							cs = named
+						}
+					}
+					c.useColorSpace(res, cs, where)
+				}
+			}
+		}
+	})
+}
+
+// useColorSpace records the device color spaces that the color space
+// cs, used by the object where with resources res, depends on.
+func (c *pdfaChecker) useColorSpace(res Value, cs Value, where ObjRef) {
+	switch colorSpaceFamily(cs) {
+	case "DeviceGray", "G":
+		c.useDevice(res, "DeviceGray", where)
+	case "DeviceRGB", "RGB":
+		c.useDevice(res, "DeviceRGB", where)
+	case "DeviceCMYK", "CMYK":
+		c.useDevice(res, "DeviceCMYK", where)
+	case "Indexed", "I":
+		c.useColorSpace(res, cs.Index(1), where)
+	case "Separation", "DeviceN":
+		c.useColorSpace(res, cs.Index(2), where)
+	case "Pattern":
+		if cs.Kind() == Array {
+			c.useColorSpace(res, cs.Index(1), where)
+		}
+	}
+}
+
+// useDevice records the use of the device color space name by the
+// object where, unless res gives a default color space replacing it.
+func (c *pdfaChecker) useDevice(res Value, name string, where ObjRef) {
+	def := "Default" + strings.TrimPrefix(name, "Device")
+	if res.Key("ColorSpace").Key(def).Kind() != Null {
+		return
+	}
+	if _, ok := c.device[name]; !ok {
+		c.device[name] = where
+	}
+}
+
+// checkColor checks the device color spaces used
+// against the document's PDF/A output intent.
+func (c *pdfaChecker) checkColor() {
+	if len(c.device) == 0 {
+		return
+	}
+	var profile Value
+	intents := c.r.Trailer.Key("Root").Key("OutputIntents")
+	for i := 0; i < intents.Len(); i++ {
+		if oi := intents.Index(i); oi.Key("S").CoerceName("") == "GTS_PDFA1" {
+			profile = oi.Key("DestOutputProfile")
+			break
+		}
+	}
+	n := profile.Key("N").CoerceInt64(0)
+	for _, name := range []string{"DeviceGray", "DeviceRGB", "DeviceCMYK"} {
+		where, ok := c.device[name]
+		if !ok {
+			continue
+		}
+		switch {
+		case profile.Kind() != Stream:
+			c.add("output-intent", where, "%s used without a PDF/A output intent", name)
+		case name == "DeviceRGB" && n != 3:
+			c.add("device-color", where, "DeviceRGB used with an output intent profile of %d components", n)
+		case name == "DeviceCMYK" && n != 4:
+			c.add("device-color", where, "DeviceCMYK used with an output intent profile of %d components", n)
+		}
+	}
+}
+
+// xmpProperty returns the value of the simple property with the
+// given namespace and name in the XMP packet, written either as an
+// element or as an attribute of an rdf:Description, or "" if none.
+func xmpProperty(packet []byte, ns, name string) string {
+	d := xml.NewDecoder(bytes.NewReader(packet))
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, a := range start.Attr {
+			if a.Name.Space == ns && a.Name.Local == name {
+				return strings.TrimSpace(a.Value)
+			}
+		}
+		if start.Name.Space == ns && start.Name.Local == name {
+			var s string
+			if d.DecodeElement(&s, &start) != nil {
+				return ""
+			}
+			return strings.TrimSpace(s)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// pdfaXMP returns an XMP packet identifying PDF/A part and conformance.
+func pdfaXMP(part, conformance string) string {
+	return `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" pdfaid:part="` + part + `">` +
+		`<pdfaid:conformance>` + conformance + `</pdfaid:conformance></rdf:Description></rdf:RDF></x:xmpmeta>`
+}
+
+// pdfaDoc returns a document of one page, with the trailer entries
+// and catalog entries given, whose content is content and whose font
+// F1 has the descriptor entries font, with the XMP packet xmp and an
+// output intent whose profile has n components.
+func pdfaDoc(trailer, catalog, content, font, xmp string, n int) []byte {
+	return buildPDF(trailer,
+		"<</Type /Catalog /Pages 2 0 R /Metadata 6 0 R "+catalog+
+			" /OutputIntents [<</Type /OutputIntent /S /GTS_PDFA1 /DestOutputProfile 7 0 R>>]>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream("", content),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Test /FontDescriptor 8 0 R>>",
+		stream("/Type /Metadata /Subtype /XML", xmp),
+		stream(fmt.Sprintf("/N %d", n), ""),
+		"<</Type /FontDescriptor /FontName /Test /Flags 32 "+font+">>",
+		stream("", "%!PS-AdobeFont-1.0: Test\n"),
+	)
+}
+
+func TestCheckPDFA(t *testing.T) {
+	const id = "/ID [<0123> <0123>]"
+	embedded := "/FontFile 9 0 R"
+	text := "1 0 0 rg " + show(72, 700, "hello")
+	tests := []struct {
+		name  string
+		level PDFALevel
+		data  []byte
+		want  []string
+	}{
+		{"conforming", PDFA1B, pdfaDoc(id, "", text, embedded, pdfaXMP("1", "B"), 3), nil},
+		{"conforming part 2", PDFA2B, pdfaDoc(id, "", text, embedded, pdfaXMP("2", "U"), 3), nil},
+		{"no ID", PDFA1B, pdfaDoc("", "", text, embedded, pdfaXMP("1", "B"), 3), []string{"file-id"}},
+		{"wrong part", PDFA2B, pdfaDoc(id, "", text, embedded, pdfaXMP("1", "B"), 3), []string{"identification"}},
+		{"U in part 1", PDFA1B, pdfaDoc(id, "", text, embedded, pdfaXMP("1", "U"), 3), []string{"identification"}},
+		{"no identification", PDFA1B, pdfaDoc(id, "", text, embedded, "<x/>", 3), []string{"identification"}},
+		{"font not embedded", PDFA1B, pdfaDoc(id, "", text, "", pdfaXMP("1", "B"), 3), []string{"font-embedding"}},
+		{"CMYK with an RGB profile", PDFA1B, pdfaDoc(id, "", "0 0 0 1 k", embedded, pdfaXMP("1", "B"), 3), []string{"device-color"}},
+		{"gray with an RGB profile", PDFA1B, pdfaDoc(id, "", "0 g", embedded, pdfaXMP("1", "B"), 3), nil},
+		{"JavaScript", PDFA1B, pdfaDoc(id, "/OpenAction <</S /JavaScript /JS (app.alert(1))>>", text, embedded, pdfaXMP("1", "B"), 3), []string{"javascript"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, v := range openPDF(t, tt.data).CheckPDFA(tt.level) {
+			got = append(got, v.Rule)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: violations %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Without an output intent, any device color space is a violation.
+	r := openPDF(t, pagePDF("<<>>", "0 0 1 RG 0 0 10 10 re S"))
+	v := r.CheckPDFA(PDFA2B)
+	var rules []string
+	for _, x := range v {
+		rules = append(rules, x.Rule)
+	}
+	if want := []string{"file-id", "metadata", "output-intent"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("bare document: violations %q, want %q", rules, want)
+	}
+	if s := v[2].String(); s != "output-intent: 3 0 R: DeviceRGB used without a PDF/A output intent" {
+		t.Errorf("violation string %q", s)
+	}
+
+	// PDF/A-1 predates cross-reference streams.
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.ObjectStreams = true
+	w.NewPage(612, 792)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r = openPDF(t, buf.Bytes())
+	for _, x := range r.CheckPDFA(PDFA1B) {
+		if x.Rule == "xref-stream" {
+			return
+		}
+	}
+	t.Errorf("cross-reference stream allowed in PDF/A-1")
+}
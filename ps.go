@@ -43,7 +43,7 @@ func (stk *Stack) Get() Value {
 }
 
 func newDict() Value {
-	return Value{nil, pdfobjptr{}, make(pdfdict)}
+	return Value{nil, pdfobjptr{}, make(pdfdict), nil, false}
 }
 
 // Interpret interprets the content in a stream as a basic PostScript program,
@@ -52,6 +52,9 @@ func newDict() Value {
 // to implement op.
 //
 // Interpret handles the operators "dict", "currentdict", "begin", "end", "def", and "pop" itself.
+// It also reads the inline images of content streams itself, calling do
+// with the operator "BI" and two operands: the image dictionary,
+// with abbreviations expanded, and the image data as a string.
 //
 // Interpret is not a full-blown PostScript interpreter. Its job is to handle the
 // very limited PostScript found in certain supporting file formats embedded
@@ -61,7 +64,11 @@ func newDict() Value {
 // There is no support for executable blocks, among other limitations.
 //
 func Interpret(strm Value, do func(stk *Stack, op string)) {
-	rd := strm.Reader()
+	interpret(strm.Reader(), do)
+}
+
+// interpret is Interpret for a program read from rd.
+func interpret(rd io.Reader, do func(stk *Stack, op string)) {
 	b := newPdfBuffer(rd, 0)
 	b.allowEOF = true
 	b.allowObjptr = false
@@ -84,21 +91,27 @@ Reading:
 			default:
 				for i := len(dicts) - 1; i >= 0; i-- {
 					if v, ok := dicts[i][pdfname(kw)]; ok {
-						stk.Push(Value{nil, pdfobjptr{}, v})
+						stk.Push(Value{nil, pdfobjptr{}, v, nil, false})
 						continue Reading
 					}
 				}
 				do(&stk, string(kw))
 				continue
+			case "BI":
+				dict, data := b.readInlineImage()
+				stk.Push(Value{data: dict})
+				stk.Push(Value{data: string(data)})
+				do(&stk, "BI")
+				continue
 			case "dict":
 				stk.Pop()
-				stk.Push(Value{nil, pdfobjptr{}, make(pdfdict)})
+				stk.Push(Value{nil, pdfobjptr{}, make(pdfdict), nil, false})
 				continue
 			case "currentdict":
 				if len(dicts) == 0 {
 					panic("no current dictionary")
 				}
-				stk.Push(Value{nil, pdfobjptr{}, dicts[len(dicts)-1]})
+				stk.Push(Value{nil, pdfobjptr{}, dicts[len(dicts)-1], nil, false})
 				continue
 			case "begin":
 				d := stk.Pop()
@@ -134,7 +147,7 @@ Reading:
 		}
 		b.unreadToken(tok)
 		obj := b.readObject()
-		stk.Push(Value{nil, pdfobjptr{}, obj})
+		stk.Push(Value{nil, pdfobjptr{}, obj, nil, false})
 	}
 }
 
@@ -68,23 +68,56 @@ import (
 	"crypto/cipher"
 	"crypto/md5"
 	"crypto/rc4"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 )
 
 // A Reader is a single PDF file open for reading.
 type Reader struct {
 	f          io.ReaderAt
 	end        int64
+	startxref  int64 // offset of the last cross-reference section
 	xref       []xref
 	//trailer    dict
 	//trailerptr objptr
     Trailer    Value
 	key        []byte
 	useAES     bool
+
+	// DecodeOrder is the order in which fonts' decoding strategies
+	// are tried when extracting text. If nil, DefaultDecodeOrder is used.
+	// It must be set before any text is read, as fonts are loaded once.
+	DecodeOrder []DecodeStrategy
+
+	// StreamCacheSize is the number of bytes of decoded stream data
+	// kept, so that streams read again, such as content streams,
+	// ToUnicode CMaps and object streams, are not decoded again.
+	// If 0, DefaultStreamCacheSize is used; if negative, no data is
+	// kept. Streams whose data is larger are decoded on every read.
+	StreamCacheSize int64
+
+	// Hook, if not nil, is told of the work the Reader does,
+	// for metrics. A Stats counts it.
+	Hook Hook
+
+	fontMu sync.Mutex
+	fonts  map[pdfobjptr]Font // fonts by font dictionary, shared by all pages
+
+	streams streamCache // decoded data of streams read
+
+	pageMu sync.Mutex
+	pages  *pageIndex // the pages, once listed
+
+	objMu  sync.Mutex
+	objs   map[pdfobjptr]pdfobject // objects resolved or created, so that changes to them persist
+	dirty  map[pdfobjptr]bool      // objects changed or created since the file was read
+	nextID uint32                  // number of the next object created, once any is
 }
 
 type xref struct {
@@ -120,6 +153,40 @@ func NewReader(f io.ReaderAt, size int64) (*Reader, error) {
 // to try. If pw returns the empty string, NewReaderEncrypted stops trying to decrypt
 // the file and returns an error.
 func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader,error) {
+	r, err := openReader(f, size)
+	if err != nil {
+		return nil, err
+	}
+	if trailer, _ := r.Trailer.data.(pdfdict); trailer["Encrypt"] == nil {
+		return r, nil
+	}
+	err = r.initEncrypt("")
+	if err == nil {
+		return r, nil
+	}
+	if pw == nil || err != ErrInvalidPassword {
+		return nil, err
+	}
+	for {
+		next := pw()
+		if next == "" {
+			break
+		}
+		if r.initEncrypt(next) == nil {
+			return r, nil
+		}
+	}
+	return nil, err
+}
+
+// openReader opens the file in f for reading, up to reading its
+// trailer, without setting up decryption.
+func openReader(f io.ReaderAt, size int64) (r *Reader, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			r, err = nil, fmt.Errorf("malformed PDF: %v", e)
+		}
+	}()
 	buf := make([]byte, 10)
 	f.ReadAt(buf, 0)
 	if !bytes.HasPrefix(buf, []byte("%PDF-1.")) || buf[7] < '0' || buf[7] > '7' || buf[8] != '\r' && buf[8] != '\n' {
@@ -127,11 +194,9 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader,er
 	}
 	end := size
 	const endChunk = 100
-	buf = make([]byte, endChunk)
-	f.ReadAt(buf, end-endChunk)
-	for len(buf) > 0 && buf[len(buf)-1] == '\n' || buf[len(buf)-1] == '\r' {
-		buf = buf[:len(buf)-1]
-	}
+	chunk := min(end, endChunk)
+	buf = make([]byte, chunk)
+	f.ReadAt(buf, end-chunk)
 	buf = bytes.TrimRight(buf, "\r\n\t ")
 	if !bytes.HasSuffix(buf, []byte("%%EOF")) {
 		return nil, fmt.Errorf("not a PDF file: missing %%%%EOF")
@@ -141,11 +206,11 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader,er
 		return nil, fmt.Errorf("malformed PDF file: missing final startxref")
 	}
 
-	r := &Reader{
+	r = &Reader{
 		f:   f,
 		end: end,
 	}
-	pos := end - endChunk + int64(i)
+	pos := end - chunk + int64(i)
 	b := newPdfBuffer(io.NewSectionReader(f, pos, end-pos), pos)
 	if b.readToken() != pdfkeyword("startxref") {
 		return nil, fmt.Errorf("malformed PDF file: missing startxref")
@@ -154,35 +219,20 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader,er
 	if !ok {
 		return nil, fmt.Errorf("malformed PDF file: startxref not followed by integer")
 	}
+	if startxref < 0 || startxref >= end {
+		return nil, fmt.Errorf("malformed PDF file: startxref %d outside file", startxref)
+	}
+	r.startxref = startxref
 	b = newPdfBuffer(io.NewSectionReader(r.f, startxref, r.end-startxref), startxref)
 	xref, trailerptr, trailer, err := readXref(r, b)
 	if err != nil {
 		return nil, err
 	}
 	r.xref = xref
-    r.Trailer = Value{r, trailerptr, trailer, nil} 
+    r.Trailer = Value{r, trailerptr, trailer, nil, false} 
 	//r.trailer = trailer
 	//r.trailerptr = trailerptr
-	if trailer["Encrypt"] == nil {
-		return r, nil
-	}
-	err = r.initEncrypt("")
-	if err == nil {
-		return r, nil
-	}
-	if pw == nil || err != ErrInvalidPassword {
-		return nil, err
-	}
-	for {
-		next := pw()
-		if next == "" {
-			break
-		}
-		if r.initEncrypt(next) == nil {
-			return r, nil
-		}
-	}
-	return nil, err
+	return r, nil
 }
 
 
@@ -216,6 +266,9 @@ func readXrefStream(r *Reader, b *pdfbuffer) ([]xref, pdfobjptr, pdfdict, error)
 	if !ok {
 		return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref stream missing Size")
 	}
+	if size < 0 || size > maxObjectID+1 {
+		return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref stream Size %d out of range", size)
+	}
 	table := make([]xref, size)
 
 	table, err := readXrefStreamData(r, strm, table, size)
@@ -223,11 +276,16 @@ func readXrefStream(r *Reader, b *pdfbuffer) ([]xref, pdfobjptr, pdfdict, error)
 		return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
 	}
 
+	seen := map[int64]bool{r.startxref: true}
 	for prevoff := strm.hdr["Prev"]; prevoff != nil; {
 		off, ok := prevoff.(int64)
 		if !ok {
 			return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref Prev is not integer: %v", prevoff)
 		}
+		if seen[off] {
+			return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref Prev loops to offset %d", off)
+		}
+		seen[off] = true
 		b := newPdfBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
 		obj1 := b.readObject()
 		obj, ok := obj1.(pdfobjdef)
@@ -239,11 +297,11 @@ func readXrefStream(r *Reader, b *pdfbuffer) ([]xref, pdfobjptr, pdfdict, error)
 			return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref prev stream not found: %v", objfmt(obj))
 		}
 		prevoff = prevstrm.hdr["Prev"]
-		prev := Value{r, pdfobjptr{}, prevstrm, nil}
+		prev := Value{r, pdfobjptr{}, prevstrm, nil, false}
 		if prev.Kind() != Stream {
 			return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref prev stream is not stream: %v", prev)
 		}
-        if prev.Key("Type").CoerceString("") != "XRef" {
+		if prev.Key("Type").CoerceName("") != "XRef" {
 			return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref prev stream does not have type XRef")
 		}
 		psize, err := prev.Key("Size").Int64()
@@ -277,7 +335,7 @@ func readXrefStreamData(r *Reader, strm pdfstream, table []xref, size int64) ([]
 	var w []int
 	for _, x := range ww {
 		i, ok := x.(int64)
-		if !ok || int64(int(i)) != i {
+		if !ok || i < 0 || i > 8 {
 			return nil, fmt.Errorf("invalid W array %v", objfmt(ww))
 		}
 		w = append(w, int(i))
@@ -286,7 +344,7 @@ func readXrefStreamData(r *Reader, strm pdfstream, table []xref, size int64) ([]
 		return nil, fmt.Errorf("invalid W array %v", objfmt(ww))
 	}
 
-	v := Value{r, pdfobjptr{}, strm, nil}
+	v := Value{r, pdfobjptr{}, strm, nil, false}
 	wtotal := 0
 	for _, wid := range w {
 		wtotal += wid
@@ -300,6 +358,9 @@ func readXrefStreamData(r *Reader, strm pdfstream, table []xref, size int64) ([]
 			return nil, fmt.Errorf("malformed Index pair %v %v %T %T", objfmt(index[0]), objfmt(index[1]), index[0], index[1])
 		}
 		index = index[2:]
+		if start < 0 || start > maxObjectID || n < 0 || n > maxObjectID+1-start {
+			return nil, fmt.Errorf("invalid Index pair %d %d", start, n)
+		}
 		for i := 0; i < int(n); i++ {
 			_, err := io.ReadFull(data, buf)
 			if err != nil {
@@ -326,7 +387,8 @@ func readXrefStreamData(r *Reader, strm pdfstream, table []xref, size int64) ([]
 			case 2:
 				table[x] = xref{ptr: pdfobjptr{uint32(x), 0}, inStream: true, stream: pdfobjptr{uint32(v2), 0}, offset: int64(v3)}
 			default:
-				fmt.Printf("invalid xref stream type %d: %x\n", v1, buf)
+				// Entries of other types are references
+				// to the null object (Table 18).
 			}
 		}
 	}
@@ -354,11 +416,16 @@ func readXrefTable(r *Reader, b *pdfbuffer) ([]xref, pdfobjptr, pdfdict, error)
 		return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref table not followed by trailer dictionary")
 	}
 
+	seen := map[int64]bool{r.startxref: true}
 	for prevoff := trailer["Prev"]; prevoff != nil; {
 		off, ok := prevoff.(int64)
 		if !ok {
 			return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref Prev is not integer: %v", prevoff)
 		}
+		if seen[off] {
+			return nil, pdfobjptr{}, nil, fmt.Errorf("malformed PDF: xref Prev loops to offset %d", off)
+		}
+		seen[off] = true
 		b := newPdfBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
 		tok := b.readToken()
 		if tok != pdfkeyword("xref") {
@@ -399,6 +466,9 @@ func readXrefTableData(b *pdfbuffer, table []xref) ([]xref, error) {
 		if !ok1 || !ok2 {
 			return nil, fmt.Errorf("malformed xref table")
 		}
+		if start < 0 || start > maxObjectID || n < 0 || n > maxObjectID+1-start {
+			return nil, fmt.Errorf("malformed xref table: subsection %d %d out of range", start, n)
+		}
 		for i := 0; i < int(n); i++ {
 			off, ok1 := b.readToken().(int64)
 			gen, ok2 := b.readToken().(int64)
@@ -445,6 +515,29 @@ type Value struct {
 	ptr  pdfobjptr
 	data interface{}
     err error
+
+	// indirect reports whether v is the object ptr itself,
+	// rather than a value within it.
+	indirect bool
+}
+
+// An ObjRef identifies an indirect object by its object and generation numbers.
+type ObjRef struct {
+	ID  uint32
+	Gen uint16
+}
+
+func (r ObjRef) String() string {
+	return fmt.Sprintf("%d %d R", r.ID, r.Gen)
+}
+
+// Ref returns the reference of the indirect object containing v.
+// For a stream, or a dictionary stored as an object of its own,
+// that is the reference of v itself.
+// Values that are not in any indirect object, such as the operands
+// of content stream operators, have the zero ObjRef.
+func (v Value) Ref() ObjRef {
+	return ObjRef{v.ptr.id, v.ptr.gen}
 }
 
 // A ValueKind specifies the kind of data underlying a Value.
@@ -463,6 +556,15 @@ const (
 	Stream
 )
 
+var valueKindNames = [...]string{"Null", "Bool", "Integer", "Real", "String", "Name", "Dict", "Array", "Stream"}
+
+func (k ValueKind) String() string {
+	if k >= 0 && int(k) < len(valueKindNames) {
+		return valueKindNames[k]
+	}
+	return "ValueKind(?)"
+}
+
 // Kind reports the kind of value underlying v.
 func (v Value) Kind() ValueKind {
 	switch v.data.(type) {
@@ -676,6 +778,62 @@ func (v Value) CoerceString(fallback string) (string) {
 	}
 	return x
 }
+
+// CoerceInt64 returns v's int64 value.
+// If v.Kind() != Integer, CoerceInt64 returns fallback.
+func (v Value) CoerceInt64(fallback int64) int64 {
+	if v.err != nil {
+		return fallback
+	}
+	x, ok := v.data.(int64)
+	if !ok {
+		return fallback
+	}
+	return x
+}
+
+// CoerceFloat64 returns v's float64 value, converting from integer if necessary.
+// If v.Kind() != Real and v.Kind() != Integer, CoerceFloat64 returns fallback.
+func (v Value) CoerceFloat64(fallback float64) float64 {
+	x, err := v.Float64()
+	if err != nil {
+		return fallback
+	}
+	return x
+}
+
+// CoerceName returns v's name value, without the leading slash.
+// If v.Kind() != Name, CoerceName returns fallback.
+func (v Value) CoerceName(fallback string) string {
+	if v.err != nil {
+		return fallback
+	}
+	x, ok := v.data.(pdfname)
+	if !ok {
+		return fallback
+	}
+	return string(x)
+}
+
+// CoerceText returns v's string value interpreted as a ``text string''
+// (defined in the PDF spec) and converted to UTF-8.
+// If v.Kind() != String, CoerceText returns fallback.
+func (v Value) CoerceText(fallback string) string {
+	if v.err != nil {
+		return fallback
+	}
+	x, ok := v.data.(string)
+	if !ok {
+		return fallback
+	}
+	if isPDFDocEncoded(x) {
+		return pdfDocDecode(x)
+	}
+	if isUTF16(x) {
+		return utf16Decode(x[2:])
+	}
+	return x
+}
 /*
 // Text returns v's string value interpreted as a ``text string'' (defined in the PDF spec)
 // and converted to UTF-8.
@@ -752,7 +910,6 @@ func (v Value) Key(key string) (Value) {
 }
 
 
-/*
 // Keys returns a sorted list of the keys in the dictionary v.
 // If v is a stream, Keys applies to the stream's header dictionary.
 // If v.Kind() != Dict and v.Kind() != Stream, Keys returns nil.
@@ -772,7 +929,6 @@ func (v Value) Keys() []string {
 	sort.Strings(keys)
 	return keys
 }
-*/
 
 // Index returns the i'th element in the array v.
 // If v.Kind() != Array or if i is outside the array bounds,
@@ -788,6 +944,28 @@ func (v Value) Index(i int) (Value) {
 	return v.r.resolve(v.ptr, x[i])
 }
 
+// entryRef returns the reference stored in the dictionary v under key
+// or, if key is an int, in the array v at that index.
+// It reports false if the entry is a direct object.
+func (v Value) entryRef(key interface{}) (pdfobjptr, bool) {
+	var x interface{}
+	switch key := key.(type) {
+	case string:
+		switch d := v.data.(type) {
+		case pdfdict:
+			x = d[pdfname(key)]
+		case pdfstream:
+			x = d.hdr[pdfname(key)]
+		}
+	case int:
+		if a, ok := v.data.(pdfarray); ok && key >= 0 && key < len(a) {
+			x = a[key]
+		}
+	}
+	ptr, ok := x.(pdfobjptr)
+	return ptr, ok
+}
+
 // Len returns the length of the array v.
 // If v.Kind() != Array, Len returns 0.
 // We define Len(error) = 0
@@ -823,6 +1001,9 @@ func (r *Reader) resolve(parent pdfobjptr, x interface{}) Value{
         }
     }
     
+    if obj, ok := r.cachedObject(ptr); ok {
+        return Value{r, ptr, obj, nil, true}
+    }
     if ptr.id >= uint32(len(r.xref)) {
         return Value{err:ErrObjectOutOfBounds}
     }
@@ -830,76 +1011,93 @@ func (r *Reader) resolve(parent pdfobjptr, x interface{}) Value{
     if xref.ptr != ptr || !xref.inStream && xref.offset == 0 {
         return Value{err:fmt.Errorf("Unknown error")}
     }
-    var obj pdfobject
-    if xref.inStream {
-        strm := r.resolve(parent, xref.stream)
-        if strm.err != nil {
-            return strm
-        }
-    Search:
-        for {
-            if strm.Kind() != Stream {
-                return Value{err:ErrNotAStream} 
-            }
-            name := strm.Key("Type").CoerceString("")
-            if name != "ObjStm" {
-                panic("not an object stream")
-            }
-            n, err := strm.Key("N").Int64()
-            if err != nil {
-                panic("some error occurred")
-            }
-            first, err := strm.Key("First").Int64()
-            if err != nil{
-                panic("missing First")
-            }
-            b := newPdfBuffer(strm.Reader(), 0)
-            b.allowEOF = true
-            for i := int64(0); i < n; i++ {
-                id, _ := b.readToken().(int64)
-                off, _ := b.readToken().(int64)
-                if uint32(id) == ptr.id {
-                    b.seekForward(first + off)
-                    x = b.readObject()
-                    break Search
-                }
-            }
-            ext := strm.Key("Extends")
-            if ext.err != nil {
-                panic("error reading stream")
-            }
-            if ext.Kind() != Stream {
-                panic("cannot find object in stream")
-            }
-            strm = ext
-        }
-    } else {
-        b := newPdfBuffer(io.NewSectionReader(r.f, xref.offset, r.end-xref.offset), xref.offset)
-        b.key = r.key
-        b.useAES = r.useAES
-        obj = b.readObject()
-        def, ok := obj.(pdfobjdef)
-        if !ok {
-            panic(fmt.Errorf("loading %v: found %T instead of objdef", ptr, obj))
-            //return Value{}
-        }
-        if def.ptr != ptr {
-            panic(fmt.Errorf("loading %v: found %v", ptr, def.ptr))
-        }
-        x = def.obj
+    x, err := r.load(xref)
+    if err != nil {
+        return Value{err: err}
+    }
+    if r.Hook != nil {
+        r.Hook.ObjectResolved(ObjRef{ptr.id, ptr.gen})
     }
     parent = ptr
+    r.cacheObject(ptr, x)
 
     switch x := x.(type) {
     case nil, bool, int64, float64, pdfname, pdfdict, pdfarray, pdfstream:
-        return Value{r, parent, x, nil}
+        return Value{r, parent, x, nil, true}
     case string:
-        return Value{r, parent, x, nil}
+        return Value{r, parent, x, nil, true}
     default:
         return Value{err:ErrUnexpectedValueType}
     }
 }
 
+// maxExtends limits the chain of object streams that load searches,
+// each extending the one before.
+const maxExtends = 64
+
+// load reads the object that the cross-reference entry xref locates,
+// from the file or from an object stream.
+func (r *Reader) load(xref xref) (x pdfobject, err error) {
+	ptr := xref.ptr
+	defer func() {
+		if e := recover(); e != nil {
+			x, err = nil, fmt.Errorf("loading %v: %v", ptr, e)
+		}
+	}()
+	if !xref.inStream {
+		b := newPdfBuffer(io.NewSectionReader(r.f, xref.offset, r.end-xref.offset), xref.offset)
+		b.key = r.key
+		b.useAES = r.useAES
+		obj := b.readObject()
+		def, ok := obj.(pdfobjdef)
+		if !ok {
+			return nil, fmt.Errorf("loading %v: found %T instead of objdef", ptr, obj)
+		}
+		if def.ptr != ptr {
+			return nil, fmt.Errorf("loading %v: found %v", ptr, def.ptr)
+		}
+		return def.obj, nil
+	}
+
+	// Object streams are not themselves in object streams
+	// (PDF 32000-1:2008, §7.5.7).
+	if id := xref.stream.id; id < uint32(len(r.xref)) && r.xref[id].inStream {
+		return nil, fmt.Errorf("loading %v: object stream %d is in an object stream", ptr, id)
+	}
+	strm := r.resolve(pdfobjptr{}, xref.stream)
+	for i := 0; i < maxExtends; i++ {
+		if strm.err != nil {
+			return nil, strm.err
+		}
+		if strm.Kind() != Stream || strm.Key("Type").CoerceName("") != "ObjStm" {
+			return nil, fmt.Errorf("loading %v: not an object stream", ptr)
+		}
+		n, err := strm.Key("N").Int64()
+		if err != nil {
+			return nil, fmt.Errorf("loading %v: object stream missing N", ptr)
+		}
+		first, err := strm.Key("First").Int64()
+		if err != nil {
+			return nil, fmt.Errorf("loading %v: object stream missing First", ptr)
+		}
+		b := newPdfBuffer(strm.Reader(), 0)
+		b.allowEOF = true
+		for i := int64(0); i < n; i++ {
+			id, ok1 := b.readToken().(int64)
+			off, ok2 := b.readToken().(int64)
+			if !ok1 || !ok2 {
+				break
+			}
+			if uint32(id) == ptr.id {
+				b.seekForward(first + off)
+				return b.readObject(), nil
+			}
+		}
+		strm = strm.Key("Extends")
+	}
+	return nil, fmt.Errorf("loading %v: not found in object stream", ptr)
+}
+
 type errorReadCloser struct {
 	err error
 }
@@ -915,39 +1113,92 @@ func (e *errorReadCloser) Close() error {
 // Reader returns the data contained in the stream v.
 // If v.Kind() != Stream, Reader returns a ReadCloser that
 // responds to all reads with a ``stream not present'' error.
-func (v Value) Reader() io.ReadCloser {
-	x, ok := v.data.(pdfstream)
-	if !ok {
+// If the stream's filters cannot be set up, as when they are unknown,
+// the ReadCloser responds to all reads with an error saying why.
+func (v Value) Reader() (rc io.ReadCloser) {
+	if _, ok := v.data.(pdfstream); !ok {
 		return &errorReadCloser{fmt.Errorf("stream not present")}
 	}
-	var rd io.Reader
-    length, err :=  v.Key("Length").Int64()
-    if err != nil {
-        panic("Some error occurred reading length")
-    }
-	rd = io.NewSectionReader(v.r.f, x.offset, length)
-	if v.r.key != nil {
-		rd = decryptStream(v.r.key, v.r.useAES, x.ptr, rd)
+	defer func() {
+		if e := recover(); e != nil {
+			rc = &errorReadCloser{fmt.Errorf("malformed PDF: reading stream: %v", e)}
+		}
+	}()
+	if x := v.data.(pdfstream); !x.mem && x.ptr.id != 0 {
+		if limit := v.r.streamCacheSize(); limit > 0 {
+			data, ok := v.r.streams.get(x.ptr)
+			if v.r.Hook != nil {
+				v.r.Hook.StreamCacheLookup(ObjRef{x.ptr.id, x.ptr.gen}, ok)
+			}
+			if ok {
+				return io.NopCloser(bytes.NewReader(data))
+			}
+			gen := v.r.streams.generation()
+			return io.NopCloser(&cachingReader{rd: v.decode(v.filters()), cache: &v.r.streams, ptr: x.ptr, gen: gen, limit: limit})
+		}
 	}
+	return io.NopCloser(v.decode(v.filters()))
+}
+
+// A streamFilter is a single entry in a stream's filter chain.
+type streamFilter struct {
+	name  string
+	param Value
+}
+
+// filters returns the filter chain of the stream v, in decoding order.
+func (v Value) filters() []streamFilter {
 	filter := v.Key("Filter")
 	param := v.Key("DecodeParms")
 	switch filter.Kind() {
 	default:
 		panic(fmt.Errorf("unsupported filter %v", filter))
 	case Null:
-		// ok
+		return nil
 	case Name:
-        name, _ := filter.RawString()
-		rd = applyFilter(rd, name, param)
+		return []streamFilter{{filter.CoerceName(""), param}}
 	case Array:
-		for i := 0; i < filter.Len(); i++ {
-            flt := filter.Index(i)
-            name := flt.CoerceString("")
-			rd = applyFilter(rd, name, flt)
+		fs := make([]streamFilter, filter.Len())
+		for i := range fs {
+			fs[i] = streamFilter{filter.Index(i).CoerceName(""), param.Index(i)}
 		}
+		return fs
+	}
+}
+
+// decode returns the data of the stream v, decrypted and then passed
+// through the given filters. Callers that want to handle the final
+// filters themselves (for example, an image codec) pass a prefix of v.filters().
+func (v Value) decode(fs []streamFilter) io.Reader {
+	x := v.data.(pdfstream)
+	if x.mem {
+		return v.applyFilters(bytes.NewReader(x.data), fs)
+	}
+	length, err := v.Key("Length").Int64()
+	if err != nil {
+		panic("Some error occurred reading length")
+	}
+	var rd io.Reader = io.NewSectionReader(v.r.f, x.offset, length)
+	if v.r.key != nil {
+		rd = decryptStream(v.r.key, v.r.useAES, x.ptr, rd)
 	}
+	return v.applyFilters(rd, fs)
+}
 
-	return io.NopCloser(rd)
+// applyFilters returns a reader of the data read from rd passed through
+// the filters fs, telling the Reader's Hook of the data each decodes.
+func (v Value) applyFilters(rd io.Reader, fs []streamFilter) io.Reader {
+	var hook Hook
+	if v.r != nil {
+		hook = v.r.Hook
+	}
+	for _, f := range fs {
+		rd = applyFilter(rd, f.name, f.param)
+		if hook != nil {
+			rd = &hookReader{rd, f.name, hook}
+		}
+	}
+	return rd
 }
 
 func applyFilter(rd io.Reader, name string, param Value) io.Reader {
@@ -967,11 +1218,13 @@ func applyFilter(rd io.Reader, name string, param Value) io.Reader {
         if err != nil{
             columns = 1
         }
-        
+		if columns < 1 || columns > maxColumns {
+			panic(fmt.Errorf("predictor Columns %d out of range", columns))
+		}
+
 		switch pred {
 		default:
-			fmt.Println("unknown predictor", pred)
-			panic("pred")
+			panic(fmt.Errorf("unsupported predictor %d", pred))
 		case 1:
 			return zr
 		case 12:
@@ -980,6 +1233,10 @@ func applyFilter(rd io.Reader, name string, param Value) io.Reader {
 	}
 }
 
+// maxColumns limits the Columns of a predictor, each of which takes
+// a byte of memory to decode.
+const maxColumns = 1 << 24
+
 type pngUpReader struct {
 	r    io.Reader
 	hist []byte
@@ -1027,6 +1284,9 @@ func (r *Reader) initEncrypt(password string) error {
 	if encrypt["Filter"] != pdfname("Standard") {
 		return fmt.Errorf("unsupported PDF: encryption filter %v", objfmt(encrypt["Filter"]))
 	}
+	if encrypt["V"] == int64(5) {
+		return r.initEncryptV5(encrypt, password)
+	}
 	n, _ := encrypt["Length"].(int64)
 	if n == 0 {
 		n = 40
@@ -1064,60 +1324,63 @@ func (r *Reader) initEncrypt(password string) error {
 	p, _ := encrypt["P"].(int64)
 	P := uint32(p)
 
-	// TODO: Password should be converted to Latin-1.
-	pw := []byte(password)
-	h := md5.New()
-	if len(pw) >= 32 {
-		h.Write(pw[:32])
-	} else {
-		h.Write(pw)
-		h.Write(passwordPad[:32-len(pw)])
-	}
-	h.Write([]byte(O))
-	h.Write([]byte{byte(P), byte(P >> 8), byte(P >> 16), byte(P >> 24)})
-	h.Write([]byte(ID))
-	key := h.Sum(nil)
+	// computeKey returns the file encryption key if the key computed
+	// from the user password pw encrypts the padding as U does, and nil
+	// if not (Algorithms 2, 4, 5 and 6).
+	computeKey := func(pw []byte) []byte {
+		h := md5.New()
+		h.Write(padPassword(pw))
+		h.Write([]byte(O))
+		h.Write([]byte{byte(P), byte(P >> 8), byte(P >> 16), byte(P >> 24)})
+		h.Write(ID)
+		key := h.Sum(nil)
+		if R >= 3 {
+			for i := 0; i < 50; i++ {
+				key = md5Sum(key[:n/8])
+			}
+			key = key[:n/8]
+		} else {
+			key = key[:40/8]
+		}
 
-	if R >= 3 {
-		for i := 0; i < 50; i++ {
+		var u []byte
+		if R == 2 {
+			u = bytes.Clone(passwordPad)
+			c, _ := rc4.NewCipher(key)
+			c.XORKeyStream(u, u)
+		} else {
 			h.Reset()
-			h.Write(key[:n/8])
-			key = h.Sum(key[:0])
+			h.Write(passwordPad)
+			h.Write(ID)
+			u = h.Sum(nil)
+			rc4Rounds(key, u)
 		}
-		key = key[:n/8]
-	} else {
-		key = key[:40/8]
-	}
-
-	c, err := rc4.NewCipher(key)
-	if err != nil {
-		return fmt.Errorf("malformed PDF: invalid RC4 key: %v", err)
+		if !bytes.HasPrefix([]byte(U), u) {
+			return nil
+		}
+		return key
 	}
 
-	var u []byte
-	if R == 2 {
-		u = make([]byte, 32)
-		copy(u, passwordPad)
-		c.XORKeyStream(u, u)
-	} else {
-		h.Reset()
-		h.Write(passwordPad)
-		h.Write([]byte(ID))
-		u = h.Sum(nil)
-		c.XORKeyStream(u, u)
-
-		for i := 1; i <= 19; i++ {
-			key1 := make([]byte, len(key))
-			copy(key1, key)
-			for j := range key1 {
-				key1[j] ^= byte(i)
+	// TODO: Password should be converted to Latin-1.
+	pw := []byte(password)
+	key := computeKey(pw)
+	if key == nil {
+		// Algorithm 7: an owner password gives the user password,
+		// as the O entry decrypted with a key derived from it.
+		okey := md5Sum(padPassword(pw))
+		upw := []byte(O)
+		if R >= 3 {
+			for i := 0; i < 50; i++ {
+				okey = md5Sum(okey[:n/8])
 			}
-			c, _ = rc4.NewCipher(key1)
-			c.XORKeyStream(u, u)
+			rc4Rounds(okey[:n/8], upw)
+		} else {
+			c, _ := rc4.NewCipher(okey[:40/8])
+			c.XORKeyStream(upw, upw)
 		}
+		key = computeKey(upw)
 	}
-
-	if !bytes.HasPrefix([]byte(U), u) {
+	if key == nil {
 		return ErrInvalidPassword
 	}
 
@@ -1129,6 +1392,93 @@ func (r *Reader) initEncrypt(password string) error {
 
 var ErrInvalidPassword = fmt.Errorf("encrypted PDF: invalid password")
 
+// initEncryptV5 sets up decryption with a 256-bit AES key, as for
+// revisions 5 and 6 of the standard security handler (ISO 32000-2,
+// §7.6.4.3.3), with password as the owner or the user password.
+func (r *Reader) initEncryptV5(encrypt pdfdict, password string) error {
+	if !okayV5(encrypt) {
+		return fmt.Errorf("unsupported PDF: encryption version V=5; %v", objfmt(encrypt))
+	}
+	R, _ := encrypt["R"].(int64)
+	if R != 5 && R != 6 {
+		return fmt.Errorf("unsupported PDF: encryption revision R=%d", R)
+	}
+	O, _ := encrypt["O"].(string)
+	U, _ := encrypt["U"].(string)
+	OE, _ := encrypt["OE"].(string)
+	UE, _ := encrypt["UE"].(string)
+	if len(O) < 48 || len(U) < 48 || len(OE) != 32 || len(UE) != 32 {
+		return fmt.Errorf("malformed PDF: missing O=, U=, OE= or UE= encryption parameters")
+	}
+	o, u := []byte(O[:48]), []byte(U[:48])
+
+	// Revision 5, an Adobe extension that revision 6 replaced,
+	// hashes the password with a single round of SHA-256.
+	hash := hashR6
+	if R == 5 {
+		hash = func(pw, salt, u []byte) []byte {
+			h := sha256.New()
+			h.Write(pw)
+			h.Write(salt)
+			h.Write(u)
+			return h.Sum(nil)
+		}
+	}
+
+	// TODO: Password should be normalized with SASLprep.
+	pw := []byte(password)
+	pw = pw[:min(len(pw), 127)]
+	var key []byte
+	switch {
+	case bytes.Equal(hash(pw, o[32:40], u), o[:32]):
+		key = aesNoPadDecrypt(hash(pw, o[40:48], u), []byte(OE))
+	case bytes.Equal(hash(pw, u[32:40], nil), u[:32]):
+		key = aesNoPadDecrypt(hash(pw, u[40:48], nil), []byte(UE))
+	default:
+		return ErrInvalidPassword
+	}
+
+	// Perms holds the permissions encrypted with the key,
+	// which confirms the key.
+	if perms, _ := encrypt["Perms"].(string); len(perms) == aes.BlockSize {
+		b := make([]byte, aes.BlockSize)
+		block, _ := aes.NewCipher(key)
+		block.Decrypt(b, []byte(perms))
+		if string(b[9:12]) != "adb" {
+			return fmt.Errorf("malformed PDF: encryption key does not decrypt Perms")
+		}
+	}
+
+	r.key = key
+	r.useAES = true
+	return nil
+}
+
+// aesNoPadDecrypt decrypts data, a multiple of 16 bytes long, with
+// AES-256 in CBC mode with a zero initialization vector and no padding.
+func aesNoPadDecrypt(key, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, data)
+	return out
+}
+
+func okayV5(encrypt pdfdict) bool {
+	cf, ok := encrypt["CF"].(pdfdict)
+	if !ok {
+		return false
+	}
+	stmf, ok := encrypt["StmF"].(pdfname)
+	if !ok || encrypt["StrF"] != stmf {
+		return false
+	}
+	cfparam, _ := cf[stmf].(pdfdict)
+	if cfparam["AuthEvent"] != nil && cfparam["AuthEvent"] != pdfname("DocOpen") {
+		return false
+	}
+	return cfparam["CFM"] == pdfname("AESV3")
+}
+
 func okayV4(encrypt pdfdict) bool {
 	cf, ok := encrypt["CF"].(pdfdict)
 	if !ok {
@@ -1158,7 +1508,13 @@ func okayV4(encrypt pdfdict) bool {
 	return true
 }
 
+// cryptKey returns the key for the strings and streams of the object
+// ptr, derived from the file key (Algorithm 1). A 256-bit AES file key
+// (V=5), which cannot be derived from, is used for every object.
 func cryptKey(key []byte, useAES bool, ptr pdfobjptr) []byte {
+	if len(key) == 32 {
+		return key
+	}
 	h := md5.New()
 	h.Write(key)
 	h.Write([]byte{byte(ptr.id), byte(ptr.id >> 8), byte(ptr.id >> 16), byte(ptr.gen), byte(ptr.gen >> 8)})
@@ -1168,17 +1524,75 @@ func cryptKey(key []byte, useAES bool, ptr pdfobjptr) []byte {
 	return h.Sum(nil)
 }
 
+// decryptString decrypts the string x in the object ptr. AES-encrypted
+// data begins with the initialization vector and ends with padding,
+// neither of which is part of the string. The string is decrypted a
+// buffer at a time into the result, which is the only copy made.
 func decryptString(key []byte, useAES bool, ptr pdfobjptr, x string) string {
 	key = cryptKey(key, useAES, ptr)
-	if useAES {
-		panic("AES not implemented")
-	} else {
+	bp := cryptBufPool.Get().(*[]byte)
+	defer cryptBufPool.Put(bp)
+	buf := *bp
+	var out strings.Builder
+	if !useAES {
+		out.Grow(len(x))
 		c, _ := rc4.NewCipher(key)
-		data := []byte(x)
-		c.XORKeyStream(data, data)
-		x = string(data)
+		for len(x) > 0 {
+			n := copy(buf, x)
+			x = x[n:]
+			c.XORKeyStream(buf[:n], buf[:n])
+			out.Write(buf[:n])
+		}
+		return out.String()
 	}
-	return x
+	if len(x) == 0 {
+		// Some writers leave empty strings unencrypted.
+		return x
+	}
+	if len(x) < 2*aes.BlockSize || len(x)%aes.BlockSize != 0 {
+		panic(fmt.Sprintf("AES-encrypted string of %d bytes", len(x)))
+	}
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		panic("AES: " + err.Error())
+	}
+	cbc := cipher.NewCBCDecrypter(cb, []byte(x[:aes.BlockSize]))
+	x = x[aes.BlockSize:]
+	out.Grow(len(x))
+	for len(x) > 0 {
+		n := copy(buf, x)
+		x = x[n:]
+		cbc.CryptBlocks(buf[:n], buf[:n])
+		if len(x) == 0 {
+			out.Write(unpad(buf[:n]))
+		} else {
+			out.Write(buf[:n])
+		}
+	}
+	return out.String()
+}
+
+// cryptBufSize is the size of the buffers in which data is decrypted,
+// a multiple of the AES block size.
+const cryptBufSize = 32 << 10
+
+// cryptBufPool holds buffers of cryptBufSize bytes.
+var cryptBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, cryptBufSize)
+		return &b
+	},
+}
+
+// unpad returns decrypted AES data without the padding at its end,
+// whose bytes all give its length (RFC 2898, §6.1.1).
+func unpad(data []byte) []byte {
+	if n := len(data); n > 0 {
+		if p := int(data[n-1]); p >= 1 && p <= aes.BlockSize && p <= n {
+			return data[:n-p]
+		}
+	}
+	return data
 }
 
 func decryptStream(key []byte, useAES bool, ptr pdfobjptr, rd io.Reader) io.Reader {
@@ -1188,10 +1602,9 @@ func decryptStream(key []byte, useAES bool, ptr pdfobjptr, rd io.Reader) io.Read
 		if err != nil {
 			panic("AES: " + err.Error())
 		}
-		iv := make([]byte, 16)
+		iv := make([]byte, aes.BlockSize)
 		io.ReadFull(rd, iv)
-		cbc := cipher.NewCBCDecrypter(cb, iv)
-		rd = &cbcReader{cbc: cbc, rd: rd, buf: make([]byte, 16)}
+		rd = &cbcReader{cbc: cipher.NewCBCDecrypter(cb, iv), rd: rd}
 	} else {
 		c, _ := rc4.NewCipher(key)
 		rd = &cipher.StreamReader{S: c, R: rd}
@@ -1199,27 +1612,58 @@ func decryptStream(key []byte, useAES bool, ptr pdfobjptr, rd io.Reader) io.Read
 	return rd
 }
 
+// A cbcReader decrypts the AES-CBC data read from rd, without the
+// padding at its end. It decrypts a buffer of blocks at a time, holding
+// back the last block decrypted until it is known whether it ends the
+// data and so holds padding.
 type cbcReader struct {
 	cbc  cipher.BlockMode
 	rd   io.Reader
-	buf  []byte
-	pend []byte
+	buf  *[]byte // a buffer from cryptBufPool, holding pend and last
+	pend []byte  // data decrypted and not yet returned
+	last []byte  // the last block decrypted, held back
+	err  error   // the error ending the data, once reached
 }
 
 func (r *cbcReader) Read(b []byte) (n int, err error) {
-	if len(r.pend) == 0 {
-		_, err = io.ReadFull(r.rd, r.buf)
-		if err != nil {
-			return 0, err
+	for len(r.pend) == 0 {
+		if r.err != nil {
+			if r.buf != nil {
+				cryptBufPool.Put(r.buf)
+				r.buf = nil
+			}
+			return 0, r.err
 		}
-		r.cbc.CryptBlocks(r.buf, r.buf)
-		r.pend = r.buf
+		r.fill()
 	}
 	n = copy(b, r.pend)
 	r.pend = r.pend[n:]
 	return n, nil
 }
 
+// fill reads and decrypts the blocks that follow the last block,
+// setting pend to the data they complete.
+func (r *cbcReader) fill() {
+	if r.buf == nil {
+		r.buf = cryptBufPool.Get().(*[]byte)
+	}
+	buf := *r.buf
+	n := copy(buf, r.last)
+	m, err := io.ReadFull(r.rd, buf[n:])
+	m -= m % aes.BlockSize // a partial block at the end is dropped
+	r.cbc.CryptBlocks(buf[n:n+m], buf[n:n+m])
+	data := buf[:n+m]
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		r.pend, r.last, r.err = unpad(data), nil, err
+		return
+	}
+	k := len(data) - aes.BlockSize
+	r.pend, r.last = data[:k], data[k:]
+}
+
 
 
 
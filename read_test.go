@@ -0,0 +1,112 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCoerce(t *testing.T) {
+	data := buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612.5 792]>>",
+		"<</Type /Page /Parent 2 0 R /Rotate 90 /Tabs /S>>",
+	)
+	r := openPDF(t, data)
+	p := r.Page(1)
+
+	if n := p.V.Key("Rotate").CoerceInt64(-1); n != 90 {
+		t.Errorf("Rotate is %d, want 90", n)
+	}
+	if n := p.V.Key("Tabs").CoerceInt64(-1); n != -1 {
+		t.Errorf("CoerceInt64 of a name is %d, want the fallback", n)
+	}
+	if s := p.V.Key("Tabs").CoerceName(""); s != "S" {
+		t.Errorf("Tabs is %q, want %q", s, "S")
+	}
+	if s := p.V.Key("Rotate").CoerceName("none"); s != "none" {
+		t.Errorf("CoerceName of an integer is %q, want the fallback", s)
+	}
+
+	// MediaBox is inherited from the page tree node.
+	box := p.findInherited("MediaBox")
+	if x := box.Index(2).CoerceFloat64(0); x != 612.5 {
+		t.Errorf("MediaBox width is %v, want 612.5", x)
+	}
+	if x := box.Index(3).CoerceFloat64(0); x != 792 {
+		t.Errorf("MediaBox height is %v, want 792 from an integer", x)
+	}
+	if x := box.Index(4).CoerceFloat64(-1); x != -1 {
+		t.Errorf("CoerceFloat64 past the end of an array is %v, want the fallback", x)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	// Unknown escapes keep the character escaped, and octal escapes
+	// drop high-order overflow.
+	r := openPDF(t, buildPDF("", "<</Type /Catalog /S (a\\qb\\777)>>"))
+	if s := r.Trailer.Key("Root").Key("S").CoerceString(""); s != "aqb\xff" {
+		t.Errorf("escapes read as %q", s)
+	}
+
+	// Arrays nested too deeply, a page tree that loops and an object
+	// stream in an object stream are errors, not crashes.
+	deep := strings.Repeat("[", maxNesting+1)
+	r = openPDF(t, buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R /Deep 3 0 R /Packed 4 0 R>>",
+		"<</Type /Pages /Kids [2 0 R] /Count 1 /Parent 2 0 R>>",
+		deep,
+	))
+	if v := r.Trailer.Key("Root").Key("Deep"); v.err == nil {
+		t.Errorf("deep nesting: %v, want error", v)
+	}
+	if p := r.Page(1); p.V.Kind() != Null {
+		t.Errorf("looping page tree has page 1: %v", p.V)
+	}
+	r.xref = append(r.xref[:4], xref{ptr: pdfobjptr{4, 0}, inStream: true, stream: pdfobjptr{4, 0}})
+	if v := r.Trailer.Key("Root").Key("Packed"); v.err == nil {
+		t.Errorf("object stream in itself: %v, want error", v)
+	}
+
+	// A cross-reference table whose Prev leads back to itself.
+	data := buildPDF("")
+	xref := bytes.Index(data, []byte("\nxref\n")) + 1
+	data = bytes.Replace(data, []byte("trailer\n<<"), fmt.Appendf(nil, "trailer\n<</Prev %d", xref), 1)
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil || !strings.Contains(err.Error(), "loops") {
+		t.Errorf("looping Prev: err = %v", err)
+	}
+
+	// Unknown filters fail when read.
+	v := Value{r: r, data: pdfstream{hdr: pdfdict{"Filter": pdfname("Bogus")}, mem: true}}
+	if _, err := v.Reader().Read(make([]byte, 1)); err == nil {
+		t.Errorf("reading with an unknown filter succeeded")
+	}
+}
+
+// TestXrefStreamPrev checks that the cross-reference streams that a
+// cross-reference stream's Prev leads to are read, with their Type
+// matched as a name.
+func TestXrefStreamPrev(t *testing.T) {
+	r := openPDF(t, threePages(t, true))
+	r.Page(2).V.SetKey("Rotate", NewInt(90))
+	var buf bytes.Buffer
+	if err := r.SaveIncremental(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if n := bytes.Count(data, []byte("/Type /XRef")); n != 2 {
+		t.Fatalf("updated file has %d cross-reference streams, want 2", n)
+	}
+	r = openPDF(t, data)
+	if rot := r.Page(2).V.Key("Rotate").CoerceInt64(0); rot != 90 {
+		t.Errorf("page 2 Rotate %d, want 90", rot)
+	}
+	if s, err := r.Page(1).GetPlainText(); err != nil || s != "one" {
+		t.Errorf("page 1 text %q, %v, want %q", s, err, "one")
+	}
+}
@@ -0,0 +1,441 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Repairing damaged files from the objects found in them.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Repair reads the PDF file in f, of the given size, without relying
+// on its cross-reference table or startxref, and writes a repaired
+// copy of the document to w as Save would.
+//
+// Repair finds the objects by scanning the file for their definitions,
+// a later definition of an object number replacing an earlier one,
+// and reads the objects packed in the object streams it finds. The
+// catalog is the Root of the last trailer found or, failing that, the
+// last catalog defined. A stream whose Length does not lead to its
+// endstream keyword is cut at the first one. If the page tree cannot
+// be walked, it is rebuilt from the pages it leads to followed by the
+// other pages found, in order of object number; if its counts or
+// parents are wrong, it is rebuilt from the pages it leads to. Pages
+// inheriting no MediaBox are given a US Letter one. References to
+// missing objects are written as null, as are objects that cannot be
+// read.
+//
+// Repair reads an encrypted file only if its user password is empty,
+// and writes the copy unencrypted.
+func Repair(f io.ReaderAt, size int64, w io.Writer) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("pdf: repairing: %v", e)
+		}
+	}()
+	rp := &repairer{
+		r:       &Reader{f: f, end: size},
+		where:   make(map[uint32]int64),
+		reached: make(map[pdfobjptr]bool),
+	}
+	r := rp.r
+	if err := rp.scan(); err != nil {
+		return fmt.Errorf("pdf: repairing: %v", err)
+	}
+	if len(r.xref) == 0 {
+		return fmt.Errorf("pdf: repairing: no objects found")
+	}
+
+	// Cross-reference streams hold trailers, and object streams objects.
+	var objstms []pdfobjptr
+	for _, x := range r.xref {
+		strm, ok := rp.load(x.ptr).data.(pdfstream)
+		if !ok {
+			continue
+		}
+		switch strm.hdr["Type"] {
+		case pdfname("XRef"):
+			rp.trailers = append(rp.trailers, repairTrailer{rp.where[x.ptr.id], strm.hdr})
+		case pdfname("ObjStm"):
+			objstms = append(objstms, x.ptr)
+		}
+	}
+	sort.SliceStable(rp.trailers, func(i, j int) bool {
+		return rp.trailers[i].offset < rp.trailers[j].offset
+	})
+	trailer := pdfdict{}
+	for _, t := range rp.trailers {
+		if t.dict["Root"] == nil {
+			continue
+		}
+		trailer = pdfdict{}
+		for _, key := range []pdfname{"Root", "Info", "ID", "Encrypt"} {
+			if x := t.dict[key]; x != nil {
+				trailer[key] = x
+			}
+		}
+	}
+	r.Trailer = Value{r, pdfobjptr{}, trailer, nil, false}
+	if trailer["Encrypt"] != nil {
+		if err := r.initEncrypt(""); err != nil {
+			return err
+		}
+	}
+	for _, ptr := range objstms {
+		rp.unpack(ptr)
+	}
+
+	// Read the objects again, decrypted and as the object streams
+	// define them, and find the catalogs and pages.
+	r.objMu.Lock()
+	r.objs = nil
+	r.objMu.Unlock()
+	for _, ptr := range objstms {
+		rp.load(ptr)
+	}
+	for _, x := range r.xref {
+		v := rp.load(x.ptr)
+		if v.Kind() != Dict {
+			continue
+		}
+		switch v.Key("Type").CoerceName("") {
+		case "Catalog":
+			if rp.catalog == (pdfobjptr{}) || rp.where[x.ptr.id] >= rp.where[rp.catalog.id] {
+				rp.catalog = x.ptr
+			}
+		case "Page":
+			rp.pages = append(rp.pages, v)
+		}
+	}
+
+	root := r.Trailer.Key("Root")
+	if root.Kind() != Dict {
+		if rp.catalog != (pdfobjptr{}) {
+			root = r.resolve(pdfobjptr{}, rp.catalog)
+		} else {
+			root = r.Add(r.NewDict())
+			root.SetKey("Type", NewName("Catalog"))
+		}
+		r.Trailer.SetKey("Root", root)
+	}
+	if r.Trailer.Key("Info").Kind() != Dict {
+		r.Trailer.SetKey("Info", Value{})
+	}
+	if err := rp.repairPages(root); err != nil {
+		return err
+	}
+	c := newObjCopier(r, NewWriter(w))
+	c.repair = true
+	return c.save()
+}
+
+// A repairer holds what Repair finds in a file.
+type repairer struct {
+	r        *Reader
+	where    map[uint32]int64 // offset of each object's definition, or of the object stream holding it
+	trailers []repairTrailer
+	catalog  pdfobjptr // the catalog defined last
+	pages    []Value   // the pages, in order of object number
+
+	// State of the walk of the page tree.
+	reached map[pdfobjptr]bool // page tree nodes and pages reached
+	walked  []Value            // pages reached, in order
+	broken  bool               // the tree cannot be walked
+	wrong   bool               // the tree can be walked, but has wrong counts or parents
+}
+
+// A repairTrailer is a trailer dictionary found at offset,
+// after the trailer keyword or as a cross-reference stream.
+type repairTrailer struct {
+	offset int64
+	dict   pdfdict
+}
+
+// maxObjectID is the largest object number (PDF 32000-1:2008, Annex C).
+const maxObjectID = 8388607
+
+// scan builds the cross-reference table from the object definitions
+// in the file and collects the trailer dictionaries after trailer keywords.
+func (rp *repairer) scan() error {
+	r := rp.r
+	return scanFile(r.f, r.end, func(offset int64, tok string, ptr pdfobjptr) {
+		switch tok {
+		case "obj":
+			for uint32(len(r.xref)) <= ptr.id {
+				r.xref = append(r.xref, xref{})
+			}
+			r.xref[ptr.id] = xref{ptr: ptr, offset: offset}
+			rp.where[ptr.id] = offset
+		case "trailer":
+			if d, ok := readTrailerAt(r, offset+int64(len(tok))); ok {
+				rp.trailers = append(rp.trailers, repairTrailer{offset, d})
+			}
+		}
+	})
+}
+
+// readTrailerAt reads the dictionary at offset in the file of r.
+func readTrailerAt(r *Reader, offset int64) (d pdfdict, ok bool) {
+	defer func() {
+		if recover() != nil {
+			d, ok = nil, false
+		}
+	}()
+	b := newPdfBuffer(io.NewSectionReader(r.f, offset, r.end-offset), offset)
+	b.allowEOF = true
+	b.allowStream = false
+	d, ok = b.readObject().(pdfdict)
+	return d, ok
+}
+
+// load returns the object ptr, or a null Value if it cannot be read,
+// as when the file is cut short in the middle of it. Such an object is
+// dropped from the cross-reference table, so that it is missing, and
+// null, to everything that refers to it afterward.
+// If the object is a stream whose Length is wrong, load corrects it.
+func (rp *repairer) load(ptr pdfobjptr) (v Value) {
+	drop := func() {
+		if ptr.id < uint32(len(rp.r.xref)) && rp.r.xref[ptr.id].ptr == ptr {
+			rp.r.xref[ptr.id] = xref{}
+		}
+		v = Value{}
+	}
+	defer func() {
+		if recover() != nil {
+			drop()
+		}
+	}()
+	if ptr == (pdfobjptr{}) {
+		return Value{}
+	}
+	v = rp.r.resolve(pdfobjptr{}, ptr)
+	if v.err != nil {
+		drop()
+		return v
+	}
+	if strm, ok := v.data.(pdfstream); ok && !strm.mem {
+		rp.repairLength(strm)
+	}
+	return v
+}
+
+// unpack adds the objects packed in the object stream ptr to the
+// cross-reference table, but not those defined later in the file.
+func (rp *repairer) unpack(ptr pdfobjptr) {
+	defer func() {
+		recover()
+	}()
+	r := rp.r
+	strm := rp.load(ptr)
+	n := strm.Key("N").CoerceInt64(0)
+	b := newPdfBuffer(strm.Reader(), 0)
+	b.allowEOF = true
+	for i := int64(0); i < n; i++ {
+		id, ok1 := b.readToken().(int64)
+		_, ok2 := b.readToken().(int64)
+		if !ok1 || !ok2 || id <= 0 || id > maxObjectID {
+			return
+		}
+		if off, ok := rp.where[uint32(id)]; ok && off > rp.where[ptr.id] {
+			continue
+		}
+		for int64(len(r.xref)) <= id {
+			r.xref = append(r.xref, xref{})
+		}
+		r.xref[id] = xref{ptr: pdfobjptr{uint32(id), 0}, inStream: true, stream: ptr}
+		rp.where[uint32(id)] = rp.where[ptr.id]
+	}
+}
+
+// repairLength sets the Length of the stream strm, read from the
+// file, to the length of its data if its Length does not lead to the
+// endstream keyword: the data end at the first endstream keyword, less
+// the end-of-line marker before it, or if there is none at the end of
+// the file.
+func (rp *repairer) repairLength(strm pdfstream) {
+	r := rp.r
+	length := int64(-1)
+	func() {
+		defer func() {
+			recover()
+		}()
+		length = r.resolve(pdfobjptr{}, strm.hdr["Length"]).CoerceInt64(-1)
+	}()
+	if length >= 0 && endstreamAt(r.f, strm.offset+length) {
+		return
+	}
+	end := indexAt(r.f, r.end, strm.offset, []byte("endstream"))
+	if end < 0 {
+		end = r.end
+	} else if end-strm.offset >= 2 {
+		var eol [2]byte
+		r.f.ReadAt(eol[:], end-2)
+		switch {
+		case eol == [2]byte{'\r', '\n'}:
+			end -= 2
+		case eol[1] == '\n' || eol[1] == '\r':
+			end--
+		}
+	}
+	strm.hdr["Length"] = end - strm.offset
+	r.streams.clear()
+}
+
+// endstreamAt reports whether the endstream keyword follows offset
+// in rd, after any white space.
+func endstreamAt(rd io.ReaderAt, offset int64) bool {
+	buf := make([]byte, 32)
+	n, _ := rd.ReadAt(buf, offset)
+	return bytes.HasPrefix(bytes.TrimLeft(buf[:n], "\x00\t\n\f\r "), []byte("endstream"))
+}
+
+// indexAt returns the offset of the first instance of sep in rd at or
+// after offset, or -1 if there is none before size.
+func indexAt(rd io.ReaderAt, size, offset int64, sep []byte) int64 {
+	buf := make([]byte, 32<<10)
+	for off := offset; off < size; off += int64(len(buf) - len(sep)) {
+		n, _ := rd.ReadAt(buf[:min(int64(len(buf)), size-off)], off)
+		if i := bytes.Index(buf[:n], sep); i >= 0 {
+			return off + int64(i)
+		}
+		if n < len(buf) {
+			break
+		}
+	}
+	return -1
+}
+
+// repairPages checks the page tree of the catalog root, rebuilding it
+// as Repair describes if needed, and gives a media box to the pages
+// inheriting none.
+func (rp *repairer) repairPages(root Value) error {
+	r := rp.r
+	tree := root.Key("Pages")
+	if tree.Key("Type").CoerceName("") == "Pages" {
+		rp.reached[tree.ptr] = true
+		rp.walk(tree, 0)
+	} else {
+		rp.broken = true
+	}
+	pages := rp.walked
+	if rp.broken {
+		for _, p := range rp.pages {
+			if !rp.reached[p.ptr] {
+				pages = append(pages, p)
+			}
+		}
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("pdf: repairing: no pages found")
+	}
+	if rp.broken || rp.wrong {
+		if tree.Key("Type").CoerceName("") != "Pages" {
+			tree = r.Add(r.NewDict())
+			tree.SetKey("Type", NewName("Pages"))
+			root.SetKey("Pages", tree)
+		}
+		r.setPages(tree, pages)
+	}
+	for _, p := range pages {
+		if (Page{V: p}).findInherited("MediaBox").Len() != 4 {
+			p.SetKey("MediaBox", r.NewArray(NewInt(0), NewInt(0), NewInt(612), NewInt(792)))
+		}
+	}
+	return nil
+}
+
+// walk collects the pages below the page tree node n in rp.walked and
+// returns their number, noting whether the tree is broken or wrong.
+func (rp *repairer) walk(n Value, depth int) int {
+	if depth >= maxPageTreeDepth {
+		rp.broken = true
+		return 0
+	}
+	kids := n.Key("Kids")
+	count := 0
+	for i := 0; i < kids.Len(); i++ {
+		kid := kids.Index(i)
+		ptr, ok := kids.entryRef(i)
+		if kid.Kind() != Dict || ok && rp.reached[ptr] {
+			rp.broken = true
+			continue
+		}
+		if ok {
+			rp.reached[ptr] = true
+		}
+		if parent, _ := kid.entryRef("Parent"); parent != n.ptr {
+			rp.wrong = true
+		}
+		switch kid.Key("Type").CoerceName("") {
+		case "Pages":
+			count += rp.walk(kid, depth+1)
+		case "Page":
+			rp.walked = append(rp.walked, kid)
+			count++
+		default:
+			rp.broken = true
+		}
+	}
+	if n.Key("Count").CoerceInt64(-1) != int64(count) {
+		rp.wrong = true
+	}
+	return count
+}
+
+// scanToken matches the start of an indirect object definition at the
+// start of a line, an %%EOF marker or a trailer keyword.
+var scanToken = regexp.MustCompile(`[\r\n][\t\f ]*([0-9]{1,10})[\t\f\r\n ]+([0-9]{1,5})[\t\f\r\n ]+obj\b|%%EOF|trailer\b`)
+
+const (
+	scanChunk   = 64 << 10 // bytes scanned at a time
+	scanOverlap = 256      // longest token found across chunks
+)
+
+// scanFile scans the size bytes of rd for object definitions, %%EOF
+// markers and trailer keywords, calling f in order of offset with the
+// offset and the token found: "obj" with the object defined, or
+// "%%EOF" or "trailer". Tokens in stream data are found too.
+func scanFile(rd io.ReaderAt, size int64, f func(offset int64, tok string, ptr pdfobjptr)) error {
+	buf := make([]byte, 1+scanChunk+scanOverlap)
+	for pos := int64(0); pos < size; pos += scanChunk {
+		// buf[0] is the byte before pos, or a newline
+		// standing for the start of the file.
+		start, data := pos-1, buf
+		if pos == 0 {
+			buf[0] = '\n'
+			start, data = 0, buf[1:]
+		}
+		n, err := rd.ReadAt(data[:min(int64(len(data)), size-start)], start)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		data = buf[:n+int(start-(pos-1))]
+		for _, m := range scanToken.FindAllSubmatchIndex(data, -1) {
+			offset := pos - 1 + int64(m[0])
+			tok := string(data[m[0]:m[1]])
+			var ptr pdfobjptr
+			if m[2] >= 0 {
+				offset = pos - 1 + int64(m[2])
+				tok = "obj"
+				id, _ := strconv.ParseUint(string(data[m[2]:m[3]]), 10, 64)
+				gen, _ := strconv.ParseUint(string(data[m[4]:m[5]]), 10, 64)
+				if id > maxObjectID || gen > 65535 {
+					continue
+				}
+				ptr = pdfobjptr{uint32(id), uint16(gen)}
+			}
+			if offset < pos || offset >= pos+scanChunk {
+				continue
+			}
+			f(offset, tok, ptr)
+		}
+	}
+	return nil
+}
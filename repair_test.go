@@ -0,0 +1,122 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// threePages returns a document of three pages, showing "one", "two"
+// and "three", written with object streams if objstm is set.
+func threePages(t *testing.T, objstm bool) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Compress = false
+	w.ObjectStreams = objstm
+	for _, s := range []string{"one", "two", "three"} {
+		w.NewPage(612, 792).ShowText(72, 700, s)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// repaired returns the text of each page of the repair of data.
+func repaired(data []byte) ([]string, error) {
+	var out bytes.Buffer
+	if err := Repair(bytes.NewReader(data), int64(len(data)), &out); err != nil {
+		return nil, err
+	}
+	r, err := NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		return nil, fmt.Errorf("reading the repaired file: %v", err)
+	}
+	var pages []string
+	for i := 1; i <= r.NumPage(); i++ {
+		c, err := r.Page(i).Content()
+		if err != nil {
+			return nil, fmt.Errorf("page %d of the repaired file: %v", i, err)
+		}
+		pages = append(pages, contentText(c))
+	}
+	return pages, nil
+}
+
+func TestRepair(t *testing.T) {
+	for _, objstm := range []bool{false, true} {
+		data := threePages(t, objstm)
+		tests := []struct {
+			name string
+			data []byte
+		}{
+			{"intact", data},
+			{"no xref", data[:bytes.LastIndex(data, []byte("endobj"))+len("endobj\n")]},
+			{"bad startxref", bytes.Replace(data, []byte("startxref\n"), []byte("startxref\n9"), 1)},
+			{"bad Length", regexp.MustCompile(`/Length \d+`).ReplaceAll(data, []byte("/Length 3   "))},
+			{"broken tree", bytes.Replace(data, []byte("/Type /Pages"), []byte("/Type /Pagez"), 1)},
+			{"bad Count", bytes.Replace(data, []byte("/Count 3"), []byte("/Count 7"), 1)},
+		}
+		for _, tt := range tests {
+			if objstm && (tt.name == "bad Length" || tt.name == "broken tree" || tt.name == "bad Count") {
+				continue // the dictionaries are compressed
+			}
+			pages, err := repaired(tt.data)
+			if err != nil {
+				t.Errorf("%s (object streams %v): %v", tt.name, objstm, err)
+			} else if got := strings.Join(pages, ","); got != "one,two,three" {
+				t.Errorf("%s (object streams %v): pages %s, want one,two,three", tt.name, objstm, got)
+			}
+		}
+	}
+	if err := Repair(strings.NewReader("%PDF-1.4\nnothing\n"), 17, &bytes.Buffer{}); err == nil {
+		t.Errorf("repaired a file without objects")
+	}
+}
+
+// TestRepairTruncated repairs the document cut short at every byte,
+// which must succeed, or fail because no pages are left, and keep the
+// pages whose objects are all there.
+func TestRepairTruncated(t *testing.T) {
+	for _, objstm := range []bool{false, true} {
+		data := threePages(t, objstm)
+		for n := len(data) - 1; n > 0; n-- {
+			pages, err := repaired(data[:n])
+			if err != nil {
+				if msg := err.Error(); msg != "pdf: repairing: no pages found" && msg != "pdf: repairing: no objects found" {
+					t.Errorf("cut at %d of %d (object streams %v): %v", n, len(data), objstm, err)
+				}
+				continue
+			}
+			if len(pages) > 3 {
+				t.Errorf("cut at %d of %d (object streams %v): %d pages", n, len(data), objstm, len(pages))
+			}
+		}
+	}
+
+	// Cut short in the page tree, the pages are found; cut short in the
+	// last page's content stream, that page is lost with the page tree.
+	// In an object stream, the pages before the cut are found.
+	data := threePages(t, false)
+	objstm := threePages(t, true)
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{data[:bytes.Index(data, []byte("/Kids"))+8], "one,two,three"},
+		{data[:bytes.Index(data, []byte("(three)"))], "one,two"},
+		{objstm[:bytes.LastIndex(objstm, []byte("/Type /Page>>"))-10], "one,two"},
+	}
+	for _, tt := range tests {
+		pages, err := repaired(tt.data)
+		if got := strings.Join(pages, ","); err != nil || got != tt.want {
+			t.Errorf("cut at %q: pages %s, %v; want %s", tt.data[len(tt.data)-20:], got, err, tt.want)
+		}
+	}
+}
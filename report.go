@@ -0,0 +1,209 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Reporting the resources a document uses and the space they take.
+
+package pdf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Report summarizes the resources a document uses and the space
+// its objects take in the file, to help find what makes it large.
+type Report struct {
+	FileSize int64
+	Fonts    []FontUsage // the fonts used by the pages, in order of first use
+	Images   []DocImage  // the images used by the pages, as Images returns them
+	Pages    []PageUsage // the resources of each page, in page order
+
+	// StreamBytes are the bytes taken by the data of the file's
+	// streams, totaled by filter chain: the names of the filters, in
+	// decoding order, separated by spaces, or "" for unfiltered data.
+	StreamBytes map[string]int64
+
+	// Largest are the largest objects in the file, largest first.
+	Largest []ObjectSize
+
+	// Errors are the objects that could not be read, and why.
+	Errors []Violation
+}
+
+// A FontUsage describes a font used by the document's pages.
+type FontUsage struct {
+	Ref       ObjRef // the font dictionary, or the zero ObjRef if it is direct
+	BaseFont  string
+	Subtype   string // such as Type1, TrueType or Type0
+	SubsetTag string // the tag marking the font as a subset, as Font.SubsetTag returns
+
+	// Embedded reports whether the font program is in the file, as it
+	// always is for Type 3 fonts, whose glyphs are content streams.
+	// ProgramBytes is the stored size of the embedded program.
+	Embedded     bool
+	ProgramBytes int64
+
+	Pages []int // the pages using the font, in order
+}
+
+// A PageUsage describes the resources of a page.
+type PageUsage struct {
+	Fonts        []ObjRef // the fonts of the page's resources and forms
+	Images       []ObjRef // the images of the page's resources and forms
+	ContentBytes int64    // stored size of the page's content streams
+	ImageBytes   int64    // stored size of its images
+}
+
+// An ObjectSize is the space an object takes in the file.
+type ObjectSize struct {
+	Ref  ObjRef
+	Type string // the object's Type entry, or else its Subtype entry
+	Size int64  // the size of the object written out, including its stream data
+}
+
+// reportLargest is the number of objects Report lists in Largest.
+const reportLargest = 10
+
+// Report reads the document's pages and every object in the file and
+// summarizes what they use, per page and for the document as a whole.
+// Fonts and images count once however many pages use them.
+//
+// An object's Size is that of its definition, stream data included,
+// as Save would write it; objects held in object streams count both
+// on their own and as part of the object stream.
+func (r *Reader) Report() *Report {
+	rep := &Report{FileSize: r.end, StreamBytes: make(map[string]int64)}
+	fonts := make(map[ObjRef]int)
+	for num := 1; num <= r.NumPage(); num++ {
+		p := r.Page(num)
+		var use PageUsage
+		for _, strm := range p.RawContents() {
+			use.ContentBytes += strm.Key("Length").CoerceInt64(0)
+		}
+		p.eachFont(func(ref ObjRef, f Value) {
+			use.Fonts = append(use.Fonts, ref)
+			i, ok := fonts[ref]
+			if !ok || ref == (ObjRef{}) {
+				i = len(rep.Fonts)
+				fonts[ref] = i
+				rep.Fonts = append(rep.Fonts, fontUsage(ref, f))
+			}
+			if pages := rep.Fonts[i].Pages; len(pages) == 0 || pages[len(pages)-1] != num {
+				rep.Fonts[i].Pages = append(pages, num)
+			}
+		})
+		p.eachImageXObject(func(name string, x Value) {
+			use.Images = append(use.Images, x.Ref())
+			use.ImageBytes += x.Key("Length").CoerceInt64(0)
+		})
+		rep.Pages = append(rep.Pages, use)
+	}
+	rep.Images = r.Images()
+
+	for _, x := range r.xref {
+		if x.ptr.id == 0 || !x.inStream && x.offset == 0 {
+			continue
+		}
+		size, err := rep.addObject(r, x.ptr)
+		if err != nil {
+			rep.Errors = append(rep.Errors, Violation{"malformed-object", err.Error(), ObjRef{x.ptr.id, x.ptr.gen}})
+			continue
+		}
+		rep.Largest = append(rep.Largest, size)
+	}
+	sort.SliceStable(rep.Largest, func(i, j int) bool { return rep.Largest[i].Size > rep.Largest[j].Size })
+	if len(rep.Largest) > reportLargest {
+		rep.Largest = rep.Largest[:reportLargest]
+	}
+	return rep
+}
+
+// addObject reads the object ptr, adding its stream data to
+// rep.StreamBytes, and returns its size.
+func (rep *Report) addObject(r *Reader, ptr pdfobjptr) (size ObjectSize, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	v := r.resolve(pdfobjptr{}, ptr)
+	if v.err != nil {
+		return ObjectSize{}, v.err
+	}
+	size = ObjectSize{Ref: ObjRef{ptr.id, ptr.gen}, Type: v.Key("Type").CoerceName("")}
+	if size.Type == "" {
+		size.Type = v.Key("Subtype").CoerceName("")
+	}
+	if strm, ok := v.data.(pdfstream); ok {
+		length := v.Key("Length").CoerceInt64(0)
+		rep.StreamBytes[strings.Join(filterNames(v), " ")] += length
+		size.Size = int64(len(appendObject(nil, strm.hdr))) + length
+	} else {
+		size.Size = int64(len(appendObject(nil, v.data)))
+	}
+	return size, nil
+}
+
+// fontUsage returns the FontUsage for the font dictionary f,
+// the object ref, without its pages.
+func fontUsage(ref ObjRef, f Value) FontUsage {
+	font := Font{V: f}
+	use := FontUsage{
+		Ref:       ref,
+		BaseFont:  font.BaseFont(),
+		Subtype:   f.Key("Subtype").CoerceName(""),
+		SubsetTag: font.SubsetTag(),
+		Embedded:  font.isType3(),
+	}
+	fd := font.descriptor()
+	for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		if prog := fd.Key(key); prog.Kind() == Stream {
+			use.Embedded = true
+			use.ProgramBytes = prog.Key("Length").CoerceInt64(0)
+			break
+		}
+	}
+	return use
+}
+
+// eachFont calls f for each font dictionary in the page's resources
+// and those of the form XObjects and Type 3 fonts in its resources,
+// in order of resource names, with the object holding it, or the zero
+// ObjRef if it is direct. Fonts that are objects of their own are
+// passed once.
+func (p Page) eachFont(f func(ref ObjRef, font Value)) {
+	seen := make(map[ObjRef]bool)
+	var walk func(res Value, depth int)
+	walk = func(res Value, depth int) {
+		fonts := res.Key("Font")
+		for _, name := range fonts.Keys() {
+			font := fonts.Key(name)
+			var ref ObjRef
+			if ptr, ok := fonts.entryRef(name); ok {
+				ref = ObjRef{ptr.id, ptr.gen}
+			}
+			if font.Kind() != Dict || ref != (ObjRef{}) && seen[ref] {
+				continue
+			}
+			seen[ref] = true
+			f(ref, font)
+			if font.Key("Subtype").CoerceName("") == "Type3" && depth < maxFormDepth {
+				walk(font.Key("Resources"), depth+1)
+			}
+		}
+		xobjs := res.Key("XObject")
+		for _, name := range xobjs.Keys() {
+			x := xobjs.Key(name)
+			if x.Key("Subtype").CoerceName("") != "Form" || seen[x.Ref()] {
+				continue
+			}
+			seen[x.Ref()] = true
+			if depth < maxFormDepth {
+				walk(x.Key("Resources"), depth+1)
+			}
+		}
+	}
+	walk(p.Resources(), 0)
+}
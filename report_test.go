@@ -0,0 +1,70 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	program := strings.Repeat("\x00", 500)
+	image := deflate(strings.Repeat("\x80", 100))
+	data := buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 5 0 R"+
+			" /Resources <</Font <</F1 6 0 R>> /XObject <</Im 8 0 R>>>>>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 5 0 R"+
+			" /Resources <</Font <</F1 6 0 R /F2 <</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>>>>>>>",
+		stream("", "BT /F1 12 Tf (hi) Tj ET"),
+		"<</Type /Font /Subtype /TrueType /BaseFont /ABCDEF+Arial /FontDescriptor 7 0 R>>",
+		"<</Type /FontDescriptor /FontName /ABCDEF+Arial /FontFile2 9 0 R>>",
+		stream("/Type /XObject /Subtype /Image /Width 10 /Height 10 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode", image),
+		stream("", program),
+	)
+	rep := openPDF(t, data).Report()
+	if rep.FileSize != int64(len(data)) {
+		t.Errorf("FileSize %d, want %d", rep.FileSize, len(data))
+	}
+	want := []FontUsage{
+		{Ref: ObjRef{6, 0}, BaseFont: "ABCDEF+Arial", Subtype: "TrueType", SubsetTag: "ABCDEF", Embedded: true, ProgramBytes: 500, Pages: []int{1, 2}},
+		{BaseFont: "Helvetica", Subtype: "Type1", Pages: []int{2}},
+	}
+	if !reflect.DeepEqual(rep.Fonts, want) {
+		t.Errorf("Fonts:\n%+v\nwant\n%+v", rep.Fonts, want)
+	}
+	if len(rep.Images) != 1 || rep.Images[0].Info.Width != 10 || rep.Images[0].Info.Length != int64(len(image)) {
+		t.Errorf("Images: %+v", rep.Images)
+	}
+	content := int64(len("BT /F1 12 Tf (hi) Tj ET"))
+	pages := []PageUsage{
+		{Fonts: []ObjRef{{6, 0}}, Images: []ObjRef{{8, 0}}, ContentBytes: content, ImageBytes: int64(len(image))},
+		{Fonts: []ObjRef{{6, 0}, {}}, ContentBytes: content},
+	}
+	if !reflect.DeepEqual(rep.Pages, pages) {
+		t.Errorf("Pages:\n%+v\nwant\n%+v", rep.Pages, pages)
+	}
+	streams := map[string]int64{"": content + 500, "FlateDecode": int64(len(image))}
+	if !reflect.DeepEqual(rep.StreamBytes, streams) {
+		t.Errorf("StreamBytes %v, want %v", rep.StreamBytes, streams)
+	}
+	if len(rep.Largest) != 9 || rep.Largest[0].Ref != (ObjRef{9, 0}) || rep.Largest[0].Size <= 500 {
+		t.Errorf("Largest: %+v", rep.Largest)
+	}
+	for i := 1; i < len(rep.Largest); i++ {
+		if rep.Largest[i].Size > rep.Largest[i-1].Size {
+			t.Errorf("Largest not in order of size: %+v", rep.Largest)
+		}
+	}
+	if got := fmt.Sprintf("%v %s", rep.Largest[1].Ref, rep.Largest[1].Type); got != "8 0 R XObject" {
+		t.Errorf("second largest object %s, want 8 0 R XObject", got)
+	}
+	if rep.Errors != nil {
+		t.Errorf("Errors: %v", rep.Errors)
+	}
+}
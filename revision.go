@@ -0,0 +1,289 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Analyzing the revisions made by incremental updates (PDF 32000-1:2008, §7.5.6).
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A Revision is one revision of the file: the document as first
+// written, or an incremental update appended to it.
+type Revision struct {
+	Xref    int64    // offset of the revision's cross-reference section
+	End     int64    // length of the file up to the end of the revision
+	Changed []ObjRef // objects the revision defines, in order of number
+
+	// Pages are the numbers of the pages, in the document as it is
+	// now, that the revision changed: those whose page object, or an
+	// object reachable from it, the revision defines.
+	Pages []int
+
+	// Signed reports whether a signature covers the revision, and
+	// Signatures are the fields of the signatures signing the file
+	// exactly as it was at the end of the revision.
+	Signed     bool
+	Signatures []string
+}
+
+// A RevisionHistory describes the revisions of the file.
+type RevisionHistory struct {
+	Revisions []Revision // oldest first
+	Updates   int        // number of incremental updates: len(Revisions)-1
+
+	// SignedLength is the number of bytes of the file covered by the
+	// signature covering the most, or 0 if the document is unsigned.
+	// If ChangedAfterSigning is set, revisions follow that signature's
+	// ByteRange; they are those not Signed.
+	SignedLength        int64
+	ChangedAfterSigning bool
+}
+
+// RevisionHistory follows the chain of cross-reference sections from
+// the last and returns the revisions of the file, with the objects and
+// pages each changed, and whether any were made after the document was
+// signed. The sections of a linearized file's first page and of the
+// rest of its document, the first leading to the later second, count
+// as one revision.
+//
+// A page counts as changed when an object reachable from it is,
+// not following Parent entries or into other pages, or when the
+// resources it inherits from a page tree node are.
+func (r *Reader) RevisionHistory() (h *RevisionHistory, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			h, err = nil, fmt.Errorf("pdf: reading revisions: %v", e)
+		}
+	}()
+
+	// Cross-reference streams are never encrypted, so read them with
+	// a Reader that does not decrypt.
+	plain := &Reader{f: r.f, end: r.end}
+	var groups [][]int64 // offsets of each revision's sections, newest revision first
+	seen := make(map[int64]bool)
+	for off, ok := r.startxref, true; ok; {
+		if seen[off] {
+			return nil, fmt.Errorf("pdf: reading revisions: cross-reference sections form a loop at offset %d", off)
+		}
+		seen[off] = true
+		prev, hasPrev, err := readXrefPrev(plain, off)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(groups); n > 0 && off > groups[n-1][len(groups[n-1])-1] {
+			groups[n-1] = append(groups[n-1], off)
+		} else {
+			groups = append(groups, []int64{off})
+		}
+		off, ok = prev, hasPrev
+	}
+
+	h = &RevisionHistory{Updates: len(groups) - 1}
+	for i := len(groups) - 1; i >= 0; i-- {
+		rev := Revision{Xref: groups[i][0], End: r.end}
+		if i > 0 {
+			last := groups[i][0]
+			for _, off := range groups[i] {
+				last = max(last, off)
+			}
+			rev.End = revisionEnd(r, last)
+		}
+		objs := make(map[pdfobjptr]bool)
+		for _, off := range groups[i] {
+			table, err := readXrefSection(plain, off)
+			if err != nil {
+				return nil, err
+			}
+			for _, x := range table {
+				if x.ptr.id != 0 && (x.inStream || x.offset != 0) {
+					objs[x.ptr] = true
+				}
+			}
+		}
+		for ptr := range objs {
+			rev.Changed = append(rev.Changed, ObjRef{ptr.id, ptr.gen})
+		}
+		sort.Slice(rev.Changed, func(i, j int) bool { return rev.Changed[i].ID < rev.Changed[j].ID })
+		h.Revisions = append(h.Revisions, rev)
+	}
+
+	for _, sig := range r.Signatures() {
+		if len(sig.ByteRange) == 0 {
+			continue
+		}
+		br := sig.ByteRange[len(sig.ByteRange)-1]
+		end := br[0] + br[1]
+		h.SignedLength = max(h.SignedLength, end)
+		for i := range h.Revisions {
+			if h.Revisions[i].End == end {
+				h.Revisions[i].Signatures = append(h.Revisions[i].Signatures, sig.Field)
+			}
+		}
+	}
+	for i := range h.Revisions {
+		h.Revisions[i].Signed = h.Revisions[i].End <= h.SignedLength
+	}
+	h.ChangedAfterSigning = h.SignedLength > 0 && h.SignedLength < r.end
+
+	for num := 1; num <= r.NumPage(); num++ {
+		objs := pageObjects(r.Page(num))
+		for i := range h.Revisions {
+			rev := &h.Revisions[i]
+			for _, ref := range rev.Changed {
+				if objs[pdfobjptr{ref.ID, ref.Gen}] {
+					rev.Pages = append(rev.Pages, num)
+					break
+				}
+			}
+		}
+	}
+	return h, nil
+}
+
+// readXrefPrev returns the Prev entry of the trailer of the
+// cross-reference section at offset off in the file of r.
+func readXrefPrev(r *Reader, off int64) (prev int64, ok bool, err error) {
+	b := newPdfBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
+	var trailer pdfdict
+	if tok := b.readToken(); tok == pdfkeyword("xref") {
+		if _, err := readXrefTableData(b, nil); err != nil {
+			return 0, false, fmt.Errorf("malformed PDF: %v", err)
+		}
+		trailer, _ = b.readObject().(pdfdict)
+	} else {
+		b.unreadToken(tok)
+		def, _ := b.readObject().(pdfobjdef)
+		strm, _ := def.obj.(pdfstream)
+		trailer = strm.hdr
+	}
+	if trailer == nil {
+		return 0, false, fmt.Errorf("malformed PDF: no cross-reference section at offset %d", off)
+	}
+	x, ok := trailer["Prev"]
+	if !ok {
+		return 0, false, nil
+	}
+	if prev, ok = x.(int64); !ok || prev < 0 || prev >= r.end {
+		return 0, false, fmt.Errorf("malformed PDF: xref Prev is not an offset in the file: %v", objfmt(x))
+	}
+	return prev, true, nil
+}
+
+// readXrefSection returns the entries of the cross-reference section
+// at offset off in the file of r alone, not those of earlier sections.
+func readXrefSection(r *Reader, off int64) ([]xref, error) {
+	b := newPdfBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
+	tok := b.readToken()
+	if tok == pdfkeyword("xref") {
+		table, err := readXrefTableData(b, nil)
+		if err != nil {
+			return nil, fmt.Errorf("malformed PDF: %v", err)
+		}
+		return table, nil
+	}
+	b.unreadToken(tok)
+	def, _ := b.readObject().(pdfobjdef)
+	strm, ok := def.obj.(pdfstream)
+	if !ok || strm.hdr["Type"] != pdfname("XRef") {
+		return nil, fmt.Errorf("malformed PDF: cross-reference stream not found at offset %d", off)
+	}
+	size, _ := strm.hdr["Size"].(int64)
+	if size < 0 || size > maxObjectID+1 {
+		return nil, fmt.Errorf("malformed PDF: xref stream Size %d out of range", size)
+	}
+	table, err := readXrefStreamData(r, strm, make([]xref, size), size)
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDF: %v", err)
+	}
+	return table, nil
+}
+
+// revisionEnd returns the end of the revision whose last
+// cross-reference section is at offset off: the end of the line
+// holding the %%EOF marker that follows the section.
+func revisionEnd(r *Reader, off int64) int64 {
+	end := indexAt(r.f, r.end, off, []byte("%%EOF"))
+	if end < 0 {
+		return r.end
+	}
+	end += int64(len("%%EOF"))
+	var eol [2]byte
+	n, _ := r.f.ReadAt(eol[:], end)
+	switch {
+	case n == 2 && eol == [2]byte{'\r', '\n'}:
+		end += 2
+	case n >= 1 && (eol[0] == '\n' || eol[0] == '\r'):
+		end++
+	}
+	return end
+}
+
+// pageObjects returns the objects reachable from the page p, not
+// following Parent entries or into other pages, page tree nodes or
+// the catalog, together with the resources p inherits, and the page
+// tree node holding them if they are direct.
+func pageObjects(p Page) map[pdfobjptr]bool {
+	r := p.V.r
+	seen := make(map[pdfobjptr]bool)
+	if r == nil {
+		return seen
+	}
+	var visit func(x pdfobject)
+	visit = func(x pdfobject) {
+		switch x := x.(type) {
+		case pdfobjptr:
+			if seen[x] {
+				return
+			}
+			v := r.resolve(pdfobjptr{}, x)
+			if d, ok := v.data.(pdfdict); ok && x != p.V.ptr {
+				switch d["Type"] {
+				case pdfname("Page"), pdfname("Pages"), pdfname("Catalog"):
+					return
+				}
+			}
+			seen[x] = true
+			visit(v.data)
+		case pdfstream:
+			visit(x.hdr)
+		case pdfarray:
+			for _, y := range x {
+				visit(y)
+			}
+		case pdfdict:
+			for k, y := range x {
+				if k != "Parent" {
+					visit(y)
+				}
+			}
+		}
+	}
+	if p.V.ptr != (pdfobjptr{}) {
+		visit(p.V.ptr)
+	} else {
+		visit(p.V.data)
+	}
+
+	// Of the inheritable attributes, only Resources holds references.
+	// The ancestor holding the resources changes with them if they
+	// are direct.
+	if p.V.Key("Resources").Kind() == Null {
+		v := p.V.Key("Parent")
+		for depth := 0; v.Kind() == Dict && depth < maxPageTreeDepth; depth++ {
+			if res, ok := v.data.(pdfdict)["Resources"]; ok {
+				if _, ok := res.(pdfobjptr); !ok {
+					seen[v.ptr] = true
+				}
+				visit(res)
+				break
+			}
+			v = v.Key("Parent")
+		}
+	}
+	return seen
+}
@@ -0,0 +1,126 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// history returns the revision history of the document data.
+func history(t *testing.T, data []byte) *RevisionHistory {
+	t.Helper()
+	h, err := openPDF(t, data).RevisionHistory()
+	if err != nil {
+		t.Fatalf("RevisionHistory: %v", err)
+	}
+	return h
+}
+
+func TestRevisionHistory(t *testing.T) {
+	for _, objstm := range []bool{false, true} {
+		data := threePages(t, objstm)
+		h := history(t, data)
+		if h.Updates != 0 || len(h.Revisions) != 1 {
+			t.Fatalf("object streams %v: %d updates, %d revisions; want 0, 1", objstm, h.Updates, len(h.Revisions))
+		}
+		if rev := h.Revisions[0]; rev.End != int64(len(data)) || len(rev.Changed) == 0 || fmt.Sprint(rev.Pages) != "[1 2 3]" {
+			t.Errorf("object streams %v: revision ends at %d, changes %d objects and pages %v; want %d, some, [1 2 3]",
+				objstm, rev.End, len(rev.Changed), rev.Pages, len(data))
+		}
+
+		// Updating the Info dictionary changes no pages; rotating the
+		// second page changes it alone.
+		r := openPDF(t, data)
+		r.SetInfo(Info{Title: "Updated"})
+		var buf bytes.Buffer
+		if err := r.SaveIncremental(&buf); err != nil {
+			t.Fatal(err)
+		}
+		first := bytes.Clone(buf.Bytes())
+		r = openPDF(t, first)
+		page := r.Page(2).V.Ref()
+		r.Page(2).V.SetKey("Rotate", NewInt(90))
+		buf.Reset()
+		if err := r.SaveIncremental(&buf); err != nil {
+			t.Fatal(err)
+		}
+		h = history(t, buf.Bytes())
+		if h.Updates != 2 || len(h.Revisions) != 3 {
+			t.Fatalf("object streams %v: after updates, %d updates, %d revisions; want 2, 3", objstm, h.Updates, len(h.Revisions))
+		}
+		ends := []int{len(data), len(first), buf.Len()}
+		pages := []string{"[1 2 3]", "[]", "[2]"}
+		for i, rev := range h.Revisions {
+			if rev.End != int64(ends[i]) || fmt.Sprint(rev.Pages) != pages[i] {
+				t.Errorf("object streams %v: revision %d ends at %d, changes pages %v; want %d, %s",
+					objstm, i, rev.End, rev.Pages, ends[i], pages[i])
+			}
+		}
+		changed := false
+		for _, ref := range h.Revisions[2].Changed {
+			changed = changed || ref == page
+		}
+		if !changed {
+			t.Errorf("object streams %v: last revision changes %v, not page %v", objstm, h.Revisions[2].Changed, page)
+		}
+		if h.SignedLength != 0 || h.ChangedAfterSigning || h.Revisions[0].Signed {
+			t.Errorf("object streams %v: unsigned document is signed", objstm)
+		}
+	}
+
+	// A linearized file's two sections are one revision.
+	var buf bytes.Buffer
+	if err := openPDF(t, threePages(t, false)).SaveLinearized(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if h := history(t, buf.Bytes()); h.Updates != 0 || fmt.Sprint(h.Revisions[0].Pages) != "[1 2 3]" {
+		t.Errorf("linearized: %d updates, first revision changes pages %v; want 0, [1 2 3]", h.Updates, h.Revisions[0].Pages)
+	}
+}
+
+func TestRevisionHistorySigned(t *testing.T) {
+	s := newTestSigner(t)
+	data := unsignedPDF()
+	start, end := contentsHole(data)
+	s.sign(t, data, 0, start, end, len(data)-end)
+
+	h := history(t, data)
+	rev := h.Revisions[0]
+	if h.SignedLength != int64(len(data)) || h.ChangedAfterSigning || !rev.Signed || fmt.Sprint(rev.Signatures) != "[Approval]" {
+		t.Errorf("signed: SignedLength %d, ChangedAfterSigning %v, Signed %v, Signatures %v; want %d, false, true, [Approval]",
+			h.SignedLength, h.ChangedAfterSigning, rev.Signed, rev.Signatures, len(data))
+	}
+
+	r := openPDF(t, data)
+	r.Page(1).V.Key("Contents").SetKey("Filter", NewName("FlateDecode"))
+	var buf bytes.Buffer
+	if err := r.SaveIncremental(&buf); err != nil {
+		t.Fatal(err)
+	}
+	h = history(t, buf.Bytes())
+	if h.SignedLength != int64(len(data)) || !h.ChangedAfterSigning || len(h.Revisions) != 2 {
+		t.Fatalf("updated: SignedLength %d, ChangedAfterSigning %v, %d revisions; want %d, true, 2",
+			h.SignedLength, h.ChangedAfterSigning, len(h.Revisions), len(data))
+	}
+	if rev := h.Revisions[1]; rev.Signed || rev.Signatures != nil || fmt.Sprint(rev.Pages) != "[1]" {
+		t.Errorf("update: Signed %v, Signatures %v, Pages %v; want false, [], [1]", rev.Signed, rev.Signatures, rev.Pages)
+	}
+	if rev := h.Revisions[0]; !rev.Signed || fmt.Sprint(rev.Signatures) != "[Approval]" {
+		t.Errorf("signed revision: Signed %v, Signatures %v; want true, [Approval]", rev.Signed, rev.Signatures)
+	}
+}
+
+func TestXrefSectionSize(t *testing.T) {
+	for _, size := range []string{"-1", "99999999999"} {
+		data := []byte("1 0 obj\n<</Type /XRef /Size " + size + " /W [1 1 1] /Length 0>>\nstream\n\nendstream\nendobj\n")
+		r := &Reader{f: bytes.NewReader(data), end: int64(len(data))}
+		_, err := readXrefSection(r, 0)
+		if want := "malformed PDF: xref stream Size " + size + " out of range"; err == nil || err.Error() != want {
+			t.Errorf("Size %s: error %v, want %q", size, err, want)
+		}
+	}
+}
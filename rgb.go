@@ -0,0 +1,93 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Conversion of device CMYK and CIE L*a*b* colors to RGB.
+
+package pdf
+
+import "math"
+
+// CMYKToRGB converts a DeviceCMYK color to RGB, with all components
+// in [0, 1]. Without the output intent's ICC profile the conversion can
+// only be approximate. It uses a polynomial fitted to a typical press
+// profile (U.S. Web Coated SWOP), which renders inks much closer to
+// their printed appearance than the naive (1-c)(1-k) formula, whose
+// 100% black is pure black and whose cyan is pure 0 255 255.
+func CMYKToRGB(c, m, y, k float64) (r, g, b float64) {
+	c, m, y, k = clamp01(c), clamp01(m), clamp01(y), clamp01(k)
+	r = 255 +
+		c*(-4.387332384609988*c+54.48615194189176*m+18.82290502165302*y+212.25662451639585*k-285.2331026137004) +
+		m*(1.7149763477362134*m-5.6096736904047315*y-17.873870861415444*k-5.497006427196366) +
+		y*(-2.5217340131683033*y-21.248923337353073*k+17.5119270841813) +
+		k*(-21.86122147463605*k-189.48180835922747)
+	g = 255 +
+		c*(8.841041422036149*c+60.118027045597366*m+6.871425592049007*y+31.159100130055922*k-79.2970844816548) +
+		m*(-15.310361306967817*m+17.575251261109482*y+131.35250912493976*k-190.9453302588951) +
+		y*(4.444339102852739*y+9.8632861493405*k-24.86741582555878) +
+		k*(-20.737325471181034*k-187.80453709719578)
+	b = 255 +
+		c*(0.8842522430003296*c+8.078677503112928*m+30.89978309703729*y-0.23883238689178934*k-14.183576799673286) +
+		m*(10.49593273432072*m+63.02378494754052*y+50.606957656360734*k-112.23884253719248) +
+		y*(0.03296041114873217*y+115.60384449646641*k-193.58209356861505) +
+		k*(-22.33816807309886*k-180.12613974708367)
+	return clamp01(r / 255), clamp01(g / 255), clamp01(b / 255)
+}
+
+// d65 is the CIE XYZ tristimulus value of the D65 white point,
+// the white point of sRGB.
+var d65 = [3]float64{0.9505, 1, 1.089}
+
+// LabToRGB converts a CIE L*a*b* color, with L* in [0, 100], to sRGB
+// with components in [0, 1]. The color is adapted from the white point
+// of its Lab color space to that of sRGB by scaling, so that white
+// maps to white whatever the white point; this is adequate for the D50
+// and D65 white points used in practice. Colors outside the sRGB gamut
+// are clipped.
+func LabToRGB(l, a, b float64) (r, g, bl float64) {
+	// L*a*b* to XYZ (PDF 32000-1:2008, §8.6.5.4),
+	// relative to the D65 white point.
+	fy := (l + 16) / 116
+	fx := fy + a/500
+	fz := fy - b/200
+	x := d65[0] * labInverse(fx)
+	y := d65[1] * labInverse(fy)
+	z := d65[2] * labInverse(fz)
+
+	// XYZ to linear sRGB, then gamma encoding.
+	r = 3.2406*x - 1.5372*y - 0.4986*z
+	g = -0.9689*x + 1.8758*y + 0.0415*z
+	bl = 0.0557*x - 0.2040*y + 1.0570*z
+	return srgbGamma(r), srgbGamma(g), srgbGamma(bl)
+}
+
+// labInverse is the inverse of the cube root function
+// of the L*a*b* transformation, linear near zero.
+func labInverse(t float64) float64 {
+	if t >= 6.0/29 {
+		return t * t * t
+	}
+	return 108.0 / 841 * (t - 4.0/29)
+}
+
+// srgbGamma applies the sRGB transfer function to a linear component.
+func srgbGamma(x float64) float64 {
+	x = clamp01(x)
+	if x <= 0.0031308 {
+		return 12.92 * x
+	}
+	return 1.055*math.Pow(x, 1/2.4) - 0.055
+}
+
+func clamp01(x float64) float64 {
+	return math.Max(0, math.Min(x, 1))
+}
+
+// labRange returns the ranges [amin amax bmin bmax] of the a* and b*
+// components of the Lab color space def, defaulting to [-100 100 -100 100].
+func labRange(def Value) []float64 {
+	if r := floats(def.Index(1).Key("Range")); len(r) == 4 {
+		return r
+	}
+	return []float64{-100, 100, -100, 100}
+}
@@ -0,0 +1,94 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// near reports whether the components are within 0.005 of want.
+func near(r, g, b float64, want [3]float64) bool {
+	return math.Abs(r-want[0]) < 0.005 && math.Abs(g-want[1]) < 0.005 && math.Abs(b-want[2]) < 0.005
+}
+
+func TestCMYKToRGB(t *testing.T) {
+	tests := []struct {
+		cmyk [4]float64
+		want [3]float64
+	}{
+		{[4]float64{0, 0, 0, 0}, [3]float64{1, 1, 1}},
+		// Inks are not pure: black is not 0 0 0, nor cyan 0 1 1.
+		{[4]float64{0, 0, 0, 1}, [3]float64{0.171, 0.182, 0.206}},
+		{[4]float64{1, 0, 0, 0}, [3]float64{0, 0.724, 0.948}},
+		{[4]float64{0, 1, 0, 0}, [3]float64{0.985, 0.191, 0.601}},
+		{[4]float64{0, 0, 1, 0}, [3]float64{1, 0.920, 0.241}},
+		{[4]float64{1, 1, 1, 1}, [3]float64{0.024, 0.025, 0.047}},
+		// Components are clamped to [0, 1].
+		{[4]float64{-1, 2, 0, 0}, [3]float64{0.985, 0.191, 0.601}},
+	}
+	for _, tt := range tests {
+		if r, g, b := CMYKToRGB(tt.cmyk[0], tt.cmyk[1], tt.cmyk[2], tt.cmyk[3]); !near(r, g, b, tt.want) {
+			t.Errorf("CMYKToRGB%v = %.3f %.3f %.3f, want %v", tt.cmyk, r, g, b, tt.want)
+		}
+	}
+}
+
+func TestLabToRGB(t *testing.T) {
+	tests := []struct {
+		lab  [3]float64
+		want [3]float64
+	}{
+		{[3]float64{100, 0, 0}, [3]float64{1, 1, 1}},
+		{[3]float64{0, 0, 0}, [3]float64{0, 0, 0}},
+		{[3]float64{50, 0, 0}, [3]float64{0.466, 0.466, 0.466}},
+		{[3]float64{50, 80, 0}, [3]float64{0.911, 0, 0.479}},
+		{[3]float64{50, 0, -80}, [3]float64{0, 0.503, 1}},
+		// Out of the sRGB gamut.
+		{[3]float64{100, 128, 128}, [3]float64{1, 0.256, 0}},
+	}
+	for _, tt := range tests {
+		if r, g, b := LabToRGB(tt.lab[0], tt.lab[1], tt.lab[2]); !near(r, g, b, tt.want) {
+			t.Errorf("LabToRGB%v = %.3f %.3f %.3f, want %v", tt.lab, r, g, b, tt.want)
+		}
+	}
+}
+
+func TestLabColors(t *testing.T) {
+	lab := "[/Lab <</WhitePoint [0.9505 1 1.089] /Range [-128 127 -128 127]>>]"
+	data := pagePDF("<</ColorSpace <</L "+lab+" /I [/Indexed "+lab+" 0 <ff8080>]>> /XObject <</Im0 5 0 R>>>>",
+		"/L cs 50 80 0 sc /I CS 0 SC 0 0 m 1 1 l B\n",
+		stream("/Subtype /Image /Width 2 /Height 1 /BitsPerComponent 8 /ColorSpace "+lab, "\x00\x80\x80\xff\x80\x80"),
+	)
+	p := openPDF(t, data).Page(1)
+	c, err := p.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Paths) != 1 {
+		t.Fatalf("%d paths, want 1", len(c.Paths))
+	}
+	if got, want := colorString(c.Paths[0].FillColor), "Lab [50 80 0] = 0.91 0.00 0.48"; got != want {
+		t.Errorf("fill color %s, want %s", got, want)
+	}
+	// Color table entries are mapped onto L* in [0, 100] and a* and b* in the Range.
+	if r, g, b, _ := c.Paths[0].StrokeColor.RGB(); !near(r, g, b, [3]float64{1, 1, 1}) {
+		t.Errorf("indexed Lab stroke color %.3f %.3f %.3f, want white", r, g, b)
+	}
+
+	imgs := p.Images()
+	if len(imgs) != 1 || imgs[0].Err != nil {
+		t.Fatalf("Images() = %+v", imgs)
+	}
+	var got string
+	for x := 0; x < 2; x++ {
+		r, g, b, _ := imgs[0].Image.At(x, 0).RGBA()
+		got += fmt.Sprintf(" %02x%02x%02x", r>>8, g>>8, b>>8)
+	}
+	if got != " 000000 ffffff" {
+		t.Errorf("Lab image pixels%s, want 000000 ffffff", got)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Writing a document read by a Reader, with any changes made to it.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+)
+
+// Save writes the document, with the changes made through SetKey,
+// SetIndex and the other editing methods, to w as a new PDF file.
+// Only the objects reachable from the trailer's Root and Info entries
+// are written, so objects no longer used are dropped; objects are
+// renumbered in the order they are reached. The file is written
+// unencrypted, with streams kept in their original encoding, or
+// compressed if they had none.
+func (r *Reader) Save(w io.Writer) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("pdf: saving: %v", e)
+		}
+	}()
+	wr := NewWriter(w)
+	return newObjCopier(r, wr).save()
+}
+
+// savedTrailerKeys are the trailer entries Save keeps.
+var savedTrailerKeys = []pdfname{"Root", "Info", "ID"}
+
+// save copies the document from the trailer on
+// and completes the file.
+func (c *objCopier) save() error {
+	trailer := pdfdict{}
+	for _, key := range savedTrailerKeys {
+		if x := c.r.trailerEntry(key); x != nil {
+			trailer[key] = c.copy(x)
+		}
+	}
+	c.flush()
+	c.w.writeTrailer(trailer)
+	if c.w.err != nil {
+		return c.w.err
+	}
+	return c.w.w.Flush()
+}
+
+// trailerEntry returns the entry key of the trailer dictionary.
+func (r *Reader) trailerEntry(key pdfname) pdfobject {
+	switch t := r.Trailer.data.(type) {
+	case pdfdict:
+		return t[key]
+	case pdfstream:
+		return t.hdr[key]
+	}
+	return nil
+}
+
+// An objCopier copies objects from a Reader to a Writer,
+// giving them new numbers in the order they are reached.
+type objCopier struct {
+	r     *Reader
+	w     *Writer
+	refs  map[pdfobjptr]pdfobjptr // new number of each object copied
+	queue []pdfobjptr             // objects numbered but not yet written
+	same  map[pdfobjptr]pdfobjptr // objects replaced by identical ones, if deduplicating
+
+	// expand selects the expanded form written by SaveExpanded:
+	// objects keep their numbers and streams are decoded.
+	expand bool
+
+	// repair selects the leniency of Repair: references to missing
+	// objects are dropped, and objects that cannot be read written as null.
+	repair bool
+}
+
+func newObjCopier(r *Reader, w *Writer) *objCopier {
+	return &objCopier{r: r, w: w, refs: make(map[pdfobjptr]pdfobjptr)}
+}
+
+// ref returns the new number of the object old,
+// queueing the object to be written if it is new.
+func (c *objCopier) ref(old pdfobjptr) pdfobjptr {
+	old = c.canon(old)
+	if ptr, ok := c.refs[old]; ok {
+		return ptr
+	}
+	var ptr pdfobjptr
+	if c.expand && old.id > 0 && old.id < c.r.numObjects() {
+		ptr = old
+	} else {
+		ptr = c.w.alloc()
+	}
+	c.refs[old] = ptr
+	c.queue = append(c.queue, old)
+	return ptr
+}
+
+// copy returns a copy of the direct object x
+// with its references renumbered.
+func (c *objCopier) copy(x pdfobject) pdfobject {
+	switch x := x.(type) {
+	case pdfobjptr:
+		if c.repair && !c.r.exists(x) {
+			return nil
+		}
+		return c.ref(x)
+	case pdfstream:
+		return c.ref(x.ptr)
+	case pdfarray:
+		y := make(pdfarray, len(x))
+		for i, v := range x {
+			y[i] = c.copy(v)
+		}
+		return y
+	case pdfdict:
+		y := make(pdfdict, len(x))
+		for k, v := range x {
+			y[k] = c.copy(v)
+		}
+		return y
+	}
+	return x
+}
+
+// flush writes the queued objects and those they refer to.
+func (c *objCopier) flush() {
+	for len(c.queue) > 0 {
+		old := c.queue[0]
+		c.queue = c.queue[1:]
+		c.write(old, c.refs[old])
+	}
+}
+
+// write writes the object old of the Reader as the object ptr.
+func (c *objCopier) write(old, ptr pdfobjptr) {
+	if c.repair {
+		defer func() {
+			if recover() != nil {
+				c.w.writeObject(ptr, nil)
+			}
+		}()
+	}
+	v := c.r.resolve(pdfobjptr{}, old)
+	strm, ok := v.data.(pdfstream)
+	if !ok {
+		c.w.writeObject(ptr, c.copy(v.data))
+		return
+	}
+	data, decoded := []byte(nil), false
+	if c.expand {
+		data, decoded = expandedStream(v)
+	}
+	if !decoded {
+		var err error
+		data, err = io.ReadAll(v.decode(nil))
+		if err != nil {
+			panic(fmt.Errorf("reading stream %d %d R: %v", old.id, old.gen, err))
+		}
+	}
+	// writeStream sets the Length, which is not copied
+	// in case it is an indirect object no longer needed.
+	hdr := make(pdfdict, len(strm.hdr))
+	for k, v := range strm.hdr {
+		switch {
+		case k == "Length":
+		case decoded && (k == "Filter" || k == "DecodeParms" || k == "DL"):
+		default:
+			hdr[k] = c.copy(v)
+		}
+	}
+	c.w.writeStream(ptr, hdr, data)
+}
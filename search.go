@@ -0,0 +1,168 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Searching for text in a document.
+
+package pdf
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// A Quad is a quadrilateral on the page, in points, given by its corners
+// in counterclockwise order starting at the lower left.
+type Quad [4]Point
+
+func rectQuad(r Rectangle) Quad {
+	return Quad{r.Min, {r.Max.X, r.Min.Y}, r.Max, {r.Min.X, r.Max.Y}}
+}
+
+// SearchOptions controls Reader.Search.
+type SearchOptions struct {
+	// CaseSensitive requires the case of the text to match the term.
+	CaseSensitive bool
+
+	// Context is the number of characters of surrounding text
+	// to include on each side of a match. Zero means 30.
+	Context int
+}
+
+// A Match is a single occurrence of a search term.
+type Match struct {
+	Page    int    // page number, starting at 1
+	Quads   []Quad // the area covered by the match, one quad per line it spans
+	Context string // the match with the surrounding text
+}
+
+// Search returns the occurrences of term in the document, in page order.
+// Runs of white space in term match any run of white space in the text,
+// including line breaks, and ligatures in the text match their component letters.
+func (r *Reader) Search(term string, opt SearchOptions) ([]Match, error) {
+	if opt.Context == 0 {
+		opt.Context = 30
+	}
+	needle := searchRunes([]rune(term), opt)
+	if len(needle) == 0 {
+		return nil, nil
+	}
+	var matches []Match
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		if p.V.Kind() == Null {
+			return nil, fmt.Errorf("page %d: page not found", i)
+		}
+		c, err := p.Content()
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", i, err)
+		}
+		for _, m := range searchText(mergeFakeBold(c.Text), needle, opt) {
+			m.Page = i
+			matches = append(matches, m)
+		}
+	}
+	return matches, nil
+}
+
+// searchRunes normalizes rs for matching: white space runs collapse
+// to a single space and, unless opt.CaseSensitive, letters are lower-cased.
+func searchRunes(rs []rune, opt SearchOptions) []rune {
+	var out []rune
+	for _, r := range rs {
+		if unicode.IsSpace(r) {
+			if len(out) > 0 && out[len(out)-1] == ' ' {
+				continue
+			}
+			r = ' '
+		} else if !opt.CaseSensitive {
+			r = unicode.ToLower(r)
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// A searchGlyph is a character of page text prepared for matching.
+type searchGlyph struct {
+	r    rune // normalized character
+	orig rune // character as it appears in the text
+	line int  // index of the line, or -1 for a line break
+	box  Rectangle
+}
+
+func searchText(text []Text, needle []rune, opt SearchOptions) []Match {
+	// Flatten the page into a single run of glyphs,
+	// normalized the same way as the search term.
+	var glyphs []searchGlyph
+	for i, l := range buildLines(text) {
+		if i > 0 {
+			glyphs = append(glyphs, searchGlyph{r: ' ', orig: ' ', line: -1})
+		}
+		rs, boxes := l.glyphs(defaultWordGap)
+		for j, r := range rs {
+			lig, ok := ligatures[r]
+			if !ok {
+				lig = string(r)
+			}
+			for _, r := range lig {
+				glyphs = append(glyphs, searchGlyph{r, r, i, boxes[j]})
+			}
+		}
+	}
+	hay := make([]searchGlyph, 0, len(glyphs))
+	for _, g := range glyphs {
+		n := searchRunes([]rune{g.r}, opt)
+		if n[0] == ' ' && len(hay) > 0 && hay[len(hay)-1].r == ' ' {
+			continue
+		}
+		g.r = n[0]
+		if g.r == ' ' {
+			g.orig = ' '
+		}
+		hay = append(hay, g)
+	}
+
+	var matches []Match
+Search:
+	for i := 0; i+len(needle) <= len(hay); i++ {
+		for j, r := range needle {
+			if hay[i+j].r != r {
+				continue Search
+			}
+		}
+		found := hay[i : i+len(needle)]
+
+		// One quad per line: the union of the glyph boxes on that line.
+		var m Match
+		line := -1
+		var box Rectangle
+		for _, g := range found {
+			if g.line < 0 {
+				continue
+			}
+			if g.line != line {
+				if line >= 0 {
+					m.Quads = append(m.Quads, rectQuad(box))
+				}
+				line, box = g.line, g.box
+				continue
+			}
+			box = unionRect(box, g.box)
+		}
+		if line >= 0 {
+			m.Quads = append(m.Quads, rectQuad(box))
+		}
+
+		lo := max(0, i-opt.Context)
+		hi := min(len(hay), i+len(needle)+opt.Context)
+		ctx := make([]rune, 0, hi-lo)
+		for k := lo; k < hi; k++ {
+			ctx = append(ctx, hay[k].orig)
+		}
+		m.Context = string(ctx)
+		matches = append(matches, m)
+		i += len(needle) - 1
+	}
+	return matches
+}
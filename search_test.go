@@ -0,0 +1,89 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// monoFont is a font whose characters are all half an em wide,
+// so that in show's 10-point text each is 5 points wide.
+// Its code 128 shows the fi ligature.
+var monoFont = "<</Type /Font /Subtype /Type1 /BaseFont /Courier" +
+	" /Encoding <</BaseEncoding /WinAnsiEncoding /Differences [128 /fi]>>" +
+	" /FirstChar 32 /LastChar 128 /Widths [" + strings.Repeat("500 ", 97) + "]>>"
+
+// monoPages returns a document of pages with the given contents,
+// in which F1 is monoFont.
+func monoPages(contents ...string) []byte {
+	objs := []string{"<</Type /Catalog /Pages 2 0 R>>", "", monoFont}
+	var kids []string
+	for _, c := range contents {
+		kids = append(kids, strconv.Itoa(len(objs)+1)+" 0 R")
+		objs = append(objs,
+			"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 3 0 R>>>> /Contents "+strconv.Itoa(len(objs)+2)+" 0 R>>",
+			stream("", c))
+	}
+	objs[1] = "<</Type /Pages /Kids [" + strings.Join(kids, " ") + "] /Count " + strconv.Itoa(len(kids)) + " /MediaBox [0 0 612 792]>>"
+	return buildPDF("", objs...)
+}
+
+func TestSearch(t *testing.T) {
+	r := openPDF(t, monoPages(
+		show(100, 700, "Search the page for a")+show(100, 688, "needle in the haystack."),
+		show(100, 700, "The NEEDLE is \x80ne, Needle."),
+	))
+	matches, err := r.Search("a  Needle", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Match{{
+		Page: 1,
+		Quads: []Quad{
+			rectQuad(Rectangle{Point{200, 698}, Point{205, 708}}),
+			rectQuad(Rectangle{Point{100, 686}, Point{130, 696}}),
+		},
+		Context: "Search the page for a needle in the haystack.",
+	}}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("Search(a Needle) = %+v\nwant %+v", matches, want)
+	}
+
+	matches, err = r.Search("needle", SearchOptions{Context: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range matches {
+		got = append(got, strconv.Itoa(m.Page)+": "+m.Context)
+	}
+	if want := []string{"1: r a needle in ", "2: The NEEDLE is ", "2: ne, Needle."}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Search(needle) found %q, want %q", got, want)
+	}
+
+	matches, err = r.Search("Needle", SearchOptions{CaseSensitive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Page != 2 {
+		t.Errorf("case-sensitive Search(Needle) = %+v, want one match on page 2", matches)
+	}
+
+	// The fi ligature matches its letters.
+	matches, err = r.Search("is fine", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("Search(is fine) = %+v, want one match", matches)
+	}
+
+	if matches, err := r.Search("", SearchOptions{}); matches != nil || err != nil {
+		t.Errorf("Search of nothing = %v, %v; want nil, nil", matches, err)
+	}
+}
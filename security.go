@@ -0,0 +1,143 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Reporting how a document is encrypted.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+)
+
+// A SecurityInfo describes how a document is encrypted, as its
+// encryption dictionary gives (PDF 32000-1:2008, §7.6).
+type SecurityInfo struct {
+	Encrypted bool
+	Handler   string // the security handler, such as Standard
+	SubFilter string // the format of the handler's data, if given
+	V, R      int    // the versions of the encryption algorithm and of the standard handler
+	KeyLength int    // the length of the file key in bits
+
+	// Cipher is the cipher encrypting streams: RC4, AESV2 or AESV3,
+	// or Identity if streams are not encrypted. StringCipher is the
+	// cipher encrypting strings, which may differ for V=4 and V=5.
+	Cipher       string
+	StringCipher string
+
+	EncryptMetadata bool // whether the metadata stream is encrypted
+
+	// Permissions are the operations allowed to a user who opens
+	// the document with the user password. For revision 2 of the
+	// standard handler, which defines only PermPrint, PermModify,
+	// PermCopy and PermAnnotate, the others follow them as viewers
+	// take them to: PermPrintHighQuality follows PermPrint,
+	// PermAssemble PermModify, PermAccessibility PermCopy and
+	// PermFillForms PermAnnotate.
+	Permissions Permission
+}
+
+// SecurityInfo reports how the document is encrypted. It reads only
+// the encryption dictionary, which is not encrypted itself, so it
+// needs no password; ReadSecurityInfo reports on a file that cannot be
+// opened without one. An unencrypted document has Encrypted false and
+// all permissions.
+func (r *Reader) SecurityInfo() (info *SecurityInfo, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			info, err = nil, fmt.Errorf("pdf: reading security: %v", e)
+		}
+	}()
+	e := r.Trailer.Key("Encrypt")
+	if e.Kind() == Null {
+		return &SecurityInfo{Permissions: PermAll, EncryptMetadata: true}, nil
+	}
+	if e.Kind() != Dict {
+		return nil, fmt.Errorf("pdf: reading security: Encrypt is %v, not a dictionary", e.Kind())
+	}
+	info = &SecurityInfo{
+		Encrypted:       true,
+		Handler:         e.Key("Filter").CoerceName(""),
+		SubFilter:       e.Key("SubFilter").CoerceName(""),
+		V:               int(e.Key("V").CoerceInt64(0)),
+		R:               int(e.Key("R").CoerceInt64(0)),
+		EncryptMetadata: true,
+	}
+	if m, ok := e.Key("EncryptMetadata").data.(bool); ok {
+		info.EncryptMetadata = m
+	}
+	length := int(e.Key("Length").CoerceInt64(40))
+	switch info.V {
+	case 0, 1:
+		info.KeyLength = 40
+		info.Cipher, info.StringCipher = "RC4", "RC4"
+	case 2, 3:
+		info.KeyLength = length
+		info.Cipher, info.StringCipher = "RC4", "RC4"
+	case 4, 5:
+		// Crypt filters name the ciphers (Table 25), and give the
+		// key length in bytes, or in bits as some writers do.
+		var bits int
+		info.Cipher, bits = cryptFilter(e, "StmF")
+		info.StringCipher, _ = cryptFilter(e, "StrF")
+		switch {
+		case info.V == 5:
+			info.KeyLength = 256
+		case bits != 0:
+			info.KeyLength = bits
+		case info.Cipher == "AESV2" || info.StringCipher == "AESV2":
+			info.KeyLength = 128
+		default:
+			info.KeyLength = length
+		}
+	}
+
+	p := Permission(uint32(e.Key("P").CoerceInt64(0))) & PermAll
+	if info.R == 2 {
+		p &^= PermPrintHighQuality | PermAssemble | PermAccessibility | PermFillForms
+		for _, x := range []struct{ base, follower Permission }{
+			{PermPrint, PermPrintHighQuality},
+			{PermModify, PermAssemble},
+			{PermCopy, PermAccessibility},
+			{PermAnnotate, PermFillForms},
+		} {
+			if p&x.base != 0 {
+				p |= x.follower
+			}
+		}
+	}
+	info.Permissions = p
+	return info, nil
+}
+
+// cryptFilter returns the cipher of the crypt filter that the entry
+// key of the encryption dictionary e names, and its key length in
+// bits, or 0 if the filter does not give it.
+func cryptFilter(e Value, key string) (cipher string, bits int) {
+	name := e.Key(key).CoerceName("Identity")
+	if name == "Identity" {
+		return "Identity", 0
+	}
+	cf := e.Key("CF").Key(name)
+	cipher = cf.Key("CFM").CoerceName("None")
+	if cipher == "V2" {
+		cipher = "RC4"
+	}
+	n := int(cf.Key("Length").CoerceInt64(0))
+	if n > 0 && n <= 32 {
+		n *= 8
+	}
+	return cipher, n
+}
+
+// ReadSecurityInfo reports how the file in f, with the given total
+// size, is encrypted, as Reader.SecurityInfo does, without the password
+// needed to open it.
+func ReadSecurityInfo(f io.ReaderAt, size int64) (*SecurityInfo, error) {
+	r, err := openReader(f, size)
+	if err != nil {
+		return nil, err
+	}
+	return r.SecurityInfo()
+}
@@ -0,0 +1,66 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecurityInfo(t *testing.T) {
+	perm := PermPrint | PermCopy | PermAccessibility
+	for _, tt := range []struct {
+		method    EncryptionMethod
+		v, r, key int
+		cipher    string
+	}{
+		{RC4128, 2, 3, 128, "RC4"},
+		{AES128, 4, 4, 128, "AESV2"},
+		{AES256, 5, 6, 256, "AESV3"},
+	} {
+		data := encryptedPDF(t, Encryption{Method: tt.method, UserPassword: "user", OwnerPassword: "owner", Permissions: perm})
+		info, err := ReadSecurityInfo(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Errorf("method %d: %v", tt.method, err)
+			continue
+		}
+		want := SecurityInfo{
+			Encrypted:       true,
+			Handler:         "Standard",
+			V:               tt.v,
+			R:               tt.r,
+			KeyLength:       tt.key,
+			Cipher:          tt.cipher,
+			StringCipher:    tt.cipher,
+			EncryptMetadata: true,
+			Permissions:     perm,
+		}
+		if *info != want {
+			t.Errorf("method %d: SecurityInfo = %+v, want %+v", tt.method, *info, want)
+		}
+	}
+
+	// Revision 2 defines fewer permissions, which imply the others.
+	r := &Reader{}
+	r.Trailer = Value{r: r, data: pdfdict{"Encrypt": pdfdict{
+		"Filter": pdfname("Standard"), "V": int64(1), "R": int64(2), "P": int64(-44),
+	}}}
+	info, err := r.SecurityInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := PermPrint | PermCopy | PermPrintHighQuality | PermAccessibility; info.Permissions != want || info.KeyLength != 40 || info.Cipher != "RC4" {
+		t.Errorf("revision 2: SecurityInfo = %+v, want permissions %#x", *info, want)
+	}
+
+	r = openPDF(t, pagePDF("<<>>", ""))
+	info, err = r.SecurityInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Encrypted || info.Permissions != PermAll {
+		t.Errorf("unencrypted: SecurityInfo = %+v", *info)
+	}
+}
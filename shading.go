@@ -0,0 +1,131 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Shadings (smooth gradients), PDF 32000-1:2008, §8.7.4.
+
+package pdf
+
+import "sort"
+
+// A Shading is a smooth color gradient painted on the page,
+// either directly by the sh operator or as the fill of a shading pattern.
+type Shading struct {
+	Type       int         // shading type: 1 function-based, 2 axial, 3 radial, 4–7 mesh
+	ColorSpace string      // color space family of the gradient colors
+	Coords     []float64   // the /Coords array of axial and radial shadings, in shading space
+	Extend     [2]bool     // whether the gradient extends beyond its start and end
+	Function   string      // a summary of the function computing the colors
+	Stops      []ColorStop // colors along an axial or radial gradient
+	Matrix     Matrix      // maps shading space to device space
+	Clip       Rectangle   // bounding box of the area painted, in device space
+}
+
+// A ColorStop is the color at a point along a gradient.
+type ColorStop struct {
+	Offset float64 // position along the gradient, from 0 (start) to 1 (end)
+	Color  Color
+}
+
+// newShading returns the Shading for the shading dictionary or stream sh,
+// painted with the shading-to-device matrix m and clipped to clip.
+func newShading(sh Value, m Matrix, clip Rectangle) Shading {
+	cs := sh.Key("ColorSpace")
+	s := Shading{
+		Type:       int(sh.Key("ShadingType").CoerceInt64(0)),
+		ColorSpace: colorSpaceFamily(cs),
+		Coords:     floats(sh.Key("Coords")),
+		Matrix:     m,
+		Clip:       clip,
+	}
+	fn := sh.Key("Function")
+	if fn.Kind() != Null {
+		s.Function = describeFunction(fn)
+	}
+	if ext := sh.Key("Extend"); ext.Len() == 2 {
+		s.Extend = [2]bool{ext.Index(0).data == true, ext.Index(1).data == true}
+	}
+	if s.Type != 2 && s.Type != 3 || fn.Kind() == Null {
+		return s
+	}
+
+	// Axial and radial shadings vary along a single parameter t
+	// over Domain; sample the function at the points where it changes
+	// character, and at regular intervals if it is not linear.
+	t0, t1 := 0.0, 1.0
+	if d := floats(sh.Key("Domain")); len(d) == 2 {
+		t0, t1 = d[0], d[1]
+	}
+	offsets := []float64{0, 1}
+	for _, b := range stitchBounds(fn) {
+		offsets = append(offsets, interpolate(b, t0, t1, 0, 1))
+	}
+	if !linearFunction(fn) {
+		for i := 1; i < 8; i++ {
+			offsets = append(offsets, float64(i)/8)
+		}
+	}
+	sort.Float64s(offsets)
+	for i, off := range offsets {
+		if i > 0 && off == offsets[i-1] || off < 0 || off > 1 {
+			continue
+		}
+		y, err := evalFunction(fn, []float64{t0 + off*(t1-t0)})
+		if err != nil {
+			break
+		}
+		c := initialColor(cs)
+		c.Components = y
+		s.Stops = append(s.Stops, ColorStop{off, c})
+	}
+	return s
+}
+
+// stitchBounds returns the /Bounds of a stitching function.
+func stitchBounds(fn Value) []float64 {
+	if fn.Key("FunctionType").CoerceInt64(-1) == 3 {
+		return floats(fn.Key("Bounds"))
+	}
+	return nil
+}
+
+// linearFunction reports whether fn interpolates linearly between
+// its end points (or between stitching bounds), so that color stops
+// at those points describe it exactly.
+func linearFunction(fn Value) bool {
+	// linear records the functions checked, which are only indirect
+	// objects, as direct ones share their container's ptr. A function
+	// still being checked when it is reached again refers to itself,
+	// and cannot be evaluated.
+	linear := make(map[pdfobjptr]bool)
+	var check func(fn, parent Value, depth int) bool
+	check = func(fn, parent Value, depth int) (ok bool) {
+		if ref := fn.ptr; ref != parent.ptr {
+			if ok, seen := linear[ref]; seen {
+				return ok
+			}
+			linear[ref] = false
+			defer func() { linear[ref] = ok }()
+		}
+		if depth > maxFunctionDepth {
+			return false
+		}
+		list := fn
+		switch {
+		case fn.Kind() == Array && depth == 0:
+		case fn.Key("FunctionType").CoerceInt64(-1) == 2:
+			return fn.Key("N").CoerceFloat64(1) == 1
+		case fn.Key("FunctionType").CoerceInt64(-1) == 3:
+			list = fn.Key("Functions")
+		default:
+			return false
+		}
+		for i := 0; i < list.Len(); i++ {
+			if !check(list.Index(i), list, depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+	return check(fn, Value{}, 0)
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShadings(t *testing.T) {
+	r := openPDF(t, pagePDF("<</Shading <</Sh 5 0 R>> /Pattern <</P0 7 0 R>>>>",
+		"q 2 0 0 2 0 0 cm /Sh sh Q /Pattern cs /P0 scn 10 10 100 50 re f",
+		"<</ShadingType 2 /ColorSpace /DeviceRGB /Coords [0 0 100 0] /Extend [true false] /Function 6 0 R>>",
+		"<</FunctionType 2 /Domain [0 1] /C0 [1 0 0] /C1 [0 0 1] /N 1>>",
+		"<</PatternType 2 /Matrix [1 0 0 1 50 50] /Shading <</ShadingType 3 /ColorSpace /DeviceGray"+
+			" /Coords [0 0 0 0 0 50] /Function <</FunctionType 2 /Domain [0 1] /C0 [0] /C1 [1] /N 2>>>>>>",
+	))
+	c, err := r.Page(1).Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Shadings) != 2 {
+		t.Fatalf("page has %d shadings, want 2", len(c.Shadings))
+	}
+
+	// The sh operator paints the whole clip.
+	s := c.Shadings[0]
+	if s.Type != 2 || s.ColorSpace != "DeviceRGB" || !reflect.DeepEqual(s.Coords, []float64{0, 0, 100, 0}) ||
+		s.Extend != [2]bool{true, false} || s.Function != "exponential (N=1)" ||
+		s.Matrix != (Matrix{{2, 0, 0}, {0, 2, 0}, {0, 0, 1}}) || s.Clip != (Rectangle{Point{0, 0}, Point{612, 792}}) {
+		t.Errorf("axial shading %+v", s)
+	}
+	// A linear function needs only its end colors.
+	if len(s.Stops) != 2 || !reflect.DeepEqual(s.Stops[0].Color.Components, []float64{1, 0, 0}) ||
+		s.Stops[1].Offset != 1 || !reflect.DeepEqual(s.Stops[1].Color.Components, []float64{0, 0, 1}) {
+		t.Errorf("axial shading stops %+v", s.Stops)
+	}
+
+	// A shading pattern paints the area filled, in the pattern's space.
+	s = c.Shadings[1]
+	if s.Type != 3 || s.ColorSpace != "DeviceGray" || s.Function != "exponential (N=2)" ||
+		s.Matrix != (Matrix{{1, 0, 0}, {0, 1, 0}, {50, 50, 1}}) || s.Clip != (Rectangle{Point{10, 10}, Point{110, 60}}) {
+		t.Errorf("radial shading %+v", s)
+	}
+	// A curve is sampled.
+	if len(s.Stops) != 9 || s.Stops[4].Offset != 0.5 || !reflect.DeepEqual(s.Stops[4].Color.Components, []float64{0.25}) {
+		t.Errorf("radial shading stops %+v", s.Stops)
+	}
+}
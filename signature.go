@@ -0,0 +1,329 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Digital signatures (PDF 32000-1:2008, §12.8).
+
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+)
+
+// A Signature is a digital signature held by a signature field.
+//
+// The signature is computed over the bytes of the file given by
+// ByteRange, which covers the whole file as it was when signed except
+// for the Contents string itself. Contents holds the signature as
+// identified by SubFilter: for adbe.pkcs7.detached and
+// ETSI.CAdES.detached, a DER-encoded PKCS#7 (CMS) SignedData object,
+// usually padded with zero bytes.
+type Signature struct {
+	Field       string     // fully qualified name of the signature field
+	Filter      string     // preferred signature handler, such as Adobe.PPKLite
+	SubFilter   string     // encoding of Contents, such as adbe.pkcs7.detached
+	ByteRange   [][2]int64 // signed byte ranges of the file: offset and length pairs
+	Contents    []byte     // the signature value
+	Name        string     // name of the signer
+	Time        time.Time  // time of signing, zero if missing or malformed
+	Reason      string
+	Location    string
+	ContactInfo string
+
+	V Value // the signature dictionary
+}
+
+// Signatures returns the signatures of the document's signed
+// signature fields, in the order of the field hierarchy.
+// Unsigned signature fields are omitted.
+func (r *Reader) Signatures() []Signature {
+	var sigs []Signature
+	for _, f := range r.Form() {
+		if f.Type != SignatureField {
+			continue
+		}
+		d := f.inherited("V")
+		if d.Kind() != Dict {
+			continue
+		}
+		sigs = append(sigs, newSignature(f.Name, d))
+	}
+	return sigs
+}
+
+// newSignature returns the Signature for the signature dictionary d
+// of the field with the given name.
+func newSignature(field string, d Value) Signature {
+	sig := Signature{
+		Field:       field,
+		Filter:      d.Key("Filter").CoerceName(""),
+		SubFilter:   d.Key("SubFilter").CoerceName(""),
+		Contents:    []byte(d.Key("Contents").CoerceString("")),
+		Name:        d.Key("Name").CoerceText(""),
+		Reason:      d.Key("Reason").CoerceText(""),
+		Location:    d.Key("Location").CoerceText(""),
+		ContactInfo: d.Key("ContactInfo").CoerceText(""),
+		V:           d,
+	}
+	br := d.Key("ByteRange")
+	for i := 0; i+1 < br.Len(); i += 2 {
+		sig.ByteRange = append(sig.ByteRange, [2]int64{br.Index(i).CoerceInt64(0), br.Index(i + 1).CoerceInt64(0)})
+	}
+	if t, err := ParseDate(d.Key("M").CoerceText("")); err == nil {
+		sig.Time = t
+	}
+	return sig
+}
+
+// isSignatureDict reports whether d is a signature dictionary,
+// whose Contents string is exempt from encryption (§7.6.1).
+func isSignatureDict(d pdfdict) bool {
+	switch d["Type"] {
+	case pdfname("Sig"), pdfname("DocTimeStamp"):
+		return true
+	}
+	_, ok := d["ByteRange"] // Type is optional
+	return ok
+}
+
+// A SignatureVerification is the result of verifying a signature.
+type SignatureVerification struct {
+	Signer       *x509.Certificate   // certificate of the signer
+	Certificates []*x509.Certificate // all certificates included in the signature
+	SigningTime  time.Time           // signed signing time, or else the signature dictionary's M entry
+
+	// Chains are the verified certificate chains from the signer to
+	// the roots. If the chain could not be verified, Chains is nil and
+	// ChainErr says why.
+	Chains   [][]*x509.Certificate
+	ChainErr error
+
+	// CoversDocument reports whether the signature covers the whole
+	// file. If not, it signs an earlier revision, of SignedLength bytes,
+	// and later revisions have changed the document since.
+	CoversDocument bool
+	SignedLength   int64
+}
+
+// VerifySignature verifies the signature sig, as returned by Signatures.
+// It checks that sig.ByteRange covers the file, up to the end of the
+// revision signed, except for the signature's Contents string, that the
+// digest of the bytes it covers matches the one signed, and that the
+// signature was made with the key of the signer's certificate,
+// returning an error if not. It then verifies the
+// signer's certificate chain against roots, using the other certificates
+// in the signature as intermediates and the signing time as the current
+// time; if roots is nil, the system roots are used. Failure to verify the
+// chain is reported in the result's ChainErr rather than as an error.
+//
+// Only the adbe.pkcs7.detached, adbe.pkcs7.sha1 and ETSI.CAdES.detached
+// subfilters are supported.
+func (r *Reader) VerifySignature(sig Signature, roots *x509.CertPool) (*SignatureVerification, error) {
+	switch sig.SubFilter {
+	case "adbe.pkcs7.detached", "adbe.pkcs7.sha1", "ETSI.CAdES.detached":
+	default:
+		return nil, fmt.Errorf("unsupported signature SubFilter %q", sig.SubFilter)
+	}
+
+	if err := r.checkByteRange(sig); err != nil {
+		return nil, err
+	}
+	end := sig.ByteRange[1][0] + sig.ByteRange[1][1]
+
+	s, err := parseCMS(sig.Contents)
+	if err != nil {
+		return nil, err
+	}
+	digestOf := func(h hash.Hash) ([]byte, error) {
+		for _, br := range sig.ByteRange {
+			if _, err := io.Copy(h, io.NewSectionReader(r.f, br[0], br[1])); err != nil {
+				return nil, err
+			}
+		}
+		return h.Sum(nil), nil
+	}
+	var digest []byte
+	if sig.SubFilter == "adbe.pkcs7.sha1" {
+		// The signed content is the SHA-1 digest of the byte ranges.
+		d, err := digestOf(crypto.SHA1.New())
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(d, s.content) {
+			return nil, fmt.Errorf("signature digest mismatch")
+		}
+		h := s.hash.New()
+		h.Write(s.content)
+		digest = h.Sum(nil)
+	} else {
+		if digest, err = digestOf(s.hash.New()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.verify(digest); err != nil {
+		return nil, err
+	}
+
+	v := &SignatureVerification{
+		Signer:         s.signer,
+		Certificates:   s.certs,
+		SigningTime:    s.signingTime,
+		CoversDocument: end == r.end,
+		SignedLength:   end,
+	}
+	if v.SigningTime.IsZero() {
+		v.SigningTime = sig.Time
+	}
+	inter := x509.NewCertPool()
+	for _, c := range s.certs {
+		if c != s.signer {
+			inter.AddCert(c)
+		}
+	}
+	v.Chains, v.ChainErr = s.signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: inter,
+		CurrentTime:   v.SigningTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return v, nil
+}
+
+// checkByteRange checks that the ByteRange of sig covers the file,
+// from its start to the end of the revision signed, except for the
+// signature itself: there must be two ranges, and the hole between them
+// must be exactly the hexadecimal string holding sig.Contents, as the
+// value of the Contents entry. Otherwise bytes outside the hole could
+// be changed without invalidating the signature.
+func (r *Reader) checkByteRange(sig Signature) error {
+	br := sig.ByteRange
+	if len(br) != 2 || br[0][0] != 0 || br[0][1] < 0 || br[1][1] < 0 ||
+		br[1][0] <= br[0][1] || br[1][0] > r.end || br[1][1] > r.end-br[1][0] {
+		return fmt.Errorf("malformed PDF: invalid signature ByteRange %v", br)
+	}
+
+	// The hole is the string, between angle brackets, preceded by the
+	// Contents key and perhaps white space. Its hexadecimal digits hold
+	// the Contents, two digits to a byte, except that the last may be
+	// missing, taken as 0.
+	start, end := br[0][1], br[1][0]
+	if n := int64(len(sig.Contents)); end-start != 2*n+2 && end-start != 2*n+1 {
+		return fmt.Errorf("malformed PDF: signature ByteRange hole is not the Contents string")
+	}
+	const key = "/Contents"
+	off := max(start-int64(len(key))-64, 0)
+	buf := make([]byte, end-off)
+	if _, err := r.f.ReadAt(buf, off); err != nil {
+		return fmt.Errorf("malformed PDF: signature ByteRange: %v", err)
+	}
+	before, hole := buf[:start-off], buf[start-off:]
+	for len(before) > 0 && isSpace(before[len(before)-1]) {
+		before = before[:len(before)-1]
+	}
+	if !bytes.HasSuffix(before, []byte(key)) || hole[0] != '<' || hole[len(hole)-1] != '>' {
+		return fmt.Errorf("malformed PDF: signature ByteRange hole is not the Contents string")
+	}
+	hex := hole[1 : len(hole)-1]
+	for i, c := range sig.Contents {
+		hi, lo := unhex(hex[2*i]), 0
+		if 2*i+1 < len(hex) {
+			lo = unhex(hex[2*i+1])
+		}
+		if hi < 0 || lo < 0 || byte(hi<<4|lo) != c {
+			return fmt.Errorf("malformed PDF: signature ByteRange hole does not hold the signature")
+		}
+	}
+	return nil
+}
+
+// An MDPPermission is the level of change that a certified document
+// permits without invalidating its certification.
+type MDPPermission int
+
+const (
+	MDPNoChanges   MDPPermission = 1 // no changes at all
+	MDPFormFilling MDPPermission = 2 // filling in forms, instantiating page templates and signing
+	MDPAnnotations MDPPermission = 3 // also creating, deleting and modifying annotations
+)
+
+// Certification returns the document's certification signature, the
+// DocMDP entry of the catalog's Perms dictionary, and the changes it
+// permits, given by its DocMDP transform parameters. It returns
+// ok == false if the document is not certified.
+func (r *Reader) Certification() (sig Signature, perm MDPPermission, ok bool) {
+	d := r.Trailer.Key("Root").Key("Perms").Key("DocMDP")
+	if d.Kind() != Dict {
+		return Signature{}, 0, false
+	}
+	sig = newSignature("", d)
+	for _, s := range r.Signatures() {
+		if s.V.Ref() == d.Ref() {
+			sig.Field = s.Field
+		}
+	}
+	perm = MDPFormFilling
+	ref := d.Key("Reference")
+	for i := 0; i < ref.Len(); i++ {
+		if sr := ref.Index(i); sr.Key("TransformMethod").CoerceName("") == "DocMDP" {
+			perm = MDPPermission(sr.Key("TransformParams").Key("P").CoerceInt64(int64(MDPFormFilling)))
+		}
+	}
+	return sig, perm, true
+}
+
+// UsageRights are the additional rights that a usage rights signature
+// grants in viewers that honor it, as Adobe Reader does for
+// "Reader-extended" documents (PDF 32000-1:2008, §12.8.2.3).
+// Each list names the permitted operations of its category.
+type UsageRights struct {
+	Document  []string // such as FullSave
+	Annots    []string // such as Create, Delete, Modify, Import, Export and Online
+	Form      []string // such as FillIn, Import, Export, SubmitStandalone and SpawnTemplate
+	Signature []string // such as Modify
+	EF        []string // embedded files: Create, Delete, Modify and Import
+	Msg       string   // message shown when the document is opened
+	Restrict  bool     // all viewers, not only those honoring usage rights, restrict the document to these rights (P)
+
+	Sig Signature // the usage rights signature
+}
+
+// UsageRights returns the rights granted by the document's usage
+// rights signature, the UR3 entry of the catalog's Perms dictionary,
+// read from its UR3 transform parameters. It returns ok == false if
+// the document has none.
+func (r *Reader) UsageRights() (rights UsageRights, ok bool) {
+	d := r.Trailer.Key("Root").Key("Perms").Key("UR3")
+	if d.Kind() != Dict {
+		return UsageRights{}, false
+	}
+	rights.Sig = newSignature("", d)
+	names := func(v Value) []string {
+		var list []string
+		for i := 0; i < v.Len(); i++ {
+			list = append(list, v.Index(i).CoerceName(""))
+		}
+		return list
+	}
+	ref := d.Key("Reference")
+	for i := 0; i < ref.Len(); i++ {
+		sr := ref.Index(i)
+		if sr.Key("TransformMethod").CoerceName("") != "UR3" && sr.Key("TransformMethod").CoerceName("") != "UR" {
+			continue
+		}
+		p := sr.Key("TransformParams")
+		rights.Document = names(p.Key("Document"))
+		rights.Annots = names(p.Key("Annots"))
+		rights.Form = names(p.Key("Form"))
+		rights.Signature = names(p.Key("Signature"))
+		rights.EF = names(p.Key("EF"))
+		rights.Msg = p.Key("Msg").CoerceText("")
+		rights.Restrict = p.Key("P").data == true
+	}
+	return rights, true
+}
@@ -0,0 +1,336 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A testSigner signs PDF files with a self-signed certificate.
+type testSigner struct {
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(42),
+		Subject:               pkix.Name{CommonName: "Test Signer"},
+		NotBefore:             time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSigner{key, cert}
+}
+
+// cms returns a detached CMS SignedData object, without signed
+// attributes, signing the SHA-256 digest digest.
+func (s *testSigner) cms(t *testing.T, digest []byte) []byte {
+	sig, err := ecdsa.SignASN1(rand.Reader, s.key, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sid, err := asn1.Marshal(cmsIssuerAndSerial{asn1.RawValue{FullBytes: s.cert.RawIssuer}, s.cert.SerialNumber})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha256OID := pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}}
+	sd, err := asn1.Marshal(cmsSignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256OID},
+		EncapContentInfo: cmsEncapContentInfo{ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: s.cert.Raw},
+		SignerInfos: []cmsSignerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    sha256OID,
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+			Signature:          sig,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ci, err := asn1.Marshal(cmsContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ci
+}
+
+const (
+	byteRangeSlot = "[0000000000 0000000000 0000000000 0000000000 0000000000 0000000000]"
+	contentsSlot  = 2048 // hexadecimal digits reserved for the signature
+)
+
+// unsignedPDF returns a document with a signature field whose
+// signature dictionary has room for the ByteRange and Contents.
+func unsignedPDF() []byte {
+	return buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R /AcroForm <</Fields [5 0 R] /SigFlags 3>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Annots [5 0 R]>>",
+		stream("", "BT /F1 12 Tf 72 700 Td (signed) Tj ET"),
+		"<</Type /Annot /Subtype /Widget /FT /Sig /T (Approval) /V 6 0 R /Rect [0 0 0 0] /P 3 0 R>>",
+		"<</Type /Sig /Filter /Adobe.PPKLite /SubFilter /adbe.pkcs7.detached /Name (Test Signer) /M (D:20240102030405Z) /ByteRange "+
+			byteRangeSlot+" /Contents <"+strings.Repeat("0", contentsSlot)+">>>",
+	)
+}
+
+// encryptedUnsignedPDF returns the document of unsignedPDF, with a
+// text annotation, encrypted with RC4 and a 40-bit key for the empty
+// user password. The signature's Contents, which is exempt, is not
+// encrypted.
+func encryptedUnsignedPDF() []byte {
+	const id, O = "0123456789abcdef", "OOOOOOOOOOOOOOOOOOOOOOOOOOOOOOOO"
+	h := md5.New()
+	h.Write(passwordPad)
+	h.Write([]byte(O))
+	h.Write([]byte{0xfc, 0xff, 0xff, 0xff}) // P = -4
+	h.Write([]byte(id))
+	key := h.Sum(nil)[:5]
+	rc4 := func(key []byte, s string) string {
+		b := []byte(s)
+		c, _ := rc4.NewCipher(key)
+		c.XORKeyStream(b, b)
+		return string(b)
+	}
+	str := func(id uint32, s string) string {
+		return "<" + hex.EncodeToString([]byte(rc4(cryptKey(key, false, pdfobjptr{id, 0}), s))) + ">"
+	}
+	hexID := "<" + hex.EncodeToString([]byte(id)) + ">"
+	return buildPDF("/Encrypt 7 0 R /ID ["+hexID+" "+hexID+"]",
+		"<</Type /Catalog /Pages 2 0 R /AcroForm <</Fields [5 0 R] /SigFlags 3>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Annots [5 0 R 8 0 R]>>",
+		stream("", rc4(cryptKey(key, false, pdfobjptr{4, 0}), "BT /F1 12 Tf 72 700 Td (signed) Tj ET")),
+		"<</Type /Annot /Subtype /Widget /FT /Sig /T "+str(5, "Approval")+" /V 6 0 R /Rect [0 0 0 0] /P 3 0 R>>",
+		"<</Type /Sig /Filter /Adobe.PPKLite /SubFilter /adbe.pkcs7.detached /Name "+str(6, "Test Signer")+" /M "+str(6, "D:20240102030405Z")+
+			" /ByteRange "+byteRangeSlot+" /Contents <"+strings.Repeat("0", contentsSlot)+">>>",
+		"<</Filter /Standard /V 1 /R 2 /O <"+hex.EncodeToString([]byte(O))+"> /U <"+hex.EncodeToString([]byte(rc4(key, string(passwordPad))))+"> /P -4>>",
+		"<</Type /Annot /Subtype /Text /Rect [0 0 10 10] /Contents "+str(8, "A note")+">>",
+	)
+}
+
+// contentsHole returns the offsets of the start of the Contents string
+// of data and of the byte after its end.
+func contentsHole(data []byte) (start, end int) {
+	start = bytes.Index(data, []byte("/Contents <")) + len("/Contents ")
+	return start, start + contentsSlot + 2
+}
+
+// sign signs data in place over the given byte ranges, which are
+// written as its ByteRange, putting the signature in its Contents.
+func (s *testSigner) sign(t *testing.T, data []byte, ranges ...int) {
+	br := fmt.Sprint(ranges)
+	i := bytes.Index(data, []byte(byteRangeSlot))
+	copy(data[i:], fmt.Sprintf("%-*s", len(byteRangeSlot), br))
+	h := sha256.New()
+	for j := 0; j+1 < len(ranges); j += 2 {
+		h.Write(data[min(ranges[j], len(data)):min(ranges[j]+ranges[j+1], len(data))])
+	}
+	sig := hex.EncodeToString(s.cms(t, h.Sum(nil)))
+	start, _ := contentsHole(data)
+	copy(data[start+1:], sig)
+}
+
+// signature returns the one signature of the document data.
+func signature(t *testing.T, data []byte) (*Reader, Signature) {
+	t.Helper()
+	r := openPDF(t, data)
+	sigs := r.Signatures()
+	if len(sigs) != 1 {
+		t.Fatalf("found %d signatures, want 1", len(sigs))
+	}
+	return r, sigs[0]
+}
+
+func TestVerifySignature(t *testing.T) {
+	s := newTestSigner(t)
+	data := unsignedPDF()
+	start, end := contentsHole(data)
+	s.sign(t, data, 0, start, end, len(data)-end)
+
+	r, sig := signature(t, data)
+	if sig.Field != "Approval" || sig.SubFilter != "adbe.pkcs7.detached" || sig.Name != "Test Signer" {
+		t.Errorf("signature = %q %q %q", sig.Field, sig.SubFilter, sig.Name)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(s.cert)
+	v, err := r.VerifySignature(sig, roots)
+	if err != nil {
+		t.Fatalf("VerifySignature: %v", err)
+	}
+	if !v.CoversDocument || v.SignedLength != int64(len(data)) {
+		t.Errorf("CoversDocument, SignedLength = %v, %d, want true, %d", v.CoversDocument, v.SignedLength, len(data))
+	}
+	if v.Signer.Subject.CommonName != "Test Signer" || v.ChainErr != nil || len(v.Chains) == 0 {
+		t.Errorf("signer %q, chains %d, chain error %v", v.Signer.Subject.CommonName, len(v.Chains), v.ChainErr)
+	}
+
+	// An update appended later is not covered.
+	update := fmt.Sprintf("xref\n0 1\n0000000000 65535 f\r\ntrailer\n<</Size 7 /Root 1 0 R /Prev %d>>\nstartxref\n%d\n%%%%EOF\n",
+		bytes.LastIndex(data, []byte("xref\n0 ")), len(data))
+	r, sig = signature(t, append(bytes.Clone(data), update...))
+	v, err = r.VerifySignature(sig, roots)
+	if err != nil {
+		t.Fatalf("VerifySignature after update: %v", err)
+	}
+	if v.CoversDocument || v.SignedLength != int64(len(data)) {
+		t.Errorf("after update: CoversDocument, SignedLength = %v, %d, want false, %d", v.CoversDocument, v.SignedLength, len(data))
+	}
+
+	// A change to the signed bytes breaks the signature.
+	changed := bytes.Replace(bytes.Clone(data), []byte("(signed)"), []byte("(forged)"), 1)
+	r, sig = signature(t, changed)
+	if _, err := r.VerifySignature(sig, roots); err == nil {
+		t.Errorf("VerifySignature succeeded after the signed content changed")
+	}
+}
+
+func TestVerifySignatureEncrypted(t *testing.T) {
+	s := newTestSigner(t)
+	data := encryptedUnsignedPDF()
+	start, end := contentsHole(data)
+	s.sign(t, data, 0, start, end, len(data)-end)
+
+	r, sig := signature(t, data)
+	if sig.Field != "Approval" || sig.Name != "Test Signer" {
+		t.Errorf("signature field %q, name %q; want Approval, Test Signer", sig.Field, sig.Name)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(s.cert)
+	if _, err := r.VerifySignature(sig, roots); err != nil {
+		t.Errorf("VerifySignature: %v", err)
+	}
+	// The Contents of other dictionaries is decrypted.
+	if note := r.Page(1).V.Key("Annots").Index(1).Key("Contents").CoerceText(""); note != "A note" {
+		t.Errorf("text annotation Contents %q, want %q", note, "A note")
+	}
+}
+
+func TestVerifySignatureByteRange(t *testing.T) {
+	s := newTestSigner(t)
+	base := unsignedPDF()
+	start, end := contentsHole(base)
+	text := bytes.Index(base, []byte("(signed)"))
+	tests := []struct {
+		name   string
+		ranges []int
+	}{
+		// The hole also leaves the content stream unsigned.
+		{"wide hole", []int{0, text, end, len(base) - end}},
+		{"hole past Contents", []int{0, start, end + 10, len(base) - end - 10}},
+		{"hole inside Contents", []int{0, start + 1, end - 1, len(base) - end + 1}},
+		{"three ranges", []int{0, text, text + 8, start - text - 8, end, len(base) - end}},
+		{"one range", []int{0, len(base)}},
+		{"overlapping", []int{0, end, start, len(base) - start}},
+		{"past the end", []int{0, start, end, len(base)}},
+	}
+	for _, tt := range tests {
+		data := bytes.Clone(base)
+		s.sign(t, data, tt.ranges...)
+		r, sig := signature(t, data)
+		if v, err := r.VerifySignature(sig, nil); err == nil {
+			t.Errorf("%s: ByteRange %v verified, covering the document: %v", tt.name, tt.ranges, v.CoversDocument)
+		} else if !strings.Contains(err.Error(), "ByteRange") {
+			t.Errorf("%s: error %q does not blame the ByteRange", tt.name, err)
+		}
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog /AcroForm <</Fields [2 0 R 3 0 R 4 0 R 6 0 R]>>>>",
+		"<</FT /Sig /T (Empty)>>",
+		"<</FT /Tx /T (Text) /V (not a signature)>>",
+		"<</FT /Sig /T (Author) /V 5 0 R>>",
+		"<</Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached /ByteRange [0 10 20 30] /Contents <0102>"+
+			" /Name (A. Signer) /M (D:20240102030405+01'00') /Reason (I agree) /Location (Here) /ContactInfo (a@example.com)>>",
+		// A field whose type and value are inherited by its kid.
+		"<</FT /Sig /T (Group) /V <</Contents <ff>>> /Kids [7 0 R]>>",
+		"<</T (Kid) /Parent 6 0 R>>",
+	))
+	sigs := r.Signatures()
+	if len(sigs) != 2 {
+		t.Fatalf("found %d signatures, want 2", len(sigs))
+	}
+	s := sigs[0]
+	if s.Field != "Author" || s.Filter != "Adobe.PPKLite" || s.SubFilter != "ETSI.CAdES.detached" ||
+		fmt.Sprint(s.ByteRange) != "[[0 10] [20 30]]" || string(s.Contents) != "\x01\x02" ||
+		s.Name != "A. Signer" || s.Reason != "I agree" || s.Location != "Here" || s.ContactInfo != "a@example.com" ||
+		s.V.Ref() != (ObjRef{5, 0}) {
+		t.Errorf("signature 0 is %+v", s)
+	}
+	if want := time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC); !s.Time.Equal(want) {
+		t.Errorf("signature 0 time %v, want %v", s.Time, want)
+	}
+	if s := sigs[1]; s.Field != "Group.Kid" || string(s.Contents) != "\xff" || s.ByteRange != nil || !s.Time.IsZero() {
+		t.Errorf("signature 1 is %+v", s)
+	}
+}
+
+func TestCertification(t *testing.T) {
+	r := openPDF(t, buildPDF("",
+		"<</Type /Catalog /AcroForm <</Fields [2 0 R]>> /Perms <</DocMDP 3 0 R /UR3 4 0 R>>>>",
+		"<</FT /Sig /T (Certify) /V 3 0 R>>",
+		"<</Type /Sig /Name (Author) /Reference [<</TransformMethod /DocMDP /TransformParams <</P 1 /V /1.2>>>>]>>",
+		"<</Type /Sig /Name (ARE Acrobat Product v8.0 P23 0002337) /Reference [<</TransformMethod /UR3 /TransformParams"+
+			" <</Document [/FullSave] /Form [/FillIn /Import] /Annots [/Create /Modify] /Msg (Extended) /P true>>>>]>>",
+	))
+	sig, perm, ok := r.Certification()
+	if !ok || perm != MDPNoChanges || sig.Field != "Certify" || sig.Name != "Author" {
+		t.Errorf("Certification() = %+v, %d, %v", sig, perm, ok)
+	}
+	rights, ok := r.UsageRights()
+	if !ok || fmt.Sprint(rights.Document, rights.Form, rights.Annots, rights.Signature, rights.EF) != "[FullSave] [FillIn Import] [Create Modify] [] []" ||
+		rights.Msg != "Extended" || !rights.Restrict || rights.Sig.Name != "ARE Acrobat Product v8.0 P23 0002337" || rights.Sig.Field != "" {
+		t.Errorf("UsageRights() = %+v, %v", rights, ok)
+	}
+
+	// Without transform parameters, form filling is permitted.
+	r = openPDF(t, buildPDF("", "<</Type /Catalog /Perms <</DocMDP <</Type /Sig>>>>>>"))
+	if _, perm, ok := r.Certification(); !ok || perm != MDPFormFilling {
+		t.Errorf("Certification() without parameters = %d, %v, want %d, true", perm, ok, MDPFormFilling)
+	}
+	if _, ok := r.UsageRights(); ok {
+		t.Errorf("UsageRights() found rights in a document without UR3")
+	}
+	if _, _, ok := openPDF(t, buildPDF("", "<</Type /Catalog>>")).Certification(); ok {
+		t.Errorf("Certification() found a certification in an uncertified document")
+	}
+}
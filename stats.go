@@ -0,0 +1,131 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Reporting what a Reader does, for metrics.
+
+package pdf
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// A Hook is told of the work a Reader does, so that applications can
+// record it in their metrics, as Stats does. Set a Reader's Hook
+// before reading the document. Its methods may be called by several
+// goroutines at once, as when pages are read by ExtractAll, and should
+// return quickly.
+type Hook interface {
+	// ObjectResolved is called when the object ref is read from the
+	// file or from an object stream, the first time it is needed.
+	ObjectResolved(ref ObjRef)
+
+	// StreamCacheLookup is called when the data of the stream ref is
+	// looked up in the cache of decoded stream data, with whether it
+	// was found there.
+	StreamCacheLookup(ref ObjRef, hit bool)
+
+	// StreamDecoded is called as the named filter decodes n more bytes
+	// of stream data.
+	StreamDecoded(filter string, n int)
+
+	// ContentInterpreted is called when the content of the page ref
+	// has been interpreted, with the time it took, including the time
+	// taken by the interpreter's callbacks.
+	ContentInterpreted(page ObjRef, d time.Duration)
+}
+
+// Stats is a Hook that counts the work a Reader does. It is safe for
+// concurrent use, and may be shared by several Readers to count their
+// work together. Stats implements expvar.Var, so it can be published
+// with expvar.Publish.
+type Stats struct {
+	mu sync.Mutex
+	c  StatCounts
+}
+
+// StatCounts are the counts kept by Stats.
+type StatCounts struct {
+	ObjectsResolved int64
+	CacheHits       int64            // streams whose decoded data was found in the cache
+	CacheMisses     int64            // streams whose decoded data was not
+	DecodedBytes    map[string]int64 // bytes of stream data decoded, by filter
+	PagesRun        int64            // pages whose content was interpreted
+	ContentTime     time.Duration    // time taken interpreting content
+}
+
+// Counts returns the counts so far.
+func (s *Stats) Counts() StatCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.c
+	c.DecodedBytes = make(map[string]int64, len(s.c.DecodedBytes))
+	for f, n := range s.c.DecodedBytes {
+		c.DecodedBytes[f] = n
+	}
+	return c
+}
+
+// Reset sets the counts to zero.
+func (s *Stats) Reset() {
+	s.mu.Lock()
+	s.c = StatCounts{}
+	s.mu.Unlock()
+}
+
+// String returns the counts as JSON, for expvar.
+func (s *Stats) String() string {
+	b, _ := json.Marshal(s.Counts())
+	return string(b)
+}
+
+func (s *Stats) ObjectResolved(ref ObjRef) {
+	s.mu.Lock()
+	s.c.ObjectsResolved++
+	s.mu.Unlock()
+}
+
+func (s *Stats) StreamCacheLookup(ref ObjRef, hit bool) {
+	s.mu.Lock()
+	if hit {
+		s.c.CacheHits++
+	} else {
+		s.c.CacheMisses++
+	}
+	s.mu.Unlock()
+}
+
+func (s *Stats) StreamDecoded(filter string, n int) {
+	s.mu.Lock()
+	if s.c.DecodedBytes == nil {
+		s.c.DecodedBytes = make(map[string]int64)
+	}
+	s.c.DecodedBytes[filter] += int64(n)
+	s.mu.Unlock()
+}
+
+func (s *Stats) ContentInterpreted(page ObjRef, d time.Duration) {
+	s.mu.Lock()
+	s.c.PagesRun++
+	s.c.ContentTime += d
+	s.mu.Unlock()
+}
+
+// A hookReader reads the data decoded by the named filter from rd,
+// telling hook of each read.
+type hookReader struct {
+	rd     io.Reader
+	filter string
+	hook   Hook
+}
+
+func (h *hookReader) Read(b []byte) (int, error) {
+	n, err := h.rd.Read(b)
+	if n > 0 {
+		h.hook.StreamDecoded(h.filter, n)
+	}
+	return n, err
+}
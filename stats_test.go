@@ -0,0 +1,58 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	pg := w.NewPage(612, 792)
+	for y := 700.0; y > 100; y -= 20 {
+		pg.ShowText(72, y, "hello, world")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := new(Stats)
+	r.Hook = stats
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Page(1).Content(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := stats.Counts()
+	if c.ObjectsResolved == 0 {
+		t.Errorf("ObjectsResolved = 0")
+	}
+	if c.CacheMisses != 1 || c.CacheHits != 1 {
+		t.Errorf("CacheMisses, CacheHits = %d, %d, want 1, 1", c.CacheMisses, c.CacheHits)
+	}
+	if c.DecodedBytes["FlateDecode"] == 0 {
+		t.Errorf("DecodedBytes = %v, want FlateDecode bytes", c.DecodedBytes)
+	}
+	if c.PagesRun != 2 || c.ContentTime <= 0 {
+		t.Errorf("PagesRun, ContentTime = %d, %v, want 2 and some time", c.PagesRun, c.ContentTime)
+	}
+
+	var got StatCounts
+	if err := json.Unmarshal([]byte(stats.String()), &got); err != nil || got.PagesRun != 2 {
+		t.Errorf("String() = %s, %v", stats.String(), err)
+	}
+
+	stats.Reset()
+	if c := stats.Counts(); c.ObjectsResolved != 0 || c.PagesRun != 0 || len(c.DecodedBytes) != 0 {
+		t.Errorf("after Reset: %+v", c)
+	}
+}
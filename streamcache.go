@@ -0,0 +1,131 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Keeping the decoded data of streams read more than once.
+
+package pdf
+
+import (
+	"container/list"
+	"io"
+	"sync"
+)
+
+// DefaultStreamCacheSize is the number of bytes of decoded stream data
+// kept by a Reader whose StreamCacheSize is 0.
+const DefaultStreamCacheSize = 16 << 20
+
+// A streamCache keeps the decoded data of the streams read most
+// recently, up to a number of bytes, discarding the data of the
+// stream read least recently first.
+type streamCache struct {
+	mu    sync.Mutex
+	size  int64     // bytes of data kept
+	lru   list.List // of *cachedStream, most recently read first
+	byPtr map[pdfobjptr]*list.Element
+	gen   int // incremented when the data kept is discarded
+}
+
+// A cachedStream is the decoded data of the stream ptr.
+type cachedStream struct {
+	ptr  pdfobjptr
+	data []byte
+}
+
+// streamCacheSize returns the number of bytes of stream data r keeps.
+func (r *Reader) streamCacheSize() int64 {
+	switch {
+	case r.StreamCacheSize == 0:
+		return DefaultStreamCacheSize
+	case r.StreamCacheSize < 0:
+		return 0
+	}
+	return r.StreamCacheSize
+}
+
+// get returns the data kept for the stream ptr, if any.
+func (c *streamCache) get(ptr pdfobjptr) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.byPtr[ptr]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedStream).data, true
+}
+
+// put keeps data as the data of the stream ptr, read since the cache
+// was at generation gen, discarding the data of other streams so that
+// no more than limit bytes are kept.
+func (c *streamCache) put(ptr pdfobjptr, data []byte, gen int, limit int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen || int64(len(data)) > limit {
+		return
+	}
+	if c.byPtr == nil {
+		c.byPtr = make(map[pdfobjptr]*list.Element)
+	}
+	if e, ok := c.byPtr[ptr]; ok {
+		c.size -= int64(len(e.Value.(*cachedStream).data))
+		c.lru.Remove(e)
+	}
+	c.byPtr[ptr] = c.lru.PushFront(&cachedStream{ptr, data})
+	c.size += int64(len(data))
+	for c.size > limit {
+		e := c.lru.Back()
+		cs := e.Value.(*cachedStream)
+		c.lru.Remove(e)
+		delete(c.byPtr, cs.ptr)
+		c.size -= int64(len(cs.data))
+	}
+}
+
+// generation returns the generation of the cache, to pass to put.
+func (c *streamCache) generation() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// clear discards all the data kept, as when a stream may have changed.
+func (c *streamCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	c.byPtr = nil
+	c.size = 0
+	c.gen++
+}
+
+// A cachingReader reads the decoded data of the stream ptr from rd,
+// keeping a copy to add to the cache once it has all been read,
+// unless it is larger than the cache.
+type cachingReader struct {
+	rd    io.Reader
+	cache *streamCache
+	ptr   pdfobjptr
+	gen   int
+	limit int64
+	buf   []byte
+	done  bool // whether the data has been added to the cache or will not be
+}
+
+func (c *cachingReader) Read(b []byte) (int, error) {
+	n, err := c.rd.Read(b)
+	if c.done {
+		return n, err
+	}
+	if int64(len(c.buf)+n) > c.limit {
+		c.buf, c.done = nil, true
+	} else {
+		c.buf = append(c.buf, b[:n]...)
+	}
+	if err == io.EOF && !c.done {
+		c.cache.put(c.ptr, c.buf, c.gen, c.limit)
+		c.buf, c.done = nil, true
+	}
+	return n, err
+}
@@ -0,0 +1,73 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// countingReaderAt counts the reads of the file it reads.
+type countingReaderAt struct {
+	rd    io.ReaderAt
+	reads int
+}
+
+func (c *countingReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	c.reads++
+	return c.rd.ReadAt(b, off)
+}
+
+func TestStreamCache(t *testing.T) {
+	data := pagePDF("<<>>", "0 0 m 10 10 l S",
+		stream("", "first stream"),
+		stream("", "second stream"),
+	)
+	// open returns a Reader keeping size bytes of stream data,
+	// and the counter of its reads of the file.
+	open := func(size int64) (*Reader, *countingReaderAt) {
+		f := &countingReaderAt{rd: bytes.NewReader(data)}
+		r, err := NewReader(f, int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.StreamCacheSize = size
+		return r, f
+	}
+	// read reads the stream id of r, reporting whether it read the file.
+	read := func(r *Reader, f *countingReaderAt, id uint32) bool {
+		t.Helper()
+		strm := object(r, id)
+		before := f.reads
+		b, err := io.ReadAll(strm.Reader())
+		if err != nil || !bytes.HasSuffix(b, []byte("stream")) {
+			t.Fatalf("reading %d 0 R: %q, %v", id, b, err)
+		}
+		return f.reads != before
+	}
+
+	r, f := open(0)
+	if !read(r, f, 5) || read(r, f, 5) {
+		t.Errorf("default cache: stream not kept")
+	}
+	object(r, 5).SetKey("Extra", NewInt(1))
+	if !read(r, f, 5) {
+		t.Errorf("stream kept after a change")
+	}
+
+	// The cache has room for one of the streams, the last read.
+	r, f = open(int64(len("second stream")))
+	read(r, f, 5)
+	read(r, f, 6)
+	if read(r, f, 6) || !read(r, f, 5) {
+		t.Errorf("small cache: not the last stream read kept")
+	}
+
+	r, f = open(-1)
+	if !read(r, f, 5) || !read(r, f, 5) {
+		t.Errorf("no cache: stream kept")
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The logical structure of tagged documents (PDF 32000-1:2008, §14.7)
+// and replacement text (§14.9).
+
+package pdf
+
+// A StructElem is an element of the document's structure tree,
+// such as a paragraph, heading, table or figure.
+type StructElem struct {
+	Type       string // structure type, mapped through the role map to a standard type where possible
+	Title      string
+	Lang       string // language of the element's text, such as en-US
+	Alt        string // alternate description, as for a figure or formula
+	ActualText string // exact replacement for the text of the element's content
+	E          string // expansion of an abbreviation or acronym
+	Page       int    // page on which the content is drawn, starting at 1, or 0 if none
+	MCIDs      []int  // marked-content sequences on that page that belong directly to the element
+	Kids       []StructElem
+
+	V Value // the structure element dictionary
+}
+
+// MarkInfo describes the document's use of tagging: the catalog's
+// MarkInfo dictionary.
+type MarkInfo struct {
+	Marked         bool // the document conforms to the conventions of tagged PDF
+	UserProperties bool // structure elements carry user properties
+	Suspects       bool // the tagging may be incorrect, as from a conversion tool
+}
+
+// MarkInfo returns the document's mark information dictionary.
+// Documents without one are not tagged.
+func (r *Reader) MarkInfo() MarkInfo {
+	m := r.Trailer.Key("Root").Key("MarkInfo")
+	return MarkInfo{
+		Marked:         m.Key("Marked").data == true,
+		UserProperties: m.Key("UserProperties").data == true,
+		Suspects:       m.Key("Suspects").data == true,
+	}
+}
+
+// Lang returns the natural language of the document's text,
+// the catalog's Lang entry, as a language tag such as en-US,
+// or the empty string if it is not given.
+func (r *Reader) Lang() string {
+	return r.Trailer.Key("Root").Key("Lang").CoerceText("")
+}
+
+// maxStructDepth limits the depth of the structure tree,
+// so that a cycle of Kids cannot be followed forever.
+const maxStructDepth = 64
+
+// StructTree returns the top-level elements of the document's
+// structure tree, or nil if the document is not tagged.
+func (r *Reader) StructTree() []StructElem {
+	root := r.Trailer.Key("Root").Key("StructTreeRoot")
+	roles := root.Key("RoleMap")
+	seen := make(map[ObjRef]bool)
+	var walk func(kids Value, pg Value, depth int, elem *StructElem) []StructElem
+	walk = func(kids Value, pg Value, depth int, elem *StructElem) []StructElem {
+		if kids.Kind() != Array {
+			kids = arrayOf(kids)
+		}
+		var list []StructElem
+		for i := 0; i < kids.Len(); i++ {
+			kid := kids.Index(i)
+			switch {
+			case kid.Kind() == Integer:
+				if elem != nil {
+					elem.MCIDs = append(elem.MCIDs, int(kid.CoerceInt64(-1)))
+				}
+			case kid.Key("Type").CoerceName("") == "MCR":
+				if elem != nil && kid.Key("Pg").Kind() == Null {
+					elem.MCIDs = append(elem.MCIDs, int(kid.Key("MCID").CoerceInt64(-1)))
+				}
+			case kid.Key("S").Kind() == Name:
+				// Only elements that are indirect objects can be
+				// reached twice; direct ones share their container's Ref.
+				if ref := kid.Ref(); ref != kids.Ref() {
+					if seen[ref] {
+						continue
+					}
+					seen[ref] = true
+				}
+				if depth >= maxStructDepth {
+					continue
+				}
+				e := StructElem{
+					Type:       roleOf(roles, kid.Key("S").CoerceName("")),
+					Title:      kid.Key("T").CoerceText(""),
+					Lang:       kid.Key("Lang").CoerceText(""),
+					Alt:        kid.Key("Alt").CoerceText(""),
+					ActualText: kid.Key("ActualText").CoerceText(""),
+					E:          kid.Key("E").CoerceText(""),
+					V:          kid,
+				}
+				kpg := pg
+				if p := kid.Key("Pg"); p.Kind() == Dict {
+					kpg = p
+				}
+				if kpg.Kind() == Dict {
+					e.Page = pageNumber(kpg)
+				}
+				e.Kids = walk(kid.Key("K"), kpg, depth+1, &e)
+				list = append(list, e)
+			}
+		}
+		return list
+	}
+	return walk(root.Key("K"), Value{}, 0, nil)
+}
+
+// arrayOf returns a Value for the array holding only v,
+// or an empty array if v is null.
+func arrayOf(v Value) Value {
+	if v.Kind() == Null {
+		return Value{r: v.r, data: pdfarray{}}
+	}
+	return Value{r: v.r, ptr: v.ptr, data: pdfarray{v.data}}
+}
+
+// roleOf returns the standard structure type to which the role map
+// roles maps the type s, or s itself if it maps to none.
+func roleOf(roles Value, s string) string {
+	for i := 0; i < 8; i++ {
+		t := roles.Key(s).CoerceName("")
+		if t == "" || t == s {
+			break
+		}
+		s = t
+	}
+	return s
+}
+
+// An actualTextKey identifies the marked-content sequence or
+// structure element whose ActualText replaces some Text.
+type actualTextKey struct {
+	seq  int    // number of the marked-content sequence, or 0
+	elem ObjRef // structure element
+}
+
+// substituteActualText replaces the characters of the Text fragments
+// drawn within a marked-content sequence or structure element that has
+// an ActualText entry with that text. The first fragment receives the
+// replacement, spread evenly over its width; the others are removed.
+// Sequences take precedence over elements, and outer ones over inner.
+func (p Page) substituteActualText(text []Text) []Text {
+	var parents Value // the page's entry in the parent tree
+	if r := p.V.r; r != nil {
+		if i := p.V.Key("StructParents"); i.Kind() == Integer {
+			parents = numTreeLookup(r.Trailer.Key("Root").Key("StructTreeRoot").Key("ParentTree"), i.CoerceInt64(-1))
+		}
+	}
+
+	done := make(map[actualTextKey]bool)
+	var out []Text
+	for _, t := range text {
+		var key actualTextKey
+		var actual string
+		for _, mc := range t.MarkedContent {
+			if a := mc.Properties.Key("ActualText"); a.Kind() == String {
+				key, actual = actualTextKey{seq: mc.seq}, a.CoerceText("")
+				break
+			}
+		}
+		if key.seq == 0 && parents.Kind() == Array {
+			for i := len(t.MarkedContent) - 1; i >= 0; i-- {
+				if mcid := t.MarkedContent[i].MCID; mcid >= 0 {
+					key, actual = elementActualText(parents.Index(mcid))
+					break
+				}
+			}
+		}
+		if key == (actualTextKey{}) {
+			out = append(out, t)
+			continue
+		}
+		if done[key] {
+			continue
+		}
+		done[key] = true
+		rs := []rune(actual)
+		if len(rs) == 0 {
+			continue
+		}
+		w := 0.0
+		for _, ch := range t.S {
+			w += ch.Width
+		}
+		t.S = make([]PositionedChar, len(rs))
+		for i, r := range rs {
+			t.S[i] = PositionedChar{Text: []rune{r}, Width: w / float64(len(rs))}
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// elementActualText returns the outermost of the structure element
+// elem and its ancestors that has an ActualText entry, and that text.
+func elementActualText(elem Value) (actualTextKey, string) {
+	var key actualTextKey
+	var actual string
+	for depth := 0; depth < maxStructDepth && elem.Key("S").Kind() == Name; depth++ {
+		if a := elem.Key("ActualText"); a.Kind() == String && elem.Ref() != (ObjRef{}) {
+			key, actual = actualTextKey{elem: elem.Ref()}, a.CoerceText("")
+		}
+		elem = elem.Key("P")
+	}
+	return key, actual
+}
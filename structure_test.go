@@ -0,0 +1,100 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// taggedPDF returns a tagged document of one page, whose text is a
+// paragraph, a ligature with ActualText, a hyphenated word whose
+// paragraph has ActualText, and a figure.
+func taggedPDF() []byte {
+	return buildPDF("",
+		"<</Type /Catalog /Pages 2 0 R /StructTreeRoot 6 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources <</Font <</F1 5 0 R>>>> /StructParents 0>>",
+		stream("", "/P <</MCID 0>> BDC "+show(72, 700, "Hello")+"EMC\n"+
+			"/Span <</ActualText (fi)>> BDC "+show(72, 688, "XY")+"EMC\n"+
+			"/P <</MCID 1>> BDC "+show(72, 676, "hy-")+show(72, 664, "phen")+"EMC\n"+
+			"/Figure <</MCID 2>> BDC 0 0 m 10 10 l S EMC\n"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /StructTreeRoot /K 7 0 R /RoleMap <</Para /P /Graphic /Figure>> /ParentTree <</Nums [0 [8 0 R 9 0 R 10 0 R]]>>>>",
+		// The document lists its first kid twice.
+		"<</S /Document /P 6 0 R /K [8 0 R 9 0 R 10 0 R 8 0 R] /Lang (en-GB)>>",
+		"<</S /Para /P 7 0 R /Pg 3 0 R /K 0>>",
+		"<</S /P /P 7 0 R /Pg 3 0 R /K [<</Type /MCR /MCID 1>>] /ActualText (hyphen)>>",
+		"<</S /Graphic /P 7 0 R /Pg 3 0 R /K 2 /Alt (A line) /T (Figure 1) /E (fig.)>>",
+	)
+}
+
+// structString returns the types of the elements, with their pages,
+// marked-content identifiers and children.
+func structString(elems []StructElem) string {
+	var list []string
+	for _, e := range elems {
+		s := fmt.Sprintf("%s@%d%v", e.Type, e.Page, e.MCIDs)
+		if len(e.Kids) > 0 {
+			s += "(" + structString(e.Kids) + ")"
+		}
+		list = append(list, s)
+	}
+	return strings.Join(list, " ")
+}
+
+func TestStructTree(t *testing.T) {
+	r := openPDF(t, taggedPDF())
+	tree := r.StructTree()
+	if got, want := structString(tree), "Document@0[](P@1[0] P@1[1] Figure@1[2])"; got != want {
+		t.Fatalf("structure tree %s, want %s", got, want)
+	}
+	doc := tree[0]
+	if doc.Lang != "en-GB" || doc.V.Ref() != (ObjRef{7, 0}) {
+		t.Errorf("Document: Lang %q, object %v", doc.Lang, doc.V.Ref())
+	}
+	if p := doc.Kids[1]; p.ActualText != "hyphen" {
+		t.Errorf("P: ActualText %q, want %q", p.ActualText, "hyphen")
+	}
+	if fig := doc.Kids[2]; fig.Alt != "A line" || fig.Title != "Figure 1" || fig.E != "fig." {
+		t.Errorf("Figure: Alt %q, Title %q, E %q", fig.Alt, fig.Title, fig.E)
+	}
+
+	if tree := openPDF(t, textPage(show(72, 700, "untagged"))).StructTree(); tree != nil {
+		t.Errorf("untagged document has structure tree %s", structString(tree))
+	}
+}
+
+func TestActualText(t *testing.T) {
+	p := openPDF(t, taggedPDF()).Page(1)
+	tests := []struct {
+		opt  TextOptions
+		want string
+	}{
+		{TextOptions{}, "Hello\nfi\nhyphen"},
+		{TextOptions{IgnoreActualText: true}, "Hello\nXY\nhy-\nphen"},
+	}
+	for _, tt := range tests {
+		if s, err := p.PlainText(tt.opt); err != nil || s != tt.want {
+			t.Errorf("PlainText(%+v) = %q, %v, want %q", tt.opt, s, err, tt.want)
+		}
+	}
+}
+
+func TestMarkInfo(t *testing.T) {
+	r := openPDF(t, buildPDF("", "<</Type /Catalog /Lang (de-CH) /MarkInfo <</Marked true /Suspects true /UserProperties false>>>>"))
+	if m := r.MarkInfo(); m != (MarkInfo{Marked: true, Suspects: true}) {
+		t.Errorf("MarkInfo() = %+v", m)
+	}
+	if l := r.Lang(); l != "de-CH" {
+		t.Errorf("Lang() = %q, want de-CH", l)
+	}
+
+	r = openPDF(t, buildPDF("", "<</Type /Catalog>>"))
+	if m, l := r.MarkInfo(), r.Lang(); m != (MarkInfo{}) || l != "" {
+		t.Errorf("MarkInfo(), Lang() = %+v, %q without entries", m, l)
+	}
+}
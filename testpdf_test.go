@@ -0,0 +1,79 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Building small PDF files for tests.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF returns a PDF file holding the objects objs, numbered from 1,
+// with a cross-reference table and a trailer naming object 1 as the
+// catalog. The trailer also holds the entries given by trailer.
+func buildPDF(trailer string, objs ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f\r\n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n\r\n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<</Size %d /Root 1 0 R %s>>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, trailer, xref)
+	return b.Bytes()
+}
+
+// stream returns a stream object with the dictionary entries dict and
+// the given data, adding its Length.
+func stream(dict, data string) string {
+	return fmt.Sprintf("<<%s /Length %d>>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+// pagePDF returns a PDF file with a single page whose content stream is
+// content and whose resources dictionary is res, followed by the objects
+// extra, which are numbered from 5.
+func pagePDF(res, content string, extra ...string) []byte {
+	objs := []string{
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources " + res + " /Contents 4 0 R>>",
+		stream("", content),
+	}
+	return buildPDF("", append(objs, extra...)...)
+}
+
+// openPDF returns a Reader reading data, failing the test if it cannot.
+func openPDF(t *testing.T, data []byte) *Reader {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	return r
+}
+
+// object returns the object numbered id in r.
+func object(r *Reader, id uint32) Value {
+	return r.resolve(pdfobjptr{}, pdfobjptr{id, 0})
+}
+
+// contentText returns the text of c.
+func contentText(c Content) string {
+	var b strings.Builder
+	for _, t := range c.Text {
+		for _, ch := range t.S {
+			b.WriteString(string(ch.Text))
+		}
+	}
+	return b.String()
+}
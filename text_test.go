@@ -0,0 +1,40 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import "testing"
+
+func TestExpandLigatures(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{"", ""},
+		{"plain", "plain"},
+		{"ﬁnd the ﬀectﬂ", "find the ffectfl"},
+		{"eﬃcient ﬄuent Ĳsselmeer", "efficient ffluent IJsselmeer"},
+		{"ﬅﬆ", "stst"},
+	}
+	for _, tt := range tests {
+		if out := expandLigatures(tt.in); out != tt.out {
+			t.Errorf("expandLigatures(%q) = %q, want %q", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestComposeNFC(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{"", ""},
+		{"cafe\u0301", "caf\u00e9"},
+		{"A\u030a\u0301", "\u01fa"},       // composes twice
+		{"\u0301e", "\u0301e"},            // a mark with no base is left alone
+		{"q\u0301", "q\u0301"},            // no composition
+		{"\u03b1\u0301", "\u03ac"},        // Greek
+		{"\u0418\u0306", "\u0419"},        // Cyrillic
+		{"e\u0301\u0301", "\u00e9\u0301"}, // a second mark
+	}
+	for _, tt := range tests {
+		if out := composeNFC(tt.in); out != tt.out {
+			t.Errorf("composeNFC(%+q) = %+q, want %+q", tt.in, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Articles (PDF 32000-1:2008, §12.4.3): threads of beads giving
+// the reading order of text laid out across columns and pages.
+
+package pdf
+
+// A Thread is an article: a sequence of regions of the document's pages,
+// its beads, to be read in order, as a magazine story continued across
+// columns and pages.
+type Thread struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	Beads    []Bead
+
+	V Value // the thread dictionary
+}
+
+// A Bead is one region of an article.
+type Bead struct {
+	Page int       // page number, starting at 1, or 0 if not found
+	Rect Rectangle // region of the page, in default user space
+
+	V Value // the bead dictionary
+}
+
+// maxBeads limits the number of beads followed in a thread,
+// so that a chain that does not lead back to the first bead ends.
+const maxBeads = 10000
+
+// Threads returns the document's articles, the catalog's Threads entry,
+// in order. The beads of each are listed in reading order.
+func (r *Reader) Threads() []Thread {
+	var threads []Thread
+	list := r.Trailer.Key("Root").Key("Threads")
+	for i := 0; i < list.Len(); i++ {
+		v := list.Index(i)
+		info := v.Key("I")
+		t := Thread{
+			Title:    info.Key("Title").CoerceText(""),
+			Author:   info.Key("Author").CoerceText(""),
+			Subject:  info.Key("Subject").CoerceText(""),
+			Keywords: info.Key("Keywords").CoerceText(""),
+			V:        v,
+		}
+		first := v.Key("F")
+		seen := make(map[ObjRef]bool)
+		for b := first; b.Kind() == Dict && len(t.Beads) < maxBeads; b = b.Key("N") {
+			if seen[b.Ref()] {
+				break
+			}
+			seen[b.Ref()] = true
+			bead := Bead{Rect: rectValue(b.Key("R")), V: b}
+			if p := b.Key("P"); p.Kind() == Dict {
+				bead.Page = pageNumber(p)
+			}
+			t.Beads = append(t.Beads, bead)
+		}
+		threads = append(threads, t)
+	}
+	return threads
+}
@@ -0,0 +1,43 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestThreads(t *testing.T) {
+	// The pages are objects 4, 5 and 6.
+	r := openPDF(t, destPDF("/Threads [7 0 R 11 0 R]",
+		"<</Type /Thread /F 8 0 R /I <</Title (Lead story) /Author (A. Writer)>>>>",
+		"<</Type /Bead /T 7 0 R /N 9 0 R /V 10 0 R /P 4 0 R /R [72 72 300 720]>>",
+		"<</Type /Bead /T 7 0 R /N 10 0 R /V 8 0 R /P 4 0 R /R [312 72 540 720]>>",
+		"<</Type /Bead /T 7 0 R /N 8 0 R /V 9 0 R /P 6 0 R /R [72 400 540 720]>>",
+		// A chain that does not lead back to its first bead.
+		"<</Type /Thread /F 12 0 R>>",
+		"<</Type /Bead /N 13 0 R /P 5 0 R /R [0 0 10 10]>>",
+		"<</Type /Bead /N 13 0 R /R [0 0 20 20]>>",
+	))
+	threads := r.Threads()
+	var got []string
+	for _, th := range threads {
+		s := fmt.Sprintf("%q %q:", th.Title, th.Author)
+		for _, b := range th.Beads {
+			s += fmt.Sprintf(" %d%v", b.Page, b.Rect)
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		`"Lead story" "A. Writer": 1{{72 72} {300 720}} 1{{312 72} {540 720}} 3{{72 400} {540 720}}`,
+		`"" "": 2{{0 0} {10 10}} 0{{0 0} {20 20}}`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("threads:\n%q\nwant\n%q", got, want)
+	}
+	if len(threads) > 0 && threads[0].V.Ref() != (ObjRef{7, 0}) {
+		t.Errorf("thread 0 is object %v, want 7", threads[0].V.Ref())
+	}
+}
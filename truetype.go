@@ -0,0 +1,392 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Embedded TrueType fonts: the table directory and the cmap table,
+// used to decode text in fonts that carry no usable encoding.
+
+package pdf
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// isTrueType reports whether f is a simple TrueType font.
+func (f Font) isTrueType() bool {
+	return f.V.Key("Subtype").CoerceName("") == "TrueType"
+}
+
+// isSymbolic reports whether f's font descriptor marks it as symbolic,
+// meaning its glyphs lie outside the standard Latin character set.
+func (f Font) isSymbolic() bool {
+	return f.Descriptor().Flags&FlagSymbolic != 0
+}
+
+// streamBytes returns the decoded contents of the stream v,
+// or nil if v is not a stream or cannot be decoded.
+func streamBytes(v Value) (data []byte) {
+	if v.Kind() != Stream {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			data = nil
+		}
+	}()
+	data, err := io.ReadAll(v.Reader())
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// sfntTables returns the tables of the TrueType (sfnt) font in data,
+// indexed by tag. It returns nil if data is not a TrueType or
+// OpenType font.
+func sfntTables(data []byte) map[string][]byte {
+	if len(data) < 12 {
+		return nil
+	}
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "true", "OTTO":
+	default:
+		return nil
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*n {
+		return nil
+	}
+	tables := make(map[string][]byte)
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		off := int64(binary.BigEndian.Uint32(rec[8:]))
+		size := int64(binary.BigEndian.Uint32(rec[12:]))
+		if off+size > int64(len(data)) {
+			continue
+		}
+		tables[string(rec[:4])] = data[off : off+size]
+	}
+	return tables
+}
+
+// sfntCmap returns the mapping from character codes to glyph indexes
+// given by the subtable of the cmap table for the platform and encoding,
+// or nil if there is no such subtable or its format is not supported.
+// Formats 0, 4, 6 and 12 are understood.
+func sfntCmap(cmap []byte, platform, encoding uint16) map[uint32]uint16 {
+	if len(cmap) < 4 {
+		return nil
+	}
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n; i++ {
+		rec := cmap[4+8*i:]
+		if len(rec) < 8 {
+			return nil
+		}
+		if binary.BigEndian.Uint16(rec) != platform || binary.BigEndian.Uint16(rec[2:]) != encoding {
+			continue
+		}
+		off := binary.BigEndian.Uint32(rec[4:])
+		if off >= uint32(len(cmap)) {
+			return nil
+		}
+		return parseCmapSubtable(cmap[off:])
+	}
+	return nil
+}
+
+// parseCmapSubtable decodes a single cmap subtable.
+func parseCmapSubtable(b []byte) map[uint32]uint16 {
+	if len(b) < 2 {
+		return nil
+	}
+	u16 := func(i int) uint16 {
+		if i < 0 || i+2 > len(b) {
+			return 0
+		}
+		return binary.BigEndian.Uint16(b[i:])
+	}
+	u32 := func(i int) uint32 {
+		if i < 0 || i+4 > len(b) {
+			return 0
+		}
+		return binary.BigEndian.Uint32(b[i:])
+	}
+	m := make(map[uint32]uint16)
+	switch u16(0) {
+	case 0:
+		// Byte encoding table.
+		for c := 0; c < 256 && 6+c < len(b); c++ {
+			if g := b[6+c]; g != 0 {
+				m[uint32(c)] = uint16(g)
+			}
+		}
+	case 4:
+		// Segment mapping to delta values.
+		segs := int(u16(6)) / 2
+		ends, starts := 14, 16+2*segs
+		deltas, ranges := starts+2*segs, starts+4*segs
+		for s := 0; s < segs; s++ {
+			end, start := u16(ends+2*s), u16(starts+2*s)
+			delta, ro := u16(deltas+2*s), int(u16(ranges+2*s))
+			for c := uint32(start); c <= uint32(end) && c != 0xffff; c++ {
+				var g uint16
+				if ro == 0 {
+					g = uint16(c) + delta
+				} else if g = u16(ranges + 2*s + ro + 2*int(c-uint32(start))); g != 0 {
+					g += delta
+				}
+				if g != 0 {
+					m[c] = g
+				}
+			}
+		}
+	case 6:
+		// Trimmed table.
+		first, count := uint32(u16(6)), int(u16(8))
+		for i := 0; i < count; i++ {
+			if g := u16(10 + 2*i); g != 0 {
+				m[first+uint32(i)] = g
+			}
+		}
+	case 12:
+		// Segmented coverage.
+		groups := int(u32(12))
+		if groups > len(b)/12 {
+			return nil
+		}
+		for i := 0; i < groups; i++ {
+			start, end, g := u32(16+12*i), u32(20+12*i), u32(24+12*i)
+			if end < start || end-start > 0x10000 {
+				continue
+			}
+			for c := start; c <= end; c++ {
+				m[c] = uint16(g + c - start)
+			}
+		}
+	default:
+		return nil
+	}
+	return m
+}
+
+// trueTypeTable builds the decoding table for a simple TrueType font
+// without a ToUnicode map from the cmap of its embedded font program.
+//
+// A code selects a glyph through the (3,0) Microsoft Symbol subtable,
+// at code, 0xF000+code, 0xF100+code or 0xF200+code, or failing that
+// through the (1,0) Macintosh Roman subtable. The glyph's character
+// is then found by inverting the (3,1) Microsoft Unicode subtable.
+// Codes that cannot be resolved this way decode as PDFDocEncoding.
+// trueTypeTable returns nil if the font has no usable cmap.
+func trueTypeTable(f Font) *[256]rune {
+	tables := sfntTables(streamBytes(f.V.Key("FontDescriptor").Key("FontFile2")))
+	cmap := tables["cmap"]
+	uni := unicodeCmap(cmap)
+	if len(uni) == 0 {
+		return nil
+	}
+	sym := sfntCmap(cmap, 3, 0)
+	mac := sfntCmap(cmap, 1, 0)
+	glyphRune := invertCmap(uni)
+
+	t := pdfDocEncoding
+	found := false
+	for c := uint32(0); c < 256; c++ {
+		g, ok := uint16(0), false
+		if sym != nil {
+			for _, base := range []uint32{0, 0xF000, 0xF100, 0xF200} {
+				if g, ok = sym[base+c]; ok {
+					break
+				}
+			}
+		}
+		if !ok && mac != nil {
+			g, ok = mac[c]
+		}
+		if !ok {
+			if _, ok := uni[c]; ok && sym == nil && mac == nil {
+				// A Unicode cmap alone: the codes are the characters.
+				t[c] = rune(c)
+				found = true
+			}
+			continue
+		}
+		if r, ok := glyphRune[g]; ok {
+			t[c] = r
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &t
+}
+
+// unicodeCmap returns the (3,1) Unicode BMP subtable of cmap,
+// or the (3,10) full Unicode subtable if there is none.
+func unicodeCmap(cmap []byte) map[uint32]uint16 {
+	if uni := sfntCmap(cmap, 3, 1); uni != nil {
+		return uni
+	}
+	return sfntCmap(cmap, 3, 10)
+}
+
+// invertCmap maps glyph indexes back to characters, preferring
+// the smallest code point when several characters share a glyph.
+func invertCmap(uni map[uint32]uint16) map[uint16]rune {
+	glyphRune := make(map[uint16]rune)
+	for c, g := range uni {
+		if r, ok := glyphRune[g]; !ok || rune(c) < r {
+			glyphRune[g] = rune(c)
+		}
+	}
+	return glyphRune
+}
+
+// sfntPostNames returns the glyph names given by a format 2 post table
+// for a font with n glyphs, or nil if the table has no names.
+func sfntPostNames(post []byte, n int) []string {
+	if len(post) < 34 || binary.BigEndian.Uint32(post) != 0x00020000 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint16(post[32:]))
+	if len(post) < 34+2*count {
+		return nil
+	}
+	// Names past the standard Macintosh set are Pascal strings
+	// following the glyph name indexes.
+	var extra []string
+	for b := post[34+2*count:]; len(b) > 0 && len(b) > int(b[0]); b = b[1+int(b[0]):] {
+		extra = append(extra, string(b[1:1+int(b[0])]))
+	}
+	names := make([]string, min(n, count))
+	for i := range names {
+		x := int(binary.BigEndian.Uint16(post[34+2*i:]))
+		if x < len(macGlyphNames) {
+			names[i] = macGlyphNames[x]
+		} else if x -= len(macGlyphNames); x < len(extra) {
+			names[i] = extra[x]
+		}
+	}
+	return names
+}
+
+// trueTypeEncodingNames returns the glyph names of the built-in
+// encoding of a symbolic TrueType font program: the codes selecting
+// glyphs through its (3,0) or (1,0) cmap subtable, named by its post
+// table. It returns nil if the program has no such cmap or names.
+func trueTypeEncodingNames(data []byte) *[256]string {
+	tables := sfntTables(data)
+	names := sfntPostNames(tables["post"], 0x10000)
+	if names == nil {
+		return nil
+	}
+	sym := sfntCmap(tables["cmap"], 3, 0)
+	mac := sfntCmap(tables["cmap"], 1, 0)
+	if sym == nil && mac == nil {
+		return nil
+	}
+	var enc [256]string
+	for c := uint32(0); c < 256; c++ {
+		g, ok := uint16(0), false
+		for _, base := range []uint32{0, 0xF000, 0xF100, 0xF200} {
+			if g, ok = sym[base+c]; ok {
+				break
+			}
+		}
+		if !ok {
+			g, ok = mac[c]
+		}
+		if ok && int(g) < len(names) {
+			enc[c] = names[g]
+		}
+	}
+	return &enc
+}
+
+// sfntGlyphBoxes returns a function reporting the bounding box of a
+// glyph of the TrueType font with the given tables, in thousandths of
+// an em, or nil if the font has no glyf table. Glyphs with no outline,
+// such as spaces, have an empty box at the origin.
+func sfntGlyphBoxes(tables map[string][]byte) func(gid uint16) (Rectangle, bool) {
+	head, loca, glyf := tables["head"], tables["loca"], tables["glyf"]
+	if len(head) < 54 || loca == nil || glyf == nil {
+		return nil
+	}
+	upem := float64(binary.BigEndian.Uint16(head[18:]))
+	if upem == 0 {
+		return nil
+	}
+	long := binary.BigEndian.Uint16(head[50:]) != 0
+	offset := func(g int) (int, bool) {
+		if long {
+			if 4*g+4 > len(loca) {
+				return 0, false
+			}
+			return int(binary.BigEndian.Uint32(loca[4*g:])), true
+		}
+		if 2*g+2 > len(loca) {
+			return 0, false
+		}
+		return 2 * int(binary.BigEndian.Uint16(loca[2*g:])), true
+	}
+	scale := 1000 / upem
+	return func(gid uint16) (Rectangle, bool) {
+		lo, ok1 := offset(int(gid))
+		hi, ok2 := offset(int(gid) + 1)
+		if !ok1 || !ok2 || hi < lo || hi > len(glyf) {
+			return Rectangle{}, false
+		}
+		if hi-lo < 10 {
+			return Rectangle{}, true
+		}
+		g := glyf[lo:]
+		c := func(i int) float64 { return float64(int16(binary.BigEndian.Uint16(g[i:]))) * scale }
+		return Rectangle{Point{c(2), c(4)}, Point{c(6), c(8)}}, true
+	}
+}
+
+// macGlyphNames are the 258 glyph names of the standard Macintosh
+// character set, to which post table name indexes below 258 refer.
+var macGlyphNames = [...]string{
+	".notdef", ".null", "nonmarkingreturn", "space", "exclam", "quotedbl",
+	"numbersign", "dollar", "percent", "ampersand", "quotesingle",
+	"parenleft", "parenright", "asterisk", "plus", "comma", "hyphen",
+	"period", "slash", "zero", "one", "two", "three", "four", "five", "six",
+	"seven", "eight", "nine", "colon", "semicolon", "less", "equal",
+	"greater", "question", "at", "A", "B", "C", "D", "E", "F", "G", "H", "I",
+	"J", "K", "L", "M", "N", "O", "P", "Q", "R", "S", "T", "U", "V", "W", "X",
+	"Y", "Z", "bracketleft", "backslash", "bracketright", "asciicircum",
+	"underscore", "grave", "a", "b", "c", "d", "e", "f", "g", "h", "i", "j",
+	"k", "l", "m", "n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y",
+	"z", "braceleft", "bar", "braceright", "asciitilde", "Adieresis", "Aring",
+	"Ccedilla", "Eacute", "Ntilde", "Odieresis", "Udieresis", "aacute",
+	"agrave", "acircumflex", "adieresis", "atilde", "aring", "ccedilla",
+	"eacute", "egrave", "ecircumflex", "edieresis", "iacute", "igrave",
+	"icircumflex", "idieresis", "ntilde", "oacute", "ograve", "ocircumflex",
+	"odieresis", "otilde", "uacute", "ugrave", "ucircumflex", "udieresis",
+	"dagger", "degree", "cent", "sterling", "section", "bullet", "paragraph",
+	"germandbls", "registered", "copyright", "trademark", "acute", "dieresis",
+	"notequal", "AE", "Oslash", "infinity", "plusminus", "lessequal",
+	"greaterequal", "yen", "mu", "partialdiff", "summation", "product", "pi",
+	"integral", "ordfeminine", "ordmasculine", "Omega", "ae", "oslash",
+	"questiondown", "exclamdown", "logicalnot", "radical", "florin",
+	"approxequal", "Delta", "guillemotleft", "guillemotright", "ellipsis",
+	"nonbreakingspace", "Agrave", "Atilde", "Otilde", "OE", "oe", "endash",
+	"emdash", "quotedblleft", "quotedblright", "quoteleft", "quoteright",
+	"divide", "lozenge", "ydieresis", "Ydieresis", "fraction", "currency",
+	"guilsinglleft", "guilsinglright", "fi", "fl", "daggerdbl",
+	"periodcentered", "quotesinglbase", "quotedblbase", "perthousand",
+	"Acircumflex", "Ecircumflex", "Aacute", "Edieresis", "Egrave", "Iacute",
+	"Icircumflex", "Idieresis", "Igrave", "Oacute", "Ocircumflex", "apple",
+	"Ograve", "Uacute", "Ucircumflex", "Ugrave", "dotlessi", "circumflex",
+	"tilde", "macron", "breve", "dotaccent", "ring", "cedilla",
+	"hungarumlaut", "ogonek", "caron", "Lslash", "lslash", "Scaron", "scaron",
+	"Zcaron", "zcaron", "brokenbar", "Eth", "eth", "Yacute", "yacute",
+	"Thorn", "thorn", "minus", "multiply", "onesuperior", "twosuperior",
+	"threesuperior", "onehalf", "onequarter", "threequarters", "franc",
+	"Gbreve", "gbreve", "Idotaccent", "Scedilla", "scedilla", "Cacute",
+	"cacute", "Ccaron", "ccaron", "dcroat",
+}
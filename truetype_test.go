@@ -0,0 +1,231 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// sfnt returns a TrueType font program with the tables given by tag.
+// Only the table directory is built; the tables are not checked.
+func sfnt(tables map[string]string) string {
+	var tags []string
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	b := binary.BigEndian.AppendUint32(nil, 0x00010000)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(tags)))
+	b = append(b, make([]byte, 6)...)
+	off := len(b) + 16*len(tags)
+	for _, tag := range tags {
+		b = append(b, tag...)
+		b = binary.BigEndian.AppendUint32(b, 0)
+		b = binary.BigEndian.AppendUint32(b, uint32(off))
+		b = binary.BigEndian.AppendUint32(b, uint32(len(tables[tag])))
+		off += len(tables[tag])
+	}
+	for _, tag := range tags {
+		b = append(b, tables[tag]...)
+	}
+	return string(b)
+}
+
+// A cmapSubtable is a subtable of a cmap table for a platform and encoding.
+type cmapSubtable struct {
+	platform, encoding uint16
+	data               string
+}
+
+// cmapTable returns a cmap table with the subtables.
+func cmapTable(subs ...cmapSubtable) string {
+	b := binary.BigEndian.AppendUint16(nil, 0)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(subs)))
+	off := 4 + 8*len(subs)
+	for _, s := range subs {
+		b = binary.BigEndian.AppendUint16(b, s.platform)
+		b = binary.BigEndian.AppendUint16(b, s.encoding)
+		b = binary.BigEndian.AppendUint32(b, uint32(off))
+		off += len(s.data)
+	}
+	for _, s := range subs {
+		b = append(b, s.data...)
+	}
+	return string(b)
+}
+
+// cmapFormat6 returns a format 6 subtable mapping the codes
+// from first on to the glyphs.
+func cmapFormat6(first uint16, glyphs ...uint16) string {
+	b := binary.BigEndian.AppendUint16(nil, 6)
+	b = binary.BigEndian.AppendUint16(b, uint16(10+2*len(glyphs)))
+	b = binary.BigEndian.AppendUint16(b, 0)
+	b = binary.BigEndian.AppendUint16(b, first)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(glyphs)))
+	for _, g := range glyphs {
+		b = binary.BigEndian.AppendUint16(b, g)
+	}
+	return string(b)
+}
+
+// cmapFormat4 returns a format 4 subtable mapping each code c from
+// start to end of a segment to the glyph c+delta, followed by the
+// required final segment.
+func cmapFormat4(segs ...[3]uint16) string {
+	segs = append(segs, [3]uint16{0xffff, 0xffff, 1})
+	n := len(segs)
+	b := binary.BigEndian.AppendUint16(nil, 4)
+	b = binary.BigEndian.AppendUint16(b, uint16(16+8*n))
+	b = binary.BigEndian.AppendUint16(b, 0)
+	for _, x := range []int{2 * n, 0, 0, 0} { // segCountX2; search fields unused
+		b = binary.BigEndian.AppendUint16(b, uint16(x))
+	}
+	for _, s := range segs {
+		b = binary.BigEndian.AppendUint16(b, s[1])
+	}
+	b = binary.BigEndian.AppendUint16(b, 0)
+	for _, s := range segs {
+		b = binary.BigEndian.AppendUint16(b, s[0])
+	}
+	for _, s := range segs {
+		b = binary.BigEndian.AppendUint16(b, s[2])
+	}
+	for range segs {
+		b = binary.BigEndian.AppendUint16(b, 0)
+	}
+	return string(b)
+}
+
+// trueTypePDF returns a page using as /F1 the simple TrueType font
+// whose program is prog, with the extra font dictionary entries and
+// the descriptor flags.
+func trueTypePDF(entries string, flags int, prog string) []byte {
+	return pagePDF("<</Font <</F1 5 0 R>>>>", "",
+		"<</Type /Font /Subtype /TrueType /BaseFont /ABCDEF+Test /FontDescriptor 6 0 R "+entries+">>",
+		"<</Type /FontDescriptor /FontName /ABCDEF+Test /Flags "+strconv.Itoa(flags)+" /FontFile2 7 0 R>>",
+		stream("", prog))
+}
+
+// decodeText returns the text that raw decodes to in f.
+func decodeText(f Font, raw string) string {
+	var s []rune
+	for _, ch := range f.Decode(raw) {
+		s = append(s, ch.Text...)
+	}
+	return string(s)
+}
+
+func TestTrueTypeCmap(t *testing.T) {
+	// Glyphs 1, 2 and 3 are A, B and C.
+	uni := cmapSubtable{3, 1, cmapFormat4([3]uint16{'A', 'C', 0x10000 + 1 - 'A'})}
+	tests := []struct {
+		name  string
+		flags int
+		cmap  string
+		raw   string
+		want  string
+	}{
+		// Macintosh codes a, b, c select glyphs 1, 2, 3.
+		{"mac", 32, cmapTable(cmapSubtable{1, 0, cmapFormat6('a', 1, 2, 3)}, uni), "cab", "CAB"},
+		// Symbol codes are found at 0xF000 + code.
+		{"symbol", 4, cmapTable(cmapSubtable{3, 0, cmapFormat6(0xF021, 3, 2)}, uni), "!\"", "CB"},
+		// With a Unicode cmap alone, the codes are the characters.
+		{"unicode", 32, cmapTable(uni), "BA", "BA"},
+	}
+	for _, tt := range tests {
+		r := openPDF(t, trueTypePDF("", tt.flags, sfnt(map[string]string{"cmap": tt.cmap})))
+		f := r.Page(1).Font("F1")
+		if got := decodeText(f, tt.raw); got != tt.want {
+			t.Errorf("%s: %q decodes as %q, want %q", tt.name, tt.raw, got, tt.want)
+		}
+	}
+
+	// A standard encoding takes precedence for a nonsymbolic font.
+	prog := sfnt(map[string]string{"cmap": cmapTable(cmapSubtable{1, 0, cmapFormat6('a', 1, 2, 3)}, uni)})
+	r := openPDF(t, trueTypePDF("/Encoding /WinAnsiEncoding", 32, prog))
+	if got := decodeText(r.Page(1).Font("F1"), "cab"); got != "cab" {
+		t.Errorf("WinAnsiEncoding: %q decodes as %q, want %q", "cab", got, "cab")
+	}
+}
+
+// postTable returns a format 2 post table naming the glyphs.
+// Names in the standard Macintosh set are given by index.
+func postTable(names ...string) string {
+	b := binary.BigEndian.AppendUint32(nil, 0x00020000)
+	b = append(b, make([]byte, 28)...)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(names)))
+	var extra []byte
+	n := 0
+	for _, name := range names {
+		x := -1
+		for i, s := range macGlyphNames {
+			if s == name {
+				x = i
+			}
+		}
+		if x < 0 {
+			x = len(macGlyphNames) + n
+			n++
+			extra = append(append(extra, byte(len(name))), name...)
+		}
+		b = binary.BigEndian.AppendUint16(b, uint16(x))
+	}
+	return string(append(b, extra...))
+}
+
+func TestSymbolicEncoding(t *testing.T) {
+	// A symbolic TrueType font with no Unicode cmap: the codes
+	// select glyphs whose names give the text.
+	prog := sfnt(map[string]string{
+		"cmap": cmapTable(cmapSubtable{3, 0, cmapFormat6(0xF041, 1, 2, 3)}),
+		"post": postTable(".notdef", "alpha", "A", "uni2192"),
+	})
+	r := openPDF(t, trueTypePDF("", 4, prog))
+	if got := decodeText(r.Page(1).Font("F1"), "ABC"); got != "αA→" {
+		t.Errorf("symbolic TrueType font: text %q, want %q", got, "αA→")
+	}
+
+	// The standard symbolic fonts need not be embedded.
+	tests := []struct {
+		font string
+		raw  string
+		want string
+	}{
+		{"Symbol", "abg", "αβγ"},
+		{"ABCDEF+Symbol,Bold", "abg", "αβγ"},
+		{"ZapfDingbats", "4", "✔"},
+		{"Helvetica", "abg", "abg"},
+	}
+	for _, tt := range tests {
+		data := pagePDF("<</Font <</F1 5 0 R>>>>", "", "<</Type /Font /Subtype /Type1 /BaseFont /"+tt.font+">>")
+		if got := decodeText(openPDF(t, data).Page(1).Font("F1"), tt.raw); got != tt.want {
+			t.Errorf("%s: %q decodes as %q, want %q", tt.font, tt.raw, got, tt.want)
+		}
+	}
+}
+
+// glyfTables returns head, loca and glyf tables for glyphs with
+// the bounding boxes, in a font with 2048 units to the em.
+// A zero box is a glyph without an outline.
+func glyfTables(boxes ...[4]int16) map[string]string {
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint16(head[18:], 2048)
+	var loca, glyf []byte
+	for _, b := range boxes {
+		loca = binary.BigEndian.AppendUint16(loca, uint16(len(glyf)/2))
+		if b == ([4]int16{}) {
+			continue
+		}
+		glyf = binary.BigEndian.AppendUint16(glyf, 1)
+		for _, x := range b {
+			glyf = binary.BigEndian.AppendUint16(glyf, uint16(x))
+		}
+	}
+	loca = binary.BigEndian.AppendUint16(loca, uint16(len(glyf)/2))
+	return map[string]string{"head": string(head), "loca": string(loca), "glyf": string(glyf)}
+}
@@ -0,0 +1,120 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Embedded Type 1 font programs and the built-in encodings
+// of embedded font programs in general.
+
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// type1EncodingNames returns the glyph names of the built-in encoding
+// declared in the clear-text portion of the Type 1 font program data,
+// indexed by code. It returns nil if the program declares no encoding.
+func type1EncodingNames(data []byte) *[256]string {
+	if i := bytes.Index(data, []byte("eexec")); i >= 0 {
+		data = data[:i]
+	}
+	i := bytes.Index(data, []byte("/Encoding"))
+	if i < 0 {
+		return nil
+	}
+	// Separate names from the tokens before them: "dup 32/space put".
+	s := strings.ReplaceAll(string(data[i+len("/Encoding"):]), "/", " /")
+	fields := strings.Fields(s)
+	if len(fields) > 0 && fields[0] == "StandardEncoding" {
+		var enc [256]string
+		for c, sid := range cffStandardEncoding {
+			if sid != 0 {
+				enc[c] = cffStandardStrings[sid]
+			}
+		}
+		return &enc
+	}
+	var enc [256]string
+	found := false
+	for j := 0; j+3 < len(fields); j++ {
+		if fields[j] == "def" || fields[j] == "readonly" {
+			break
+		}
+		if fields[j] != "dup" || fields[j+3] != "put" || !strings.HasPrefix(fields[j+2], "/") {
+			continue
+		}
+		c, err := strconv.Atoi(fields[j+1])
+		if err != nil || c < 0 || c > 255 {
+			continue
+		}
+		enc[c] = fields[j+2][1:]
+		found = true
+		j += 3
+	}
+	if !found {
+		return nil
+	}
+	return &enc
+}
+
+// builtinEncoding returns the glyph names of the built-in encoding of
+// f's embedded font program, indexed by code, or nil if f has no
+// embedded font program or its encoding cannot be read.
+func (f Font) builtinEncoding() *[256]string {
+	fd := f.V.Key("FontDescriptor")
+	if ff := fd.Key("FontFile"); ff.Kind() == Stream {
+		return type1EncodingNames(streamBytes(ff))
+	}
+	if ff := fd.Key("FontFile2"); ff.Kind() == Stream {
+		return trueTypeEncodingNames(streamBytes(ff))
+	}
+	ff := fd.Key("FontFile3")
+	switch ff.Key("Subtype").CoerceName("") {
+	case "Type1C":
+		return cffEncodingNames(streamBytes(ff))
+	case "OpenType":
+		if cff, ok := sfntTables(streamBytes(ff))["CFF "]; ok {
+			return cffEncodingNames(cff)
+		}
+	}
+	return nil
+}
+
+// standardFontEncoding returns the built-in encoding of f if f is one
+// of the standard symbolic fonts, Symbol or ZapfDingbats, which are
+// often used without being embedded; otherwise it returns nil.
+func (f Font) standardFontEncoding() *[256]rune {
+	name := f.CanonicalBaseFont()
+	if i := strings.IndexAny(name, ",-"); i >= 0 {
+		name = name[:i]
+	}
+	switch name {
+	case "Symbol", "SymbolMT":
+		return &symbolEncoding
+	case "ZapfDingbats", "ZapfDingbatsITC", "Dingbats":
+		return &zapfDingbatsEncoding
+	}
+	return nil
+}
+
+// namesTable returns the text table for the glyph names, indexed by
+// code. Ligature glyphs decode to all their letters. Codes without
+// a name, or whose name is not in the glyph list, decode through the
+// fallback table, if any.
+func namesTable(names *[256]string, fallback *[256]rune) *[256][]rune {
+	var t [256][]rune
+	for c := range t {
+		if names[c] != "" {
+			if rs := glyphRunes(names[c]); len(rs) > 0 {
+				t[c] = rs
+				continue
+			}
+		}
+		if fallback != nil && fallback[c] != noRune {
+			t[c] = []rune{fallback[c]}
+		}
+	}
+	return &t
+}
@@ -0,0 +1,115 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// cffIndexData returns a CFF INDEX of the elements.
+func cffIndexData(elems ...string) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(elems)))
+	if len(elems) == 0 {
+		return b
+	}
+	b = append(b, 1, 1) // one-byte offsets, starting at 1
+	off := 1
+	for _, e := range elems {
+		off += len(e)
+		b = append(b, byte(off))
+	}
+	for _, e := range elems {
+		b = append(b, e...)
+	}
+	return b
+}
+
+// cffFont returns a CFF font program whose glyphs after .notdef are
+// named by glyphs and encoded by the corresponding codes.
+func cffFont(glyphs []string, codes []byte) string {
+	var strs []string
+	sids := make([]byte, 0, 2*len(glyphs))
+	for _, name := range glyphs {
+		sid := -1
+		for i, s := range cffStandardStrings {
+			if s == name {
+				sid = i
+			}
+		}
+		if sid < 0 {
+			sid = len(cffStandardStrings) + len(strs)
+			strs = append(strs, name)
+		}
+		sids = binary.BigEndian.AppendUint16(sids, uint16(sid))
+	}
+	charStrings := []string{"\x0e"} // endchar
+	for range glyphs {
+		charStrings = append(charStrings, "\x0e")
+	}
+
+	// The top DICT gives the offsets of the charset, the encoding and
+	// the CharStrings as 5-byte integers, so its size is known.
+	head := append([]byte{1, 0, 4, 1}, cffIndexData("Test")...)
+	const topSize = 2 + 1 + 2 + 18
+	rest := append(cffIndexData(strs...), cffIndexData()...) // String and Global Subr INDEXes
+	charset := len(head) + topSize + len(rest)
+	encoding := charset + 1 + len(sids)
+	cs := encoding + 2 + len(codes)
+	var top []byte
+	for _, x := range [][2]int{{charset, cffCharset}, {encoding, cffEncoding}, {cs, cffCharStrings}} {
+		top = append(top, 29)
+		top = binary.BigEndian.AppendUint32(top, uint32(x[0]))
+		top = append(top, byte(x[1]))
+	}
+
+	b := append(head, cffIndexData(string(top))...)
+	b = append(b, rest...)
+	b = append(b, 0)
+	b = append(b, sids...)
+	b = append(b, 0, byte(len(codes)))
+	b = append(b, codes...)
+	b = append(b, cffIndexData(charStrings...)...)
+	return string(b)
+}
+
+func TestBuiltinEncoding(t *testing.T) {
+	type1 := "%!PS-AdobeFont-1.0: Test\n/FontName /Test def\n" +
+		"/Encoding 256 array\n0 1 255 {1 index exch /.notdef put} for\n" +
+		"dup 65 /Eth put\ndup 66 /alpha put\nreadonly def\ncurrentfile eexec\n\x80\x81"
+	cff := cffFont([]string{"Eth", "alpha", "beta", "Test.alt"}, []byte{'A', 'B', 'C', 'D'})
+	fonts := []struct {
+		name string
+		file string
+	}{
+		{"Type 1", "/FontFile 7 0 R>>"},
+		{"CFF", "/FontFile3 7 0 R>>"},
+	}
+	tests := []struct {
+		encoding string
+		want     string
+	}{
+		{"", "ÐαD"},
+		// Differences apply to the built-in encoding.
+		{"/Encoding <</Differences [66 /beta]>>", "ÐβD"},
+		{"/Encoding <</BaseEncoding /WinAnsiEncoding /Differences [66 /beta]>>", "AβD"},
+	}
+	for i, prog := range []string{type1, cff} {
+		dict := ""
+		if i == 1 {
+			dict = "/Subtype /Type1C"
+		}
+		for _, tt := range tests {
+			data := pagePDF("<</Font <</F1 5 0 R>>>>", "",
+				"<</Type /Font /Subtype /Type1 /BaseFont /ABCDEF+Test /FontDescriptor 6 0 R "+tt.encoding+">>",
+				"<</Type /FontDescriptor /FontName /ABCDEF+Test /Flags 4 "+fonts[i].file,
+				stream(dict, prog))
+			f := openPDF(t, data).Page(1).Font("F1")
+			if got := decodeText(f, "ABD"); got != tt.want {
+				t.Errorf("%s font with %q: text %q, want %q", fonts[i].name, tt.encoding, got, tt.want)
+			}
+		}
+	}
+}
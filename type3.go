@@ -0,0 +1,137 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Type 3 fonts (PDF 32000-1:2008, §9.6.5), whose glyphs are drawn
+// by content streams and whose metrics are given in glyph space.
+
+package pdf
+
+// isType3 reports whether f is a Type 3 font.
+func (f Font) isType3() bool {
+	return f.V.Key("Subtype").CoerceName("") == "Type3"
+}
+
+// fontMatrix returns the matrix mapping glyph space to text space.
+// It is only meaningful for Type 3 fonts; all other fonts
+// use the fixed scale of 1000 glyph units per text space unit.
+func (f Font) fontMatrix() Matrix {
+	if a := floats(f.V.Key("FontMatrix")); len(a) == 6 {
+		return Matrix{{a[0], a[1], 0}, {a[2], a[3], 0}, {a[4], a[5], 1}}
+	}
+	return Matrix{{0.001, 0, 0}, {0, 0.001, 0}, {0, 0, 1}}
+}
+
+// CharProc returns the content stream that draws the glyph
+// for the character code in a Type 3 font.
+// If f is not a Type 3 font or has no glyph for code,
+// CharProc returns a null Value.
+func (f Font) CharProc(code byte) Value {
+	if !f.isType3() {
+		return Value{}
+	}
+	name := differencesName(f.V.Key("Encoding").Key("Differences"), code)
+	if name == "" {
+		return Value{}
+	}
+	return f.V.Key("CharProcs").Key(name)
+}
+
+// differencesName returns the glyph name that the Differences array diff
+// assigns to code, or "" if it assigns none.
+func differencesName(diff Value, code byte) string {
+	n := -1
+	name := ""
+	for j := 0; j < diff.Len(); j++ {
+		x := diff.Index(j)
+		switch x.Kind() {
+		case Integer:
+			n = int(x.CoerceInt64(0))
+		case Name:
+			if n == int(code) {
+				name = x.CoerceName("")
+			}
+			n++
+		}
+	}
+	return name
+}
+
+// type3WidthGrabber converts the glyph-space widths of a Type 3 font
+// into the thousandths of a text space unit used for all other fonts.
+// Codes missing from the Widths array take the width set by the
+// d0 or d1 operator at the start of the glyph's content stream.
+type type3WidthGrabber struct {
+	f     Font
+	wg    WidthGrabber
+	scale float64
+	procs map[uint32]float64 // widths read from glyph procedures
+}
+
+func newType3WidthGrabber(f Font, wg WidthGrabber) *type3WidthGrabber {
+	return &type3WidthGrabber{
+		f:     f,
+		wg:    wg,
+		scale: 1000 * f.fontMatrix()[0][0],
+		procs: make(map[uint32]float64),
+	}
+}
+
+func (t *type3WidthGrabber) Width(code uint32) float64 {
+	if w := t.wg.Width(code); w != 0 || code > 0xff {
+		return w * t.scale
+	}
+	w, ok := t.procs[code]
+	if !ok {
+		w = glyphProcWidth(t.f.CharProc(byte(code)))
+		t.procs[code] = w
+	}
+	return w * t.scale
+}
+
+// glyphProcWidth returns the horizontal displacement set by
+// the d0 or d1 operator in the Type 3 glyph procedure proc.
+func glyphProcWidth(proc Value) float64 {
+	w, _, _ := glyphProcMetrics(proc)
+	return w
+}
+
+// glyphProcMetrics returns the operands of the d0 or d1 operator
+// that begins the Type 3 glyph procedure proc: the horizontal
+// displacement and, for d1, the glyph's bounding box.
+func glyphProcMetrics(proc Value) (w float64, bbox Rectangle, hasBBox bool) {
+	if proc.Kind() != Stream {
+		return 0, Rectangle{}, false
+	}
+	defer func() {
+		// A malformed glyph procedure just has no metrics.
+		if recover() != nil {
+			w, bbox, hasBBox = 0, Rectangle{}, false
+		}
+	}()
+	done := false
+	Interpret(proc, func(stk *Stack, op string) {
+		n := stk.Len()
+		args := make([]Value, n)
+		for i := n - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+		if done || n < 2 {
+			return
+		}
+		switch op {
+		case "d1":
+			if n >= 6 {
+				x0, y0 := args[2].CoerceFloat64(0), args[3].CoerceFloat64(0)
+				x1, y1 := args[4].CoerceFloat64(0), args[5].CoerceFloat64(0)
+				bbox = Rectangle{Point{min(x0, x1), min(y0, y1)}, Point{max(x0, x1), max(y0, y1)}}
+				hasBBox = true
+			}
+			fallthrough
+		case "d0":
+			w = args[0].CoerceFloat64(0)
+			done = true
+		}
+	})
+	return w, bbox, hasBBox
+}
@@ -0,0 +1,56 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestType3(t *testing.T) {
+	// Glyph space is 100 units to the em. Code 97 (a) has its width in
+	// Widths; code 98 (b) only in its d1 operator; code 99 has no glyph.
+	data := pagePDF("<</Font <</T3 5 0 R>>>>", "BT /T3 20 Tf 100 700 Td (abc) Tj (a) Tj ET",
+		"<</Type /Font /Subtype /Type3 /FontBBox [0 0 100 100] /FontMatrix [0.01 0 0 0.01 0 0]"+
+			" /CharProcs <</square 6 0 R /bar 7 0 R>> /Encoding <</Type /Encoding /Differences [97 /square /bar]>>"+
+			" /FirstChar 97 /LastChar 98 /Widths [60 0]>>",
+		stream("", "60 0 d0 0 0 50 50 re f"),
+		stream("", "30 0 0 0 20 100 d1 0 0 20 100 re f"),
+	)
+	r := openPDF(t, data)
+	p := r.Page(1)
+	f := p.Font("T3")
+	if v := f.CharProc('a'); v.Kind() != Stream {
+		t.Errorf("CharProc('a') = %v, want the square's stream", v)
+	}
+	if v := f.CharProc('c'); v.Kind() != Null {
+		t.Errorf("CharProc('c') = %v, want null", v)
+	}
+
+	c, err := p.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tx := range c.Text {
+		for _, ch := range tx.S {
+			got = append(got, fmt.Sprintf("%q %v", string(ch.Text), ch.Width))
+		}
+	}
+	// Widths are in thousandths of an em. The glyph name square is
+	// not a standard name, so the code is taken as the text.
+	want := fmt.Sprint([]string{`"a" 600`, `"|" 300`, `"c" 0`, `"a" 600`})
+	if fmt.Sprint(got) != want {
+		t.Errorf("glyphs %v, want %v", got, want)
+	}
+	// The text advances by 0.9 em at 20 points, and its size
+	// matches its widths, whatever the font matrix.
+	if len(c.Text) != 2 || c.Text[1].X != 118 || c.Text[0].FontSize != 20 {
+		t.Fatalf("text %+v, want the second string at x = 118 in 20-point type", c.Text)
+	}
+	if b := glyphBoxes(c.Text[0]); b[1].Max.X != 118 {
+		t.Errorf("glyph boxes %v, want the bar to end at x = 118", b)
+	}
+}
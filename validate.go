@@ -0,0 +1,327 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Checking the structure of a document.
+
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Validate checks the structure of the document and returns what it
+// finds wrong, or nil if nothing is. It checks that:
+//
+//   - each cross-reference entry leads to the object it numbers
+//     (rule xref), and each object can be parsed (malformed-object);
+//   - no object number is defined twice in the same revision of the
+//     file (duplicate-object);
+//   - every reference from the objects reachable from the trailer
+//     leads to an object (dangling-ref);
+//   - the entries of catalogs, page tree nodes, pages, outlines,
+//     fonts, font descriptors, annotations, XObjects and object
+//     streams, and streams' Length entries, have the kinds of value
+//     PDF 32000-1:2008 requires, rectangles having four numbers (type);
+//   - the content streams of the pages parse and their operators have
+//     the operands they require, as reported in Content's Warnings
+//     (content).
+//
+// Validate reads every object in the file, which may take a while
+// for large files.
+func (r *Reader) Validate() []Violation {
+	v := &validator{r: r, seen: make(map[pdfobjptr]bool)}
+	v.checkXref()
+	v.checkDuplicates()
+	v.checkGraph()
+	v.checkContents()
+	return v.out
+}
+
+// A validator accumulates the violations Validate finds.
+type validator struct {
+	r     *Reader
+	out   []Violation
+	seen  map[pdfobjptr]bool // objects reached
+	queue []pdfobjptr        // objects reached but not yet checked
+}
+
+func (v *validator) add(rule string, ref ObjRef, format string, args ...interface{}) {
+	v.out = append(v.out, Violation{rule, fmt.Sprintf(format, args...), ref})
+}
+
+// load returns the object ptr, or an error if it is missing or malformed.
+func (v *validator) load(ptr pdfobjptr) (val Value, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			val, err = Value{}, fmt.Errorf("%v", e)
+		}
+	}()
+	val = v.r.resolve(pdfobjptr{}, ptr)
+	return val, val.err
+}
+
+// checkXref loads every object in the cross-reference table, which
+// fails if the entry for the object leads elsewhere.
+func (v *validator) checkXref() {
+	for _, x := range v.r.xref {
+		if x.ptr == (pdfobjptr{}) || !x.inStream && x.offset == 0 {
+			continue
+		}
+		if _, err := v.load(x.ptr); err != nil {
+			rule := "malformed-object"
+			if !x.inStream && !v.objectAt(x.offset, x.ptr) {
+				rule = "xref"
+				err = fmt.Errorf("cross-reference entry at offset %d does not lead to the object", x.offset)
+			}
+			v.add(rule, ObjRef{x.ptr.id, x.ptr.gen}, "%v", err)
+		}
+	}
+}
+
+// objHeader matches the start of an indirect object definition.
+var objHeader = regexp.MustCompile(`^[\x00\t\n\f\r ]*([0-9]{1,10})[\x00\t\n\f\r ]+([0-9]{1,5})[\x00\t\n\f\r ]+obj`)
+
+// objectAt reports whether the definition of the object ptr begins at offset.
+func (v *validator) objectAt(offset int64, ptr pdfobjptr) bool {
+	buf := make([]byte, 64)
+	n, _ := v.r.f.ReadAt(buf, offset)
+	m := objHeader.FindSubmatch(buf[:n])
+	if m == nil {
+		return false
+	}
+	id, _ := strconv.ParseUint(string(m[1]), 10, 32)
+	gen, _ := strconv.ParseUint(string(m[2]), 10, 16)
+	return pdfobjptr{uint32(id), uint16(gen)} == ptr
+}
+
+// checkDuplicates scans the file for object definitions and reports
+// object numbers defined twice in the same revision. Revisions added
+// by incremental updates may redefine objects of earlier ones.
+func (v *validator) checkDuplicates() {
+	defined := make(map[uint32]int64)
+	err := scanFile(v.r.f, v.r.end, func(offset int64, tok string, ptr pdfobjptr) {
+		switch tok {
+		case "%%EOF":
+			defined = make(map[uint32]int64)
+		case "obj":
+			if prev, ok := defined[ptr.id]; ok {
+				v.add("duplicate-object", ObjRef{ptr.id, ptr.gen}, "object defined at offsets %d and %d", prev, offset)
+			}
+			defined[ptr.id] = offset
+		}
+	})
+	if err != nil {
+		v.add("duplicate-object", ObjRef{}, "reading file: %v", err)
+	}
+}
+
+// checkGraph checks the objects reachable from the trailer.
+func (v *validator) checkGraph() {
+	if t, ok := v.r.Trailer.data.(pdfdict); ok {
+		v.visit(t, ObjRef{}, "trailer")
+	}
+	for len(v.queue) > 0 {
+		ptr := v.queue[0]
+		v.queue = v.queue[1:]
+		ref := ObjRef{ptr.id, ptr.gen}
+		val, err := v.load(ptr)
+		if err != nil {
+			// Reported by checkXref.
+			continue
+		}
+		if strm, ok := val.data.(pdfstream); ok {
+			if k := v.kind(strm.hdr["Length"]); k != Integer {
+				v.add("type", ref, "stream Length is %v, want Integer", k)
+			}
+			v.visit(strm.hdr, ref, "")
+			continue
+		}
+		v.visit(val.data, ref, "")
+	}
+}
+
+// visit checks the direct object x, found at path in the object ref,
+// and queues the objects it refers to.
+func (v *validator) visit(x pdfobject, ref ObjRef, path string) {
+	switch x := x.(type) {
+	case pdfobjptr:
+		if !v.r.exists(x) {
+			v.add("dangling-ref", ref, "%s refers to missing object %d %d R", pathOrSelf(path), x.id, x.gen)
+		} else if !v.seen[x] {
+			v.seen[x] = true
+			v.queue = append(v.queue, x)
+		}
+	case pdfarray:
+		for i, y := range x {
+			v.visit(y, ref, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case pdfdict:
+		v.checkEntries(x, ref, path)
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, string(k))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v.visit(x[pdfname(k)], ref, path+"/"+k)
+		}
+	}
+}
+
+// pathOrSelf returns path, or "object" for the empty path of an object itself.
+func pathOrSelf(path string) string {
+	if path == "" {
+		return "object"
+	}
+	return path
+}
+
+// kind returns the kind of x, following a reference.
+func (v *validator) kind(x pdfobject) ValueKind {
+	if ptr, ok := x.(pdfobjptr); ok {
+		val, err := v.load(ptr)
+		if err != nil {
+			return Null
+		}
+		return val.Kind()
+	}
+	return Value{data: x}.Kind()
+}
+
+// entryKinds gives the kinds of value that entries of dictionaries
+// of each Type may have (PDF 32000-1:2008, Tables 28, 29, 30, 152,
+// 111, 122, 168, 89 and 16).
+var entryKinds = map[string]map[string][]ValueKind{
+	"Catalog": {
+		"Pages": {Dict}, "Outlines": {Dict}, "Names": {Dict}, "Dests": {Dict},
+		"AcroForm": {Dict}, "Metadata": {Stream}, "PageLabels": {Dict},
+		"OpenAction": {Array, Dict},
+	},
+	"Pages": {"Kids": {Array}, "Count": {Integer}, "Parent": {Dict}},
+	"Page": {
+		"Parent": {Dict}, "Resources": {Dict}, "MediaBox": {Array}, "CropBox": {Array},
+		"Contents": {Stream, Array}, "Rotate": {Integer}, "Annots": {Array},
+	},
+	"Outlines": {"First": {Dict}, "Last": {Dict}, "Count": {Integer}},
+	"Font": {
+		"Subtype": {Name}, "BaseFont": {Name}, "FirstChar": {Integer}, "LastChar": {Integer},
+		"Widths": {Array}, "FontDescriptor": {Dict}, "Encoding": {Name, Dict, Stream},
+		"ToUnicode": {Stream, Name}, "DescendantFonts": {Array},
+	},
+	"FontDescriptor": {
+		"FontName": {Name}, "Flags": {Integer}, "FontBBox": {Array}, "ItalicAngle": {Integer, Real},
+		"FontFile": {Stream}, "FontFile2": {Stream}, "FontFile3": {Stream},
+	},
+	"Annot":   {"Subtype": {Name}, "Rect": {Array}, "P": {Dict}, "AP": {Dict}, "F": {Integer}},
+	"XObject": {"Subtype": {Name}, "BBox": {Array}, "Width": {Integer}, "Height": {Integer}, "BitsPerComponent": {Integer}},
+	"ObjStm":  {"N": {Integer}, "First": {Integer}, "Extends": {Stream}},
+}
+
+// rectKeys are the entries holding rectangles, which must be arrays of four numbers.
+var rectKeys = []pdfname{"MediaBox", "CropBox", "BleedBox", "TrimBox", "ArtBox", "Rect", "BBox", "FontBBox"}
+
+// checkEntries checks the kinds of the entries of the dictionary d,
+// found at path in the object ref.
+func (v *validator) checkEntries(d pdfdict, ref ObjRef, path string) {
+	typ, _ := d["Type"].(pdfname)
+	kinds := entryKinds[string(typ)]
+	keys := make([]string, 0, len(kinds))
+	for k := range kinds {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		x, ok := d[pdfname(key)]
+		if !ok {
+			continue
+		}
+		k := v.kind(x)
+		if k == Null {
+			// A null entry is as if absent, and a dangling
+			// reference is reported as such.
+			continue
+		}
+		want := kinds[key]
+		found := false
+		for _, w := range want {
+			found = found || k == w
+		}
+		if !found {
+			v.add("type", ref, "%s/%s of %s is %v, want %v", path, key, typ, k, kindList(want))
+		}
+	}
+	if typ == "" {
+		return
+	}
+	for _, key := range rectKeys {
+		x, ok := d[key]
+		if !ok || v.kind(x) != Array {
+			continue
+		}
+		a := Value{r: v.r, data: x}
+		if ptr, ok := x.(pdfobjptr); ok {
+			a, _ = v.load(ptr)
+		}
+		valid := a.Len() == 4
+		for i := 0; i < a.Len(); i++ {
+			if k := a.Index(i).Kind(); k != Integer && k != Real {
+				valid = false
+			}
+		}
+		if !valid {
+			v.add("type", ref, "%s/%s of %s is not a rectangle", path, key, typ)
+		}
+	}
+}
+
+// kindList returns the kinds in want, listed as alternatives.
+func kindList(want []ValueKind) string {
+	s := ""
+	for i, k := range want {
+		if i > 0 {
+			s += " or "
+		}
+		s += k.String()
+	}
+	return s
+}
+
+// checkContents interprets the content of each page,
+// reporting the problems the interpreter works around.
+func (v *validator) checkContents() {
+	defer func() {
+		if e := recover(); e != nil {
+			v.add("content", ObjRef{}, "reading page tree: %v", e)
+		}
+	}()
+	if pages := v.r.Trailer.Key("Root").Key("Pages"); pages.err != nil {
+		v.add("content", ObjRef{}, "reading page tree: %v", pages.err)
+		return
+	}
+	for i := 1; i <= v.r.NumPage(); i++ {
+		v.checkPage(i)
+	}
+}
+
+// checkPage interprets the content of page i.
+func (v *validator) checkPage(i int) {
+	var ref ObjRef
+	defer func() {
+		if e := recover(); e != nil {
+			v.add("content", ref, "page %d: %v", i, e)
+		}
+	}()
+	p := v.r.Page(i)
+	ref = p.V.Ref()
+	c, err := p.Content()
+	if err != nil {
+		v.add("content", ref, "page %d: %v", i, err)
+	}
+	for _, w := range c.Warnings {
+		v.add("content", ref, "page %d: %v", i, w)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	font := "<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>"
+	page := func(entries, content string, extra ...string) []byte {
+		return buildPDF("", append([]string{
+			"<</Type /Catalog /Pages 2 0 R>>",
+			"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+			"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R " + entries + ">>",
+			stream("", content),
+			font,
+		}, extra...)...)
+	}
+	good := page("/MediaBox [0 0 612 792]", show(72, 700, "hello"))
+
+	// Object 2's cross-reference entry leads to object 3.
+	off2, off3 := bytes.Index(good, []byte("\n2 0 obj"))+1, bytes.Index(good, []byte("\n3 0 obj"))+1
+	misplaced := bytes.Replace(good, fmt.Appendf(nil, "%010d 00000 n", off2), fmt.Appendf(nil, "%010d 00000 n", off3), 1)
+
+	// Object 6 is defined twice.
+	dup := page("/MediaBox [0 0 612 792]", "", "<<>>\nendobj\n6 0 obj\n<<>>")
+	first, second := bytes.Index(dup, []byte("\n6 0 obj"))+1, bytes.LastIndex(dup, []byte("\n6 0 obj"))+1
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"good", good, "[]"},
+		{"dangling", page("/MediaBox [0 0 612 792] /Annots [9 0 R]", ""),
+			"[dangling-ref: 3 0 R: /Annots[0] refers to missing object 9 0 R]"},
+		{"wrong kind", page("/MediaBox [0 0 612 792] /Rotate (90)", ""),
+			"[type: 3 0 R: /Rotate of Page is String, want Integer]"},
+		{"not a rectangle", page("/MediaBox [0 0 612]", ""),
+			"[type: 3 0 R: /MediaBox of Page is not a rectangle]"},
+		{"duplicate", dup, fmt.Sprintf("[duplicate-object: 6 0 R: object defined at offsets %d and %d]", first, second)},
+		{"misplaced", misplaced, fmt.Sprintf("[xref: 2 0 R: cross-reference entry at offset %d does not lead to the object content: reading page tree: loading {2 0}: found {3 0}]", off3)},
+		{"content", page("/MediaBox [0 0 612 792]", "0 0 rg"), "[content: 3 0 R: page 1: rg: want 3 operands, have 2]"},
+	}
+	for _, tt := range tests {
+		got := fmt.Sprint(openPDF(t, tt.data).Validate())
+		if got != tt.want {
+			t.Errorf("%s: Validate() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
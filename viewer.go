@@ -0,0 +1,61 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// How the document should be presented: the catalog's PageLayout and
+// PageMode and its viewer preferences (PDF 32000-1:2008, §12.2).
+
+package pdf
+
+// ViewerPreferences describes how the document asks to be displayed
+// and printed. Entries missing from the document have their defaults.
+type ViewerPreferences struct {
+	PageLayout string // SinglePage, OneColumn, TwoColumnLeft, TwoColumnRight, TwoPageLeft or TwoPageRight
+	PageMode   string // UseNone, UseOutlines, UseThumbs, FullScreen, UseOC or UseAttachments
+
+	HideToolbar           bool
+	HideMenubar           bool
+	HideWindowUI          bool
+	FitWindow             bool   // resize the window to fit the first page
+	CenterWindow          bool   // center the window on the screen
+	DisplayDocTitle       bool   // show the document's title, not its file name, in the title bar
+	NonFullScreenPageMode string // page mode on leaving full-screen mode: UseNone, UseOutlines, UseThumbs or UseOC
+	Direction             string // reading order: L2R or R2L, which also orders pages side by side
+
+	PrintScaling      string   // AppDefault, or None to print at actual size
+	Duplex            string   // Simplex, DuplexFlipShortEdge or DuplexFlipLongEdge, or empty if unspecified
+	PickTrayByPDFSize bool     // choose the paper tray by page size
+	PrintPageRange    [][2]int // ranges of pages to print initially, starting at 1, or nil for all
+	NumCopies         int
+
+	V Value // the viewer preferences dictionary
+}
+
+// ViewerPreferences returns the document's presentation preferences,
+// the catalog's ViewerPreferences, PageLayout and PageMode entries.
+func (r *Reader) ViewerPreferences() ViewerPreferences {
+	root := r.Trailer.Key("Root")
+	v := root.Key("ViewerPreferences")
+	p := ViewerPreferences{
+		PageLayout:            root.Key("PageLayout").CoerceName("SinglePage"),
+		PageMode:              root.Key("PageMode").CoerceName("UseNone"),
+		HideToolbar:           v.Key("HideToolbar").data == true,
+		HideMenubar:           v.Key("HideMenubar").data == true,
+		HideWindowUI:          v.Key("HideWindowUI").data == true,
+		FitWindow:             v.Key("FitWindow").data == true,
+		CenterWindow:          v.Key("CenterWindow").data == true,
+		DisplayDocTitle:       v.Key("DisplayDocTitle").data == true,
+		NonFullScreenPageMode: v.Key("NonFullScreenPageMode").CoerceName("UseNone"),
+		Direction:             v.Key("Direction").CoerceName("L2R"),
+		PrintScaling:          v.Key("PrintScaling").CoerceName("AppDefault"),
+		Duplex:                v.Key("Duplex").CoerceName(""),
+		PickTrayByPDFSize:     v.Key("PickTrayByPDFSize").data == true,
+		NumCopies:             int(v.Key("NumCopies").CoerceInt64(1)),
+		V:                     v,
+	}
+	rng := v.Key("PrintPageRange")
+	for i := 0; i+1 < rng.Len(); i += 2 {
+		p.PrintPageRange = append(p.PrintPageRange, [2]int{int(rng.Index(i).CoerceInt64(0)), int(rng.Index(i + 1).CoerceInt64(0))})
+	}
+	return p
+}
@@ -0,0 +1,38 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestViewerPreferences(t *testing.T) {
+	p := openPDF(t, buildPDF("", "<</Type /Catalog>>")).ViewerPreferences()
+	want := ViewerPreferences{
+		PageLayout:            "SinglePage",
+		PageMode:              "UseNone",
+		NonFullScreenPageMode: "UseNone",
+		Direction:             "L2R",
+		PrintScaling:          "AppDefault",
+		NumCopies:             1,
+		V:                     p.V,
+	}
+	if fmt.Sprintf("%+v", p) != fmt.Sprintf("%+v", want) {
+		t.Errorf("default preferences %+v, want %+v", p, want)
+	}
+
+	p = openPDF(t, buildPDF("", "<</Type /Catalog /PageLayout /TwoPageRight /PageMode /FullScreen"+
+		" /ViewerPreferences <</HideToolbar true /FitWindow true /DisplayDocTitle true /CenterWindow false"+
+		" /NonFullScreenPageMode /UseOutlines /Direction /R2L /PrintScaling /None /Duplex /DuplexFlipLongEdge"+
+		" /PickTrayByPDFSize true /PrintPageRange [1 3 7 7 9] /NumCopies 2>>>>")).ViewerPreferences()
+	if p.PageLayout != "TwoPageRight" || p.PageMode != "FullScreen" || !p.HideToolbar || p.HideMenubar ||
+		p.HideWindowUI || !p.FitWindow || p.CenterWindow || !p.DisplayDocTitle ||
+		p.NonFullScreenPageMode != "UseOutlines" || p.Direction != "R2L" || p.PrintScaling != "None" ||
+		p.Duplex != "DuplexFlipLongEdge" || !p.PickTrayByPDFSize || p.NumCopies != 2 ||
+		fmt.Sprint(p.PrintPageRange) != "[[1 3] [7 7]]" {
+		t.Errorf("preferences %+v", p)
+	}
+}
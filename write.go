@@ -0,0 +1,509 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Writing PDF files: object syntax (PDF 32000-1:2008, §7.3)
+// and file structure (§7.5).
+
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// A Writer writes a new PDF file.
+//
+// Pages are added with NewPage and drawn on through the returned
+// PageWriter; Close completes the file. For example:
+//
+//	w := pdf.NewWriter(f)
+//	p := w.NewPage(612, 792)
+//	p.SetFont("Helvetica", 24)
+//	p.ShowText(72, 700, "Hello, world")
+//	err := w.Close()
+//
+// Objects are written to the underlying writer as they are completed,
+// so a Writer holds little more than the pages' content in memory.
+type Writer struct {
+	// Info holds the entries of the document information dictionary
+	// written by Close. Its V field is ignored.
+	Info Info
+
+	// Compress selects whether streams are compressed with Flate.
+	// NewWriter sets it; clear it to write uncompressed streams.
+	Compress bool
+
+	// ObjectStreams selects whether objects other than streams are
+	// packed into object streams (§7.5.7), which are compressed with
+	// the others, and the cross-reference table written as a stream
+	// (§7.5.8). Readers of PDF versions before 1.5 cannot read such
+	// files.
+	ObjectStreams bool
+
+	w      *bufio.Writer
+	offset int64     // bytes written so far
+	sum    hash.Hash // digest of the bytes written, for the file identifier
+	err    error     // first write error
+
+	xref    []objLocation  // location of each object, by number
+	pending []packedObject // objects to be packed into the next object stream
+	pages   []*PageWriter
+	fonts   map[string]pdfobjptr // standard fonts, by name
+
+	closed bool
+	pretty bool        // lay out dictionaries one entry per line
+	crypt  *writeCrypt // encryption, if SetEncryption was called
+
+	compressed int // document streams compressed by the Writer
+	packed     int // objects packed into object streams
+}
+
+// An objLocation records where an object was written: at offset in
+// the file, with generation gen, or, if stm is not 0, as the object
+// numbered index in the object stream stm. Objects not yet written
+// have neither.
+type objLocation struct {
+	offset int64
+	gen    uint16
+	stm    uint32
+	index  int
+}
+
+// A packedObject is an object waiting to be packed into an object stream.
+type packedObject struct {
+	id   uint32
+	data []byte
+}
+
+// maxPackedObjects is the number of objects packed into each object
+// stream, a balance between compression and the cost to readers of
+// decompressing a stream to find one object.
+const maxPackedObjects = 100
+
+// NewWriter returns a Writer writing a PDF file to w.
+// It writes the file header immediately.
+func NewWriter(w io.Writer) *Writer {
+	wr := &Writer{
+		Compress: true,
+		w:        bufio.NewWriter(w),
+		sum:      md5.New(),
+		xref:     []objLocation{{}},
+		fonts:    make(map[string]pdfobjptr),
+	}
+	// The comment of bytes above 127 marks the file as binary.
+	wr.write([]byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n"))
+	return wr
+}
+
+// write writes b to the file.
+func (w *Writer) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.sum.Write(b[:n])
+	w.offset += int64(n)
+	w.err = err
+}
+
+// alloc allocates an object number.
+func (w *Writer) alloc() pdfobjptr {
+	w.xref = append(w.xref, objLocation{})
+	return pdfobjptr{uint32(len(w.xref) - 1), 0}
+}
+
+// writeObject writes obj as the indirect object ptr,
+// or queues it for packing if w.ObjectStreams is set.
+func (w *Writer) writeObject(ptr pdfobjptr, obj pdfobject) {
+	if w.ObjectStreams && ptr.gen == 0 {
+		w.pending = append(w.pending, packedObject{ptr.id, appendObject(nil, obj)})
+		if len(w.pending) >= maxPackedObjects {
+			w.flushObjectStream()
+		}
+		return
+	}
+	if w.crypt != nil {
+		obj = w.crypt.encryptObject(ptr, obj)
+	}
+	w.writeIndirect(ptr, obj)
+}
+
+// writeIndirect writes obj as the indirect object ptr, as it is.
+func (w *Writer) writeIndirect(ptr pdfobjptr, obj pdfobject) {
+	w.xref[ptr.id] = objLocation{offset: w.offset, gen: ptr.gen}
+	b := fmt.Appendf(nil, "%d %d obj\n", ptr.id, ptr.gen)
+	b = w.appendBody(b, obj)
+	b = append(b, "\nendobj\n"...)
+	w.write(b)
+}
+
+// writeStream writes a stream with the dictionary hdr and the
+// contents data as the indirect object ptr, compressing data with
+// Flate if w.Compress is set and hdr has no filter already and is not
+// for metadata, and encrypting it if w is encrypting, unless it is the
+// cross-reference stream. It sets hdr's Length and Filter entries.
+func (w *Writer) writeStream(ptr pdfobjptr, hdr pdfdict, data []byte) {
+	// Metadata is left uncompressed for tools that search files for it.
+	if w.Compress && hdr["Filter"] == nil && hdr["Type"] != pdfname("Metadata") {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+		if buf.Len() < len(data) {
+			data = buf.Bytes()
+			hdr["Filter"] = pdfname("FlateDecode")
+			w.compressed++
+		}
+	}
+	if w.crypt != nil && hdr["Type"] != pdfname("XRef") {
+		hdr = w.crypt.encryptObject(ptr, hdr).(pdfdict)
+		data = w.crypt.encryptData(ptr, data)
+	}
+	hdr["Length"] = int64(len(data))
+	w.xref[ptr.id] = objLocation{offset: w.offset, gen: ptr.gen}
+	b := fmt.Appendf(nil, "%d %d obj\n", ptr.id, ptr.gen)
+	b = w.appendBody(b, hdr)
+	b = append(b, "\nstream\n"...)
+	w.write(b)
+	w.write(data)
+	w.write([]byte("\nendstream\nendobj\n"))
+}
+
+// flushObjectStream writes the objects queued
+// for packing as a new object stream.
+func (w *Writer) flushObjectStream() {
+	if len(w.pending) == 0 {
+		return
+	}
+	stm := w.alloc()
+	var index, objs []byte
+	for i, p := range w.pending {
+		index = fmt.Appendf(index, "%d %d ", p.id, len(objs))
+		objs = append(objs, p.data...)
+		objs = append(objs, '\n')
+		w.xref[p.id] = objLocation{stm: stm.id, index: i}
+	}
+	w.packed += len(w.pending)
+	hdr := pdfdict{
+		"Type":  pdfname("ObjStm"),
+		"N":     int64(len(w.pending)),
+		"First": int64(len(index)),
+	}
+	w.pending = nil
+	n := w.compressed
+	w.writeStream(stm, hdr, append(index, objs...))
+	w.compressed = n // object streams are not the document's own
+}
+
+// Close writes the pages, the document catalog and information
+// dictionary, and the cross-reference table and trailer, completing
+// the file. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return fmt.Errorf("pdf: Writer already closed")
+	}
+	w.closed = true
+
+	pagesRef := w.alloc()
+	var kids pdfarray
+	for _, p := range w.pages {
+		p.writeTo(pagesRef)
+		kids = append(kids, p.ref)
+	}
+	w.writeObject(pagesRef, pdfdict{
+		"Type":  pdfname("Pages"),
+		"Kids":  kids,
+		"Count": int64(len(kids)),
+	})
+	root := w.alloc()
+	w.writeObject(root, pdfdict{
+		"Type":  pdfname("Catalog"),
+		"Pages": pagesRef,
+	})
+	trailer := pdfdict{"Root": root}
+	if info := infoDict(w.Info); len(info) > 0 {
+		ref := w.alloc()
+		w.writeObject(ref, info)
+		trailer["Info"] = ref
+	}
+	w.writeTrailer(trailer)
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// writeTrailer writes the cross-reference table for the objects
+// written and then the trailer, to which it adds the Size and,
+// if missing, ID entries. If w.ObjectStreams is set, it first packs
+// the objects still queued and writes the table and trailer
+// as a cross-reference stream.
+func (w *Writer) writeTrailer(trailer pdfdict) {
+	if w.crypt != nil {
+		// The encryption dictionary is itself not encrypted,
+		// and the file identifier is the one its keys derive from.
+		ref := w.alloc()
+		w.writeIndirect(ref, w.crypt.encrypt)
+		trailer["Encrypt"] = ref
+		trailer["ID"] = pdfarray{w.crypt.id, string(w.sum.Sum(nil))}
+	}
+	if w.ObjectStreams {
+		w.flushObjectStream()
+		w.writeXrefStream(trailer)
+		return
+	}
+	start := w.offset
+	b := fmt.Appendf(nil, "xref\n0 %d\n0000000000 65535 f\r\n", len(w.xref))
+	for _, loc := range w.xref[1:] {
+		if loc.offset == 0 {
+			b = append(b, "0000000000 65535 f\r\n"...)
+			continue
+		}
+		b = fmt.Appendf(b, "%010d %05d n\r\n", loc.offset, loc.gen)
+	}
+	w.write(b)
+
+	w.setTrailerEntries(trailer)
+	b = w.appendBody([]byte("trailer\n"), trailer)
+	b = fmt.Appendf(b, "\nstartxref\n%d\n%%%%EOF\n", start)
+	w.write(b)
+}
+
+// setTrailerEntries sets the Size and, if missing, ID entries of trailer.
+func (w *Writer) setTrailerEntries(trailer pdfdict) {
+	trailer["Size"] = int64(len(w.xref))
+	if trailer["ID"] == nil {
+		id := string(w.sum.Sum(nil))
+		trailer["ID"] = pdfarray{id, id}
+	}
+}
+
+// writeXrefStream writes the cross-reference stream, which is also
+// the trailer, with the entries of trailer.
+func (w *Writer) writeXrefStream(trailer pdfdict) {
+	ptr := w.alloc()
+	start := w.offset
+	w.xref[ptr.id] = objLocation{offset: start}
+
+	// Each entry is a type byte, then an offset or object stream number
+	// of width bytes, then a generation or index of 2 bytes.
+	width := 1
+	for _, loc := range w.xref {
+		for max(loc.offset, int64(loc.stm)) >= 1<<(8*width) {
+			width++
+		}
+	}
+	data := make([]byte, 0, len(w.xref)*(width+3))
+	for i, loc := range w.xref {
+		typ, field, gen := 1, uint64(loc.offset), int(loc.gen)
+		switch {
+		case loc.stm != 0:
+			typ, field, gen = 2, uint64(loc.stm), loc.index
+		case i == 0 || loc.offset == 0:
+			typ, field, gen = 0, 0, 0xffff
+		}
+		data = append(data, byte(typ))
+		for j := width - 1; j >= 0; j-- {
+			data = append(data, byte(field>>(8*j)))
+		}
+		data = append(data, byte(gen>>8), byte(gen))
+	}
+
+	w.setTrailerEntries(trailer)
+	trailer["Type"] = pdfname("XRef")
+	trailer["W"] = pdfarray{int64(1), int64(width), int64(2)}
+	w.writeStream(ptr, trailer, data)
+	w.write(fmt.Appendf(nil, "startxref\n%d\n%%%%EOF\n", start))
+}
+
+// appendBody appends the PDF syntax for obj, the body of an indirect
+// object or a stream's dictionary, to b, laid out by appendIndented if
+// w.pretty is set.
+func (w *Writer) appendBody(b []byte, obj pdfobject) []byte {
+	if w.pretty {
+		return appendIndented(b, obj, "")
+	}
+	return appendObject(b, obj)
+}
+
+// appendIndented appends the PDF syntax for obj to b as appendObject
+// does, but with each entry of a dictionary on a line of its own,
+// indented two spaces more than the line holding the dictionary, which
+// begins with indent. Arrays holding dictionaries are laid out in the
+// same way, with an element per line.
+func appendIndented(b []byte, obj pdfobject, indent string) []byte {
+	switch x := obj.(type) {
+	case pdfdict:
+		if len(x) == 0 {
+			return appendObject(b, x)
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, string(k))
+		}
+		sort.Strings(keys)
+		b = append(b, "<<\n"...)
+		for _, k := range keys {
+			b = append(b, indent+"  "...)
+			b = append(b, nameToken(k)...)
+			b = append(b, ' ')
+			b = appendIndented(b, x[pdfname(k)], indent+"  ")
+			b = append(b, '\n')
+		}
+		return append(append(b, indent...), ">>"...)
+	case pdfarray:
+		nested := false
+		for _, v := range x {
+			if _, ok := v.(pdfdict); ok {
+				nested = true
+			}
+		}
+		if !nested {
+			return appendObject(b, x)
+		}
+		b = append(b, "[\n"...)
+		for _, v := range x {
+			b = append(b, indent+"  "...)
+			b = appendIndented(b, v, indent+"  ")
+			b = append(b, '\n')
+		}
+		return append(append(b, indent...), ']')
+	}
+	return appendObject(b, obj)
+}
+
+// appendObject appends the PDF syntax for obj to b.
+func appendObject(b []byte, obj pdfobject) []byte {
+	switch x := obj.(type) {
+	case nil:
+		return append(b, "null"...)
+	case bool:
+		return strconv.AppendBool(b, x)
+	case int:
+		return strconv.AppendInt(b, int64(x), 10)
+	case int64:
+		return strconv.AppendInt(b, x, 10)
+	case float64:
+		return appendReal(b, x)
+	case string:
+		return append(b, literalString(x)...)
+	case pdfname:
+		return append(b, nameToken(string(x))...)
+	case pdfobjptr:
+		return fmt.Appendf(b, "%d %d R", x.id, x.gen)
+	case ObjRef:
+		return fmt.Appendf(b, "%d %d R", x.ID, x.Gen)
+	case pdfarray:
+		b = append(b, '[')
+		for i, v := range x {
+			if i > 0 {
+				b = append(b, ' ')
+			}
+			b = appendObject(b, v)
+		}
+		return append(b, ']')
+	case pdfdict:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, string(k))
+		}
+		sort.Strings(keys)
+		b = append(b, "<<"...)
+		for _, k := range keys {
+			b = append(b, nameToken(k)...)
+			b = append(b, ' ')
+			b = appendObject(b, x[pdfname(k)])
+		}
+		return append(b, ">>"...)
+	}
+	panic(fmt.Sprintf("pdf: cannot write %T as a direct object", obj))
+}
+
+// appendReal appends the PDF syntax for the real number x, which
+// has no exponent. Values that are not finite are written as 0.
+func appendReal(b []byte, x float64) []byte {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return append(b, '0')
+	}
+	s := strconv.FormatFloat(x, 'f', -1, 64)
+	if strings.Contains(s, ".") && len(s) > 12 {
+		// Trim excess precision: five decimal places are
+		// more than any PDF consumer resolves.
+		s = strings.TrimRight(strconv.FormatFloat(x, 'f', 5, 64), "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return append(b, s...)
+}
+
+// encodeTextString encodes s as a PDF text string: unchanged if it is
+// printable ASCII, and otherwise as UTF-16BE with a byte order mark.
+func encodeTextString(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 && s[i] != '\t' && s[i] != '\n' && s[i] != '\r' || s[i] >= 0x7f {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+	var b strings.Builder
+	b.WriteString("\xfe\xff")
+	for _, u := range utf16.Encode([]rune(s)) {
+		b.WriteByte(byte(u >> 8))
+		b.WriteByte(byte(u))
+	}
+	return b.String()
+}
+
+// literalString returns the PDF literal string syntax for the bytes s.
+func literalString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// nameToken returns the PDF name syntax for the name n, escaping
+// delimiters, white space and bytes outside printable ASCII as #xx.
+func nameToken(n string) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	for i := 0; i < len(n); i++ {
+		c := n[i]
+		if c <= ' ' || c >= 0x7f || c == '#' || isDelim(c) {
+			fmt.Fprintf(&b, "#%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
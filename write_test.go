@@ -0,0 +1,120 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	for _, objStms := range []bool{false, true} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.ObjectStreams = objStms
+		w.Info = Info{Title: "Writer test", Author: "Zoë", CreationDate: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)}
+
+		p := w.NewPage(612, 792)
+		if _, err := p.SetFont("Times-Bold", 14); err != nil {
+			t.Fatal(err)
+		}
+		p.ShowText(72, 700, "Café – déjà vu")
+		if _, err := p.SetFont("Comic Sans", 14); err == nil {
+			t.Errorf("SetFont accepted a font that is not standard")
+		}
+
+		rgba := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+		rgba.Set(0, 0, color.NRGBA{255, 0, 0, 255})
+		rgba.Set(1, 0, color.NRGBA{0, 0, 255, 0})
+		img, err := w.AddImage(rgba)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gray := image.NewGray(image.Rect(0, 0, 1, 2))
+		gray.Pix[1] = 0x80
+		g, err := w.AddImage(gray)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jpg, err := w.AddJPEG([]byte(jpegData(8, 8, 0x40)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.AddImage(image.NewGray(image.Rect(0, 0, 0, 0))); err == nil {
+			t.Errorf("AddImage accepted an empty image")
+		}
+		if _, err := w.AddJPEG([]byte("not a JPEG")); err == nil {
+			t.Errorf("AddJPEG accepted data that is not JPEG")
+		}
+
+		q := w.NewPage(200, 100)
+		if name := q.DrawImage(img, 0, 0, 20, 10); name != "Im1" {
+			t.Errorf("first image drawn as %s, want Im1", name)
+		}
+		q.DrawImage(g, 20, 0, 10, 20)
+		q.DrawImage(jpg, 40, 0, 8, 8)
+		if name := q.DrawImage(img, 50, 50, 20, 10); name != "Im1" {
+			t.Errorf("image drawn again as %s, want Im1", name)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err == nil {
+			t.Errorf("second Close succeeded")
+		}
+
+		r := openPDF(t, buf.Bytes())
+		if n := r.NumPage(); n != 2 {
+			t.Fatalf("object streams %v: %d pages, want 2", objStms, n)
+		}
+		info := r.Info()
+		if info.Title != "Writer test" || info.Author != "Zoë" || !info.CreationDate.Equal(w.Info.CreationDate) {
+			t.Errorf("object streams %v: Info %+v", objStms, info)
+		}
+		if s, err := r.Page(1).GetPlainText(); err != nil || s != "Café – déjà vu" {
+			t.Errorf("object streams %v: page 1 text %q, %v", objStms, s, err)
+		}
+		if box := r.Page(2).MediaBox(); box.Index(2).CoerceFloat64(0) != 200 || box.Index(3).CoerceFloat64(0) != 100 {
+			t.Errorf("object streams %v: page 2 MediaBox %v, want [0 0 200 100]", objStms, box)
+		}
+
+		imgs := r.Page(2).Images()
+		if len(imgs) != 3 {
+			t.Fatalf("object streams %v: %d images on page 2, want 3", objStms, len(imgs))
+		}
+		for _, im := range imgs {
+			if im.Err != nil {
+				t.Errorf("object streams %v: image %s: %v", objStms, im.Name, im.Err)
+			}
+		}
+		if m, err := imgs[0].Info.DecodeMasked(); err != nil {
+			t.Errorf("object streams %v: Im1: %v", objStms, err)
+		} else if r0, _, _, a0 := m.At(0, 0).RGBA(); r0 != 0xffff || a0 != 0xffff {
+			t.Errorf("object streams %v: Im1 pixel 0 is %v, want opaque red", objStms, m.At(0, 0))
+		} else if _, _, _, a1 := m.At(1, 0).RGBA(); a1 != 0 {
+			t.Errorf("object streams %v: Im1 pixel 1 is %v, want transparent", objStms, m.At(1, 0))
+		}
+		if m := imgs[1].Image; m == nil || m.Bounds().Dx() != 1 || m.Bounds().Dy() != 2 {
+			t.Errorf("object streams %v: Im2 is %v, want 1×2", objStms, m)
+		} else if y := color.GrayModel.Convert(m.At(0, 1)).(color.Gray).Y; y != 0x80 {
+			t.Errorf("object streams %v: Im2 pixel (0, 1) is %#x, want 0x80", objStms, y)
+		}
+		if m := imgs[2].Image; m == nil || m.Bounds().Dx() != 8 {
+			t.Errorf("object streams %v: Im3 is %v, want 8×8", objStms, m)
+		}
+		if f := imgs[2].Info.Filters; len(f) != 1 || f[0] != "DCTDecode" {
+			t.Errorf("object streams %v: Im3 filters %q, want [DCTDecode]", objStms, f)
+		}
+	}
+}
+
+func TestWinAnsiEncode(t *testing.T) {
+	if got, want := winAnsiEncode("a€é✓"), "a\x80\xe9?"; got != want {
+		t.Errorf("winAnsiEncode = %q, want %q", got, want)
+	}
+}
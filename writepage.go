@@ -0,0 +1,283 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Writing pages: content streams, the standard 14 fonts
+// and images.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"sync"
+)
+
+// A PageWriter adds content to a page of a Writer.
+// Its content is written when the Writer is closed.
+//
+// Besides the drawing methods, a PageWriter is an io.Writer
+// to which content stream operators can be written directly,
+// with resources named as returned by SetFont and DrawImage.
+type PageWriter struct {
+	w       *Writer
+	ref     pdfobjptr
+	box     Rectangle
+	content bytes.Buffer
+	fonts   pdfdict // font resources, by resource name
+	xobjs   pdfdict // XObject resources, by resource name
+	font    string  // resource name of the current font
+	base    string  // base font name of the current font
+	size    float64 // current font size
+}
+
+// NewPage adds a page of the given width and height, in points
+// (1/72 inch), after the pages already added.
+func (w *Writer) NewPage(width, height float64) *PageWriter {
+	p := &PageWriter{
+		w:     w,
+		ref:   w.alloc(),
+		box:   Rectangle{Max: Point{width, height}},
+		fonts: pdfdict{},
+		xobjs: pdfdict{},
+	}
+	w.pages = append(w.pages, p)
+	return p
+}
+
+// Write appends b, which should hold content stream operators,
+// to the page's content.
+func (p *PageWriter) Write(b []byte) (int, error) {
+	return p.content.Write(b)
+}
+
+// writeTo writes the page's content stream and page dictionary,
+// whose parent is the page tree node parent.
+func (p *PageWriter) writeTo(parent pdfobjptr) {
+	contents := p.w.alloc()
+	p.w.writeStream(contents, pdfdict{}, p.content.Bytes())
+	res := pdfdict{"ProcSet": pdfarray{pdfname("PDF"), pdfname("Text"), pdfname("ImageB"), pdfname("ImageC")}}
+	if len(p.fonts) > 0 {
+		res["Font"] = p.fonts
+	}
+	if len(p.xobjs) > 0 {
+		res["XObject"] = p.xobjs
+	}
+	p.w.writeObject(p.ref, pdfdict{
+		"Type":      pdfname("Page"),
+		"Parent":    parent,
+		"MediaBox":  pdfarray{p.box.Min.X, p.box.Min.Y, p.box.Max.X, p.box.Max.Y},
+		"Resources": res,
+		"Contents":  contents,
+	})
+}
+
+// standardFonts are the names of the standard 14 fonts,
+// which viewers provide and documents need not embed.
+var standardFonts = map[string]bool{
+	"Times-Roman": true, "Times-Bold": true, "Times-Italic": true, "Times-BoldItalic": true,
+	"Helvetica": true, "Helvetica-Bold": true, "Helvetica-Oblique": true, "Helvetica-BoldOblique": true,
+	"Courier": true, "Courier-Bold": true, "Courier-Oblique": true, "Courier-BoldOblique": true,
+	"Symbol": true, "ZapfDingbats": true,
+}
+
+// SetFont sets the font and size, in points, used by ShowText.
+// The font must be one of the standard 14 fonts, such as Helvetica,
+// Times-Bold or Courier-Oblique. SetFont returns the font's resource
+// name on the page, for use in content written directly.
+func (p *PageWriter) SetFont(name string, size float64) (string, error) {
+	if !standardFonts[name] {
+		return "", fmt.Errorf("pdf: %q is not a standard font", name)
+	}
+	ref, ok := p.w.fonts[name]
+	if !ok {
+		font := pdfdict{
+			"Type":     pdfname("Font"),
+			"Subtype":  pdfname("Type1"),
+			"BaseFont": pdfname(name),
+		}
+		if name != "Symbol" && name != "ZapfDingbats" {
+			font["Encoding"] = pdfname("WinAnsiEncoding")
+		}
+		ref = p.w.alloc()
+		p.w.writeObject(ref, font)
+		p.w.fonts[name] = ref
+	}
+	res := p.resourceName(p.fonts, "F", ref)
+	p.font, p.base, p.size = res, name, size
+	return res, nil
+}
+
+// resourceName returns the name of the resource ref in the resource
+// dictionary res, adding it with a name beginning with prefix if needed.
+func (p *PageWriter) resourceName(res pdfdict, prefix string, ref pdfobjptr) string {
+	for k, v := range res {
+		if v == ref {
+			return string(k)
+		}
+	}
+	name := fmt.Sprintf("%s%d", prefix, len(res)+1)
+	res[pdfname(name)] = ref
+	return name
+}
+
+// ShowText draws the text s with its baseline starting at (x, y),
+// in the font set by SetFont, or 12-point Helvetica if none was set.
+// Characters the font's encoding lacks are drawn as question marks.
+// For the Symbol and ZapfDingbats fonts, the bytes of s are the
+// codes of the characters to draw.
+func (p *PageWriter) ShowText(x, y float64, s string) {
+	if p.font == "" {
+		p.SetFont("Helvetica", 12)
+	}
+	if p.base != "Symbol" && p.base != "ZapfDingbats" {
+		s = winAnsiEncode(s)
+	}
+	b := []byte("BT ")
+	b = appendObject(b, pdfname(p.font))
+	b = append(b, ' ')
+	b = appendReal(b, p.size)
+	b = append(b, " Tf "...)
+	b = appendReal(b, x)
+	b = append(b, ' ')
+	b = appendReal(b, y)
+	b = append(b, " Td "...)
+	b = appendObject(b, s)
+	b = append(b, " Tj ET\n"...)
+	p.content.Write(b)
+}
+
+var (
+	winAnsiOnce  sync.Once
+	winAnsiCodes map[rune]byte
+)
+
+// winAnsiEncode returns s encoded in WinAnsiEncoding,
+// with characters it lacks replaced by question marks.
+func winAnsiEncode(s string) string {
+	winAnsiOnce.Do(func() {
+		winAnsiCodes = make(map[rune]byte)
+		for i, r := range winAnsiEncoding {
+			if r != noRune {
+				winAnsiCodes[r] = byte(i)
+			}
+		}
+	})
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		c, ok := winAnsiCodes[r]
+		if !ok {
+			c = '?'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+// DrawImage draws the image img, added by AddImage or AddJPEG,
+// in the rectangle with lower left corner (x, y) and the given width
+// and height. It returns the image's resource name on the page.
+func (p *PageWriter) DrawImage(img ObjRef, x, y, width, height float64) string {
+	name := p.resourceName(p.xobjs, "Im", pdfobjptr{img.ID, img.Gen})
+	b := []byte("q ")
+	for _, v := range []float64{width, 0, 0, height, x, y} {
+		b = appendReal(b, v)
+		b = append(b, ' ')
+	}
+	b = append(b, "cm "...)
+	b = appendObject(b, pdfname(name))
+	b = append(b, " Do Q\n"...)
+	p.content.Write(b)
+	return name
+}
+
+// AddImage writes img as an image XObject that pages can draw with
+// DrawImage. Gray images are written in DeviceGray and others in
+// DeviceRGB, with 8 bits per component; an image that is not opaque
+// gets a soft mask holding its alpha channel.
+func (w *Writer) AddImage(img image.Image) (ObjRef, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 {
+		return ObjRef{}, fmt.Errorf("pdf: empty image")
+	}
+	hdr := pdfdict{
+		"Type":             pdfname("XObject"),
+		"Subtype":          pdfname("Image"),
+		"Width":            int64(width),
+		"Height":           int64(height),
+		"BitsPerComponent": int64(8),
+	}
+	var data, alpha []byte
+	opaque := true
+	if g, ok := img.(*image.Gray); ok {
+		hdr["ColorSpace"] = pdfname("DeviceGray")
+		data = make([]byte, 0, width*height)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			i := g.PixOffset(bounds.Min.X, y)
+			data = append(data, g.Pix[i:i+width]...)
+		}
+	} else {
+		hdr["ColorSpace"] = pdfname("DeviceRGB")
+		data = make([]byte, 0, 3*width*height)
+		alpha = make([]byte, 0, width*height)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				data = append(data, c.R, c.G, c.B)
+				alpha = append(alpha, c.A)
+				opaque = opaque && c.A == 0xff
+			}
+		}
+	}
+	if !opaque {
+		smask := w.alloc()
+		w.writeStream(smask, pdfdict{
+			"Type":             pdfname("XObject"),
+			"Subtype":          pdfname("Image"),
+			"Width":            int64(width),
+			"Height":           int64(height),
+			"BitsPerComponent": int64(8),
+			"ColorSpace":       pdfname("DeviceGray"),
+		}, alpha)
+		hdr["SMask"] = smask
+	}
+	ref := w.alloc()
+	w.writeStream(ref, hdr, data)
+	return ObjRef{ref.id, ref.gen}, w.err
+}
+
+// AddJPEG writes the JPEG image data as an image XObject that pages
+// can draw with DrawImage. The data is stored as is, with the
+// DCTDecode filter, so the image is not recompressed.
+func (w *Writer) AddJPEG(data []byte) (ObjRef, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ObjRef{}, err
+	}
+	hdr := pdfdict{
+		"Type":             pdfname("XObject"),
+		"Subtype":          pdfname("Image"),
+		"Width":            int64(cfg.Width),
+		"Height":           int64(cfg.Height),
+		"BitsPerComponent": int64(8),
+		"Filter":           pdfname("DCTDecode"),
+	}
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		hdr["ColorSpace"] = pdfname("DeviceGray")
+	case color.CMYKModel:
+		// CMYK JPEG files, as written by Adobe applications,
+		// store the components inverted.
+		hdr["ColorSpace"] = pdfname("DeviceCMYK")
+		hdr["Decode"] = pdfarray{int64(1), int64(0), int64(1), int64(0), int64(1), int64(0), int64(1), int64(0)}
+	default:
+		hdr["ColorSpace"] = pdfname("DeviceRGB")
+	}
+	ref := w.alloc()
+	w.writeStream(ref, hdr, data)
+	return ObjRef{ref.id, ref.gen}, w.err
+}
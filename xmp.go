@@ -0,0 +1,123 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// XMP metadata streams (PDF 32000-1:2008, §14.3.2).
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// Metadata returns the document's XMP metadata packet,
+// the catalog's Metadata stream, or nil if it has none.
+func (r *Reader) Metadata() []byte {
+	return streamBytes(r.Trailer.Key("Root").Key("Metadata"))
+}
+
+// SetMetadata sets the document's XMP metadata to packet,
+// such as one returned by XMPPacket, or removes it if packet is nil.
+// The change is written by Save or SaveIncremental.
+func (r *Reader) SetMetadata(packet []byte) error {
+	root := r.Trailer.Key("Root")
+	if packet == nil {
+		return root.SetKey("Metadata", Value{})
+	}
+	hdr := r.NewDict()
+	hdr.SetKey("Type", NewName("Metadata"))
+	hdr.SetKey("Subtype", NewName("XML"))
+	return root.SetKey("Metadata", r.NewStream(hdr, packet))
+}
+
+// xmpPadding is the white space left at the end of an XMP packet
+// for tools that change the packet in place.
+const xmpPadding = 2000
+
+// XMPPacket returns an XMP metadata packet holding the properties
+// of info, as the Dublin Core, XMP basic and Adobe PDF schemas record
+// them, and those of info.V's other text entries, which are recorded
+// in the PDF extension schema as Acrobat does for custom properties.
+// Entries whose names are not valid XML names are left out.
+func XMPPacket(info Info) []byte {
+	var b bytes.Buffer
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:dc="http://purl.org/dc/elements/1.1/"
+    xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+    xmlns:pdf="http://ns.adobe.com/pdf/1.3/"
+    xmlns:pdfx="http://ns.adobe.com/pdfx/1.3/">
+   <dc:format>application/pdf</dc:format>
+`)
+	lang := func(prop, val string) {
+		if val != "" {
+			b.WriteString("   <" + prop + "><rdf:Alt><rdf:li xml:lang=\"x-default\">" + xmlText(val) + "</rdf:li></rdf:Alt></" + prop + ">\n")
+		}
+	}
+	simple := func(prop, val string) {
+		if val != "" {
+			b.WriteString("   <" + prop + ">" + xmlText(val) + "</" + prop + ">\n")
+		}
+	}
+	date := func(prop string, t time.Time) {
+		if !t.IsZero() {
+			simple(prop, t.Format(time.RFC3339))
+		}
+	}
+	lang("dc:title", info.Title)
+	if info.Author != "" {
+		b.WriteString("   <dc:creator><rdf:Seq><rdf:li>" + xmlText(info.Author) + "</rdf:li></rdf:Seq></dc:creator>\n")
+	}
+	lang("dc:description", info.Subject)
+	simple("pdf:Keywords", info.Keywords)
+	simple("pdf:Producer", info.Producer)
+	simple("pdf:Trapped", info.Trapped)
+	simple("xmp:CreatorTool", info.Creator)
+	date("xmp:CreateDate", info.CreationDate)
+	date("xmp:ModifyDate", info.ModDate)
+
+	standard := make(map[string]bool)
+	for _, key := range infoKeys {
+		standard[key] = true
+	}
+	for _, key := range info.V.Keys() {
+		v := info.V.Key(key)
+		if !standard[key] && v.Kind() == String && isXMLName(key) {
+			simple("pdfx:"+key, v.CoerceText(""))
+		}
+	}
+
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	for i := 0; i < xmpPadding/100; i++ {
+		b.WriteString(strings.Repeat(" ", 99) + "\n")
+	}
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return b.Bytes()
+}
+
+// xmlText returns s escaped for use as XML character data.
+func xmlText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// isXMLName reports whether s is an XML name without a colon,
+// limited to ASCII, as a property name in a schema must be.
+func isXMLName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', c == '_':
+		case i > 0 && ('0' <= c && c <= '9' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
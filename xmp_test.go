@@ -0,0 +1,95 @@
+// Copyright 2014 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// xmpProperties returns the text of each property in the XMP packet,
+// by prefixed name, failing the test if the packet is not well formed.
+func xmpProperties(t *testing.T, packet []byte) map[string]string {
+	t.Helper()
+	prefix := map[string]string{
+		"http://purl.org/dc/elements/1.1/": "dc",
+		"http://ns.adobe.com/xap/1.0/":     "xmp",
+		"http://ns.adobe.com/pdf/1.3/":     "pdf",
+		"http://ns.adobe.com/pdfx/1.3/":    "pdfx",
+	}
+	props := make(map[string]string)
+	d := xml.NewDecoder(bytes.NewReader(packet))
+	var prop string
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("malformed XMP packet: %v", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if p, ok := prefix[tok.Name.Space]; ok {
+				prop = p + ":" + tok.Name.Local
+			}
+		case xml.CharData:
+			if s := strings.TrimSpace(string(tok)); s != "" && prop != "" {
+				props[prop] += s
+			}
+		}
+	}
+	return props
+}
+
+func TestXMPPacket(t *testing.T) {
+	r := openPDF(t, buildPDF("/Info 3 0 R",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [] /Count 0>>",
+		"<</Title (Fish & <Chips>) /Department (R&D) /Bad:Name (x) /Count 3>>",
+	))
+	info := r.Info()
+	info.Author = "Zoë"
+	info.CreationDate = time.Date(2024, 1, 31, 12, 0, 0, 0, time.FixedZone("", 3600))
+	packet := XMPPacket(info)
+	want := map[string]string{
+		"dc:format":       "application/pdf",
+		"dc:title":        "Fish & <Chips>",
+		"dc:creator":      "Zoë",
+		"xmp:CreateDate":  "2024-01-31T12:00:00+01:00",
+		"pdfx:Department": "R&D",
+	}
+	got := xmpProperties(t, packet)
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("properties %q, want %q", got, want)
+	}
+	if !bytes.HasSuffix(packet, []byte("<?xpacket end=\"w\"?>")) {
+		t.Errorf("packet does not end with a writable trailer")
+	}
+
+	if err := r.SetMetadata(packet); err != nil {
+		t.Fatal(err)
+	}
+	r = save(t, r)
+	if m := r.Metadata(); !bytes.Equal(m, packet) {
+		t.Errorf("Metadata after Save is %d bytes, want the %d set", len(m), len(packet))
+	}
+	if typ := r.Trailer.Key("Root").Key("Metadata").Key("Subtype").CoerceName(""); typ != "XML" {
+		t.Errorf("Metadata Subtype %q, want XML", typ)
+	}
+	r.SetMetadata(nil)
+	if m := save(t, r).Metadata(); m != nil {
+		t.Errorf("Metadata after removal is %d bytes", len(m))
+	}
+}